@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/config"
+	"github.com/agentlog/agentlog/internal/diag"
+	"github.com/agentlog/agentlog/internal/logfile"
+	"github.com/agentlog/agentlog/internal/self"
+)
+
+// alertsFileName records every alert fired, for doctor to report
+const alertsFileName = "alerts.jsonl"
+
+// alertTimeout bounds a webhook post or alert command
+const alertTimeout = 10 * time.Second
+
+// envAlertRule names the rule an alert command was run for
+const envAlertRule = "AGENTLOG_ALERT"
+
+// Alert is a rule's threshold being crossed. It's what webhooks are posted
+// and alert commands read on stdin.
+type Alert struct {
+	Rule      string     `json:"rule"`
+	Text      string     `json:"text"` // one-line summary; chat webhooks show it
+	Query     string     `json:"query,omitempty"`
+	Threshold int        `json:"threshold"`
+	Window    string     `json:"window"`
+	Count     int        `json:"count"` // entries matching within the window
+	FiredAt   string     `json:"fired_at"`
+	Project   string     `json:"project"`
+	Latest    ErrorEntry `json:"latest"` // the entry that crossed the threshold
+}
+
+// alertsPath is where fired alerts are recorded
+func alertsPath(baseDir string) string {
+	return filepath.Join(baseDir, ".agentlog", alertsFileName)
+}
+
+// alertRule is a configured rule ready to check, with the matching entries
+// seen within its window
+type alertRule struct {
+	config.AlertRule
+	query  queryExpr
+	window time.Duration
+	seen   []alertHit
+	fired  time.Time
+}
+
+type alertHit struct {
+	at time.Time
+	n  int
+}
+
+// compileAlertRule checks a configured rule and parses its query and window
+func compileAlertRule(r config.AlertRule) (*alertRule, error) {
+	rule := &alertRule{AlertRule: r}
+	if r.Name == "" {
+		return nil, fmt.Errorf("needs a name")
+	}
+	d, ok := parseSpan(r.Window)
+	if !ok || d <= 0 {
+		return nil, fmt.Errorf("invalid window %q (e.g. 10m, 1h)", r.Window)
+	}
+	rule.window = d
+	if r.Threshold < 0 {
+		return nil, fmt.Errorf("threshold can't be negative")
+	}
+	if r.Query != "" {
+		q, err := parseQuery(r.Query)
+		if err != nil {
+			return nil, fmt.Errorf("%s", strings.Replace(err.Error(), "invalid --query", "invalid query", 1))
+		}
+		rule.query = q
+	}
+	if r.Webhook != "" && !strings.HasPrefix(r.Webhook, "http://") && !strings.HasPrefix(r.Webhook, "https://") {
+		return nil, fmt.Errorf("webhook %q isn't an http(s) URL", r.Webhook)
+	}
+	return rule, nil
+}
+
+// loadAlertRules compiles the project's alert rules, returning the valid
+// ones and a problem for each invalid one
+func loadAlertRules(baseDir string) ([]*alertRule, []string) {
+	cfg, err := config.Load(baseDir)
+	if err != nil {
+		return nil, []string{err.Error()}
+	}
+	var rules []*alertRule
+	var problems []string
+	for i, r := range cfg.Alerts {
+		rule, err := compileAlertRule(r)
+		if err != nil {
+			label := fmt.Sprintf("alert %d", i+1)
+			if r.Name != "" {
+				label = fmt.Sprintf("alert %q", r.Name)
+			}
+			problems = append(problems, fmt.Sprintf("%s: %v", label, err))
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules, problems
+}
+
+// alertEngine checks entries against the alert rules as they arrive and
+// delivers the alerts they fire. It's safe for concurrent use.
+type alertEngine struct {
+	baseDir string
+	project string
+	rules   []*alertRule
+	now     func() time.Time
+	client  *http.Client
+
+	mu      sync.Mutex
+	pending sync.WaitGroup
+}
+
+// newAlertEngine loads baseDir's alert rules, warning about invalid ones.
+// It returns nil when there are no valid rules.
+func newAlertEngine(baseDir string) *alertEngine {
+	rules, problems := loadAlertRules(baseDir)
+	for _, p := range problems {
+		diag.Warnf("ignoring %s", p)
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	diag.Debugf("checking %d alert rules", len(rules))
+	seedFired(baseDir, rules)
+	return &alertEngine{
+		baseDir: baseDir,
+		project: projectName(baseDir),
+		rules:   rules,
+		now:     time.Now,
+		client:  &http.Client{Timeout: alertTimeout},
+	}
+}
+
+// seedFired sets when each rule last fired from the alerts recorded in
+// baseDir, so a restarted tail or serve waits out the window of an alert
+// an earlier run fired rather than firing it again for the same entries
+func seedFired(baseDir string, rules []*alertRule) {
+	alerts, err := readAlerts(baseDir)
+	if err != nil {
+		return
+	}
+	last := make(map[string]time.Time)
+	for _, al := range alerts {
+		if at, err := parseEntryTime(al.FiredAt); err == nil && at.After(last[al.Rule]) {
+			last[al.Rule] = at
+		}
+	}
+	for _, r := range rules {
+		r.fired = last[r.Name]
+	}
+}
+
+// observe counts e toward each rule it matches, firing those it takes
+// over their threshold. Entries are placed by their timestamps, so those
+// tail replays on start count only if they're within a window of now, and
+// a rule that fired within its window before a restart doesn't fire again.
+func (a *alertEngine) observe(e ErrorEntry) {
+	if a == nil {
+		return
+	}
+	now := a.now()
+	at, err := parseEntryTime(e.Timestamp)
+	if err != nil || at.After(now) {
+		at = now
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, r := range a.rules {
+		if at.Before(now.Add(-r.window)) || (r.query != nil && !r.query.match(e)) {
+			continue
+		}
+		r.seen = append(r.seen, alertHit{at: at, n: e.occurrences()})
+
+		// Keep only what's still within the window
+		count, kept := 0, r.seen[:0]
+		for _, h := range r.seen {
+			if !h.at.Before(now.Add(-r.window)) {
+				kept = append(kept, h)
+				count += h.n
+			}
+		}
+		r.seen = kept
+
+		if count > r.Threshold && (r.fired.IsZero() || now.Sub(r.fired) >= r.window) {
+			r.fired = now
+			a.fire(r, count, e, now)
+		}
+	}
+}
+
+// fire reports an alert on stderr and records it, then hands it to the
+// rule's webhook and command in the background
+func (a *alertEngine) fire(r *alertRule, count int, latest ErrorEntry, now time.Time) {
+	if latest.ID == "" {
+		latest.ID = entryID(latest)
+	}
+	what := "entries"
+	if r.Query != "" {
+		what = "entries matching " + r.Query
+	}
+	alert := Alert{
+		Rule:      r.Name,
+		Text:      fmt.Sprintf("agentlog alert %s: %d %s in %s (threshold %d) in %s", r.Name, count, what, r.Window, r.Threshold, a.project),
+		Query:     r.Query,
+		Threshold: r.Threshold,
+		Window:    r.Window,
+		Count:     count,
+		FiredAt:   now.UTC().Format(time.RFC3339),
+		Project:   a.project,
+		Latest:    latest,
+	}
+	diag.Warnf("%s", strings.TrimPrefix(alert.Text, "agentlog "))
+
+	data, _ := json.Marshal(alert)
+	if err := logfile.Append(alertsPath(a.baseDir), append(data, '\n')); err != nil {
+		self.LogError(a.baseDir, "ALERT_ERROR", err.Error())
+	}
+
+	if r.Webhook == "" && r.Command == "" {
+		return
+	}
+	a.pending.Add(1)
+	go func() {
+		defer a.pending.Done()
+		if r.Webhook != "" {
+			a.deliver(r, "webhook", a.post(r.Webhook, data))
+		}
+		if r.Command != "" {
+			a.deliver(r, "command", a.run(r, data))
+		}
+	}()
+}
+
+func (a *alertEngine) deliver(r *alertRule, how string, err error) {
+	if err == nil {
+		return
+	}
+	diag.Warnf("alert %s: %s failed: %v", r.Name, how, err)
+	self.LogError(a.baseDir, "ALERT_ERROR", fmt.Sprintf("alert %s %s: %v", r.Name, how, err))
+}
+
+// post sends the alert to a webhook
+func (a *alertEngine) post(url string, data []byte) error {
+	resp, err := a.client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s answered %s", url, resp.Status)
+	}
+	return nil
+}
+
+// run gives the alert to the rule's command on stdin. Its output goes to
+// stderr, leaving stdout to tail's entries.
+func (a *alertEngine) run(r *alertRule, data []byte) error {
+	c := shellCommand(a.baseDir, r.Command, envAlertRule+"="+r.Name)
+	c.Stdin = bytes.NewReader(data)
+	c.Stdout, c.Stderr = os.Stderr, os.Stderr
+	if err := c.Start(); err != nil {
+		return err
+	}
+	timer := time.AfterFunc(alertTimeout, func() { c.Process.Kill() })
+	defer timer.Stop()
+	return c.Wait()
+}
+
+// wait blocks until alerts being delivered are done
+func (a *alertEngine) wait() {
+	if a != nil {
+		a.pending.Wait()
+	}
+}
+
+// readAlerts reads the alerts recorded in baseDir, oldest first
+func readAlerts(baseDir string) ([]Alert, error) {
+	data, err := os.ReadFile(alertsPath(baseDir))
+	if err != nil {
+		return nil, err
+	}
+	var alerts []Alert
+	for _, line := range strings.Split(string(data), "\n") {
+		var a Alert
+		if json.Unmarshal([]byte(line), &a) == nil && a.Rule != "" {
+			alerts = append(alerts, a)
+		}
+	}
+	return alerts, nil
+}
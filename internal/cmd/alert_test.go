@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/config"
+)
+
+// writeAlertRules configures the alert rules of a new project, returning
+// its directory
+func writeAlertRules(t *testing.T, rules ...config.AlertRule) string {
+	t.Helper()
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, ".agentlog"), 0755)
+	data, _ := json.Marshal(map[string][]config.AlertRule{"alerts": rules})
+	if err := os.WriteFile(filepath.Join(dir, ".agentlog", "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestCompileAlertRule(t *testing.T) {
+	tests := []struct {
+		rule    config.AlertRule
+		wantErr string
+	}{
+		{config.AlertRule{Name: "storm", Query: "type=NETWORK_ERROR", Threshold: 20, Window: "10m"}, ""},
+		{config.AlertRule{Name: "daily", Window: "1d"}, ""},
+		{config.AlertRule{Window: "10m"}, "needs a name"},
+		{config.AlertRule{Name: "x"}, "invalid window"},
+		{config.AlertRule{Name: "x", Window: "soon"}, "invalid window"},
+		{config.AlertRule{Name: "x", Window: "10m", Threshold: -1}, "negative"},
+		{config.AlertRule{Name: "x", Window: "10m", Query: "type="}, "invalid query at column"},
+		{config.AlertRule{Name: "x", Window: "10m", Webhook: "hooks.example.com"}, "isn't an http(s) URL"},
+	}
+	for _, tt := range tests {
+		_, err := compileAlertRule(tt.rule)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("compileAlertRule(%+v) error = %v", tt.rule, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("compileAlertRule(%+v) error = %v, want %q", tt.rule, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadAlertRules_NamesInvalidRules(t *testing.T) {
+	dir := writeAlertRules(t,
+		config.AlertRule{Name: "ok", Window: "5m"},
+		config.AlertRule{Name: "bad", Window: "later"},
+		config.AlertRule{Window: "5m"},
+	)
+	rules, problems := loadAlertRules(dir)
+	if len(rules) != 1 || rules[0].Name != "ok" {
+		t.Errorf("rules = %+v, want just ok", rules)
+	}
+	if len(problems) != 2 || !strings.HasPrefix(problems[0], `alert "bad": `) || !strings.HasPrefix(problems[1], "alert 3: ") {
+		t.Errorf("problems = %q", problems)
+	}
+}
+
+func TestNewAlertEngine_NoRules(t *testing.T) {
+	if a := newAlertEngine(t.TempDir()); a != nil {
+		t.Errorf("newAlertEngine() = %+v, want nil without rules", a)
+	}
+	// A nil engine ignores entries
+	var a *alertEngine
+	a.observe(ErrorEntry{ErrorType: "X"})
+	a.wait()
+}
+
+func TestAlertEngine_FiresOverThreshold(t *testing.T) {
+	var posted []Alert
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a Alert
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &a)
+		posted = append(posted, a)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	dir := writeAlertRules(t, config.AlertRule{Name: "network-storm", Query: "type=NETWORK_ERROR", Threshold: 2, Window: "10m", Webhook: srv.URL})
+	a := newAlertEngine(dir)
+	now := time.Date(2025, 12, 10, 12, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+	at := func(ago time.Duration, errType string) ErrorEntry {
+		return ErrorEntry{Timestamp: now.Add(-ago).Format(time.RFC3339), Source: "frontend", ErrorType: errType, Message: "fetch failed"}
+	}
+
+	a.observe(at(20*time.Minute, "NETWORK_ERROR")) // outside the window
+	a.observe(at(5*time.Minute, "NETWORK_ERROR"))
+	a.observe(at(4*time.Minute, "UNCAUGHT_ERROR")) // doesn't match
+	a.observe(at(3*time.Minute, "NETWORK_ERROR"))
+	a.wait()
+	if len(posted) != 0 {
+		t.Fatalf("posted %d alerts at the threshold, want none", len(posted))
+	}
+
+	a.observe(at(time.Minute, "NETWORK_ERROR"))
+	a.observe(at(0, "NETWORK_ERROR")) // already fired this window
+	a.wait()
+	if len(posted) != 1 {
+		t.Fatalf("posted %d alerts, want 1", len(posted))
+	}
+	got := posted[0]
+	if got.Rule != "network-storm" || got.Count != 3 || got.Threshold != 2 || got.Window != "10m" || got.Latest.ErrorType != "NETWORK_ERROR" || got.Latest.ID == "" {
+		t.Errorf("alert = %+v", got)
+	}
+	if !strings.Contains(got.Text, "3 entries matching type=NETWORK_ERROR in 10m") {
+		t.Errorf("Text = %q", got.Text)
+	}
+
+	// A window later, the rule can fire again
+	now = now.Add(11 * time.Minute)
+	for i := 0; i < 3; i++ {
+		a.observe(at(0, "NETWORK_ERROR"))
+	}
+	a.wait()
+	if len(posted) != 2 {
+		t.Errorf("posted %d alerts after the window passed, want 2", len(posted))
+	}
+
+	recorded, err := readAlerts(dir)
+	if err != nil || len(recorded) != 2 || recorded[0].FiredAt != "2025-12-10T12:00:00Z" {
+		t.Errorf("readAlerts() = %+v, %v", recorded, err)
+	}
+}
+
+func TestAlertEngine_RestartDoesNotRefire(t *testing.T) {
+	dir := writeAlertRules(t, config.AlertRule{Name: "any", Threshold: 1, Window: "10m"})
+	now := time.Date(2025, 12, 10, 12, 0, 0, 0, time.UTC)
+	entries := []ErrorEntry{
+		{Timestamp: now.Add(-2 * time.Minute).Format(time.RFC3339), ErrorType: "X", Message: "boom"},
+		{Timestamp: now.Add(-time.Minute).Format(time.RFC3339), ErrorType: "X", Message: "boom"},
+	}
+	start := func(at time.Time) {
+		a := newAlertEngine(dir)
+		a.now = func() time.Time { return at }
+		for _, e := range entries {
+			a.observe(e)
+		}
+		a.wait()
+	}
+
+	start(now)
+	// Restarted a minute later, it sees the same entries again
+	start(now.Add(time.Minute))
+	if alerts, _ := readAlerts(dir); len(alerts) != 1 {
+		t.Fatalf("fired %d alerts across a restart, want 1", len(alerts))
+	}
+
+	// Once the window has passed, the rule can fire again
+	entries = append(entries, ErrorEntry{Timestamp: now.Add(11 * time.Minute).Format(time.RFC3339), ErrorType: "X", Message: "boom"},
+		ErrorEntry{Timestamp: now.Add(11 * time.Minute).Format(time.RFC3339), ErrorType: "X", Message: "boom"})
+	start(now.Add(11 * time.Minute))
+	if alerts, _ := readAlerts(dir); len(alerts) != 2 {
+		t.Errorf("fired %d alerts after the window, want 2", len(alerts))
+	}
+}
+
+func TestAlertEngine_CountsOccurrences(t *testing.T) {
+	dir := writeAlertRules(t, config.AlertRule{Name: "any", Threshold: 5, Window: "1h"})
+	a := newAlertEngine(dir)
+	a.observe(ErrorEntry{ErrorType: "X", Message: "repeated", Count: 6}) // no timestamp counts as now
+	if alerts, _ := readAlerts(dir); len(alerts) != 1 || alerts[0].Count != 6 {
+		t.Errorf("alerts = %+v, want one for a deduped entry of 6", alerts)
+	}
+}
+
+func TestAlertEngine_Command(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("alert commands need a POSIX shell")
+	}
+	dir := writeAlertRules(t, config.AlertRule{Name: "first", Window: "1m", Command: `cat > alert.json; echo "$AGENTLOG_ALERT" > rule.txt`})
+	a := newAlertEngine(dir)
+	a.observe(ErrorEntry{ErrorType: "X", Message: "boom"})
+	a.wait()
+
+	rule, _ := os.ReadFile(filepath.Join(dir, "rule.txt"))
+	if strings.TrimSpace(string(rule)) != "first" {
+		t.Errorf("AGENTLOG_ALERT = %q", rule)
+	}
+	var got Alert
+	data, _ := os.ReadFile(filepath.Join(dir, "alert.json"))
+	if err := json.Unmarshal(data, &got); err != nil || got.Rule != "first" || got.Latest.Message != "boom" {
+		t.Errorf("command stdin = %s (%v)", data, err)
+	}
+}
+
+func TestAlertEngine_WebhookFailureIsLogged(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	dir := writeAlertRules(t, config.AlertRule{Name: "hook", Window: "1m", Webhook: srv.URL})
+	a := newAlertEngine(dir)
+	a.observe(ErrorEntry{ErrorType: "X", Message: "boom"})
+	a.wait()
+
+	data, _ := os.ReadFile(filepath.Join(dir, ".agentlog", "errors.jsonl"))
+	if !strings.Contains(string(data), `"error_type":"ALERT_ERROR"`) || !strings.Contains(string(data), "502") {
+		t.Errorf("a failed webhook should be logged as ALERT_ERROR, got %s", data)
+	}
+}
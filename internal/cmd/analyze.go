@@ -0,0 +1,413 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/config"
+	"github.com/agentlog/agentlog/internal/self"
+	"github.com/spf13/cobra"
+)
+
+// Providers analyze can send to
+const (
+	providerOpenAI    = "openai"
+	providerAnthropic = "anthropic"
+	providerLocal     = "local"
+)
+
+// analyzeTimeout bounds one model request; analysis of a large sample can
+// take a while
+const analyzeTimeout = 2 * time.Minute
+
+// analyzeMaxTokens caps the analysis's length where the API requires a cap
+const analyzeMaxTokens = 2000
+
+var (
+	analyzeSince   string
+	analyzeLimit   int
+	analyzeContext bool
+	analyzeModel   string
+	analyzeDryRun  bool
+)
+
+// analyzeProvider is a provider's defaults
+type analyzeProvider struct {
+	endpoint  string
+	model     string
+	apiKeyEnv string
+}
+
+var analyzeProviders = map[string]analyzeProvider{
+	providerOpenAI:    {endpoint: "https://api.openai.com/v1/chat/completions", model: "gpt-4o-mini", apiKeyEnv: "OPENAI_API_KEY"},
+	providerAnthropic: {endpoint: "https://api.anthropic.com/v1/messages", model: "claude-3-5-haiku-latest", apiKeyEnv: "ANTHROPIC_API_KEY"},
+	providerLocal:     {endpoint: "http://localhost:11434/v1/chat/completions"},
+}
+
+// analyzeSystemPrompt tells the model what it's given and what to answer
+const analyzeSystemPrompt = `You triage errors captured by agentlog in a software project under development. You are given the most frequent recent error groups as JSON: each has a fingerprint, type, occurrence count, sources, first and last seen times, a normalized pattern, and the latest message, with secrets and paths masked.
+
+Reply in plain text with a prioritized root-cause analysis:
+1. Group errors that likely share one cause, and say why.
+2. For each cause, most urgent first: the likely root cause, the evidence from the errors, and a concrete next step to confirm or fix it.
+3. Name errors by fingerprint so they can be looked up with 'agentlog show <fingerprint>'.
+Be brief, say when the evidence is thin, and don't invent details the errors don't show.`
+
+// AnalyzeGroup is one error group in the sample sent to the model
+type AnalyzeGroup struct {
+	Fingerprint string                 `json:"fingerprint"`
+	ErrorType   string                 `json:"error_type"`
+	Count       int                    `json:"count"`
+	Sources     []string               `json:"sources"`
+	FirstSeen   string                 `json:"first_seen"`
+	LastSeen    string                 `json:"last_seen"`
+	Pattern     string                 `json:"pattern"`
+	Message     string                 `json:"message"`
+	File        string                 `json:"file,omitempty"`
+	Endpoint    string                 `json:"endpoint,omitempty"`
+	Context     map[string]interface{} `json:"context,omitempty"`
+}
+
+// AnalyzeResult is what analyze sent and what came back
+type AnalyzeResult struct {
+	Provider string         `json:"provider"`
+	Model    string         `json:"model"`
+	Endpoint string         `json:"endpoint"`
+	Since    string         `json:"since,omitempty"`
+	Groups   []AnalyzeGroup `json:"groups"`
+	Analysis string         `json:"analysis,omitempty"`
+	DryRun   bool           `json:"dry_run,omitempty"`
+}
+
+// analyzeCmd represents the analyze command
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze",
+	Short: "Ask a language model for a root-cause analysis of recent errors (opt-in)",
+	Long: `Send a redacted sample of the most frequent recent error groups to a
+configured model and print its prioritized root-cause analysis.
+
+Nothing is sent unless "analyze" in .agentlog/config.json names a provider:
+  {"analyze": {"provider": "openai"}}
+  {"analyze": {"provider": "anthropic", "model": "claude-3-5-haiku-latest"}}
+  {"analyze": {"provider": "local", "model": "llama3.1", "endpoint": "http://localhost:11434/v1/chat/completions"}}
+
+openai and anthropic read the API key from OPENAI_API_KEY or
+ANTHROPIC_API_KEY ("api_key_env" names another variable; "api_key" holds
+the key itself). local is any OpenAI-compatible server, such as Ollama or
+llama.cpp, and needs a model; it sends a key only if one is configured.
+
+The sample is the latest --limit groups by count (see 'agentlog errors
+--group'), with each group's pattern, latest message, file, and endpoint.
+It's sanitized like 'agentlog share': secrets and personal data are
+masked, paths under the project become relative, the home directory and
+hostname are masked, and entry host and user are left out. Context is left
+out unless --include-context is given. --dry-run prints what would be sent
+without sending it.
+
+The deterministic alternative, with no model involved, is 'agentlog
+errors --full' and the suggestions in 'agentlog prime'.
+
+Examples:
+  agentlog analyze                     # Groups from the last 24 hours
+  agentlog analyze --since 2h --limit 5
+  agentlog analyze --dry-run           # Show the request, send nothing
+  agentlog analyze --json              # Sample and analysis as JSON`,
+	RunE: runAnalyze,
+}
+
+func init() {
+	rootCmd.AddCommand(analyzeCmd)
+
+	analyzeCmd.Flags().StringVar(&analyzeSince, "since", "24h", "Only analyze errors since time (e.g., '2h', '2d', 'yesterday'); empty for the whole log")
+	analyzeCmd.Flags().IntVar(&analyzeLimit, "limit", 10, "Most error groups to send, the most frequent first")
+	analyzeCmd.Flags().BoolVar(&analyzeContext, "include-context", false, "Send each group's latest context too (left out by default)")
+	analyzeCmd.Flags().StringVar(&analyzeModel, "model", "", "Model to use instead of config.json's analyze.model")
+	analyzeCmd.Flags().BoolVar(&analyzeDryRun, "dry-run", false, "Print the sample that would be sent, without sending it")
+}
+
+func runAnalyze(cmd *cobra.Command, args []string) error {
+	// Determine base directory (use --path override or cwd)
+	baseDir := GetPathOverride()
+	if baseDir == "" {
+		var err error
+		baseDir, err = os.Getwd()
+		if err != nil {
+			self.LogError(".", "GETWD_ERROR", err.Error())
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+	if uninitialized(baseDir) {
+		return exitNotInitialized(cmd)
+	}
+
+	if analyzeLimit <= 0 {
+		return invalidInput("--limit must be positive")
+	}
+	var sinceTime time.Time
+	if analyzeSince != "" {
+		var err error
+		if sinceTime, err = parseSince(analyzeSince); err != nil {
+			self.LogError(baseDir, "INVALID_INPUT", fmt.Sprintf("invalid --since value '%s': %v", analyzeSince, err))
+			return invalidInput("invalid --since value: %w", err)
+		}
+	}
+	cfg, err := config.Load(baseDir)
+	if err != nil {
+		return err
+	}
+	client, err := newAnalyzeClient(cfg.Analyze, analyzeModel)
+	if err != nil {
+		return invalidInput("%w", err)
+	}
+
+	entries, err := readErrors(baseDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	entries = filterErrors(filterKind(entries, kindError), "", "", sinceTime)
+	result := AnalyzeResult{
+		Provider: client.provider,
+		Model:    client.model,
+		Endpoint: client.endpoint,
+		Since:    analyzeSince,
+		Groups:   analyzeSample(baseDir, entries, analyzeLimit, analyzeContext),
+		DryRun:   analyzeDryRun,
+	}
+
+	if len(result.Groups) > 0 && !analyzeDryRun {
+		prompt, err := analyzePrompt(result.Groups)
+		if err != nil {
+			return err
+		}
+		if result.Analysis, err = client.complete(analyzeSystemPrompt, prompt); err != nil {
+			self.LogError(baseDir, "ANALYZE_ERROR", err.Error())
+			return fmt.Errorf("analyze: %w", err)
+		}
+	}
+
+	w := cmd.OutOrStdout()
+	if IsJSONOutput() {
+		output, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Fprintln(w, string(output))
+		return nil
+	}
+	fmt.Fprint(w, formatAnalyzeHuman(result))
+	return nil
+}
+
+// analyzeSample returns the limit most frequent groups in entries, with
+// their latest entry's location, sanitized for sending off the machine
+func analyzeSample(baseDir string, entries []ErrorEntry, limit int, withContext bool) []AnalyzeGroup {
+	scrub := newShareScrubber(baseDir)
+	clusters := clusterEntries(entries)
+	groups := make([]AnalyzeGroup, 0, len(clusters))
+	for _, c := range clusters {
+		g := summarizeCluster(c)
+		latest := scrub.entry(c.entries[len(c.entries)-1], withContext)
+		groups = append(groups, AnalyzeGroup{
+			Fingerprint: g.Fingerprint,
+			ErrorType:   g.ErrorType,
+			Count:       g.Count,
+			Sources:     g.Sources,
+			FirstSeen:   g.FirstSeen,
+			LastSeen:    g.LastSeen,
+			Pattern:     scrub.text(g.Pattern),
+			Message:     latest.Message,
+			File:        latest.File,
+			Endpoint:    latest.Endpoint,
+			Context:     latest.Context,
+		})
+	}
+	// Ordered like errors --group: by count, then the most recently seen
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return timestampBefore(groups[j].LastSeen, groups[i].LastSeen)
+	})
+	if len(groups) > limit {
+		groups = groups[:limit]
+	}
+	return groups
+}
+
+// analyzePrompt is the user message: the sample as indented JSON
+func analyzePrompt(groups []AnalyzeGroup) (string, error) {
+	data, err := json.MarshalIndent(groups, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("The %d most frequent recent error groups:\n\n%s\n", len(groups), data), nil
+}
+
+// analyzeClient sends one completion request to the configured provider
+type analyzeClient struct {
+	provider string
+	model    string
+	endpoint string
+	apiKey   string
+	http     *http.Client
+}
+
+// newAnalyzeClient checks the analyze config, filling in the provider's
+// defaults. model, when set, overrides the configured one.
+func newAnalyzeClient(cfg config.AnalyzeConfig, model string) (*analyzeClient, error) {
+	if cfg.Provider == "" {
+		return nil, errors.New(`analyze is off: name a provider under "analyze" in .agentlog/config.json, e.g. {"analyze": {"provider": "openai"}} (see 'agentlog analyze --help')`)
+	}
+	defaults, ok := analyzeProviders[cfg.Provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown analyze provider %q: use %s, %s, or %s", cfg.Provider, providerOpenAI, providerAnthropic, providerLocal)
+	}
+	c := &analyzeClient{
+		provider: cfg.Provider,
+		model:    firstNonEmpty(model, cfg.Model, defaults.model),
+		endpoint: firstNonEmpty(cfg.Endpoint, defaults.endpoint),
+		http:     &http.Client{Timeout: analyzeTimeout},
+	}
+	if c.model == "" {
+		return nil, fmt.Errorf(`the %s provider needs a model: set "model" under "analyze" in .agentlog/config.json, or pass --model`, cfg.Provider)
+	}
+	if !strings.HasPrefix(c.endpoint, "http://") && !strings.HasPrefix(c.endpoint, "https://") {
+		return nil, fmt.Errorf("analyze endpoint %q isn't an http(s) URL", c.endpoint)
+	}
+	keyEnv := firstNonEmpty(cfg.APIKeyEnv, defaults.apiKeyEnv)
+	if keyEnv != "" {
+		c.apiKey = os.Getenv(keyEnv)
+	}
+	if c.apiKey == "" {
+		c.apiKey = cfg.APIKey
+	}
+	if c.apiKey == "" && cfg.Provider != providerLocal {
+		return nil, fmt.Errorf(`no API key for %s: set %s, or "api_key_env" under "analyze" in .agentlog/config.json to name another variable`, cfg.Provider, keyEnv)
+	}
+	return c, nil
+}
+
+// complete sends the system and user messages and returns the reply's text
+func (c *analyzeClient) complete(system, prompt string) (string, error) {
+	var body interface{}
+	if c.provider == providerAnthropic {
+		body = map[string]interface{}{
+			"model":      c.model,
+			"max_tokens": analyzeMaxTokens,
+			"system":     system,
+			"messages":   []map[string]string{{"role": "user", "content": prompt}},
+		}
+	} else {
+		body = map[string]interface{}{
+			"model": c.model,
+			"messages": []map[string]string{
+				{"role": "system", "content": system},
+				{"role": "user", "content": prompt},
+			},
+		}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.provider == providerAnthropic {
+		req.Header.Set("x-api-key", c.apiKey)
+		req.Header.Set("anthropic-version", "2023-06-01")
+	} else if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	reply, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("%s answered %s: %s", c.endpoint, resp.Status, truncate(singleLine(string(reply)), 300))
+	}
+	return completionText(c.provider, reply)
+}
+
+// completionText extracts the reply's text from an Anthropic messages or
+// OpenAI chat completions response
+func completionText(provider string, reply []byte) (string, error) {
+	var text string
+	if provider == providerAnthropic {
+		var r struct {
+			Content []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+		}
+		if err := json.Unmarshal(reply, &r); err != nil {
+			return "", fmt.Errorf("unreadable response: %w", err)
+		}
+		var parts []string
+		for _, c := range r.Content {
+			if c.Type == "text" {
+				parts = append(parts, c.Text)
+			}
+		}
+		text = strings.Join(parts, "\n")
+	} else {
+		var r struct {
+			Choices []struct {
+				Message struct {
+					Content string `json:"content"`
+				} `json:"message"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal(reply, &r); err != nil {
+			return "", fmt.Errorf("unreadable response: %w", err)
+		}
+		if len(r.Choices) > 0 {
+			text = r.Choices[0].Message.Content
+		}
+	}
+	if strings.TrimSpace(text) == "" {
+		return "", errors.New("the response had no text")
+	}
+	return strings.TrimSpace(text), nil
+}
+
+// formatAnalyzeHuman prints the analysis under a line naming what was
+// sent, or with --dry-run, the request that would be sent
+func formatAnalyzeHuman(r AnalyzeResult) string {
+	var sb strings.Builder
+	if len(r.Groups) == 0 {
+		sb.WriteString("No errors to analyze")
+		if r.Since != "" {
+			sb.WriteString(" since " + r.Since)
+		}
+		sb.WriteString(".\n")
+		return sb.String()
+	}
+	groupWord := "groups"
+	if len(r.Groups) == 1 {
+		groupWord = "group"
+	}
+	if r.DryRun {
+		prompt, _ := analyzePrompt(r.Groups)
+		sb.WriteString(fmt.Sprintf("Would send %d error %s to %s (%s at %s):\n\n", len(r.Groups), groupWord, r.Provider, r.Model, r.Endpoint))
+		sb.WriteString(colors.dim("System: "+analyzeSystemPrompt) + "\n\n")
+		sb.WriteString(prompt)
+		return sb.String()
+	}
+	sb.WriteString(colors.dim(fmt.Sprintf("Analysis of %d error %s by %s (%s):", len(r.Groups), groupWord, r.Provider, r.Model)) + "\n\n")
+	sb.WriteString(r.Analysis + "\n")
+	return sb.String()
+}
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/config"
+)
+
+func resetAnalyzeFlags() {
+	analyzeSince, analyzeLimit, analyzeContext, analyzeModel, analyzeDryRun = "24h", 10, false, "", false
+}
+
+// writeAnalyzeLog writes a log of two recent groups and an old one, with
+// analyze configured as given
+func writeAnalyzeLog(t *testing.T, analyze string) string {
+	t.Helper()
+	now := time.Now().UTC()
+	at := func(d time.Duration) string { return now.Add(d).Format(time.RFC3339Nano) }
+	dir := writeEntriesLog(t, []ErrorEntry{
+		{Timestamp: at(-72 * time.Hour), Source: "backend", ErrorType: "BUILD_ERROR", Message: "tsc exited with 2"},
+		{Timestamp: at(-3 * time.Minute), Source: "backend", ErrorType: "DATABASE_ERROR", Message: "connect ECONNREFUSED 127.0.0.1:5432", Context: map[string]interface{}{"query": "SELECT 1"}},
+		{Timestamp: at(-2 * time.Minute), Source: "backend", ErrorType: "DATABASE_ERROR", Message: "connect ECONNREFUSED 127.0.0.1:5432", File: "/root/app/src/db.ts"},
+		{Timestamp: at(-time.Minute), Source: "frontend", ErrorType: "NETWORK_ERROR", Message: "fetch failed for jane@example.com"},
+	})
+	if analyze != "" {
+		os.WriteFile(filepath.Join(dir, ".agentlog", "config.json"), []byte(`{"analyze": `+analyze+`}`), 0644)
+	}
+	return dir
+}
+
+func TestNewAnalyzeClient(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant-test")
+	t.Setenv("MY_KEY", "sk-mine")
+
+	tests := []struct {
+		name    string
+		cfg     config.AnalyzeConfig
+		model   string
+		wantErr string
+		want    analyzeClient
+	}{
+		{name: "off", wantErr: "analyze is off"},
+		{name: "unknown", cfg: config.AnalyzeConfig{Provider: "gemini"}, wantErr: "unknown analyze provider"},
+		{name: "no key", cfg: config.AnalyzeConfig{Provider: "openai"}, wantErr: "no API key for openai: set OPENAI_API_KEY"},
+		{name: "anthropic defaults", cfg: config.AnalyzeConfig{Provider: "anthropic"},
+			want: analyzeClient{provider: "anthropic", model: "claude-3-5-haiku-latest", endpoint: "https://api.anthropic.com/v1/messages", apiKey: "sk-ant-test"}},
+		{name: "key env and flag model", cfg: config.AnalyzeConfig{Provider: "openai", Model: "gpt-4o", APIKeyEnv: "MY_KEY"}, model: "o3-mini",
+			want: analyzeClient{provider: "openai", model: "o3-mini", endpoint: "https://api.openai.com/v1/chat/completions", apiKey: "sk-mine"}},
+		{name: "config key", cfg: config.AnalyzeConfig{Provider: "openai", APIKey: "sk-config"},
+			want: analyzeClient{provider: "openai", model: "gpt-4o-mini", endpoint: "https://api.openai.com/v1/chat/completions", apiKey: "sk-config"}},
+		{name: "local needs a model", cfg: config.AnalyzeConfig{Provider: "local"}, wantErr: "needs a model"},
+		{name: "local", cfg: config.AnalyzeConfig{Provider: "local", Model: "llama3.1"},
+			want: analyzeClient{provider: "local", model: "llama3.1", endpoint: "http://localhost:11434/v1/chat/completions"}},
+		{name: "bad endpoint", cfg: config.AnalyzeConfig{Provider: "local", Model: "m", Endpoint: "localhost:8080"}, wantErr: "isn't an http(s) URL"},
+	}
+	for _, tt := range tests {
+		c, err := newAnalyzeClient(tt.cfg, tt.model)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		c.http = nil
+		if *c != tt.want {
+			t.Errorf("%s: client = %+v, want %+v", tt.name, *c, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeSample(t *testing.T) {
+	dir := writeAnalyzeLog(t, "")
+	entries, _ := readErrors(dir)
+	groups := analyzeSample(dir, filterErrors(entries, "", "", time.Now().Add(-24*time.Hour)), 5, false)
+	if len(groups) != 2 || groups[0].ErrorType != "DATABASE_ERROR" || groups[0].Count != 2 {
+		t.Fatalf("groups = %+v", groups)
+	}
+	if groups[0].Context != nil {
+		t.Error("context should be left out without --include-context")
+	}
+	if strings.Contains(groups[1].Message, "jane@example.com") {
+		t.Errorf("personal data should be masked, got %q", groups[1].Message)
+	}
+
+	if groups := analyzeSample(dir, entries, 1, true); len(groups) != 1 {
+		t.Errorf("--limit 1 should send one group, got %d", len(groups))
+	}
+}
+
+func TestAnalyzeClient_Complete(t *testing.T) {
+	var got struct {
+		path, auth, apiKey string
+		body               map[string]interface{}
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.path, got.auth, got.apiKey = r.URL.Path, r.Header.Get("Authorization"), r.Header.Get("x-api-key")
+		json.NewDecoder(r.Body).Decode(&got.body)
+		if r.URL.Path == "/v1/messages" {
+			w.Write([]byte(`{"content": [{"type": "text", "text": "1. The database is down."}]}`))
+			return
+		}
+		if r.URL.Path == "/fail" {
+			http.Error(w, `{"error": "rate limited"}`, http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "  1. Start Postgres.\n"}}]}`))
+	}))
+	defer srv.Close()
+
+	openai := &analyzeClient{provider: providerOpenAI, model: "gpt-4o-mini", endpoint: srv.URL + "/v1/chat/completions", apiKey: "sk-test", http: srv.Client()}
+	text, err := openai.complete("system", "prompt")
+	if err != nil || text != "1. Start Postgres." {
+		t.Errorf("openai complete() = %q, %v", text, err)
+	}
+	if got.auth != "Bearer sk-test" || len(got.body["messages"].([]interface{})) != 2 {
+		t.Errorf("openai request: auth %q, body %v", got.auth, got.body)
+	}
+
+	anthropic := &analyzeClient{provider: providerAnthropic, model: "claude", endpoint: srv.URL + "/v1/messages", apiKey: "sk-ant", http: srv.Client()}
+	text, err = anthropic.complete("system", "prompt")
+	if err != nil || text != "1. The database is down." {
+		t.Errorf("anthropic complete() = %q, %v", text, err)
+	}
+	if got.apiKey != "sk-ant" || got.auth != "" || got.body["system"] != "system" || got.body["max_tokens"] == nil {
+		t.Errorf("anthropic request: key %q, auth %q, body %v", got.apiKey, got.auth, got.body)
+	}
+
+	local := &analyzeClient{provider: providerLocal, model: "llama3.1", endpoint: srv.URL + "/v1/chat/completions", http: srv.Client()}
+	if _, err := local.complete("system", "prompt"); err != nil || got.auth != "" {
+		t.Errorf("local sends no key: auth %q, %v", got.auth, err)
+	}
+
+	failing := &analyzeClient{provider: providerOpenAI, endpoint: srv.URL + "/fail", apiKey: "k", http: srv.Client()}
+	if _, err := failing.complete("system", "prompt"); err == nil || !strings.Contains(err.Error(), "429 Too Many Requests: {\"error\": \"rate limited\"}") {
+		t.Errorf("expected the error status and body, got %v", err)
+	}
+}
+
+func TestAnalyzeCommand(t *testing.T) {
+	var requests int
+	var prompt string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var body struct {
+			Messages []struct{ Content string } `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		prompt = body.Messages[1].Content
+		w.Write([]byte(`{"choices": [{"message": {"content": "Start Postgres first."}}]}`))
+	}))
+	defer srv.Close()
+
+	dir := writeAnalyzeLog(t, `{"provider": "local", "model": "llama3.1", "endpoint": "`+srv.URL+`"}`)
+	originalPath, originalJSON := pathOverride, jsonOutput
+	defer func() { pathOverride, jsonOutput = originalPath, originalJSON; resetAnalyzeFlags() }()
+	pathOverride, jsonOutput = dir, false
+	resetAnalyzeFlags()
+
+	buf := new(bytes.Buffer)
+	analyzeCmd.SetOut(buf)
+	defer analyzeCmd.SetOut(nil)
+
+	analyzeDryRun = true
+	if err := runAnalyze(analyzeCmd, nil); err != nil {
+		t.Fatalf("runAnalyze(--dry-run) error = %v", err)
+	}
+	if requests != 0 || !strings.HasPrefix(buf.String(), "Would send 2 error groups to local (llama3.1 at "+srv.URL+"):") {
+		t.Errorf("--dry-run shouldn't send anything, got %d requests:\n%s", requests, buf.String())
+	}
+
+	analyzeDryRun = false
+	buf.Reset()
+	if err := runAnalyze(analyzeCmd, nil); err != nil {
+		t.Fatalf("runAnalyze() error = %v", err)
+	}
+	if requests != 1 || buf.String() != "Analysis of 2 error groups by local (llama3.1):\n\nStart Postgres first.\n" {
+		t.Errorf("output = %q", buf.String())
+	}
+	if !strings.Contains(prompt, `"error_type": "DATABASE_ERROR"`) || strings.Contains(prompt, "BUILD_ERROR") || strings.Contains(prompt, "SELECT 1") {
+		t.Errorf("the prompt should hold the last 24h of groups, without context:\n%s", prompt)
+	}
+
+	jsonOutput = true
+	buf.Reset()
+	if err := runAnalyze(analyzeCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	var result AnalyzeResult
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil || result.Analysis != "Start Postgres first." || len(result.Groups) != 2 {
+		t.Errorf("JSON = %s (%v)", buf.String(), err)
+	}
+
+	jsonOutput, analyzeSince = false, "1s"
+	buf.Reset()
+	if err := runAnalyze(analyzeCmd, nil); err != nil || requests != 2 || buf.String() != "No errors to analyze since 1s.\n" {
+		t.Errorf("with no errors, nothing is sent: %d requests, %q (%v)", requests, buf.String(), err)
+	}
+
+	pathOverride = writeAnalyzeLog(t, "")
+	if err := runAnalyze(analyzeCmd, nil); ExitCode(err) != ExitError || !strings.Contains(err.Error(), "analyze is off") {
+		t.Errorf("without a provider analyze should exit 2, got %v", err)
+	}
+}
@@ -0,0 +1,295 @@
+package cmd
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/logfile"
+	"github.com/agentlog/agentlog/internal/self"
+	"github.com/spf13/cobra"
+)
+
+var (
+	captureParser  string
+	captureSource  string
+	captureTestRun string
+)
+
+// testRunIDEnv passes the test run ID to the captured command, whose
+// capture snippets and 'agentlog log' calls put it in context.test_run_id
+const testRunIDEnv = "AGENTLOG_TEST_RUN_ID"
+
+// testRunKey is the context key that ties entries to one test invocation
+const testRunKey = "test_run_id"
+
+// newTestRunFlag is --test-run given without a value
+const newTestRunFlag = "new"
+
+const testRunsFileName = "test-runs.jsonl"
+
+// TestRun is one line of .agentlog/test-runs.jsonl: a command run under
+// 'agentlog capture --test-run', and how it ended
+type TestRun struct {
+	ID       string `json:"id"`
+	Command  string `json:"command"`
+	Started  string `json:"started"`
+	Finished string `json:"finished"`
+	ExitCode int    `json:"exit_code"`
+	Captured int    `json:"captured"` // entries parsed from the command's output
+}
+
+// captureCmd represents the capture command
+var captureCmd = &cobra.Command{
+	Use:   "capture [flags] -- <command> [args...]",
+	Short: "Run a command and record the errors in its output",
+	Long: `Run a command, pass its output through unchanged, and append the lines a
+line parser matches as entries in .agentlog/errors.jsonl, so a test suite's
+or script's failures reach agentlog without a capture snippet.
+
+--test-run marks everything the run produces as one test invocation: the
+entries parsed from its output get context.test_run_id, and the command
+runs with AGENTLOG_TEST_RUN_ID set, which the Node and Python snippets and
+'agentlog log' add to the entries they write. Without a value a new ID is
+generated. The run is recorded in .agentlog/test-runs.jsonl; 'agentlog runs'
+lists the runs and 'agentlog runs <id>' groups one run's errors.
+
+The default parser (errorlines) picks up lines with common error markers.
+Any line-oriented parser works, including regex parsers from
+.agentlog/config.json.
+
+capture exits with the command's exit code. With --json the command's
+output goes to stderr, and stdout gets the run as JSON.
+
+Examples:
+  agentlog capture -- make build
+  agentlog capture --test-run -- npm test
+  agentlog capture --test-run ci-4812 -- pytest -x
+  agentlog capture --parser myapp --source backend -- ./scripts/migrate.sh`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runCapture,
+}
+
+func init() {
+	rootCmd.AddCommand(captureCmd)
+
+	// Flags after the command name belong to the command
+	captureCmd.Flags().SetInterspersed(false)
+	captureCmd.Flags().StringVar(&captureParser, "parser", "errorlines", "Line parser to apply to the command's output")
+	captureCmd.Flags().StringVar(&captureSource, "source", "test", "Source to record on captured entries")
+	captureCmd.Flags().StringVar(&captureTestRun, "test-run", "", "Tag entries with this test run ID (a new one when given without a value)")
+	captureCmd.Flags().Lookup("test-run").NoOptDefVal = newTestRunFlag
+}
+
+func runCapture(cmd *cobra.Command, args []string) error {
+	// Determine base directory (use --path override or cwd)
+	baseDir := GetPathOverride()
+	if baseDir == "" {
+		var err error
+		baseDir, err = os.Getwd()
+		if err != nil {
+			self.LogError(".", "GETWD_ERROR", err.Error())
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+
+	ingester, err := newStreamIngester(baseDir, captureParser, captureSource)
+	if err != nil {
+		return err
+	}
+
+	started := time.Now()
+	runID := strings.TrimSpace(captureTestRun)
+	if runID == newTestRunFlag {
+		runID = newTestRunID(started)
+	}
+
+	c := exec.Command(args[0], args[1:]...)
+	c.Stdin = cmd.InOrStdin()
+	c.Env = os.Environ()
+	if runID != "" {
+		ingester.context = map[string]interface{}{testRunKey: runID}
+		c.Env = append(c.Env, testRunIDEnv+"="+runID)
+	}
+
+	// Ctrl+C reaches the command too; capture outlives it to record the
+	// run, and passes SIGTERM on
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	go func() {
+		for sig := range sigChan {
+			if sig == syscall.SIGTERM && c.Process != nil {
+				c.Process.Signal(sig)
+			}
+		}
+	}()
+
+	stdout, stderr := cmd.OutOrStdout(), cmd.ErrOrStderr()
+	if IsJSONOutput() {
+		stdout = stderr
+	}
+	runErr := captureOutput(c, stdout, stderr, ingester)
+
+	exitCode := 0
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(runErr, &exitErr):
+		exitCode = exitErr.ExitCode()
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			exitCode = 128 + int(status.Signal())
+		}
+	case runErr != nil && c.ProcessState == nil:
+		self.LogError(baseDir, "COMMAND_ERROR", fmt.Sprintf("failed to run %s: %v", args[0], runErr))
+		return fmt.Errorf("failed to run %s: %w", args[0], runErr)
+	case runErr != nil:
+		self.LogError(baseDir, "INGEST_ERROR", runErr.Error())
+		return runErr
+	}
+
+	run := TestRun{
+		ID:       runID,
+		Command:  strings.Join(args, " "),
+		Started:  started.UTC().Format(time.RFC3339Nano),
+		Finished: time.Now().UTC().Format(time.RFC3339Nano),
+		ExitCode: exitCode,
+		Captured: ingester.count,
+	}
+	if runID != "" {
+		if err := recordTestRun(baseDir, run); err != nil {
+			self.LogError(baseDir, "FILE_WRITE_ERROR", err.Error())
+			return fmt.Errorf("failed to record the test run: %w", err)
+		}
+	}
+
+	if IsJSONOutput() {
+		output, _ := json.MarshalIndent(run, "", "  ")
+		fmt.Fprintln(cmd.OutOrStdout(), string(output))
+	} else {
+		entryWord := "entries"
+		if run.Captured == 1 {
+			entryWord = "entry"
+		}
+		summary := fmt.Sprintf("agentlog: captured %d %s from %s (exit %d)", run.Captured, entryWord, args[0], exitCode)
+		if runID != "" {
+			summary += fmt.Sprintf("; see 'agentlog runs %s'", runID)
+		}
+		fmt.Fprintln(stderr, summary)
+	}
+
+	if exitCode != 0 {
+		return exitWith(cmd, exitCode)
+	}
+	return nil
+}
+
+// captureOutput runs c, copying its stdout and stderr to stdout and stderr
+// as they arrive while the ingester parses each line
+func captureOutput(c *exec.Cmd, stdout, stderr io.Writer, ingester *streamIngester) error {
+	outPipe, err := c.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	errPipe, err := c.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := c.Start(); err != nil {
+		return err
+	}
+
+	var mu sync.Mutex
+	var ingestErr error
+	var wg sync.WaitGroup
+	copyLines := func(r io.Reader, w io.Writer) {
+		defer wg.Done()
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadString('\n')
+			if line != "" {
+				mu.Lock()
+				io.WriteString(w, line)
+				text := strings.TrimRight(line, "\r\n")
+				if ingestErr == nil && len(text) <= logfile.DefaultMaxLine {
+					_, ingestErr = ingester.ingestLine(text, "", nil)
+				}
+				mu.Unlock()
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+	wg.Add(2)
+	go copyLines(outPipe, stdout)
+	go copyLines(errPipe, stderr)
+	wg.Wait()
+
+	waitErr := c.Wait()
+	if ingestErr != nil {
+		return ingestErr
+	}
+	return waitErr
+}
+
+// newTestRunID returns an ID that sorts by when the run started, e.g.
+// 20251210-150405-3f2a
+func newTestRunID(now time.Time) string {
+	b := make([]byte, 2)
+	rand.Read(b)
+	return now.UTC().Format("20060102-150405") + "-" + hex.EncodeToString(b)
+}
+
+func testRunsPath(baseDir string) string {
+	return filepath.Join(baseDir, ".agentlog", testRunsFileName)
+}
+
+// recordTestRun appends run to .agentlog/test-runs.jsonl
+func recordTestRun(baseDir string, run TestRun) error {
+	if err := os.MkdirAll(filepath.Join(baseDir, ".agentlog"), 0755); err != nil {
+		return err
+	}
+	data, _ := json.Marshal(run)
+	return logfile.Append(testRunsPath(baseDir), append(data, '\n'))
+}
+
+// readTestRuns reads .agentlog/test-runs.jsonl, skipping lines that don't
+// parse
+func readTestRuns(baseDir string) ([]TestRun, error) {
+	f, err := os.Open(testRunsPath(baseDir))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var runs []TestRun
+	err = scanRecords(baseDir, f, func(line []byte) error {
+		var run TestRun
+		if err := json.Unmarshal(line, &run); err != nil {
+			return err
+		}
+		if run.ID != "" {
+			runs = append(runs, run)
+		}
+		return nil
+	})
+	return runs, err
+}
+
+// entryTestRun returns the entry's context.test_run_id, if any
+func entryTestRun(e ErrorEntry) string {
+	id, _ := e.Context[testRunKey].(string)
+	return id
+}
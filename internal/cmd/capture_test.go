@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func resetCaptureFlags() {
+	captureParser, captureSource, captureTestRun = "errorlines", "test", ""
+}
+
+// runCaptureIn runs capture with args in a fresh project, returning its
+// stdout, stderr, and error
+func runCaptureIn(t *testing.T, dir string, args ...string) (string, string, error) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	originalPath := pathOverride
+	t.Cleanup(func() { pathOverride = originalPath; captureCmd.SetOut(nil); captureCmd.SetErr(nil) })
+	pathOverride = dir
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	captureCmd.SetOut(stdout)
+	captureCmd.SetErr(stderr)
+	err := runCapture(captureCmd, args)
+	return stdout.String(), stderr.String(), err
+}
+
+func TestCaptureCommand_TestRun(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, ".agentlog"), 0755)
+	defer resetCaptureFlags()
+	resetCaptureFlags()
+	captureTestRun = "ci-7"
+
+	stdout, stderr, err := runCaptureIn(t, dir, "sh", "-c", `echo "run=$AGENTLOG_TEST_RUN_ID"; echo "ERROR: seed failed" >&2; exit 3`)
+	var exitErr *ExitCodeError
+	if !errors.As(err, &exitErr) || exitErr.Code != 3 {
+		t.Fatalf("runCapture() error = %v, want exit code 3", err)
+	}
+	if stdout != "run=ci-7\n" {
+		t.Errorf("stdout = %q, want the command's output with AGENTLOG_TEST_RUN_ID set", stdout)
+	}
+	if !strings.Contains(stderr, "ERROR: seed failed\n") || !strings.Contains(stderr, "captured 1 entry from sh (exit 3); see 'agentlog runs ci-7'") {
+		t.Errorf("stderr = %q", stderr)
+	}
+
+	entries, err := readErrors(dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d (%v)", len(entries), err)
+	}
+	if entries[0].Source != "test" || entryTestRun(entries[0]) != "ci-7" {
+		t.Errorf("entry = %+v", entries[0])
+	}
+	runs, err := readTestRuns(dir)
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("expected 1 recorded run, got %+v (%v)", runs, err)
+	}
+	if r := runs[0]; r.ID != "ci-7" || r.ExitCode != 3 || r.Captured != 1 || r.Command != `sh -c echo "run=$AGENTLOG_TEST_RUN_ID"; echo "ERROR: seed failed" >&2; exit 3` || r.Finished < r.Started {
+		t.Errorf("run = %+v", r)
+	}
+}
+
+func TestCaptureCommand_JSON(t *testing.T) {
+	dir := t.TempDir()
+	originalJSON := jsonOutput
+	defer func() { jsonOutput = originalJSON; resetCaptureFlags() }()
+	jsonOutput = true
+	resetCaptureFlags()
+	captureTestRun = newTestRunFlag
+
+	stdout, stderr, err := runCaptureIn(t, dir, "sh", "-c", "echo building; echo done")
+	if err != nil {
+		t.Fatalf("runCapture() error = %v", err)
+	}
+	var run TestRun
+	if err := json.Unmarshal([]byte(stdout), &run); err != nil {
+		t.Fatalf("stdout should be the run as JSON: %v\n%s", err, stdout)
+	}
+	if !regexp.MustCompile(`^\d{8}-\d{6}-[0-9a-f]{4}$`).MatchString(run.ID) || run.ExitCode != 0 || run.Captured != 0 {
+		t.Errorf("run = %+v", run)
+	}
+	if stderr != "building\ndone\n" {
+		t.Errorf("with --json the command's output should go to stderr, got %q", stderr)
+	}
+}
+
+func TestCaptureCommand_NotFound(t *testing.T) {
+	dir := t.TempDir()
+	defer resetCaptureFlags()
+	resetCaptureFlags()
+	captureTestRun = "ci-8"
+
+	if _, _, err := runCaptureIn(t, dir, "agentlog-no-such-command"); err == nil || ExitCode(err) != ExitError {
+		t.Errorf("runCapture() error = %v, want a failure to start", err)
+	}
+	if _, err := readTestRuns(dir); !os.IsNotExist(err) {
+		t.Error("a command that didn't start shouldn't be recorded as a run")
+	}
+}
+
+func TestNewTestRunID(t *testing.T) {
+	now := time.Date(2025, 12, 10, 15, 4, 5, 0, time.UTC)
+	a, b := newTestRunID(now), newTestRunID(now)
+	if !strings.HasPrefix(a, "20251210-150405-") || a == b {
+		t.Errorf("newTestRunID() = %q, %q", a, b)
+	}
+}
+
+func TestAppendErrors_TestRunEnv(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(testRunIDEnv, "ci-9")
+	err := appendErrors(dir, []ErrorEntry{
+		{Timestamp: "2025-12-10T10:00:00Z", Source: "cli", ErrorType: "E", Message: "tagged"},
+		{Timestamp: "2025-12-10T10:00:01Z", Source: "cli", ErrorType: "E", Message: "own run", Context: map[string]interface{}{testRunKey: "local-1"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := readErrors(dir)
+	if len(entries) != 2 || entryTestRun(entries[0]) != "ci-9" || entryTestRun(entries[1]) != "local-1" {
+		t.Errorf("entries = %+v", entries)
+	}
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// CommandError is a failure with a machine-readable code, from the same
+// vocabulary self.LogError records (INVALID_INPUT, FILE_READ_ERROR, ...)
+type CommandError struct {
+	Code string
+	Err  error
+}
+
+func (e *CommandError) Error() string { return e.Err.Error() }
+
+func (e *CommandError) Unwrap() error { return e.Err }
+
+// invalidInput is a CommandError for a bad flag value or combination
+func invalidInput(format string, a ...any) error {
+	return &CommandError{Code: "INVALID_INPUT", Err: fmt.Errorf(format, a...)}
+}
+
+// JSONError is what a failed command writes to stderr with --json:
+// {"error": {"code": ..., "message": ..., "exit_code": ...}}
+type JSONError struct {
+	Error JSONErrorDetail `json:"error"`
+}
+
+// JSONErrorDetail describes a failure in a JSONError
+type JSONErrorDetail struct {
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// errorCode is the machine-readable code for err: a CommandError's own, or
+// one inferred from what it wraps
+func errorCode(err error) string {
+	var cmdErr *CommandError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &cmdErr):
+		return cmdErr.Code
+	case errors.Is(err, fs.ErrNotExist):
+		return "FILE_NOT_FOUND"
+	case errors.Is(err, fs.ErrPermission):
+		return "PERMISSION_DENIED"
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return "PARSE_ERROR"
+	case strings.HasPrefix(err.Error(), "unknown command"):
+		return "UNKNOWN_COMMAND"
+	}
+	return "COMMAND_ERROR"
+}
+
+// writeJSONError writes err to w as a JSONError
+func writeJSONError(w io.Writer, err error) {
+	data, _ := json.Marshal(JSONError{Error: JSONErrorDetail{
+		Code:     errorCode(err),
+		Message:  err.Error(),
+		ExitCode: ExitCode(err),
+	}})
+	fmt.Fprintln(w, string(data))
+}
+
+// reportFailure prints a failure the command handles itself to stderr, as
+// a JSONError with --json, and ends cmd with ExitError
+func reportFailure(cmd *cobra.Command, prefix string, err error) error {
+	if IsJSONOutput() {
+		writeJSONError(cmd.ErrOrStderr(), err)
+	} else {
+		fmt.Fprintf(cmd.ErrOrStderr(), "%s: %v\n", prefix, err)
+	}
+	return exitWith(cmd, ExitError)
+}
+
+// jsonRequested reports whether args ask for JSON output. Execute needs to
+// know before cobra parses them, since failing to parse is one of the
+// errors to report as JSON.
+func jsonRequested(args []string) bool {
+	requested := false
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			return requested
+		case arg == "--json":
+			requested = true
+		case strings.HasPrefix(arg, "--json="):
+			requested, _ = strconv.ParseBool(strings.TrimPrefix(arg, "--json="))
+		case arg == "--output" && i+1 < len(args):
+			requested = requested || args[i+1] == "json" || args[i+1] == "ndjson"
+		case arg == "--output=json", arg == "--output=ndjson":
+			requested = true
+		}
+	}
+	return requested
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestJSONRequested(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"errors", "--json"}, true},
+		{[]string{"--json", "prime"}, true},
+		{[]string{"errors", "--json=false"}, false},
+		{[]string{"errors", "--json=false", "--json"}, true},
+		{[]string{"errors", "--output", "ndjson"}, true},
+		{[]string{"errors", "--output=json"}, true},
+		{[]string{"errors", "--output", "text"}, false},
+		{[]string{"log", "--", "--json"}, false},
+		{[]string{"errors"}, false},
+	}
+	for _, tt := range tests {
+		if got := jsonRequested(tt.args); got != tt.want {
+			t.Errorf("jsonRequested(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	_, notExist := os.Open("/nonexistent/agentlog")
+	var syntax map[string]any
+	parseErr := json.Unmarshal([]byte("{"), &syntax)
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{invalidInput("--limit must be positive"), "INVALID_INPUT"},
+		{fmt.Errorf("wrapped: %w", invalidInput("bad")), "INVALID_INPUT"},
+		{fmt.Errorf("failed to open: %w", notExist), "FILE_NOT_FOUND"},
+		{fmt.Errorf("invalid eslint JSON: %w", parseErr), "PARSE_ERROR"},
+		{exitNotInitialized(errorsCmd), "NOT_INITIALIZED"},
+		{errors.New(`unknown command "nope" for "agentlog"`), "UNKNOWN_COMMAND"},
+		{errors.New("something else"), "COMMAND_ERROR"},
+	}
+	for _, tt := range tests {
+		if got := errorCode(tt.err); got != tt.want {
+			t.Errorf("errorCode(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestExecute_JSONErrorsOnStderr(t *testing.T) {
+	defer func() { jsonOutput, errorsKind, pathOverride, errorsLimit = false, "", "", 10 }()
+	dir := t.TempDir()
+	os.Mkdir(dir+"/.agentlog", 0755)
+
+	tests := []struct {
+		args     []string
+		code     string
+		exitCode int
+	}{
+		{[]string{"errors", "--json", "--path", dir, "--kind", "bad"}, "INVALID_INPUT", ExitError},
+		{[]string{"errors", "--json", "--path", dir, "--limit", "ten"}, "INVALID_FLAG", ExitError},
+		{[]string{"errors", "--json", "--path", t.TempDir()}, "NOT_INITIALIZED", ExitNotInitialized},
+	}
+	for _, tt := range tests {
+		jsonOutput, errorsKind, errorsLimit = false, "", 10
+		stderr := new(bytes.Buffer)
+		errorsCmd.SetOut(new(bytes.Buffer))
+		errorsCmd.SetErr(stderr)
+		err := execute(tt.args, stderr)
+		if ExitCode(err) != tt.exitCode {
+			t.Errorf("%q: exit code %d, want %d (%v)", tt.args, ExitCode(err), tt.exitCode, err)
+		}
+		var got JSONError
+		if err := json.Unmarshal(stderr.Bytes(), &got); err != nil {
+			t.Errorf("%q: stderr isn't a JSON error: %q", tt.args, stderr.String())
+			continue
+		}
+		if got.Error.Code != tt.code || got.Error.Message == "" || got.Error.ExitCode != tt.exitCode {
+			t.Errorf("%q: error = %+v, want code %s", tt.args, got.Error, tt.code)
+		}
+	}
+
+	// Results that aren't failures write nothing to stderr
+	stderr := new(bytes.Buffer)
+	jsonOutput, errorsKind = false, ""
+	errorsCmd.SetOut(new(bytes.Buffer))
+	errorsCmd.SetErr(stderr)
+	if err := execute([]string{"errors", "--json", "--path", dir}, stderr); err != nil || stderr.Len() > 0 {
+		t.Errorf("success should leave stderr empty, got %v, %q", err, stderr.String())
+	}
+}
+
+func TestExecute_NotInitialized(t *testing.T) {
+	defer func() { jsonOutput, pathOverride = false, "" }()
+	defer resetResolveFlags()
+	defer resetSnoozeFlags()
+	rootCmd.SetOut(new(bytes.Buffer))
+	defer rootCmd.SetOut(nil)
+
+	for _, args := range [][]string{
+		{"show", "3f9a2c1b7e"},
+		{"digest"},
+		{"dedupe"},
+		{"resolve", "3f9a2c1b7e"},
+		{"resolve", "--list"},
+		{"similar", "3f9a2c1b7e"},
+		{"snooze", "3f9a2c1b7e", "--for", "2d"},
+		{"snooze", "3f9a2c1b7e", "--undo"},
+		{"snooze", "--list"},
+	} {
+		jsonOutput = false
+		stderr := new(bytes.Buffer)
+		err := execute(append(args, "--json", "--path", t.TempDir()), stderr)
+		if ExitCode(err) != ExitNotInitialized {
+			t.Errorf("%s: exit code %d, want %d (%v)", args[0], ExitCode(err), ExitNotInitialized, err)
+		}
+		var got JSONError
+		if err := json.Unmarshal(stderr.Bytes(), &got); err != nil || got.Error.Code != "NOT_INITIALIZED" {
+			t.Errorf("%s: stderr = %q, want a NOT_INITIALIZED error", args[0], stderr.String())
+		}
+	}
+}
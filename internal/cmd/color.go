@@ -0,0 +1,60 @@
+package cmd
+
+import "os"
+
+// ANSI escapes the theme uses
+const (
+	ansiReset  = "\033[0m"
+	ansiBold   = "\033[1m"
+	ansiDim    = "\033[2m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+)
+
+// theme colors human output: red for errors, yellow for warnings, green
+// for what's fine, and dim for timestamps and other secondary detail. Its
+// zero value adds no color, which is what pipes, JSON output, tests, and
+// NO_COLOR get.
+type theme struct {
+	on bool
+}
+
+// colors is the theme for stdout, set once flags are parsed
+var colors theme
+
+func (t theme) paint(code, s string) string {
+	if !t.on || s == "" {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+func (t theme) err(s string) string  { return t.paint(ansiRed, s) }
+func (t theme) warn(s string) string { return t.paint(ansiYellow, s) }
+func (t theme) ok(s string) string   { return t.paint(ansiGreen, s) }
+func (t theme) dim(s string) string  { return t.paint(ansiDim, s) }
+func (t theme) bold(s string) string { return t.paint(ansiBold, s) }
+
+// status colors s by a doctor check or health status
+func (t theme) status(status, s string) string {
+	switch status {
+	case "error", "unhealthy":
+		return t.err(s)
+	case "warning":
+		return t.warn(s)
+	case "ok", "healthy":
+		return t.ok(s)
+	}
+	return s
+}
+
+// colorEnabled reports whether output to f should be colored: f is a
+// terminal, TERM isn't "dumb", and neither --no-color nor NO_COLOR (any
+// non-empty value, per no-color.org) is set
+func colorEnabled(f *os.File) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isTerminal(f)
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTheme(t *testing.T) {
+	if got := (theme{}).err("boom"); got != "boom" {
+		t.Errorf("the zero theme shouldn't color, got %q", got)
+	}
+	on := theme{on: true}
+	if got := on.err("boom"); got != ansiRed+"boom"+ansiReset {
+		t.Errorf("err = %q, want red", got)
+	}
+	if got := on.status("warning", "[WARNING]"); got != ansiYellow+"[WARNING]"+ansiReset {
+		t.Errorf("status(warning) = %q, want yellow", got)
+	}
+	if got := on.dim(""); got != "" {
+		t.Errorf("empty text shouldn't get escapes, got %q", got)
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	defer func() { noColor = false }()
+	// Not terminals, whatever the settings
+	tmp, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tmp.Close()
+	if colorEnabled(tmp) {
+		t.Error("a file isn't a terminal")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if colorEnabled(os.Stdout) {
+		t.Error("NO_COLOR should disable color")
+	}
+	t.Setenv("NO_COLOR", "")
+	noColor = true
+	if colorEnabled(os.Stdout) {
+		t.Error("--no-color should disable color")
+	}
+}
+
+func TestColoredHumanOutput(t *testing.T) {
+	defer func() { colors = theme{} }()
+	entry := ErrorEntry{ID: "abc", Timestamp: "2024-01-01T00:00:00Z", Source: "backend", ErrorType: "DB_ERROR", Message: "timeout"}
+	result := HealthResult{Status: "unhealthy", Checks: []HealthCheck{
+		{Name: "Directory", Status: "error", Message: "missing"},
+		{Name: "File", Status: "ok", Message: "fine"},
+	}}
+
+	plainErrors, plainTail, plainDoctor := formatHuman([]ErrorEntry{entry}, 1), formatTailEntry(entry, false), formatHealthHuman(result)
+	for _, out := range []string{plainErrors, plainTail, plainDoctor} {
+		if strings.Contains(out, "\033[") {
+			t.Errorf("output should be plain without color:\n%q", out)
+		}
+	}
+
+	colors = theme{on: true}
+	if out := formatHuman([]ErrorEntry{entry}, 1); !strings.Contains(out, ansiRed+"Error:"+ansiReset) || !strings.Contains(out, ansiDim) {
+		t.Errorf("errors should mark the error red and dim the time:\n%q", out)
+	}
+	if out := formatTailEntry(entry, false); !strings.HasPrefix(out, ansiDim+"[") || !strings.Contains(out, ansiRed+"DB_ERROR") {
+		t.Errorf("tail should dim the timestamp and mark the type red:\n%q", out)
+	}
+	out := formatHealthHuman(result)
+	if !strings.Contains(out, ansiRed+"[ERROR]"+ansiReset) || !strings.Contains(out, ansiGreen+"[OK]"+ansiReset) || !strings.Contains(out, ansiRed+"UNHEALTHY") {
+		t.Errorf("doctor should color statuses:\n%q", out)
+	}
+	if strings.Contains(formatTailEntry(entry, true), "\033[") {
+		t.Error("JSON output should never be colored")
+	}
+}
+
+func TestPickerColorsKeepWidth(t *testing.T) {
+	p := newPicker(pickEntriesFixture)
+	plain := p.render(100, 10)
+	p.colors = theme{on: true}
+	colored := p.render(100, 10)
+	strip := strings.NewReplacer(ansiRed, "", ansiDim, "", ansiReset, "")
+	if strip.Replace(colored) != strip.Replace(plain) || colored == plain {
+		t.Errorf("colors should only add escapes:\n%q\n%q", plain, colored)
+	}
+}
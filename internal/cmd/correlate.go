@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// correlationWindow is how close in time errors from two sources must be
+// to count as coinciding when they share no correlation key
+const correlationWindow = 2 * time.Second
+
+// Correlation is a pair of error types from different sources that occur
+// together, by a shared correlation key or within correlationWindow
+type Correlation struct {
+	Source     string `json:"source"`
+	ErrorType  string `json:"error_type"`
+	Count      int    `json:"count"`
+	WithSource string `json:"with_source"`
+	WithType   string `json:"with_error_type"`
+	WithCount  int    `json:"with_count"`
+	Endpoint   string `json:"endpoint,omitempty"`
+	SharedKey  string `json:"shared_key,omitempty"` // request_id, trace_id, or session_id; empty for time matches
+}
+
+// String describes the correlation as one sentence, e.g. "8 frontend
+// NETWORK_ERRORs coincide with backend DATABASE_ERRORs on /api/users"
+func (c Correlation) String() string {
+	s := fmt.Sprintf("%d %s %s coincide with %s %s", c.Count, c.Source, pluralType(c.ErrorType, c.Count), c.WithSource, pluralType(c.WithType, c.WithCount))
+	if c.Count == 1 {
+		s = strings.Replace(s, " coincide ", " coincides ", 1)
+	}
+	if c.Endpoint != "" {
+		s += " on " + c.Endpoint
+	}
+	if c.SharedKey != "" {
+		s += fmt.Sprintf(" (shared %s)", c.SharedKey)
+	}
+	return s
+}
+
+func pluralType(errorType string, n int) string {
+	if n == 1 {
+		return errorType
+	}
+	return errorType + "s"
+}
+
+// correlationSide tracks the distinct entries on each side of a pair
+type correlationSide struct {
+	corr  Correlation
+	left  map[int]bool
+	right map[int]bool
+}
+
+// correlate finds error types from different sources that share request,
+// trace, or session IDs, or happen within correlationWindow of each other
+// on the same endpoint. Time matches need two or more entries, since a
+// single coincidence proves little. Returns at most n, most entries first.
+func correlate(entries []ErrorEntry, n int) []Correlation {
+	pairs := make(map[string]*correlationSide)
+	var order []string
+	link := func(i, j int, key string) {
+		a, b := entries[i], entries[j]
+		if a.Source == b.Source || a.Source == "" || b.Source == "" {
+			return
+		}
+		if sourceRank(b.Source) < sourceRank(a.Source) || (sourceRank(b.Source) == sourceRank(a.Source) && b.Source < a.Source) {
+			a, b, i, j = b, a, j, i
+		}
+		endpoint := correlationEndpoint(b)
+		if endpoint == "" {
+			endpoint = correlationEndpoint(a)
+		}
+		id := strings.Join([]string{a.Source, a.ErrorType, b.Source, b.ErrorType, endpoint, key}, "\x00")
+		p, ok := pairs[id]
+		if !ok {
+			p = &correlationSide{
+				corr:  Correlation{Source: a.Source, ErrorType: a.ErrorType, WithSource: b.Source, WithType: b.ErrorType, Endpoint: endpoint, SharedKey: key},
+				left:  make(map[int]bool),
+				right: make(map[int]bool),
+			}
+			pairs[id] = p
+			order = append(order, id)
+		}
+		p.left[i] = true
+		p.right[j] = true
+	}
+
+	// Shared correlation keys
+	keyed := make(map[[2]int]bool)
+	for _, key := range correlationKeys {
+		byValue := make(map[string][]int)
+		for i, e := range entries {
+			if v, ok := e.Context[key]; ok && v != nil {
+				byValue[formatCell(v)] = append(byValue[formatCell(v)], i)
+			}
+		}
+		for _, idx := range byValue {
+			for x := 0; x < len(idx); x++ {
+				for y := x + 1; y < len(idx); y++ {
+					if entries[idx[x]].Source != entries[idx[y]].Source {
+						keyed[[2]int{idx[x], idx[y]}] = true
+						link(idx[x], idx[y], key)
+					}
+				}
+			}
+		}
+	}
+
+	// Tight time windows, for entries not already tied by a key
+	type timed struct {
+		i  int
+		ts time.Time
+	}
+	var byTime []timed
+	for i, e := range entries {
+		if ts, err := parseEntryTime(e.Timestamp); err == nil {
+			byTime = append(byTime, timed{i, ts})
+		}
+	}
+	sort.SliceStable(byTime, func(x, y int) bool { return byTime[x].ts.Before(byTime[y].ts) })
+	for x := range byTime {
+		for y := x + 1; y < len(byTime) && byTime[y].ts.Sub(byTime[x].ts) <= correlationWindow; y++ {
+			i, j := byTime[x].i, byTime[y].i
+			if keyed[[2]int{i, j}] || keyed[[2]int{j, i}] {
+				continue
+			}
+			ea, eb := correlationEndpoint(entries[i]), correlationEndpoint(entries[j])
+			if ea != "" && eb != "" && ea != eb {
+				continue
+			}
+			link(i, j, "")
+		}
+	}
+
+	var result []Correlation
+	for _, id := range order {
+		p := pairs[id]
+		c := p.corr
+		for i := range p.left {
+			c.Count += entries[i].occurrences()
+		}
+		for j := range p.right {
+			c.WithCount += entries[j].occurrences()
+		}
+		if c.SharedKey == "" && (c.Count < 2 || c.WithCount < 2) {
+			continue
+		}
+		result = append(result, c)
+	}
+	sort.SliceStable(result, func(x, y int) bool {
+		if result[x].Count+result[x].WithCount != result[y].Count+result[y].WithCount {
+			return result[x].Count+result[x].WithCount > result[y].Count+result[y].WithCount
+		}
+		return result[x].SharedKey != "" && result[y].SharedKey == ""
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
+
+// sourceRank puts the frontend first, so sentences read from symptom to
+// cause ("frontend NETWORK_ERRORs coincide with backend DATABASE_ERRORs")
+func sourceRank(source string) int {
+	if source == "frontend" {
+		return 0
+	}
+	return 1
+}
+
+// correlationEndpoint is the path an entry concerns, without its method:
+// a network error's request URL, otherwise its endpoint
+func correlationEndpoint(e ErrorEntry) string {
+	if raw, ok := e.Context["url"].(string); ok {
+		if u, err := url.Parse(raw); err == nil && u.Path != "" {
+			return entryEndpoint(ErrorEntry{Endpoint: u.Path})
+		}
+	}
+	ep := entryEndpoint(e)
+	if _, path, found := strings.Cut(ep, " "); found {
+		return path
+	}
+	return ep
+}
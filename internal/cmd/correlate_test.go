@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCorrelate_TimeWindow(t *testing.T) {
+	var entries []ErrorEntry
+	for _, ts := range []string{"2024-01-01T10:00:00Z", "2024-01-01T10:05:00Z", "2024-01-01T10:09:00Z"} {
+		entries = append(entries,
+			ErrorEntry{Timestamp: ts, Source: "backend", ErrorType: "DATABASE_ERROR", Message: "connection refused", Endpoint: "GET /api/users"},
+			ErrorEntry{Timestamp: strings.Replace(ts, ":00Z", ":01Z", 1), Source: "frontend", ErrorType: "NETWORK_ERROR", Message: "HTTP 500", Context: map[string]interface{}{"url": "http://localhost:3000/api/users?page=2"}},
+		)
+	}
+	// Same second, but a different endpoint: not correlated
+	entries = append(entries, ErrorEntry{Timestamp: "2024-01-01T10:00:01Z", Source: "frontend", ErrorType: "NETWORK_ERROR", Message: "HTTP 500", Context: map[string]interface{}{"url": "http://localhost:3000/api/orders"}})
+	// Far away in time: not correlated
+	entries = append(entries, ErrorEntry{Timestamp: "2024-01-01T12:00:00Z", Source: "frontend", ErrorType: "UNCAUGHT_ERROR", Message: "x"})
+
+	got := correlate(entries, 3)
+	if len(got) != 1 {
+		t.Fatalf("expected 1 correlation, got %+v", got)
+	}
+	want := "3 frontend NETWORK_ERRORs coincide with backend DATABASE_ERRORs on /api/users"
+	if got[0].String() != want {
+		t.Errorf("String() = %q, want %q", got[0].String(), want)
+	}
+}
+
+func TestCorrelate_SharedRequestID(t *testing.T) {
+	entries := []ErrorEntry{
+		{Timestamp: "2024-01-01T10:00:00Z", Source: "frontend", ErrorType: "UNCAUGHT_ERROR", Message: "Cannot read property 'id'", Context: map[string]interface{}{"request_id": "req-1"}},
+		{Timestamp: "2024-01-01T10:00:30Z", Source: "backend", ErrorType: "VALIDATION_ERROR", Message: "missing id", Endpoint: "/api/cart", Context: map[string]interface{}{"request_id": "req-1"}},
+		// A single time coincidence isn't enough
+		{Timestamp: "2024-01-01T11:00:00Z", Source: "frontend", ErrorType: "RENDER_ERROR", Message: "boom"},
+		{Timestamp: "2024-01-01T11:00:01Z", Source: "worker", ErrorType: "JOB_ERROR", Message: "failed"},
+	}
+
+	got := correlate(entries, 3)
+	if len(got) != 1 {
+		t.Fatalf("expected 1 correlation, got %+v", got)
+	}
+	want := "1 frontend UNCAUGHT_ERROR coincides with backend VALIDATION_ERROR on /api/cart (shared request_id)"
+	if got[0].String() != want {
+		t.Errorf("String() = %q, want %q", got[0].String(), want)
+	}
+}
+
+func TestCorrelate_SameSourceIgnored(t *testing.T) {
+	entries := []ErrorEntry{
+		{Timestamp: "2024-01-01T10:00:00Z", Source: "backend", ErrorType: "DATABASE_ERROR", Message: "a", Context: map[string]interface{}{"request_id": "r"}},
+		{Timestamp: "2024-01-01T10:00:00Z", Source: "backend", ErrorType: "REQUEST_ERROR", Message: "b", Context: map[string]interface{}{"request_id": "r"}},
+	}
+	if got := correlate(entries, 3); len(got) != 0 {
+		t.Errorf("entries from one source should not correlate, got %+v", got)
+	}
+}
+
+func TestFormatPrimeSummaryHuman_Correlations(t *testing.T) {
+	summary := PrimeSummary{
+		TotalErrors:   6,
+		TopErrorTypes: []ErrorTypeCount{{ErrorType: "NETWORK_ERROR", Count: 3}},
+		TopSources:    []SourceCount{{Source: "frontend", Count: 3}},
+		Correlations:  []Correlation{{Source: "frontend", ErrorType: "NETWORK_ERROR", Count: 3, WithSource: "backend", WithType: "DATABASE_ERROR", WithCount: 3, Endpoint: "/api/users"}},
+	}
+	out := formatPrimeSummaryHuman(summary)
+	if !strings.Contains(out, "  Correlated: 3 frontend NETWORK_ERRORs coincide with backend DATABASE_ERRORs on /api/users\n") {
+		t.Errorf("expected correlation line, got:\n%s", out)
+	}
+	if out := formatPrimeClaude(summary); !strings.Contains(out, "look at the backend DATABASE_ERROR first") {
+		t.Errorf("claude preset should point at the likely cause, got:\n%s", out)
+	}
+}
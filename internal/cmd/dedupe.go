@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/agentlog/agentlog/internal/logfile"
+	"github.com/agentlog/agentlog/internal/self"
+	"github.com/spf13/cobra"
+)
+
+var dedupeDryRun bool
+
+// DedupeResult reports what agentlog dedupe did (or would do)
+type DedupeResult struct {
+	EntriesBefore int   `json:"entries_before"`
+	EntriesAfter  int   `json:"entries_after"`
+	BytesBefore   int64 `json:"bytes_before"`
+	BytesAfter    int64 `json:"bytes_after"`
+	DryRun        bool  `json:"dry_run,omitempty"`
+	NoLogFile     bool  `json:"no_log_file,omitempty"`
+}
+
+// dedupeCmd represents the dedupe command
+var dedupeCmd = &cobra.Command{
+	Use:   "dedupe",
+	Short: "Compact errors.jsonl by collapsing runs of repeated errors",
+	Long: `Rewrite .agentlog/errors.jsonl, collapsing consecutive entries with the same
+fingerprint (error type and normalized message, as in errors --group) from
+the same source, project, environment, host, user, agent, file, endpoint,
+and kind into one entry.
+
+The collapsed entry keeps the most recent occurrence's fields and records
+"count", "first_seen", and "last_seen". A collapsed perf entry keeps the
+slowest run's "duration_ms". errors, stats, prime, and digest
+count it once per occurrence, so totals don't change.
+
+Lines that aren't valid entries are kept as they are. Entries appended
+while dedupe runs are preserved. The compacted log is written to a temp
+file and renamed over errors.jsonl, so a crash mid-rewrite leaves the
+original intact.
+
+Examples:
+  agentlog dedupe             # Compact the log file
+  agentlog dedupe --dry-run   # Report what would be collapsed
+  agentlog dedupe --json`,
+	RunE: runDedupe,
+}
+
+func init() {
+	rootCmd.AddCommand(dedupeCmd)
+
+	dedupeCmd.Flags().BoolVar(&dedupeDryRun, "dry-run", false, "Report what would be collapsed without rewriting the file")
+}
+
+func runDedupe(cmd *cobra.Command, args []string) error {
+	// Determine base directory (use --path override or cwd)
+	baseDir := GetPathOverride()
+	if baseDir == "" {
+		var err error
+		baseDir, err = os.Getwd()
+		if err != nil {
+			self.LogError(".", "GETWD_ERROR", err.Error())
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+
+	result, err := dedupeFile(GetErrorsPath(baseDir), dedupeDryRun)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			self.LogError(baseDir, "FILE_WRITE_ERROR", err.Error())
+			return err
+		}
+		result = DedupeResult{NoLogFile: true}
+	}
+
+	if IsJSONOutput() {
+		output, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Fprintln(cmd.OutOrStdout(), string(output))
+	} else {
+		fmt.Fprint(cmd.OutOrStdout(), formatDedupeHuman(result))
+	}
+	if result.NoLogFile && uninitialized(baseDir) {
+		return exitNotInitialized(cmd)
+	}
+	return nil
+}
+
+// dedupeLine is one line of errors.jsonl: a parsed entry, or raw text that
+// didn't parse and is written back unchanged
+type dedupeLine struct {
+	entry *ErrorEntry
+	raw   string
+}
+
+// dedupeFile collapses runs of repeated entries in the file at path. Only
+// complete lines are rewritten; anything appended after the file was read
+// is copied to the new file before it replaces the old one.
+func dedupeFile(path string, dryRun bool) (DedupeResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return DedupeResult{}, err
+	}
+	// A writer may be midway through a line; leave it for the next run
+	data = data[:bytes.LastIndexByte(data, '\n')+1]
+
+	var lines []dedupeLine
+	before := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var e ErrorEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			lines = append(lines, dedupeLine{raw: line})
+			continue
+		}
+		before++
+		lines = append(lines, dedupeLine{entry: &e})
+	}
+
+	lines = collapseRuns(lines)
+
+	var out bytes.Buffer
+	after := 0
+	for _, l := range lines {
+		if l.entry == nil {
+			out.WriteString(l.raw + "\n")
+			continue
+		}
+		after++
+		l.entry.ID = ""
+		encoded, err := json.Marshal(l.entry)
+		if err != nil {
+			return DedupeResult{}, fmt.Errorf("failed to encode entry: %w", err)
+		}
+		out.Write(encoded)
+		out.WriteString("\n")
+	}
+
+	result := DedupeResult{
+		EntriesBefore: before,
+		EntriesAfter:  after,
+		BytesBefore:   int64(len(data)),
+		BytesAfter:    int64(out.Len()),
+		DryRun:        dryRun,
+	}
+	if dryRun || after == before {
+		return result, nil
+	}
+	return result, logfile.Replace(path, out.Bytes(), int64(len(data)))
+}
+
+// collapseRuns merges each entry into the one before it when both share a
+// dedupe key
+func collapseRuns(lines []dedupeLine) []dedupeLine {
+	var out []dedupeLine
+	prevKey := ""
+	for _, l := range lines {
+		if l.entry == nil {
+			out = append(out, l)
+			prevKey = ""
+			continue
+		}
+		key := dedupeKey(*l.entry)
+		if key == prevKey {
+			last := out[len(out)-1].entry
+			merged := mergeRepeat(*last, *l.entry)
+			out[len(out)-1].entry = &merged
+			continue
+		}
+		out = append(out, l)
+		prevKey = key
+	}
+	return out
+}
+
+// dedupeKey identifies entries that are repeats of one another. Where an
+// error happened is part of it, since merging keeps only the later entry's
+// file and endpoint.
+func dedupeKey(e ErrorEntry) string {
+	return strings.Join([]string{
+		fingerprint(e.ErrorType, normalizeMessage(e.Message)),
+		e.Source, e.Project, e.Environment, e.Host, e.User, e.Agent,
+		e.File, e.Endpoint, e.kind(),
+	}, "\x00")
+}
+
+// mergeRepeat combines a repeated entry into the earlier one, keeping the
+// later entry's fields and widening the seen range. Perf entries keep the
+// slowest duration so a fast final run doesn't hide an earlier slow one.
+func mergeRepeat(earlier, later ErrorEntry) ErrorEntry {
+	first, last := earlier.firstSeen(), later.lastSeen()
+	if timestampBefore(later.firstSeen(), first) {
+		first = later.firstSeen()
+	}
+	if timestampBefore(last, earlier.lastSeen()) {
+		last = earlier.lastSeen()
+	}
+
+	merged := later
+	merged.Count = earlier.occurrences() + later.occurrences()
+	merged.FirstSeen = first
+	merged.LastSeen = last
+	merged.Timestamp = last
+	if earlier.DurationMs > merged.DurationMs {
+		merged.DurationMs = earlier.DurationMs
+	}
+	return merged
+}
+
+func (e ErrorEntry) firstSeen() string {
+	if e.FirstSeen != "" {
+		return e.FirstSeen
+	}
+	return e.Timestamp
+}
+
+func (e ErrorEntry) lastSeen() string {
+	if e.LastSeen != "" {
+		return e.LastSeen
+	}
+	return e.Timestamp
+}
+
+// formatDedupeHuman formats a dedupe result for human-readable output
+func formatDedupeHuman(r DedupeResult) string {
+	if r.NoLogFile {
+		return "No errors file found. Run 'agentlog init' to set up.\n"
+	}
+	if r.EntriesAfter == r.EntriesBefore {
+		return fmt.Sprintf("Nothing to collapse (%d entries).\n", r.EntriesBefore)
+	}
+
+	verb := "Collapsed"
+	if r.DryRun {
+		verb = "Would collapse"
+	}
+	return fmt.Sprintf("%s %d entries into %d (%s -> %s)\n",
+		verb, r.EntriesBefore, r.EntriesAfter, formatBytes(r.BytesBefore), formatBytes(r.BytesAfter))
+}
+
+// formatBytes renders a file size with a binary unit
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fKB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%dB", n)
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+const dedupeLog = `{"timestamp":"2025-12-10T19:00:00Z","source":"backend","error_type":"NETWORK_ERROR","message":"timeout after 3001ms"}
+{"timestamp":"2025-12-10T19:00:05Z","source":"backend","error_type":"NETWORK_ERROR","message":"timeout after 2999ms"}
+{"timestamp":"2025-12-10T19:00:10Z","source":"backend","error_type":"NETWORK_ERROR","message":"timeout after 3004ms"}
+{"timestamp":"2025-12-10T19:00:11Z","source":"frontend","error_type":"NETWORK_ERROR","message":"timeout after 3004ms"}
+not json
+{"timestamp":"2025-12-10T19:00:12Z","source":"frontend","error_type":"NETWORK_ERROR","message":"timeout after 10ms"}
+{"timestamp":"2025-12-10T19:00:20Z","source":"backend","error_type":"NETWORK_ERROR","message":"timeout after 3000ms"}
+`
+
+func TestDedupeFile(t *testing.T) {
+	dir := writeTestLog(t, t.TempDir(), dedupeLog)
+
+	result, err := dedupeFile(GetErrorsPath(dir), false)
+	if err != nil {
+		t.Fatalf("dedupeFile() error = %v", err)
+	}
+	if result.EntriesBefore != 6 || result.EntriesAfter != 4 || result.BytesAfter >= result.BytesBefore {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	data, _ := os.ReadFile(GetErrorsPath(dir))
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 5 || lines[2] != "not json" {
+		t.Fatalf("malformed lines should be kept in place, got:\n%s", data)
+	}
+
+	entries, _ := readErrors(dir)
+	first := entries[0]
+	if first.Count != 3 || first.FirstSeen != "2025-12-10T19:00:00Z" || first.LastSeen != "2025-12-10T19:00:10Z" {
+		t.Errorf("run should collapse with count and seen range, got %+v", first)
+	}
+	if first.Timestamp != first.LastSeen || first.Message != "timeout after 3004ms" {
+		t.Errorf("collapsed entry should keep the latest occurrence, got %+v", first)
+	}
+	if entries[1].Count != 0 || entries[1].Source != "frontend" {
+		t.Errorf("a different source should break the run, got %+v", entries[1])
+	}
+	if entries[3].Count != 0 {
+		t.Errorf("the malformed line should break the run, got %+v", entries[3])
+	}
+	if total := totalOccurrences(entries); total != 6 {
+		t.Errorf("occurrences should be preserved, got %d", total)
+	}
+
+	// A second pass has nothing left to do
+	again, err := dedupeFile(GetErrorsPath(dir), false)
+	if err != nil || again.EntriesBefore != again.EntriesAfter {
+		t.Errorf("second pass should be a no-op, got %+v, %v", again, err)
+	}
+}
+
+func TestDedupeFile_DryRun(t *testing.T) {
+	dir := writeTestLog(t, t.TempDir(), dedupeLog)
+
+	result, err := dedupeFile(GetErrorsPath(dir), true)
+	if err != nil {
+		t.Fatalf("dedupeFile() error = %v", err)
+	}
+	if !result.DryRun || result.EntriesAfter != 4 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	data, _ := os.ReadFile(GetErrorsPath(dir))
+	if string(data) != dedupeLog {
+		t.Error("--dry-run should leave the file untouched")
+	}
+}
+
+func TestDedupeFile_KeepsPartialLine(t *testing.T) {
+	partial := `{"timestamp":"2025-12-10T19:00:30Z","source":"back`
+	dir := writeTestLog(t, t.TempDir(), dedupeLog+partial)
+
+	if _, err := dedupeFile(GetErrorsPath(dir), false); err != nil {
+		t.Fatalf("dedupeFile() error = %v", err)
+	}
+	data, _ := os.ReadFile(GetErrorsPath(dir))
+	if !strings.HasSuffix(string(data), "\n"+partial) {
+		t.Errorf("an unfinished line should be carried over, got:\n%s", data)
+	}
+}
+
+func TestMergeRepeat_AlreadyCollapsed(t *testing.T) {
+	earlier := ErrorEntry{Timestamp: "2025-12-10T19:05:00Z", Count: 4, FirstSeen: "2025-12-10T19:00:00Z", LastSeen: "2025-12-10T19:05:00Z"}
+	later := ErrorEntry{Timestamp: "2025-12-10T19:06:00Z"}
+
+	merged := mergeRepeat(earlier, later)
+	if merged.Count != 5 || merged.FirstSeen != "2025-12-10T19:00:00Z" || merged.LastSeen != "2025-12-10T19:06:00Z" {
+		t.Errorf("mergeRepeat() = %+v", merged)
+	}
+}
+
+func TestCollapseRuns_PerfKeepsSlowest(t *testing.T) {
+	perf := func(ts string, ms float64) *ErrorEntry {
+		return &ErrorEntry{Timestamp: ts, Source: "backend", ErrorType: "SLOW_QUERY", Message: "select users", Kind: kindPerf, DurationMs: ms}
+	}
+	lines := collapseRuns([]dedupeLine{
+		{entry: perf("2025-12-10T19:00:00Z", 120)},
+		{entry: perf("2025-12-10T19:00:05Z", 4800)},
+		{entry: perf("2025-12-10T19:00:10Z", 95)},
+	})
+	if len(lines) != 1 {
+		t.Fatalf("the perf run should collapse, got %d lines", len(lines))
+	}
+	if got := lines[0].entry; got.occurrences() != 3 || got.DurationMs != 4800 {
+		t.Errorf("collapsed perf entry should keep the slowest duration, got count %d, %vms", got.occurrences(), got.DurationMs)
+	}
+}
+
+func TestCollapseRuns_KeepsLocationsApart(t *testing.T) {
+	entry := func(endpoint, file, kind string) *ErrorEntry {
+		return &ErrorEntry{Timestamp: "2025-12-10T19:00:00Z", Source: "backend", ErrorType: "NETWORK_ERROR", Message: "timeout", Endpoint: endpoint, File: file, Kind: kind}
+	}
+	lines := collapseRuns([]dedupeLine{
+		{entry: entry("/api/users", "", "")},
+		{entry: entry("/api/orders", "", "")},
+		{entry: entry("/api/orders", "", "")},
+		{entry: entry("/api/orders", "client.go", "")},
+		{entry: entry("/api/orders", "client.go", kindPerf)},
+	})
+	if len(lines) != 4 || lines[1].entry.occurrences() != 2 {
+		t.Errorf("only the repeats at /api/orders should merge, got %d lines", len(lines))
+	}
+}
+
+func TestDedupeCommand_JSON(t *testing.T) {
+	dir := writeTestLog(t, t.TempDir(), dedupeLog)
+
+	originalPath := pathOverride
+	defer func() {
+		pathOverride = originalPath
+		jsonOutput = false
+		dedupeDryRun = false
+	}()
+	pathOverride, jsonOutput, dedupeDryRun = dir, true, true
+
+	buf := new(bytes.Buffer)
+	dedupeCmd.SetOut(buf)
+	if err := runDedupe(dedupeCmd, nil); err != nil {
+		t.Fatalf("runDedupe() error = %v", err)
+	}
+	var r DedupeResult
+	if err := json.Unmarshal(buf.Bytes(), &r); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if r.EntriesBefore != 6 || r.EntriesAfter != 4 || !r.DryRun {
+		t.Errorf("unexpected result: %+v", r)
+	}
+
+	pathOverride, jsonOutput = t.TempDir(), false
+	buf.Reset()
+	if err := runDedupe(dedupeCmd, nil); ExitCode(err) != ExitNotInitialized {
+		t.Fatalf("runDedupe() without .agentlog/ = %v, want exit %d", err, ExitNotInitialized)
+	}
+	if !strings.Contains(buf.String(), "No errors file found") {
+		t.Errorf("missing log should be reported, got %q", buf.String())
+	}
+}
+
+func TestFormatDedupeHuman(t *testing.T) {
+	got := formatDedupeHuman(DedupeResult{EntriesBefore: 120, EntriesAfter: 8, BytesBefore: 49357, BytesAfter: 3174})
+	if got != "Collapsed 120 entries into 8 (48.2KB -> 3.1KB)\n" {
+		t.Errorf("formatDedupeHuman() = %q", got)
+	}
+}
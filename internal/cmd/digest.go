@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/self"
+	"github.com/spf13/cobra"
+)
+
+var digestHours int
+
+// DigestReport summarizes a time window against the window before it
+type DigestReport struct {
+	WindowHours   int             `json:"window_hours"`
+	Start         string          `json:"start"`
+	End           string          `json:"end"`
+	TotalErrors   int             `json:"total_errors"`
+	PreviousTotal int             `json:"previous_total"`
+	Sources       []SourceCount   `json:"sources"`
+	NewTypes      []DigestType    `json:"new_types"`
+	Movers        []DigestMover   `json:"movers"`
+	Quiet         []DigestType    `json:"quiet"`
+	TopFiles      []LocationCount `json:"top_files"`
+	TopEndpoints  []LocationCount `json:"top_endpoints"`
+	NoLogFile     bool            `json:"no_log_file,omitempty"`
+}
+
+// DigestType is an error type with its count and a sample message
+type DigestType struct {
+	ErrorType string `json:"error_type"`
+	Count     int    `json:"count"`
+	Sample    string `json:"sample"`
+}
+
+// DigestMover is an error type whose count changed between windows
+type DigestMover struct {
+	ErrorType string `json:"error_type"`
+	Count     int    `json:"count"`
+	Previous  int    `json:"previous"`
+	Delta     int    `json:"delta"`
+}
+
+// digestCmd represents the digest command
+var digestCmd = &cobra.Command{
+	Use:   "digest",
+	Short: "Summarize recent errors for standup notes",
+	Long: `Summarize the last N hours of errors for humans, compared with the N hours
+before that:
+  - New error types (never seen before the window)
+  - Biggest movers (types whose count changed the most)
+  - Gone quiet (types seen in the previous window but not this one)
+  - Noisiest files and endpoints
+
+Output is Markdown, ready to paste into standup notes. For a terse summary
+aimed at AI agents, use 'agentlog prime'.
+
+Examples:
+  agentlog digest              # Last 24 hours
+  agentlog digest --hours 72   # Since Friday
+  agentlog digest --json`,
+	RunE: runDigest,
+}
+
+func init() {
+	rootCmd.AddCommand(digestCmd)
+
+	digestCmd.Flags().IntVar(&digestHours, "hours", 24, "Size of the window to summarize, in hours")
+}
+
+func runDigest(cmd *cobra.Command, args []string) error {
+	// Determine base directory (use --path override or cwd)
+	baseDir := GetPathOverride()
+	if baseDir == "" {
+		var err error
+		baseDir, err = os.Getwd()
+		if err != nil {
+			self.LogError(".", "GETWD_ERROR", err.Error())
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+
+	if digestHours <= 0 {
+		return invalidInput("--hours must be positive")
+	}
+
+	var report DigestReport
+	entries, err := readErrors(baseDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		report = DigestReport{WindowHours: digestHours, NoLogFile: true}
+	} else {
+		report = generateDigest(filterKind(entries, kindError), time.Now().UTC(), time.Duration(digestHours)*time.Hour)
+	}
+
+	if IsJSONOutput() {
+		output, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Fprintln(cmd.OutOrStdout(), string(output))
+	} else {
+		fmt.Fprint(cmd.OutOrStdout(), formatDigestHuman(report))
+	}
+	if report.NoLogFile && uninitialized(baseDir) {
+		return exitNotInitialized(cmd)
+	}
+	return nil
+}
+
+// generateDigest compares entries in (now-window, now] with the window
+// before it
+func generateDigest(entries []ErrorEntry, now time.Time, window time.Duration) DigestReport {
+	start := now.Add(-window)
+	prevStart := start.Add(-window)
+
+	report := DigestReport{
+		WindowHours: int(window.Hours()),
+		Start:       start.Format(time.RFC3339),
+		End:         now.Format(time.RFC3339),
+	}
+
+	current := make(map[string]int)
+	previous := make(map[string]int)
+	seenBefore := make(map[string]bool)
+	samples := make(map[string]string)
+	prevSamples := make(map[string]string)
+	sources := make(map[string]int)
+	var inWindow []ErrorEntry
+
+	for _, e := range entries {
+		ts, err := parseEntryTime(e.Timestamp)
+		if err != nil || ts.After(now) {
+			continue
+		}
+
+		if !ts.After(start) {
+			seenBefore[e.ErrorType] = true
+			if ts.After(prevStart) {
+				previous[e.ErrorType] += e.occurrences()
+				prevSamples[e.ErrorType] = e.Message
+				report.PreviousTotal += e.occurrences()
+			}
+			continue
+		}
+
+		report.TotalErrors += e.occurrences()
+		current[e.ErrorType] += e.occurrences()
+		samples[e.ErrorType] = e.Message
+		sources[e.Source] += e.occurrences()
+		inWindow = append(inWindow, e)
+	}
+
+	for t, n := range current {
+		if !seenBefore[t] {
+			report.NewTypes = append(report.NewTypes, DigestType{ErrorType: t, Count: n, Sample: samples[t]})
+		} else if delta := n - previous[t]; delta != 0 {
+			report.Movers = append(report.Movers, DigestMover{ErrorType: t, Count: n, Previous: previous[t], Delta: delta})
+		}
+	}
+	for t, n := range previous {
+		if current[t] == 0 {
+			report.Quiet = append(report.Quiet, DigestType{ErrorType: t, Count: n, Sample: prevSamples[t]})
+		}
+	}
+
+	sortDigestTypes(report.NewTypes)
+	sortDigestTypes(report.Quiet)
+	sort.Slice(report.Movers, func(i, j int) bool {
+		if abs(report.Movers[i].Delta) != abs(report.Movers[j].Delta) {
+			return abs(report.Movers[i].Delta) > abs(report.Movers[j].Delta)
+		}
+		return report.Movers[i].ErrorType < report.Movers[j].ErrorType
+	})
+	if len(report.Movers) > 5 {
+		report.Movers = report.Movers[:5]
+	}
+
+	report.Sources = topNSources(sources, len(sources))
+	files, endpoints := locationCounts(inWindow)
+	report.TopFiles = topLocations(files, 5)
+	report.TopEndpoints = topLocations(endpoints, 5)
+
+	return report
+}
+
+// sortDigestTypes orders types by count, then name
+func sortDigestTypes(types []DigestType) {
+	sort.Slice(types, func(i, j int) bool {
+		if types[i].Count != types[j].Count {
+			return types[i].Count > types[j].Count
+		}
+		return types[i].ErrorType < types[j].ErrorType
+	})
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// formatDigestHuman returns the digest as Markdown
+func formatDigestHuman(r DigestReport) string {
+	var sb strings.Builder
+
+	if r.NoLogFile {
+		sb.WriteString("No errors file found. Run 'agentlog init' to set up.\n")
+		return sb.String()
+	}
+
+	start, _ := time.Parse(time.RFC3339, r.Start)
+	end, _ := time.Parse(time.RFC3339, r.End)
+	sb.WriteString(fmt.Sprintf("## agentlog digest: last %dh (%s to %s UTC)\n\n",
+		r.WindowHours, start.Format("Jan 2 15:04"), end.Format("Jan 2 15:04")))
+
+	if r.TotalErrors == 0 && r.PreviousTotal == 0 {
+		sb.WriteString("No errors in this period.\n")
+		return sb.String()
+	}
+
+	sb.WriteString(fmt.Sprintf("**%d errors** (%s vs previous %dh)", r.TotalErrors, signed(r.TotalErrors-r.PreviousTotal), r.WindowHours))
+	if len(r.Sources) > 0 {
+		var parts []string
+		for _, s := range r.Sources {
+			parts = append(parts, fmt.Sprintf("%s %d", s.Source, s.Count))
+		}
+		sb.WriteString(": " + strings.Join(parts, ", "))
+	}
+	sb.WriteString("\n")
+
+	if len(r.NewTypes) > 0 {
+		sb.WriteString("\n**New**\n")
+		for _, t := range r.NewTypes {
+			sb.WriteString(fmt.Sprintf("- %s (%d): %s\n", t.ErrorType, t.Count, truncate(t.Sample, 100)))
+		}
+	}
+
+	if len(r.Movers) > 0 {
+		sb.WriteString("\n**Biggest movers**\n")
+		for _, m := range r.Movers {
+			sb.WriteString(fmt.Sprintf("- %s: %d -> %d (%s)\n", m.ErrorType, m.Previous, m.Count, signed(m.Delta)))
+		}
+	}
+
+	if len(r.Quiet) > 0 {
+		sb.WriteString("\n**Gone quiet**\n")
+		for _, t := range r.Quiet {
+			sb.WriteString(fmt.Sprintf("- %s (%d in previous %dh)\n", t.ErrorType, t.Count, r.WindowHours))
+		}
+	}
+
+	if len(r.TopFiles) > 0 {
+		sb.WriteString("\n**Noisiest files**\n")
+		for _, l := range r.TopFiles {
+			sb.WriteString(fmt.Sprintf("- %s (%d)\n", l.Name, l.Count))
+		}
+	}
+
+	if len(r.TopEndpoints) > 0 {
+		sb.WriteString("\n**Noisiest endpoints**\n")
+		for _, l := range r.TopEndpoints {
+			sb.WriteString(fmt.Sprintf("- %s (%d)\n", l.Name, l.Count))
+		}
+	}
+
+	return sb.String()
+}
+
+// signed formats n with an explicit sign
+func signed(n int) string {
+	if n > 0 {
+		return fmt.Sprintf("+%d", n)
+	}
+	return fmt.Sprintf("%d", n)
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func digestFixture(now time.Time) []ErrorEntry {
+	at := func(ago time.Duration) string { return now.Add(-ago).Format(time.RFC3339) }
+	return []ErrorEntry{
+		// Older history
+		{Timestamp: at(100 * time.Hour), Source: "backend", ErrorType: "DATABASE_ERROR", Message: "connection refused"},
+		// Previous window
+		{Timestamp: at(30 * time.Hour), Source: "backend", ErrorType: "DATABASE_ERROR", Message: "connection refused"},
+		{Timestamp: at(30 * time.Hour), Source: "frontend", ErrorType: "NETWORK_ERROR", Message: "fetch failed"},
+		{Timestamp: at(40 * time.Hour), Source: "frontend", ErrorType: "RENDER_ERROR", Message: "hydration mismatch"},
+		// Current window
+		{Timestamp: at(2 * time.Hour), Source: "frontend", ErrorType: "NETWORK_ERROR", Message: "fetch failed", Endpoint: "/api/users"},
+		{Timestamp: at(3 * time.Hour), Source: "frontend", ErrorType: "NETWORK_ERROR", Message: "fetch failed", Endpoint: "/api/users"},
+		{Timestamp: at(4 * time.Hour), Source: "frontend", ErrorType: "NETWORK_ERROR", Message: "fetch failed", Endpoint: "/api/orders"},
+		{Timestamp: at(5 * time.Hour), Source: "frontend", ErrorType: "UNCAUGHT_ERROR", Message: "x is undefined", File: "src/app.ts"},
+		{Timestamp: at(6 * time.Hour), Source: "backend", ErrorType: "DATABASE_ERROR", Message: "deadlock", File: "db/pool.go", Line: 12},
+		// Future entries are ignored
+		{Timestamp: now.Add(time.Hour).Format(time.RFC3339), Source: "backend", ErrorType: "CLOCK_SKEW", Message: "future"},
+	}
+}
+
+func TestGenerateDigest(t *testing.T) {
+	now := time.Date(2025, 12, 11, 9, 0, 0, 0, time.UTC)
+	r := generateDigest(digestFixture(now), now, 24*time.Hour)
+
+	if r.TotalErrors != 5 || r.PreviousTotal != 3 {
+		t.Errorf("totals = %d/%d, want 5/3", r.TotalErrors, r.PreviousTotal)
+	}
+
+	if len(r.NewTypes) != 1 || r.NewTypes[0].ErrorType != "UNCAUGHT_ERROR" || r.NewTypes[0].Sample != "x is undefined" {
+		t.Errorf("NewTypes = %+v, want only UNCAUGHT_ERROR", r.NewTypes)
+	}
+
+	if len(r.Movers) != 1 || r.Movers[0].ErrorType != "NETWORK_ERROR" || r.Movers[0].Delta != 2 {
+		t.Errorf("Movers = %+v, want NETWORK_ERROR +2", r.Movers)
+	}
+
+	if len(r.Quiet) != 1 || r.Quiet[0].ErrorType != "RENDER_ERROR" {
+		t.Errorf("Quiet = %+v, want RENDER_ERROR", r.Quiet)
+	}
+
+	if len(r.TopEndpoints) != 2 || r.TopEndpoints[0] != (LocationCount{Name: "/api/users", Count: 2}) {
+		t.Errorf("TopEndpoints = %+v", r.TopEndpoints)
+	}
+	if len(r.TopFiles) != 2 || r.TopFiles[0].Name != "db/pool.go" {
+		t.Errorf("TopFiles = %+v, want ties sorted by name", r.TopFiles)
+	}
+}
+
+func TestFormatDigestHuman(t *testing.T) {
+	now := time.Date(2025, 12, 11, 9, 0, 0, 0, time.UTC)
+	out := formatDigestHuman(generateDigest(digestFixture(now), now, 24*time.Hour))
+
+	for _, want := range []string{
+		"## agentlog digest: last 24h (Dec 10 09:00 to Dec 11 09:00 UTC)",
+		"**5 errors** (+2 vs previous 24h)",
+		"**New**\n- UNCAUGHT_ERROR (1): x is undefined",
+		"- NETWORK_ERROR: 1 -> 3 (+2)",
+		"**Gone quiet**\n- RENDER_ERROR (1 in previous 24h)",
+		"**Noisiest endpoints**\n- /api/users (2)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("digest missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatDigestHuman_Empty(t *testing.T) {
+	now := time.Now().UTC()
+	out := formatDigestHuman(generateDigest(nil, now, 24*time.Hour))
+	if !strings.Contains(out, "No errors in this period.") {
+		t.Errorf("unexpected output: %s", out)
+	}
+}
+
+func TestDigestCommand_JSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, ".agentlog"), 0755)
+	ts := time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	os.WriteFile(filepath.Join(tmpDir, ".agentlog", "errors.jsonl"),
+		[]byte(`{"timestamp":"`+ts+`","source":"backend","error_type":"DATABASE_ERROR","message":"boom"}`+"\n"), 0644)
+
+	originalPath, originalJSON := pathOverride, jsonOutput
+	defer func() { pathOverride, jsonOutput = originalPath, originalJSON }()
+	pathOverride, jsonOutput = tmpDir, true
+	digestHours = 24
+
+	buf := new(bytes.Buffer)
+	digestCmd.SetOut(buf)
+	defer digestCmd.SetOut(nil)
+	if err := runDigest(digestCmd, nil); err != nil {
+		t.Fatalf("runDigest() error = %v", err)
+	}
+
+	var report DigestReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, buf.String())
+	}
+	if report.TotalErrors != 1 || len(report.NewTypes) != 1 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/diag"
+	"github.com/agentlog/agentlog/internal/self"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dockerContainer string
+	dockerParser    string
+	dockerSource    string
+	dockerSince     string
+)
+
+// dockerCmd represents the docker command
+var dockerCmd = &cobra.Command{
+	Use:   "docker",
+	Short: "Stream a container's logs into .agentlog/errors.jsonl",
+	Long: `Follow 'docker logs -f' for a container, apply a line parser, and append
+matching lines as entries tagged with context.container.
+
+Dev databases, caches, and sidecars running in Docker otherwise have no
+path into agentlog. By default only new log lines are ingested; use --since
+to backfill. Use Ctrl+C to stop.
+
+The default parser (errorlines) picks up lines with common error markers.
+Any line-oriented parser works, including regex parsers from
+.agentlog/config.json.
+
+Examples:
+  agentlog docker --container postgres
+  agentlog docker --container api --parser myapp --source backend
+  agentlog docker --container redis --since 1h`,
+	RunE: runDocker,
+}
+
+func init() {
+	rootCmd.AddCommand(dockerCmd)
+
+	dockerCmd.Flags().StringVar(&dockerContainer, "container", "", "Container name or ID to follow (required)")
+	dockerCmd.Flags().StringVar(&dockerParser, "parser", "errorlines", "Line parser to apply")
+	dockerCmd.Flags().StringVar(&dockerSource, "source", "backend", "Source to record on ingested entries")
+	dockerCmd.Flags().StringVar(&dockerSince, "since", "", "Also ingest logs since this time (passed to docker logs --since)")
+}
+
+func runDocker(cmd *cobra.Command, args []string) error {
+	// Determine base directory (use --path override or cwd)
+	baseDir := GetPathOverride()
+	if baseDir == "" {
+		var err error
+		baseDir, err = os.Getwd()
+		if err != nil {
+			self.LogError(".", "GETWD_ERROR", err.Error())
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+
+	if dockerContainer == "" {
+		return invalidInput("--container is required")
+	}
+
+	ingester, err := newStreamIngester(baseDir, dockerParser, dockerSource)
+	if err != nil {
+		return err
+	}
+	ingester.context = map[string]interface{}{"container": dockerContainer}
+
+	if _, err := exec.LookPath("docker"); err != nil {
+		self.LogError(baseDir, "COMMAND_ERROR", "docker not found in PATH")
+		return fmt.Errorf("docker not found in PATH: install Docker or check your PATH")
+	}
+
+	// Set up signal handling for graceful shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		cancel()
+	}()
+
+	since := dockerSince
+	if since == "" {
+		since = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	if !IsJSONOutput() {
+		diag.Infof("Following logs for container %s (Ctrl+C to stop)", dockerContainer)
+	}
+
+	c := exec.CommandContext(ctx, "docker", dockerLogsArgs(dockerContainer, since)...)
+	err = followCommand(c, cmd.OutOrStdout(), splitDockerTimestamp, ingester)
+	if err != nil && ctx.Err() == nil {
+		self.LogError(baseDir, "COMMAND_ERROR", fmt.Sprintf("docker logs failed: %v", err))
+		return fmt.Errorf("docker logs failed: %w", err)
+	}
+
+	return nil
+}
+
+// dockerLogsArgs builds the docker CLI arguments for following a container
+func dockerLogsArgs(container, since string) []string {
+	return []string{"logs", "--follow", "--timestamps", "--since", since, container}
+}
+
+// splitDockerTimestamp splits the RFC3339Nano prefix added by
+// `docker logs --timestamps` from the log line
+func splitDockerTimestamp(line string) (string, string, map[string]interface{}) {
+	prefix, rest, ok := strings.Cut(line, " ")
+	if !ok {
+		return line, "", nil
+	}
+	ts, err := time.Parse(time.RFC3339Nano, prefix)
+	if err != nil {
+		return line, "", nil
+	}
+	return rest, ts.UTC().Format(time.RFC3339Nano), nil
+}
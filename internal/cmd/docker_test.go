@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestSplitDockerTimestamp(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		wantLine string
+		wantTS   string
+	}{
+		{
+			name:     "timestamped line",
+			line:     "2025-12-10T19:19:32.941234567Z ERROR:  relation \"users\" does not exist",
+			wantLine: "ERROR:  relation \"users\" does not exist",
+			wantTS:   "2025-12-10T19:19:32.941234567Z",
+		},
+		{
+			name:     "no timestamp",
+			line:     "ERROR: something",
+			wantLine: "ERROR: something",
+			wantTS:   "",
+		},
+		{
+			name:     "single word",
+			line:     "panic",
+			wantLine: "panic",
+			wantTS:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, ts, _ := splitDockerTimestamp(tt.line)
+			if line != tt.wantLine {
+				t.Errorf("line = %q, want %q", line, tt.wantLine)
+			}
+			if ts != tt.wantTS {
+				t.Errorf("timestamp = %q, want %q", ts, tt.wantTS)
+			}
+		})
+	}
+}
+
+func TestDockerLogsArgs(t *testing.T) {
+	args := dockerLogsArgs("postgres", "1h")
+	joined := strings.Join(args, " ")
+	for _, want := range []string{"logs", "--follow", "--timestamps", "--since 1h", "postgres"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("args %q should contain %q", joined, want)
+		}
+	}
+	if args[len(args)-1] != "postgres" {
+		t.Errorf("container should be the last argument, got %v", args)
+	}
+}
+
+func TestFollowCommand_TagsContainer(t *testing.T) {
+	tmpDir := t.TempDir()
+	ingester, err := newStreamIngester(tmpDir, "errorlines", "backend")
+	if err != nil {
+		t.Fatalf("newStreamIngester() error = %v", err)
+	}
+	ingester.context = map[string]interface{}{"container": "postgres"}
+
+	// Simulate docker logs output on both stdout and stderr
+	c := exec.Command("sh", "-c", `printf '2025-12-10T19:19:32.000Z LOG: database system is ready\n'; printf '2025-12-10T19:19:33.000Z ERROR: duplicate key value\n' >&2`)
+	buf := new(bytes.Buffer)
+	if err := followCommand(c, buf, splitDockerTimestamp, ingester); err != nil {
+		t.Fatalf("followCommand() error = %v", err)
+	}
+
+	entries, err := readErrors(tmpDir)
+	if err != nil {
+		t.Fatalf("readErrors() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d: %+v", len(entries), entries)
+	}
+	e := entries[0]
+	if e.Context["container"] != "postgres" {
+		t.Errorf("context.container = %v, want postgres", e.Context["container"])
+	}
+	if e.Timestamp != "2025-12-10T19:19:33Z" {
+		t.Errorf("Timestamp = %q, want docker timestamp", e.Timestamp)
+	}
+	if e.Source != "backend" {
+		t.Errorf("Source = %q, want backend", e.Source)
+	}
+	if !strings.Contains(buf.String(), "duplicate key value") {
+		t.Errorf("ingested entries should be echoed, got: %s", buf.String())
+	}
+}
+
+func TestNewStreamIngester_RejectsDocumentParsers(t *testing.T) {
+	tmpDir := t.TempDir()
+	if _, err := newStreamIngester(tmpDir, "eslint", "backend"); err == nil {
+		t.Error("newStreamIngester should reject document parsers like eslint")
+	}
+	if _, err := newStreamIngester(tmpDir, "nope", "backend"); err == nil {
+		t.Error("newStreamIngester should reject unknown parsers")
+	}
+}
+
+func TestDockerCommand_RequiresContainer(t *testing.T) {
+	originalPath := pathOverride
+	defer func() { pathOverride = originalPath }()
+	pathOverride = t.TempDir()
+
+	dockerContainer = ""
+	err := runDocker(dockerCmd, []string{})
+	if err == nil || !strings.Contains(err.Error(), "--container") {
+		t.Errorf("expected --container required error, got: %v", err)
+	}
+}
@@ -1,13 +1,14 @@
 package cmd
 
 import (
-	"bufio"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/agentlog/agentlog/internal/logfile"
 	"github.com/agentlog/agentlog/internal/self"
 	"github.com/spf13/cobra"
 )
@@ -43,16 +44,46 @@ Verifies:
   - .agentlog/ directory exists
   - errors.jsonl is valid JSONL format
   - File size is within limits
+  - Timestamps are UTC RFC3339, not in the future, and agree across
+    sources (entries sharing a request, trace, or session ID)
   - No obvious configuration issues
+  - No conflicting captures (the Vite plugin alongside 'agentlog serve',
+    window.onerror set in several files, snippets appended twice)
+  - A healthcheck entry posted to the capture endpoint ('agentlog serve',
+    the Vite plugin, or the Rails route) reaches errors.jsonl; --endpoint
+    picks the URL, --offline skips it
+  - errors.jsonl isn't tracked by git (--fix untracks it and adds the
+    .gitignore entry)
+  - Alert rules in config.json are valid, and none fired in the last 24h
+  - No error storms in the last 24h: one error 50 or more times within a
+    minute ("storms" in config.json), each likely a single problem
+
+doctor exits 0 whatever it finds. With --strict it exits 1 when there are
+warnings, 2 when there are errors, and 3 when there's no .agentlog/
+directory at all.
 
 Examples:
   agentlog doctor         # Human-readable health check
+  agentlog doctor --fix   # Repair what can be repaired
+  agentlog doctor --strict || exit  # Fail a hook when unhealthy
   agentlog doctor --json  # JSON output for programmatic use`,
 	RunE: runDoctor,
 }
 
+var (
+	doctorFix      bool
+	doctorStrict   bool
+	doctorEndpoint string
+	doctorOffline  bool
+)
+
 func init() {
 	rootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().BoolVar(&doctorStrict, "strict", false, "Exit 1 on warnings, 2 on errors, and 3 when not initialized")
+	doctorCmd.Flags().StringVar(&doctorEndpoint, "endpoint", "", "Capture endpoint to test (default: found in the installed captures)")
+	doctorCmd.Flags().BoolVar(&doctorOffline, "offline", false, "Skip posting a healthcheck entry to the capture endpoint")
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "Untrack .agentlog data committed to git and add the .gitignore entry")
 }
 
 func runDoctor(cmd *cobra.Command, args []string) error {
@@ -75,9 +106,28 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 		fmt.Fprint(cmd.OutOrStdout(), formatHealthHuman(result))
 	}
 
+	if doctorStrict {
+		if uninitialized(baseDir) {
+			return exitNotInitialized(cmd)
+		}
+		if code := healthExitCode(result); code != ExitOK {
+			return exitWith(cmd, code)
+		}
+	}
 	return nil
 }
 
+// healthExitCode is doctor's exit code under --strict
+func healthExitCode(result HealthResult) int {
+	switch result.Status {
+	case "unhealthy":
+		return ExitError
+	case "warning":
+		return ExitWarnings
+	}
+	return ExitOK
+}
+
 // checkHealth performs all health checks and returns the result
 func checkHealth(baseDir string) HealthResult {
 	result := HealthResult{
@@ -112,7 +162,7 @@ func checkHealth(baseDir string) HealthResult {
 
 	// Check 3: JSONL validity (only if file exists)
 	if fileExists(errorsFile) {
-		jsonlCheck := checkJSONL(errorsFile)
+		jsonlCheck := checkJSONL(errorsFile, maxLineBytes(baseDir))
 		result.Checks = append(result.Checks, jsonlCheck)
 
 		if jsonlCheck.Status == "error" {
@@ -122,12 +172,82 @@ func checkHealth(baseDir string) HealthResult {
 		}
 	}
 
+	// Lines torn by concurrent writers
+	if fileExists(errorsFile) {
+		tornCheck := checkTornLines(errorsFile)
+		result.Checks = append(result.Checks, tornCheck)
+		if tornCheck.Status == "error" {
+			result.Status = "unhealthy"
+		} else if tornCheck.Status == "warning" && result.Status == "healthy" {
+			result.Status = "warning"
+		}
+	}
+
 	// Check file size
 	if fileExists(errorsFile) {
 		sizeCheck := checkFileSize(errorsFile)
 		result.Checks = append(result.Checks, sizeCheck)
 
-		if sizeCheck.Status == "warning" && result.Status == "healthy" {
+		if sizeCheck.Status == "error" {
+			result.Status = "unhealthy"
+		} else if sizeCheck.Status == "warning" && result.Status == "healthy" {
+			result.Status = "warning"
+		}
+	}
+
+	// Timestamps that break time filtering
+	if fileExists(errorsFile) {
+		timeCheck := checkTimestamps(errorsFile, maxLineBytes(baseDir), time.Now().UTC())
+		result.Checks = append(result.Checks, timeCheck)
+		if timeCheck.Status == "error" {
+			result.Status = "unhealthy"
+		} else if timeCheck.Status == "warning" && result.Status == "healthy" {
+			result.Status = "warning"
+		}
+	}
+
+	// Data files committed to git
+	gitCheck := checkGitTracking(baseDir, doctorFix)
+	result.Checks = append(result.Checks, gitCheck)
+	if gitCheck.Status == "error" {
+		result.Status = "unhealthy"
+	} else if gitCheck.Status == "warning" && result.Status == "healthy" {
+		result.Status = "warning"
+	}
+
+	// Conflicting or duplicated browser captures
+	scan := scanCaptures(baseDir)
+	integrationCheck := checkIntegrations(scan)
+	result.Checks = append(result.Checks, integrationCheck)
+	if integrationCheck.Status == "warning" && result.Status == "healthy" {
+		result.Status = "warning"
+	}
+
+	// Alert rules that can't run, and alerts nobody may have seen
+	if alertCheck, ok := checkAlerts(baseDir, time.Now().UTC()); ok {
+		result.Checks = append(result.Checks, alertCheck)
+		if alertCheck.Status == "error" {
+			result.Status = "unhealthy"
+		} else if alertCheck.Status == "warning" && result.Status == "healthy" {
+			result.Status = "warning"
+		}
+	}
+
+	// Bursts of one error
+	if stormCheck, ok := checkStorms(baseDir, time.Now().UTC()); ok {
+		result.Checks = append(result.Checks, stormCheck)
+		if result.Status == "healthy" {
+			result.Status = "warning"
+		}
+	}
+
+	// Posted entries reach the file
+	if !doctorOffline {
+		endpointCheck := checkEndpoint(baseDir, scan, doctorEndpoint)
+		result.Checks = append(result.Checks, endpointCheck)
+		if endpointCheck.Status == "error" {
+			result.Status = "unhealthy"
+		} else if endpointCheck.Status == "warning" && result.Status == "healthy" {
 			result.Status = "warning"
 		}
 	}
@@ -194,8 +314,9 @@ func checkFile(filePath string) HealthCheck {
 	return check
 }
 
-// checkJSONL validates that the file contains valid JSONL
-func checkJSONL(filePath string) HealthCheck {
+// checkJSONL validates that the file contains valid JSONL, counting lines
+// over maxLine that readers will skip
+func checkJSONL(filePath string, maxLine int) HealthCheck {
 	check := HealthCheck{
 		Name: "JSONL format",
 	}
@@ -208,14 +329,13 @@ func checkJSONL(filePath string) HealthCheck {
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	lineNum := 0
+	scanner := logfile.NewLines(file, maxLine)
 	validLines := 0
 	malformedLines := 0
 	var malformedLineNums []int
 
 	for scanner.Scan() {
-		lineNum++
+		lineNum := scanner.Line()
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" {
 			continue
@@ -242,6 +362,18 @@ func checkJSONL(filePath string) HealthCheck {
 		check.Status = "warning"
 		lineNumStr := formatLineNumbers(malformedLineNums)
 		check.Message = fmt.Sprintf("%d malformed/invalid JSON lines (lines: %s). %d valid entries.", malformedLines, lineNumStr, validLines)
+	}
+	if skipped := scanner.Skipped; len(skipped) > 0 {
+		var nums []int
+		for _, s := range skipped {
+			if len(nums) < 5 {
+				nums = append(nums, s.Line)
+			}
+		}
+		check.Status = "warning"
+		check.Message = strings.TrimSpace(fmt.Sprintf("%s %d lines over the %d-byte limit are skipped by readers (lines: %s); raise max_line_bytes in .agentlog/config.json to read them.", check.Message, len(skipped), maxLine, formatLineNumbers(nums)))
+	}
+	if check.Status == "warning" {
 		return check
 	}
 
@@ -345,12 +477,12 @@ func formatHealthHuman(result HealthResult) string {
 	sb.WriteString("===============\n\n")
 
 	for _, check := range result.Checks {
-		icon := getStatusIcon(check.Status)
+		icon := colors.status(check.Status, getStatusIcon(check.Status))
 		sb.WriteString(fmt.Sprintf("%s %s: %s\n", icon, check.Name, check.Message))
 	}
 
 	sb.WriteString("\n")
-	sb.WriteString(fmt.Sprintf("Status: %s\n", strings.ToUpper(result.Status)))
+	sb.WriteString(fmt.Sprintf("Status: %s\n", colors.status(result.Status, strings.ToUpper(result.Status))))
 	sb.WriteString(result.Summary + "\n")
 
 	return sb.String()
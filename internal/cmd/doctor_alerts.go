@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/config"
+)
+
+// alertLookback is how far back doctor reports fired alerts
+const alertLookback = 24 * time.Hour
+
+// checkAlerts reports alert rules that can't be checked, as an error, and
+// alerts fired in the last day, as a warning: they name problems nobody
+// may have looked at yet. ok is false when there are no rules and no
+// alerts, so there's nothing to report.
+func checkAlerts(baseDir string, now time.Time) (check HealthCheck, ok bool) {
+	check = HealthCheck{Name: "Alerts"}
+	cfg, err := config.Load(baseDir)
+	if err != nil {
+		cfg = &config.Config{}
+	}
+	_, problems := loadAlertRules(baseDir)
+	alerts, err := readAlerts(baseDir)
+	if err != nil && !os.IsNotExist(err) {
+		check.Status = "warning"
+		check.Message = fmt.Sprintf("Cannot read %s: %v", alertsFileName, err)
+		return check, true
+	}
+	if len(cfg.Alerts) == 0 && len(alerts) == 0 {
+		return check, false
+	}
+
+	if len(problems) > 0 {
+		check.Status = "error"
+		check.Message = "Invalid alert rules, which tail and serve skip: " + strings.Join(problems, "; ")
+		return check, true
+	}
+
+	// Fired alerts in the lookback, per rule: how many and the latest
+	counts := make(map[string]int)
+	latest := make(map[string]time.Time)
+	for _, a := range alerts {
+		at, err := time.Parse(time.RFC3339, a.FiredAt)
+		if err != nil || now.Sub(at) > alertLookback {
+			continue
+		}
+		counts[a.Rule]++
+		if at.After(latest[a.Rule]) {
+			latest[a.Rule] = at
+		}
+	}
+	if len(counts) == 0 {
+		check.Status = "ok"
+		check.Message = fmt.Sprintf("%d alert rule(s); none fired in the last 24h", len(cfg.Alerts))
+		return check, true
+	}
+
+	rules := make([]string, 0, len(counts))
+	for rule := range counts {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return latest[rules[i]].After(latest[rules[j]]) })
+	var parts []string
+	total := 0
+	for _, rule := range rules {
+		total += counts[rule]
+		parts = append(parts, fmt.Sprintf("%s (%dx, last %s)", rule, counts[rule], relativeTime(latest[rule], now)))
+	}
+	check.Status = "warning"
+	check.Message = fmt.Sprintf("%d alert(s) fired in the last 24h: %s", total, strings.Join(parts, ", "))
+	return check, true
+}
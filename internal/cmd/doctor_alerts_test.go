@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/config"
+)
+
+func TestCheckAlerts(t *testing.T) {
+	now := time.Date(2025, 12, 10, 12, 0, 0, 0, time.UTC)
+
+	if _, ok := checkAlerts(t.TempDir(), now); ok {
+		t.Error("checkAlerts() should skip a project without rules or alerts")
+	}
+
+	dir := writeAlertRules(t, config.AlertRule{Name: "storm", Window: "10m"})
+	check, ok := checkAlerts(dir, now)
+	if !ok || check.Status != "ok" || !strings.Contains(check.Message, "none fired") {
+		t.Errorf("without alerts: %+v", check)
+	}
+
+	os.WriteFile(alertsPath(dir), []byte(`{"rule":"storm","fired_at":"2025-12-08T12:00:00Z"}
+{"rule":"storm","fired_at":"2025-12-10T11:00:00Z"}
+{"rule":"storm","fired_at":"2025-12-10T11:57:00Z"}
+{"rule":"slow","fired_at":"2025-12-10T09:00:00Z"}
+`), 0644)
+	check, _ = checkAlerts(dir, now)
+	if check.Status != "warning" {
+		t.Errorf("Status = %q, want warning for recent alerts", check.Status)
+	}
+	if want := "3 alert(s) fired in the last 24h: storm (2x, last 3m ago), slow (1x, last 3h ago)"; check.Message != want {
+		t.Errorf("Message = %q, want %q", check.Message, want)
+	}
+
+	os.WriteFile(filepath.Join(dir, ".agentlog", "config.json"), []byte(`{"alerts":[{"name":"storm","window":"often"}]}`), 0644)
+	check, _ = checkAlerts(dir, now)
+	if check.Status != "error" || !strings.Contains(check.Message, `alert "storm": invalid window`) {
+		t.Errorf("with an invalid rule: %+v", check)
+	}
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/config"
+)
+
+// Default capture endpoints, by what serves them
+const (
+	defaultServeURL = "http://127.0.0.1:7654/__agentlog"
+	defaultViteURL  = "http://localhost:5173/__agentlog"
+	defaultRailsURL = "http://localhost:3000/__agentlog"
+)
+
+// endpointTimeout bounds the healthcheck request, and how long doctor
+// waits for the entry to reach the file afterwards
+const endpointTimeout = 2 * time.Second
+
+// captureEndpoint is the URL the installed captures post to: the first
+// literal 'agentlog serve' URL, else the default for whatever serves
+// /__agentlog. Empty when no capture posts anywhere doctor can guess.
+func captureEndpoint(scan captureScan) string {
+	switch {
+	case scan.serveURL != "":
+		return scan.serveURL
+	case len(scan.serveFiles) > 0:
+		return defaultServeURL
+	case len(scan.viteConfigs) > 0:
+		return defaultViteURL
+	case scan.railsRoute:
+		return defaultRailsURL
+	}
+	return ""
+}
+
+// checkEndpoint posts a healthcheck entry to the capture endpoint and
+// checks it reaches errors.jsonl, which proves the whole pipeline rather
+// than just that the file exists. endpoint overrides the one found in scan.
+func checkEndpoint(baseDir string, scan captureScan, endpoint string) HealthCheck {
+	check := HealthCheck{Name: "Capture endpoint"}
+	if endpoint == "" {
+		endpoint = captureEndpoint(scan)
+	}
+	if endpoint == "" {
+		check.Status = "ok"
+		if len(scan.relative) > 0 {
+			check.Message = fmt.Sprintf("%s posts to /__agentlog on an unknown server; pass --endpoint to test it", strings.Join(scan.relative, ", "))
+		} else {
+			check.Message = "No /__agentlog capture installed; skipped"
+		}
+		return check
+	}
+
+	path := GetErrorsPath(baseDir)
+	var offset int64
+	if info, err := os.Stat(path); err == nil {
+		offset = info.Size()
+	}
+
+	nonce := make([]byte, 8)
+	rand.Read(nonce)
+	marker := "agentlog doctor healthcheck " + hex.EncodeToString(nonce)
+	body, _ := json.Marshal(ErrorEntry{
+		Timestamp: time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+		Source:    "agentlog",
+		ErrorType: "HEALTHCHECK",
+		Message:   marker,
+		Kind:      kindHealthcheck,
+	})
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		check.Status = "error"
+		check.Message = fmt.Sprintf("Invalid endpoint %s: %v", endpoint, err)
+		return check
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cfg, err := config.Load(baseDir); err == nil && cfg.Serve.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.Serve.Token)
+	}
+
+	client := &http.Client{Timeout: endpointTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		check.Status = "warning"
+		check.Message = fmt.Sprintf("Could not reach %s (%v). Is the dev server or 'agentlog serve' running?", endpoint, err)
+		return check
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		check.Status = "error"
+		check.Message = fmt.Sprintf("%s rejected the serve token (401); restart 'agentlog serve' so it reads .agentlog/config.json", endpoint)
+		return check
+	case resp.StatusCode >= 300:
+		check.Status = "error"
+		check.Message = fmt.Sprintf("%s answered %s to a healthcheck entry", endpoint, resp.Status)
+		return check
+	}
+
+	start := time.Now()
+	for {
+		if landed(path, offset, marker) {
+			check.Status = "ok"
+			check.Message = fmt.Sprintf("Healthcheck entry posted to %s reached errors.jsonl", endpoint)
+			return check
+		}
+		if time.Since(start) > endpointTimeout {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	check.Status = "error"
+	check.Message = fmt.Sprintf("%s accepted a healthcheck entry, but it never reached %s. The server may be writing to another project's .agentlog (check its working directory)", endpoint, path)
+	return check
+}
+
+// landed reports whether marker appears in the file after offset
+func landed(path string, offset int64, marker string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return false
+	}
+	data, err := io.ReadAll(f)
+	return err == nil && bytes.Contains(data, []byte(marker))
+}
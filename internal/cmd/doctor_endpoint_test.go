@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCaptureEndpoint(t *testing.T) {
+	tests := []struct {
+		name string
+		scan captureScan
+		want string
+	}{
+		{"literal serve URL", captureScan{serveFiles: []string{"a.ts"}, serveURL: "http://localhost:9000/__agentlog"}, "http://localhost:9000/__agentlog"},
+		{"templated serve URL", captureScan{serveFiles: []string{"a.ts"}}, defaultServeURL},
+		{"vite plugin", captureScan{viteConfigs: []string{"vite.config.ts"}}, defaultViteURL},
+		{"rails route", captureScan{railsRoute: true}, defaultRailsURL},
+		{"nothing installed", captureScan{}, ""},
+	}
+	for _, tt := range tests {
+		if got := captureEndpoint(tt.scan); got != tt.want {
+			t.Errorf("%s: captureEndpoint() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckEndpoint_Skipped(t *testing.T) {
+	check := checkEndpoint(t.TempDir(), captureScan{}, "")
+	if check.Status != "ok" || !strings.Contains(check.Message, "skipped") {
+		t.Errorf("expected skip, got %s: %s", check.Status, check.Message)
+	}
+}
+
+func TestCheckEndpoint_ReachesFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, ".agentlog"), 0755)
+	srv := httptest.NewServer(newIngestServer(tmpDir, defaultAllowedOrigins).handler())
+	defer srv.Close()
+
+	check := checkEndpoint(tmpDir, captureScan{}, srv.URL+"/__agentlog")
+	if check.Status != "ok" {
+		t.Fatalf("expected ok, got %s: %s", check.Status, check.Message)
+	}
+
+	// The entry is in the file, but readers skip it
+	data, _ := os.ReadFile(GetErrorsPath(tmpDir))
+	if !strings.Contains(string(data), `"kind":"healthcheck"`) {
+		t.Errorf("expected a healthcheck entry in the file, got %s", data)
+	}
+	entries, err := readErrors(tmpDir)
+	if err != nil || len(entries) != 0 {
+		t.Errorf("expected readErrors to skip healthcheck entries, got %v, %v", entries, err)
+	}
+}
+
+func TestCheckEndpoint_NeverLands(t *testing.T) {
+	tmpDir := t.TempDir()
+	srv := httptest.NewServer(newIngestServer(t.TempDir(), defaultAllowedOrigins).handler())
+	defer srv.Close()
+
+	check := checkEndpoint(tmpDir, captureScan{}, srv.URL+"/__agentlog")
+	if check.Status != "error" || !strings.Contains(check.Message, "never reached") {
+		t.Errorf("expected error, got %s: %s", check.Status, check.Message)
+	}
+}
+
+func TestCheckEndpoint_Unauthorized(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	check := checkEndpoint(t.TempDir(), captureScan{}, srv.URL+"/__agentlog")
+	if check.Status != "error" || !strings.Contains(check.Message, "token") {
+		t.Errorf("expected token error, got %s: %s", check.Status, check.Message)
+	}
+}
+
+func TestCheckEndpoint_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL + "/__agentlog"
+	srv.Close()
+
+	check := checkEndpoint(t.TempDir(), captureScan{}, url)
+	if check.Status != "warning" || !strings.Contains(check.Message, "Could not reach") {
+		t.Errorf("expected warning, got %s: %s", check.Status, check.Message)
+	}
+}
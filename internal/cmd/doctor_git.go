@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/agentlog/agentlog/internal/config"
+)
+
+// agentlogDataFile reports whether a file in .agentlog is data agentlog
+// writes (the log, its rotations, prime's cache and delta position), as
+// opposed to capture snippets meant to be committed
+func agentlogDataFile(name string) bool {
+	name = path.Base(name)
+	return (strings.HasPrefix(name, "errors") && strings.Contains(name, ".jsonl")) ||
+		name == "cache.json" || name == "prime-delta.json" || name == editsFileName || name == testRunsFileName ||
+		name == resolvedFileName || name == fixesFileName || name == snoozedFileName || name == embeddingsFileName ||
+		name == samplingFileName
+}
+
+// gitOutput runs git in dir and returns its stdout
+func gitOutput(dir string, args ...string) (string, error) {
+	c := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stdout, stderr bytes.Buffer
+	c.Stdout, c.Stderr = &stdout, &stderr
+	if err := c.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// checkGitTracking warns when agentlog's data files are tracked by git,
+// which happens when errors.jsonl was committed before it was ignored, or
+// when a subproject's .gitignore lacks the entry. With fix, the files are
+// untracked (kept on disk) and the entry is added to .gitignore.
+func checkGitTracking(baseDir string, fix bool) HealthCheck {
+	check := HealthCheck{Name: "Git tracking", Status: "ok"}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		check.Message = "git not found; skipped"
+		return check
+	}
+	if out, err := gitOutput(baseDir, "rev-parse", "--is-inside-work-tree"); err != nil || strings.TrimSpace(out) != "true" {
+		check.Message = "Not a git repository; skipped"
+		return check
+	}
+
+	out, err := gitOutput(baseDir, "ls-files", "-z", "--", ".agentlog")
+	if err != nil {
+		check.Status = "warning"
+		check.Message = fmt.Sprintf("Cannot list tracked files: %v", err)
+		return check
+	}
+	var tracked []string
+	for _, f := range strings.Split(out, "\x00") {
+		if f != "" && agentlogDataFile(f) {
+			tracked = append(tracked, f)
+		}
+	}
+	// check-ignore skips tracked files unless --no-index is given
+	_, ignoreErr := gitOutput(baseDir, "check-ignore", "-q", "--no-index", gitignoreEntry)
+	ignored := ignoreErr == nil
+
+	if len(tracked) == 0 && ignored {
+		check.Message = ".agentlog data is not tracked by git"
+		return check
+	}
+
+	if fix {
+		var done []string
+		if len(tracked) > 0 {
+			if _, err := gitOutput(baseDir, append([]string{"rm", "--cached", "--quiet", "--"}, tracked...)...); err != nil {
+				check.Status = "error"
+				check.Message = fmt.Sprintf("Failed to untrack %s: %v", strings.Join(tracked, ", "), err)
+				return check
+			}
+			done = append(done, "untracked "+strings.Join(tracked, ", ")+" (files kept; commit the removal)")
+		}
+		if !ignored {
+			all := false
+			if cfg, err := config.Load(baseDir); err == nil {
+				all = cfg.GitignoreAll
+			}
+			if _, err := ensureGitignored(diskFiles{}, baseDir, all); err != nil {
+				check.Status = "error"
+				check.Message = err.Error()
+				return check
+			}
+			entry := gitignoreEntry
+			if all {
+				entry = gitignoreAllEntry
+			}
+			done = append(done, "added "+entry+" to .gitignore")
+		}
+		check.Message = "Fixed: " + strings.Join(done, "; ")
+		return check
+	}
+
+	check.Status = "warning"
+	var problems []string
+	if len(tracked) > 0 {
+		problems = append(problems, fmt.Sprintf("%s tracked by git, so errors end up in commits", strings.Join(tracked, ", ")))
+	}
+	if !ignored {
+		problems = append(problems, fmt.Sprintf("%s is not ignored", gitignoreEntry))
+	}
+	check.Message = strings.Join(problems, "; ") + ". Run 'agentlog doctor --fix' to untrack and ignore it."
+	return check
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gitRepo creates a temp git repo with .agentlog/errors.jsonl committed
+// before any .gitignore
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, ".agentlog"), 0755)
+	os.WriteFile(filepath.Join(dir, ".agentlog", "errors.jsonl"), []byte(`{"error_type":"X","message":"m"}`+"\n"), 0644)
+	os.WriteFile(filepath.Join(dir, ".agentlog", "capture.ts"), []byte(typescriptCapture), 0644)
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-qm", "init"},
+	} {
+		if out, err := gitOutput(dir, args...); err != nil {
+			t.Fatalf("git %v: %v %s", args, err, out)
+		}
+	}
+	return dir
+}
+
+func TestAgentlogDataFile(t *testing.T) {
+	for name, want := range map[string]bool{
+		".agentlog/errors.jsonl":      true,
+		".agentlog/errors.jsonl.1":    true,
+		".agentlog/cache.json":        true,
+		".agentlog/edits.jsonl":       true,
+		".agentlog/test-runs.jsonl":   true,
+		".agentlog/resolved.jsonl":    true,
+		".agentlog/embeddings.json":   true,
+		".agentlog/resolutions.jsonl": true,
+		".agentlog/snoozed.jsonl":     true,
+		".agentlog/sampling.json":     true,
+		".agentlog/capture.ts":        false,
+		".agentlog/config.json":       false,
+	} {
+		if got := agentlogDataFile(name); got != want {
+			t.Errorf("agentlogDataFile(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestCheckGitTracking_NotRepo(t *testing.T) {
+	check := checkGitTracking(t.TempDir(), false)
+	if check.Status != "ok" {
+		t.Errorf("expected ok outside a repo, got %s: %s", check.Status, check.Message)
+	}
+}
+
+func TestCheckGitTracking_Tracked(t *testing.T) {
+	dir := gitRepo(t)
+	check := checkGitTracking(dir, false)
+	if check.Status != "warning" {
+		t.Fatalf("expected warning, got %s: %s", check.Status, check.Message)
+	}
+	for _, want := range []string{".agentlog/errors.jsonl tracked by git", "not ignored", "--fix"} {
+		if !strings.Contains(check.Message, want) {
+			t.Errorf("expected message to contain %q, got: %s", want, check.Message)
+		}
+	}
+	if strings.Contains(check.Message, "capture.ts") {
+		t.Errorf("capture snippets are meant to be committed, got: %s", check.Message)
+	}
+}
+
+func TestCheckGitTracking_IgnoredAfterCommit(t *testing.T) {
+	dir := gitRepo(t)
+	os.WriteFile(filepath.Join(dir, ".gitignore"), []byte(gitignoreEntry+"\n"), 0644)
+
+	check := checkGitTracking(dir, false)
+	if check.Status != "warning" || strings.Contains(check.Message, "not ignored") {
+		t.Errorf("expected only the tracked-file warning, got %s: %s", check.Status, check.Message)
+	}
+}
+
+func TestCheckGitTracking_Fix(t *testing.T) {
+	dir := gitRepo(t)
+	check := checkGitTracking(dir, true)
+	if check.Status != "ok" || !strings.HasPrefix(check.Message, "Fixed:") {
+		t.Fatalf("expected fix, got %s: %s", check.Status, check.Message)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".agentlog", "errors.jsonl")); err != nil {
+		t.Errorf("expected errors.jsonl kept on disk: %v", err)
+	}
+	out, _ := gitOutput(dir, "ls-files", ".agentlog")
+	if strings.Contains(out, "errors.jsonl") || !strings.Contains(out, "capture.ts") {
+		t.Errorf("expected only errors.jsonl untracked, ls-files: %q", out)
+	}
+	gitignore, _ := os.ReadFile(filepath.Join(dir, ".gitignore"))
+	if !strings.Contains(string(gitignore), gitignoreEntry) {
+		t.Errorf("expected .gitignore entry, got %q", gitignore)
+	}
+
+	if again := checkGitTracking(dir, false); again.Status != "ok" {
+		t.Errorf("expected ok after fix, got %s: %s", again.Status, again.Message)
+	}
+}
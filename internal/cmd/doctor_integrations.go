@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// doctorSkipDirs are never searched for captures: dependencies, build
+// output, and VCS metadata
+var doctorSkipDirs = map[string]bool{
+	"node_modules": true, ".git": true, "dist": true, "build": true, "vendor": true,
+	"target": true, ".next": true, ".nuxt": true, ".svelte-kit": true, "coverage": true,
+	".venv": true, "venv": true, "__pycache__": true, "tmp": true, "log": true,
+}
+
+// doctorScanExts are the files browser captures get pasted into
+var doctorScanExts = map[string]bool{
+	".js": true, ".jsx": true, ".ts": true, ".tsx": true, ".mjs": true, ".cjs": true,
+	".mts": true, ".vue": true, ".svelte": true, ".html": true, ".erb": true,
+}
+
+// Limits that keep doctor fast in large repos
+const (
+	doctorMaxScanFiles = 5000
+	doctorMaxScanSize  = 1 << 20
+)
+
+// serveEndpointPattern matches a capture posting to 'agentlog serve' (an
+// absolute URL) rather than the dev server's own /__agentlog route
+var serveEndpointPattern = regexp.MustCompile(`https?://[^'"\s]+/__agentlog`)
+
+// vitePluginPattern matches the agentlog dev-server plugin in a Vite config
+var vitePluginPattern = regexp.MustCompile(`agentlogPlugin|/__agentlog`)
+
+// onerrorPattern matches a window.onerror assignment
+var onerrorPattern = regexp.MustCompile(`window\.onerror\s*=`)
+
+// captureScan is what checkIntegrations found in the project's files
+type captureScan struct {
+	viteConfigs []string       // Vite configs with the agentlog plugin
+	serveFiles  []string       // files posting to agentlog serve
+	serveURL    string         // the first literal serve URL among them
+	relative    []string       // files posting to the dev server's /__agentlog
+	railsRoute  bool           // config/routes.rb has the /__agentlog route
+	onerror     []string       // files where agentlog sets window.onerror
+	copies      map[string]int // files with more than one installed snippet
+}
+
+// checkIntegrations looks for browser captures that conflict: the Vite
+// plugin alongside a capture posting to 'agentlog serve', window.onerror
+// set by agentlog in more than one file, and snippets appended to a file
+// more than once
+func checkIntegrations(scan captureScan) HealthCheck {
+	check := HealthCheck{Name: "Integrations"}
+
+	var problems []string
+	if len(scan.viteConfigs) > 0 && len(scan.serveFiles) > 0 {
+		problems = append(problems, fmt.Sprintf(
+			"the Vite plugin (%s) and a capture posting to 'agentlog serve' (%s) are both set up; use one: point the capture at '/__agentlog', or remove the plugin",
+			strings.Join(scan.viteConfigs, ", "), strings.Join(scan.serveFiles, ", ")))
+	}
+	if len(scan.onerror) > 1 {
+		problems = append(problems, fmt.Sprintf(
+			"window.onerror is set by agentlog in %d files (%s), so only the last one loaded reports; keep one and remove the others",
+			len(scan.onerror), strings.Join(scan.onerror, ", ")))
+	}
+	var copies []string
+	for path, n := range scan.copies {
+		copies = append(copies, fmt.Sprintf("%s (%dx)", path, n))
+	}
+	sort.Strings(copies)
+	if len(copies) > 0 {
+		problems = append(problems, fmt.Sprintf(
+			"the agentlog snippet was appended more than once to %s, so errors are logged repeatedly; delete the extra copies",
+			strings.Join(copies, ", ")))
+	}
+
+	if len(problems) > 0 {
+		check.Status = "warning"
+		check.Message = "Conflicting captures: " + strings.Join(problems, "; ")
+		return check
+	}
+	check.Status = "ok"
+	check.Message = "No conflicting captures found"
+	return check
+}
+
+// scanCaptures walks the project for agentlog captures, skipping
+// dependencies and build output
+func scanCaptures(baseDir string) captureScan {
+	scan := captureScan{copies: make(map[string]int)}
+	if routes, err := os.ReadFile(filepath.Join(baseDir, "config", "routes.rb")); err == nil {
+		scan.railsRoute = strings.Contains(string(routes), "__agentlog")
+	}
+	scanned := 0
+	filepath.WalkDir(baseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != baseDir && doctorSkipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !doctorScanExts[filepath.Ext(path)] || scanned >= doctorMaxScanFiles {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > doctorMaxScanSize {
+			return nil
+		}
+		scanned++
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		content := string(data)
+		if !strings.Contains(content, "agentlog") {
+			return nil
+		}
+		rel, _ := filepath.Rel(baseDir, path)
+		rel = filepath.ToSlash(rel)
+
+		if strings.HasPrefix(d.Name(), "vite.config.") {
+			if vitePluginPattern.MatchString(content) {
+				scan.viteConfigs = append(scan.viteConfigs, rel)
+			}
+			return nil
+		}
+		if !strings.Contains(content, "__agentlog") {
+			return nil
+		}
+		if urls := serveEndpointPattern.FindAllString(content, -1); len(urls) > 0 {
+			scan.serveFiles = append(scan.serveFiles, rel)
+			for _, u := range urls {
+				if scan.serveURL == "" && !strings.ContainsAny(u, "${}") {
+					scan.serveURL = u
+				}
+			}
+		} else if strings.Contains(content, "'/__agentlog'") || strings.Contains(content, `"/__agentlog"`) {
+			scan.relative = append(scan.relative, rel)
+		}
+		handlers := len(onerrorPattern.FindAllStringIndex(content, -1))
+		if handlers > 0 {
+			scan.onerror = append(scan.onerror, rel)
+		}
+		// A pasted snippet has no marker, but does set window.onerror
+		if n := strings.Count(content, "agentlog:installed"); n > 1 || handlers > 1 {
+			scan.copies[rel] = max(n, handlers)
+		}
+		return nil
+	})
+	return scan
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeProjectFiles writes files (relative path -> content) under a temp dir
+func writeProjectFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for rel, content := range files {
+		path := filepath.Join(dir, rel)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCheckIntegrations_Clean(t *testing.T) {
+	dir := writeProjectFiles(t, map[string]string{
+		".agentlog/capture.ts": typescriptCapture,
+		"vite.config.ts":       "import { agentlogPlugin } from './.agentlog/capture';\nexport default { plugins: [agentlogPlugin()] };\n",
+		"src/main.ts":          "import '../.agentlog/capture';\n",
+	})
+	check := checkIntegrations(scanCaptures(dir))
+	if check.Status != "ok" {
+		t.Errorf("expected ok, got %s: %s", check.Status, check.Message)
+	}
+}
+
+func TestCheckIntegrations_VitePluginAndServe(t *testing.T) {
+	dir := writeProjectFiles(t, map[string]string{
+		"vite.config.ts":  "export default { plugins: [agentlogPlugin()] };\n",
+		"src/agentlog.ts": "// agentlog capture\nfetch('http://localhost:7654/__agentlog', { method: 'POST' });\n",
+	})
+	check := checkIntegrations(scanCaptures(dir))
+	if check.Status != "warning" {
+		t.Fatalf("expected warning, got %s: %s", check.Status, check.Message)
+	}
+	for _, want := range []string{"vite.config.ts", "src/agentlog.ts", "'agentlog serve'"} {
+		if !strings.Contains(check.Message, want) {
+			t.Errorf("expected message to mention %q, got: %s", want, check.Message)
+		}
+	}
+}
+
+func TestCheckIntegrations_DuplicateOnerror(t *testing.T) {
+	dir := writeProjectFiles(t, map[string]string{
+		".agentlog/capture.ts": typescriptCapture,
+		"src/main.ts":          snippetTypeScript,
+	})
+	check := checkIntegrations(scanCaptures(dir))
+	if check.Status != "warning" || !strings.Contains(check.Message, "window.onerror is set by agentlog in 2 files (.agentlog/capture.ts, src/main.ts)") {
+		t.Errorf("expected duplicate window.onerror warning, got %s: %s", check.Status, check.Message)
+	}
+}
+
+func TestCheckIntegrations_AppendedTwice(t *testing.T) {
+	dir := writeProjectFiles(t, map[string]string{
+		"app/javascript/application.js": "import './controllers';\n\n" + rubyFrontendJS + "\n" + rubyFrontendJS,
+	})
+	check := checkIntegrations(scanCaptures(dir))
+	if check.Status != "warning" || !strings.Contains(check.Message, "app/javascript/application.js (2x)") {
+		t.Errorf("expected appended-twice warning, got %s: %s", check.Status, check.Message)
+	}
+}
+
+func TestCheckIntegrations_SkipsDependencies(t *testing.T) {
+	dir := writeProjectFiles(t, map[string]string{
+		".agentlog/capture.ts":              typescriptCapture,
+		"node_modules/some-pkg/agentlog.js": typescriptCapture,
+		"dist/assets/index.js":              typescriptCapture,
+	})
+	check := checkIntegrations(scanCaptures(dir))
+	if check.Status != "ok" {
+		t.Errorf("expected node_modules and dist to be skipped, got %s: %s", check.Status, check.Message)
+	}
+}
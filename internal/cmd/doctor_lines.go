@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// tornLines counts the lines checkTornLines found, with the first few line
+// numbers of each kind
+type tornLines struct {
+	merged, fragments     int
+	mergedAt, fragmentsAt []int
+	incomplete            bool // the last line is invalid and has no newline
+}
+
+// checkTornLines looks for the marks concurrent writers leave when they
+// don't write whole lines at once: two entries run together on one line,
+// fragments of an entry on lines of their own, and a last line with no
+// newline. Other invalid JSON is left to the JSONL check.
+func checkTornLines(filePath string) HealthCheck {
+	check := HealthCheck{Name: "Torn lines"}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		check.Status = "error"
+		check.Message = fmt.Sprintf("Cannot open file: %v", err)
+		return check
+	}
+	defer file.Close()
+
+	var t tornLines
+	reader := bufio.NewReader(file)
+	lineNum := 0
+	for {
+		raw, err := reader.ReadString('\n')
+		if raw != "" {
+			lineNum++
+			line := strings.TrimSpace(raw)
+			valid := line == "" || json.Valid([]byte(line))
+			switch {
+			case valid:
+			case !strings.HasSuffix(raw, "\n"):
+				t.incomplete = true // the last line; its writer may still be at it
+			case strings.Contains(line, "}{"):
+				t.merged++
+				t.mergedAt = noteLine(t.mergedAt, lineNum)
+			case !strings.HasPrefix(line, "{") || !strings.HasSuffix(line, "}"):
+				t.fragments++
+				t.fragmentsAt = noteLine(t.fragmentsAt, lineNum)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			check.Status = "error"
+			check.Message = fmt.Sprintf("Error reading file: %v", err)
+			return check
+		}
+	}
+
+	var problems []string
+	if t.merged > 0 {
+		problems = append(problems, fmt.Sprintf("%d with entries run together (lines: %s)", t.merged, formatLineNumbers(t.mergedAt)))
+	}
+	if t.fragments > 0 {
+		problems = append(problems, fmt.Sprintf("%d partial entries (lines: %s)", t.fragments, formatLineNumbers(t.fragmentsAt)))
+	}
+	if len(problems) > 0 {
+		check.Status = "warning"
+		check.Message = "Torn lines from interleaved writes: " + strings.Join(problems, ", ") +
+			". A writer isn't appending whole lines; 'agentlog upgrade' refreshes installed capture files."
+		return check
+	}
+	if t.incomplete {
+		check.Status = "warning"
+		check.Message = "Last line is incomplete: a writer stopped mid-entry (or is still writing). agentlog's own writes start a new line after it."
+		return check
+	}
+
+	check.Status = "ok"
+	check.Message = "No torn lines"
+	return check
+}
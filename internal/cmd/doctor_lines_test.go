@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckTornLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		status  string
+		want    string
+	}{
+		{"clean", `{"a":1}` + "\n" + `{"b":2}` + "\n", "ok", "No torn lines"},
+		{"empty", "", "ok", "No torn lines"},
+		{"run together", `{"a":1}{"b":2}` + "\n" + `{"c":3}` + "\n", "warning", "1 with entries run together (lines: 1)"},
+		{"fragments", `{"a":1,"mess` + "\n" + `age":"x"}` + "\n" + `{"c":3}` + "\n", "warning", "2 partial entries (lines: 1, 2)"},
+		{"unterminated", `{"a":1}` + "\n" + `{"b":`, "warning", "Last line is incomplete"},
+		{"valid last line without newline", `{"a":1}` + "\n" + `{"b":2}`, "ok", "No torn lines"},
+		{"other invalid JSON", `{"a":1,}` + "\n", "ok", "No torn lines"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "errors.jsonl")
+			os.WriteFile(path, []byte(tt.content), 0644)
+			check := checkTornLines(path)
+			if check.Status != tt.status || !strings.Contains(check.Message, tt.want) {
+				t.Errorf("got %s %q, want %s containing %q", check.Status, check.Message, tt.status, tt.want)
+			}
+		})
+	}
+}
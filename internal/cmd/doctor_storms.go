@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// stormLookback is how far back doctor reports error storms
+const stormLookback = 24 * time.Hour
+
+// checkStorms reports error storms in the last day as a warning: one error
+// bursting hundreds of times is usually a retry loop or a dependency down,
+// and it crowds everything else out of the log. ok is false when there
+// were none, so there's nothing to report.
+func checkStorms(baseDir string, now time.Time) (check HealthCheck, ok bool) {
+	check = HealthCheck{Name: "Error storms"}
+	entries, err := readErrors(baseDir)
+	if err != nil {
+		return check, false
+	}
+	var recent []ErrorEntry
+	for _, e := range entries {
+		if ts, err := parseEntryTime(e.Timestamp); err == nil && now.Sub(ts) <= stormLookback {
+			recent = append(recent, e)
+		}
+	}
+	threshold, window := stormSettingsFor(baseDir)
+	storms := detectStorms(recent, threshold, window)
+	if len(storms) == 0 {
+		return check, false
+	}
+
+	total := len(storms)
+	if len(storms) > maxStorms {
+		storms = storms[:maxStorms]
+	}
+	redactor := entryPolicyFor(baseDir).redactor
+	var parts []string
+	for _, s := range storms {
+		s.Pattern = redactor.String(s.Pattern)
+		parts = append(parts, s.String())
+	}
+	check.Status = "warning"
+	check.Message = fmt.Sprintf("%d error storm(s) in the last 24h, each likely one problem: %s", total, strings.Join(parts, "; "))
+	if total > len(storms) {
+		check.Message += fmt.Sprintf("; and %d more", total-len(storms))
+	}
+	return check, true
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckStorms(t *testing.T) {
+	now := time.Now().UTC()
+	if _, ok := checkStorms(t.TempDir(), now); ok {
+		t.Error("checkStorms() should skip a project without a log")
+	}
+
+	dir := writeStormLog(t, now)
+	check, ok := checkStorms(dir, now)
+	if !ok || check.Status != "warning" || !strings.HasPrefix(check.Message, "2 error storm(s) in the last 24h") ||
+		!strings.Contains(check.Message, `NETWORK_ERROR "timeout after <n>ms" 60x`) {
+		t.Errorf("check = %+v", check)
+	}
+
+	// A day later they're past the lookback
+	if check, ok := checkStorms(dir, now.Add(25*time.Hour)); ok {
+		t.Errorf("expected no storms in the last 24h, got %+v", check)
+	}
+}
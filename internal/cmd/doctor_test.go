@@ -220,7 +220,7 @@ func TestCheckHealth(t *testing.T) {
 				return tmpDir
 			},
 			wantStatus: "healthy",
-			wantChecks: 4, // directory, file, jsonl valid, file size
+			wantChecks: 9, // directory, file, jsonl valid, torn lines, file size, timestamps, git tracking, integrations, endpoint
 		},
 		{
 			name: "missing directory",
@@ -246,3 +246,75 @@ func TestCheckHealth(t *testing.T) {
 		})
 	}
 }
+
+func TestDoctorCommand_StrictExitCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(dir string)
+		wantCode int
+	}{
+		{
+			name: "healthy",
+			setup: func(dir string) {
+				os.MkdirAll(filepath.Join(dir, ".agentlog"), 0755)
+			},
+			wantCode: 0,
+		},
+		{
+			name: "warning",
+			setup: func(dir string) {
+				os.MkdirAll(filepath.Join(dir, ".agentlog"), 0755)
+				os.WriteFile(filepath.Join(dir, ".agentlog", "errors.jsonl"), []byte("not json\n"), 0644)
+			},
+			wantCode: 1,
+		},
+		{
+			name: "unhealthy",
+			setup: func(dir string) {
+				os.WriteFile(filepath.Join(dir, ".agentlog"), []byte("not a directory"), 0644)
+			},
+			wantCode: 2,
+		},
+		{
+			name:     "not initialized",
+			setup:    func(dir string) {},
+			wantCode: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			tt.setup(tmpDir)
+			origDir, _ := os.Getwd()
+			os.Chdir(tmpDir)
+			defer os.Chdir(origDir)
+
+			doctorStrict = true
+			defer func() { doctorStrict = false }()
+			buf := new(bytes.Buffer)
+			doctorCmd.SetOut(buf)
+
+			err := runDoctor(doctorCmd, []string{})
+			code := 0
+			if err != nil {
+				code = ExitCode(err)
+			}
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d (err %v)\n%s", code, tt.wantCode, err, buf.String())
+			}
+		})
+	}
+}
+
+func TestDoctorCommand_NotStrictExitsZero(t *testing.T) {
+	tmpDir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(origDir)
+
+	doctorCmd.SetOut(new(bytes.Buffer))
+	if err := runDoctor(doctorCmd, []string{}); err != nil {
+		t.Errorf("expected no error without --strict, got %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/logfile"
+)
+
+const (
+	// futureTolerance allows for small clock differences before an entry
+	// counts as future-dated
+	futureTolerance = time.Minute
+	// skewLimit is how far apart two sources' clocks may be, judged by
+	// entries that share a correlation key, before doctor warns
+	skewLimit = 30 * time.Second
+)
+
+// timestampProblems counts the bad timestamps checkTimestamps found, with
+// the first few line numbers of each kind
+type timestampProblems struct {
+	future, nonUTC, ambiguous, missing         int
+	futureAt, nonUTCAt, ambiguousAt, missingAt []int
+}
+
+func noteLine(lines []int, n int) []int {
+	if len(lines) < 5 {
+		lines = append(lines, n)
+	}
+	return lines
+}
+
+// checkTimestamps looks for timestamps that break --since filtering and
+// prime's hourly windows: future-dated entries, offsets other than UTC,
+// values that aren't RFC3339 at all, and clock skew between sources. Lines
+// over maxLine are left to the JSONL check.
+func checkTimestamps(filePath string, maxLine int, now time.Time) HealthCheck {
+	check := HealthCheck{Name: "Timestamps"}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		check.Status = "error"
+		check.Message = fmt.Sprintf("Cannot open file: %v", err)
+		return check
+	}
+	defer file.Close()
+
+	var p timestampProblems
+	var timed []ErrorEntry
+	scanner := logfile.NewLines(file, maxLine)
+	for scanner.Scan() {
+		lineNum := scanner.Line()
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var raw struct {
+			Timestamp interface{}            `json:"timestamp"`
+			Source    string                 `json:"source"`
+			Context   map[string]interface{} `json:"context"`
+		}
+		if json.Unmarshal([]byte(line), &raw) != nil {
+			continue // reported by the JSONL check
+		}
+
+		ts, isString := raw.Timestamp.(string)
+		switch {
+		case raw.Timestamp == nil || (isString && ts == ""):
+			p.missing++
+			p.missingAt = noteLine(p.missingAt, lineNum)
+			continue
+		case !isString:
+			p.ambiguous++
+			p.ambiguousAt = noteLine(p.ambiguousAt, lineNum)
+			continue
+		}
+		t, err := parseEntryTime(ts)
+		if err != nil {
+			p.ambiguous++
+			p.ambiguousAt = noteLine(p.ambiguousAt, lineNum)
+			continue
+		}
+		if _, offset := t.Zone(); offset != 0 {
+			p.nonUTC++
+			p.nonUTCAt = noteLine(p.nonUTCAt, lineNum)
+		}
+		if t.After(now.Add(futureTolerance)) {
+			p.future++
+			p.futureAt = noteLine(p.futureAt, lineNum)
+		}
+		timed = append(timed, ErrorEntry{Timestamp: ts, Source: raw.Source, Context: raw.Context})
+	}
+	if err := scanner.Err(); err != nil {
+		check.Status = "error"
+		check.Message = fmt.Sprintf("Error reading file: %v", err)
+		return check
+	}
+
+	var problems []string
+	if p.future > 0 {
+		problems = append(problems, fmt.Sprintf("%d in the future (lines: %s)", p.future, formatLineNumbers(p.futureAt)))
+	}
+	if p.ambiguous > 0 {
+		problems = append(problems, fmt.Sprintf("%d not RFC3339 with a zone, so --since skips them (lines: %s)", p.ambiguous, formatLineNumbers(p.ambiguousAt)))
+	}
+	if p.missing > 0 {
+		problems = append(problems, fmt.Sprintf("%d missing (lines: %s)", p.missing, formatLineNumbers(p.missingAt)))
+	}
+	if p.nonUTC > 0 {
+		problems = append(problems, fmt.Sprintf("%d not in UTC (lines: %s); write them with a Z suffix", p.nonUTC, formatLineNumbers(p.nonUTCAt)))
+	}
+	problems = append(problems, clockSkew(timed)...)
+
+	if len(problems) > 0 {
+		check.Status = "warning"
+		check.Message = "Timestamps: " + strings.Join(problems, "; ")
+		return check
+	}
+	check.Status = "ok"
+	check.Message = fmt.Sprintf("All %d timestamps are valid UTC", len(timed))
+	return check
+}
+
+// clockSkew compares the timestamps of entries from different sources that
+// share a correlation key: they describe the same request, so a consistent
+// gap means one side's clock is off
+func clockSkew(entries []ErrorEntry) []string {
+	type pair struct{ a, b string }
+	gaps := make(map[pair][]time.Duration)
+	for _, key := range correlationKeys {
+		byValue := make(map[string][]ErrorEntry)
+		for _, e := range entries {
+			if v, ok := e.Context[key]; ok && v != nil {
+				byValue[formatCell(v)] = append(byValue[formatCell(v)], e)
+			}
+		}
+		for _, group := range byValue {
+			for x := 0; x < len(group); x++ {
+				for y := x + 1; y < len(group); y++ {
+					a, b := group[x], group[y]
+					if a.Source == b.Source || a.Source == "" || b.Source == "" {
+						continue
+					}
+					if sourceRank(b.Source) < sourceRank(a.Source) || (sourceRank(b.Source) == sourceRank(a.Source) && b.Source < a.Source) {
+						a, b = b, a
+					}
+					ta, _ := parseEntryTime(a.Timestamp)
+					tb, _ := parseEntryTime(b.Timestamp)
+					gaps[pair{a.Source, b.Source}] = append(gaps[pair{a.Source, b.Source}], ta.Sub(tb))
+				}
+			}
+		}
+	}
+
+	var problems []string
+	for p, ds := range gaps {
+		sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+		median := ds[len(ds)/2]
+		if median > -skewLimit && median < skewLimit {
+			continue
+		}
+		direction := "ahead of"
+		if median < 0 {
+			direction, median = "behind", -median
+		}
+		problems = append(problems, fmt.Sprintf("%s clock is about %s %s %s (median of %d correlated entries); check the machines' time sync",
+			p.a, median.Round(time.Second), direction, p.b, len(ds)))
+	}
+	sort.Strings(problems)
+	return problems
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeLog(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "errors.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+var timestampNow = time.Date(2025, 12, 10, 20, 0, 0, 0, time.UTC)
+
+func TestCheckTimestamps_Valid(t *testing.T) {
+	path := writeLog(t,
+		`{"timestamp":"2025-12-10T19:19:32.941Z","source":"frontend","error_type":"X","message":"a"}`,
+		`{"timestamp":"2025-12-10T19:19:33Z","source":"backend","error_type":"X","message":"b"}`,
+	)
+	check := checkTimestamps(path, 0, timestampNow)
+	if check.Status != "ok" {
+		t.Errorf("expected ok, got %s: %s", check.Status, check.Message)
+	}
+}
+
+func TestCheckTimestamps_Problems(t *testing.T) {
+	path := writeLog(t,
+		`{"timestamp":"2025-12-11T19:00:00Z","source":"frontend","error_type":"X","message":"future"}`,
+		`{"timestamp":"2025-12-10T21:00:00+02:00","source":"backend","error_type":"X","message":"offset"}`,
+		`{"timestamp":"2025-12-10 19:00:00","source":"backend","error_type":"X","message":"no zone"}`,
+		`{"timestamp":1765393172,"source":"backend","error_type":"X","message":"epoch"}`,
+		`{"source":"backend","error_type":"X","message":"missing"}`,
+	)
+	check := checkTimestamps(path, 0, timestampNow)
+	if check.Status != "warning" {
+		t.Fatalf("expected warning, got %s: %s", check.Status, check.Message)
+	}
+	for _, want := range []string{
+		"1 in the future (lines: 1)",
+		"1 not in UTC (lines: 2)",
+		"2 not RFC3339 with a zone, so --since skips them (lines: 3, 4)",
+		"1 missing (lines: 5)",
+	} {
+		if !strings.Contains(check.Message, want) {
+			t.Errorf("expected %q in message, got: %s", want, check.Message)
+		}
+	}
+}
+
+func TestCheckTimestamps_ClockSkew(t *testing.T) {
+	var lines []string
+	for i, id := range []string{"r1", "r2", "r3"} {
+		backend := timestampNow.Add(-time.Duration(10-i) * time.Minute)
+		frontend := backend.Add(3 * time.Minute)
+		lines = append(lines,
+			`{"timestamp":"`+frontend.Format(time.RFC3339)+`","source":"frontend","error_type":"NETWORK_ERROR","message":"m","context":{"request_id":"`+id+`"}}`,
+			`{"timestamp":"`+backend.Format(time.RFC3339)+`","source":"backend","error_type":"DATABASE_ERROR","message":"m","context":{"request_id":"`+id+`"}}`,
+		)
+	}
+	check := checkTimestamps(writeLog(t, lines...), 0, timestampNow)
+	want := "frontend clock is about 3m0s ahead of backend (median of 3 correlated entries)"
+	if check.Status != "warning" || !strings.Contains(check.Message, want) {
+		t.Errorf("expected %q, got %s: %s", want, check.Status, check.Message)
+	}
+}
+
+func TestCheckTimestamps_SmallSkewIgnored(t *testing.T) {
+	path := writeLog(t,
+		`{"timestamp":"2025-12-10T19:00:02Z","source":"frontend","error_type":"X","message":"a","context":{"trace_id":"t1"}}`,
+		`{"timestamp":"2025-12-10T19:00:00Z","source":"backend","error_type":"X","message":"b","context":{"trace_id":"t1"}}`,
+	)
+	if check := checkTimestamps(path, 0, timestampNow); check.Status != "ok" {
+		t.Errorf("expected ok for a 2s gap, got %s: %s", check.Status, check.Message)
+	}
+}
@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/config"
+	"github.com/agentlog/agentlog/internal/diag"
+	"github.com/agentlog/agentlog/internal/logfile"
+)
+
+const editsFileName = "edits.jsonl"
+
+// editWindow is how long before an error type first appeared an edit must
+// have been made to be named as a likely cause
+const editWindow = 10 * time.Minute
+
+// editSnapshotInterval is how often the working tree is checked for edits:
+// at most this often by writers, and this often by serve and tail
+const editSnapshotInterval = 5 * time.Second
+
+// FileEdit is one line of .agentlog/edits.jsonl: a working-tree file git
+// reports as changed, and when it was last modified
+type FileEdit struct {
+	File     string `json:"file"`
+	Modified string `json:"modified"`
+}
+
+func editsPath(baseDir string) string {
+	return filepath.Join(baseDir, ".agentlog", editsFileName)
+}
+
+// tracksEdits reports whether "track_edits" is set in baseDir's config
+func tracksEdits(baseDir string) bool {
+	cfg, err := config.Load(baseDir)
+	return err == nil && cfg.TrackEdits
+}
+
+// lastEditSnapshot throttles recordEdits across the writers in a process
+var lastEditSnapshot struct {
+	sync.Mutex
+	at map[string]time.Time
+}
+
+// recordEdits appends the files git reports as modified, staged, or
+// untracked under baseDir whose modification time is newer than the one
+// last recorded for them. Snapshots closer together than
+// editSnapshotInterval are skipped.
+func recordEdits(baseDir string, now time.Time) error {
+	lastEditSnapshot.Lock()
+	if lastEditSnapshot.at == nil {
+		lastEditSnapshot.at = make(map[string]time.Time)
+	}
+	if now.Sub(lastEditSnapshot.at[baseDir]) < editSnapshotInterval {
+		lastEditSnapshot.Unlock()
+		return nil
+	}
+	lastEditSnapshot.at[baseDir] = now
+	lastEditSnapshot.Unlock()
+
+	changed, err := changedFiles(baseDir)
+	if err != nil {
+		return err
+	}
+	recorded, err := readEdits(baseDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	latest := make(map[string]string, len(recorded))
+	for _, e := range recorded {
+		if e.Modified > latest[e.File] {
+			latest[e.File] = e.Modified
+		}
+	}
+
+	var sb strings.Builder
+	for _, file := range changed {
+		info, err := os.Stat(filepath.Join(baseDir, file))
+		if err != nil || info.IsDir() {
+			continue // deleted since git listed it
+		}
+		modified := info.ModTime().UTC().Format("2006-01-02T15:04:05.000Z")
+		if modified <= latest[file] {
+			continue
+		}
+		data, _ := json.Marshal(FileEdit{File: filepath.ToSlash(file), Modified: modified})
+		sb.Write(append(data, '\n'))
+	}
+	if sb.Len() == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Join(baseDir, ".agentlog"), 0755); err != nil {
+		return err
+	}
+	return logfile.Append(editsPath(baseDir), []byte(sb.String()))
+}
+
+// changedFiles lists the files under baseDir that differ from HEAD or
+// aren't tracked, relative to baseDir, skipping .agentlog/
+func changedFiles(baseDir string) ([]string, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git not found")
+	}
+	worktree, err := gitOutput(baseDir, "ls-files", "-z", "--modified", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	staged, err := gitOutput(baseDir, "diff", "--cached", "--name-only", "--relative", "-z")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var files []string
+	for _, f := range strings.Split(worktree+"\x00"+staged, "\x00") {
+		if f == "" || seen[f] || strings.HasPrefix(f, ".agentlog/") {
+			continue
+		}
+		seen[f] = true
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// readEdits reads .agentlog/edits.jsonl, skipping lines that don't parse
+func readEdits(baseDir string) ([]FileEdit, error) {
+	f, err := os.Open(editsPath(baseDir))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var edits []FileEdit
+	err = scanRecords(baseDir, f, func(line []byte) error {
+		var e FileEdit
+		if err := json.Unmarshal(line, &e); err != nil {
+			return err
+		}
+		if e.File != "" {
+			edits = append(edits, e)
+		}
+		return nil
+	})
+	return edits, err
+}
+
+// watchEdits records edits every editSnapshotInterval until the returned
+// function is called. It does nothing unless baseDir tracks edits.
+func watchEdits(baseDir string) (stop func()) {
+	if !tracksEdits(baseDir) {
+		return func() {}
+	}
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(editSnapshotInterval)
+		defer ticker.Stop()
+		for {
+			if err := recordEdits(baseDir, time.Now()); err != nil {
+				diag.Debugf("recording edits: %v", err)
+			}
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// EditCorrelation is an error type that first appeared shortly after
+// files were edited
+type EditCorrelation struct {
+	ErrorType string   `json:"error_type"`
+	Count     int      `json:"count"`
+	Started   string   `json:"started"`
+	Files     []string `json:"files"` // edited within editWindow before, latest first
+	Gap       string   `json:"gap"`   // from the latest of those edits to the first error
+}
+
+// String describes the correlation as one sentence, e.g. "NETWORK_ERROR
+// (12) started 2m after src/api/users.ts was modified"
+func (c EditCorrelation) String() string {
+	verb := "was"
+	if len(c.Files) > 1 {
+		verb = "were"
+	}
+	return fmt.Sprintf("%s (%d) started %s after %s %s modified", c.ErrorType, c.Count, c.Gap, strings.Join(c.Files, ", "), verb)
+}
+
+// correlateEdits finds the error types that first appeared in the last 24
+// hours within editWindow of recorded edits, most occurrences first, and
+// returns up to n of them. entries are the whole log, so an error type
+// that was already happening before the edits isn't blamed on them.
+func correlateEdits(entries []ErrorEntry, edits []FileEdit, now time.Time, n int) []EditCorrelation {
+	if len(edits) == 0 {
+		return nil
+	}
+	type onset struct {
+		first time.Time
+		count int
+	}
+	types := make(map[string]*onset)
+	for _, e := range entries {
+		ts, err := parseEntryTime(e.firstSeen())
+		if err != nil {
+			continue
+		}
+		o := types[e.ErrorType]
+		if o == nil {
+			o = &onset{first: ts}
+			types[e.ErrorType] = o
+		}
+		if ts.Before(o.first) {
+			o.first = ts
+		}
+		o.count += e.occurrences()
+	}
+
+	var result []EditCorrelation
+	for errorType, o := range types {
+		if o.first.Before(now.Add(-primeRecentWindow)) {
+			continue
+		}
+		latest := make(map[string]time.Time)
+		for _, edit := range edits {
+			ts, err := parseEntryTime(edit.Modified)
+			if err != nil || ts.After(o.first) || o.first.Sub(ts) > editWindow {
+				continue
+			}
+			if ts.After(latest[edit.File]) {
+				latest[edit.File] = ts
+			}
+		}
+		if len(latest) == 0 {
+			continue
+		}
+		files := make([]string, 0, len(latest))
+		for f := range latest {
+			files = append(files, f)
+		}
+		sort.Slice(files, func(i, j int) bool {
+			if !latest[files[i]].Equal(latest[files[j]]) {
+				return latest[files[i]].After(latest[files[j]])
+			}
+			return files[i] < files[j]
+		})
+		if len(files) > 3 {
+			files = files[:3]
+		}
+		result = append(result, EditCorrelation{
+			ErrorType: errorType,
+			Count:     o.count,
+			Started:   o.first.UTC().Format(time.RFC3339),
+			Files:     files,
+			Gap:       shortGap(o.first.Sub(latest[files[0]])),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].ErrorType < result[j].ErrorType
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
+
+// shortGap formats d to the second, or the minute past one: "40s", "3m"
+func shortGap(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d/time.Second))
+	}
+	return fmt.Sprintf("%dm", int(d/time.Minute))
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordEdits(t *testing.T) {
+	dir := gitRepo(t)
+	os.WriteFile(filepath.Join(dir, ".agentlog", "capture.ts"), []byte("// edited\n"), 0644)
+	os.MkdirAll(filepath.Join(dir, "src", "api"), 0755)
+	os.WriteFile(filepath.Join(dir, "src", "api", "users.ts"), []byte("export {}\n"), 0644)
+
+	now := time.Now()
+	if err := recordEdits(dir, now); err != nil {
+		t.Fatalf("recordEdits() error = %v", err)
+	}
+	edits, err := readEdits(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 1 || edits[0].File != "src/api/users.ts" {
+		t.Fatalf("edits = %+v, want only src/api/users.ts (.agentlog/ is skipped)", edits)
+	}
+
+	// Unchanged files aren't recorded twice; touched ones are
+	if err := recordEdits(dir, now.Add(editSnapshotInterval)); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(dir, "src", "api", "users.ts"), later, later)
+	recordEdits(dir, now.Add(editSnapshotInterval/2*3)) // throttled
+	if edits, _ := readEdits(dir); len(edits) != 1 {
+		t.Errorf("expected 1 edit before the next snapshot, got %+v", edits)
+	}
+	recordEdits(dir, now.Add(2*editSnapshotInterval))
+	if edits, _ := readEdits(dir); len(edits) != 2 {
+		t.Errorf("expected the touched file to be recorded again, got %+v", edits)
+	}
+}
+
+func TestRecordEdits_NotRepo(t *testing.T) {
+	dir := t.TempDir()
+	if err := recordEdits(dir, time.Now()); err == nil {
+		t.Error("recordEdits() outside a git repo should fail")
+	}
+	if _, err := os.Stat(editsPath(dir)); !os.IsNotExist(err) {
+		t.Error("nothing should be written outside a git repo")
+	}
+}
+
+func TestCorrelateEdits(t *testing.T) {
+	now := time.Date(2025, 12, 10, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) string { return now.Add(-d).Format("2006-01-02T15:04:05.000Z") }
+	entries := []ErrorEntry{
+		{Timestamp: at(30 * time.Minute), ErrorType: "NETWORK_ERROR", Count: 10, FirstSeen: at(58 * time.Minute)},
+		{Timestamp: at(20 * time.Minute), ErrorType: "NETWORK_ERROR", Count: 2},
+		{Timestamp: at(45 * time.Minute), ErrorType: "TYPE_ERROR"},     // edited too long before
+		{Timestamp: at(95 * time.Minute), ErrorType: "OLD_ERROR"},      // nothing edited before
+		{Timestamp: at(59 * time.Minute), ErrorType: "LONG_RUNNING"},   // started before these edits...
+		{Timestamp: at(48 * time.Hour), ErrorType: "LONG_RUNNING"},     // ...days ago
+		{Timestamp: at(56 * time.Minute), ErrorType: "UNCAUGHT_ERROR"}, // edits 4m and 34m before
+	}
+	edits := []FileEdit{
+		{File: "src/api/users.ts", Modified: at(61 * time.Minute)},
+		{File: "src/api/users.ts", Modified: at(60 * time.Minute)},
+		{File: "src/db.ts", Modified: at(90 * time.Minute)},
+		{File: "README.md", Modified: at(10 * time.Minute)},
+		{File: "bad", Modified: "yesterday"},
+	}
+
+	got := correlateEdits(entries, edits, now, 3)
+	want := []EditCorrelation{
+		{ErrorType: "NETWORK_ERROR", Count: 12, Started: now.Add(-58 * time.Minute).Format(time.RFC3339), Files: []string{"src/api/users.ts"}, Gap: "2m"},
+		{ErrorType: "UNCAUGHT_ERROR", Count: 1, Started: now.Add(-56 * time.Minute).Format(time.RFC3339), Files: []string{"src/api/users.ts"}, Gap: "4m"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("correlateEdits() = %+v, want %+v", got, want)
+	}
+	if s := got[0].String(); s != "NETWORK_ERROR (12) started 2m after src/api/users.ts was modified" {
+		t.Errorf("String() = %q", s)
+	}
+	if got := correlateEdits(entries, nil, now, 3); got != nil {
+		t.Errorf("correlateEdits() without edits = %+v", got)
+	}
+}
+
+func TestPrimeSummary_RecentEdits(t *testing.T) {
+	dir := gitRepo(t)
+	os.WriteFile(filepath.Join(dir, ".agentlog", "config.json"), []byte(`{"track_edits": true}`), 0644)
+	os.WriteFile(filepath.Join(dir, "users.ts"), []byte("export {}\n"), 0644)
+	edited := time.Now().Add(-3*time.Minute - 30*time.Second)
+	os.Chtimes(filepath.Join(dir, "users.ts"), edited, edited)
+
+	originalPath, originalNoCache := pathOverride, primeNoCache
+	defer func() { pathOverride, primeNoCache = originalPath, originalNoCache }()
+	pathOverride, primeNoCache = dir, true
+	os.Remove(GetErrorsPath(dir))
+	if err := appendErrors(dir, []ErrorEntry{{Timestamp: time.Now().UTC().Format(time.RFC3339), Source: "backend", ErrorType: "NETWORK_ERROR", Message: "fetch failed"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := generatePrimeSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.RecentEdits) != 1 || summary.RecentEdits[0].Files[0] != "users.ts" {
+		t.Fatalf("RecentEdits = %+v", summary.RecentEdits)
+	}
+	if out := formatPrimeSummaryHuman(summary); !strings.Contains(out, "After edits: NETWORK_ERROR (1) started 3m after users.ts was modified") {
+		t.Errorf("human output should name the edit:\n%s", out)
+	}
+}
@@ -1,32 +1,212 @@
 package cmd
 
 import (
-	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"os/user"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"syscall"
+	"text/template"
 	"time"
 
+	"github.com/agentlog/agentlog/internal/config"
+	"github.com/agentlog/agentlog/internal/diag"
+	"github.com/agentlog/agentlog/internal/logfile"
 	"github.com/agentlog/agentlog/internal/self"
 	"github.com/spf13/cobra"
 )
 
 // ErrorEntry represents a single error from errors.jsonl
 type ErrorEntry struct {
-	Timestamp string                 `json:"timestamp"`
-	Source    string                 `json:"source"`
-	ErrorType string                 `json:"error_type"`
-	Message   string                 `json:"message"`
-	Context   map[string]interface{} `json:"context,omitempty"`
+	// ID is a short content hash computed when the entry is read; it is
+	// never stored in errors.jsonl
+	ID          string                 `json:"id,omitempty"`
+	Timestamp   string                 `json:"timestamp"`
+	Source      string                 `json:"source"`
+	ErrorType   string                 `json:"error_type"`
+	Message     string                 `json:"message"`
+	Project     string                 `json:"project,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	Host        string                 `json:"host,omitempty"`
+	User        string                 `json:"user,omitempty"`
+	Agent       string                 `json:"agent,omitempty"`
+	File        string                 `json:"file,omitempty"`
+	Line        int                    `json:"line,omitempty"`
+	Column      int                    `json:"column,omitempty"`
+	Endpoint    string                 `json:"endpoint,omitempty"`
+	Kind        string                 `json:"kind,omitempty"`
+	DurationMs  float64                `json:"duration_ms,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+	Context     map[string]interface{} `json:"context,omitempty"`
+
+	// Count, FirstSeen, and LastSeen are set on entries that agentlog
+	// dedupe collapsed from a run of repeats; Timestamp is then the most
+	// recent occurrence
+	Count     int    `json:"count,omitempty"`
+	FirstSeen string `json:"first_seen,omitempty"`
+	LastSeen  string `json:"last_seen,omitempty"`
+
+	// Regression is set by errors and tail on entries of a resolved error
+	// that occurred again (see 'agentlog resolve'); it is never stored
+	Regression *Regression `json:"regression,omitempty"`
+
+	// PastFixes are set by errors and tail on entries of an error fixed
+	// before they occurred (see 'agentlog resolve --note'), the latest
+	// first; they're never stored
+	PastFixes []Fix `json:"past_fixes,omitempty"`
+
+	// SnoozeExpired is set by errors on entries of a snoozed error that
+	// occurred since it was snoozed, once the snooze is over (see 'agentlog
+	// snooze'); it is never stored
+	SnoozeExpired *SnoozeExpiry `json:"snooze_expired,omitempty"`
+
+	// Suggestion is set by errors --full on entries a suggestion rule
+	// matches; it is never stored
+	Suggestion *Suggestion `json:"suggestion,omitempty"`
+}
+
+// Entry kinds. Entries without a kind are errors. Healthcheck entries are
+// written by 'agentlog doctor' to test the capture pipeline, and skipped by
+// readErrors.
+const (
+	kindError       = "error"
+	kindPerf        = "perf"
+	kindHealthcheck = "healthcheck"
+)
+
+// kind returns the entry's kind, defaulting to error
+func (e ErrorEntry) kind() string {
+	if e.Kind == "" {
+		return kindError
+	}
+	return e.Kind
+}
+
+// occurrences returns how many times the entry's error happened: its Count
+// when it was collapsed by dedupe, otherwise 1
+func (e ErrorEntry) occurrences() int {
+	if e.Count > 1 {
+		return e.Count
+	}
+	return 1
+}
+
+// totalOccurrences sums occurrences over entries
+func totalOccurrences(entries []ErrorEntry) int {
+	total := 0
+	for _, e := range entries {
+		total += e.occurrences()
+	}
+	return total
+}
+
+// UnmarshalJSON decodes an entry, moving file/line/column/endpoint written
+// to context by older snippets into their top-level fields, and computes
+// its ID
+func (e *ErrorEntry) UnmarshalJSON(data []byte) error {
+	type plain ErrorEntry
+	if err := json.Unmarshal(data, (*plain)(e)); err != nil {
+		return err
+	}
+	e.promoteLocation()
+	e.ID = entryID(*e)
+	return nil
+}
+
+// entryIDLength is the number of hex characters in an entry ID
+const entryIDLength = 10
+
+// entryID returns a short hash of the entry's content, stable across reads
+// so agents can refer to the same entry from one turn to the next
+func entryID(e ErrorEntry) string {
+	e.ID, e.Regression, e.PastFixes, e.SnoozeExpired, e.Suggestion = "", nil, nil, nil, nil
+	data, _ := json.Marshal(e)
+	sum := sha1.Sum(data)
+	return hex.EncodeToString(sum[:])[:entryIDLength]
+}
+
+// promoteLocation moves legacy location keys out of context. Keys are left
+// in place when the top-level field is already set or the value has the
+// wrong type.
+func (e *ErrorEntry) promoteLocation() {
+	if len(e.Context) == 0 {
+		return
+	}
+	if v, ok := e.Context["file"].(string); ok && e.File == "" {
+		e.File = v
+		delete(e.Context, "file")
+	}
+	if v, ok := contextInt(e.Context["line"]); ok && e.Line == 0 {
+		e.Line = v
+		delete(e.Context, "line")
+	}
+	if v, ok := contextInt(e.Context["column"]); ok && e.Column == 0 {
+		e.Column = v
+		delete(e.Context, "column")
+	}
+	if v, ok := e.Context["endpoint"].(string); ok && e.Endpoint == "" {
+		e.Endpoint = v
+		delete(e.Context, "endpoint")
+	}
+	if len(e.Context) == 0 {
+		e.Context = nil
+	}
+}
+
+// contextInt converts a decoded JSON number (or numeric string) to an int
+func contextInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case float64:
+		return int(n), n == float64(int(n))
+	case int:
+		return n, true
+	case string:
+		i, err := strconv.Atoi(n)
+		return i, err == nil
+	}
+	return 0, false
 }
 
 var (
-	errorsLimit  int
-	errorsSource string
-	errorsType   string
-	errorsSince  string
+	errorsLimit      int
+	errorsSource     string
+	errorsType       string
+	errorsSince      string
+	errorsGroup      bool
+	errorsFile       string
+	errorsEndpoint   string
+	errorsTags       []string
+	errorsExcludeTag []string
+	errorsProject    string
+	errorsEnv        string
+	errorsHost       string
+	errorsUser       string
+	errorsAgent      string
+	errorsTestRun    string
+	errorsIDs        []string
+	errorsWhere      []string
+	errorsQuery      string
+	errorsKind       string
+	errorsFields     string
+	errorsTemplate   string
+	errorsNoFormat   bool
+	errorsCount      bool
+	errorsGroupBy    string
+	errorsWatch      bool
+	errorsPick       bool
+	errorsInterval   time.Duration
+	errorsOutput     string
+	errorsFull       bool
 )
 
 // errorsCmd represents the errors command
@@ -35,16 +215,101 @@ var errorsCmd = &cobra.Command{
 	Short: "Query and display errors from .agentlog/errors.jsonl",
 	Long: `Query and display errors from the local .agentlog/errors.jsonl file.
 
-Supports filtering by source, type, and time. Output is human-readable by
+Supports filtering by source, type, time, and any context key (--where). Output is human-readable by
 default, or JSON with the --json flag.
 
+--query filters with an expression instead of separate flags: comparisons
+joined with and, or, not, and parentheses, e.g.
+  type=DATABASE_ERROR and (source=backend or source=worker) and message~timeout
+Operators are = and != (exact), ~ and !~ (substring, or a regular
+expression as ~/pattern/), and <, <=, >, >= for numbers and timestamps
+(timestamp>1h is the last hour; any --since value works). As with --where,
+names that aren't entry fields are context keys, and an entry without the
+field fails the comparison. Quote values with spaces: message~"not found".
+
+--template renders each entry with a Go template instead. Fields are the
+entry's Go names (.ID, .Timestamp, .Source, .ErrorType, .Message, .File,
+.Line, .Column, .Endpoint, .Project, .Environment, .Host, .User, .Agent,
+.Tags, .Context) and the functions join, json, and truncate are available.
+A default can be set as "errors": {"template": "..."} in
+.agentlog/config.json.
+
+"formatter": "./scripts/format.sh" in .agentlog/config.json hands the
+human-readable list to a command instead: it's run through the shell in
+the project directory, reads the entries as NDJSON on stdin, and its
+output is printed in their place. It gets the variables plugins do, plus
+AGENTLOG_TOTAL, the matches before --limit. --template and
+--no-formatter bypass it; --json, --fields, --group, --count, and --pick
+don't use it.
+
+Logs over 64MB are read from the end, a chunk at a time, until --limit
+matches are found, so listing the latest errors stays fast however long
+the log has grown. --group, --count, and --pick read the whole file; scans
+of a large log show their progress on stderr.
+
+--output ndjson writes each match as one compact JSON object per line
+instead of an array. With --limit 0 every match is written as soon as it's
+read, so any number of results can be piped on in constant memory; with a
+limit, only the last --limit matches are held until the end of the log.
+
+Secrets and personal data are masked in what's shown: bearer tokens, API
+keys and labeled credentials ("password=..."), emails, and card numbers.
+Filters still match the stored text. agentlog's own writers (serve, ingest,
+the Node capture) mask them before writing too. Add patterns, or turn
+masking off, under "redact" in .agentlog/config.json:
+  {"redact": {"patterns": ["cust_[0-9]{6}"]}}
+  {"redact": {"disabled": true}}
+
+Context keys listed under "context" are dropped too, on write and on read.
+"deny" keys match at any depth, dotted paths ("request.body") from the top;
+"allow" keeps only the top-level keys it lists:
+  {"context": {"deny": ["headers", "body", "env", "request.cookies"]}}
+
+Entries of an error marked fixed with 'agentlog resolve' that occurred
+again are labeled REGRESSION, with a "regression" object in JSON. Entries
+of an error whose fix was recorded with 'agentlog resolve --note' or
+--commit list it as "Fixed before:", with "past_fixes" in JSON. Errors
+snoozed with 'agentlog snooze' are left out until the snooze ends; those
+still occurring after it are labeled SNOOZE EXPIRED, with a
+"snooze_expired" object in JSON.
+
+--full shows each entry's project, attribution, agent, and context, and a
+suggested next step for errors matching a common pattern (connection
+refused, CORS, undefined property access, database timeouts, ...), with a
+"suggestion" object in JSON. Rules under "suggestions" in config.json are
+checked before the built-in ones:
+  {"suggestions": [{"name": "stripe", "match": "(?i)stripe.*rate limit", "hint": "Retry with backoff"}]}
+
 Examples:
   agentlog errors                    # Show last 10 errors
   agentlog errors --limit 50         # Show last 50 errors
   agentlog errors --source frontend  # Show only frontend errors
   agentlog errors --type DATABASE_ERROR  # Show only database errors
   agentlog errors --since 1h         # Show errors from last hour
-  agentlog errors --json             # Output as JSON array`,
+  agentlog errors --group            # Collapse similar errors into groups
+  agentlog errors --file src/api.ts  # Errors raised in a file
+  agentlog errors --endpoint /api/users  # Errors for an endpoint
+  agentlog errors --tag checkout-v2  # Errors tagged checkout-v2
+  agentlog errors --exclude-tag flaky  # Hide errors tagged flaky
+  agentlog errors --project api      # Errors from one project in a merged log
+  agentlog errors --host ci-runner-2 # Errors recorded on one machine
+  agentlog errors --count --group-by agent  # Errors per agent session
+  agentlog errors --env test         # Errors raised during test runs
+  agentlog errors --test-run 20251210-150405-3f2a  # Errors from one 'capture --test-run'
+  agentlog errors --kind perf        # Slow requests, queries, and long tasks
+  agentlog errors --id 3f9a2c1b7e    # One entry by ID (a unique prefix works)
+  agentlog errors --where queue=emails --where user_id~42  # Match context values
+  agentlog errors --query 'type=DATABASE_ERROR and not message~/dead ?lock/'  # Expression filter
+  agentlog errors --fields timestamp,type,message,context.endpoint  # Only these columns
+  agentlog errors --template '{{.ErrorType}}: {{.Message}}'  # One line per entry
+  agentlog errors --count --since 1h  # Just the number of matching errors
+  agentlog errors --count --group-by type  # Count per error type
+  agentlog errors --watch --group    # Live view, redrawn as errors arrive
+  agentlog errors --pick --since 1h  # Fuzzy-search entries interactively
+  agentlog errors --full --since 1h  # Context and suggested next steps
+  agentlog errors --absolute         # Full timestamps instead of "3m ago"
+  agentlog errors --json             # Output as JSON array
+  agentlog errors --output ndjson --limit 0 | jq .message  # Stream every match, one per line`,
 	RunE: runErrors,
 }
 
@@ -54,7 +319,32 @@ func init() {
 	errorsCmd.Flags().IntVar(&errorsLimit, "limit", 10, "Maximum number of errors to show")
 	errorsCmd.Flags().StringVar(&errorsSource, "source", "", "Filter by source (frontend, backend, cli, worker, test)")
 	errorsCmd.Flags().StringVar(&errorsType, "type", "", "Filter by error type")
-	errorsCmd.Flags().StringVar(&errorsSince, "since", "", "Show errors since time (e.g., '1h', '30m', '2024-01-01')")
+	errorsCmd.Flags().StringVar(&errorsSince, "since", "", "Show errors since time (e.g., '1h', '2d', '1w', 'yesterday', '2024-01-01 14:00')")
+	errorsCmd.Flags().StringVar(&errorsFile, "file", "", "Filter by file (substring match)")
+	errorsCmd.Flags().StringVar(&errorsEndpoint, "endpoint", "", "Filter by endpoint (substring match)")
+	errorsCmd.Flags().StringSliceVar(&errorsIDs, "id", nil, "Show entries with this ID or ID prefix (repeatable)")
+	errorsCmd.Flags().StringArrayVar(&errorsWhere, "where", nil, "Filter on a field or context key: key=value (exact) or key~value (substring); repeatable")
+	errorsCmd.Flags().StringVar(&errorsQuery, "query", "", "Filter with an expression: and/or/not, parentheses, =, !=, ~ (substring or /regex/), !~, <, <=, >, >= over fields and context keys")
+	errorsCmd.Flags().StringVar(&errorsKind, "kind", "", "Filter by entry kind: error or perf (default: both)")
+	errorsCmd.Flags().StringVar(&errorsEnv, "env", "", "Filter by environment (dev, test, preview, staging)")
+	errorsCmd.Flags().StringVar(&errorsProject, "project", "", "Filter by project (entries without one belong to this directory's project)")
+	errorsCmd.Flags().StringVar(&errorsHost, "host", "", "Filter by the machine that recorded the error")
+	errorsCmd.Flags().StringVar(&errorsUser, "user", "", "Filter by the user who recorded the error")
+	errorsCmd.Flags().StringVar(&errorsAgent, "agent", "", "Filter by the AI agent or session that produced the error")
+	errorsCmd.Flags().StringVar(&errorsTestRun, "test-run", "", "Filter by test run (context.test_run_id, as set by 'agentlog capture --test-run')")
+	errorsCmd.Flags().StringSliceVar(&errorsTags, "tag", nil, "Only show errors with this tag (repeatable; all must match)")
+	errorsCmd.Flags().StringSliceVar(&errorsExcludeTag, "exclude-tag", nil, "Hide errors with this tag (repeatable)")
+	errorsCmd.Flags().StringVar(&errorsFields, "fields", "", "Comma-separated fields to show (e.g. timestamp,type,message,context.endpoint)")
+	errorsCmd.Flags().StringVar(&errorsTemplate, "template", "", "Go template rendered per entry (e.g. '{{.ErrorType}}: {{.Message}}')")
+	errorsCmd.Flags().BoolVar(&errorsNoFormat, "no-formatter", false, "Use the built-in human-readable output even if config.json sets a formatter")
+	errorsCmd.Flags().BoolVar(&errorsCount, "count", false, "Only print the number of matching errors (ignores --limit)")
+	errorsCmd.Flags().StringVar(&errorsGroupBy, "group-by", "", "With --count, count per value of this field (e.g. type, source, tags, context.endpoint)")
+	errorsCmd.Flags().BoolVar(&errorsWatch, "watch", false, "Redraw the view when errors.jsonl changes (Ctrl-C to exit)")
+	errorsCmd.Flags().BoolVar(&errorsPick, "pick", false, "Fuzzy-search matching errors interactively; prints the chosen entry as JSON (ctrl-o opens its file)")
+	errorsCmd.Flags().DurationVar(&errorsInterval, "interval", 2*time.Second, "With --watch, redraw at least this often")
+	errorsCmd.Flags().BoolVar(&errorsGroup, "group", false, "Group similar errors (numbers, IDs, and paths ignored), most frequent first")
+	errorsCmd.Flags().BoolVar(&errorsFull, "full", false, "Show each entry in full: attribution, context, and a suggested next step when a rule matches")
+	errorsCmd.Flags().StringVar(&errorsOutput, "output", "", "Output format: text, json (same as --json), or ndjson (one entry per line, streamed as found)")
 }
 
 func runErrors(cmd *cobra.Command, args []string) error {
@@ -69,19 +359,72 @@ func runErrors(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Read errors
-	entries, err := readErrors(baseDir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			fmt.Fprintln(cmd.OutOrStdout(), "No errors file found. Run 'agentlog init' to set up.")
-			return nil
+	switch errorsOutput {
+	case "", "text":
+	case "json":
+		jsonOutput = true
+	case "ndjson":
+		if IsJSONOutput() || errorsWatch || errorsPick || errorsCount || errorsGroup || errorsFields != "" || errorsTemplate != "" {
+			return invalidInput("--output ndjson can't be combined with --json, --watch, --pick, --count, --group, --fields, or --template")
 		}
+	default:
+		return invalidInput("invalid --output '%s' (want text, json, or ndjson)", errorsOutput)
+	}
+
+	if errorsWatch {
+		return watchErrors(cmd, baseDir)
+	}
+	if err := renderErrors(cmd.OutOrStdout(), baseDir); err != nil {
 		return err
 	}
+	if uninitialized(baseDir) {
+		return exitNotInitialized(cmd)
+	}
+	return nil
+}
 
-	if len(entries) == 0 {
-		fmt.Fprintln(cmd.OutOrStdout(), "No errors recorded yet.")
-		return nil
+// renderErrors reads, filters, and writes errors to w according to the
+// errors command's flags
+func renderErrors(w io.Writer, baseDir string) error {
+	var groupBy string
+	if errorsGroupBy != "" {
+		if !errorsCount {
+			return invalidInput("--group-by requires --count")
+		}
+		groupByFields, err := parseFields(errorsGroupBy)
+		if err != nil {
+			self.LogError(baseDir, "INVALID_INPUT", err.Error())
+			return err
+		}
+		if len(groupByFields) != 1 {
+			return invalidInput("--group-by takes a single field")
+		}
+		groupBy = groupByFields[0]
+	}
+
+	if errorsPick && (errorsCount || errorsGroup || errorsFields != "" || errorsTemplate != "") {
+		return invalidInput("--pick can't be combined with --count, --group, --fields, or --template")
+	}
+	if errorsFull && (errorsCount || errorsGroup || errorsFields != "" || errorsPick) {
+		return invalidInput("--full can't be combined with --count, --group, --fields, or --pick")
+	}
+
+	if !validKind(errorsKind) {
+		return invalidInput("invalid --kind '%s' (want error or perf)", errorsKind)
+	}
+
+	where, err := parseWhere(errorsWhere)
+	if err != nil {
+		self.LogError(baseDir, "INVALID_INPUT", err.Error())
+		return err
+	}
+
+	var query queryExpr
+	if errorsQuery != "" {
+		if query, err = parseQuery(errorsQuery); err != nil {
+			self.LogError(baseDir, "INVALID_INPUT", err.Error())
+			return err
+		}
 	}
 
 	// Parse --since if provided
@@ -90,92 +433,602 @@ func runErrors(cmd *cobra.Command, args []string) error {
 		sinceTime, err = parseSince(errorsSince)
 		if err != nil {
 			self.LogError(baseDir, "INVALID_INPUT", fmt.Sprintf("invalid --since value '%s': %v", errorsSince, err))
-			return fmt.Errorf("invalid --since value: %w", err)
+			return invalidInput("invalid --since value: %w", err)
+		}
+	}
+
+	var fields []string
+	if errorsFields != "" {
+		if errorsGroup {
+			return invalidInput("--fields can't be combined with --group")
+		}
+		fields, err = parseFields(errorsFields)
+		if err != nil {
+			self.LogError(baseDir, "INVALID_INPUT", err.Error())
+			return err
 		}
 	}
 
-	// Apply filters
-	filtered := filterErrors(entries, errorsSource, errorsType, sinceTime)
+	// A configured formatter takes over the human-readable list, unless
+	// --template asks for a template instead
+	otherMode := fields != nil || errorsGroup || errorsCount || errorsPick || IsJSONOutput()
+	var formatter string
+	if errorsTemplate == "" && !otherMode && !errorsNoFormat {
+		formatter = configuredFormatter(baseDir)
+	}
+	var tmpl *template.Template
+	if formatter == "" {
+		tmpl, err = errorsOutputTemplate(baseDir, errorsTemplate, otherMode)
+		if err != nil {
+			self.LogError(baseDir, "INVALID_INPUT", err.Error())
+			return err
+		}
+	}
+
+	project := projectName(baseDir)
+	policy := entryPolicyFor(baseDir)
+	resolutions := loadResolutions(baseDir)
+	snoozes := loadSnoozes(baseDir, time.Now())
+	suggestions := func(entries []ErrorEntry) []ErrorEntry {
+		if !errorsFull {
+			return entries
+		}
+		return suggesterFor(baseDir).mark(entries)
+	}
+	applyFilters := func(entries []ErrorEntry) []ErrorEntry {
+		filtered := filterErrors(entries, errorsSource, errorsType, sinceTime)
+		filtered = filterLocation(filtered, errorsFile, errorsEndpoint)
+		filtered = filterTags(filtered, errorsTags, errorsExcludeTag)
+		filtered = filterEnvironment(filtered, errorsEnv)
+		filtered = filterAttribution(filtered, errorsHost, errorsUser)
+		filtered = filterAgent(filtered, errorsAgent)
+		filtered = filterTestRun(filtered, errorsTestRun)
+		filtered = filterKind(filtered, errorsKind)
+		filtered = filterIDs(filtered, errorsIDs)
+		filtered = filterWhere(filtered, where)
+		filtered = filterQuery(filtered, query)
+		if errorsProject != "" {
+			filtered = filterProject(filtered, errorsProject, project)
+		}
+		return snoozes.hide(filtered)
+	}
+
+	if errorsOutput == "ndjson" {
+		return streamNDJSON(w, baseDir, errorsLimit, func(e ErrorEntry) bool {
+			return len(applyFilters([]ErrorEntry{e})) == 1
+		})
+	}
+
+	// The latest few matches of a large log are found reading it from the
+	// end, without loading the rest
+	listing := !errorsCount && !errorsPick && !errorsGroup
+	if info, err := os.Stat(GetErrorsPath(baseDir)); err == nil && info.Size() >= largeLogBytes && listing && errorsLimit > 0 {
+		latest, err := latestErrors(baseDir, errorsLimit, func(e ErrorEntry) bool {
+			return len(applyFilters([]ErrorEntry{e})) == 1
+		})
+		if err != nil {
+			return err
+		}
+		return writeErrorList(w, baseDir, policy.applyAll(suggestions(snoozes.mark(resolutions.mark(latest)))), len(latest), formatter, tmpl, fields)
+	}
+
+	// Read errors
+	entries, err := readErrors(baseDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			if errorsCount {
+				writeCount(w, CountResult{})
+				return nil
+			}
+			fmt.Fprintln(w, "No errors file found. Run 'agentlog init' to set up.")
+			return nil
+		}
+		return err
+	}
+
+	if len(entries) == 0 && !errorsCount {
+		fmt.Fprintln(w, "No errors recorded yet.")
+		return nil
+	}
+
+	// Filters match the stored entries, as do resolutions and snoozes;
+	// what's shown is redacted
+	filtered := policy.applyAll(snoozes.mark(resolutions.mark(applyFilters(entries))))
+
+	if errorsCount {
+		writeCount(w, countEntries(filtered, groupBy))
+		return nil
+	}
+
+	// The picker searches every match, so --limit doesn't apply
+	if errorsPick {
+		return pickEntries(w, baseDir, filtered)
+	}
+
+	if errorsGroup {
+		groups := groupErrors(filtered)
+		total := len(groups)
+		if errorsLimit > 0 && len(groups) > errorsLimit {
+			groups = groups[:errorsLimit]
+		}
+		if IsJSONOutput() {
+			output, _ := json.MarshalIndent(groups, "", "  ")
+			fmt.Fprintln(w, string(output))
+		} else {
+			fmt.Fprint(w, formatGroupsHuman(groups, total))
+		}
+		return nil
+	}
 
 	// Apply limit (from the end - most recent)
 	if errorsLimit > 0 && len(filtered) > errorsLimit {
 		filtered = filtered[len(filtered)-errorsLimit:]
 	}
+	filtered = suggestions(filtered)
 
-	// Output
-	if IsJSONOutput() {
-		fmt.Fprintln(cmd.OutOrStdout(), formatJSON(filtered))
+	return writeErrorList(w, baseDir, filtered, len(entries), formatter, tmpl, fields)
+}
+
+// writeErrorList prints entries in the output mode chosen: a formatter
+// command, a template, --fields, JSON, or the human-readable list, which
+// notes when entries are fewer than total
+func writeErrorList(w io.Writer, baseDir string, entries []ErrorEntry, total int, formatter string, tmpl *template.Template, fields []string) error {
+	if formatter != "" {
+		if err := runFormatter(w, baseDir, formatter, entries, total); err != nil {
+			self.LogError(baseDir, "FORMATTER_ERROR", err.Error())
+			return err
+		}
+	} else if tmpl != nil {
+		output, err := formatTemplate(entries, tmpl)
+		if err != nil {
+			self.LogError(baseDir, "INVALID_INPUT", err.Error())
+			return err
+		}
+		fmt.Fprint(w, output)
+	} else if fields != nil {
+		if IsJSONOutput() {
+			fmt.Fprintln(w, formatFieldsJSON(entries, fields))
+		} else {
+			fmt.Fprint(w, formatFieldsTable(entries, fields))
+		}
+	} else if IsJSONOutput() {
+		fmt.Fprintln(w, formatJSON(entries))
 	} else {
-		fmt.Fprint(cmd.OutOrStdout(), formatHuman(filtered, len(entries)))
+		fmt.Fprint(w, formatEntriesHuman(entries, total, errorsFull))
 	}
 
 	return nil
 }
 
-// readErrors reads all error entries from .agentlog/errors.jsonl
+// writeCount prints a --count result as JSON or plain text
+func writeCount(w io.Writer, result CountResult) {
+	if IsJSONOutput() {
+		output, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Fprintln(w, string(output))
+		return
+	}
+	fmt.Fprint(w, formatCountHuman(result))
+}
+
+// clearScreen moves the cursor home and clears the terminal
+const clearScreen = "\033[H\033[2J"
+
+// watchPollInterval is how often --watch checks errors.jsonl for changes
+const watchPollInterval = 500 * time.Millisecond
+
+// watchErrors redraws the filtered view whenever errors.jsonl changes, and
+// at least every --interval so relative filters like --since 1h stay current
+func watchErrors(cmd *cobra.Command, baseDir string) error {
+	if IsJSONOutput() || errorsPick {
+		return invalidInput("--watch can't be combined with --json or --pick")
+	}
+	if errorsInterval <= 0 {
+		return invalidInput("--interval must be positive")
+	}
+
+	// Set up signal handling for graceful shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	go func() {
+		select {
+		case <-sigChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// The first render surfaces invalid flags before the screen is cleared
+	var frame bytes.Buffer
+	if err := renderErrors(&frame, baseDir); err != nil {
+		return err
+	}
+	return watchLoop(ctx, cmd.OutOrStdout(), baseDir, errorsInterval, watchPollInterval, frame.String())
+}
+
+// watchLoop redraws first, then re-renders on file change or every interval
+// until ctx is cancelled. Each frame is rendered to a buffer and written in
+// one piece so the screen doesn't flicker.
+func watchLoop(ctx context.Context, w io.Writer, baseDir string, interval, poll time.Duration, first string) error {
+	path := GetErrorsPath(baseDir)
+	lastState := func() string {
+		info, err := os.Stat(path)
+		if err != nil {
+			return ""
+		}
+		return fmt.Sprintf("%d-%d", info.Size(), info.ModTime().UnixNano())
+	}
+	state := lastState()
+
+	draw := func(content string) {
+		header := fmt.Sprintf("Every %s: agentlog errors    %s  (Ctrl-C to exit)\n\n", interval, time.Now().Format("15:04:05"))
+		fmt.Fprint(w, clearScreen+header+content)
+	}
+	draw(first)
+	lastDraw := time.Now()
+
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			current := lastState()
+			if current == state && time.Since(lastDraw) < interval {
+				continue
+			}
+			state = current
+
+			var frame bytes.Buffer
+			if err := renderErrors(&frame, baseDir); err != nil {
+				frame.Reset()
+				fmt.Fprintf(&frame, "Error: %v\n", err)
+			}
+			draw(frame.String())
+			lastDraw = time.Now()
+		}
+	}
+}
+
+// readErrors reads the error entries from .agentlog/errors.jsonl. Large
+// logs are parsed in ranges on separate goroutines. At most entryCap
+// entries are held; past it the latest are kept, with a warning.
 func readErrors(baseDir string) ([]ErrorEntry, error) {
-	filePath := filepath.Join(baseDir, ".agentlog", "errors.jsonl")
+	max := entryCap(baseDir)
+	if useParallel(GetErrorsPath(baseDir)) {
+		var ring *rangedRing
+		err := scanErrorsParallel(baseDir, func(ranges int) {
+			ring = newRangedRing(max, ranges)
+		}, func(rang int, e ErrorEntry) {
+			ring.add(rang, e)
+		})
+		if ring == nil {
+			return nil, err
+		}
+		warnDropped(ring.held, ring.total)
+		return ring.ordered(), err
+	}
+
+	ring := newEntryRing(max)
+	err := scanErrors(baseDir, func(e ErrorEntry) bool {
+		ring.add(e)
+		return true
+	})
+	warnDropped(len(ring.entries), ring.total)
+	return ring.ordered(), err
+}
+
+// maxLineBytes is the longest errors.jsonl line readers accept: the
+// "max_line_bytes" config setting, or logfile.DefaultMaxLine
+func maxLineBytes(baseDir string) int {
+	if cfg, err := config.Load(baseDir); err == nil && cfg.MaxLineBytes > 0 {
+		return cfg.MaxLineBytes
+	}
+	return logfile.DefaultMaxLine
+}
+
+// warnSkippedLines reports lines a reader skipped for being over maxLine.
+// A Line of 0 means the line's number isn't known.
+func warnSkippedLines(skipped []logfile.SkippedLine, maxLine int) {
+	for _, s := range skipped {
+		which := "a line"
+		if s.Line > 0 {
+			which = fmt.Sprintf("line %d", s.Line)
+		}
+		diag.Warnf("skipping %s of %d bytes, over the %d-byte limit (raise max_line_bytes in .agentlog/config.json)", which, s.Size, maxLine)
+	}
+}
 
-	file, err := os.Open(filePath)
+// appendErrors appends entries to .agentlog/errors.jsonl, creating the
+// directory and file if needed. Entries are redacted and stripped of the
+// context keys config excludes, and those without a project, host, user,
+// or agent are stamped with baseDir's project name and this machine, user,
+// and agent session, in place. Error types config samples are thinned
+// out as sampleEntries describes. With the "fsync" config setting or
+// AGENTLOG_FSYNC set to a true value (1, t, true; see envFsync), the
+// append is fsynced before returning.
+func appendErrors(baseDir string, entries []ErrorEntry) error {
+	return appendErrorsSync(baseDir, entries, false, false)
+}
+
+// appendErrorsSync is appendErrors for serve, which can ask for an fsync
+// itself (serve --fsync): with fsync set, the append is fsynced whatever
+// config says. Posted entries came from a client, not this login, so they
+// aren't stamped with this machine's user; serve sets their host itself.
+func appendErrorsSync(baseDir string, entries []ErrorEntry, fsync, posted bool) error {
+	cfg, err := config.Load(baseDir)
 	if err != nil {
-		return nil, err
+		cfg = &config.Config{}
+	}
+	project, host, login, agent := projectName(baseDir), entryHost(), entryUser(), entryAgent()
+	testRun := os.Getenv(testRunIDEnv)
+	policy := policyFor(cfg)
+	for i := range entries {
+		// Under 'agentlog capture --test-run', entries belong to the run
+		if _, ok := entries[i].Context[testRunKey]; testRun != "" && !ok {
+			if entries[i].Context == nil {
+				entries[i].Context = make(map[string]interface{})
+			}
+			entries[i].Context[testRunKey] = testRun
+		}
+		entries[i] = policy.apply(entries[i])
+		if entries[i].Project == "" {
+			entries[i].Project = project
+		}
+		if entries[i].Host == "" && !posted {
+			entries[i].Host = host
+		}
+		if entries[i].User == "" && !posted {
+			entries[i].User = login
+		}
+		if entries[i].Agent == "" {
+			entries[i].Agent = agent
+		}
 	}
-	defer file.Close()
 
-	var entries []ErrorEntry
-	scanner := bufio.NewScanner(file)
-	lineNum := 0
+	agentlogDir := filepath.Join(baseDir, ".agentlog")
+	if err := os.MkdirAll(agentlogDir, 0755); err != nil {
+		return fmt.Errorf("failed to create .agentlog directory: %w", err)
+	}
+	if entries = sampleEntries(baseDir, cfg, entries, time.Now()); len(entries) == 0 {
+		return nil
+	}
 
-	for scanner.Scan() {
-		lineNum++
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
+	var sb strings.Builder
+	for _, e := range entries {
+		e.ID = ""
+		data, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to encode entry: %w", err)
 		}
+		sb.Write(data)
+		sb.WriteString("\n")
+	}
 
-		var entry ErrorEntry
-		if err := json.Unmarshal([]byte(line), &entry); err != nil {
-			// Skip malformed lines with warning to stderr
-			fmt.Fprintf(os.Stderr, "Warning: skipping malformed line %d: %v\n", lineNum, err)
-			continue
+	write := logfile.Append
+	if fsync || cfg.Fsync || envFsync() {
+		write = logfile.AppendSync
+	}
+	if err := write(GetErrorsPath(baseDir), []byte(sb.String())); err != nil {
+		return fmt.Errorf("failed to write errors.jsonl: %w", err)
+	}
+	if cfg.TrackEdits {
+		if err := recordEdits(baseDir, time.Now()); err != nil {
+			diag.Debugf("recording edits: %v", err)
 		}
+	}
+	return nil
+}
+
+// envFsync reports whether AGENTLOG_FSYNC asks for fsynced appends. It
+// takes the values strconv.ParseBool does, so AGENTLOG_FSYNC=0 turns it
+// off; anything else is warned about and ignored.
+func envFsync() bool {
+	v := os.Getenv("AGENTLOG_FSYNC")
+	if v == "" {
+		return false
+	}
+	on, err := strconv.ParseBool(v)
+	if err != nil {
+		diag.Warnf("ignoring AGENTLOG_FSYNC=%q: use 1 or 0 (or true/false)", v)
+		return false
+	}
+	return on
+}
+
+// entryHost returns the machine name stamped on entries written here:
+// AGENTLOG_HOST, or the hostname
+func entryHost() string {
+	if host := os.Getenv("AGENTLOG_HOST"); host != "" {
+		return host
+	}
+	host, _ := os.Hostname()
+	return host
+}
+
+// entryUser returns the user name stamped on entries written here:
+// AGENTLOG_USER, or the login user
+func entryUser() string {
+	for _, name := range []string{"AGENTLOG_USER", "USER", "USERNAME"} {
+		if u := os.Getenv(name); u != "" {
+			return u
+		}
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+// agentSessions maps environment variables that AI coding agents set in
+// the shells they run to the agent's name, for entryAgent
+var agentSessions = []struct{ env, agent string }{
+	{"CLAUDE_SESSION_ID", "claude"},
+}
 
-		entries = append(entries, entry)
+// entryAgent returns the AI agent stamped on entries written here:
+// AGENTLOG_AGENT, or "<agent>:<session>" when run by a known agent, so
+// entries from several agents working one repo can be told apart
+func entryAgent() string {
+	if agent := os.Getenv("AGENTLOG_AGENT"); agent != "" {
+		return agent
 	}
+	for _, s := range agentSessions {
+		if session := os.Getenv(s.env); session != "" {
+			return s.agent + ":" + session
+		}
+	}
+	return ""
+}
 
-	if err := scanner.Err(); err != nil {
-		return entries, fmt.Errorf("error reading file: %w", err)
+// projectName returns the project configured in .agentlog/config.json,
+// falling back to the name of baseDir
+func projectName(baseDir string) string {
+	if cfg, err := config.Load(baseDir); err == nil && cfg.Project != "" {
+		return cfg.Project
 	}
+	if abs, err := filepath.Abs(baseDir); err == nil {
+		baseDir = abs
+	}
+	return filepath.Base(baseDir)
+}
 
-	return entries, nil
+// parseEntryTime parses an entry timestamp (RFC3339, with or without
+// fractional seconds)
+func parseEntryTime(ts string) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, ts)
 }
 
-// parseSince parses a --since value into a time.Time
-// Supports duration format (1h, 30m) and date format (2024-01-01)
+// formatTimestamp renders an entry timestamp for human output: relative to
+// now ("3m ago") unless --absolute is set. Unparseable timestamps are shown
+// as written.
+func formatTimestamp(ts string) string {
+	if IsAbsoluteTime() {
+		return ts
+	}
+	t, err := parseEntryTime(ts)
+	if err != nil {
+		return ts
+	}
+	return relativeTime(t, time.Now())
+}
+
+// relativeTime describes t as a coarse age relative to now. Times more than
+// 30 days old, or in the future by more than a minute, fall back to the date.
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < -time.Minute:
+		return t.Local().Format("2006-01-02 15:04")
+	case d < 10*time.Second:
+		return "just now"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d/time.Second))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d/time.Hour))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d/(24*time.Hour)))
+	}
+	return t.Local().Format("2006-01-02")
+}
+
+// sinceUnitPattern matches relative times with units time.ParseDuration
+// doesn't know ("2d", "1w", "3 days ago", "90 minutes")
+var sinceUnitPattern = regexp.MustCompile(`^(\d+)\s*(s|secs?|seconds?|m|mins?|minutes?|h|hrs?|hours?|d|days?|w|weeks?)(?:\s+ago)?$`)
+
+// sinceUnits maps the first letter of a unit to its duration
+var sinceUnits = map[byte]time.Duration{
+	's': time.Second,
+	'm': time.Minute,
+	'h': time.Hour,
+	'd': 24 * time.Hour,
+	'w': 7 * 24 * time.Hour,
+}
+
+// sinceLayouts are the absolute datetime formats accepted by --since.
+// Layouts without a zone are read in local time.
+var sinceLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// sinceDateLayout is a bare --since date, which means midnight UTC as it
+// always has, so the same command selects the same entries on any machine
+const sinceDateLayout = "2006-01-02"
+
+// parseSince parses a --since value into a time.Time. It accepts Go
+// durations (1h, 30m), day and week units (2d, 1w, "3 days ago"), dates and
+// datetimes (2024-01-01, "2024-01-01 14:00", RFC3339), and the phrases now,
+// today, yesterday, "last hour", "last week".
 func parseSince(since string) (time.Time, error) {
-	if since == "" {
+	return parseSinceAt(since, time.Now())
+}
+
+// parseSpan reads a length of time: a Go duration ("15m", "1h30m") or a
+// count of units ParseDuration doesn't know ("1d", "2w")
+func parseSpan(s string) (time.Duration, bool) {
+	value := strings.ToLower(strings.TrimSpace(s))
+	if d, err := time.ParseDuration(value); err == nil {
+		return d, true
+	}
+	m := sinceUnitPattern.FindStringSubmatch(value)
+	if m == nil {
+		return 0, false
+	}
+	n, _ := strconv.Atoi(m[1])
+	return time.Duration(n) * sinceUnits[m[2][0]], true
+}
+
+// parseSinceAt is parseSince relative to now
+func parseSinceAt(since string, now time.Time) (time.Time, error) {
+	value := strings.ToLower(strings.TrimSpace(since))
+	if value == "" {
 		return time.Time{}, fmt.Errorf("empty since value")
 	}
 
 	// Try duration first (relative)
-	if dur, err := time.ParseDuration(since); err == nil {
-		return time.Now().Add(-dur), nil
+	if dur, err := time.ParseDuration(value); err == nil {
+		return now.Add(-dur), nil
+	}
+	if m := sinceUnitPattern.FindStringSubmatch(value); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return now.Add(-time.Duration(n) * sinceUnits[m[2][0]]), nil
 	}
 
-	// Try date formats
-	formats := []string{
-		"2006-01-02",                // YYYY-MM-DD
-		"2006-01-02T15:04:05Z07:00", // RFC3339
-		"2006-01-02T15:04:05Z",      // RFC3339 UTC
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch value {
+	case "now":
+		return now, nil
+	case "today":
+		return midnight, nil
+	case "yesterday":
+		return midnight.AddDate(0, 0, -1), nil
+	case "last hour":
+		return now.Add(-time.Hour), nil
+	case "last week":
+		return now.AddDate(0, 0, -7), nil
 	}
 
-	for _, f := range formats {
-		if t, err := time.Parse(f, since); err == nil {
+	// Try date formats (original spelling, since layouts are case-sensitive)
+	for _, layout := range sinceLayouts {
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(since), now.Location()); err == nil {
 			return t, nil
 		}
 	}
+	if t, err := time.Parse(sinceDateLayout, strings.TrimSpace(since)); err == nil {
+		return t, nil
+	}
 
-	return time.Time{}, fmt.Errorf("invalid time format: %s (use '1h', '30m', or 'YYYY-MM-DD')", since)
+	return time.Time{}, fmt.Errorf("invalid time format: %s (use '1h', '2d', '1w', 'yesterday', 'YYYY-MM-DD', or 'YYYY-MM-DD HH:MM')", since)
 }
 
 // filterErrors applies source, type, and since filters
@@ -217,8 +1070,209 @@ func filterErrors(entries []ErrorEntry, source, errType string, since time.Time)
 	return filtered
 }
 
+// filterLocation keeps entries whose file and endpoint contain the given
+// substrings (empty matches everything)
+func filterLocation(entries []ErrorEntry, file, endpoint string) []ErrorEntry {
+	if file == "" && endpoint == "" {
+		return entries
+	}
+
+	var filtered []ErrorEntry
+	for _, e := range entries {
+		if file != "" && !strings.Contains(e.File, file) {
+			continue
+		}
+		if endpoint != "" && !strings.Contains(e.Endpoint, endpoint) {
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+	return filtered
+}
+
+// filterProject keeps entries from project. Entries written without a
+// project are attributed to local, the project of the directory being read.
+func filterProject(entries []ErrorEntry, project, local string) []ErrorEntry {
+	var filtered []ErrorEntry
+	for _, e := range entries {
+		p := e.Project
+		if p == "" {
+			p = local
+		}
+		if p == project {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// filterIDs keeps entries whose ID starts with one of ids (empty matches
+// everything)
+func filterIDs(entries []ErrorEntry, ids []string) []ErrorEntry {
+	if len(ids) == 0 {
+		return entries
+	}
+
+	var filtered []ErrorEntry
+	for _, e := range entries {
+		for _, id := range ids {
+			if id != "" && strings.HasPrefix(e.ID, strings.ToLower(id)) {
+				filtered = append(filtered, e)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// validKind reports whether kind is empty (any kind) or a known kind
+func validKind(kind string) bool {
+	return kind == "" || kind == kindError || kind == kindPerf
+}
+
+// filterKind keeps entries of the given kind (empty matches everything)
+func filterKind(entries []ErrorEntry, kind string) []ErrorEntry {
+	if kind == "" {
+		return entries
+	}
+
+	var filtered []ErrorEntry
+	for _, e := range entries {
+		if e.kind() == kind {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// formatDuration renders a duration in milliseconds as "850ms" or "8.2s"
+func formatDuration(ms float64) string {
+	d := time.Duration(ms * float64(time.Millisecond))
+	if d >= time.Second {
+		d = d.Round(100 * time.Millisecond)
+	} else {
+		d = d.Round(time.Millisecond)
+	}
+	return d.String()
+}
+
+// filterEnvironment keeps entries recorded in env (empty matches everything)
+func filterEnvironment(entries []ErrorEntry, env string) []ErrorEntry {
+	if env == "" {
+		return entries
+	}
+
+	var filtered []ErrorEntry
+	for _, e := range entries {
+		if e.Environment == env {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// filterAttribution keeps entries recorded on host by user (empty matches
+// everything)
+func filterAttribution(entries []ErrorEntry, host, user string) []ErrorEntry {
+	if host == "" && user == "" {
+		return entries
+	}
+
+	var filtered []ErrorEntry
+	for _, e := range entries {
+		if (host == "" || e.Host == host) && (user == "" || e.User == user) {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// filterAgent keeps entries produced by agent, either the whole value or
+// the agent's name before its session ("claude" matches "claude:4f2a")
+func filterAgent(entries []ErrorEntry, agent string) []ErrorEntry {
+	if agent == "" {
+		return entries
+	}
+
+	var filtered []ErrorEntry
+	for _, e := range entries {
+		if e.Agent == agent || strings.HasPrefix(e.Agent, agent+":") {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// filterTags keeps entries carrying every tag in include and none in exclude
+func filterTags(entries []ErrorEntry, include, exclude []string) []ErrorEntry {
+	if len(include) == 0 && len(exclude) == 0 {
+		return entries
+	}
+
+	var filtered []ErrorEntry
+	for _, e := range entries {
+		has := make(map[string]bool, len(e.Tags))
+		for _, t := range e.Tags {
+			has[t] = true
+		}
+		keep := true
+		for _, t := range include {
+			if !has[t] {
+				keep = false
+				break
+			}
+		}
+		for _, t := range exclude {
+			if has[t] {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// normalizeTags trims tags and drops empty and duplicate ones, keeping order
+func normalizeTags(tags []string) []string {
+	var result []string
+	seen := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		result = append(result, t)
+	}
+	return result
+}
+
+// formatLocation returns "file:line:column" for an entry, or "" if unknown
+func formatLocation(e ErrorEntry) string {
+	if e.File == "" {
+		return ""
+	}
+	loc := e.File
+	if e.Line > 0 {
+		loc += fmt.Sprintf(":%d", e.Line)
+		if e.Column > 0 {
+			loc += fmt.Sprintf(":%d", e.Column)
+		}
+	}
+	return loc
+}
+
 // formatHuman formats errors for human-readable output
 func formatHuman(entries []ErrorEntry, totalCount int) string {
+	return formatEntriesHuman(entries, totalCount, false)
+}
+
+// formatEntriesHuman formats errors for human-readable output, with full
+// adding attribution, context, and suggestions
+func formatEntriesHuman(entries []ErrorEntry, totalCount int, full bool) string {
 	if len(entries) == 0 {
 		return "No errors match the filter criteria.\n"
 	}
@@ -230,18 +1284,119 @@ func formatHuman(entries []ErrorEntry, totalCount int) string {
 			sb.WriteString("\n")
 		}
 
-		sb.WriteString(fmt.Sprintf("Error: %s\n", e.Message))
-		sb.WriteString(fmt.Sprintf("  Source: %s | Type: %s\n", e.Source, e.ErrorType))
-		sb.WriteString(fmt.Sprintf("  Time: %s\n", e.Timestamp))
+		label := colors.err("Error:")
+		if e.Kind == kindPerf {
+			label = colors.warn("Error:")
+		}
+		if e.SnoozeExpired != nil {
+			label = colors.warn("SNOOZE EXPIRED:")
+		}
+		if e.Regression != nil {
+			label = colors.err("REGRESSION:")
+		}
+		sb.WriteString(fmt.Sprintf("%s %s\n", label, e.Message))
+		if e.Regression != nil {
+			sb.WriteString(fmt.Sprintf("  Resolved: %s (group %s)\n", formatTimestamp(e.Regression.ResolvedAt), e.Regression.Fingerprint))
+		}
+		if e.SnoozeExpired != nil {
+			sb.WriteString(fmt.Sprintf("  Snoozed: %s, until %s (group %s)\n", formatTimestamp(e.SnoozeExpired.SnoozedAt), formatTimestamp(e.SnoozeExpired.Until), e.SnoozeExpired.Fingerprint))
+		}
+		writeFixLines(&sb, "  ", e.PastFixes)
+		meta := fmt.Sprintf("  ID: %s | Source: %s | Type: %s", e.ID, e.Source, e.ErrorType)
+		if e.Environment != "" {
+			meta += fmt.Sprintf(" | Env: %s", e.Environment)
+		}
+		if e.Kind == kindPerf {
+			meta += " | Kind: perf"
+		}
+		sb.WriteString(meta + "\n")
+		if e.DurationMs > 0 {
+			sb.WriteString(fmt.Sprintf("  Duration: %s\n", formatDuration(e.DurationMs)))
+		}
+		if loc := formatLocation(e); loc != "" {
+			sb.WriteString(fmt.Sprintf("  Location: %s\n", loc))
+		}
+		if e.Endpoint != "" {
+			sb.WriteString(fmt.Sprintf("  Endpoint: %s\n", e.Endpoint))
+		}
+		if len(e.Tags) > 0 {
+			sb.WriteString(fmt.Sprintf("  Tags: %s\n", strings.Join(e.Tags, ", ")))
+		}
+		if e.Count > 1 {
+			sb.WriteString(fmt.Sprintf("  Seen: %dx since %s\n", e.Count, colors.dim(formatTimestamp(e.FirstSeen))))
+		}
+		sb.WriteString(fmt.Sprintf("  Time: %s\n", colors.dim(formatTimestamp(e.Timestamp))))
+		if !full {
+			continue
+		}
+		if e.Project != "" {
+			sb.WriteString(fmt.Sprintf("  Project: %s\n", e.Project))
+		}
+		if e.Host != "" || e.User != "" {
+			sb.WriteString(fmt.Sprintf("  Recorded by: %s\n", attribution(e)))
+		}
+		if e.Agent != "" {
+			sb.WriteString(fmt.Sprintf("  Agent: %s\n", e.Agent))
+		}
+		writeContextLines(&sb, e.Context)
+		if e.Suggestion != nil {
+			sb.WriteString(fmt.Sprintf("  Suggestion: %s\n", e.Suggestion.Hint))
+		}
 	}
 
 	if len(entries) < totalCount {
-		sb.WriteString(fmt.Sprintf("\nShowing %d of %d errors (use --limit to see more)\n", len(entries), totalCount))
+		sb.WriteString("\n" + colors.dim(fmt.Sprintf("Showing %d of %d errors (use --limit to see more)", len(entries), totalCount)) + "\n")
 	}
 
 	return sb.String()
 }
 
+// templateFuncs are the functions available to --template
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"truncate": func(max int, s string) string { return truncate(s, max) },
+}
+
+// errorsOutputTemplate parses the --template flag, falling back to the
+// config default unless another output mode (--json, --fields, --group)
+// was chosen. It returns nil when no template applies.
+func errorsOutputTemplate(baseDir, flagValue string, otherMode bool) (*template.Template, error) {
+	text := flagValue
+	if text != "" && otherMode {
+		return nil, invalidInput("--template can't be combined with --json, --fields, or --group")
+	}
+	if text == "" && !otherMode {
+		if cfg, err := config.Load(baseDir); err == nil {
+			text = cfg.Errors.Template
+		}
+	}
+	if text == "" {
+		return nil, nil
+	}
+
+	tmpl, err := template.New("errors").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// formatTemplate renders each entry with tmpl, one per line
+func formatTemplate(entries []ErrorEntry, tmpl *template.Template) (string, error) {
+	var sb strings.Builder
+	for _, e := range entries {
+		if err := tmpl.Execute(&sb, e); err != nil {
+			return "", fmt.Errorf("template failed on entry %s: %w", e.ID, err)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
 // formatJSON formats errors as JSON array
 func formatJSON(entries []ErrorEntry) string {
 	if entries == nil {
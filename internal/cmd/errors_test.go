@@ -2,12 +2,16 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/agentlog/agentlog/internal/diag"
 )
 
 func TestErrorEntry_ParseJSON(t *testing.T) {
@@ -36,7 +40,37 @@ func TestErrorEntry_ParseJSON(t *testing.T) {
 				Source:    "backend",
 				ErrorType: "DATABASE_ERROR",
 				Message:   "Connection refused",
-				Context:   map[string]interface{}{"endpoint": "/api/users"},
+				Endpoint:  "/api/users",
+			},
+			wantErr: false,
+		},
+		{
+			name:  "location fields",
+			input: `{"timestamp":"2025-12-10T19:19:32.941Z","source":"frontend","error_type":"UNCAUGHT_ERROR","message":"x is undefined","file":"src/App.tsx","line":12,"column":5,"context":{"session_id":"abc"}}`,
+			want: ErrorEntry{
+				Timestamp: "2025-12-10T19:19:32.941Z",
+				Source:    "frontend",
+				ErrorType: "UNCAUGHT_ERROR",
+				Message:   "x is undefined",
+				File:      "src/App.tsx",
+				Line:      12,
+				Column:    5,
+				Context:   map[string]interface{}{"session_id": "abc"},
+			},
+			wantErr: false,
+		},
+		{
+			name:  "legacy location in context",
+			input: `{"timestamp":"2025-12-10T19:19:32.941Z","source":"frontend","error_type":"UNCAUGHT_ERROR","message":"x is undefined","context":{"file":"src/App.tsx","line":12,"column":"5","session_id":"abc"}}`,
+			want: ErrorEntry{
+				Timestamp: "2025-12-10T19:19:32.941Z",
+				Source:    "frontend",
+				ErrorType: "UNCAUGHT_ERROR",
+				Message:   "x is undefined",
+				File:      "src/App.tsx",
+				Line:      12,
+				Column:    5,
+				Context:   map[string]interface{}{"session_id": "abc"},
 			},
 			wantErr: false,
 		},
@@ -477,3 +511,678 @@ func TestErrorsCommand_Integration(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterLocation(t *testing.T) {
+	entries := []ErrorEntry{
+		{Message: "a", File: "src/api/users.ts", Endpoint: "/api/users"},
+		{Message: "b", File: "src/App.tsx"},
+		{Message: "c", Endpoint: "/api/orders"},
+	}
+
+	if got := filterLocation(entries, "", ""); len(got) != 3 {
+		t.Errorf("no filters should keep all entries, got %d", len(got))
+	}
+	if got := filterLocation(entries, "api/users", ""); len(got) != 1 || got[0].Message != "a" {
+		t.Errorf("--file filter = %+v", got)
+	}
+	if got := filterLocation(entries, "", "/api/"); len(got) != 2 {
+		t.Errorf("--endpoint filter should match 2 entries, got %d", len(got))
+	}
+	if got := filterLocation(entries, "App.tsx", "/api/"); len(got) != 0 {
+		t.Errorf("filters should combine, got %+v", got)
+	}
+}
+
+func TestFormatHuman_Location(t *testing.T) {
+	output := formatHuman([]ErrorEntry{{
+		Timestamp: "2025-12-10T19:19:32.941Z", Source: "frontend", ErrorType: "UNCAUGHT_ERROR",
+		Message: "x is undefined", File: "src/App.tsx", Line: 12, Column: 5, Endpoint: "/dashboard",
+		Tags: []string{"checkout-v2", "experiment"},
+	}}, 1)
+	if !strings.Contains(output, "Location: src/App.tsx:12:5") {
+		t.Errorf("expected location in output, got: %s", output)
+	}
+	if !strings.Contains(output, "Tags: checkout-v2, experiment") {
+		t.Errorf("expected tags in output, got: %s", output)
+	}
+	if !strings.Contains(output, "Endpoint: /dashboard") {
+		t.Errorf("expected endpoint in output, got: %s", output)
+	}
+}
+
+func TestFilterTags(t *testing.T) {
+	entries := []ErrorEntry{
+		{Message: "a", Tags: []string{"checkout-v2", "flaky"}},
+		{Message: "b", Tags: []string{"checkout-v2"}},
+		{Message: "c"},
+	}
+
+	if got := filterTags(entries, nil, nil); len(got) != 3 {
+		t.Errorf("no filters should keep all entries, got %d", len(got))
+	}
+	if got := filterTags(entries, []string{"checkout-v2"}, nil); len(got) != 2 {
+		t.Errorf("--tag should match 2 entries, got %+v", got)
+	}
+	if got := filterTags(entries, []string{"checkout-v2", "flaky"}, nil); len(got) != 1 || got[0].Message != "a" {
+		t.Errorf("every --tag must match, got %+v", got)
+	}
+	if got := filterTags(entries, nil, []string{"flaky"}); len(got) != 2 || got[0].Message != "b" {
+		t.Errorf("--exclude-tag should drop tagged entries, got %+v", got)
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got := normalizeTags([]string{" a", "", "b", "a", "  "})
+	if strings.Join(got, ",") != "a,b" {
+		t.Errorf("normalizeTags() = %v, want [a b]", got)
+	}
+	if normalizeTags(nil) != nil {
+		t.Error("normalizeTags(nil) should be nil")
+	}
+}
+
+func TestProjectName(t *testing.T) {
+	tmpDir := filepath.Join(t.TempDir(), "billing-api")
+	os.MkdirAll(filepath.Join(tmpDir, ".agentlog"), 0755)
+
+	if got := projectName(tmpDir); got != "billing-api" {
+		t.Errorf("projectName() = %q, want directory name", got)
+	}
+
+	os.WriteFile(filepath.Join(tmpDir, ".agentlog", "config.json"), []byte(`{"project":"billing"}`), 0644)
+	if got := projectName(tmpDir); got != "billing" {
+		t.Errorf("projectName() = %q, want configured project", got)
+	}
+}
+
+func TestAppendErrors_StampsProject(t *testing.T) {
+	tmpDir := filepath.Join(t.TempDir(), "web")
+	err := appendErrors(tmpDir, []ErrorEntry{
+		{Timestamp: "2025-12-10T19:19:32Z", Source: "frontend", ErrorType: "X", Message: "local"},
+		{Timestamp: "2025-12-10T19:19:32Z", Source: "backend", ErrorType: "X", Message: "merged", Project: "api"},
+	})
+	if err != nil {
+		t.Fatalf("appendErrors() error = %v", err)
+	}
+
+	entries, _ := readErrors(tmpDir)
+	if len(entries) != 2 || entries[0].Project != "web" || entries[1].Project != "api" {
+		t.Errorf("unexpected projects: %+v", entries)
+	}
+}
+
+func TestFilterProject(t *testing.T) {
+	entries := []ErrorEntry{
+		{Message: "a", Project: "api"},
+		{Message: "b", Project: "web"},
+		{Message: "c"},
+	}
+
+	if got := filterProject(entries, "api", "web"); len(got) != 1 || got[0].Message != "a" {
+		t.Errorf("filterProject(api) = %+v", got)
+	}
+	if got := filterProject(entries, "web", "web"); len(got) != 2 {
+		t.Errorf("entries without a project should belong to the local project, got %+v", got)
+	}
+}
+
+func TestFilterEnvironment(t *testing.T) {
+	entries := []ErrorEntry{
+		{Message: "a", Environment: "dev"},
+		{Message: "b", Environment: "test"},
+		{Message: "c"},
+	}
+
+	if got := filterEnvironment(entries, ""); len(got) != 3 {
+		t.Errorf("empty env should keep all entries, got %d", len(got))
+	}
+	if got := filterEnvironment(entries, "test"); len(got) != 1 || got[0].Message != "b" {
+		t.Errorf("filterEnvironment(test) = %+v", got)
+	}
+
+	output := formatHuman(entries[1:2], 1)
+	if !strings.Contains(output, "Source:  | Type:  | Env: test") {
+		t.Errorf("expected environment in output, got: %s", output)
+	}
+}
+
+func TestEntryID(t *testing.T) {
+	e := ErrorEntry{Timestamp: "2025-12-10T19:19:32.941Z", Source: "frontend", ErrorType: "UNCAUGHT_ERROR", Message: "x is undefined"}
+	id := entryID(e)
+	if len(id) != entryIDLength {
+		t.Errorf("entryID() = %q, want %d hex characters", id, entryIDLength)
+	}
+	if entryID(e) != id {
+		t.Error("entryID() should be stable")
+	}
+	e.ID = "ignored"
+	if entryID(e) != id {
+		t.Error("entryID() should not depend on the ID field")
+	}
+	e.Message = "y is undefined"
+	if entryID(e) == id {
+		t.Error("entryID() should change with content")
+	}
+}
+
+func TestReadErrors_AssignsStableIDs(t *testing.T) {
+	tmpDir := t.TempDir()
+	entry := ErrorEntry{Timestamp: "2025-12-10T19:19:32.941Z", Source: "frontend", ErrorType: "UNCAUGHT_ERROR", Message: "x is undefined", ID: "stale"}
+	if err := appendErrors(tmpDir, []ErrorEntry{entry}); err != nil {
+		t.Fatalf("appendErrors() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(GetErrorsPath(tmpDir))
+	if strings.Contains(string(data), `"id"`) {
+		t.Errorf("IDs should not be stored, got: %s", data)
+	}
+
+	first, _ := readErrors(tmpDir)
+	second, _ := readErrors(tmpDir)
+	if first[0].ID == "" || first[0].ID != second[0].ID {
+		t.Errorf("IDs should be assigned on read and stable, got %q and %q", first[0].ID, second[0].ID)
+	}
+}
+
+func TestFilterIDs(t *testing.T) {
+	entries := []ErrorEntry{
+		{Message: "a", ID: "3f9a2c1b7e"},
+		{Message: "b", ID: "3f00000000"},
+		{Message: "c", ID: "a1b2c3d4e5"},
+	}
+
+	if got := filterIDs(entries, nil); len(got) != 3 {
+		t.Errorf("no IDs should keep all entries, got %d", len(got))
+	}
+	if got := filterIDs(entries, []string{"3F9A"}); len(got) != 1 || got[0].Message != "a" {
+		t.Errorf("prefix match should be case-insensitive, got %+v", got)
+	}
+	if got := filterIDs(entries, []string{"3f", "a1b2c3d4e5"}); len(got) != 3 {
+		t.Errorf("each ID should match independently, got %+v", got)
+	}
+	if got := filterIDs(entries, []string{""}); len(got) != 0 {
+		t.Errorf("empty ID should match nothing, got %+v", got)
+	}
+}
+
+func TestFormatTemplate(t *testing.T) {
+	entries := []ErrorEntry{
+		{ErrorType: "NETWORK_ERROR", Message: "fetch failed", Tags: []string{"a", "b"}, Context: map[string]interface{}{"url": "/settings"}},
+		{ErrorType: "UNCAUGHT_ERROR", Message: strings.Repeat("x", 20)},
+	}
+	tmpl, err := errorsOutputTemplate(t.TempDir(), `{{.ErrorType}}: {{truncate 12 .Message}} [{{join .Tags ","}}] {{.Context.url}}`, false)
+	if err != nil {
+		t.Fatalf("errorsOutputTemplate() error = %v", err)
+	}
+
+	output, err := formatTemplate(entries, tmpl)
+	if err != nil {
+		t.Fatalf("formatTemplate() error = %v", err)
+	}
+	want := "NETWORK_ERROR: fetch failed [a,b] /settings\nUNCAUGHT_ERROR: xxxxxxxxx... [] <no value>\n"
+	if output != want {
+		t.Errorf("formatTemplate() = %q, want %q", output, want)
+	}
+}
+
+func TestErrorsOutputTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, ".agentlog"), 0755)
+
+	if tmpl, err := errorsOutputTemplate(tmpDir, "", false); err != nil || tmpl != nil {
+		t.Errorf("no flag or config should mean no template, got %v, %v", tmpl, err)
+	}
+	if _, err := errorsOutputTemplate(tmpDir, "{{.Message", false); err == nil {
+		t.Error("invalid template should fail")
+	}
+	if _, err := errorsOutputTemplate(tmpDir, "{{.Message}}", true); err == nil {
+		t.Error("--template with another output mode should fail")
+	}
+
+	os.WriteFile(filepath.Join(tmpDir, ".agentlog", "config.json"), []byte(`{"errors":{"template":"{{.Source}}"}}`), 0644)
+	tmpl, err := errorsOutputTemplate(tmpDir, "", false)
+	if err != nil || tmpl == nil {
+		t.Fatalf("config default should apply, got %v, %v", tmpl, err)
+	}
+	if out, _ := formatTemplate([]ErrorEntry{{Source: "cli"}}, tmpl); out != "cli\n" {
+		t.Errorf("config template output = %q", out)
+	}
+	if tmpl, _ := errorsOutputTemplate(tmpDir, "", true); tmpl != nil {
+		t.Error("config default should not apply to --json/--fields/--group output")
+	}
+}
+
+func TestErrorsCommand_Count(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, ".agentlog"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, ".agentlog", "errors.jsonl"), []byte(
+		`{"timestamp":"2025-12-10T19:19:32.941Z","source":"frontend","error_type":"NETWORK_ERROR","message":"a"}
+{"timestamp":"2025-12-10T19:19:33.941Z","source":"backend","error_type":"DATABASE_ERROR","message":"b"}
+{"timestamp":"2025-12-10T19:19:34.941Z","source":"frontend","error_type":"NETWORK_ERROR","message":"c"}
+`), 0644)
+
+	originalPath := pathOverride
+	defer func() {
+		pathOverride = originalPath
+		errorsCount, errorsGroupBy, errorsLimit = false, "", 10
+		jsonOutput = false
+	}()
+	pathOverride = tmpDir
+	errorsLimit, errorsSource, errorsType, errorsSince = 1, "", "", ""
+	errorsCount = true
+
+	buf := new(bytes.Buffer)
+	errorsCmd.SetOut(buf)
+	if err := runErrors(errorsCmd, []string{}); err != nil {
+		t.Fatalf("runErrors() error = %v", err)
+	}
+	if buf.String() != "3\n" {
+		t.Errorf("--count should ignore --limit, got %q", buf.String())
+	}
+
+	errorsGroupBy, jsonOutput = "source", true
+	buf.Reset()
+	if err := runErrors(errorsCmd, []string{}); err != nil {
+		t.Fatalf("runErrors() error = %v", err)
+	}
+	var r CountResult
+	if err := json.Unmarshal(buf.Bytes(), &r); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if r.Count != 3 || r.GroupBy != "source" || len(r.Groups) != 2 || r.Groups[0] != (FieldCount{Value: "frontend", Count: 2}) {
+		t.Errorf("unexpected result: %+v", r)
+	}
+
+	pathOverride, jsonOutput, errorsGroupBy = t.TempDir(), false, ""
+	buf.Reset()
+	if err := runErrors(errorsCmd, []string{}); ExitCode(err) != ExitNotInitialized {
+		t.Fatalf("runErrors() without .agentlog/ = %v, want exit %d", err, ExitNotInitialized)
+	}
+	if buf.String() != "0\n" {
+		t.Errorf("missing log should count 0, got %q", buf.String())
+	}
+
+	errorsCount, errorsGroupBy = false, "type"
+	if err := runErrors(errorsCmd, []string{}); err == nil {
+		t.Error("--group-by without --count should fail")
+	}
+}
+
+func TestWatchLoop_RedrawsOnChange(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, ".agentlog"), 0755)
+	errorsPath := GetErrorsPath(tmpDir)
+	os.WriteFile(errorsPath, []byte(`{"timestamp":"2025-12-10T19:19:32.941Z","source":"frontend","error_type":"NETWORK_ERROR","message":"first"}
+`), 0644)
+
+	originalPath := pathOverride
+	defer func() { pathOverride = originalPath }()
+	pathOverride = tmpDir
+	errorsLimit, errorsSource, errorsType, errorsSince = 10, "", "", ""
+	errorsCount, errorsGroup, jsonOutput = false, false, false
+
+	ctx, cancel := context.WithCancel(context.Background())
+	buf := &syncBuffer{}
+	done := make(chan error)
+	go func() { done <- watchLoop(ctx, buf, tmpDir, time.Hour, 10*time.Millisecond, "initial frame\n") }()
+
+	time.Sleep(50 * time.Millisecond)
+	f, _ := os.OpenFile(errorsPath, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`{"timestamp":"2025-12-10T19:20:00.000Z","source":"backend","error_type":"DATABASE_ERROR","message":"second"}` + "\n")
+	f.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(buf.String(), "second") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("watchLoop() error = %v", err)
+	}
+
+	output := buf.String()
+	if !strings.HasPrefix(output, clearScreen) || !strings.Contains(output, "initial frame") {
+		t.Errorf("first frame should clear the screen and show the initial render, got: %q", output)
+	}
+	if strings.Count(output, clearScreen) != 2 {
+		t.Errorf("expected one redraw after the file changed, got %d frames", strings.Count(output, clearScreen))
+	}
+	if !strings.Contains(output, "second") {
+		t.Errorf("redraw should include the new entry, got: %s", output)
+	}
+}
+
+func TestErrorsCommand_WatchRejectsJSON(t *testing.T) {
+	originalPath := pathOverride
+	defer func() {
+		pathOverride = originalPath
+		errorsWatch, jsonOutput = false, false
+	}()
+	pathOverride = t.TempDir()
+	errorsWatch, jsonOutput = true, true
+
+	if err := runErrors(errorsCmd, []string{}); err == nil || !strings.Contains(err.Error(), "--json") {
+		t.Errorf("expected --watch/--json error, got %v", err)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes and reads
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestParseSinceAt_Extended(t *testing.T) {
+	loc := time.FixedZone("test", 2*60*60)
+	now := time.Date(2025, 12, 10, 19, 30, 0, 0, loc)
+
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"2d", now.Add(-48 * time.Hour)},
+		{"1w", now.Add(-7 * 24 * time.Hour)},
+		{"3 days ago", now.Add(-72 * time.Hour)},
+		{"90 minutes", now.Add(-90 * time.Minute)},
+		{"2 hrs ago", now.Add(-2 * time.Hour)},
+		{"1h30m", now.Add(-90 * time.Minute)},
+		{"now", now},
+		{"Today", time.Date(2025, 12, 10, 0, 0, 0, 0, loc)},
+		{"yesterday", time.Date(2025, 12, 9, 0, 0, 0, 0, loc)},
+		{"last hour", now.Add(-time.Hour)},
+		{"last week", now.AddDate(0, 0, -7)},
+		{"2024-01-01", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"2024-01-01 14:00", time.Date(2024, 1, 1, 14, 0, 0, 0, loc)},
+		{"2024-01-01T14:00:30", time.Date(2024, 1, 1, 14, 0, 30, 0, loc)},
+		{"2024-01-01T14:00:00Z", time.Date(2024, 1, 1, 14, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseSinceAt(tt.input, now)
+		if err != nil {
+			t.Errorf("parseSinceAt(%q) error = %v", tt.input, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseSinceAt(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"2 fortnights", "tomorrowish", "2024-13-01", "d"} {
+		if _, err := parseSinceAt(bad, now); err == nil {
+			t.Errorf("parseSinceAt(%q) should fail", bad)
+		}
+	}
+}
+
+func TestParseSince_DateIsUTC(t *testing.T) {
+	defer func(local *time.Location) { time.Local = local }(time.Local)
+	time.Local = time.FixedZone("UTC-8", -8*60*60)
+
+	got, err := parseSince("2026-01-02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("parseSince(2026-01-02) = %v, want midnight UTC whatever the local zone", got)
+	}
+	got, _ = parseSince("2026-01-02 00:00")
+	if want := time.Date(2026, 1, 2, 8, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("a datetime without a zone should be local, got %v", got)
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2025, 12, 10, 19, 30, 0, 0, time.UTC)
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{0, "just now"},
+		{-30 * time.Second, "just now"},
+		{42 * time.Second, "42s ago"},
+		{3*time.Minute + 20*time.Second, "3m ago"},
+		{2*time.Hour + 59*time.Minute, "2h ago"},
+		{50 * time.Hour, "2d ago"},
+	}
+	for _, tt := range tests {
+		if got := relativeTime(now.Add(-tt.ago), now); got != tt.want {
+			t.Errorf("relativeTime(-%v) = %q, want %q", tt.ago, got, tt.want)
+		}
+	}
+
+	old := now.AddDate(0, -3, 0)
+	if got := relativeTime(old, now); got != old.Local().Format("2006-01-02") {
+		t.Errorf("old times should show the date, got %q", got)
+	}
+}
+
+func TestFormatTimestamp_Absolute(t *testing.T) {
+	defer func() { absoluteTime = false }()
+
+	ts := time.Now().UTC().Add(-5 * time.Minute).Format(time.RFC3339Nano)
+	if got := formatTimestamp(ts); got != "5m ago" {
+		t.Errorf("formatTimestamp() = %q, want 5m ago", got)
+	}
+	if got := formatTimestamp("not a time"); got != "not a time" {
+		t.Errorf("unparseable timestamps should pass through, got %q", got)
+	}
+
+	absoluteTime = true
+	if got := formatTimestamp(ts); got != ts {
+		t.Errorf("--absolute should show %q, got %q", ts, got)
+	}
+}
+
+func TestOccurrences_CountCollapsedEntries(t *testing.T) {
+	entries := []ErrorEntry{
+		{Timestamp: "2025-12-10T19:00:10Z", Source: "backend", ErrorType: "NETWORK_ERROR", Message: "timeout after 3004ms",
+			Count: 3, FirstSeen: "2025-12-10T19:00:00Z", LastSeen: "2025-12-10T19:00:10Z"},
+		{Timestamp: "2025-12-10T19:00:20Z", Source: "backend", ErrorType: "NETWORK_ERROR", Message: "timeout after 3000ms"},
+	}
+
+	if got := countEntries(entries, "").Count; got != 4 {
+		t.Errorf("countEntries() = %d, want 4", got)
+	}
+	groups := groupErrors(entries)
+	if len(groups) != 1 || groups[0].Count != 4 || groups[0].FirstSeen != "2025-12-10T19:00:00Z" {
+		t.Errorf("groupErrors() = %+v", groups)
+	}
+	if report := generateStats(entries, 5); report.TotalErrors != 4 || report.ByType[0].Count != 4 {
+		t.Errorf("generateStats() = %+v", report)
+	}
+	if output := formatHuman(entries[:1], 1); !strings.Contains(output, "Seen: 3x since") {
+		t.Errorf("collapsed entries should show their count, got:\n%s", output)
+	}
+}
+
+func TestFilterKind(t *testing.T) {
+	entries := []ErrorEntry{
+		{Message: "a"},
+		{Message: "b", Kind: kindPerf, DurationMs: 8200},
+		{Message: "c", Kind: kindError},
+	}
+	tests := []struct {
+		kind string
+		want string
+	}{
+		{"", "abc"},
+		{kindError, "ac"},
+		{kindPerf, "b"},
+	}
+	for _, tt := range tests {
+		var got string
+		for _, e := range filterKind(entries, tt.kind) {
+			got += e.Message
+		}
+		if got != tt.want {
+			t.Errorf("filterKind(%q) = %q, want %q", tt.kind, got, tt.want)
+		}
+	}
+
+	output := formatHuman(entries[1:2], 1)
+	if !strings.Contains(output, "| Kind: perf") || !strings.Contains(output, "  Duration: 8.2s\n") {
+		t.Errorf("perf entries should show kind and duration, got:\n%s", output)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[float64]string{
+		0.4:    "0s",
+		12:     "12ms",
+		950.4:  "950ms",
+		8234:   "8.2s",
+		125000: "2m5s",
+	}
+	for ms, want := range tests {
+		if got := formatDuration(ms); got != want {
+			t.Errorf("formatDuration(%v) = %q, want %q", ms, got, want)
+		}
+	}
+}
+
+func TestReadErrors_OversizedLines(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, ".agentlog"), 0755)
+	huge, _ := json.Marshal(ErrorEntry{Timestamp: "2025-12-10T19:19:32.941Z", Source: "backend", ErrorType: "HUGE", Message: "trace", Context: map[string]interface{}{"stack_trace": strings.Repeat("at frame\n", 20000)}})
+	big := string(huge)
+	small := `{"timestamp":"2025-12-10T19:20:00.000Z","source":"backend","error_type":"SMALL","message":"after"}`
+	os.WriteFile(GetErrorsPath(tmpDir), []byte(big+"\n"+small+"\n"), 0644)
+
+	// Past bufio.Scanner's 64KB limit, within the default
+	entries, err := readErrors(tmpDir)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("readErrors() = %d entries, %v; want 2", len(entries), err)
+	}
+
+	// Over a configured limit, the line is skipped, not fatal
+	os.WriteFile(filepath.Join(tmpDir, ".agentlog", "config.json"), []byte(`{"max_line_bytes": 65536}`), 0644)
+	entries, err = readErrors(tmpDir)
+	if err != nil || len(entries) != 1 || entries[0].ErrorType != "SMALL" {
+		t.Fatalf("readErrors() = %+v, %v; want only the small entry", entries, err)
+	}
+	check := checkJSONL(GetErrorsPath(tmpDir), 64*1024)
+	if check.Status != "warning" || !strings.Contains(check.Message, "1 lines over the 65536-byte limit") {
+		t.Errorf("checkJSONL() = %+v, want a warning about the oversized line", check)
+	}
+}
+
+func TestAppendErrors_StampsAttribution(t *testing.T) {
+	t.Setenv("AGENTLOG_HOST", "devbox-2")
+	t.Setenv("AGENTLOG_USER", "ann")
+	tmpDir := t.TempDir()
+	err := appendErrors(tmpDir, []ErrorEntry{
+		{Timestamp: "2025-12-10T19:19:32Z", Source: "frontend", ErrorType: "X", Message: "local"},
+		{Timestamp: "2025-12-10T19:19:32Z", Source: "backend", ErrorType: "X", Message: "merged", Host: "ci-1", User: "bot"},
+	})
+	if err != nil {
+		t.Fatalf("appendErrors() error = %v", err)
+	}
+
+	entries, _ := readErrors(tmpDir)
+	if len(entries) != 2 || attribution(entries[0]) != "ann@devbox-2" || attribution(entries[1]) != "bot@ci-1" {
+		t.Errorf("unexpected attribution: %+v", entries)
+	}
+}
+
+func TestFilterAttribution(t *testing.T) {
+	entries := []ErrorEntry{
+		{Message: "a", Host: "devbox", User: "ann"},
+		{Message: "b", Host: "devbox", User: "bob"},
+		{Message: "c", Host: "laptop", User: "ann"},
+		{Message: "d"},
+	}
+
+	if got := filterAttribution(entries, "", ""); len(got) != 4 {
+		t.Errorf("no filters should keep all entries, got %d", len(got))
+	}
+	if got := filterAttribution(entries, "devbox", ""); len(got) != 2 || got[1].Message != "b" {
+		t.Errorf("filterAttribution(devbox) = %+v", got)
+	}
+	if got := filterAttribution(entries, "devbox", "ann"); len(got) != 1 || got[0].Message != "a" {
+		t.Errorf("filterAttribution(devbox, ann) = %+v", got)
+	}
+	q, err := parseQuery("user=ann and host!=devbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := filterQuery(entries, q); len(got) != 1 || got[0].Message != "c" {
+		t.Errorf("--query user=ann and host!=devbox = %+v", got)
+	}
+}
+
+func TestEntryAgent(t *testing.T) {
+	t.Setenv("AGENTLOG_AGENT", "")
+	t.Setenv("CLAUDE_SESSION_ID", "")
+	if got := entryAgent(); got != "" {
+		t.Errorf("entryAgent() = %q outside an agent", got)
+	}
+	t.Setenv("CLAUDE_SESSION_ID", "4f2a9c")
+	if got := entryAgent(); got != "claude:4f2a9c" {
+		t.Errorf("entryAgent() = %q, want the session", got)
+	}
+	t.Setenv("AGENTLOG_AGENT", "reviewer-bot")
+	if got := entryAgent(); got != "reviewer-bot" {
+		t.Errorf("entryAgent() = %q, AGENTLOG_AGENT should win", got)
+	}
+}
+
+func TestFilterAgent(t *testing.T) {
+	entries := []ErrorEntry{
+		{Message: "a", Agent: "claude:4f2a"},
+		{Message: "b", Agent: "claude:9e1b"},
+		{Message: "c", Agent: "claudette"},
+		{Message: "d"},
+	}
+
+	if got := filterAgent(entries, ""); len(got) != 4 {
+		t.Errorf("empty agent should keep all entries, got %d", len(got))
+	}
+	if got := filterAgent(entries, "claude"); len(got) != 2 {
+		t.Errorf("filterAgent(claude) should match its sessions, got %+v", got)
+	}
+	if got := filterAgent(entries, "claude:9e1b"); len(got) != 1 || got[0].Message != "b" {
+		t.Errorf("filterAgent(claude:9e1b) = %+v", got)
+	}
+	if got := countEntries(entries, "agent"); len(got.Groups) != 4 {
+		t.Errorf("--group-by agent = %+v", got)
+	}
+}
+
+func TestEnvFsync(t *testing.T) {
+	buf := new(bytes.Buffer)
+	defer diag.SetOutput(diag.SetOutput(buf))
+
+	for _, tt := range []struct {
+		value string
+		want  bool
+		warn  bool
+	}{
+		{"", false, false},
+		{"1", true, false},
+		{"true", true, false},
+		{"0", false, false},
+		{"false", false, false},
+		{"yes", false, true},
+	} {
+		buf.Reset()
+		t.Setenv("AGENTLOG_FSYNC", tt.value)
+		if got := envFsync(); got != tt.want {
+			t.Errorf("AGENTLOG_FSYNC=%q: envFsync() = %v, want %v", tt.value, got, tt.want)
+		}
+		if warned := strings.Contains(buf.String(), "AGENTLOG_FSYNC"); warned != tt.warn {
+			t.Errorf("AGENTLOG_FSYNC=%q: warned = %v, want %v (%q)", tt.value, warned, tt.warn, buf.String())
+		}
+	}
+}
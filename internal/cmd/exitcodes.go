@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit codes every command shares, so scripts and agents can branch on the
+// outcome without parsing output. Commands document what codes 1 and 2 mean
+// for them in their ai-help exit_codes.
+const (
+	ExitOK             = 0 // success
+	ExitWarnings       = 1 // completed, but found warnings or violations
+	ExitError          = 2 // failed: bad flags or input, unreadable files, or errors found
+	ExitNotInitialized = 3 // no .agentlog/ directory; run 'agentlog init'
+)
+
+// exitCodes describes the shared codes for ai-help
+func exitCodes() map[string]string {
+	return map[string]string{
+		strconv.Itoa(ExitOK):             "Success",
+		strconv.Itoa(ExitWarnings):       "Completed with warnings or violations (see each command's exit_codes)",
+		strconv.Itoa(ExitError):          "Failed: invalid flags or input, an unreadable file, or errors found (see each command's exit_codes)",
+		strconv.Itoa(ExitNotInitialized): "Not initialized: no .agentlog/ directory here (or at --path); run 'agentlog init'",
+	}
+}
+
+// uninitialized reports whether baseDir has no .agentlog directory, i.e.
+// 'agentlog init' hasn't been run there. A project posting to serve has the
+// directory without errors.jsonl, so the file's absence alone doesn't count.
+func uninitialized(baseDir string) bool {
+	_, err := os.Stat(filepath.Join(baseDir, ".agentlog"))
+	return os.IsNotExist(err)
+}
+
+// exitNotInitialized ends cmd with ExitNotInitialized once it has printed
+// its usual output; with --json a NOT_INITIALIZED error goes to stderr
+func exitNotInitialized(cmd *cobra.Command) error {
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	return &ExitCodeError{Code: ExitNotInitialized, Err: &CommandError{
+		Code: "NOT_INITIALIZED",
+		Err:  errors.New("no .agentlog directory; run 'agentlog init'"),
+	}}
+}
@@ -0,0 +1,328 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// entryFields lists the names accepted by errors --fields, in display order.
+// "context.<key>" selects a single context value.
+var entryFields = []string{
+	"id", "timestamp", "source", "type", "error_type", "message",
+	"file", "line", "column", "endpoint", "project", "environment", "host", "user", "agent", "kind", "duration_ms", "tags", "context", "count",
+}
+
+// parseFields splits a comma-separated --fields value and checks each name
+func parseFields(spec string) ([]string, error) {
+	var fields []string
+	for _, f := range strings.Split(spec, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if !validField(f) {
+			return nil, invalidInput("unknown field '%s' (available: %s, context.<key>)", f, strings.Join(entryFields, ", "))
+		}
+		fields = append(fields, f)
+	}
+	if len(fields) == 0 {
+		return nil, invalidInput("--fields needs at least one field name")
+	}
+	return fields, nil
+}
+
+func validField(name string) bool {
+	if key, ok := strings.CutPrefix(name, "context."); ok {
+		return key != ""
+	}
+	for _, f := range entryFields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldValue returns the value of a named field, or nil if the entry
+// doesn't set it
+func fieldValue(e ErrorEntry, name string) interface{} {
+	if key, ok := strings.CutPrefix(name, "context."); ok {
+		return e.Context[key]
+	}
+
+	switch name {
+	case "id":
+		return e.ID
+	case "timestamp":
+		return e.Timestamp
+	case "source":
+		return e.Source
+	case "type", "error_type":
+		return e.ErrorType
+	case "message":
+		return e.Message
+	case "file":
+		return nonEmpty(e.File)
+	case "line":
+		if e.Line > 0 {
+			return e.Line
+		}
+	case "column":
+		if e.Column > 0 {
+			return e.Column
+		}
+	case "endpoint":
+		return nonEmpty(e.Endpoint)
+	case "project":
+		return nonEmpty(e.Project)
+	case "environment":
+		return nonEmpty(e.Environment)
+	case "host":
+		return nonEmpty(e.Host)
+	case "user":
+		return nonEmpty(e.User)
+	case "agent":
+		return nonEmpty(e.Agent)
+	case "tags":
+		if len(e.Tags) > 0 {
+			return e.Tags
+		}
+	case "context":
+		if len(e.Context) > 0 {
+			return e.Context
+		}
+	case "kind":
+		return e.kind()
+	case "duration_ms":
+		if e.DurationMs > 0 {
+			return e.DurationMs
+		}
+	case "count":
+		return e.occurrences()
+	}
+	return nil
+}
+
+func nonEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// formatFieldsJSON returns entries as a JSON array of objects holding only
+// the selected fields, keyed by the names given to --fields. Unset fields
+// are null so every object has the same keys.
+func formatFieldsJSON(entries []ErrorEntry, fields []string) string {
+	rows := make([]map[string]interface{}, 0, len(entries))
+	for _, e := range entries {
+		row := make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			row[f] = fieldValue(e, f)
+		}
+		rows = append(rows, row)
+	}
+
+	output, err := json.MarshalIndent(rows, "", "  ")
+	if err != nil {
+		return "[]"
+	}
+	return string(output)
+}
+
+// formatFieldsTable returns entries as aligned columns with a header row
+func formatFieldsTable(entries []ErrorEntry, fields []string) string {
+	if len(entries) == 0 {
+		return "No errors match the filter criteria.\n"
+	}
+
+	var sb strings.Builder
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+
+	header := make([]string, len(fields))
+	for i, f := range fields {
+		header[i] = strings.ToUpper(f)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	for _, e := range entries {
+		cells := make([]string, len(fields))
+		for i, f := range fields {
+			cells[i] = formatCell(fieldValue(e, f))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	tw.Flush()
+	return sb.String()
+}
+
+// formatCell renders a field value on one line for table output
+func formatCell(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "-"
+	case string:
+		return singleLine(val)
+	case []string:
+		return strings.Join(val, ",")
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(val)
+		return string(data)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// CountResult is the output of errors --count
+type CountResult struct {
+	Count   int          `json:"count"`
+	GroupBy string       `json:"group_by,omitempty"`
+	Groups  []FieldCount `json:"groups,omitempty"`
+}
+
+// FieldCount is the number of entries sharing one value of a field
+type FieldCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// countEntries counts occurrences, broken down by groupBy when it is set.
+// Entries are counted once per tag when grouping by tags; entries without
+// the field are counted under "-".
+func countEntries(entries []ErrorEntry, groupBy string) CountResult {
+	result := CountResult{Count: totalOccurrences(entries), GroupBy: groupBy}
+	if groupBy == "" {
+		return result
+	}
+
+	counts := make(map[string]int)
+	for _, e := range entries {
+		for _, v := range fieldGroups(e, groupBy) {
+			counts[v] += e.occurrences()
+		}
+	}
+
+	for value, count := range counts {
+		result.Groups = append(result.Groups, FieldCount{Value: value, Count: count})
+	}
+	sort.Slice(result.Groups, func(i, j int) bool {
+		if result.Groups[i].Count != result.Groups[j].Count {
+			return result.Groups[i].Count > result.Groups[j].Count
+		}
+		return result.Groups[i].Value < result.Groups[j].Value
+	})
+	return result
+}
+
+// fieldGroups is the values an entry is counted under when grouping by
+// field: each of its tags for tags, otherwise the field's value, with "-"
+// for entries without it
+func fieldGroups(e ErrorEntry, field string) []string {
+	if field == "tags" && len(e.Tags) > 0 {
+		return e.Tags
+	}
+	return []string{formatCell(fieldValue(e, field))}
+}
+
+// formatCountHuman prints the total, or one "count value" line per group
+func formatCountHuman(r CountResult) string {
+	if r.GroupBy == "" {
+		return fmt.Sprintf("%d\n", r.Count)
+	}
+
+	var sb strings.Builder
+	for _, g := range r.Groups {
+		sb.WriteString(fmt.Sprintf("%d\t%s\n", g.Count, g.Value))
+	}
+	return sb.String()
+}
+
+// whereClause is one --where condition. Exact clauses (key=value) compare
+// the whole value; others (key~value) match a substring.
+type whereClause struct {
+	Field string
+	Value string
+	Exact bool
+}
+
+// parseWhere parses --where values of the form key=value or key~value.
+// A key naming an entry field (file, endpoint, type, ...) selects that
+// field; any other key is looked up in context.
+func parseWhere(specs []string) ([]whereClause, error) {
+	var clauses []whereClause
+	for _, spec := range specs {
+		i := strings.IndexAny(spec, "=~")
+		if i <= 0 {
+			return nil, invalidInput("invalid --where '%s' (want key=value or key~value)", spec)
+		}
+		key := strings.TrimSpace(spec[:i])
+		if !validField(key) {
+			key = "context." + key
+		}
+		clauses = append(clauses, whereClause{
+			Field: key,
+			Value: spec[i+1:],
+			Exact: spec[i] == '=',
+		})
+	}
+	return clauses, nil
+}
+
+// filterWhere keeps entries matching every clause. Entries without the
+// field never match.
+func filterWhere(entries []ErrorEntry, clauses []whereClause) []ErrorEntry {
+	if len(clauses) == 0 {
+		return entries
+	}
+
+	var filtered []ErrorEntry
+	for _, e := range entries {
+		if matchesWhere(e, clauses) {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+func matchesWhere(e ErrorEntry, clauses []whereClause) bool {
+	for _, c := range clauses {
+		v := fieldValue(e, c.Field)
+		if v == nil {
+			return false
+		}
+		if tags, ok := v.([]string); ok {
+			if !matchesAny(tags, c) {
+				return false
+			}
+			continue
+		}
+		s, ok := v.(string)
+		if !ok {
+			s = formatCell(v)
+		}
+		if !c.matches(s) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesAny(values []string, c whereClause) bool {
+	for _, v := range values {
+		if c.matches(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c whereClause) matches(s string) bool {
+	if c.Exact {
+		return s == c.Value
+	}
+	return strings.Contains(s, c.Value)
+}
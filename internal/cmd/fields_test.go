@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var fieldsEntry = ErrorEntry{
+	ID:        "3f9a2c1b7e",
+	Timestamp: "2025-12-10T19:19:32.941Z",
+	Source:    "backend",
+	ErrorType: "DATABASE_ERROR",
+	Message:   "connection refused\nretrying",
+	Line:      12,
+	Tags:      []string{"checkout-v2", "flaky"},
+	Context:   map[string]interface{}{"endpoint_name": "users", "attempt": float64(3)},
+}
+
+func TestParseFields(t *testing.T) {
+	fields, err := parseFields("timestamp, type,message,,context.endpoint_name")
+	if err != nil {
+		t.Fatalf("parseFields() error = %v", err)
+	}
+	if strings.Join(fields, ",") != "timestamp,type,message,context.endpoint_name" {
+		t.Errorf("parseFields() = %v", fields)
+	}
+
+	for _, spec := range []string{"", " , ", "timestamp,bogus", "context."} {
+		if _, err := parseFields(spec); err == nil {
+			t.Errorf("parseFields(%q) should fail", spec)
+		}
+	}
+}
+
+func TestFieldValue(t *testing.T) {
+	tests := []struct {
+		field string
+		want  interface{}
+	}{
+		{"id", "3f9a2c1b7e"},
+		{"type", "DATABASE_ERROR"},
+		{"error_type", "DATABASE_ERROR"},
+		{"line", 12},
+		{"column", nil},
+		{"file", nil},
+		{"context.attempt", float64(3)},
+		{"context.missing", nil},
+	}
+	for _, tt := range tests {
+		if got := fieldValue(fieldsEntry, tt.field); got != tt.want {
+			t.Errorf("fieldValue(%q) = %v, want %v", tt.field, got, tt.want)
+		}
+	}
+}
+
+func TestFormatFieldsJSON(t *testing.T) {
+	output := formatFieldsJSON([]ErrorEntry{fieldsEntry}, []string{"type", "file", "context.endpoint_name"})
+
+	var rows []map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &rows); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, output)
+	}
+	if len(rows) != 1 || len(rows[0]) != 3 {
+		t.Fatalf("expected one row with 3 keys, got %v", rows)
+	}
+	if rows[0]["type"] != "DATABASE_ERROR" || rows[0]["context.endpoint_name"] != "users" {
+		t.Errorf("unexpected row: %v", rows[0])
+	}
+	if v, ok := rows[0]["file"]; !ok || v != nil {
+		t.Errorf("unset fields should be null, got %v", rows[0])
+	}
+
+	if got := formatFieldsJSON(nil, []string{"type"}); got != "[]" {
+		t.Errorf("no entries should be an empty array, got %s", got)
+	}
+}
+
+func TestFormatFieldsTable(t *testing.T) {
+	output := formatFieldsTable([]ErrorEntry{fieldsEntry}, []string{"id", "message", "tags", "column"})
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected header and one row, got:\n%s", output)
+	}
+	if !strings.HasPrefix(lines[0], "ID") || !strings.Contains(lines[0], "MESSAGE") {
+		t.Errorf("unexpected header: %q", lines[0])
+	}
+	for _, want := range []string{"3f9a2c1b7e", "connection refused retrying", "checkout-v2,flaky", "-"} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("row missing %q: %q", want, lines[1])
+		}
+	}
+	if strings.Index(lines[0], "MESSAGE") != strings.Index(lines[1], "connection") {
+		t.Errorf("columns should be aligned:\n%s", output)
+	}
+}
+
+func TestCountEntries(t *testing.T) {
+	entries := []ErrorEntry{
+		{ErrorType: "NETWORK_ERROR", Tags: []string{"a", "b"}},
+		{ErrorType: "NETWORK_ERROR", Tags: []string{"a"}},
+		{ErrorType: "UNCAUGHT_ERROR"},
+	}
+
+	if r := countEntries(entries, ""); r.Count != 3 || r.Groups != nil {
+		t.Errorf("countEntries() = %+v", r)
+	}
+	if got := formatCountHuman(countEntries(entries, "")); got != "3\n" {
+		t.Errorf("formatCountHuman() = %q, want bare count", got)
+	}
+
+	r := countEntries(entries, "type")
+	if len(r.Groups) != 2 || r.Groups[0] != (FieldCount{Value: "NETWORK_ERROR", Count: 2}) {
+		t.Errorf("countEntries(type) = %+v", r)
+	}
+	if got := formatCountHuman(r); got != "2\tNETWORK_ERROR\n1\tUNCAUGHT_ERROR\n" {
+		t.Errorf("formatCountHuman() = %q", got)
+	}
+
+	r = countEntries(entries, "tags")
+	want := []FieldCount{{Value: "a", Count: 2}, {Value: "-", Count: 1}, {Value: "b", Count: 1}}
+	if len(r.Groups) != len(want) {
+		t.Fatalf("countEntries(tags) = %+v", r.Groups)
+	}
+	for i := range want {
+		if r.Groups[i] != want[i] {
+			t.Errorf("Groups[%d] = %+v, want %+v", i, r.Groups[i], want[i])
+		}
+	}
+}
+
+func TestParseWhere(t *testing.T) {
+	clauses, err := parseWhere([]string{"endpoint=/api/users", "queue~mail", "context.attempt=3", "note=a=b"})
+	if err != nil {
+		t.Fatalf("parseWhere() error = %v", err)
+	}
+	want := []whereClause{
+		{Field: "endpoint", Value: "/api/users", Exact: true},
+		{Field: "context.queue", Value: "mail"},
+		{Field: "context.attempt", Value: "3", Exact: true},
+		{Field: "context.note", Value: "a=b", Exact: true},
+	}
+	if len(clauses) != len(want) {
+		t.Fatalf("parseWhere() = %+v", clauses)
+	}
+	for i := range want {
+		if clauses[i] != want[i] {
+			t.Errorf("clause %d = %+v, want %+v", i, clauses[i], want[i])
+		}
+	}
+
+	for _, spec := range []string{"queue", "=emails", "~x"} {
+		if _, err := parseWhere([]string{spec}); err == nil {
+			t.Errorf("parseWhere(%q) should fail", spec)
+		}
+	}
+}
+
+func TestFilterWhere(t *testing.T) {
+	entries := []ErrorEntry{
+		{Message: "a", Endpoint: "/api/users", Context: map[string]interface{}{"queue": "emails", "attempt": float64(3)}},
+		{Message: "b", Endpoint: "/api/users/42", Context: map[string]interface{}{"queue": "emails-priority"}},
+		{Message: "c", Tags: []string{"flaky", "checkout-v2"}},
+	}
+
+	tests := []struct {
+		specs []string
+		want  string
+	}{
+		{[]string{"endpoint=/api/users"}, "a"},
+		{[]string{"endpoint~/api/users"}, "ab"},
+		{[]string{"queue=emails"}, "a"},
+		{[]string{"queue~emails", "endpoint~42"}, "b"},
+		{[]string{"attempt=3"}, "a"},
+		{[]string{"tags=flaky"}, "c"},
+		{[]string{"queue~"}, "ab"},
+		{[]string{"missing=x"}, ""},
+		{nil, "abc"},
+	}
+	for _, tt := range tests {
+		clauses, err := parseWhere(tt.specs)
+		if err != nil {
+			t.Fatalf("parseWhere(%v) error = %v", tt.specs, err)
+		}
+		var got string
+		for _, e := range filterWhere(entries, clauses) {
+			got += e.Message
+		}
+		if got != tt.want {
+			t.Errorf("filterWhere(%v) = %q, want %q", tt.specs, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/diag"
+	"github.com/agentlog/agentlog/internal/logfile"
+)
+
+const fixesFileName = "resolutions.jsonl"
+
+// maxPastFixes is the most fixes shown for one error, the latest first
+const maxPastFixes = 3
+
+var commitHashPattern = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+
+// Fix is one line of .agentlog/resolutions.jsonl: how an error group was
+// fixed, as told to 'agentlog resolve --note/--commit'. Fixes are kept when
+// a group is reopened, since how it was fixed before matters most when it
+// comes back.
+type Fix struct {
+	Fingerprint string `json:"fingerprint"`
+	ErrorType   string `json:"error_type"`
+	Pattern     string `json:"pattern"`
+	FixedAt     string `json:"fixed_at"`
+	Commit      string `json:"commit,omitempty"`
+	Note        string `json:"note,omitempty"`
+}
+
+// String describes the fix, e.g. `Retry the pool on startup (commit
+// 3f9a2c1, 2d ago)`
+func (f Fix) String() string {
+	when := formatTimestamp(f.FixedAt)
+	if f.Commit != "" {
+		when = "commit " + f.Commit + ", " + when
+	}
+	if f.Note == "" {
+		return when
+	}
+	return fmt.Sprintf("%s (%s)", f.Note, when)
+}
+
+func fixesPath(baseDir string) string {
+	return filepath.Join(baseDir, ".agentlog", fixesFileName)
+}
+
+// recordFixes appends fixes to .agentlog/resolutions.jsonl
+func recordFixes(baseDir string, fixes []Fix) error {
+	if err := os.MkdirAll(filepath.Join(baseDir, ".agentlog"), 0755); err != nil {
+		return err
+	}
+	var sb strings.Builder
+	for _, f := range fixes {
+		data, _ := json.Marshal(f)
+		sb.Write(append(data, '\n'))
+	}
+	return logfile.Append(fixesPath(baseDir), []byte(sb.String()))
+}
+
+// readFixes reads .agentlog/resolutions.jsonl, skipping lines that don't
+// parse
+func readFixes(baseDir string) ([]Fix, error) {
+	f, err := os.Open(fixesPath(baseDir))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var fixes []Fix
+	err = scanRecords(baseDir, f, func(line []byte) error {
+		var fix Fix
+		if err := json.Unmarshal(line, &fix); err != nil {
+			return err
+		}
+		if fix.Fingerprint != "" {
+			fixes = append(fixes, fix)
+		}
+		return nil
+	})
+	return fixes, err
+}
+
+// commitHash turns ref into a short commit hash with git. A ref git
+// can't find is kept if it looks like a hash, for fixes committed
+// elsewhere.
+func commitHash(baseDir, ref string) (string, error) {
+	out, err := gitOutput(baseDir, "rev-parse", "--verify", "--short", ref+"^{commit}")
+	if err == nil {
+		return strings.TrimSpace(out), nil
+	}
+	if commitHashPattern.MatchString(ref) {
+		return strings.ToLower(ref), nil
+	}
+	return "", fmt.Errorf("--commit %s: %v", ref, err)
+}
+
+// parsedFix is a fix parsed for matching
+type parsedFix struct {
+	Fix
+	fixed  time.Time
+	tokens map[string]bool
+}
+
+// fixSet is the recorded fixes for a project
+type fixSet struct {
+	baseDir string
+	modTime time.Time
+	fixes   []parsedFix
+}
+
+// loadFixes reads the recorded fixes. A missing or unreadable file means
+// none.
+func loadFixes(baseDir string) *fixSet {
+	set := &fixSet{baseDir: baseDir}
+	if info, err := os.Stat(fixesPath(baseDir)); err == nil {
+		set.modTime = info.ModTime()
+	}
+	fixes, err := readFixes(baseDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			diag.Debugf("reading %s: %v", fixesPath(baseDir), err)
+		}
+		return set
+	}
+	for _, f := range fixes {
+		ts, err := parseEntryTime(f.FixedAt)
+		if err != nil {
+			continue
+		}
+		set.fixes = append(set.fixes, parsedFix{Fix: f, fixed: ts, tokens: messageTokens(f.Pattern)})
+	}
+	return set
+}
+
+// refresh reloads the set if resolutions.jsonl changed since it was read
+func (s *fixSet) refresh() {
+	info, err := os.Stat(fixesPath(s.baseDir))
+	if err != nil && s.modTime.IsZero() {
+		return
+	}
+	if err == nil && info.ModTime().Equal(s.modTime) {
+		return
+	}
+	*s = *loadFixes(s.baseDir)
+}
+
+// find returns the fixes of groups an error of errorType with the given
+// normalized pattern would group with, recorded before the error occurred
+// when occurred is set; the latest first
+func (s *fixSet) find(errorType, pattern string, occurred time.Time) []Fix {
+	var tokens map[string]bool
+	var found []parsedFix
+	for _, f := range s.fixes {
+		if f.ErrorType != errorType || (!occurred.IsZero() && !occurred.After(f.fixed)) {
+			continue
+		}
+		if f.Pattern != pattern {
+			if tokens == nil {
+				tokens = messageTokens(pattern)
+			}
+			if !wouldGroup(tokens, f.tokens) {
+				continue
+			}
+		}
+		found = append(found, f)
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].fixed.After(found[j].fixed) })
+	if len(found) > maxPastFixes {
+		found = found[:maxPastFixes]
+	}
+	var fixes []Fix
+	for _, f := range found {
+		fixes = append(fixes, f.Fix)
+	}
+	return fixes
+}
+
+// match returns the fixes of e's error recorded before it occurred
+func (s *fixSet) match(e ErrorEntry) []Fix {
+	if len(s.fixes) == 0 || e.kind() != kindError {
+		return nil
+	}
+	ts, err := parseEntryTime(e.Timestamp)
+	if err != nil {
+		return nil
+	}
+	return s.find(e.ErrorType, normalizeMessage(e.Message), ts)
+}
+
+// RecurringFix is a fixed error that occurred again after the fix
+type RecurringFix struct {
+	Fix
+	LastSeen string `json:"last_seen"`
+}
+
+// String describes the fix and the error it was for, e.g.
+// `DATABASE_ERROR "connection refused" was fixed 2d ago: Retry the pool on
+// startup (commit 3f9a2c1)`
+func (r RecurringFix) String() string {
+	s := fmt.Sprintf("%s %q was fixed %s", r.ErrorType, truncate(r.Pattern, 80), formatTimestamp(r.FixedAt))
+	var how []string
+	if r.Note != "" {
+		how = append(how, r.Note)
+	}
+	if r.Commit != "" {
+		how = append(how, "commit "+r.Commit)
+	}
+	if len(how) > 0 {
+		s += ": " + strings.Join(how, ", ")
+	}
+	return s
+}
+
+// recurring returns the latest fix of each error entries include an
+// occurrence of since it was fixed, most recently seen first
+func (s *fixSet) recurring(entries []ErrorEntry) []RecurringFix {
+	byFingerprint := make(map[string]*RecurringFix)
+	for _, e := range entries {
+		fixes := s.match(e)
+		if len(fixes) == 0 {
+			continue
+		}
+		r := byFingerprint[fixes[0].Fingerprint]
+		if r == nil {
+			r = &RecurringFix{Fix: fixes[0]}
+			byFingerprint[fixes[0].Fingerprint] = r
+		} else if timestampBefore(r.FixedAt, fixes[0].FixedAt) {
+			r.Fix = fixes[0]
+		}
+		if r.LastSeen == "" || timestampBefore(r.LastSeen, e.Timestamp) {
+			r.LastSeen = e.Timestamp
+		}
+	}
+
+	result := make([]RecurringFix, 0, len(byFingerprint))
+	for _, r := range byFingerprint {
+		result = append(result, *r)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].LastSeen != result[j].LastSeen {
+			return timestampBefore(result[j].LastSeen, result[i].LastSeen)
+		}
+		return result[i].Fingerprint < result[j].Fingerprint
+	})
+	return result
+}
+
+// writeFixLines writes one line per past fix, under an error
+func writeFixLines(sb *strings.Builder, indent string, fixes []Fix) {
+	for _, f := range fixes {
+		sb.WriteString(fmt.Sprintf("%sFixed before: %s\n", indent, f))
+	}
+}
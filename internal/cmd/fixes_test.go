@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/config"
+)
+
+// recordFixAt records a fix of the database error in writeResolveLog
+func recordFixAt(t *testing.T, dir string, at time.Time, commit, note string) {
+	t.Helper()
+	pattern := "connection refused on port <n>"
+	err := recordFixes(dir, []Fix{{
+		Fingerprint: fingerprint("DATABASE_ERROR", pattern),
+		ErrorType:   "DATABASE_ERROR",
+		Pattern:     pattern,
+		FixedAt:     at.UTC().Format(time.RFC3339),
+		Commit:      commit,
+		Note:        note,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFixSet_Match(t *testing.T) {
+	now := time.Now()
+	dir := writeResolveLog(t, now)
+	recordFixAt(t, dir, now.Add(-150*time.Minute), "", "Raise the pool size")
+	recordFixAt(t, dir, now.Add(-time.Hour), "abc1234", "Retry the pool on startup")
+	set := loadFixes(dir)
+
+	entries, _ := readErrors(dir)
+	var got []string
+	for _, e := range entries {
+		var notes []string
+		for _, f := range set.match(e) {
+			notes = append(notes, f.Note)
+		}
+		got = append(got, strings.Join(notes, ","))
+	}
+	want := []string{"", "Raise the pool size", "", "Retry the pool on startup,Raise the pool size"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("fixes by entry = %q, want %q", got, want)
+	}
+	if fixes := set.find("DATABASE_ERROR", "connection refused on port <n> again", time.Time{}); len(fixes) != 2 {
+		t.Errorf("a zero time should find every fix of a close pattern, got %+v", fixes)
+	}
+	if fixes := set.find("NETWORK_ERROR", "connection refused on port <n>", time.Time{}); len(fixes) != 0 {
+		t.Errorf("another type shouldn't match, got %+v", fixes)
+	}
+
+	recurring := set.recurring(entries)
+	if len(recurring) != 1 || recurring[0].String() != `DATABASE_ERROR "connection refused on port <n>" was fixed 1h ago: Retry the pool on startup, commit abc1234` {
+		t.Errorf("recurring = %+v", recurring)
+	}
+}
+
+func TestResolveCommand_Fix(t *testing.T) {
+	dir := gitRepo(t)
+	originalPath, originalJSON := pathOverride, jsonOutput
+	defer func() { pathOverride, jsonOutput = originalPath, originalJSON; resetResolveFlags() }()
+	pathOverride, jsonOutput = dir, true
+	resetResolveFlags()
+
+	head, err := gitOutput(dir, "rev-parse", "--short", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := readErrors(dir)
+	buf := new(bytes.Buffer)
+	resolveCmd.SetOut(buf)
+	defer resolveCmd.SetOut(nil)
+	resolveNote, resolveCommit = "Handle m", "HEAD"
+	if err := runResolve(resolveCmd, []string{entries[0].ID}); err != nil {
+		t.Fatalf("runResolve() error = %v", err)
+	}
+	var results []ResolveResult
+	if err := json.Unmarshal(buf.Bytes(), &results); err != nil || len(results) != 1 || results[0].Fix == nil {
+		t.Fatalf("JSON = %s (%v)", buf.String(), err)
+	}
+	if f := results[0].Fix; f.Commit != strings.TrimSpace(head) || f.Note != "Handle m" || f.FixedAt != results[0].ResolvedAt {
+		t.Errorf("fix = %+v", f)
+	}
+	if fixes, err := readFixes(dir); err != nil || len(fixes) != 1 {
+		t.Errorf("resolutions.jsonl = %+v (%v)", fixes, err)
+	}
+
+	resolveNote, resolveCommit, jsonOutput = "", "", false
+	resolveList = true
+	buf.Reset()
+	if err := runResolve(resolveCmd, nil); err != nil || !strings.Contains(buf.String(), "\n  Fix: Handle m (commit "+strings.TrimSpace(head)) {
+		t.Errorf("--list should show the fix, got %q (%v)", buf.String(), err)
+	}
+
+	// The fix outlives a reopen
+	resolveList, resolveUndo = false, true
+	if err := runResolve(resolveCmd, []string{results[0].Fingerprint}); err != nil {
+		t.Fatal(err)
+	}
+	if fixes, _ := readFixes(dir); len(fixes) != 1 {
+		t.Errorf("--undo shouldn't drop the fix, got %+v", fixes)
+	}
+
+	resolveNote = "x"
+	if err := runResolve(resolveCmd, []string{entries[0].ID}); ExitCode(err) != ExitError {
+		t.Errorf("--undo with --note should exit 2, got %v", err)
+	}
+	resolveUndo, resolveNote, resolveCommit = false, "", "not-a-ref"
+	if err := runResolve(resolveCmd, []string{entries[0].ID}); ExitCode(err) != ExitError {
+		t.Errorf("an unknown --commit should exit 2, got %v", err)
+	}
+}
+
+func TestRenderErrors_PastFixes(t *testing.T) {
+	now := time.Now()
+	dir := writeResolveLog(t, now)
+	recordFixAt(t, dir, now.Add(-time.Hour), "abc1234", "Retry the pool on startup")
+	defer func() { errorsLimit, errorsGroup, jsonOutput = 10, false, false }()
+	errorsLimit, errorsSource, errorsType, errorsSince = 10, "", "", ""
+	errorsCount, errorsGroup, errorsPick, errorsFields, errorsTemplate, jsonOutput = false, false, false, "", "", false
+
+	var buf bytes.Buffer
+	if err := renderErrors(&buf, dir); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(buf.String(), "Fixed before: ") != 1 || !strings.Contains(buf.String(), "connection refused on port 5433\n  Fixed before: Retry the pool on startup (commit abc1234, 1h ago)\n") {
+		t.Errorf("only the entry after the fix should show it:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	errorsGroup, jsonOutput = true, true
+	if err := renderErrors(&buf, dir); err != nil {
+		t.Fatal(err)
+	}
+	var groups []ErrorGroup
+	if err := json.Unmarshal(buf.Bytes(), &groups); err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range groups {
+		if (len(g.PastFixes) == 1) != (g.ErrorType == "DATABASE_ERROR") {
+			t.Errorf("group %s past fixes = %+v", g.ErrorType, g.PastFixes)
+		}
+	}
+}
+
+func TestFormatTailEntry_PastFixes(t *testing.T) {
+	entry := ErrorEntry{
+		Timestamp: "2025-12-10T19:19:32.941Z",
+		Source:    "backend",
+		ErrorType: "DATABASE_ERROR",
+		Message:   "connection refused",
+		PastFixes: []Fix{{Fingerprint: "8c1d2e3f4a5b", FixedAt: "2025-12-08T10:00:00Z", Commit: "abc1234"}},
+	}
+	defer func() { absoluteTime = false }()
+	absoluteTime = true
+	if output := formatTailEntry(entry, false); !strings.Contains(output, "  Fixed before: commit abc1234, 2025-12-08T10:00:00Z\n") {
+		t.Errorf("output = %q", output)
+	}
+	withoutFixes := entry
+	withoutFixes.PastFixes = nil
+	if entryID(entry) != entryID(withoutFixes) {
+		t.Error("past fixes shouldn't change the entry's ID")
+	}
+}
+
+func TestPrimeSummary_PastFixes(t *testing.T) {
+	now := time.Now()
+	dir := writeResolveLog(t, now)
+	recordFixAt(t, dir, now.Add(-time.Hour), "abc1234", "Retry the pool on startup")
+
+	summary := primeInDir(t, dir, false)
+	if len(summary.PastFixes) != 1 || summary.PastFixes[0].Note != "Retry the pool on startup" {
+		t.Fatalf("PastFixes = %+v", summary.PastFixes)
+	}
+	want := `  Fixed before: DATABASE_ERROR "connection refused on port <n>" was fixed 1h ago: Retry the pool on startup, commit abc1234` + "\n"
+	if out := formatPrimeSummaryHuman(summary); !strings.Contains(out, want) {
+		t.Errorf("expected %q in:\n%s", want, out)
+	}
+	if claude := formatPrimeClaude(summary); !strings.Contains(claude, "fixed before") || !strings.Contains(claude, "reverted") {
+		t.Errorf("the claude preset should point at the earlier fix:\n%s", claude)
+	}
+	var generic genericPrimeSummary
+	if err := json.Unmarshal([]byte(formatPrimeGenericJSON(summary)), &generic); err != nil || len(generic.PastFixes) != 1 {
+		t.Errorf("generic-json past_fixes = %+v (%v)", generic.PastFixes, err)
+	}
+}
+
+func TestShowAndSimilar_PastFixes(t *testing.T) {
+	now := time.Now()
+	dir := writeResolveLog(t, now)
+	recordFixAt(t, dir, now.Add(-time.Hour), "abc1234", "Retry the pool on startup")
+	originalPath, originalJSON := pathOverride, jsonOutput
+	defer func() { pathOverride, jsonOutput = originalPath, originalJSON; resetSimilarFlags() }()
+	pathOverride, jsonOutput = dir, false
+	resetSimilarFlags()
+
+	buf := new(bytes.Buffer)
+	showCmd.SetOut(buf)
+	defer showCmd.SetOut(nil)
+	fp := fingerprint("DATABASE_ERROR", "connection refused on port <n>")
+	if err := runShow(showCmd, []string{fp}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "\nPast fixes:\n  Retry the pool on startup (commit abc1234, 1h ago)\n") {
+		t.Errorf("show should list the past fixes:\n%s", buf.String())
+	}
+
+	entries, _ := readErrors(dir)
+	result, err := findSimilar(dir, entries, entries[3].ID, similarTokens, config.EmbeddingsConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Group.PastFixes) != 1 {
+		t.Errorf("the group's past fixes = %+v", result.Group.PastFixes)
+	}
+	if out := formatSimilarHuman(result); !strings.Contains(out, "\n  Fixed before: Retry the pool on startup (commit abc1234, 1h ago)\n") {
+		t.Errorf("similar should show the fix:\n%s", out)
+	}
+}
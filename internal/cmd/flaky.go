@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// flakySlot is the resolution sessions are found at: a slot of this length
+// with no entries at all separates one session from the next
+const flakySlot = 30 * time.Minute
+
+// flakyMaxSlots caps the slots remembered per error, keeping the latest
+const flakyMaxSlots = 500
+
+// flakyMinReturns is how many times an error must come back, after a
+// session without it, to be called flaky
+const flakyMinReturns = 2
+
+// FlakyError is an error group that keeps going away and coming back
+// across sessions, which suggests an intermittent cause (a network blip, a
+// race, a timing-dependent test) rather than a regression
+type FlakyError struct {
+	Fingerprint string `json:"fingerprint"`
+	ErrorType   string `json:"error_type"`
+	Pattern     string `json:"pattern"`
+	Count       int    `json:"count"`
+	Sessions    int    `json:"sessions"` // sessions it occurred in
+	Of          int    `json:"of"`       // sessions since it first occurred
+	Returns     int    `json:"returns"`  // times it came back after a session without it
+}
+
+// String describes the error's pattern, e.g. `NETWORK_ERROR "fetch failed"
+// in 4 of 9 sessions, back 3 times`
+func (f FlakyError) String() string {
+	return fmt.Sprintf("%s %q in %d of %d sessions, back %d times", f.ErrorType, truncate(f.Pattern, 80), f.Sessions, f.Of, f.Returns)
+}
+
+// presenceBucket is one error type and normalized message, and the slots
+// it occurred in
+type presenceBucket struct {
+	ErrorType string  `json:"error_type"`
+	Pattern   string  `json:"pattern"`
+	Count     int     `json:"count"`
+	Slots     []int64 `json:"slots"` // ascending
+}
+
+// presence records which slots of the log's timeline had entries, and which
+// of them each error occurred in. Sets of slots merge in any order, so
+// ranges of a log counted in parallel combine exactly.
+type presence struct {
+	active  map[int64]bool
+	buckets map[string]*presenceBucket
+}
+
+func newPresence() *presence {
+	return &presence{active: make(map[int64]bool), buckets: make(map[string]*presenceBucket)}
+}
+
+func slotOf(t time.Time) int64 {
+	return t.Unix() / int64(flakySlot/time.Second)
+}
+
+// add records e's slot as active and, for an error, as one its bucket
+// occurred in. Healthchecks are doctor's, not the app's, so they don't
+// count as activity.
+func (p *presence) add(e ErrorEntry) {
+	ts, err := parseEntryTime(e.Timestamp)
+	if err != nil {
+		return
+	}
+	if e.kind() == kindHealthcheck {
+		return
+	}
+	slot := slotOf(ts)
+	p.active[slot] = true
+	if e.kind() != kindError {
+		return
+	}
+	pattern := normalizeMessage(e.Message)
+	key := e.ErrorType + "\x00" + pattern
+	b := p.buckets[key]
+	if b == nil {
+		b = &presenceBucket{ErrorType: e.ErrorType, Pattern: pattern}
+		p.buckets[key] = b
+	}
+	b.Count += e.occurrences()
+	b.Slots = insertSlot(b.Slots, slot)
+}
+
+// insertSlot adds slot to the ascending slots, keeping the latest
+// flakyMaxSlots
+func insertSlot(slots []int64, slot int64) []int64 {
+	i := sort.Search(len(slots), func(i int) bool { return slots[i] >= slot })
+	if i < len(slots) && slots[i] == slot {
+		return slots
+	}
+	slots = append(slots, 0)
+	copy(slots[i+1:], slots[i:])
+	slots[i] = slot
+	if len(slots) > flakyMaxSlots {
+		slots = slots[len(slots)-flakyMaxSlots:]
+	}
+	return slots
+}
+
+// merge adds other's slots and buckets to p
+func (p *presence) merge(other *presence) {
+	for slot := range other.active {
+		p.active[slot] = true
+	}
+	for key, ob := range other.buckets {
+		b := p.buckets[key]
+		if b == nil {
+			b = &presenceBucket{ErrorType: ob.ErrorType, Pattern: ob.Pattern}
+			p.buckets[key] = b
+		}
+		b.Count += ob.Count
+		for _, slot := range ob.Slots {
+			b.Slots = insertSlot(b.Slots, slot)
+		}
+	}
+}
+
+// sessions numbers each active slot by the session it falls in: runs of
+// consecutive active slots, oldest first. It returns the numbering and the
+// number of sessions.
+func (p *presence) sessions() (map[int64]int, int) {
+	slots := make([]int64, 0, len(p.active))
+	for slot := range p.active {
+		slots = append(slots, slot)
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i] < slots[j] })
+
+	numbering := make(map[int64]int, len(slots))
+	session := -1
+	for i, slot := range slots {
+		if i == 0 || slot > slots[i-1]+1 {
+			session++
+		}
+		numbering[slot] = session
+	}
+	return numbering, session + 1
+}
+
+// flaky returns the error groups that came back at least flakyMinReturns
+// times after a session without them, most returns first, up to n.
+// Buckets are grouped as errors --group groups entries.
+func (p *presence) flaky(n int) []FlakyError {
+	if p == nil || len(p.buckets) == 0 {
+		return nil
+	}
+	numbering, total := p.sessions()
+
+	// Buckets stand in for their entries, so clusters come out as they
+	// would from the log
+	keys := make([]string, 0, len(p.buckets))
+	for key := range p.buckets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var reps []ErrorEntry
+	for _, key := range keys {
+		b := p.buckets[key]
+		reps = append(reps, ErrorEntry{ErrorType: b.ErrorType, Message: b.Pattern, Count: b.Count})
+	}
+
+	var result []FlakyError
+	for _, c := range clusterEntries(reps) {
+		seen := make(map[int]bool)
+		for _, e := range c.entries {
+			for _, slot := range p.buckets[e.ErrorType+"\x00"+e.Message].Slots {
+				seen[numbering[slot]] = true
+			}
+		}
+		present := make([]int, 0, len(seen))
+		for s := range seen {
+			present = append(present, s)
+		}
+		sort.Ints(present)
+
+		returns := 0
+		for i := 1; i < len(present); i++ {
+			if present[i] > present[i-1]+1 {
+				returns++
+			}
+		}
+		if returns < flakyMinReturns {
+			continue
+		}
+		result = append(result, FlakyError{
+			Fingerprint: c.fingerprint(),
+			ErrorType:   c.errorType,
+			Pattern:     c.pattern,
+			Count:       totalOccurrences(c.entries),
+			Sessions:    len(present),
+			Of:          total - present[0],
+			Returns:     returns,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Returns != result[j].Returns {
+			return result[i].Returns > result[j].Returns
+		}
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Fingerprint < result[j].Fingerprint
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
+
+// presenceJSON is how a presence is kept in prime's cache
+type presenceJSON struct {
+	Active  []int64           `json:"active"`
+	Buckets []*presenceBucket `json:"buckets"`
+}
+
+func (p *presence) MarshalJSON() ([]byte, error) {
+	out := presenceJSON{Active: make([]int64, 0, len(p.active)), Buckets: make([]*presenceBucket, 0, len(p.buckets))}
+	for slot := range p.active {
+		out.Active = append(out.Active, slot)
+	}
+	sort.Slice(out.Active, func(i, j int) bool { return out.Active[i] < out.Active[j] })
+	for _, b := range p.buckets {
+		out.Buckets = append(out.Buckets, b)
+	}
+	sort.Slice(out.Buckets, func(i, j int) bool {
+		if out.Buckets[i].ErrorType != out.Buckets[j].ErrorType {
+			return out.Buckets[i].ErrorType < out.Buckets[j].ErrorType
+		}
+		return out.Buckets[i].Pattern < out.Buckets[j].Pattern
+	})
+	return json.Marshal(out)
+}
+
+func (p *presence) UnmarshalJSON(data []byte) error {
+	var in presenceJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*p = *newPresence()
+	for _, slot := range in.Active {
+		p.active[slot] = true
+	}
+	for _, b := range in.Buckets {
+		if b != nil {
+			p.buckets[b.ErrorType+"\x00"+b.Pattern] = b
+		}
+	}
+	return nil
+}
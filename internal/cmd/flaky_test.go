@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flakyFixture has five sessions two hours apart: BUILD_ERROR in every
+// one, NETWORK_ERROR in the first, third, and fifth, and DATABASE_ERROR,
+// new, in the last two
+func flakyFixture(start time.Time) []ErrorEntry {
+	at := func(session int, minutes int) string {
+		return start.Add(time.Duration(session)*2*time.Hour + time.Duration(minutes)*time.Minute).Format(time.RFC3339Nano)
+	}
+	var entries []ErrorEntry
+	for s := 0; s < 5; s++ {
+		entries = append(entries, ErrorEntry{Timestamp: at(s, 0), Source: "backend", ErrorType: "BUILD_ERROR", Message: "tsc exited with 2"})
+		entries = append(entries, ErrorEntry{Timestamp: at(s, 25), Source: "backend", ErrorType: "BUILD_ERROR", Message: "tsc exited with 1"})
+		if s%2 == 0 {
+			entries = append(entries, ErrorEntry{Timestamp: at(s, 10), Source: "frontend", ErrorType: "NETWORK_ERROR", Message: "fetch failed after 503ms"})
+		}
+		if s >= 3 {
+			entries = append(entries, ErrorEntry{Timestamp: at(s, 20), Source: "backend", ErrorType: "DATABASE_ERROR", Message: "deadlock detected"})
+		}
+	}
+	return entries
+}
+
+func TestPresenceFlaky(t *testing.T) {
+	p := newPresence()
+	for _, e := range flakyFixture(time.Date(2025, 12, 10, 8, 0, 0, 0, time.UTC)) {
+		p.add(e)
+	}
+	if _, sessions := p.sessions(); sessions != 5 {
+		t.Errorf("sessions = %d, want 5", sessions)
+	}
+
+	flaky := p.flaky(5)
+	if len(flaky) != 1 {
+		t.Fatalf("flaky = %+v, want only NETWORK_ERROR", flaky)
+	}
+	f := flaky[0]
+	if f.ErrorType != "NETWORK_ERROR" || f.Sessions != 3 || f.Of != 5 || f.Returns != 2 || f.Count != 3 {
+		t.Errorf("flaky[0] = %+v", f)
+	}
+	if f.Fingerprint != fingerprint("NETWORK_ERROR", "fetch failed after <n>ms") {
+		t.Errorf("fingerprint should match errors --group's, got %s", f.Fingerprint)
+	}
+	if got, want := f.String(), `NETWORK_ERROR "fetch failed after <n>ms" in 3 of 5 sessions, back 2 times`; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if len(p.flaky(0)) != 0 {
+		t.Error("flaky(0) should return nothing")
+	}
+}
+
+func TestPresence_MergeAndJSON(t *testing.T) {
+	entries := flakyFixture(time.Date(2025, 12, 10, 8, 0, 0, 0, time.UTC))
+	whole := newPresence()
+	a, b := newPresence(), newPresence()
+	for i, e := range entries {
+		whole.add(e)
+		if i%2 == 0 {
+			a.add(e)
+		} else {
+			b.add(e)
+		}
+	}
+	a.merge(b)
+	if !reflect.DeepEqual(a.flaky(5), whole.flaky(5)) {
+		t.Errorf("merged = %+v, want %+v", a.flaky(5), whole.flaky(5))
+	}
+
+	data, err := json.Marshal(whole)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded presence
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.flaky(5), whole.flaky(5)) {
+		t.Errorf("decoded = %+v, want %+v", decoded.flaky(5), whole.flaky(5))
+	}
+}
+
+func TestInsertSlot(t *testing.T) {
+	var slots []int64
+	for _, s := range []int64{5, 1, 3, 3, 9} {
+		slots = insertSlot(slots, s)
+	}
+	if !reflect.DeepEqual(slots, []int64{1, 3, 5, 9}) {
+		t.Errorf("slots = %v", slots)
+	}
+	for s := int64(100); s < 100+flakyMaxSlots; s++ {
+		slots = insertSlot(slots, s)
+	}
+	if len(slots) != flakyMaxSlots || slots[0] != 100 {
+		t.Errorf("expected the latest %d slots, got %d from %d", flakyMaxSlots, len(slots), slots[0])
+	}
+}
+
+func TestStatsCommand_Flaky(t *testing.T) {
+	dir := writeEntriesLog(t, flakyFixture(time.Date(2025, 12, 10, 8, 0, 0, 0, time.UTC)))
+	originalPath, originalJSON := pathOverride, jsonOutput
+	defer func() { pathOverride, jsonOutput = originalPath, originalJSON }()
+	pathOverride, jsonOutput = dir, false
+	statsSince, statsLimit, statsKind = "", 5, kindError
+
+	buf := new(bytes.Buffer)
+	statsCmd.SetOut(buf)
+	defer statsCmd.SetOut(nil)
+	if err := runStats(statsCmd, nil); err != nil {
+		t.Fatalf("runStats() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "\nLikely flaky:\n  NETWORK_ERROR \"fetch failed after <n>ms\" in 3 of 5 sessions, back 2 times\n") {
+		t.Errorf("stats should list the flaky error:\n%s", buf.String())
+	}
+
+	jsonOutput = true
+	buf.Reset()
+	if err := runStats(statsCmd, nil); err != nil {
+		t.Fatalf("runStats() error = %v", err)
+	}
+	var r StatsReport
+	if err := json.Unmarshal(buf.Bytes(), &r); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(r.Flaky) != 1 || r.Flaky[0].Returns != 2 {
+		t.Errorf("flaky = %+v", r.Flaky)
+	}
+}
+
+func TestPrimeSummary_Flaky(t *testing.T) {
+	entries := flakyFixture(time.Now().UTC().Add(-10 * time.Hour))
+	for i := range entries {
+		entries[i].Environment = "dev"
+	}
+	dir := writeEntriesLog(t, entries)
+
+	cached := primeInDir(t, dir, false)
+	full := primeInDir(t, dir, true)
+	if !reflect.DeepEqual(cached, full) {
+		t.Errorf("cached summary differs from full read:\ncached: %+v\nfull:   %+v", cached.Flaky, full.Flaky)
+	}
+	if len(full.Flaky) != 1 || full.Flaky[0].ErrorType != "NETWORK_ERROR" {
+		t.Fatalf("Flaky = %+v", full.Flaky)
+	}
+	if out := formatPrimeSummaryHuman(full); !strings.Contains(out, "  Likely flaky: NETWORK_ERROR") {
+		t.Errorf("human output should flag the flaky error:\n%s", out)
+	}
+	if out := formatPrimeClaude(full); !strings.Contains(out, "rather than a regression") {
+		t.Errorf("the claude preset should say how to treat flaky errors:\n%s", out)
+	}
+
+	defer func() { primeEnv = "" }()
+	primeEnv = "prod"
+	if s := primeInDir(t, dir, true); len(s.Flaky) != 0 {
+		t.Errorf("--env prod has no errors, so nothing is flaky in it: %+v", s.Flaky)
+	}
+}
+
+func TestGenerateTip_Flaky(t *testing.T) {
+	s := PrimeSummary{
+		TotalErrors:   10,
+		TopErrorTypes: []ErrorTypeCount{{ErrorType: "NETWORK_ERROR", Count: 6}},
+		TopSources:    []SourceCount{{Source: "frontend", Count: 10}},
+	}
+	if tip := generateTip(s); strings.Contains(tip, "flaky") {
+		t.Errorf("tip = %q", tip)
+	}
+	s.Flaky = []FlakyError{{ErrorType: "NETWORK_ERROR"}}
+	if tip := generateTip(s); !strings.HasSuffix(tip, "60% of errors, though it comes and goes and may be flaky") {
+		t.Errorf("tip = %q", tip)
+	}
+}
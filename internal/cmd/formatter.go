@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/agentlog/agentlog/internal/config"
+)
+
+// envFormatterTotal tells a formatter run by errors how many entries
+// matched before --limit, so it can say how many it wasn't shown
+const envFormatterTotal = "AGENTLOG_TOTAL"
+
+// configuredFormatter is the project's formatter command, or "" when it
+// has none
+func configuredFormatter(baseDir string) string {
+	cfg, err := config.Load(baseDir)
+	if err != nil {
+		return ""
+	}
+	return cfg.Formatter
+}
+
+// formatterStream feeds entries, one JSON object per line, to a running
+// formatter command whose output goes where agentlog's own would
+type formatterStream struct {
+	command string
+	stdin   io.WriteCloser
+	enc     *json.Encoder
+	exited  chan struct{} // closed once the command exits
+	err     error         // how it exited, once it has
+}
+
+// startFormatter runs command through the shell in the project directory,
+// with the same environment a plugin gets plus extra, writing its output
+// to w. Its stderr is agentlog's.
+func startFormatter(w io.Writer, baseDir, command string, extra ...string) (*formatterStream, error) {
+	c := shellCommand(baseDir, command, extra...)
+	c.Stdout = w
+	c.Stderr = os.Stderr
+	stdin, err := c.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Start(); err != nil {
+		return nil, &CommandError{Code: "FORMATTER_ERROR", Err: fmt.Errorf("formatter %q: %w", command, err)}
+	}
+	f := &formatterStream{command: command, stdin: stdin, enc: json.NewEncoder(stdin), exited: make(chan struct{})}
+	go func() {
+		f.err = c.Wait()
+		close(f.exited)
+	}()
+	return f, nil
+}
+
+// shellCommand runs command through the shell in the project directory,
+// with the variables plugins get plus extra
+func shellCommand(baseDir, command string, extra ...string) *exec.Cmd {
+	var c *exec.Cmd
+	if runtime.GOOS == "windows" {
+		c = exec.Command("cmd", "/C", command)
+	} else {
+		c = exec.Command("sh", "-c", command)
+	}
+	c.Dir = baseDir
+	c.Env = append(pluginEnv(os.Environ(), baseDir), extra...)
+	return c
+}
+
+// write sends one entry to the formatter
+func (f *formatterStream) write(e ErrorEntry) error {
+	// Entries echoed by ingesters haven't been read back from the file
+	if e.ID == "" {
+		e.ID = entryID(e)
+	}
+	return f.enc.Encode(e)
+}
+
+// close ends the formatter's input and waits for it to exit, reporting a
+// non-zero exit as an error
+func (f *formatterStream) close() error {
+	f.stdin.Close()
+	<-f.exited
+	if f.err != nil {
+		return &CommandError{Code: "FORMATTER_ERROR", Err: fmt.Errorf("formatter %q: %w", f.command, f.err)}
+	}
+	return nil
+}
+
+// runFormatter renders entries with the formatter command: they're its
+// input as NDJSON, and its output replaces the human-readable list
+func runFormatter(w io.Writer, baseDir, command string, entries []ErrorEntry, total int) error {
+	f, err := startFormatter(w, baseDir, command, fmt.Sprintf("%s=%d", envFormatterTotal, total))
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		// A formatter that exits without reading everything is reported
+		// by close; the broken pipe isn't the error worth showing
+		if f.write(e) != nil {
+			break
+		}
+	}
+	return f.close()
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writeFormatter configures the project in dir to format with command
+func writeFormatter(t *testing.T, dir, command string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("formatter commands need a POSIX shell")
+	}
+	config, _ := json.Marshal(map[string]string{"formatter": command})
+	if err := os.WriteFile(filepath.Join(dir, ".agentlog", "config.json"), config, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRenderErrors_Formatter(t *testing.T) {
+	tmpDir := writeScanLog(t, 10)
+	os.WriteFile(filepath.Join(tmpDir, "format.sh"), []byte(`n=0
+while read -r line; do n=$((n+1)); last=$line; done
+echo "$n of $AGENTLOG_TOTAL in $(basename "$PWD")"
+echo "$last"
+`), 0755)
+	writeFormatter(t, tmpDir, "sh ./format.sh")
+	defer func() { errorsLimit, errorsTemplate, errorsNoFormat = 10, "", false }()
+	errorsLimit, errorsSource, errorsType, errorsSince = 3, "", "", ""
+	errorsCount, errorsGroup, errorsPick, errorsFields, errorsTemplate, jsonOutput = false, false, false, "", "", false
+
+	var buf bytes.Buffer
+	if err := renderErrors(&buf, tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || lines[0] != "3 of 10 in "+filepath.Base(tmpDir) {
+		t.Fatalf("formatter output should replace the list, got:\n%s", buf.String())
+	}
+	var last ErrorEntry
+	if err := json.Unmarshal([]byte(lines[1]), &last); err != nil || last.Message != "error 9" || last.ID == "" {
+		t.Errorf("formatter should read entries as JSON lines, got %q (%v)", lines[1], err)
+	}
+
+	// --template and --no-formatter bypass it
+	buf.Reset()
+	errorsTemplate = "{{.Message}}"
+	if err := renderErrors(&buf, tmpDir); err != nil || buf.String() != "error 7\nerror 8\nerror 9\n" {
+		t.Errorf("--template should win over the formatter, got %q (%v)", buf.String(), err)
+	}
+	buf.Reset()
+	errorsTemplate, errorsNoFormat = "", true
+	if err := renderErrors(&buf, tmpDir); err != nil || !strings.Contains(buf.String(), "Showing 3 of 10") {
+		t.Errorf("--no-formatter should print the built-in list, got %q (%v)", buf.String(), err)
+	}
+}
+
+func TestRenderErrors_FormatterFails(t *testing.T) {
+	tmpDir := writeScanLog(t, 5)
+	writeFormatter(t, tmpDir, "exit 3")
+	defer func() { errorsLimit = 10 }()
+	errorsLimit, errorsSource, errorsType, errorsSince = 10, "", "", ""
+	errorsCount, errorsGroup, errorsPick, errorsFields, errorsTemplate, errorsNoFormat, jsonOutput = false, false, false, "", "", false, false
+
+	err := renderErrors(new(bytes.Buffer), tmpDir)
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Code != "FORMATTER_ERROR" {
+		t.Errorf("a failing formatter should be a FORMATTER_ERROR, got %v", err)
+	}
+}
+
+func TestRunTail_Formatter(t *testing.T) {
+	tmpDir := writeScanLog(t, 5)
+	writeFormatter(t, tmpDir, "head -n 2")
+	defer func() { pathOverride, tailNoFormat = "", false }()
+	pathOverride, tailNoFormat, jsonOutput = tmpDir, false, false
+
+	// head exits after two entries, which stops tail
+	var buf bytes.Buffer
+	tailCmd.SetOut(&buf)
+	if err := runTail(tailCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], `"message":"error 1"`) {
+		t.Errorf("tail should stream entries to the formatter, got:\n%s", buf.String())
+	}
+}
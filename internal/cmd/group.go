@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// clusterSimilarity is the minimum Jaccard similarity between the token sets
+// of two normalized messages for them to share a group
+const clusterSimilarity = 0.7
+
+// minClusterTokens is the token count below which messages only group on an
+// exact normalized match; short messages are too easily confused
+const minClusterTokens = 4
+
+// Variable parts of messages, replaced in order by normalizeMessage
+var (
+	uuidPattern   = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	hexPattern    = regexp.MustCompile(`(?i)\b0x[0-9a-f]+\b|\b[0-9a-f]{12,}\b`)
+	pathPattern   = regexp.MustCompile(`(?:[A-Za-z]:)?(?:[/\\][\w.@~-]+){2,}[/\\]?`)
+	numberPattern = regexp.MustCompile(`\d+(?:\.\d+)?`)
+	tokenPattern  = regexp.MustCompile(`<\w+>|[\pL\pN_]+`)
+)
+
+// ErrorGroup is a cluster of entries with similar messages
+type ErrorGroup struct {
+	Fingerprint string   `json:"fingerprint"`
+	ErrorType   string   `json:"error_type"`
+	Pattern     string   `json:"pattern"`
+	Message     string   `json:"message"` // most recent message in the group
+	Count       int      `json:"count"`
+	Sources     []string `json:"sources"`
+	FirstSeen   string   `json:"first_seen"`
+	LastSeen    string   `json:"last_seen"`
+	// Regression is set when the group has entries marked as regressions
+	Regression *Regression `json:"regression,omitempty"`
+	// PastFixes are the fixes recorded before the group's latest entry
+	PastFixes []Fix `json:"past_fixes,omitempty"`
+	// SnoozeExpired is set when the group has entries marked as occurring
+	// since a snooze that's over
+	SnoozeExpired *SnoozeExpiry `json:"snooze_expired,omitempty"`
+}
+
+// normalizeMessage strips the variable parts of a message (UUIDs, hex IDs,
+// paths, numbers) so repeated occurrences of one error compare equal
+func normalizeMessage(msg string) string {
+	msg = uuidPattern.ReplaceAllString(msg, "<uuid>")
+	msg = hexPattern.ReplaceAllString(msg, "<hex>")
+	msg = pathPattern.ReplaceAllString(msg, "<path>")
+	msg = numberPattern.ReplaceAllString(msg, "<n>")
+	return strings.Join(strings.Fields(msg), " ")
+}
+
+// messageTokens returns the lowercased word set of a normalized message
+func messageTokens(normalized string) map[string]bool {
+	tokens := make(map[string]bool)
+	for _, t := range tokenPattern.FindAllString(strings.ToLower(normalized), -1) {
+		tokens[t] = true
+	}
+	return tokens
+}
+
+// jaccard returns |a ∩ b| / |a ∪ b|
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	shared := 0
+	for t := range a {
+		if b[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// fingerprint identifies a group by error type and normalized message
+func fingerprint(errorType, pattern string) string {
+	sum := sha1.Sum([]byte(errorType + "\x00" + pattern))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// errorCluster is the set of entries behind one ErrorGroup
+type errorCluster struct {
+	errorType string
+	pattern   string
+	tokens    map[string]bool
+	entries   []ErrorEntry
+}
+
+func (c *errorCluster) fingerprint() string {
+	return fingerprint(c.errorType, c.pattern)
+}
+
+// clusterEntries buckets entries of the same error type whose normalized
+// messages match exactly or are token-similar. Entries keep their order
+// within a cluster.
+func clusterEntries(entries []ErrorEntry) []*errorCluster {
+	// Exact buckets by type and normalized message
+	buckets := make(map[string]*errorCluster)
+	var order []*errorCluster
+	for _, e := range entries {
+		pattern := normalizeMessage(e.Message)
+		key := e.ErrorType + "\x00" + pattern
+		b, ok := buckets[key]
+		if !ok {
+			b = &errorCluster{errorType: e.ErrorType, pattern: pattern, tokens: messageTokens(pattern)}
+			buckets[key] = b
+			order = append(order, b)
+		}
+		b.entries = append(b.entries, e)
+	}
+
+	// Merge similar buckets, largest first, so each cluster is named after
+	// its most common pattern
+	sort.SliceStable(order, func(i, j int) bool { return totalOccurrences(order[i].entries) > totalOccurrences(order[j].entries) })
+	var clusters []*errorCluster
+	for _, b := range order {
+		var target *errorCluster
+		if len(b.tokens) >= minClusterTokens {
+			for _, c := range clusters {
+				if c.errorType == b.errorType && len(c.tokens) >= minClusterTokens && jaccard(c.tokens, b.tokens) >= clusterSimilarity {
+					target = c
+					break
+				}
+			}
+		}
+		if target == nil {
+			clusters = append(clusters, &errorCluster{errorType: b.errorType, pattern: b.pattern, tokens: b.tokens, entries: b.entries})
+			continue
+		}
+		target.entries = append(target.entries, b.entries...)
+	}
+	return clusters
+}
+
+// groupErrors clusters entries (see clusterEntries) and summarizes each
+// cluster. Groups are ordered by count, then most recent.
+func groupErrors(entries []ErrorEntry) []ErrorGroup {
+	clusters := clusterEntries(entries)
+	groups := make([]ErrorGroup, 0, len(clusters))
+	for _, c := range clusters {
+		groups = append(groups, summarizeCluster(c))
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return timestampBefore(groups[j].LastSeen, groups[i].LastSeen)
+	})
+	return groups
+}
+
+// summarizeCluster counts a cluster's occurrences and records its sources,
+// seen range, and latest message
+func summarizeCluster(c *errorCluster) ErrorGroup {
+	g := ErrorGroup{
+		Fingerprint: c.fingerprint(),
+		ErrorType:   c.errorType,
+		Pattern:     c.pattern,
+	}
+	sources := make(map[string]bool)
+	for _, e := range c.entries {
+		g.Count += e.occurrences()
+		if !sources[e.Source] {
+			sources[e.Source] = true
+			g.Sources = append(g.Sources, e.Source)
+		}
+		if g.FirstSeen == "" || timestampBefore(e.firstSeen(), g.FirstSeen) {
+			g.FirstSeen = e.firstSeen()
+		}
+		if g.LastSeen == "" || !timestampBefore(e.Timestamp, g.LastSeen) {
+			g.LastSeen = e.Timestamp
+			g.Message = e.Message
+			g.PastFixes = e.PastFixes
+		}
+		if e.Regression != nil {
+			g.Regression = e.Regression
+		}
+		if e.SnoozeExpired != nil {
+			g.SnoozeExpired = e.SnoozeExpired
+		}
+	}
+	sort.Strings(g.Sources)
+	return g
+}
+
+// timestampBefore reports whether timestamp a is earlier than b, comparing
+// as strings when either fails to parse
+func timestampBefore(a, b string) bool {
+	ta, errA := parseEntryTime(a)
+	tb, errB := parseEntryTime(b)
+	if errA != nil || errB != nil {
+		return a < b
+	}
+	return ta.Before(tb)
+}
+
+// formatGroupsHuman formats groups for human-readable output
+func formatGroupsHuman(groups []ErrorGroup, totalGroups int) string {
+	if len(groups) == 0 {
+		return "No errors match the filter criteria.\n"
+	}
+
+	var sb strings.Builder
+	for i, g := range groups {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		if g.Regression != nil {
+			sb.WriteString(colors.err("REGRESSION ") + colors.dim("(resolved "+formatTimestamp(g.Regression.ResolvedAt)+") "))
+		} else if g.SnoozeExpired != nil {
+			sb.WriteString(colors.warn("SNOOZE EXPIRED ") + colors.dim("(ended "+formatTimestamp(g.SnoozeExpired.Until)+") "))
+		}
+		sb.WriteString(fmt.Sprintf("[%dx] %s: %s\n", g.Count, colors.err(g.ErrorType), g.Pattern))
+		if g.Message != g.Pattern {
+			sb.WriteString(fmt.Sprintf("  Latest: %s\n", g.Message))
+		}
+		sb.WriteString(fmt.Sprintf("  Sources: %s | Fingerprint: %s\n", strings.Join(g.Sources, ", "), g.Fingerprint))
+		sb.WriteString(fmt.Sprintf("  First: %s | Last: %s\n", colors.dim(formatTimestamp(g.FirstSeen)), colors.dim(formatTimestamp(g.LastSeen))))
+		writeFixLines(&sb, "  ", g.PastFixes)
+	}
+
+	if len(groups) < totalGroups {
+		sb.WriteString("\n" + colors.dim(fmt.Sprintf("Showing %d of %d groups (use --limit to see more)", len(groups), totalGroups)) + "\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeMessage(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{"timeout after 3001ms", "timeout after <n>ms"},
+		{"user 550e8400-e29b-41d4-a716-446655440000 not found", "user <uuid> not found"},
+		{"ENOENT: no such file '/tmp/build/out.js'", "ENOENT: no such file '<path>'"},
+		{"bad pointer 0xc000123abc", "bad pointer <hex>"},
+		{"commit deadbeefcafe1234 missing", "commit <hex> missing"},
+		{"retry 2 of 5  took 1.5s", "retry <n> of <n> took <n>s"},
+	}
+	for _, tt := range tests {
+		if got := normalizeMessage(tt.msg); got != tt.want {
+			t.Errorf("normalizeMessage(%q) = %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestGroupErrors(t *testing.T) {
+	entries := []ErrorEntry{
+		{Timestamp: "2025-12-10T19:00:00Z", Source: "backend", ErrorType: "NETWORK_ERROR", Message: "timeout after 3001ms"},
+		{Timestamp: "2025-12-10T19:05:00Z", Source: "frontend", ErrorType: "NETWORK_ERROR", Message: "timeout after 2999ms"},
+		{Timestamp: "2025-12-10T19:01:00Z", Source: "backend", ErrorType: "DATABASE_ERROR", Message: "failed to fetch user profile from replica"},
+		{Timestamp: "2025-12-10T19:02:00Z", Source: "backend", ErrorType: "DATABASE_ERROR", Message: "failed to fetch user profile from primary"},
+		{Timestamp: "2025-12-10T19:03:00Z", Source: "backend", ErrorType: "DATABASE_ERROR", Message: "failed to fetch user profile from replica"},
+		// Same message, different type: separate group
+		{Timestamp: "2025-12-10T19:04:00Z", Source: "backend", ErrorType: "TIMEOUT", Message: "timeout after 10ms"},
+		// Short messages only group on exact match
+		{Timestamp: "2025-12-10T19:06:00Z", Source: "cli", ErrorType: "COMMAND_ERROR", Message: "unknown flag"},
+		{Timestamp: "2025-12-10T19:07:00Z", Source: "cli", ErrorType: "COMMAND_ERROR", Message: "unknown command"},
+	}
+
+	groups := groupErrors(entries)
+	if len(groups) != 5 {
+		t.Fatalf("expected 5 groups, got %d: %+v", len(groups), groups)
+	}
+
+	db := groups[0]
+	if db.ErrorType != "DATABASE_ERROR" || db.Count != 3 {
+		t.Errorf("first group = %+v, want 3 similar DATABASE_ERROR entries", db)
+	}
+	if db.Pattern != "failed to fetch user profile from replica" {
+		t.Errorf("group should be named after its most common pattern, got %q", db.Pattern)
+	}
+	if db.FirstSeen != "2025-12-10T19:01:00Z" || db.LastSeen != "2025-12-10T19:03:00Z" {
+		t.Errorf("first/last seen = %s/%s", db.FirstSeen, db.LastSeen)
+	}
+
+	net := groups[1]
+	if net.ErrorType != "NETWORK_ERROR" || net.Count != 2 {
+		t.Fatalf("second group = %+v, want 2 NETWORK_ERROR entries", net)
+	}
+	if net.Message != "timeout after 2999ms" {
+		t.Errorf("Message should be the latest occurrence, got %q", net.Message)
+	}
+	if strings.Join(net.Sources, ",") != "backend,frontend" {
+		t.Errorf("Sources = %v", net.Sources)
+	}
+	if net.Fingerprint != fingerprint("NETWORK_ERROR", "timeout after <n>ms") {
+		t.Errorf("unexpected fingerprint %q", net.Fingerprint)
+	}
+}
+
+func TestErrorsCommand_Group(t *testing.T) {
+	tmpDir := t.TempDir()
+	agentlogDir := filepath.Join(tmpDir, ".agentlog")
+	os.MkdirAll(agentlogDir, 0755)
+	os.WriteFile(filepath.Join(agentlogDir, "errors.jsonl"), []byte(
+		`{"timestamp":"2025-12-10T19:19:32.941Z","source":"frontend","error_type":"NETWORK_ERROR","message":"timeout after 3001ms"}
+{"timestamp":"2025-12-10T19:20:00.000Z","source":"frontend","error_type":"NETWORK_ERROR","message":"timeout after 2999ms"}
+{"timestamp":"2025-12-10T19:21:00.000Z","source":"backend","error_type":"DATABASE_ERROR","message":"deadlock detected"}
+`), 0644)
+
+	originalPath := pathOverride
+	defer func() {
+		pathOverride = originalPath
+		errorsGroup = false
+		jsonOutput = false
+	}()
+	pathOverride = tmpDir
+	errorsLimit = 10
+	errorsSource = ""
+	errorsType = ""
+	errorsSince = ""
+	errorsGroup = true
+
+	buf := new(bytes.Buffer)
+	errorsCmd.SetOut(buf)
+	if err := runErrors(errorsCmd, []string{}); err != nil {
+		t.Fatalf("runErrors() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "[2x] NETWORK_ERROR: timeout after <n>ms") {
+		t.Errorf("expected grouped output, got: %s", buf.String())
+	}
+
+	jsonOutput = true
+	buf.Reset()
+	if err := runErrors(errorsCmd, []string{}); err != nil {
+		t.Fatalf("runErrors() error = %v", err)
+	}
+	var groups []ErrorGroup
+	if err := json.Unmarshal(buf.Bytes(), &groups); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(groups) != 2 || groups[0].Count != 2 {
+		t.Errorf("unexpected groups: %+v", groups)
+	}
+}
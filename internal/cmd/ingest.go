@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/config"
+	"github.com/agentlog/agentlog/internal/logfile"
+	"github.com/agentlog/agentlog/internal/self"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ingestParser string
+	ingestSource string
+)
+
+// IngestResult is the output structure for the ingest command
+type IngestResult struct {
+	Parser   string `json:"parser"`
+	Source   string `json:"source"`
+	Ingested int    `json:"ingested"`
+}
+
+// ingestCmd represents the ingest command
+var ingestCmd = &cobra.Command{
+	Use:   "ingest [file]",
+	Short: "Convert tool output into entries in .agentlog/errors.jsonl",
+	Long: `Parse the output of a build tool and append one entry per diagnostic
+to .agentlog/errors.jsonl, so agents see compile errors through the same
+errors/prime interface as runtime failures.
+
+Reads from the given file, or from stdin when no file is given.
+
+Parsers:
+  errorlines  Lines with an error marker (ERROR, FATAL, panic:, Traceback,
+              FooException:), error_type LOG_ERROR
+  journal     journalctl -o json records with priority err or above, error_type LOG_ERROR
+  tsc         TypeScript compiler output (tsc --noEmit), error_type TYPE_ERROR
+  eslint      ESLint JSON output (eslint -f json), error_type LINT_ERROR
+  ruff        Ruff JSON output (ruff check --output-format json), error_type LINT_ERROR
+  golangci    golangci-lint JSON output (--out-format json), error_type LINT_ERROR
+
+Lint entries carry context.severity (error, warning, info), context.rule,
+and context.linter so agents can triage lint debt by rule and file.
+
+Custom parsers for arbitrary log formats can be defined in
+.agentlog/config.json as regexes with named groups. "message" is required;
+"timestamp" and "type" map to entry fields and any other group is stored
+in context:
+
+  {
+    "parsers": {
+      "nginx": {
+        "pattern": "^(?P<timestamp>\\S+ \\S+) \\[error\\] \\d+#\\d+: (?P<message>.*)$",
+        "error_type": "REQUEST_ERROR",
+        "time_format": "2006/01/02 15:04:05"
+      }
+    }
+  }
+
+Examples:
+  tsc --noEmit | agentlog ingest --parser tsc
+  eslint -f json src | agentlog ingest --parser eslint
+  ruff check --output-format json . | agentlog ingest --parser ruff
+  golangci-lint run --out-format json | agentlog ingest --parser golangci
+  agentlog ingest --parser nginx /var/log/nginx/error.log
+  agentlog ingest --parser tsc tsc-output.txt
+  agentlog ingest --parser tsc --source frontend < tsc.log`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runIngest,
+}
+
+func init() {
+	rootCmd.AddCommand(ingestCmd)
+
+	ingestCmd.Flags().StringVar(&ingestParser, "parser", "", "Parser to apply (errorlines, journal, tsc, eslint, ruff, golangci, or a name from config.json)")
+	ingestCmd.Flags().StringVar(&ingestSource, "source", "build", "Source to record on ingested entries")
+}
+
+func runIngest(cmd *cobra.Command, args []string) error {
+	// Determine base directory (use --path override or cwd)
+	baseDir := GetPathOverride()
+	if baseDir == "" {
+		var err error
+		baseDir, err = os.Getwd()
+		if err != nil {
+			self.LogError(".", "GETWD_ERROR", err.Error())
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+
+	cfg, err := config.Load(baseDir)
+	if err != nil {
+		self.LogError(baseDir, "CONFIG_ERROR", err.Error())
+		return err
+	}
+
+	if ingestParser == "" {
+		return invalidInput("--parser is required (available: %s)", strings.Join(parserNames(cfg), ", "))
+	}
+	parser, err := getParser(ingestParser, cfg)
+	if err != nil {
+		self.LogError(baseDir, "INVALID_INPUT", err.Error())
+		return err
+	}
+
+	// Read from file argument or stdin
+	var input io.Reader = cmd.InOrStdin()
+	if len(args) == 1 {
+		f, err := os.Open(args[0])
+		if err != nil {
+			self.LogError(baseDir, "FILE_READ_ERROR", fmt.Sprintf("failed to open %s: %v", args[0], err))
+			return fmt.Errorf("failed to open %s: %w", args[0], err)
+		}
+		defer f.Close()
+		input = f
+	}
+
+	result, err := ingest(baseDir, parser, input, ingestSource)
+	if err != nil {
+		self.LogError(baseDir, "INGEST_ERROR", err.Error())
+		return err
+	}
+	result.Parser = ingestParser
+
+	if IsJSONOutput() {
+		output, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Fprintln(cmd.OutOrStdout(), string(output))
+		return nil
+	}
+
+	entryWord := "entries"
+	if result.Ingested == 1 {
+		entryWord = "entry"
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Ingested %d %s from %s output\n", result.Ingested, entryWord, result.Parser)
+	return nil
+}
+
+// ingest parses input with parser and appends the resulting entries
+func ingest(baseDir string, parser Parser, input io.Reader, source string) (*IngestResult, error) {
+	entries, err := parser(input)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := time.Now().UTC().Format(time.RFC3339Nano)
+	for i := range entries {
+		if entries[i].Timestamp == "" {
+			entries[i].Timestamp = timestamp
+		}
+		if entries[i].Source == "" {
+			entries[i].Source = source
+		}
+	}
+
+	if len(entries) > 0 {
+		if err := appendErrors(baseDir, entries); err != nil {
+			return nil, err
+		}
+	}
+
+	return &IngestResult{Source: source, Ingested: len(entries)}, nil
+}
+
+// streamIngester parses and appends log lines one at a time, for commands
+// that follow a live log stream (docker, etc.)
+type streamIngester struct {
+	baseDir string
+	parser  Parser
+	source  string
+	context map[string]interface{} // merged into every entry's context
+	count   int
+}
+
+// ingestLine parses a single line and appends any resulting entries.
+// timestamp, if non-empty, is used for entries the parser didn't stamp;
+// extra is merged into context after the ingester's own context.
+func (s *streamIngester) ingestLine(line, timestamp string, extra map[string]interface{}) ([]ErrorEntry, error) {
+	entries, err := s.parser(strings.NewReader(line))
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+
+	if timestamp == "" {
+		timestamp = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	for i := range entries {
+		if entries[i].Timestamp == "" {
+			entries[i].Timestamp = timestamp
+		}
+		if entries[i].Source == "" {
+			entries[i].Source = s.source
+		}
+		if len(s.context) > 0 || len(extra) > 0 {
+			if entries[i].Context == nil {
+				entries[i].Context = make(map[string]interface{})
+			}
+			for k, v := range s.context {
+				entries[i].Context[k] = v
+			}
+			for k, v := range extra {
+				entries[i].Context[k] = v
+			}
+		}
+	}
+
+	if err := appendErrors(s.baseDir, entries); err != nil {
+		return nil, err
+	}
+	s.count += len(entries)
+	return entries, nil
+}
+
+// newStreamIngester resolves a line parser by name for a streaming command
+func newStreamIngester(baseDir, parserName, source string) (*streamIngester, error) {
+	cfg, err := config.Load(baseDir)
+	if err != nil {
+		self.LogError(baseDir, "CONFIG_ERROR", err.Error())
+		return nil, err
+	}
+
+	parser, err := getParser(parserName, cfg)
+	if err != nil {
+		self.LogError(baseDir, "INVALID_INPUT", err.Error())
+		return nil, err
+	}
+	if !isLineParser(parserName, cfg) {
+		return nil, fmt.Errorf("parser '%s' reads whole documents and can't be applied to a log stream", parserName)
+	}
+
+	return &streamIngester{baseDir: baseDir, parser: parser, source: source}, nil
+}
+
+// lineSplitter strips a prefix added by a log source (timestamps, pod
+// names) from a line, returning the remaining text, an RFC3339 timestamp
+// (empty if none), and extra context for the line's entries
+type lineSplitter func(line string) (text, timestamp string, extra map[string]interface{})
+
+// followCommand runs c, feeding each stdout/stderr line through split (which
+// may be nil) and the ingester, and echoes ingested entries to w in tail format
+func followCommand(c *exec.Cmd, w io.Writer, split lineSplitter, ingester *streamIngester) error {
+	pr, pw := io.Pipe()
+	c.Stdout = pw
+	c.Stderr = pw
+
+	if err := c.Start(); err != nil {
+		return err
+	}
+
+	waitErr := make(chan error, 1)
+	go func() {
+		err := c.Wait()
+		pw.Close()
+		waitErr <- err
+	}()
+
+	if err := scanStream(pr, w, split, ingester); err != nil {
+		return err
+	}
+	return <-waitErr
+}
+
+// scanStream ingests each line of r until EOF
+func scanStream(r io.Reader, w io.Writer, split lineSplitter, ingester *streamIngester) error {
+	jsonMode := IsJSONOutput()
+	scanner := logfile.NewLines(r, logfile.DefaultMaxLine)
+	for scanner.Scan() {
+		line, timestamp := scanner.Text(), ""
+		var extra map[string]interface{}
+		if split != nil {
+			line, timestamp, extra = split(line)
+		}
+
+		entries, err := ingester.ingestLine(line, timestamp, extra)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			fmt.Fprintln(w, formatTailEntry(e, jsonMode))
+		}
+	}
+	warnSkippedLines(scanner.Skipped, logfile.DefaultMaxLine)
+	return scanner.Err()
+}
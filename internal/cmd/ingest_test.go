@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIngest_AppendsEntries(t *testing.T) {
+	tmpDir := t.TempDir()
+	input := `src/app.ts(12,5): error TS2322: Type 'string' is not assignable to type 'number'.
+src/db.ts(1,1): error TS2307: Cannot find module 'pg'.
+`
+
+	result, err := ingest(tmpDir, parseTSC, strings.NewReader(input), "build")
+	if err != nil {
+		t.Fatalf("ingest() error = %v", err)
+	}
+	if result.Ingested != 2 {
+		t.Errorf("Ingested = %d, want 2", result.Ingested)
+	}
+
+	entries, err := readErrors(tmpDir)
+	if err != nil {
+		t.Fatalf("readErrors() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries in errors.jsonl, got %d", len(entries))
+	}
+	for _, e := range entries {
+		if e.Source != "build" {
+			t.Errorf("Source = %q, want build", e.Source)
+		}
+		if e.Timestamp == "" {
+			t.Error("Timestamp should be set")
+		}
+	}
+}
+
+func TestIngest_PreservesExistingEntries(t *testing.T) {
+	tmpDir := t.TempDir()
+	agentlogDir := filepath.Join(tmpDir, ".agentlog")
+	os.MkdirAll(agentlogDir, 0755)
+	os.WriteFile(filepath.Join(agentlogDir, "errors.jsonl"), []byte(
+		`{"timestamp":"2025-12-10T19:19:32.941Z","source":"frontend","error_type":"UNCAUGHT_ERROR","message":"Existing"}
+`), 0644)
+
+	_, err := ingest(tmpDir, parseTSC, strings.NewReader("error TS5023: Unknown compiler option 'foo'.\n"), "build")
+	if err != nil {
+		t.Fatalf("ingest() error = %v", err)
+	}
+
+	entries, _ := readErrors(tmpDir)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Message != "Existing" {
+		t.Errorf("existing entry should be preserved, got %q", entries[0].Message)
+	}
+}
+
+func TestIngestCommand_Stdin(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalPath := pathOverride
+	defer func() { pathOverride = originalPath }()
+	pathOverride = tmpDir
+
+	ingestParser = "tsc"
+	ingestSource = "frontend"
+	jsonOutput = false
+	defer func() {
+		ingestParser = ""
+		ingestSource = "build"
+	}()
+
+	buf := new(bytes.Buffer)
+	ingestCmd.SetOut(buf)
+	ingestCmd.SetIn(strings.NewReader("src/a.ts(1,1): error TS1005: ';' expected.\n"))
+
+	if err := runIngest(ingestCmd, []string{}); err != nil {
+		t.Fatalf("runIngest() error = %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "Ingested 1 entry") {
+		t.Errorf("output should report ingested count, got: %s", buf.String())
+	}
+
+	entries, _ := readErrors(tmpDir)
+	if len(entries) != 1 || entries[0].Source != "frontend" {
+		t.Errorf("expected 1 frontend entry, got %+v", entries)
+	}
+}
+
+func TestIngestCommand_RequiresParser(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalPath := pathOverride
+	defer func() { pathOverride = originalPath }()
+	pathOverride = tmpDir
+
+	ingestParser = ""
+	err := runIngest(ingestCmd, []string{})
+	if err == nil {
+		t.Fatal("runIngest() should fail without --parser")
+	}
+	if !strings.Contains(err.Error(), "--parser") {
+		t.Errorf("error should mention --parser, got: %v", err)
+	}
+}
@@ -1,40 +1,102 @@
 package cmd
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/agentlog/agentlog/internal/config"
 	"github.com/agentlog/agentlog/internal/detect"
 	"github.com/agentlog/agentlog/internal/self"
 	"github.com/spf13/cobra"
 )
 
 var (
-	initForce   bool
-	initStack   string
-	initInstall bool
+	initForce          bool
+	initStack          []string
+	initInstall        bool
+	initCaptureConsole bool
+	initCaptureNetwork bool
+	initIntegrations   []string
+	initDryRun         bool
+	initInteractive    bool
+	initGitignoreAll   bool
+	initRemote         string
+	initDocker         bool
 )
 
+// initOptions are the choices behind one run of init
+type initOptions struct {
+	Force bool
+	// Stacks override detection; each gets its own snippet
+	Stacks  []string
+	Install bool
+	// CaptureConsole adds console.error/warn reporting to browser snippets
+	CaptureConsole bool
+	// CaptureNetwork adds failed fetch/XHR reporting to browser snippets
+	CaptureNetwork bool
+	// Integrations are framework hooks to add to those detected
+	Integrations []string
+	// DryRun records the changes in InitResult.Plan instead of making them
+	DryRun bool
+	// Transport is where browser snippets send entries: "file" (the dev
+	// server or app writes them to errors.jsonl) or "serve"
+	Transport string
+	// OnlyIntegrations installs just Integrations, without the detected ones
+	OnlyIntegrations bool
+	// Record saves these choices to the config's "init" section
+	Record bool
+	// GitignoreAll ignores all of .agentlog/, and saves that to config
+	GitignoreAll bool
+	// Remote is an 'agentlog serve' URL every snippet posts to instead of
+	// writing errors.jsonl
+	Remote string
+	// Docker generates the Compose and dev container config containers
+	// need to reach .agentlog/
+	Docker bool
+}
+
 // InstallAction represents a file operation performed during installation
 type InstallAction struct {
 	Path      string `json:"path"`
-	Operation string `json:"operation"` // "create", "append", "insert"
+	Operation string `json:"operation"` // "create", "append", "insert", "skip"
+}
+
+// StackSetup is the snippet for one of the stacks init set up
+type StackSetup struct {
+	Stack       string `json:"stack"`
+	SnippetLang string `json:"snippet_language"`
+	Snippet     string `json:"snippet"`
 }
 
-// InitResult contains the result of the init command
+// InitResult contains the result of the init command. Stack, SnippetLang,
+// and MarkerFile describe the first stack; Snippet holds every stack's.
 type InitResult struct {
 	Stack          string          `json:"stack"`
 	Detected       bool            `json:"detected"`
 	MarkerFile     string          `json:"marker_file,omitempty"`
 	DirCreated     bool            `json:"dir_created"`
 	GitIgnored     bool            `json:"gitignore_updated"`
+	GitignoreAll   bool            `json:"gitignore_all,omitempty"` // all of .agentlog/ is ignored, not just the log
+	TokenCreated   bool            `json:"serve_token_created"`
 	SnippetLang    string          `json:"snippet_language"`
+	BrowserCapture []string        `json:"browser_capture,omitempty"`
+	Transport      string          `json:"transport,omitempty"`
+	Remote         string          `json:"remote,omitempty"` // the serve URL snippets post to, with --remote
+	Docker         *DockerSetup    `json:"docker,omitempty"`
+	Integrations   []string        `json:"integrations,omitempty"`
 	Snippet        string          `json:"snippet"`
+	Stacks         []StackSetup    `json:"stacks"`
+	Templates      []string        `json:"templates,omitempty"` // project templates used instead of the built-in ones
 	Installed      bool            `json:"installed"`
 	InstallActions []InstallAction `json:"install_actions,omitempty"`
+	ChoicesSaved   bool            `json:"choices_saved,omitempty"` // --interactive answers written to config.json
+	DryRun         bool            `json:"dry_run,omitempty"`
+	Plan           []PlannedChange `json:"plan,omitempty"` // with --dry-run, what init would change
 }
 
 // initCmd represents the init command
@@ -46,17 +108,104 @@ var initCmd = &cobra.Command{
 This command will:
   1. Detect your project's tech stack (TypeScript, Go, Python, Rust, Ruby)
   2. Create the .agentlog/ directory
-  3. Add .agentlog/errors.jsonl to .gitignore
-  4. Print a code snippet to capture errors in your detected language
+  3. Add .agentlog/errors.jsonl to .gitignore (all of .agentlog/ with
+     --gitignore-all, which is saved to config for later runs)
+  4. Generate an auth token for 'agentlog serve' in .agentlog/config.json
+  5. Print a code snippet to capture errors in your detected language
+
+--stack may be repeated for a project with a backend and a frontend: each
+stack gets its own snippet, and with --install its own files. Browser
+options (--capture-console, --capture-network) apply to the browser stack.
+
+Teams can replace the built-in snippets with their own by putting templates
+in .agentlog/templates/<stack>/: snippet.<ext> for the printed snippet, and
+the file names --install writes (capture.ts, capture.go, capture.py,
+capture.rs, capture.rb; for Rails agentlog_controller.rb, agentlog.rb, and
+application.js). {{AGENTLOG_TOKEN}} is replaced with the serve token.
 
 With --install flag, agentlog will write files directly to your project:
   - Rails: Creates controller, initializer, adds route, appends to application.js
+  - Ruby without Rails: Creates .agentlog/capture.rb to require from your script
+  - React Native: Creates .agentlog/capture.ts, which posts errors to
+    'agentlog serve' on the machine running Metro
   - Other stacks: Creates .agentlog/capture.<ext> file you can import
 
+With --dry-run, nothing is written: init lists the files it would create,
+append to, or edit, with a diff for each existing file it would change
+(.gitignore, config/routes.rb, application.js). With --json the plan is in
+the "plan" field, for an agent to review before running init for real.
+
+With --interactive, init asks instead: it confirms the detected stack, then
+asks whether to install or print the snippet, which browser captures and
+framework or job worker hooks to add, and whether browser errors go through
+the dev server (file) or to 'agentlog serve' (serve). The answers are saved
+in the "init" section of .agentlog/config.json and offered as the defaults
+next time.
+
+With --remote URL, the snippets post their entries to 'agentlog serve' at
+URL instead of appending to .agentlog/errors.jsonl (or, in the browser,
+going through the dev server), for apps that can't reach the project
+directory: browsers without the dev-server middleware, devcontainers, and
+mobile targets. A URL without a path gets /__agentlog. The Node, Go,
+Python, and Ruby snippets read AGENTLOG_URL first, so a container can point
+them elsewhere; the Rust one speaks plain http only. The Rails backend
+(controller and initializer) still writes the file. Run 'agentlog serve
+--addr 0.0.0.0:7654' where URL points, and list browser origins other than
+localhost under "serve.allowed_origins". URL is saved in the "init" section
+of .agentlog/config.json for 'agentlog upgrade'.
+
+With --docker, init reads the project's Compose file (compose.yaml,
+docker-compose.yml) and dev container config (devcontainer.json) and
+generates what they need so processes inside containers write to the
+host's .agentlog/: for each service built from the project, a bind mount
+of .agentlog/ where it runs (skipped when it already mounts the project
+there) and AGENTLOG_PROJECT. With --remote, services get AGENTLOG_URL and
+a host.docker.internal address to reach 'agentlog serve' on the host
+instead. With --install the Compose additions are written to
+docker-compose.override.yml (which docker compose merges automatically),
+unless one exists; otherwise they're printed to merge in.
+
+With --capture-console, the browser snippets (TypeScript and Rails) also
+patch console.error and console.warn to report CONSOLE_ERROR and
+CONSOLE_WARN entries. Each distinct message is reported once a minute, and
+at most 10 entries a minute.
+
+With --capture-network, they also wrap fetch and XMLHttpRequest to report
+requests that fail (network errors and 5xx responses) as NETWORK_ERROR
+entries with the method, URL, and status in context.
+
+Frameworks that catch errors before window.onerror sees them get their own
+hook, added when the framework is detected or named with --integration:
+  - vue: app.config.errorHandler plugin (.agentlog/vue.ts)
+  - sveltekit: handleError hooks (src/hooks.client.ts, src/hooks.server.ts)
+  - angular: ErrorHandler provider (src/app/agentlog-error-handler.ts)
+  - celery, rq: job failure hooks (.agentlog/agentlog_celery.py, agentlog_rq.py)
+  - sidekiq: error and death handlers (config/initializers/agentlog_sidekiq.rb)
+  - bullmq: Worker failed-event hook (.agentlog/bullmq.ts)
+  - apollo, graphql-yoga: resolver error plugins (.agentlog/apollo.ts, yoga.ts)
+  - gqlgen: error presenter (.agentlog/agentlog_gqlgen.go)
+  - grpc-go, grpc-node: server interceptors (.agentlog/agentlog_grpc.go, grpc.ts)
+  - electron: main process hooks and renderer preload (.agentlog/electron-*.ts)
+  - cloudflare-workers: fetch handler wrapper posting to 'agentlog serve'
+    (.agentlog/worker.ts)
+  - tauri: Rust panic hook plus a webview capture sent through a Tauri
+    command (src-tauri/src/agentlog.rs, .agentlog/tauri.ts)
+  - lambda: handler wrappers for sam local and serverless offline
+    (.agentlog/lambda.ts, agentlog_lambda.py)
+
 Examples:
   agentlog init              # Auto-detect stack and print snippet
   agentlog init --install    # Auto-detect and install files
+  agentlog init --capture-console  # Also report console.error/warn
+  agentlog init --capture-network  # Also report failed fetch/XHR requests
+  agentlog init --install --integration vue  # Add the Vue error hook
+  agentlog init --install --integration sidekiq  # Record failed Sidekiq jobs
   agentlog init --stack go   # Force Go stack
+  agentlog init --install --stack ruby --stack typescript  # Backend and frontend
+  agentlog init --interactive  # Answer a few questions instead of passing flags
+  agentlog init --install --dry-run  # Preview the changes, with diffs
+  agentlog init --install --remote http://host.docker.internal:7654  # Post from a container
+  agentlog init --install --docker  # Mount .agentlog/ into Compose services
   agentlog init --json       # Output result as JSON`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cwd, err := os.Getwd()
@@ -65,7 +214,29 @@ Examples:
 			return fmt.Errorf("failed to get current directory: %w", err)
 		}
 
-		result, err := runInit(cwd, initForce, initStack, initInstall)
+		opts := initOptions{
+			Force:          initForce,
+			Stacks:         initStack,
+			Install:        initInstall,
+			CaptureConsole: initCaptureConsole,
+			CaptureNetwork: initCaptureNetwork,
+			Integrations:   initIntegrations,
+			DryRun:         initDryRun,
+			GitignoreAll:   initGitignoreAll,
+			Remote:         initRemote,
+			Docker:         initDocker,
+		}
+		if initInteractive {
+			if IsJSONOutput() {
+				return invalidInput("--interactive can't be combined with --json")
+			}
+			if opts, err = runInitWizard(cmd.InOrStdin(), cmd.OutOrStdout(), cwd, opts); err != nil {
+				return err
+			}
+			fmt.Println()
+		}
+
+		result, err := initWithOptions(cwd, opts)
 		if err != nil {
 			return err
 		}
@@ -77,6 +248,10 @@ Examples:
 		}
 
 		// Human-readable output
+		if result.DryRun {
+			printInitPlan(os.Stdout, result)
+			return nil
+		}
 		printInitResult(result)
 		return nil
 	},
@@ -85,30 +260,135 @@ Examples:
 func init() {
 	rootCmd.AddCommand(initCmd)
 	initCmd.Flags().BoolVar(&initForce, "force", false, "Reinitialize even if .agentlog/ already exists")
-	initCmd.Flags().StringVar(&initStack, "stack", "", "Override stack detection (typescript, go, python, rust, ruby)")
+	initCmd.Flags().StringArrayVar(&initStack, "stack", nil, "Override stack detection (typescript, go, python, rust, ruby, ruby-script, react-native); repeat for a backend plus a frontend")
 	initCmd.Flags().BoolVar(&initInstall, "install", false, "Install snippets directly to project files")
+	initCmd.Flags().BoolVar(&initCaptureConsole, "capture-console", false, "Also report console.error/warn from browser snippets (deduped, rate-limited)")
+	initCmd.Flags().BoolVar(&initCaptureNetwork, "capture-network", false, "Also report failed fetch/XHR requests (network errors, 5xx) from browser snippets")
+	initCmd.Flags().BoolVar(&initInteractive, "interactive", false, "Ask for the stack, install vs print, captures, hooks, and transport, and save the answers to config")
+	initCmd.Flags().BoolVar(&initGitignoreAll, "gitignore-all", false, "Ignore all of .agentlog/ (capture files, config) in .gitignore, not just the log; saved to config")
+	initCmd.Flags().StringVar(&initRemote, "remote", "", "Post entries to an 'agentlog serve' URL (e.g. "+defaultServeURL+") instead of writing .agentlog/errors.jsonl; saved to config")
+	initCmd.Flags().BoolVar(&initDocker, "docker", false, "Generate the docker-compose.yml / devcontainer.json additions that let containers write to .agentlog/ (or reach serve, with --remote)")
+	initCmd.Flags().BoolVar(&initDryRun, "dry-run", false, "Show the files init would create or change, with diffs, without touching them")
+	initCmd.Flags().StringArrayVar(&initIntegrations, "integration", nil, "Add a framework error hook ("+strings.Join(integrationNames(), ", ")+"); detected frameworks are added automatically (repeatable)")
 }
 
 // runInit performs the init operation and returns the result
 func runInit(dir string, force bool, stackOverride string, install bool) (*InitResult, error) {
-	result := &InitResult{}
-
-	// Detect or override stack
+	opts := initOptions{Force: force, Install: install}
 	if stackOverride != "" {
-		result.Stack = strings.ToLower(stackOverride)
-		result.Detected = false
-	} else {
+		opts.Stacks = []string{stackOverride}
+	}
+	return initWithOptions(dir, opts)
+}
+
+// initWithOptions performs the init operation and returns the result
+func initWithOptions(dir string, opts initOptions) (*InitResult, error) {
+	if opts.DryRun {
+		plan := newPlanFiles(dir)
+		result, err := initWithFiles(dir, opts, plan)
+		if err != nil {
+			return nil, err
+		}
+		result.Plan = plan.changes()
+		return result, nil
+	}
+	return initWithFiles(dir, opts, diskFiles{})
+}
+
+// initWithFiles performs the init operation through files
+func initWithFiles(dir string, opts initOptions, files initFiles) (*InitResult, error) {
+	result := &InitResult{DryRun: opts.DryRun}
+
+	// Detect or override stacks
+	if len(opts.Stacks) == 0 {
 		detection := detect.DetectStack(dir)
-		result.Stack = detection.Stack.String()
+		setup := StackSetup{Stack: detection.Stack.String(), SnippetLang: detection.Stack.String()}
+		// Ruby projects without Rails get the script capture instead of
+		// the Rails middleware
+		if filepath.Base(detection.MarkerFile) == "Gemfile" && !detect.IsRails(filepath.Join(dir, filepath.Dir(detection.MarkerFile))) {
+			setup.SnippetLang = rubyScript
+		}
+		result.Stacks = []StackSetup{setup}
 		result.Detected = detection.Detected
 		result.MarkerFile = detection.MarkerFile
+	} else {
+		stacks, err := overrideStacks(opts.Stacks)
+		if err != nil {
+			self.LogError(dir, "INVALID_INPUT", err.Error())
+			return nil, err
+		}
+		result.Stacks = stacks
+	}
+	result.Stack = result.Stacks[0].Stack
+	result.SnippetLang = result.Stacks[0].SnippetLang
+
+	// Browser options apply to the browser snippet, wherever it is in the list
+	browserLang := result.SnippetLang
+	for _, s := range result.Stacks {
+		if isBrowserSnippet(s.SnippetLang) {
+			browserLang = s.SnippetLang
+			break
+		}
+	}
+
+	captures, err := browserCaptures(browserLang, opts)
+	if err != nil {
+		self.LogError(dir, "INVALID_INPUT", err.Error())
+		return nil, err
+	}
+	for _, c := range captures {
+		result.BrowserCapture = append(result.BrowserCapture, c.Name)
+	}
+	switch opts.Transport {
+	case "", "file":
+	case "serve":
+		if !isBrowserSnippet(browserLang) {
+			err := fmt.Errorf("the serve transport needs a browser snippet (--stack typescript or ruby), not %s", browserLang)
+			self.LogError(dir, "INVALID_INPUT", err.Error())
+			return nil, err
+		}
+		result.Transport = "serve"
+	default:
+		err := fmt.Errorf("unknown transport '%s' (available: file, serve)", opts.Transport)
+		self.LogError(dir, "INVALID_INPUT", err.Error())
+		return nil, err
+	}
+	snippets := snippetOptions{captures: captures}
+	if result.Transport == "serve" {
+		snippets.serveURL = defaultServeURL
+	}
+	if opts.Remote != "" {
+		if snippets.remote, err = remoteEndpoint(opts.Remote, result.Stacks); err != nil {
+			self.LogError(dir, "INVALID_INPUT", err.Error())
+			return nil, err
+		}
+		snippets.serveURL = snippets.remote
+		result.Remote = snippets.remote
+		if isBrowserSnippet(browserLang) {
+			result.Transport = "serve"
+		}
+	}
+
+	if opts.Docker {
+		if result.Docker, err = planDocker(files, dir, result.Remote); err != nil {
+			self.LogError(dir, "INVALID_INPUT", err.Error())
+			return nil, err
+		}
+	}
+
+	hooks, err := resolveIntegrations(dir, opts.Integrations, !opts.OnlyIntegrations)
+	if err != nil {
+		self.LogError(dir, "INVALID_INPUT", err.Error())
+		return nil, err
+	}
+	for _, in := range hooks {
+		result.Integrations = append(result.Integrations, in.Name)
 	}
-	result.SnippetLang = result.Stack
 
 	// Create .agentlog directory
 	agentlogDir := filepath.Join(dir, ".agentlog")
-	if _, err := os.Stat(agentlogDir); os.IsNotExist(err) {
-		if err := os.MkdirAll(agentlogDir, 0755); err != nil {
+	if files.NotExist(agentlogDir) {
+		if err := files.MkdirAll(agentlogDir); err != nil {
 			self.LogError(dir, "MKDIR_ERROR", fmt.Sprintf("failed to create .agentlog directory: %v", err))
 			return nil, fmt.Errorf("failed to create .agentlog directory: %w", err)
 		}
@@ -117,91 +397,365 @@ func runInit(dir string, force bool, stackOverride string, install bool) (*InitR
 
 	// Create errors.jsonl file (touch)
 	errorsFile := filepath.Join(agentlogDir, "errors.jsonl")
-	if _, err := os.Stat(errorsFile); os.IsNotExist(err) {
-		if err := os.WriteFile(errorsFile, []byte{}, 0644); err != nil {
+	if files.NotExist(errorsFile) {
+		if err := files.WriteFile(errorsFile, []byte{}); err != nil {
 			self.LogError(dir, "FILE_CREATE_ERROR", fmt.Sprintf("failed to create errors.jsonl: %v", err))
 			return nil, fmt.Errorf("failed to create errors.jsonl: %w", err)
 		}
 	}
 
 	// Update .gitignore
-	gitignorePath := filepath.Join(dir, ".gitignore")
-	gitignoreEntry := ".agentlog/errors.jsonl"
+	cfg, err := config.Load(dir)
+	if err != nil {
+		self.LogError(dir, "CONFIG_ERROR", err.Error())
+		return nil, err
+	}
+	result.GitignoreAll = opts.GitignoreAll || cfg.GitignoreAll
+	if result.GitIgnored, err = ensureGitignored(files, dir, result.GitignoreAll); err != nil {
+		return nil, err
+	}
+	if opts.GitignoreAll && !cfg.GitignoreAll {
+		if err := updateConfig(files, dir, func(c *config.Config) { c.GitignoreAll = true }); err != nil {
+			self.LogError(dir, "CONFIG_ERROR", err.Error())
+			return nil, err
+		}
+	}
 
-	gitignoreContent, err := os.ReadFile(gitignorePath)
-	if err != nil && !os.IsNotExist(err) {
-		self.LogError(dir, "FILE_READ_ERROR", fmt.Sprintf("failed to read .gitignore: %v", err))
-		return nil, fmt.Errorf("failed to read .gitignore: %w", err)
+	// Generate serve auth token
+	token, created, err := ensureServeToken(files, dir)
+	if err != nil {
+		self.LogError(dir, "CONFIG_ERROR", err.Error())
+		return nil, err
 	}
+	result.TokenCreated = created
+	snippets.token = token
 
-	if !strings.Contains(string(gitignoreContent), gitignoreEntry) {
-		var newContent string
-		if len(gitignoreContent) == 0 {
-			newContent = gitignoreEntry + "\n"
-		} else {
-			content := string(gitignoreContent)
-			if !strings.HasSuffix(content, "\n") {
-				content += "\n"
-			}
-			newContent = content + gitignoreEntry + "\n"
+	if opts.Record || opts.Install {
+		if err := recordInitChoices(files, dir, result, opts); err != nil {
+			self.LogError(dir, "CONFIG_ERROR", err.Error())
+			return nil, err
 		}
+		result.ChoicesSaved = opts.Record
+	}
 
-		if err := os.WriteFile(gitignorePath, []byte(newContent), 0644); err != nil {
-			self.LogError(dir, "FILE_WRITE_ERROR", fmt.Sprintf("failed to update .gitignore: %v", err))
-			return nil, fmt.Errorf("failed to update .gitignore: %w", err)
+	// Get snippets
+	var printed []string
+	for i := range result.Stacks {
+		s := &result.Stacks[i]
+		s.Snippet = snippets.apply(s.SnippetLang, printedSnippet(files, dir, s.SnippetLang))
+		if len(result.Stacks) == 1 {
+			printed = append(printed, s.Snippet)
+		} else {
+			printed = append(printed, fmt.Sprintf("%s === %s ===\n%s", snippetComment(s.SnippetLang), strings.ToUpper(s.SnippetLang), strings.TrimRight(s.Snippet, "\n")))
 		}
-		result.GitIgnored = true
 	}
-
-	// Get snippet
-	result.Snippet = getSnippet(result.Stack)
+	result.Snippet = strings.Join(printed, "\n\n") + injectToken(integrationSnippet(hooks), token)
+	result.Templates = usedTemplates(files, dir, result.Stacks)
 
 	// Install snippets if requested
-	if install {
-		actions, err := installSnippets(dir, result.Stack)
+	if opts.Install {
+		for _, s := range result.Stacks {
+			actions, err := installSnippets(files, dir, s.SnippetLang, snippets)
+			if err != nil {
+				return nil, err
+			}
+			result.InstallActions = append(result.InstallActions, actions...)
+		}
+		hookActions, err := installIntegrations(files, dir, hooks, token)
 		if err != nil {
 			return nil, err
 		}
-		result.Installed = true
-		result.InstallActions = actions
+		if result.Docker != nil {
+			dockerActions, err := installDocker(files, dir, result.Docker)
+			if err != nil {
+				return nil, err
+			}
+			hookActions = append(hookActions, dockerActions...)
+		}
+		result.Installed = !opts.DryRun
+		result.InstallActions = append(result.InstallActions, hookActions...)
 	}
 
 	return result, nil
 }
 
+// captureModules are the stacks whose capture is .agentlog/capture.ts, so
+// only one of them can be set up at a time
+var captureModules = []string{"typescript", "node", "react-native"}
+
+// overrideStacks returns the setups for --stack values, each once. Asking
+// for ruby-script means Ruby with the script capture.
+func overrideStacks(names []string) ([]StackSetup, error) {
+	var stacks []StackSetup
+	seen := make(map[string]bool)
+	module := ""
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if containsString(captureModules, name) {
+			if module != "" {
+				return nil, invalidInput("--stack %s and %s both use .agentlog/capture.ts; pick one", module, name)
+			}
+			module = name
+		}
+		setup := StackSetup{Stack: name, SnippetLang: name}
+		if name == rubyScript {
+			setup.Stack = "ruby"
+		}
+		stacks = append(stacks, setup)
+	}
+	return stacks, nil
+}
+
+// snippetComment is the line comment marker for a stack's snippet
+func snippetComment(lang string) string {
+	switch lang {
+	case "python", "ruby", rubyScript:
+		return "#"
+	}
+	return "//"
+}
+
+// stackTitle names the stacks init set up, e.g. "Ruby + Typescript"
+func stackTitle(result *InitResult) string {
+	if len(result.Stacks) == 0 {
+		return capitalize(result.Stack)
+	}
+	var names []string
+	for _, s := range result.Stacks {
+		names = append(names, capitalize(s.Stack))
+	}
+	return strings.Join(names, " + ")
+}
+
+// tokenPlaceholder marks where browser snippets carry the serve auth token
+const tokenPlaceholder = "{{AGENTLOG_TOKEN}}"
+
+// gitignoreEntry keeps the error log out of commits
+const gitignoreEntry = ".agentlog/errors.jsonl"
+
+// gitignoreAllEntry keeps all of .agentlog/ out of commits. It ignores the
+// directory's contents rather than the directory, so files can still be
+// un-ignored one by one.
+const gitignoreAllEntry = ".agentlog/*"
+
+// gitignoreAllBlock is what --gitignore-all adds to .gitignore
+const gitignoreAllBlock = `# agentlog: everything in .agentlog/ stays local. If your app imports a
+# capture file, or your team shares templates, un-ignore them, e.g.:
+#   !.agentlog/capture.ts
+#   !.agentlog/templates/
+` + gitignoreAllEntry
+
+// gitignoreCovers reports whether a .gitignore already ignores the log,
+// or with all, the whole directory
+func gitignoreCovers(content string, all bool) bool {
+	for _, line := range strings.Split(content, "\n") {
+		switch strings.TrimSpace(line) {
+		case gitignoreAllEntry, ".agentlog/", ".agentlog", "/.agentlog/*", "/.agentlog/", "/.agentlog":
+			return true
+		}
+	}
+	return !all && strings.Contains(content, gitignoreEntry)
+}
+
+// ensureGitignored adds gitignoreEntry (with all, gitignoreAllBlock) to
+// dir's .gitignore unless it is already covered, and reports whether it
+// was added
+func ensureGitignored(files initFiles, dir string, all bool) (bool, error) {
+	gitignorePath := filepath.Join(dir, ".gitignore")
+	gitignoreContent, err := files.ReadFile(gitignorePath)
+	if err != nil && !os.IsNotExist(err) {
+		self.LogError(dir, "FILE_READ_ERROR", fmt.Sprintf("failed to read .gitignore: %v", err))
+		return false, fmt.Errorf("failed to read .gitignore: %w", err)
+	}
+	if gitignoreCovers(string(gitignoreContent), all) {
+		return false, nil
+	}
+
+	content := string(gitignoreContent)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	entry := gitignoreEntry
+	if all {
+		entry = gitignoreAllBlock
+	}
+	if err := files.WriteFile(gitignorePath, []byte(content+entry+"\n")); err != nil {
+		self.LogError(dir, "FILE_WRITE_ERROR", fmt.Sprintf("failed to update .gitignore: %v", err))
+		return false, fmt.Errorf("failed to update .gitignore: %w", err)
+	}
+	return true, nil
+}
+
+// ensureServeToken returns the serve auth token from .agentlog/config.json,
+// generating and saving one if none exists
+func ensureServeToken(files initFiles, dir string) (string, bool, error) {
+	cfg, err := config.Load(dir)
+	if err != nil {
+		return "", false, err
+	}
+	if cfg.Serve.Token != "" {
+		return cfg.Serve.Token, false, nil
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", false, fmt.Errorf("failed to generate token: %w", err)
+	}
+	cfg.Serve.Token = hex.EncodeToString(buf)
+	data, err := config.Encode(cfg)
+	if err != nil {
+		return "", false, err
+	}
+	if err := files.MkdirAll(filepath.Dir(config.Path(dir))); err != nil {
+		return "", false, fmt.Errorf("failed to create .agentlog directory: %w", err)
+	}
+	if err := files.WriteFile(config.Path(dir), data); err != nil {
+		return "", false, fmt.Errorf("failed to write %s: %w", config.FileName, err)
+	}
+	return cfg.Serve.Token, true, nil
+}
+
+// injectToken fills the auth token into a snippet
+func injectToken(snippet, token string) string {
+	return strings.ReplaceAll(snippet, tokenPlaceholder, token)
+}
+
+// browserCapture is opt-in code added to the browser snippets. TS is
+// inserted where the TypeScript snippets' _sendLog is in scope, JS inside
+// the plain JavaScript snippets' closure where log is.
+type browserCapture struct {
+	Name string
+	TS   string
+	JS   string
+}
+
+// browserCaptures returns the opt-in captures opts asks for, which only
+// browser snippets (typescript and ruby) support
+func browserCaptures(stack string, opts initOptions) ([]browserCapture, error) {
+	var captures []browserCapture
+	if opts.CaptureConsole {
+		captures = append(captures, browserCapture{Name: "console", TS: consoleCaptureTS, JS: consoleCaptureJS})
+	}
+	if opts.CaptureNetwork {
+		captures = append(captures, browserCapture{Name: "network", TS: networkCaptureTS, JS: networkCaptureJS})
+	}
+	if len(captures) > 0 && !isBrowserSnippet(stack) {
+		return nil, invalidInput("--capture-%s needs a browser snippet (--stack typescript or ruby), not %s", captures[0].Name, stack)
+	}
+	return captures, nil
+}
+
+// isBrowserSnippet reports whether stack's snippet runs in the browser
+func isBrowserSnippet(stack string) bool {
+	return stack == "typescript" || stack == "ruby"
+}
+
+// snippetOptions customizes the snippets init prints and installs: the
+// opt-in browser captures, where they post, and the serve auth token
+type snippetOptions struct {
+	captures []browserCapture
+	serveURL string // browser snippets post here instead of the dev server
+	remote   string // the other snippets post here instead of writing errors.jsonl
+	token    string
+}
+
+// apply customizes one of stack's snippets
+func (o snippetOptions) apply(stack, snippet string) string {
+	if isBrowserSnippet(stack) {
+		snippet = addBrowserCaptures(snippet, o.captures)
+		if o.serveURL != "" {
+			snippet = strings.ReplaceAll(snippet, "fetch('/__agentlog'", "fetch('"+o.serveURL+"'")
+		}
+	} else if o.remote != "" {
+		snippet = remoteSnippet(stack, snippet, o.remote)
+	}
+	return injectToken(snippet, o.token)
+}
+
+// addBrowserCaptures inserts captures into a browser snippet
+func addBrowserCaptures(snippet string, captures []browserCapture) string {
+	if len(captures) == 0 {
+		return snippet
+	}
+	var ts, js []string
+	for _, c := range captures {
+		ts = append(ts, c.TS)
+		js = append(js, c.JS)
+	}
+
+	switch snippet {
+	case snippetTypeScript:
+		block := "if (_agentlogDev) {\n" + indentLines(strings.Join(ts, "\n"), "  ") + "}\n\n"
+		return insertBefore(snippet, "// === DEV SERVER", block)
+	case typescriptCapture:
+		return insertBefore(snippet, "}\n", "\n"+indentLines(strings.Join(ts, "\n"), "  "))
+	case snippetRuby, rubyFrontendJS:
+		return insertBefore(snippet, "})();", "\n"+indentLines(strings.Join(js, "\n"), "  "))
+	}
+	return snippet
+}
+
+// insertBefore inserts block before the last occurrence of marker in s
+func insertBefore(s, marker, block string) string {
+	i := strings.LastIndex(s, marker)
+	if i < 0 {
+		return s + block
+	}
+	return s[:i] + block + s[i:]
+}
+
+// indentLines prefixes every non-empty line of s with prefix
+func indentLines(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 // installSnippets writes snippet files to the project
-func installSnippets(dir string, stack string) ([]InstallAction, error) {
+func installSnippets(files initFiles, dir string, stack string, snippets snippetOptions) ([]InstallAction, error) {
 	switch stack {
 	case "ruby":
-		return installRubySnippets(dir)
+		return installRubySnippets(files, dir, snippets)
+	case rubyScript:
+		return installRubyScriptSnippets(files, dir, snippets)
+	case "react-native":
+		return installReactNativeSnippets(files, dir, snippets)
 	case "typescript":
-		return installTypeScriptSnippets(dir)
+		return installTypeScriptSnippets(files, dir, snippets)
 	case "node":
-		return installNodeSnippets(dir)
+		return installNodeSnippets(files, dir, snippets)
 	case "go":
-		return installGoSnippets(dir)
+		return installGoSnippets(files, dir, snippets)
 	case "python":
-		return installPythonSnippets(dir)
+		return installPythonSnippets(files, dir, snippets)
 	case "rust":
-		return installRustSnippets(dir)
+		return installRustSnippets(files, dir, snippets)
 	default:
-		return installTypeScriptSnippets(dir)
+		return installTypeScriptSnippets(files, dir, snippets)
 	}
 }
 
 // installRubySnippets installs Rails-specific files
-func installRubySnippets(dir string) ([]InstallAction, error) {
+func installRubySnippets(files initFiles, dir string, snippets snippetOptions) ([]InstallAction, error) {
 	var actions []InstallAction
 
 	// 1. Create controller
 	controllerDir := filepath.Join(dir, "app", "controllers")
-	if err := os.MkdirAll(controllerDir, 0755); err != nil {
+	if err := files.MkdirAll(controllerDir); err != nil {
 		return nil, fmt.Errorf("failed to create controllers directory: %w", err)
 	}
 
 	controllerPath := filepath.Join(controllerDir, "agentlog_controller.rb")
-	if _, err := os.Stat(controllerPath); os.IsNotExist(err) {
-		if err := os.WriteFile(controllerPath, []byte(rubyController), 0644); err != nil {
+	if files.NotExist(controllerPath) {
+		if err := writeStamped(files, controllerPath, snippetTemplate(files, dir, "ruby", "agentlog_controller.rb", rubyController)); err != nil {
 			return nil, fmt.Errorf("failed to create controller: %w", err)
 		}
 		actions = append(actions, InstallAction{Path: "app/controllers/agentlog_controller.rb", Operation: "create"})
@@ -209,13 +763,13 @@ func installRubySnippets(dir string) ([]InstallAction, error) {
 
 	// 2. Create initializer
 	initializerDir := filepath.Join(dir, "config", "initializers")
-	if err := os.MkdirAll(initializerDir, 0755); err != nil {
+	if err := files.MkdirAll(initializerDir); err != nil {
 		return nil, fmt.Errorf("failed to create initializers directory: %w", err)
 	}
 
 	initializerPath := filepath.Join(initializerDir, "agentlog.rb")
-	if _, err := os.Stat(initializerPath); os.IsNotExist(err) {
-		if err := os.WriteFile(initializerPath, []byte(rubyInitializer), 0644); err != nil {
+	if files.NotExist(initializerPath) {
+		if err := writeStamped(files, initializerPath, snippetTemplate(files, dir, "ruby", "agentlog.rb", rubyInitializer)); err != nil {
 			return nil, fmt.Errorf("failed to create initializer: %w", err)
 		}
 		actions = append(actions, InstallAction{Path: "config/initializers/agentlog.rb", Operation: "create"})
@@ -223,11 +777,11 @@ func installRubySnippets(dir string) ([]InstallAction, error) {
 
 	// 3. Add route to config/routes.rb
 	routesPath := filepath.Join(dir, "config", "routes.rb")
-	routesContent, err := os.ReadFile(routesPath)
+	routesContent, err := files.ReadFile(routesPath)
 	if err == nil && !strings.Contains(string(routesContent), "__agentlog") {
 		// Insert route before the final "end"
 		newContent := insertRouteIntoRailsRoutes(string(routesContent))
-		if err := os.WriteFile(routesPath, []byte(newContent), 0644); err != nil {
+		if err := files.WriteFile(routesPath, []byte(newContent)); err != nil {
 			return nil, fmt.Errorf("failed to update routes.rb: %w", err)
 		}
 		actions = append(actions, InstallAction{Path: "config/routes.rb", Operation: "insert"})
@@ -235,10 +789,10 @@ func installRubySnippets(dir string) ([]InstallAction, error) {
 
 	// 4. Append frontend JS to app/javascript/application.js
 	jsPath := filepath.Join(dir, "app", "javascript", "application.js")
-	jsContent, err := os.ReadFile(jsPath)
+	jsContent, err := files.ReadFile(jsPath)
 	if err == nil && !strings.Contains(string(jsContent), "window.onerror") {
-		newContent := string(jsContent) + "\n" + rubyFrontendJS
-		if err := os.WriteFile(jsPath, []byte(newContent), 0644); err != nil {
+		newContent := string(jsContent) + "\n" + snippets.apply("ruby", snippetTemplate(files, dir, "ruby", "application.js", rubyFrontendJS))
+		if err := files.WriteFile(jsPath, []byte(newContent)); err != nil {
 			return nil, fmt.Errorf("failed to update application.js: %w", err)
 		}
 		actions = append(actions, InstallAction{Path: "app/javascript/application.js", Operation: "append"})
@@ -277,17 +831,17 @@ func insertRouteIntoRailsRoutes(content string) string {
 }
 
 // installTypeScriptSnippets creates a capture.ts file
-func installTypeScriptSnippets(dir string) ([]InstallAction, error) {
+func installTypeScriptSnippets(files initFiles, dir string, snippets snippetOptions) ([]InstallAction, error) {
 	var actions []InstallAction
 
 	agentlogDir := filepath.Join(dir, ".agentlog")
-	if err := os.MkdirAll(agentlogDir, 0755); err != nil {
+	if err := files.MkdirAll(agentlogDir); err != nil {
 		return nil, fmt.Errorf("failed to create .agentlog directory: %w", err)
 	}
 
 	capturePath := filepath.Join(agentlogDir, "capture.ts")
-	if _, err := os.Stat(capturePath); os.IsNotExist(err) {
-		if err := os.WriteFile(capturePath, []byte(typescriptCapture), 0644); err != nil {
+	if files.NotExist(capturePath) {
+		if err := writeStamped(files, capturePath, snippets.apply("typescript", snippetTemplate(files, dir, "typescript", "capture.ts", typescriptCapture))); err != nil {
 			return nil, fmt.Errorf("failed to create capture.ts: %w", err)
 		}
 		actions = append(actions, InstallAction{Path: ".agentlog/capture.ts", Operation: "create"})
@@ -297,17 +851,17 @@ func installTypeScriptSnippets(dir string) ([]InstallAction, error) {
 }
 
 // installNodeSnippets creates a capture.ts file for Node.js
-func installNodeSnippets(dir string) ([]InstallAction, error) {
+func installNodeSnippets(files initFiles, dir string, snippets snippetOptions) ([]InstallAction, error) {
 	var actions []InstallAction
 
 	agentlogDir := filepath.Join(dir, ".agentlog")
-	if err := os.MkdirAll(agentlogDir, 0755); err != nil {
+	if err := files.MkdirAll(agentlogDir); err != nil {
 		return nil, fmt.Errorf("failed to create .agentlog directory: %w", err)
 	}
 
 	capturePath := filepath.Join(agentlogDir, "capture.ts")
-	if _, err := os.Stat(capturePath); os.IsNotExist(err) {
-		if err := os.WriteFile(capturePath, []byte(nodeCapture), 0644); err != nil {
+	if files.NotExist(capturePath) {
+		if err := writeStamped(files, capturePath, snippets.apply("node", snippetTemplate(files, dir, "node", "capture.ts", nodeCapture))); err != nil {
 			return nil, fmt.Errorf("failed to create capture.ts: %w", err)
 		}
 		actions = append(actions, InstallAction{Path: ".agentlog/capture.ts", Operation: "create"})
@@ -317,17 +871,17 @@ func installNodeSnippets(dir string) ([]InstallAction, error) {
 }
 
 // installGoSnippets creates a capture.go file
-func installGoSnippets(dir string) ([]InstallAction, error) {
+func installGoSnippets(files initFiles, dir string, snippets snippetOptions) ([]InstallAction, error) {
 	var actions []InstallAction
 
 	agentlogDir := filepath.Join(dir, ".agentlog")
-	if err := os.MkdirAll(agentlogDir, 0755); err != nil {
+	if err := files.MkdirAll(agentlogDir); err != nil {
 		return nil, fmt.Errorf("failed to create .agentlog directory: %w", err)
 	}
 
 	capturePath := filepath.Join(agentlogDir, "capture.go")
-	if _, err := os.Stat(capturePath); os.IsNotExist(err) {
-		if err := os.WriteFile(capturePath, []byte(snippetGo), 0644); err != nil {
+	if files.NotExist(capturePath) {
+		if err := writeStamped(files, capturePath, snippets.apply("go", snippetTemplate(files, dir, "go", "capture.go", snippetGo))); err != nil {
 			return nil, fmt.Errorf("failed to create capture.go: %w", err)
 		}
 		actions = append(actions, InstallAction{Path: ".agentlog/capture.go", Operation: "create"})
@@ -337,17 +891,17 @@ func installGoSnippets(dir string) ([]InstallAction, error) {
 }
 
 // installPythonSnippets creates a capture.py file
-func installPythonSnippets(dir string) ([]InstallAction, error) {
+func installPythonSnippets(files initFiles, dir string, snippets snippetOptions) ([]InstallAction, error) {
 	var actions []InstallAction
 
 	agentlogDir := filepath.Join(dir, ".agentlog")
-	if err := os.MkdirAll(agentlogDir, 0755); err != nil {
+	if err := files.MkdirAll(agentlogDir); err != nil {
 		return nil, fmt.Errorf("failed to create .agentlog directory: %w", err)
 	}
 
 	capturePath := filepath.Join(agentlogDir, "capture.py")
-	if _, err := os.Stat(capturePath); os.IsNotExist(err) {
-		if err := os.WriteFile(capturePath, []byte(snippetPython), 0644); err != nil {
+	if files.NotExist(capturePath) {
+		if err := writeStamped(files, capturePath, snippets.apply("python", snippetTemplate(files, dir, "python", "capture.py", snippetPython))); err != nil {
 			return nil, fmt.Errorf("failed to create capture.py: %w", err)
 		}
 		actions = append(actions, InstallAction{Path: ".agentlog/capture.py", Operation: "create"})
@@ -356,18 +910,58 @@ func installPythonSnippets(dir string) ([]InstallAction, error) {
 	return actions, nil
 }
 
+// installRubyScriptSnippets creates a capture.rb file for plain Ruby
+func installRubyScriptSnippets(files initFiles, dir string, snippets snippetOptions) ([]InstallAction, error) {
+	var actions []InstallAction
+
+	agentlogDir := filepath.Join(dir, ".agentlog")
+	if err := files.MkdirAll(agentlogDir); err != nil {
+		return nil, fmt.Errorf("failed to create .agentlog directory: %w", err)
+	}
+
+	capturePath := filepath.Join(agentlogDir, "capture.rb")
+	if files.NotExist(capturePath) {
+		if err := writeStamped(files, capturePath, snippets.apply(rubyScript, snippetTemplate(files, dir, rubyScript, "capture.rb", snippetRubyScript))); err != nil {
+			return nil, fmt.Errorf("failed to create capture.rb: %w", err)
+		}
+		actions = append(actions, InstallAction{Path: ".agentlog/capture.rb", Operation: "create"})
+	}
+
+	return actions, nil
+}
+
+// installReactNativeSnippets creates a capture.ts file for React Native apps
+func installReactNativeSnippets(files initFiles, dir string, snippets snippetOptions) ([]InstallAction, error) {
+	var actions []InstallAction
+
+	agentlogDir := filepath.Join(dir, ".agentlog")
+	if err := files.MkdirAll(agentlogDir); err != nil {
+		return nil, fmt.Errorf("failed to create .agentlog directory: %w", err)
+	}
+
+	capturePath := filepath.Join(agentlogDir, "capture.ts")
+	if files.NotExist(capturePath) {
+		if err := writeStamped(files, capturePath, snippets.apply("react-native", snippetTemplate(files, dir, "react-native", "capture.ts", snippetReactNative))); err != nil {
+			return nil, fmt.Errorf("failed to create capture.ts: %w", err)
+		}
+		actions = append(actions, InstallAction{Path: ".agentlog/capture.ts", Operation: "create"})
+	}
+
+	return actions, nil
+}
+
 // installRustSnippets creates a capture.rs file
-func installRustSnippets(dir string) ([]InstallAction, error) {
+func installRustSnippets(files initFiles, dir string, snippets snippetOptions) ([]InstallAction, error) {
 	var actions []InstallAction
 
 	agentlogDir := filepath.Join(dir, ".agentlog")
-	if err := os.MkdirAll(agentlogDir, 0755); err != nil {
+	if err := files.MkdirAll(agentlogDir); err != nil {
 		return nil, fmt.Errorf("failed to create .agentlog directory: %w", err)
 	}
 
 	capturePath := filepath.Join(agentlogDir, "capture.rs")
-	if _, err := os.Stat(capturePath); os.IsNotExist(err) {
-		if err := os.WriteFile(capturePath, []byte(snippetRust), 0644); err != nil {
+	if files.NotExist(capturePath) {
+		if err := writeStamped(files, capturePath, snippets.apply("rust", snippetTemplate(files, dir, "rust", "capture.rs", snippetRust))); err != nil {
 			return nil, fmt.Errorf("failed to create capture.rs: %w", err)
 		}
 		actions = append(actions, InstallAction{Path: ".agentlog/capture.rs", Operation: "create"})
@@ -381,6 +975,8 @@ func printInitResult(result *InitResult) {
 	// Stack detection
 	if result.Detected {
 		fmt.Printf("Detected stack: %s (from %s)\n\n", capitalize(result.Stack), result.MarkerFile)
+	} else if len(result.Stacks) > 1 {
+		fmt.Printf("Using stacks: %s\n\n", stackTitle(result))
 	} else if result.Stack != "" {
 		fmt.Printf("Using stack: %s\n\n", capitalize(result.Stack))
 	}
@@ -393,12 +989,40 @@ func printInitResult(result *InitResult) {
 	}
 
 	// Gitignore update
-	if result.GitIgnored {
+	if result.GitIgnored && result.GitignoreAll {
+		fmt.Println("Added .agentlog/* to .gitignore (un-ignore files your team shares; see the comment there)")
+	} else if result.GitIgnored {
 		fmt.Println("Added .agentlog/errors.jsonl to .gitignore")
 	}
 
+	if result.TokenCreated {
+		fmt.Println("Generated serve auth token in .agentlog/config.json")
+	}
+
+	if len(result.BrowserCapture) > 0 {
+		fmt.Printf("Browser snippet also captures: %s\n", strings.Join(result.BrowserCapture, ", "))
+	}
+
+	if result.Remote != "" {
+		fmt.Printf("Snippets post to %s instead of writing .agentlog/errors.jsonl; keep 'agentlog serve' running there\n", result.Remote)
+	} else if result.Transport == "serve" {
+		fmt.Printf("Browser snippet posts to %s; keep 'agentlog serve' running while developing\n", defaultServeURL)
+	}
+
+	if len(result.Templates) > 0 {
+		fmt.Printf("Using project templates: %s\n", strings.Join(result.Templates, ", "))
+	}
+
+	if result.ChoicesSaved {
+		fmt.Println("Saved your answers to .agentlog/config.json")
+	}
+
 	fmt.Println()
 
+	if result.Docker != nil {
+		printDockerSetup(result.Docker, result.InstallActions)
+	}
+
 	// Installation results
 	if result.Installed {
 		fmt.Println("Installed agentlog to your project:")
@@ -410,43 +1034,25 @@ func printInitResult(result *InitResult) {
 				fmt.Printf("  Modified: %s (appended error capture)\n", action.Path)
 			case "insert":
 				fmt.Printf("  Modified: %s (added route)\n", action.Path)
+			case "skip":
+				fmt.Printf("  Skipped: %s (already exists; merge in the hook from 'agentlog init' output)\n", action.Path)
 			}
 		}
+		printIntegrationUsage(result.Integrations)
 
 		// Stack-specific follow-up instructions
 		fmt.Println()
-		switch result.Stack {
-		case "ruby":
-			fmt.Println("Done! Run 'agentlog tail' to watch for errors.")
-		case "typescript":
-			fmt.Println("Import the capture file in your app entry point:")
-			fmt.Println("  import './.agentlog/capture';")
-			fmt.Println()
-			fmt.Println("Done! Run 'agentlog tail' to watch for errors.")
-		case "go":
-			fmt.Println("Add to your main.go:")
-			fmt.Println("  // import \".agentlog\"")
-			fmt.Println("  // call initAgentlog() at startup")
-			fmt.Println()
-			fmt.Println("Done! Run 'agentlog tail' to watch for errors.")
-		case "python":
-			fmt.Println("Add to your main module:")
-			fmt.Println("  from .agentlog.capture import init_agentlog")
-			fmt.Println("  init_agentlog()")
-			fmt.Println()
-			fmt.Println("Done! Run 'agentlog tail' to watch for errors.")
-		case "rust":
-			fmt.Println("Add to your main.rs:")
-			fmt.Println("  mod agentlog { include!(\".agentlog/capture.rs\"); }")
-			fmt.Println("  agentlog::init_agentlog();")
-			fmt.Println()
-			fmt.Println("Done! Run 'agentlog tail' to watch for errors.")
-		default:
-			fmt.Println("Done! Run 'agentlog tail' to watch for errors.")
+		for _, stack := range result.Stacks {
+			printStackFollowUp(stack.SnippetLang)
 		}
+		fmt.Println("Done! Run 'agentlog tail' to watch for errors.")
 	} else {
 		// No installation - print snippet for manual copy/paste
-		fmt.Printf("Add this snippet to your %s code:\n\n", capitalize(result.Stack))
+		if len(result.Stacks) > 1 {
+			fmt.Printf("Add these snippets to your %s code:\n\n", stackTitle(result))
+		} else {
+			fmt.Printf("Add this snippet to your %s code:\n\n", capitalize(result.Stack))
+		}
 		fmt.Println("---")
 		fmt.Println(result.Snippet)
 		fmt.Println("---")
@@ -455,6 +1061,43 @@ func printInitResult(result *InitResult) {
 	}
 }
 
+// printStackFollowUp prints how to wire in a stack's installed capture
+// file, if it needs more than the capture file itself
+func printStackFollowUp(lang string) {
+	switch lang {
+	case rubyScript:
+		fmt.Println("Require the capture file first thing in your script:")
+		fmt.Println("  require_relative '.agentlog/capture'")
+		fmt.Println()
+	case "react-native":
+		fmt.Println("Import the capture file first thing in index.js:")
+		fmt.Println("  import './.agentlog/capture';")
+		fmt.Println()
+		fmt.Println("Errors are sent to the machine running Metro, so serve on all interfaces:")
+		fmt.Println("  agentlog serve --addr 0.0.0.0:7654")
+		fmt.Println()
+	case "typescript":
+		fmt.Println("Import the capture file in your app entry point:")
+		fmt.Println("  import './.agentlog/capture';")
+		fmt.Println()
+	case "go":
+		fmt.Println("Add to your main.go:")
+		fmt.Println("  // import \".agentlog\"")
+		fmt.Println("  // call initAgentlog() at startup")
+		fmt.Println()
+	case "python":
+		fmt.Println("Add to your main module:")
+		fmt.Println("  from .agentlog.capture import init_agentlog")
+		fmt.Println("  init_agentlog()")
+		fmt.Println()
+	case "rust":
+		fmt.Println("Add to your main.rs:")
+		fmt.Println("  mod agentlog { include!(\".agentlog/capture.rs\"); }")
+		fmt.Println("  agentlog::init_agentlog();")
+		fmt.Println()
+	}
+}
+
 func capitalize(s string) string {
 	if s == "" {
 		return s
@@ -477,6 +1120,10 @@ func getSnippet(stack string) string {
 		return snippetRust
 	case "ruby":
 		return snippetRuby
+	case rubyScript:
+		return snippetRubyScript
+	case "react-native":
+		return snippetReactNative
 	default:
 		return snippetTypeScript
 	}
@@ -485,36 +1132,61 @@ func getSnippet(stack string) string {
 const snippetTypeScript = `// === BROWSER (add to app entry point) ===
 const _agentlogDev = typeof window !== 'undefined' && import.meta.env?.DEV !== false;
 
-const _sendLog = (type: string, msg: unknown, ctx?: object) => {
+// Tags added to every entry, e.g. ['checkout-v2'] while an experiment runs
+const _agentlogTags: string[] = [];
+
+// Operations slower than this many ms are reported as perf entries (0 = off)
+const _agentlogSlowMs = 0;
+
+const _sendLog = (type: string, msg: unknown, ctx?: object, fields?: object) => {
   if (!_agentlogDev) return;
   fetch('/__agentlog', {
     method: 'POST',
-    headers: { 'Content-Type': 'application/json' },
+    headers: { 'Content-Type': 'application/json', Authorization: 'Bearer {{AGENTLOG_TOKEN}}' },
     body: JSON.stringify({
       timestamp: new Date().toISOString(),
       source: 'frontend',
       error_type: type,
       message: String(msg).slice(0, 500),
+      environment: import.meta.env?.MODE === 'test' ? 'test' : 'dev',
+      tags: _agentlogTags.length ? _agentlogTags : undefined,
+      ...fields,
       context: ctx,
     }),
   }).catch(() => {});
 };
 
 // Log caught errors - call from try/catch blocks
-export function logError(errorType: string, message: string, context?: object): void {
+export function logError(errorType: string, message: string, context?: object, tags?: string[]): void {
   const ctx = context && typeof (context as any).stack_trace === 'string'
     ? { ...context, stack_trace: ((context as any).stack_trace as string).slice(0, 2048) }
     : context;
-  _sendLog(errorType, message, ctx);
+  _sendLog(errorType, message, ctx, tags?.length ? { tags: [..._agentlogTags, ...tags] } : undefined);
+}
+
+// Report a slow operation when _agentlogSlowMs is set and exceeded, e.g.
+// logSlow('SLOW_REQUEST', 'GET /api/users', ms, { endpoint: '/api/users' })
+export function logSlow(perfType: string, message: string, durationMs: number, fields?: object): void {
+  if (!_agentlogSlowMs || durationMs < _agentlogSlowMs) return;
+  _sendLog(perfType, message, undefined, { kind: 'perf', duration_ms: Math.round(durationMs), ...fields });
 }
 
 // Automatic capture of uncaught errors
 if (_agentlogDev) {
   window.onerror = (msg, src, line, col, err) =>
-    _sendLog('UNCAUGHT_ERROR', msg, { file: src, line, column: col, stack_trace: err?.stack?.slice(0, 2048) });
+    _sendLog('UNCAUGHT_ERROR', msg, { stack_trace: err?.stack?.slice(0, 2048) }, { file: src, line, column: col });
 
   window.onunhandledrejection = (e) =>
     _sendLog('UNHANDLED_REJECTION', e.reason, { stack_trace: e.reason?.stack?.slice(0, 2048) });
+
+  // Long tasks blocking the main thread (Chromium), when _agentlogSlowMs is set
+  if (_agentlogSlowMs && typeof PerformanceObserver !== 'undefined') {
+    try {
+      new PerformanceObserver((list) => list.getEntries().forEach((t) =>
+        logSlow('LONG_TASK', 'long task on ' + location.pathname, t.duration)
+      )).observe({ type: 'longtask', buffered: true });
+    } catch {}
+  }
 }
 
 // === DEV SERVER (vite.config.ts or similar) ===
@@ -538,26 +1210,72 @@ export const agentlogPlugin = () => ({
 
 const snippetNode = `// agentlog error handler for Node.js - add to your app entry point
 // Works with BullMQ workers, scrapers, CLI tools, and any Node.js service
-import { appendFileSync, mkdirSync, existsSync, readFileSync, writeFileSync } from 'fs';
+// (failed BullMQ jobs need 'agentlog init --integration bullmq')
+import { closeSync, fsyncSync, mkdirSync, existsSync, openSync, readFileSync, writeFileSync, writeSync } from 'fs';
+import { hostname } from 'os';
+import { basename } from 'path';
 
 const AGENTLOG_FILE = '.agentlog/errors.jsonl';
 
 // Skip in production
 const isProduction = process.env.NODE_ENV === 'production';
 
+// Project name stamped on every entry, so merged logs stay distinguishable
+const project = process.env.AGENTLOG_PROJECT || basename(process.cwd());
+
+// Environment (dev, test, preview, staging); test runners are detected
+const environment = process.env.AGENTLOG_ENV
+  || (process.env.NODE_ENV === 'test' || process.env.VITEST || process.env.JEST_WORKER_ID ? 'test' : 'dev');
+
+// Machine and user stamped on every entry, so merged logs stay attributable
+const host = process.env.AGENTLOG_HOST || hostname();
+const user = process.env.AGENTLOG_USER || process.env.USER || process.env.USERNAME;
+
+// AI agent session that produced the code, e.g. AGENTLOG_AGENT=reviewer-bot
+const agent = process.env.AGENTLOG_AGENT
+  || (process.env.CLAUDE_SESSION_ID ? 'claude:' + process.env.CLAUDE_SESSION_ID : undefined);
+
+// Tags added to every entry (comma-separated), e.g. AGENTLOG_TAGS=feature/search
+const defaultTags = (process.env.AGENTLOG_TAGS || '').split(',').map(t => t.trim()).filter(Boolean);
+
+// Test invocation entries belong to, set by 'agentlog capture --test-run'
+const testRunId = process.env.AGENTLOG_TEST_RUN_ID;
+
+// Operations slower than this are reported by logSlow/timed, e.g. AGENTLOG_SLOW_MS=500 (unset = off)
+const slowMs = Number(process.env.AGENTLOG_SLOW_MS) || 0;
+
+// Repeats of an error within this long of one written are collapsed into one
+// entry with a count, e.g. AGENTLOG_REPEAT_WINDOW_MS=0 writes every one
+const repeatWindowMs = Number(process.env.AGENTLOG_REPEAT_WINDOW_MS ?? 5000) || 0;
+
+// Also report process warnings (deprecations, MaxListenersExceeded), e.g. AGENTLOG_WARNINGS=1
+const captureWarnings = process.env.AGENTLOG_WARNINGS === '1';
+
 interface AgentlogEntry {
   timestamp: string;
   source: string;
   error_type: string;
   message: string;
+  project: string;
+  environment: string;
+  host: string;
+  user?: string;
+  agent?: string;
+  tags?: string[];
+  kind?: 'perf';
+  duration_ms?: number;
   context?: Record<string, unknown>;
+  count?: number;
+  first_seen?: string;
+  last_seen?: string;
 }
 
 // Log an error to agentlog - call this directly or use with your logger (pino, winston, etc.)
 export function logError(
   errorType: string,
   message: string,
-  context?: Record<string, unknown>
+  context?: Record<string, unknown>,
+  tags?: string[]
 ): void {
   if (isProduction) return;
 
@@ -566,8 +1284,18 @@ export function logError(
     source: 'worker',
     error_type: errorType,
     message: String(message).slice(0, 500),
+    project,
+    environment,
+    host,
+    user,
+    agent,
   };
 
+  const allTags = [...new Set([...defaultTags, ...(tags || [])])];
+  if (allTags.length) {
+    entry.tags = allTags;
+  }
+
   if (context) {
     // Truncate stack_trace if present
     if (typeof context.stack_trace === 'string') {
@@ -576,6 +1304,164 @@ export function logError(
     entry.context = context;
   }
 
+  if (admitRepeat(entry)) appendEntry(entry);
+}
+
+// Storm suppression: the first of an error is written as it happens; repeats
+// within repeatWindowMs are held and written as one entry (count, first_seen,
+// last_seen) when the window closes, so a retry loop can't fill the log
+const repeats = new Map<string, { until: number; held?: AgentlogEntry; count: number; first?: string }>();
+
+function admitRepeat(entry: AgentlogEntry): boolean {
+  if (!repeatWindowMs) return true;
+  const key = entry.error_type + '\0' + entry.message;
+  const now = Date.now();
+  const r = repeats.get(key);
+  if (!r || (!r.held && now >= r.until)) {
+    if (repeats.size >= 1024) {
+      for (const [k, v] of repeats) if (!v.held && now >= v.until) repeats.delete(k);
+    }
+    repeats.set(key, { until: now + repeatWindowMs, count: 0 });
+    return true;
+  }
+  if (!r.held) {
+    r.first = entry.timestamp;
+    setTimeout(() => flushRepeats(key), r.until - now).unref();
+  }
+  r.held = entry;
+  r.count++;
+  return false;
+}
+
+// flushRepeats writes the repeats held for key and opens the next window
+function flushRepeats(key: string): void {
+  const r = repeats.get(key);
+  if (!r || !r.held) return;
+  const { held, count, first } = r;
+  r.held = undefined;
+  r.count = 0;
+  r.until = Date.now() + repeatWindowMs;
+  appendEntry(count > 1 ? { ...held, count, first_seen: first, last_seen: held.timestamp } : held);
+}
+
+// Held repeats are written before the process exits
+process.on('exit', () => {
+  for (const key of repeats.keys()) flushRepeats(key);
+});
+
+// Report a slow operation (no-op unless AGENTLOG_SLOW_MS is set and exceeded)
+export function logSlow(
+  perfType: string,
+  message: string,
+  durationMs: number,
+  context?: Record<string, unknown>
+): void {
+  if (isProduction || !slowMs || durationMs < slowMs) return;
+
+  appendEntry({
+    timestamp: new Date().toISOString(),
+    source: 'worker',
+    error_type: perfType,
+    message: String(message).slice(0, 500),
+    project,
+    environment,
+    host,
+    user,
+    agent,
+    tags: defaultTags.length ? defaultTags : undefined,
+    kind: 'perf',
+    duration_ms: Math.round(durationMs),
+    context,
+  });
+}
+
+// Time an async operation, reporting it when slow:
+//   const rows = await timed('SLOW_QUERY', 'orders report', () => db.query(sql));
+export async function timed<T>(perfType: string, message: string, fn: () => Promise<T>): Promise<T> {
+  const start = performance.now();
+  try {
+    return await fn();
+  } finally {
+    logSlow(perfType, message, performance.now() - start);
+  }
+}
+
+// Secrets and personal data are masked before entries are written, as
+// agentlog masks them (AGENTLOG_REDACT=0 turns this off)
+const redactRules: [RegExp, string][] = [
+  [/\bbearer\s+[A-Za-z0-9\-._~+/]{8,}=*/gi, 'Bearer [REDACTED]'],
+  [/\b([a-z][a-z0-9+.-]*:\/\/)[^/\s:@]+:[^/\s@]+@/g, '$1[REDACTED]@'],
+  [/((?:api[_-]?key|apikey|access[_-]?key|access[_-]?token|auth[_-]?token|refresh[_-]?token|client[_-]?secret|secret[_-]?key|secret|passwd|password|pwd|token|private[_-]?key)["']?\s*[:=]\s*["']?)[^\s"'&,;}]{3,}/gi, '$1[REDACTED]'],
+  [/\b(?:sk|pk|rk)_(?:live|test)_[A-Za-z0-9]{8,}\b|\bsk-[A-Za-z0-9_-]{20,}|\bgh[pousr]_[A-Za-z0-9]{20,}\b|\bxox[abprs]-[A-Za-z0-9-]{10,}|\b(?:AKIA|ASIA)[0-9A-Z]{16}\b|\bAIza[0-9A-Za-z_-]{35}\b/g, '[REDACTED]'],
+  [/\beyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}/g, '[REDACTED]'],
+  [/\b[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}\b/g, '[EMAIL]'],
+];
+const secretKey = /^(?:api[_-]?key|apikey|access[_-]?key|access[_-]?token|auth[_-]?token|refresh[_-]?token|client[_-]?secret|secret[_-]?key|secret|passwd|password|pwd|token|private[_-]?key|authorization|cookie|set-cookie)$/i;
+const redactEnabled = process.env.AGENTLOG_REDACT !== '0';
+
+// luhn checks the digits of a card-number-like run
+function luhn(digits: string): boolean {
+  let sum = 0;
+  for (let i = 0; i < digits.length; i++) {
+    let d = Number(digits[digits.length - 1 - i]);
+    if (i % 2 === 1) d = d * 2 > 9 ? d * 2 - 9 : d * 2;
+    sum += d;
+  }
+  return sum % 10 === 0;
+}
+
+// redact masks every string in a message or context value
+function redact(value: unknown): unknown {
+  if (typeof value === 'string') {
+    const masked = redactRules.reduce((s, [re, mask]) => s.replace(re, mask), value);
+    return masked.replace(/\b[3-6](?:[ -]?\d){12,18}\b/g, (m) => (luhn(m.replace(/\D/g, '')) ? '[CARD]' : m));
+  }
+  if (Array.isArray(value)) return value.map(redact);
+  if (value && typeof value === 'object') {
+    return Object.fromEntries(Object.entries(value).map(([k, v]) =>
+      [k, typeof v === 'string' && v && secretKey.test(k) ? '[REDACTED]' : redact(v)]));
+  }
+  return value;
+}
+
+// Context keys kept, from "context" in .agentlog/config.json: allow lists
+// the top-level keys kept, deny the keys ("headers") or dotted paths
+// ("request.body") dropped
+const contextPolicy = ((): { allow: string[]; deny: string[] } => {
+  try {
+    const { allow = [], deny = [] } = JSON.parse(readFileSync('.agentlog/config.json', 'utf-8')).context || {};
+    const lower = (keys: unknown[]) => keys.map((k) => String(k).trim().toLowerCase()).filter(Boolean);
+    return { allow: lower(allow), deny: lower(deny) };
+  } catch {
+    return { allow: [], deny: [] };
+  }
+})();
+
+// filterContext drops the context keys the policy excludes
+function filterContext(ctx: Record<string, unknown> | undefined, prefix = ''): Record<string, unknown> | undefined {
+  if (!ctx || (!contextPolicy.allow.length && !contextPolicy.deny.length)) return ctx;
+  const kept: Record<string, unknown> = {};
+  for (const [k, v] of Object.entries(ctx)) {
+    const key = k.toLowerCase();
+    if (!prefix && contextPolicy.allow.length && !contextPolicy.allow.includes(key)) continue;
+    if (contextPolicy.deny.includes(key) || contextPolicy.deny.includes(prefix + key)) continue;
+    if (v && typeof v === 'object' && !Array.isArray(v) && Object.keys(v).length) {
+      const nested = filterContext(v as Record<string, unknown>, prefix + key + '.');
+      if (nested) kept[k] = nested;
+      continue;
+    }
+    kept[k] = v;
+  }
+  return Object.keys(kept).length ? kept : undefined;
+}
+
+// Append an entry, creating .agentlog/ (and its .gitignore line) on first use
+function appendEntry(entry: AgentlogEntry): void {
+  if (testRunId) entry = { ...entry, context: { test_run_id: testRunId, ...entry.context } };
+  entry = { ...entry, context: filterContext(entry.context) };
+  if (redactEnabled) {
+    entry = { ...entry, message: redact(entry.message) as string, context: redact(entry.context) as Record<string, unknown> | undefined };
+  }
   try {
     if (!existsSync('.agentlog')) {
       mkdirSync('.agentlog', { recursive: true });
@@ -596,7 +1482,14 @@ export function logError(
         writeFileSync(gitignorePath, newContent);
       }
     }
-    appendFileSync(AGENTLOG_FILE, JSON.stringify(entry) + '\n');
+    const fd = openSync(AGENTLOG_FILE, 'a');
+    try {
+      writeSync(fd, JSON.stringify(entry) + '\n');
+      // AGENTLOG_FSYNC=1 flushes each entry to disk, so a crash can't lose it
+      if (process.env.AGENTLOG_FSYNC) fsyncSync(fd);
+    } finally {
+      closeSync(fd);
+    }
   } catch {
     // Silently fail - don't crash the app for logging
   }
@@ -621,6 +1514,23 @@ export function initAgentlog(): void {
       stack_trace: stack,
     });
   });
+
+  // Warnings are reported once per name and message, with context.level
+  // "warning" so they can be told apart from failures
+  if (captureWarnings) {
+    const seenWarnings = new Set<string>();
+    process.on('warning', (warning: Error & { code?: string }) => {
+      const key = warning.name + ':' + warning.message;
+      if (seenWarnings.has(key)) return;
+      seenWarnings.add(key);
+      logError(warning.name === 'DeprecationWarning' ? 'DEPRECATION_WARNING' : 'PROCESS_WARNING', warning.message, {
+        level: 'warning',
+        warning: warning.name,
+        code: warning.code,
+        stack_trace: warning.stack,
+      });
+    });
+  }
 }
 
 // Pino integration example:
@@ -644,9 +1554,13 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime/debug"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -664,20 +1578,82 @@ func initAgentlog() {
 }
 
 func logAgentError(errType, message, stackTrace string) {
+	entry := agentEntry(errType, message)
+	if stackTrace != "" {
+		entry["context"] = map[string]string{"stack_trace": truncate(stackTrace, 2048)}
+	}
+	writeAgentEntry(entry)
+}
+
+// logAgentSlow records an operation that took longer than AGENTLOG_SLOW_MS
+// (unset = off): defer logAgentSlow("SLOW_QUERY", "orders report", time.Now())
+func logAgentSlow(perfType, message string, start time.Time) {
+	elapsed := time.Since(start)
+	threshold, _ := strconv.Atoi(os.Getenv("AGENTLOG_SLOW_MS"))
+	if threshold <= 0 || elapsed < time.Duration(threshold)*time.Millisecond || os.Getenv("PRODUCTION") != "" {
+		return
+	}
+	entry := agentEntry(perfType, message)
+	entry["kind"] = "perf"
+	entry["duration_ms"] = elapsed.Milliseconds()
+	writeAgentEntry(entry)
+}
+
+func agentEntry(errType, message string) map[string]interface{} {
 	entry := map[string]interface{}{
 		"timestamp":  time.Now().UTC().Format(time.RFC3339Nano),
 		"source":     "backend",
 		"error_type": errType,
 		"message":    truncate(message, 500),
 	}
-	if stackTrace != "" {
-		entry["context"] = map[string]string{"stack_trace": truncate(stackTrace, 2048)}
+	// Project name stamped on every entry, so merged logs stay distinguishable
+	if project := os.Getenv("AGENTLOG_PROJECT"); project != "" {
+		entry["project"] = project
+	} else if wd, err := os.Getwd(); err == nil {
+		entry["project"] = filepath.Base(wd)
+	}
+	// Environment (dev, test, preview, staging); go test runs are detected
+	if env := os.Getenv("AGENTLOG_ENV"); env != "" {
+		entry["environment"] = env
+	} else if flag.Lookup("test.v") != nil {
+		entry["environment"] = "test"
+	} else {
+		entry["environment"] = "dev"
+	}
+	// Machine and user, so merged logs stay attributable
+	if host := os.Getenv("AGENTLOG_HOST"); host != "" {
+		entry["host"] = host
+	} else if host, err := os.Hostname(); err == nil {
+		entry["host"] = host
+	}
+	for _, name := range []string{"AGENTLOG_USER", "USER", "USERNAME"} {
+		if user := os.Getenv(name); user != "" {
+			entry["user"] = user
+			break
+		}
 	}
+	// AI agent session that produced the code, e.g. AGENTLOG_AGENT=reviewer-bot
+	if agent := os.Getenv("AGENTLOG_AGENT"); agent != "" {
+		entry["agent"] = agent
+	} else if session := os.Getenv("CLAUDE_SESSION_ID"); session != "" {
+		entry["agent"] = "claude:" + session
+	}
+	// Tags added to every entry (comma-separated), e.g. AGENTLOG_TAGS=feature/search
+	if tags := os.Getenv("AGENTLOG_TAGS"); tags != "" {
+		entry["tags"] = strings.Split(tags, ",")
+	}
+	return entry
+}
 
+func writeAgentEntry(entry map[string]interface{}) {
 	data, _ := json.Marshal(entry)
 	f, _ := os.OpenFile(".agentlog/errors.jsonl", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	defer f.Close()
 	f.WriteString(string(data) + "\n")
+	// AGENTLOG_FSYNC=1 flushes each entry to disk, so a crash can't lose it
+	if os.Getenv("AGENTLOG_FSYNC") != "" {
+		f.Sync()
+	}
 }
 
 func truncate(s string, max int) string {
@@ -689,9 +1665,47 @@ const snippetPython = `# agentlog error handler - add to your main module
 import sys
 import os
 import json
+import socket
+import logging
 import traceback
 from datetime import datetime, timezone
 
+def _agentlog_write(entry):
+    # Test invocation the entry belongs to, set by 'agentlog capture --test-run'
+    if os.environ.get('AGENTLOG_TEST_RUN_ID'):
+        entry.setdefault("context", {}).setdefault("test_run_id", os.environ['AGENTLOG_TEST_RUN_ID'])
+    os.makedirs('.agentlog', exist_ok=True)
+    with open('.agentlog/errors.jsonl', 'a') as f:
+        f.write(json.dumps(entry) + '\n')
+        # AGENTLOG_FSYNC=1 flushes each entry to disk, so a crash can't lose it
+        if os.environ.get('AGENTLOG_FSYNC'):
+            f.flush()
+            os.fsync(f.fileno())
+
+def log_slow(perf_type, message, duration_ms, **context):
+    """Record an operation slower than AGENTLOG_SLOW_MS (unset = off), e.g.
+    start = time.perf_counter(); ...; log_slow('SLOW_QUERY', 'orders report', (time.perf_counter() - start) * 1000)
+    """
+    threshold = float(os.environ.get('AGENTLOG_SLOW_MS') or 0)
+    if not threshold or duration_ms < threshold or os.environ.get('ENV') == 'production':
+        return
+    entry = {
+        "timestamp": datetime.now(timezone.utc).isoformat(),
+        "source": "backend",
+        "error_type": perf_type,
+        "message": str(message)[:500],
+        "project": os.environ.get('AGENTLOG_PROJECT') or os.path.basename(os.getcwd()),
+        "environment": os.environ.get('AGENTLOG_ENV') or ('test' if 'PYTEST_CURRENT_TEST' in os.environ else 'dev'),
+        "host": os.environ.get('AGENTLOG_HOST') or socket.gethostname(),
+        "user": os.environ.get('AGENTLOG_USER') or os.environ.get('USER') or os.environ.get('USERNAME'),
+        "agent": os.environ.get('AGENTLOG_AGENT') or ('claude:' + os.environ['CLAUDE_SESSION_ID'] if os.environ.get('CLAUDE_SESSION_ID') else None),
+        "kind": "perf",
+        "duration_ms": round(duration_ms),
+    }
+    if context:
+        entry["context"] = context
+    _agentlog_write(entry)
+
 def init_agentlog():
     if os.environ.get('ENV') == 'production':
         return  # no-op in production
@@ -704,21 +1718,70 @@ def init_agentlog():
             "source": "backend",
             "error_type": "EXCEPTION",
             "message": str(exc_value)[:500],
+            "project": os.environ.get('AGENTLOG_PROJECT') or os.path.basename(os.getcwd()),
+            "environment": os.environ.get('AGENTLOG_ENV') or ('test' if 'PYTEST_CURRENT_TEST' in os.environ else 'dev'),
+            "host": os.environ.get('AGENTLOG_HOST') or socket.gethostname(),
+            "user": os.environ.get('AGENTLOG_USER') or os.environ.get('USER') or os.environ.get('USERNAME'),
+            "agent": os.environ.get('AGENTLOG_AGENT') or ('claude:' + os.environ['CLAUDE_SESSION_ID'] if os.environ.get('CLAUDE_SESSION_ID') else None),
             "context": {
                 "stack_trace": "".join(traceback.format_exception(exc_type, exc_value, exc_tb))[:2048]
             }
         }
+        frames = traceback.extract_tb(exc_tb)
+        if frames:
+            entry["file"] = frames[-1].filename
+            entry["line"] = frames[-1].lineno
+        # Tags added to every entry (comma-separated), e.g. AGENTLOG_TAGS=feature/search
+        tags = [t.strip() for t in os.environ.get('AGENTLOG_TAGS', '').split(',') if t.strip()]
+        if tags:
+            entry["tags"] = tags
 
-        os.makedirs('.agentlog', exist_ok=True)
-        with open('.agentlog/errors.jsonl', 'a') as f:
-            f.write(json.dumps(entry) + '\n')
+        _agentlog_write(entry)
 
         original_excepthook(exc_type, exc_value, exc_tb)
 
     sys.excepthook = agentlog_excepthook
 
+class AgentlogHandler(logging.Handler):
+    """Forwards log records at ERROR and above, with the logger name and any
+    traceback. sys.excepthook never sees errors that are caught and logged
+    (logger.exception, logger.error), so attach this to the root logger:
+    logging.getLogger().addHandler(AgentlogHandler())
+    """
+
+    def __init__(self, level=logging.ERROR):
+        super().__init__(level)
+
+    def emit(self, record):
+        if os.environ.get('ENV') == 'production':
+            return
+        try:
+            entry = {
+                "timestamp": datetime.fromtimestamp(record.created, timezone.utc).isoformat(),
+                "source": "backend",
+                "error_type": "LOG_ERROR",
+                "message": record.getMessage()[:500],
+                "project": os.environ.get('AGENTLOG_PROJECT') or os.path.basename(os.getcwd()),
+                "environment": os.environ.get('AGENTLOG_ENV') or ('test' if 'PYTEST_CURRENT_TEST' in os.environ else 'dev'),
+                "host": os.environ.get('AGENTLOG_HOST') or socket.gethostname(),
+                "user": os.environ.get('AGENTLOG_USER') or os.environ.get('USER') or os.environ.get('USERNAME'),
+                "agent": os.environ.get('AGENTLOG_AGENT') or ('claude:' + os.environ['CLAUDE_SESSION_ID'] if os.environ.get('CLAUDE_SESSION_ID') else None),
+                "file": record.pathname,
+                "line": record.lineno,
+                "context": {"logger": record.name, "level": record.levelname.lower()},
+            }
+            if record.exc_info and record.exc_info[0] is not None:
+                entry["context"]["stack_trace"] = "".join(traceback.format_exception(*record.exc_info))[:2048]
+            tags = [t.strip() for t in os.environ.get('AGENTLOG_TAGS', '').split(',') if t.strip()]
+            if tags:
+                entry["tags"] = tags
+            _agentlog_write(entry)
+        except Exception:
+            self.handleError(record)
+
 # Call at application startup
-init_agentlog()`
+init_agentlog()
+logging.getLogger().addHandler(AgentlogHandler())`
 
 const snippetRust = `// agentlog error handler - add to your main.rs
 use std::fs::{OpenOptions, create_dir_all};
@@ -734,19 +1797,43 @@ pub fn init_agentlog() {
 
     panic::set_hook(Box::new(|panic_info| {
         let message = panic_info.to_string();
-        let location = panic_info.location()
-            .map(|l| format!("{}:{}:{}", l.file(), l.line(), l.column()))
+        let (file, line, column) = panic_info.location()
+            .map(|l| (l.file().to_string(), l.line(), l.column()))
             .unwrap_or_default();
-
-        let entry = json!({
+        let project = std::env::var("AGENTLOG_PROJECT").ok().or_else(|| {
+            std::env::current_dir().ok()
+                .and_then(|d| d.file_name().map(|n| n.to_string_lossy().into_owned()))
+        });
+        // Machine and user, so merged logs stay attributable
+        let host = std::env::var("AGENTLOG_HOST").or_else(|_| std::env::var("HOSTNAME")).ok()
+            .or_else(|| std::fs::read_to_string("/etc/hostname").ok().map(|h| h.trim().to_string()));
+        let user = std::env::var("AGENTLOG_USER").or_else(|_| std::env::var("USER"))
+            .or_else(|_| std::env::var("USERNAME")).ok();
+        // AI agent session that produced the code, e.g. AGENTLOG_AGENT=reviewer-bot
+        let agent = std::env::var("AGENTLOG_AGENT").ok()
+            .or_else(|| std::env::var("CLAUDE_SESSION_ID").ok().map(|s| format!("claude:{}", s)));
+
+        let mut entry = json!({
             "timestamp": Utc::now().to_rfc3339(),
             "source": "backend",
             "error_type": "PANIC",
             "message": &message[..message.len().min(500)],
-            "context": {
-                "file": location
-            }
+            "project": project,
+            "environment": std::env::var("AGENTLOG_ENV").unwrap_or_else(|_| "dev".to_string()),
+            "host": host,
+            "user": user,
+            "agent": agent,
+            "file": file,
+            "line": line,
+            "column": column
         });
+        // Tags added to every entry (comma-separated), e.g. AGENTLOG_TAGS=feature/search
+        if let Ok(tags) = std::env::var("AGENTLOG_TAGS") {
+            let tags: Vec<&str> = tags.split(',').filter(|t| !t.is_empty()).collect();
+            if !tags.is_empty() {
+                entry["tags"] = json!(tags);
+            }
+        }
 
         let _ = create_dir_all(".agentlog");
         if let Ok(mut file) = OpenOptions::new()
@@ -754,32 +1841,61 @@ pub fn init_agentlog() {
             .append(true)
             .open(".agentlog/errors.jsonl")
         {
-            let _ = writeln!(file, "{}", entry);
+            // One write per line, so concurrent writers can't interleave;
+            // AGENTLOG_FSYNC=1 also flushes each entry to disk
+            let _ = file.write_all(format!("{}\n", entry).as_bytes());
+            if std::env::var_os("AGENTLOG_FSYNC").is_some() { let _ = file.sync_data(); }
         }
     }));
 }
 
+// Record an operation slower than AGENTLOG_SLOW_MS (unset = off):
+// let start = std::time::Instant::now(); ...; log_slow("SLOW_QUERY", "orders report", start.elapsed());
+pub fn log_slow(perf_type: &str, message: &str, duration: std::time::Duration) {
+    let threshold: u128 = std::env::var("AGENTLOG_SLOW_MS").ok()
+        .and_then(|v| v.parse().ok())
+        .unwrap_or(0);
+    if threshold == 0 || duration.as_millis() < threshold || std::env::var("PRODUCTION").is_ok() {
+        return;
+    }
+    let entry = json!({
+        "timestamp": Utc::now().to_rfc3339(),
+        "source": "backend",
+        "error_type": perf_type,
+        "message": &message[..message.len().min(500)],
+        "environment": std::env::var("AGENTLOG_ENV").unwrap_or_else(|_| "dev".to_string()),
+        "kind": "perf",
+        "duration_ms": duration.as_millis() as u64
+    });
+    let _ = create_dir_all(".agentlog");
+    if let Ok(mut file) = OpenOptions::new().create(true).append(true).open(".agentlog/errors.jsonl") {
+        let _ = file.write_all(format!("{}\n", entry).as_bytes());
+        if std::env::var_os("AGENTLOG_FSYNC").is_some() { let _ = file.sync_data(); }
+    }
+}
+
 // Call at application startup
 // fn main() { init_agentlog(); ... }`
 
 const snippetRuby = `# === BROWSER (add to app/javascript/application.js) ===
 // Error capture for agentlog - sends frontend errors to /__agentlog endpoint
 (function() {
-  const log = (type, msg, ctx) =>
+  const log = (type, msg, ctx, loc) =>
     fetch('/__agentlog', {
       method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
+      headers: { 'Content-Type': 'application/json', Authorization: 'Bearer {{AGENTLOG_TOKEN}}' },
       body: JSON.stringify({
         timestamp: new Date().toISOString(),
         source: 'frontend',
         error_type: type,
         message: String(msg).slice(0, 500),
+        ...loc,
         context: ctx,
       }),
     }).catch(() => {});
 
   window.onerror = (msg, src, line, col, err) =>
-    log('UNCAUGHT_ERROR', msg, { file: src, line, column: col, stack_trace: err?.stack?.slice(0, 2048) });
+    log('UNCAUGHT_ERROR', msg, { stack_trace: err?.stack?.slice(0, 2048) }, { file: src, line, column: col });
 
   window.onunhandledrejection = (e) =>
     log('UNHANDLED_REJECTION', e.reason, { stack_trace: e.reason?.stack?.slice(0, 2048) });
@@ -806,6 +1922,7 @@ post '/__agentlog', to: 'agentlog#create' if Rails.env.development?
 
 # === BACKEND MIDDLEWARE (add to config/initializers/agentlog.rb) ===
 require 'json'
+require 'socket'
 require 'fileutils'
 
 module Agentlog
@@ -815,7 +1932,10 @@ module Agentlog
     end
 
     def call(env)
-      @app.call(env)
+      started = Process.clock_gettime(Process::CLOCK_MONOTONIC)
+      response = @app.call(env)
+      log_slow(env, started)
+      response
     rescue Exception => e
       log_error(e, env)
       raise
@@ -823,19 +1943,54 @@ module Agentlog
 
     private
 
+    # Requests slower than AGENTLOG_SLOW_MS (unset = off) become perf entries
+    def log_slow(env, started)
+      threshold = ENV['AGENTLOG_SLOW_MS'].to_i
+      return if threshold <= 0
+
+      duration_ms = ((Process.clock_gettime(Process::CLOCK_MONOTONIC) - started) * 1000).round
+      return if duration_ms < threshold
+
+      path = env['REQUEST_PATH'] || env['PATH_INFO']
+      write_entry(
+        timestamp: Time.now.utc.iso8601(3),
+        source: 'backend',
+        error_type: 'SLOW_REQUEST',
+        message: "#{env['REQUEST_METHOD']} #{path}",
+        project: ENV['AGENTLOG_PROJECT'] || File.basename(Dir.pwd),
+        environment: ENV['AGENTLOG_ENV'] || 'dev',
+        host: ENV['AGENTLOG_HOST'] || Socket.gethostname,
+        user: ENV['AGENTLOG_USER'] || ENV['USER'] || ENV['USERNAME'],
+        agent: ENV['AGENTLOG_AGENT'] || (ENV['CLAUDE_SESSION_ID'] && "claude:#{ENV['CLAUDE_SESSION_ID']}"),
+        endpoint: path,
+        kind: 'perf',
+        duration_ms: duration_ms
+      )
+    end
+
     def log_error(exception, env)
       entry = {
         timestamp: Time.now.utc.iso8601(3),
         source: 'backend',
         error_type: 'REQUEST_ERROR',
         message: exception.message.to_s[0, 500],
+        project: ENV['AGENTLOG_PROJECT'] || File.basename(Dir.pwd),
+        environment: ENV['AGENTLOG_ENV'] || 'dev',
+        host: ENV['AGENTLOG_HOST'] || Socket.gethostname,
+        user: ENV['AGENTLOG_USER'] || ENV['USER'] || ENV['USERNAME'],
+        agent: ENV['AGENTLOG_AGENT'] || (ENV['CLAUDE_SESSION_ID'] && "claude:#{ENV['CLAUDE_SESSION_ID']}"),
+        endpoint: env['REQUEST_PATH'] || env['PATH_INFO'],
+        tags: ENV['AGENTLOG_TAGS'].to_s.split(',').presence,
         context: {
           stack_trace: exception.backtrace&.join("\n")&.slice(0, 2048),
-          endpoint: env['REQUEST_PATH'] || env['PATH_INFO'],
           request_id: env['action_dispatch.request_id']
         }.compact
-      }
+      }.compact
+
+      write_entry(entry)
+    end
 
+    def write_entry(entry)
       FileUtils.mkdir_p('.agentlog')
       File.open('.agentlog/errors.jsonl', 'a') do |f|
         f.puts(entry.to_json)
@@ -851,6 +2006,127 @@ end`
 
 // Installable snippet parts for --install flag
 
+const snippetReactNative = `// agentlog:installed - React Native error capture
+// Usage: import './.agentlog/capture'; first thing in index.js
+// The device's filesystem isn't the project, so errors are posted to
+// 'agentlog serve --addr 0.0.0.0:7654' on the machine running Metro.
+import { NativeModules, Platform } from 'react-native';
+
+const AGENTLOG_PORT = 7654;
+
+// The dev bundle is loaded from Metro, so its URL names the dev machine as
+// the device sees it (10.0.2.2 on the Android emulator, a LAN IP on devices)
+const agentlogHost = (): string | null => {
+  const scriptURL: string | undefined = NativeModules.SourceCode?.scriptURL;
+  const match = scriptURL?.match(/^https?:\/\/([^/:]+)/);
+  return match ? match[1] : null;
+};
+
+const _sendLog = (errorType: string, err: unknown, context: Record<string, unknown> = {}) => {
+  const host = agentlogHost();
+  if (!host) return;
+  const e = err instanceof Error ? err : new Error(String(err));
+  fetch('http://' + host + ':' + AGENTLOG_PORT + '/__agentlog', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json', Authorization: 'Bearer {{AGENTLOG_TOKEN}}' },
+    body: JSON.stringify({
+      timestamp: new Date().toISOString(),
+      source: 'frontend',
+      error_type: errorType,
+      message: e.message.slice(0, 500),
+      context: { platform: Platform.OS, stack_trace: e.stack?.slice(0, 2048), ...context },
+    }),
+  }).catch(() => {});
+};
+
+type GlobalHandler = (error: Error, isFatal?: boolean) => void;
+const errorUtils = (globalThis as unknown as {
+  ErrorUtils?: { getGlobalHandler(): GlobalHandler; setGlobalHandler(handler: GlobalHandler): void };
+}).ErrorUtils;
+
+if (__DEV__ && errorUtils) {
+  // Keep the default handler so the red box still shows
+  const previous = errorUtils.getGlobalHandler();
+  errorUtils.setGlobalHandler((error, isFatal) => {
+    _sendLog('UNCAUGHT_ERROR', error, { fatal: !!isFatal });
+    previous(error, isFatal);
+  });
+}
+`
+
+// rubyScript is the snippet language for Ruby projects without Rails
+const rubyScript = "ruby-script"
+
+const snippetRubyScript = `# agentlog:installed - Error capture for Ruby scripts and CLIs
+# Usage: require_relative '.agentlog/capture' at the top of your script
+require 'json'
+require 'socket'
+require 'fileutils'
+require 'time'
+
+module Agentlog
+  FILE = '.agentlog/errors.jsonl'
+
+  def self.log_error(error_type, message, context = {}, file: nil, line: nil)
+    return if ENV['APP_ENV'] == 'production' || ENV['RACK_ENV'] == 'production'
+
+    entry = {
+      timestamp: Time.now.utc.iso8601(3),
+      source: 'cli',
+      error_type: error_type,
+      message: message.to_s[0, 500],
+      project: ENV['AGENTLOG_PROJECT'] || File.basename(Dir.pwd),
+      environment: ENV['AGENTLOG_ENV'] || 'dev',
+      host: ENV['AGENTLOG_HOST'] || Socket.gethostname,
+      user: ENV['AGENTLOG_USER'] || ENV['USER'] || ENV['USERNAME'],
+      agent: ENV['AGENTLOG_AGENT'] || (ENV['CLAUDE_SESSION_ID'] && "claude:#{ENV['CLAUDE_SESSION_ID']}")
+    }
+    entry[:file] = file if file
+    entry[:line] = line if line
+    # Tags added to every entry (comma-separated), e.g. AGENTLOG_TAGS=feature/search
+    tags = ENV.fetch('AGENTLOG_TAGS', '').split(',').map(&:strip).reject(&:empty?)
+    entry[:tags] = tags unless tags.empty?
+    entry[:context] = context unless context.empty?
+
+    FileUtils.mkdir_p(File.dirname(FILE))
+    File.open(FILE, 'a') do |f|
+      f.puts(entry.to_json)
+      f.fsync if ENV['AGENTLOG_FSYNC'] # flush each entry to disk, so a crash can't lose it
+    end
+  rescue StandardError
+    # Never let logging break the script
+  end
+
+  def self.log_exception(error, error_type = 'EXCEPTION', context = {})
+    location = error.backtrace_locations&.first
+    log_error(error_type, error.message, context.merge(
+      error_class: error.class.name,
+      stack_trace: error.full_message(highlight: false)[0, 2048]
+    ), file: location&.path, line: location&.lineno)
+  end
+
+  # Threads report exceptions to stderr and die quietly; record them too
+  module ThreadCapture
+    def initialize(*args, &block)
+      super(*args) do |*block_args|
+        block.call(*block_args)
+      rescue Exception => e # rubocop:disable Lint/RescueException
+        Agentlog.log_exception(e, 'THREAD_EXCEPTION', thread: Thread.current.name) if Thread.current.report_on_exception
+        raise
+      end
+    end
+  end
+end
+
+Thread.prepend(Agentlog::ThreadCapture)
+
+# $! is the exception ending the script, if any; exit and Ctrl-C aren't errors
+at_exit do
+  error = $!
+  Agentlog.log_exception(error, 'UNCAUGHT_EXCEPTION') if error && !error.is_a?(SystemExit) && !error.is_a?(Interrupt)
+end
+`
+
 const rubyController = `# agentlog:installed
 class AgentlogController < ApplicationController
   skip_before_action :verify_authenticity_token, only: :create
@@ -870,6 +2146,7 @@ end
 
 const rubyInitializer = `# agentlog:installed
 require 'json'
+require 'socket'
 require 'fileutils'
 
 module Agentlog
@@ -879,7 +2156,10 @@ module Agentlog
     end
 
     def call(env)
-      @app.call(env)
+      started = Process.clock_gettime(Process::CLOCK_MONOTONIC)
+      response = @app.call(env)
+      log_slow(env, started)
+      response
     rescue Exception => e
       log_error(e, env)
       raise
@@ -887,19 +2167,54 @@ module Agentlog
 
     private
 
+    # Requests slower than AGENTLOG_SLOW_MS (unset = off) become perf entries
+    def log_slow(env, started)
+      threshold = ENV['AGENTLOG_SLOW_MS'].to_i
+      return if threshold <= 0
+
+      duration_ms = ((Process.clock_gettime(Process::CLOCK_MONOTONIC) - started) * 1000).round
+      return if duration_ms < threshold
+
+      path = env['REQUEST_PATH'] || env['PATH_INFO']
+      write_entry(
+        timestamp: Time.now.utc.iso8601(3),
+        source: 'backend',
+        error_type: 'SLOW_REQUEST',
+        message: "#{env['REQUEST_METHOD']} #{path}",
+        project: ENV['AGENTLOG_PROJECT'] || File.basename(Dir.pwd),
+        environment: ENV['AGENTLOG_ENV'] || 'dev',
+        host: ENV['AGENTLOG_HOST'] || Socket.gethostname,
+        user: ENV['AGENTLOG_USER'] || ENV['USER'] || ENV['USERNAME'],
+        agent: ENV['AGENTLOG_AGENT'] || (ENV['CLAUDE_SESSION_ID'] && "claude:#{ENV['CLAUDE_SESSION_ID']}"),
+        endpoint: path,
+        kind: 'perf',
+        duration_ms: duration_ms
+      )
+    end
+
     def log_error(exception, env)
       entry = {
         timestamp: Time.now.utc.iso8601(3),
         source: 'backend',
         error_type: 'REQUEST_ERROR',
         message: exception.message.to_s[0, 500],
+        project: ENV['AGENTLOG_PROJECT'] || File.basename(Dir.pwd),
+        environment: ENV['AGENTLOG_ENV'] || 'dev',
+        host: ENV['AGENTLOG_HOST'] || Socket.gethostname,
+        user: ENV['AGENTLOG_USER'] || ENV['USER'] || ENV['USERNAME'],
+        agent: ENV['AGENTLOG_AGENT'] || (ENV['CLAUDE_SESSION_ID'] && "claude:#{ENV['CLAUDE_SESSION_ID']}"),
+        endpoint: env['REQUEST_PATH'] || env['PATH_INFO'],
+        tags: ENV['AGENTLOG_TAGS'].to_s.split(',').presence,
         context: {
           stack_trace: exception.backtrace&.join("\n")&.slice(0, 2048),
-          endpoint: env['REQUEST_PATH'] || env['PATH_INFO'],
           request_id: env['action_dispatch.request_id']
         }.compact
-      }
+      }.compact
+
+      write_entry(entry)
+    end
 
+    def write_entry(entry)
       FileUtils.mkdir_p('.agentlog')
       File.open('.agentlog/errors.jsonl', 'a') do |f|
         f.puts(entry.to_json)
@@ -918,21 +2233,22 @@ const rubyRoute = `post '/__agentlog', to: 'agentlog#create' if Rails.env.develo
 
 const rubyFrontendJS = `// agentlog:installed - Error capture for agentlog
 (function() {
-  const log = (type, msg, ctx) =>
+  const log = (type, msg, ctx, loc) =>
     fetch('/__agentlog', {
       method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
+      headers: { 'Content-Type': 'application/json', Authorization: 'Bearer {{AGENTLOG_TOKEN}}' },
       body: JSON.stringify({
         timestamp: new Date().toISOString(),
         source: 'frontend',
         error_type: type,
         message: String(msg).slice(0, 500),
+        ...loc,
         context: ctx,
       }),
     }).catch(() => {});
 
   window.onerror = (msg, src, line, col, err) =>
-    log('UNCAUGHT_ERROR', msg, { file: src, line, column: col, stack_trace: err?.stack?.slice(0, 2048) });
+    log('UNCAUGHT_ERROR', msg, { stack_trace: err?.stack?.slice(0, 2048) }, { file: src, line, column: col });
 
   window.onunhandledrejection = (e) =>
     log('UNHANDLED_REJECTION', e.reason, { stack_trace: e.reason?.stack?.slice(0, 2048) });
@@ -943,51 +2259,98 @@ const typescriptCapture = `// agentlog:installed - Import this in your app entry
 // Usage: import './.agentlog/capture';
 
 if (typeof window !== 'undefined') {
-  const log = (type: string, msg: unknown, ctx?: object) =>
+  const _sendLog = (type: string, msg: unknown, ctx?: object, loc?: object) =>
     fetch('/__agentlog', {
       method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
+      headers: { 'Content-Type': 'application/json', Authorization: 'Bearer {{AGENTLOG_TOKEN}}' },
       body: JSON.stringify({
         timestamp: new Date().toISOString(),
         source: 'frontend',
         error_type: type,
         message: String(msg).slice(0, 500),
+        ...loc,
         context: ctx,
       }),
     }).catch(() => {});
 
   window.onerror = (msg, src, line, col, err) =>
-    log('UNCAUGHT_ERROR', msg, { file: src, line, column: col, stack_trace: err?.stack?.slice(0, 2048) });
+    _sendLog('UNCAUGHT_ERROR', msg, { stack_trace: err?.stack?.slice(0, 2048) }, { file: src, line, column: col });
 
   window.onunhandledrejection = (e) =>
-    log('UNHANDLED_REJECTION', e.reason, { stack_trace: e.reason?.stack?.slice(0, 2048) });
+    _sendLog('UNHANDLED_REJECTION', e.reason, { stack_trace: e.reason?.stack?.slice(0, 2048) });
 }
 `
 
 const nodeCapture = `// agentlog:installed - Import this in your Node.js app entry point
 // Usage: import './.agentlog/capture';
 // Works with BullMQ workers, scrapers, CLI tools, and any Node.js service
+// (failed BullMQ jobs need 'agentlog init --integration bullmq')
 
-import { appendFileSync, mkdirSync, existsSync, readFileSync, writeFileSync } from 'fs';
+import { closeSync, fsyncSync, mkdirSync, existsSync, openSync, readFileSync, writeFileSync, writeSync } from 'fs';
+import { hostname } from 'os';
+import { basename } from 'path';
 
 const AGENTLOG_FILE = '.agentlog/errors.jsonl';
 
 // Skip in production
 const isProduction = process.env.NODE_ENV === 'production';
 
+// Project name stamped on every entry, so merged logs stay distinguishable
+const project = process.env.AGENTLOG_PROJECT || basename(process.cwd());
+
+// Environment (dev, test, preview, staging); test runners are detected
+const environment = process.env.AGENTLOG_ENV
+  || (process.env.NODE_ENV === 'test' || process.env.VITEST || process.env.JEST_WORKER_ID ? 'test' : 'dev');
+
+// Machine and user stamped on every entry, so merged logs stay attributable
+const host = process.env.AGENTLOG_HOST || hostname();
+const user = process.env.AGENTLOG_USER || process.env.USER || process.env.USERNAME;
+
+// AI agent session that produced the code, e.g. AGENTLOG_AGENT=reviewer-bot
+const agent = process.env.AGENTLOG_AGENT
+  || (process.env.CLAUDE_SESSION_ID ? 'claude:' + process.env.CLAUDE_SESSION_ID : undefined);
+
+// Tags added to every entry (comma-separated), e.g. AGENTLOG_TAGS=feature/search
+const defaultTags = (process.env.AGENTLOG_TAGS || '').split(',').map(t => t.trim()).filter(Boolean);
+
+// Test invocation entries belong to, set by 'agentlog capture --test-run'
+const testRunId = process.env.AGENTLOG_TEST_RUN_ID;
+
+// Operations slower than this are reported by logSlow/timed, e.g. AGENTLOG_SLOW_MS=500 (unset = off)
+const slowMs = Number(process.env.AGENTLOG_SLOW_MS) || 0;
+
+// Repeats of an error within this long of one written are collapsed into one
+// entry with a count, e.g. AGENTLOG_REPEAT_WINDOW_MS=0 writes every one
+const repeatWindowMs = Number(process.env.AGENTLOG_REPEAT_WINDOW_MS ?? 5000) || 0;
+
+// Also report process warnings (deprecations, MaxListenersExceeded), e.g. AGENTLOG_WARNINGS=1
+const captureWarnings = process.env.AGENTLOG_WARNINGS === '1';
+
 interface AgentlogEntry {
   timestamp: string;
   source: string;
   error_type: string;
   message: string;
+  project: string;
+  environment: string;
+  host: string;
+  user?: string;
+  agent?: string;
+  tags?: string[];
+  kind?: 'perf';
+  duration_ms?: number;
   context?: Record<string, unknown>;
+  count?: number;
+  first_seen?: string;
+  last_seen?: string;
 }
 
 // Log an error to agentlog - call this directly or use with your logger (pino, winston, etc.)
 export function logError(
   errorType: string,
   message: string,
-  context?: Record<string, unknown>
+  context?: Record<string, unknown>,
+  tags?: string[]
 ): void {
   if (isProduction) return;
 
@@ -996,8 +2359,18 @@ export function logError(
     source: 'worker',
     error_type: errorType,
     message: String(message).slice(0, 500),
+    project,
+    environment,
+    host,
+    user,
+    agent,
   };
 
+  const allTags = [...new Set([...defaultTags, ...(tags || [])])];
+  if (allTags.length) {
+    entry.tags = allTags;
+  }
+
   if (context) {
     // Truncate stack_trace if present
     if (typeof context.stack_trace === 'string') {
@@ -1006,6 +2379,164 @@ export function logError(
     entry.context = context;
   }
 
+  if (admitRepeat(entry)) appendEntry(entry);
+}
+
+// Storm suppression: the first of an error is written as it happens; repeats
+// within repeatWindowMs are held and written as one entry (count, first_seen,
+// last_seen) when the window closes, so a retry loop can't fill the log
+const repeats = new Map<string, { until: number; held?: AgentlogEntry; count: number; first?: string }>();
+
+function admitRepeat(entry: AgentlogEntry): boolean {
+  if (!repeatWindowMs) return true;
+  const key = entry.error_type + '\0' + entry.message;
+  const now = Date.now();
+  const r = repeats.get(key);
+  if (!r || (!r.held && now >= r.until)) {
+    if (repeats.size >= 1024) {
+      for (const [k, v] of repeats) if (!v.held && now >= v.until) repeats.delete(k);
+    }
+    repeats.set(key, { until: now + repeatWindowMs, count: 0 });
+    return true;
+  }
+  if (!r.held) {
+    r.first = entry.timestamp;
+    setTimeout(() => flushRepeats(key), r.until - now).unref();
+  }
+  r.held = entry;
+  r.count++;
+  return false;
+}
+
+// flushRepeats writes the repeats held for key and opens the next window
+function flushRepeats(key: string): void {
+  const r = repeats.get(key);
+  if (!r || !r.held) return;
+  const { held, count, first } = r;
+  r.held = undefined;
+  r.count = 0;
+  r.until = Date.now() + repeatWindowMs;
+  appendEntry(count > 1 ? { ...held, count, first_seen: first, last_seen: held.timestamp } : held);
+}
+
+// Held repeats are written before the process exits
+process.on('exit', () => {
+  for (const key of repeats.keys()) flushRepeats(key);
+});
+
+// Report a slow operation (no-op unless AGENTLOG_SLOW_MS is set and exceeded)
+export function logSlow(
+  perfType: string,
+  message: string,
+  durationMs: number,
+  context?: Record<string, unknown>
+): void {
+  if (isProduction || !slowMs || durationMs < slowMs) return;
+
+  appendEntry({
+    timestamp: new Date().toISOString(),
+    source: 'worker',
+    error_type: perfType,
+    message: String(message).slice(0, 500),
+    project,
+    environment,
+    host,
+    user,
+    agent,
+    tags: defaultTags.length ? defaultTags : undefined,
+    kind: 'perf',
+    duration_ms: Math.round(durationMs),
+    context,
+  });
+}
+
+// Time an async operation, reporting it when slow:
+//   const rows = await timed('SLOW_QUERY', 'orders report', () => db.query(sql));
+export async function timed<T>(perfType: string, message: string, fn: () => Promise<T>): Promise<T> {
+  const start = performance.now();
+  try {
+    return await fn();
+  } finally {
+    logSlow(perfType, message, performance.now() - start);
+  }
+}
+
+// Secrets and personal data are masked before entries are written, as
+// agentlog masks them (AGENTLOG_REDACT=0 turns this off)
+const redactRules: [RegExp, string][] = [
+  [/\bbearer\s+[A-Za-z0-9\-._~+/]{8,}=*/gi, 'Bearer [REDACTED]'],
+  [/\b([a-z][a-z0-9+.-]*:\/\/)[^/\s:@]+:[^/\s@]+@/g, '$1[REDACTED]@'],
+  [/((?:api[_-]?key|apikey|access[_-]?key|access[_-]?token|auth[_-]?token|refresh[_-]?token|client[_-]?secret|secret[_-]?key|secret|passwd|password|pwd|token|private[_-]?key)["']?\s*[:=]\s*["']?)[^\s"'&,;}]{3,}/gi, '$1[REDACTED]'],
+  [/\b(?:sk|pk|rk)_(?:live|test)_[A-Za-z0-9]{8,}\b|\bsk-[A-Za-z0-9_-]{20,}|\bgh[pousr]_[A-Za-z0-9]{20,}\b|\bxox[abprs]-[A-Za-z0-9-]{10,}|\b(?:AKIA|ASIA)[0-9A-Z]{16}\b|\bAIza[0-9A-Za-z_-]{35}\b/g, '[REDACTED]'],
+  [/\beyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}/g, '[REDACTED]'],
+  [/\b[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}\b/g, '[EMAIL]'],
+];
+const secretKey = /^(?:api[_-]?key|apikey|access[_-]?key|access[_-]?token|auth[_-]?token|refresh[_-]?token|client[_-]?secret|secret[_-]?key|secret|passwd|password|pwd|token|private[_-]?key|authorization|cookie|set-cookie)$/i;
+const redactEnabled = process.env.AGENTLOG_REDACT !== '0';
+
+// luhn checks the digits of a card-number-like run
+function luhn(digits: string): boolean {
+  let sum = 0;
+  for (let i = 0; i < digits.length; i++) {
+    let d = Number(digits[digits.length - 1 - i]);
+    if (i % 2 === 1) d = d * 2 > 9 ? d * 2 - 9 : d * 2;
+    sum += d;
+  }
+  return sum % 10 === 0;
+}
+
+// redact masks every string in a message or context value
+function redact(value: unknown): unknown {
+  if (typeof value === 'string') {
+    const masked = redactRules.reduce((s, [re, mask]) => s.replace(re, mask), value);
+    return masked.replace(/\b[3-6](?:[ -]?\d){12,18}\b/g, (m) => (luhn(m.replace(/\D/g, '')) ? '[CARD]' : m));
+  }
+  if (Array.isArray(value)) return value.map(redact);
+  if (value && typeof value === 'object') {
+    return Object.fromEntries(Object.entries(value).map(([k, v]) =>
+      [k, typeof v === 'string' && v && secretKey.test(k) ? '[REDACTED]' : redact(v)]));
+  }
+  return value;
+}
+
+// Context keys kept, from "context" in .agentlog/config.json: allow lists
+// the top-level keys kept, deny the keys ("headers") or dotted paths
+// ("request.body") dropped
+const contextPolicy = ((): { allow: string[]; deny: string[] } => {
+  try {
+    const { allow = [], deny = [] } = JSON.parse(readFileSync('.agentlog/config.json', 'utf-8')).context || {};
+    const lower = (keys: unknown[]) => keys.map((k) => String(k).trim().toLowerCase()).filter(Boolean);
+    return { allow: lower(allow), deny: lower(deny) };
+  } catch {
+    return { allow: [], deny: [] };
+  }
+})();
+
+// filterContext drops the context keys the policy excludes
+function filterContext(ctx: Record<string, unknown> | undefined, prefix = ''): Record<string, unknown> | undefined {
+  if (!ctx || (!contextPolicy.allow.length && !contextPolicy.deny.length)) return ctx;
+  const kept: Record<string, unknown> = {};
+  for (const [k, v] of Object.entries(ctx)) {
+    const key = k.toLowerCase();
+    if (!prefix && contextPolicy.allow.length && !contextPolicy.allow.includes(key)) continue;
+    if (contextPolicy.deny.includes(key) || contextPolicy.deny.includes(prefix + key)) continue;
+    if (v && typeof v === 'object' && !Array.isArray(v) && Object.keys(v).length) {
+      const nested = filterContext(v as Record<string, unknown>, prefix + key + '.');
+      if (nested) kept[k] = nested;
+      continue;
+    }
+    kept[k] = v;
+  }
+  return Object.keys(kept).length ? kept : undefined;
+}
+
+// Append an entry, creating .agentlog/ (and its .gitignore line) on first use
+function appendEntry(entry: AgentlogEntry): void {
+  if (testRunId) entry = { ...entry, context: { test_run_id: testRunId, ...entry.context } };
+  entry = { ...entry, context: filterContext(entry.context) };
+  if (redactEnabled) {
+    entry = { ...entry, message: redact(entry.message) as string, context: redact(entry.context) as Record<string, unknown> | undefined };
+  }
   try {
     if (!existsSync('.agentlog')) {
       mkdirSync('.agentlog', { recursive: true });
@@ -1026,7 +2557,14 @@ export function logError(
         writeFileSync(gitignorePath, newContent);
       }
     }
-    appendFileSync(AGENTLOG_FILE, JSON.stringify(entry) + '\n');
+    const fd = openSync(AGENTLOG_FILE, 'a');
+    try {
+      writeSync(fd, JSON.stringify(entry) + '\n');
+      // AGENTLOG_FSYNC=1 flushes each entry to disk, so a crash can't lose it
+      if (process.env.AGENTLOG_FSYNC) fsyncSync(fd);
+    } finally {
+      closeSync(fd);
+    }
   } catch {
     // Silently fail - don't crash the app for logging
   }
@@ -1051,6 +2589,23 @@ export function initAgentlog(): void {
       stack_trace: stack,
     });
   });
+
+  // Warnings are reported once per name and message, with context.level
+  // "warning" so they can be told apart from failures
+  if (captureWarnings) {
+    const seenWarnings = new Set<string>();
+    process.on('warning', (warning: Error & { code?: string }) => {
+      const key = warning.name + ':' + warning.message;
+      if (seenWarnings.has(key)) return;
+      seenWarnings.add(key);
+      logError(warning.name === 'DeprecationWarning' ? 'DEPRECATION_WARNING' : 'PROCESS_WARNING', warning.message, {
+        level: 'warning',
+        warning: warning.name,
+        code: warning.code,
+        stack_trace: warning.stack,
+      });
+    });
+  }
 }
 
 // Pino integration example:
@@ -1069,3 +2624,127 @@ export function initAgentlog(): void {
 // Call at application startup
 initAgentlog();
 `
+
+// consoleCaptureTS reports console.error/warn from the TypeScript snippets
+const consoleCaptureTS = `// Console capture (init --capture-console): console.error/warn also report
+// entries, each distinct message once a minute and at most 10 a minute
+const _consoleSeen = new Set<string>();
+let _consoleWindow = 0;
+let _consoleSent = 0;
+const _consoleText = (a: unknown): string => {
+  if (typeof a === 'string') return a;
+  if (a instanceof Error) return a.message;
+  try { return JSON.stringify(a) ?? String(a); } catch { return String(a); }
+};
+(['error', 'warn'] as const).forEach((level) => {
+  const original = console[level];
+  console[level] = (...args: unknown[]) => {
+    original.apply(console, args);
+    const msg = args.map(_consoleText).join(' ');
+    const now = Date.now();
+    if (now - _consoleWindow > 60000) {
+      _consoleWindow = now;
+      _consoleSent = 0;
+      _consoleSeen.clear();
+    }
+    if (_consoleSent >= 10 || _consoleSeen.has(level + msg)) return;
+    _consoleSeen.add(level + msg);
+    _consoleSent++;
+    const err = args.find((a): a is Error => a instanceof Error);
+    _sendLog(level === 'error' ? 'CONSOLE_ERROR' : 'CONSOLE_WARN', msg, { level, stack_trace: err?.stack?.slice(0, 2048) });
+  };
+});
+`
+
+// consoleCaptureJS reports console.error/warn from the Rails snippets
+const consoleCaptureJS = `// Console capture (init --capture-console): console.error/warn also report
+// entries, each distinct message once a minute and at most 10 a minute
+const consoleSeen = new Set();
+let consoleWindow = 0;
+let consoleSent = 0;
+const consoleText = (a) => {
+  if (typeof a === 'string') return a;
+  if (a instanceof Error) return a.message;
+  try { return JSON.stringify(a) ?? String(a); } catch { return String(a); }
+};
+['error', 'warn'].forEach((level) => {
+  const original = console[level];
+  console[level] = (...args) => {
+    original.apply(console, args);
+    const msg = args.map(consoleText).join(' ');
+    const now = Date.now();
+    if (now - consoleWindow > 60000) {
+      consoleWindow = now;
+      consoleSent = 0;
+      consoleSeen.clear();
+    }
+    if (consoleSent >= 10 || consoleSeen.has(level + msg)) return;
+    consoleSeen.add(level + msg);
+    consoleSent++;
+    const err = args.find((a) => a instanceof Error);
+    log(level === 'error' ? 'CONSOLE_ERROR' : 'CONSOLE_WARN', msg, { level, stack_trace: err?.stack?.slice(0, 2048) });
+  };
+});
+`
+
+// networkCaptureTS reports failed requests from the TypeScript snippets
+const networkCaptureTS = `// Network capture (init --capture-network): failed fetch/XHR requests
+// (network errors and 5xx responses) are reported as NETWORK_ERROR
+const _networkFailed = (method: string, url: string, status: number, error?: string) => {
+  if (url.includes('/__agentlog')) return;
+  const what = status ? 'HTTP ' + status : (error || 'network error');
+  _sendLog('NETWORK_ERROR', method + ' ' + url + ' failed: ' + what, { method, url, status, error }, { endpoint: url.split('?')[0] });
+};
+const _originalFetch = window.fetch;
+window.fetch = async (input: RequestInfo | URL, init?: RequestInit) => {
+  const method = (init?.method || (input instanceof Request ? input.method : 'GET')).toUpperCase();
+  const url = input instanceof Request ? input.url : String(input);
+  try {
+    const res = await _originalFetch(input, init);
+    if (res.status >= 500) _networkFailed(method, url, res.status);
+    return res;
+  } catch (err) {
+    if ((err as Error)?.name !== 'AbortError') _networkFailed(method, url, 0, String(err));
+    throw err;
+  }
+};
+const _originalOpen = XMLHttpRequest.prototype.open;
+XMLHttpRequest.prototype.open = function (this: XMLHttpRequest, method: string, url: string | URL, ...rest: unknown[]) {
+  const m = method.toUpperCase();
+  this.addEventListener('load', () => { if (this.status >= 500) _networkFailed(m, String(url), this.status); });
+  this.addEventListener('error', () => _networkFailed(m, String(url), 0));
+  this.addEventListener('timeout', () => _networkFailed(m, String(url), 0, 'timeout'));
+  return (_originalOpen as (...args: unknown[]) => void).call(this, method, url, ...rest);
+} as typeof XMLHttpRequest.prototype.open;
+`
+
+// networkCaptureJS reports failed requests from the Rails snippets
+const networkCaptureJS = `// Network capture (init --capture-network): failed fetch/XHR requests
+// (network errors and 5xx responses) are reported as NETWORK_ERROR
+const networkFailed = (method, url, status, error) => {
+  if (url.includes('/__agentlog')) return;
+  const what = status ? 'HTTP ' + status : (error || 'network error');
+  log('NETWORK_ERROR', method + ' ' + url + ' failed: ' + what, { method, url, status, error }, { endpoint: url.split('?')[0] });
+};
+const originalFetch = window.fetch;
+window.fetch = async (input, init) => {
+  const method = (init?.method || (input instanceof Request ? input.method : 'GET')).toUpperCase();
+  const url = input instanceof Request ? input.url : String(input);
+  try {
+    const res = await originalFetch(input, init);
+    if (res.status >= 500) networkFailed(method, url, res.status);
+    return res;
+  } catch (err) {
+    if (err?.name !== 'AbortError') networkFailed(method, url, 0, String(err));
+    throw err;
+  }
+};
+const originalOpen = XMLHttpRequest.prototype.open;
+XMLHttpRequest.prototype.open = function (method, url, ...rest) {
+  const m = method.toUpperCase();
+  this.addEventListener('load', () => { if (this.status >= 500) networkFailed(m, String(url), this.status); });
+  this.addEventListener('error', () => networkFailed(m, String(url), 0));
+  this.addEventListener('timeout', () => networkFailed(m, String(url), 0, 'timeout'));
+  return originalOpen.call(this, method, url, ...rest);
+};
+`
@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// composeFiles are the Compose file names, in the order docker compose
+// looks for them
+var composeFiles = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// devcontainerFiles are where a dev container's config can live
+var devcontainerFiles = []string{".devcontainer/devcontainer.json", ".devcontainer.json"}
+
+// defaultContainerDir is where a service is assumed to run when its Compose
+// file names neither a working_dir nor a project mount
+const defaultContainerDir = "/app"
+
+// DockerSetup is what init --docker found and the config it generated to
+// let containers reach the host project's .agentlog/
+type DockerSetup struct {
+	ComposeFile        string   `json:"compose_file,omitempty"`
+	Services           []string `json:"services,omitempty"` // services wired up: those built from the project
+	Shared             []string `json:"shared,omitempty"`   // of those, the ones already mounting the project where they run
+	ComposeStanza      string   `json:"compose_stanza,omitempty"`
+	OverrideFile       string   `json:"override_file,omitempty"` // where --install writes ComposeStanza
+	DevcontainerFile   string   `json:"devcontainer_file,omitempty"`
+	DevcontainerStanza string   `json:"devcontainer_stanza,omitempty"`
+	URL                string   `json:"url,omitempty"` // with --remote, the serve URL as containers reach it
+}
+
+// composeService is what init needs to know about one Compose service
+type composeService struct {
+	Name       string
+	Build      bool   // built from the project, so it runs the project's code
+	WorkingDir string // working_dir, if set
+	Mount      string // where the project directory is bind-mounted, if it is
+}
+
+// dir is where the service's relative .agentlog/ writes land
+func (s composeService) dir() string {
+	return firstNonEmpty(s.WorkingDir, s.Mount, defaultContainerDir)
+}
+
+// shared reports whether the service already writes into the host
+// project: it mounts the project and runs where it's mounted. With no
+// working_dir, the image's WORKDIR is assumed to be the mount.
+func (s composeService) shared() bool {
+	return s.Mount != "" && (s.WorkingDir == "" || path.Clean(s.WorkingDir) == path.Clean(s.Mount))
+}
+
+// parseComposeServices reads the services from a Compose file. It's a line
+// scanner rather than a YAML parser: it understands the block style Compose
+// files are written in, and short-syntax volumes.
+func parseComposeServices(content string) []composeService {
+	var services []composeService
+	topLevel := ""
+	serviceIndent := -1
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if indent == 0 {
+			topLevel = strings.TrimSuffix(trimmed, ":")
+			serviceIndent = -1
+			continue
+		}
+		if topLevel != "services" {
+			continue
+		}
+		if serviceIndent < 0 {
+			serviceIndent = indent
+		}
+		if indent == serviceIndent {
+			if name, ok := strings.CutSuffix(trimmed, ":"); ok {
+				services = append(services, composeService{Name: strings.Trim(name, `"'`)})
+			}
+			continue
+		}
+		if indent < serviceIndent || len(services) == 0 {
+			continue
+		}
+		s := &services[len(services)-1]
+		switch {
+		case strings.HasPrefix(trimmed, "build:"):
+			s.Build = true
+		case strings.HasPrefix(trimmed, "working_dir:"):
+			s.WorkingDir = strings.Trim(strings.TrimSpace(strings.TrimPrefix(trimmed, "working_dir:")), `"'`)
+		case strings.HasPrefix(trimmed, "- "):
+			volume := strings.Trim(strings.TrimSpace(strings.TrimPrefix(trimmed, "- ")), `"'`)
+			parts := strings.Split(volume, ":")
+			if len(parts) >= 2 && (parts[0] == "." || parts[0] == "./") && s.Mount == "" {
+				s.Mount = parts[1]
+			}
+		}
+	}
+	return services
+}
+
+// containerURL is the serve URL as seen from inside a container, where
+// localhost is the container itself
+func containerURL(remote string) string {
+	u, err := url.Parse(remote)
+	if err != nil {
+		return remote
+	}
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+		port := u.Port()
+		u.Host = "host.docker.internal"
+		if port != "" {
+			u.Host += ":" + port
+		}
+	}
+	return u.String()
+}
+
+// planDocker finds the project's Compose file and dev container config and
+// generates what each needs to add so processes inside write to the host's
+// .agentlog/, or with remote, post to the host's 'agentlog serve'
+func planDocker(files initFiles, dir, remote string) (*DockerSetup, error) {
+	docker := &DockerSetup{}
+	if remote != "" {
+		docker.URL = containerURL(remote)
+	}
+	project := projectName(dir)
+
+	for _, name := range composeFiles {
+		data, err := files.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		docker.ComposeFile = name
+		ext := filepath.Ext(name)
+		docker.OverrideFile = strings.TrimSuffix(name, ext) + ".override" + ext
+
+		services := parseComposeServices(string(data))
+		var built []composeService
+		for _, s := range services {
+			if s.Build {
+				built = append(built, s)
+			}
+		}
+		if len(built) == 0 {
+			built = services // nothing is built here; the project may run in any of them
+		}
+		if len(built) == 0 {
+			return nil, fmt.Errorf("no services found in %s", name)
+		}
+		for _, s := range built {
+			docker.Services = append(docker.Services, s.Name)
+			if s.shared() {
+				docker.Shared = append(docker.Shared, s.Name)
+			}
+		}
+		docker.ComposeStanza = composeStanza(built, project, docker.URL)
+		break
+	}
+
+	for _, name := range devcontainerFiles {
+		data, err := files.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			continue
+		}
+		docker.DevcontainerFile = name
+		docker.DevcontainerStanza = devcontainerStanza(string(data), project, docker.URL)
+		break
+	}
+
+	if docker.ComposeFile == "" && docker.DevcontainerFile == "" {
+		return nil, fmt.Errorf("--docker found no %s or %s", strings.Join(composeFiles, ", "), strings.Join(devcontainerFiles, ", "))
+	}
+	return docker, nil
+}
+
+// composeStanza is a Compose override wiring services to agentlog: a
+// mount of .agentlog/ where a service runs, unless it already mounts the
+// project there or posts to serve instead, and the environment
+func composeStanza(services []composeService, project, serveURL string) string {
+	var sb strings.Builder
+	sb.WriteString("# agentlog: lets containers reach the host's .agentlog/ (agentlog init --docker)\n")
+	sb.WriteString("services:\n")
+	for _, s := range services {
+		fmt.Fprintf(&sb, "  %s:\n", s.Name)
+		if serveURL == "" && !s.shared() {
+			sb.WriteString("    volumes:\n")
+			fmt.Fprintf(&sb, "      - ./.agentlog:%s\n", path.Join(s.dir(), ".agentlog"))
+		}
+		sb.WriteString("    environment:\n")
+		fmt.Fprintf(&sb, "      AGENTLOG_PROJECT: %s\n", project)
+		if serveURL != "" {
+			fmt.Fprintf(&sb, "      AGENTLOG_URL: %s\n", serveURL)
+			sb.WriteString("    extra_hosts:\n")
+			sb.WriteString("      - \"host.docker.internal:host-gateway\"\n")
+		}
+	}
+	return sb.String()
+}
+
+// devcontainerStanza is what to merge into devcontainer.json. The default
+// workspace mount already includes .agentlog/, so only the environment is
+// needed, plus the host's address when posting to serve on Linux.
+func devcontainerStanza(content, project, serveURL string) string {
+	var sb strings.Builder
+	sb.WriteString("// agentlog (agentlog init --docker): merge into devcontainer.json\n")
+	sb.WriteString("\"containerEnv\": {\n")
+	if serveURL != "" {
+		fmt.Fprintf(&sb, "  \"AGENTLOG_PROJECT\": %q,\n", project)
+		fmt.Fprintf(&sb, "  \"AGENTLOG_URL\": %q\n", serveURL)
+	} else {
+		fmt.Fprintf(&sb, "  \"AGENTLOG_PROJECT\": %q\n", project)
+	}
+	sb.WriteString("}")
+	if serveURL != "" && !strings.Contains(content, "dockerComposeFile") {
+		sb.WriteString(",\n\"runArgs\": [\"--add-host=host.docker.internal:host-gateway\"]")
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// installDocker writes the Compose override, unless the project already
+// has one; then the stanza is printed to merge in by hand
+func installDocker(files initFiles, dir string, docker *DockerSetup) ([]InstallAction, error) {
+	if docker.ComposeFile == "" {
+		return nil, nil
+	}
+	overridePath := filepath.Join(dir, docker.OverrideFile)
+	if !files.NotExist(overridePath) {
+		return []InstallAction{{Path: docker.OverrideFile, Operation: "skip"}}, nil
+	}
+	if err := files.WriteFile(overridePath, []byte(docker.ComposeStanza)); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", docker.OverrideFile, err)
+	}
+	return []InstallAction{{Path: docker.OverrideFile, Operation: "create"}}, nil
+}
+
+// printDockerSetup prints the container wiring init --docker generated
+func printDockerSetup(docker *DockerSetup, installed []InstallAction) {
+	written := false
+	for _, a := range installed {
+		written = written || (a.Path == docker.OverrideFile && a.Operation == "create")
+	}
+
+	if docker.ComposeFile != "" {
+		if len(docker.Shared) > 0 && docker.URL == "" {
+			fmt.Printf("Already mounting the project where they run, so writing to .agentlog/ as is: %s\n", strings.Join(docker.Shared, ", "))
+		}
+		if written {
+			fmt.Printf("Wired %s into agentlog in %s (docker compose merges it with %s).\n", strings.Join(docker.Services, ", "), docker.OverrideFile, docker.ComposeFile)
+		} else {
+			fmt.Printf("Add this to %s (docker compose merges it with %s):\n\n", docker.OverrideFile, docker.ComposeFile)
+			fmt.Println(docker.ComposeStanza)
+		}
+	}
+	if docker.DevcontainerFile != "" {
+		fmt.Printf("Add this to %s:\n\n", docker.DevcontainerFile)
+		fmt.Print(docker.DevcontainerStanza)
+		if docker.ComposeFile != "" && docker.URL == "" {
+			fmt.Println()
+		}
+	}
+	if docker.URL != "" {
+		fmt.Printf("Containers post to %s; run 'agentlog serve --addr 0.0.0.0:7654' on the host.\n", docker.URL)
+	}
+	fmt.Println()
+}
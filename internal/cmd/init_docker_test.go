@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testCompose = `version: "3.8"
+services:
+  api:
+    build: .
+    working_dir: /srv
+    ports:
+      - "3000:3000"
+  web:
+    build:
+      context: ./web
+    volumes:
+      - .:/code
+  db:
+    image: postgres:16
+volumes:
+  pgdata:
+`
+
+func TestParseComposeServices(t *testing.T) {
+	services := parseComposeServices(testCompose)
+	if len(services) != 3 {
+		t.Fatalf("expected 3 services, got %+v", services)
+	}
+	api, web, db := services[0], services[1], services[2]
+	if api.Name != "api" || !api.Build || api.WorkingDir != "/srv" || api.Mount != "" || api.shared() {
+		t.Errorf("api = %+v", api)
+	}
+	if web.Name != "web" || !web.Build || web.Mount != "/code" || !web.shared() {
+		t.Errorf("web = %+v", web)
+	}
+	if db.Name != "db" || db.Build {
+		t.Errorf("db = %+v", db)
+	}
+}
+
+func TestInit_DockerCompose(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "docker-compose.yml"), []byte(testCompose), 0644)
+
+	result, err := initWithOptions(tmpDir, initOptions{Stacks: []string{"go"}, Install: true, Docker: true})
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	docker := result.Docker
+	if docker == nil || docker.ComposeFile != "docker-compose.yml" || docker.OverrideFile != "docker-compose.override.yml" {
+		t.Fatalf("Docker = %+v", docker)
+	}
+	if strings.Join(docker.Services, ",") != "api,web" || strings.Join(docker.Shared, ",") != "web" {
+		t.Errorf("Services = %v, Shared = %v; want api,web and web", docker.Services, docker.Shared)
+	}
+
+	override, err := os.ReadFile(filepath.Join(tmpDir, "docker-compose.override.yml"))
+	if err != nil {
+		t.Fatalf("override not written: %v", err)
+	}
+	content := string(override)
+	if strings.Count(content, "- ./.agentlog:") != 1 || !strings.Contains(content, "- ./.agentlog:/srv/.agentlog") {
+		t.Errorf("only api needs the mount, at its working_dir:\n%s", content)
+	}
+	if strings.Count(content, "AGENTLOG_PROJECT: "+filepath.Base(tmpDir)) != 2 || strings.Contains(content, "db:") {
+		t.Errorf("api and web (not db) should get AGENTLOG_PROJECT:\n%s", content)
+	}
+
+	// An override the project already has is left alone
+	os.WriteFile(filepath.Join(tmpDir, "docker-compose.override.yml"), []byte("services: {}\n"), 0644)
+	result, err = initWithOptions(tmpDir, initOptions{Stacks: []string{"go"}, Install: true, Docker: true})
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if !containsAction(result.InstallActions, "docker-compose.override.yml", "skip") {
+		t.Errorf("existing override should be skipped, got %+v", result.InstallActions)
+	}
+}
+
+func TestInit_DockerRemoteDevcontainer(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, ".devcontainer"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, ".devcontainer", "devcontainer.json"), []byte(`{"image": "mcr.microsoft.com/devcontainers/go"}`), 0644)
+
+	result, err := initWithOptions(tmpDir, initOptions{Stacks: []string{"go"}, Docker: true, Remote: "http://localhost:7654"})
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	docker := result.Docker
+	if docker.URL != "http://host.docker.internal:7654/__agentlog" {
+		t.Errorf("URL = %q, want the host's address from inside the container", docker.URL)
+	}
+	if !strings.Contains(docker.DevcontainerStanza, `"AGENTLOG_URL": "http://host.docker.internal:7654/__agentlog"`) ||
+		!strings.Contains(docker.DevcontainerStanza, "host-gateway") {
+		t.Errorf("devcontainer stanza should set AGENTLOG_URL and map the host:\n%s", docker.DevcontainerStanza)
+	}
+	if docker.ComposeFile != "" {
+		t.Errorf("no Compose file exists, got %q", docker.ComposeFile)
+	}
+}
+
+func TestInit_DockerNothingFound(t *testing.T) {
+	if _, err := initWithOptions(t.TempDir(), initOptions{Stacks: []string{"go"}, Docker: true}); err == nil {
+		t.Error("expected an error without a Compose file or devcontainer.json")
+	}
+}
+
+func containsAction(actions []InstallAction, path, operation string) bool {
+	for _, a := range actions {
+		if a.Path == path && a.Operation == operation {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/agentlog/agentlog/internal/logfile"
+)
+
+// initFiles is how init touches the project: directly, or for --dry-run
+// by recording what it would change
+type initFiles interface {
+	ReadFile(path string) ([]byte, error)
+	NotExist(path string) bool
+	MkdirAll(path string) error
+	WriteFile(path string, data []byte) error
+}
+
+// diskFiles writes to the project
+type diskFiles struct{}
+
+func (diskFiles) ReadFile(path string) ([]byte, error) { return os.ReadFile(path) }
+
+func (diskFiles) NotExist(path string) bool {
+	_, err := os.Stat(path)
+	return os.IsNotExist(err)
+}
+
+func (diskFiles) MkdirAll(path string) error { return os.MkdirAll(path, 0755) }
+
+func (diskFiles) WriteFile(path string, data []byte) error {
+	return logfile.WriteFile(path, data, 0644)
+}
+
+// PlannedChange is one file init --dry-run would create or change
+type PlannedChange struct {
+	Path      string `json:"path"`
+	Operation string `json:"operation"`      // "create", "append", or "edit"
+	Diff      string `json:"diff,omitempty"` // for files that exist, the lines init would add or change
+	Lines     int    `json:"lines,omitempty"`
+}
+
+// planFiles reads the project but keeps writes in memory, so later steps
+// see earlier ones (a directory created, a file appended to twice)
+type planFiles struct {
+	dir     string
+	written map[string][]byte
+	dirs    map[string]bool
+}
+
+func newPlanFiles(dir string) *planFiles {
+	return &planFiles{dir: dir, written: make(map[string][]byte), dirs: make(map[string]bool)}
+}
+
+func (p *planFiles) ReadFile(path string) ([]byte, error) {
+	if data, ok := p.written[path]; ok {
+		return data, nil
+	}
+	return os.ReadFile(path)
+}
+
+func (p *planFiles) NotExist(path string) bool {
+	if _, ok := p.written[path]; ok || p.dirs[path] {
+		return false
+	}
+	return diskFiles{}.NotExist(path)
+}
+
+func (p *planFiles) MkdirAll(path string) error {
+	for ; path != p.dir && path != filepath.Dir(path); path = filepath.Dir(path) {
+		if !p.NotExist(path) {
+			break
+		}
+		p.dirs[path] = true
+	}
+	return nil
+}
+
+func (p *planFiles) WriteFile(path string, data []byte) error {
+	p.written[path] = data
+	return nil
+}
+
+// changes lists the planned writes by path, with a diff for each file
+// that exists now
+func (p *planFiles) changes() []PlannedChange {
+	var paths []string
+	for path := range p.written {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var changes []PlannedChange
+	for _, path := range paths {
+		rel, err := filepath.Rel(p.dir, path)
+		if err != nil {
+			rel = path
+		}
+		data := string(p.written[path])
+		change := PlannedChange{Path: filepath.ToSlash(rel), Operation: "create", Lines: strings.Count(data, "\n")}
+
+		old, err := os.ReadFile(path)
+		if err == nil {
+			if string(old) == data {
+				continue
+			}
+			change.Operation = "edit"
+			if strings.HasPrefix(data, string(old)) {
+				change.Operation = "append"
+			}
+			change.Diff = lineDiff(change.Path, string(old), data)
+			change.Lines = 0
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// lineDiff is a unified diff of one changed region: the lines between the
+// longest common prefix and suffix of old and new, with 3 lines of context.
+// That covers init's edits, which each append or insert one block.
+func lineDiff(path, old, new string) string {
+	a, b := splitLines(old), splitLines(new)
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	const context = 3
+	start := max(prefix-context, 0)
+	endA := min(len(a)-suffix+context, len(a))
+	endB := min(len(b)-suffix+context, len(b))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- a/%s\n+++ b/%s\n", path, path)
+	fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(start, endA-start), hunkRange(start, endB-start))
+	for _, line := range a[start:prefix] {
+		sb.WriteString(" " + line + "\n")
+	}
+	for _, line := range a[prefix : len(a)-suffix] {
+		sb.WriteString("-" + line + "\n")
+	}
+	for _, line := range b[prefix : len(b)-suffix] {
+		sb.WriteString("+" + line + "\n")
+	}
+	for _, line := range a[len(a)-suffix : endA] {
+		sb.WriteString(" " + line + "\n")
+	}
+	return sb.String()
+}
+
+// hunkRange formats a unified diff range (1-based start, line count)
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// printInitPlan prints what init --dry-run would do
+func printInitPlan(w io.Writer, result *InitResult) {
+	fmt.Fprintf(w, "agentlog init --dry-run (%s): nothing was changed.\n", stackTitle(result))
+	if len(result.Plan) == 0 {
+		fmt.Fprintln(w, "\nNothing to do: agentlog is already set up here.")
+		return
+	}
+
+	fmt.Fprintln(w, "\nWould change:")
+	for _, c := range result.Plan {
+		if c.Diff == "" {
+			fmt.Fprintf(w, "  %-7s %s (%d lines)\n", c.Operation, c.Path, c.Lines)
+		} else {
+			fmt.Fprintf(w, "  %-7s %s\n", c.Operation, c.Path)
+		}
+	}
+	for _, a := range result.InstallActions {
+		if a.Operation == "skip" {
+			fmt.Fprintf(w, "  skip    %s (exists; merge the hook in by hand)\n", a.Path)
+		}
+	}
+
+	for _, c := range result.Plan {
+		if c.Diff != "" {
+			fmt.Fprintln(w)
+			fmt.Fprint(w, c.Diff)
+		}
+	}
+	fmt.Fprintln(w, "\nRun without --dry-run to apply.")
+}
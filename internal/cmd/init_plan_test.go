@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func railsFixture(t *testing.T) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, "config"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "app", "controllers"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "app", "javascript"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "config", "routes.rb"), []byte("Rails.application.routes.draw do\nend\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "app", "javascript", "application.js"), []byte("// Entry point\n"), 0644)
+	return tmpDir
+}
+
+func findPlanned(plan []PlannedChange, path string) *PlannedChange {
+	for i := range plan {
+		if plan[i].Path == path {
+			return &plan[i]
+		}
+	}
+	return nil
+}
+
+func TestInitDryRun_ChangesNothing(t *testing.T) {
+	tmpDir := railsFixture(t)
+
+	result, err := initWithOptions(tmpDir, initOptions{Stacks: []string{"ruby"}, Install: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("init --dry-run failed: %v", err)
+	}
+	if !result.DryRun || result.Installed {
+		t.Errorf("DryRun = %v, Installed = %v; want true, false", result.DryRun, result.Installed)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, ".agentlog")); !os.IsNotExist(err) {
+		t.Error(".agentlog should not be created by --dry-run")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, ".gitignore")); !os.IsNotExist(err) {
+		t.Error(".gitignore should not be created by --dry-run")
+	}
+	routes, _ := os.ReadFile(filepath.Join(tmpDir, "config", "routes.rb"))
+	if string(routes) != "Rails.application.routes.draw do\nend\n" {
+		t.Errorf("routes.rb changed by --dry-run:\n%s", routes)
+	}
+}
+
+func TestInitDryRun_Plan(t *testing.T) {
+	tmpDir := railsFixture(t)
+
+	result, err := initWithOptions(tmpDir, initOptions{Stacks: []string{"ruby"}, Install: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("init --dry-run failed: %v", err)
+	}
+
+	routes := findPlanned(result.Plan, "config/routes.rb")
+	if routes == nil || routes.Operation != "edit" {
+		t.Fatalf("plan should edit config/routes.rb, got %+v", result.Plan)
+	}
+	if !strings.Contains(routes.Diff, "+  post '/__agentlog'") || !strings.Contains(routes.Diff, " Rails.application.routes.draw do") {
+		t.Errorf("routes.rb diff should add the route in context:\n%s", routes.Diff)
+	}
+
+	app := findPlanned(result.Plan, "app/javascript/application.js")
+	if app == nil || app.Operation != "append" {
+		t.Errorf("plan should append to application.js, got %+v", app)
+	}
+
+	for _, path := range []string{".agentlog/errors.jsonl", ".agentlog/config.json", ".gitignore", "app/controllers/agentlog_controller.rb"} {
+		c := findPlanned(result.Plan, path)
+		if c == nil || c.Operation != "create" {
+			t.Errorf("plan should create %s, got %+v", path, c)
+		}
+	}
+}
+
+func TestInitDryRun_TypeScript(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte("{}"), 0644)
+
+	result, err := initWithOptions(tmpDir, initOptions{Install: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("init --dry-run failed: %v", err)
+	}
+	c := findPlanned(result.Plan, ".agentlog/capture.ts")
+	if c == nil || c.Operation != "create" || c.Lines == 0 {
+		t.Errorf("plan should create .agentlog/capture.ts, got %+v", c)
+	}
+}
+
+func TestInitDryRun_AfterInit(t *testing.T) {
+	tmpDir := railsFixture(t)
+	if _, err := initWithOptions(tmpDir, initOptions{Stacks: []string{"ruby"}, Install: true}); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+
+	result, err := initWithOptions(tmpDir, initOptions{Stacks: []string{"ruby"}, Install: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("init --dry-run failed: %v", err)
+	}
+	if len(result.Plan) != 0 {
+		t.Errorf("plan after init should be empty, got %+v", result.Plan)
+	}
+
+	var out bytes.Buffer
+	printInitPlan(&out, result)
+	if !strings.Contains(out.String(), "Nothing to do") {
+		t.Errorf("expected nothing to do, got:\n%s", out.String())
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\n"
+	new := "a\nb\nc\nd\nX\ne\nf\n"
+	want := "--- a/f.txt\n+++ b/f.txt\n@@ -2,5 +2,6 @@\n b\n c\n d\n+X\n e\n f\n"
+	if got := lineDiff("f.txt", old, new); got != want {
+		t.Errorf("lineDiff =\n%s\nwant\n%s", got, want)
+	}
+
+	appended := lineDiff("f.txt", "a\n", "a\nb\n")
+	if !strings.Contains(appended, "@@ -1,1 +1,2 @@\n a\n+b\n") {
+		t.Errorf("append diff =\n%s", appended)
+	}
+}
+
+func TestPrintInitPlan(t *testing.T) {
+	tmpDir := railsFixture(t)
+	result, err := initWithOptions(tmpDir, initOptions{Stacks: []string{"ruby"}, Install: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("init --dry-run failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	printInitPlan(&out, result)
+	s := out.String()
+	for _, want := range []string{"nothing was changed", "Would change:", "edit    config/routes.rb", "+++ b/config/routes.rb", "Run without --dry-run"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("output missing %q:\n%s", want, s)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// remoteEdit replaces the text from Start through the first End after it
+// (just Start when End is empty) in a snippet
+type remoteEdit struct {
+	Start string
+	End   string
+	With  string
+}
+
+// remoteEdits turn each server snippet's file writer into a POST to
+// 'agentlog serve' (init --remote). {{AGENTLOG_URL}}, {{AGENTLOG_HOST}},
+// and {{AGENTLOG_PATH}} are filled from the URL; the token is filled in
+// afterwards, like every other snippet's. Edits whose Start isn't in a
+// project template are skipped.
+var remoteEdits = map[string][]remoteEdit{
+	"node": {
+		{Start: "import { closeSync, fsyncSync, mkdirSync, existsSync, openSync, readFileSync, writeFileSync, writeSync } from 'fs';\n"},
+		{Start: "const AGENTLOG_FILE = '.agentlog/errors.jsonl';", With: "// Entries are posted to 'agentlog serve' (init --remote); AGENTLOG_URL overrides it\nconst AGENTLOG_URL = process.env.AGENTLOG_URL || '{{AGENTLOG_URL}}';"},
+		{Start: "// Append an entry, creating .agentlog/", End: "\n}\n", With: remoteNodeWriter},
+		{Start: "    // Re-throw to let the process crash as expected\n    throw err;", With: "    // Crash as an uncaught exception would, once the entry is posted\n    console.error(err);\n    Promise.allSettled(pendingSends).finally(() => process.exit(1));"},
+	},
+	"go": {
+		{Start: "\t\"encoding/json\"\n", With: "\t\"bytes\"\n\t\"encoding/json\"\n"},
+		{Start: "\t\"fmt\"\n", With: "\t\"fmt\"\n\t\"net/http\"\n"},
+		{Start: "func writeAgentEntry(", End: "\n}\n", With: remoteGoWriter},
+	},
+	"python": {
+		{Start: "def _agentlog_write(entry):", End: "\n\n", With: remotePythonWriter},
+	},
+	rubyScript: {
+		{Start: "require 'fileutils'", With: "require 'net/http'"},
+		{Start: "  FILE = '.agentlog/errors.jsonl'", With: "  # Entries are posted to 'agentlog serve' (init --remote); AGENTLOG_URL overrides it\n  URL = ENV['AGENTLOG_URL'] || '{{AGENTLOG_URL}}'"},
+		{Start: "    FileUtils.mkdir_p(File.dirname(FILE))\n    File.open(FILE, 'a') do |f|\n      f.puts(entry.to_json)\n      f.fsync if ENV['AGENTLOG_FSYNC'] # flush each entry to disk, so a crash can't lose it\n    end\n", With: remoteRubyWriter},
+	},
+	"rust": {
+		{Start: "use std::fs::{OpenOptions, create_dir_all};\nuse std::io::Write;", With: "use std::io::{Read, Write};\nuse std::net::TcpStream;"},
+		{Start: "        let _ = create_dir_all(\".agentlog\");\n        if let Ok(mut file) = OpenOptions::new()", End: "        }\n", With: "        agentlog_send(&entry);\n"},
+		{Start: "    let _ = create_dir_all(\".agentlog\");\n    if let Ok(mut file)", End: "    }\n", With: "    agentlog_send(&entry);\n"},
+		{Start: "// Call at application startup", With: remoteRustWriter + "\n// Call at application startup"},
+	},
+	"react-native": {
+		{Start: "fetch('http://' + host + ':' + AGENTLOG_PORT + '/__agentlog', {", With: "fetch('{{AGENTLOG_URL}}', {"},
+	},
+}
+
+// remoteEndpoint checks an init --remote URL, giving one without a path
+// the serve endpoint's
+func remoteEndpoint(raw string, stacks []StackSetup) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.ContainsAny(raw, "'\"`\\ ") {
+		return "", invalidInput("--remote needs an http or https URL like %s, not '%s'", defaultServeURL, raw)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/__agentlog"
+	}
+	for _, s := range stacks {
+		if s.SnippetLang == "rust" && u.Scheme == "https" {
+			return "", fmt.Errorf("the Rust snippet posts over plain http, so --remote can't be an https URL with --stack rust")
+		}
+	}
+	return u.String(), nil
+}
+
+// remoteSnippet rewrites stack's snippet to post its entries to endpoint
+func remoteSnippet(stack, snippet, endpoint string) string {
+	for _, edit := range remoteEdits[stack] {
+		start := strings.Index(snippet, edit.Start)
+		if start < 0 {
+			continue
+		}
+		end := start + len(edit.Start)
+		if edit.End != "" {
+			i := strings.Index(snippet[end:], edit.End)
+			if i < 0 {
+				continue
+			}
+			end += i + len(edit.End)
+		}
+		snippet = snippet[:start] + edit.With + snippet[end:]
+	}
+
+	u, _ := url.Parse(endpoint)
+	host := u.Host
+	if u.Port() == "" {
+		host += ":80"
+	}
+	return strings.NewReplacer(
+		"{{AGENTLOG_URL}}", endpoint,
+		"{{AGENTLOG_HOST}}", host,
+		"{{AGENTLOG_PATH}}", u.RequestURI(),
+	).Replace(snippet)
+}
+
+const remoteNodeWriter = `// Post an entry to 'agentlog serve'. Posts still in flight when an uncaught
+// exception ends the process are waited for.
+const pendingSends = new Set<Promise<unknown>>();
+function appendEntry(entry: AgentlogEntry): void {
+  if (testRunId) entry = { ...entry, context: { test_run_id: testRunId, ...entry.context } };
+  const send: Promise<unknown> = fetch(AGENTLOG_URL, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json', Authorization: 'Bearer {{AGENTLOG_TOKEN}}' },
+    body: JSON.stringify(entry),
+  })
+    .catch(() => {}) // Silently fail - don't crash the app for logging
+    .finally(() => pendingSends.delete(send));
+  pendingSends.add(send);
+}
+`
+
+const remoteGoWriter = `// writeAgentEntry posts to 'agentlog serve' (init --remote); AGENTLOG_URL
+// overrides where
+func writeAgentEntry(entry map[string]interface{}) {
+	url := os.Getenv("AGENTLOG_URL")
+	if url == "" {
+		url = "{{AGENTLOG_URL}}"
+	}
+	data, _ := json.Marshal(entry)
+	req, err := http.NewRequest("POST", url, bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer {{AGENTLOG_TOKEN}}")
+	client := &http.Client{Timeout: 2 * time.Second}
+	if resp, err := client.Do(req); err == nil {
+		resp.Body.Close()
+	}
+}
+`
+
+const remotePythonWriter = `import urllib.request
+
+# Entries are posted to 'agentlog serve' (init --remote); AGENTLOG_URL overrides it
+AGENTLOG_URL = os.environ.get('AGENTLOG_URL') or '{{AGENTLOG_URL}}'
+
+def _agentlog_write(entry):
+    # Test invocation the entry belongs to, set by 'agentlog capture --test-run'
+    if os.environ.get('AGENTLOG_TEST_RUN_ID'):
+        entry.setdefault("context", {}).setdefault("test_run_id", os.environ['AGENTLOG_TEST_RUN_ID'])
+    request = urllib.request.Request(
+        AGENTLOG_URL,
+        data=json.dumps(entry).encode(),
+        method='POST',
+        headers={'Content-Type': 'application/json', 'Authorization': 'Bearer {{AGENTLOG_TOKEN}}'},
+    )
+    try:
+        urllib.request.urlopen(request, timeout=2).close()
+    except Exception:
+        pass  # never let logging break the app
+
+`
+
+const remoteRubyWriter = `    uri = URI(URL)
+    Net::HTTP.start(uri.host, uri.port, use_ssl: uri.scheme == 'https', open_timeout: 2, read_timeout: 2) do |http|
+      http.post(uri.request_uri, entry.to_json, 'Content-Type' => 'application/json', 'Authorization' => 'Bearer {{AGENTLOG_TOKEN}}')
+    end
+`
+
+const remoteRustWriter = `// Post an entry to 'agentlog serve' (init --remote). Plain HTTP over a
+// TcpStream, so no HTTP client crate is needed.
+fn agentlog_send(entry: &serde_json::Value) {
+    let body = entry.to_string();
+    if let Ok(mut stream) = TcpStream::connect("{{AGENTLOG_HOST}}") {
+        let _ = stream.set_read_timeout(Some(std::time::Duration::from_secs(2)));
+        let _ = write!(
+            stream,
+            "POST {{AGENTLOG_PATH}} HTTP/1.1\r\nHost: {{AGENTLOG_HOST}}\r\nContent-Type: application/json\r\nAuthorization: Bearer {{AGENTLOG_TOKEN}}\r\nContent-Length: {}\r\nConnection: close\r\n\r\n{}",
+            body.len(),
+            body
+        );
+        let _ = stream.read(&mut [0u8; 64]); // wait until serve has the entry
+    }
+}
+`
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agentlog/agentlog/internal/config"
+)
+
+func TestRemoteEndpoint(t *testing.T) {
+	tests := []struct {
+		raw     string
+		stacks  []StackSetup
+		want    string
+		wantErr bool
+	}{
+		{raw: "http://host.docker.internal:7654", want: "http://host.docker.internal:7654/__agentlog"},
+		{raw: "https://logs.example.test/", want: "https://logs.example.test/__agentlog"},
+		{raw: "http://10.0.2.2:7654/custom/path", want: "http://10.0.2.2:7654/custom/path"},
+		{raw: "localhost:7654", wantErr: true},
+		{raw: "ftp://example.test", wantErr: true},
+		{raw: "http://example.test/it's", wantErr: true},
+		{raw: "https://logs.example.test", stacks: []StackSetup{{SnippetLang: "rust"}}, wantErr: true},
+		{raw: "http://logs.example.test", stacks: []StackSetup{{SnippetLang: "rust"}}, want: "http://logs.example.test/__agentlog"},
+	}
+	for _, tt := range tests {
+		got, err := remoteEndpoint(tt.raw, tt.stacks)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("remoteEndpoint(%q) = %q, %v; want %q, error %v", tt.raw, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestInit_RemoteServerSnippets(t *testing.T) {
+	const url = "http://host.docker.internal:7654/__agentlog"
+	for _, stack := range []string{"node", "go", "python", "rust", rubyScript, "react-native"} {
+		t.Run(stack, func(t *testing.T) {
+			result, err := initWithOptions(t.TempDir(), initOptions{Stacks: []string{stack}, Remote: "http://host.docker.internal:7654"})
+			if err != nil {
+				t.Fatalf("init failed: %v", err)
+			}
+			if result.Remote != url {
+				t.Errorf("Remote = %q, want %q", result.Remote, url)
+			}
+			snippet := result.Snippet
+			if strings.Contains(snippet, "errors.jsonl") || strings.Contains(snippet, "{{") {
+				t.Errorf("remote snippet should neither write the file nor keep placeholders:\n%s", snippet)
+			}
+			if !strings.Contains(snippet, "host.docker.internal:7654") || !strings.Contains(snippet, "Bearer ") {
+				t.Errorf("remote snippet should post to the URL with the token:\n%s", snippet)
+			}
+		})
+	}
+}
+
+func TestInit_RemoteBrowserSnippet(t *testing.T) {
+	result, err := initWithOptions(t.TempDir(), initOptions{Stacks: []string{"ruby"}, Remote: "http://10.0.2.2:7654"})
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if result.Transport != "serve" {
+		t.Errorf("Transport = %q, want serve", result.Transport)
+	}
+	if !strings.Contains(result.Snippet, "fetch('http://10.0.2.2:7654/__agentlog'") || strings.Contains(result.Snippet, "fetch('/__agentlog'") {
+		t.Errorf("browser snippet should post to the remote URL:\n%s", result.Snippet)
+	}
+}
+
+func TestInit_RemoteRecordedForUpgrade(t *testing.T) {
+	tmpDir := t.TempDir()
+	if _, err := initWithOptions(tmpDir, initOptions{Stacks: []string{"go"}, Install: true, Remote: "http://192.168.1.20:7654"}); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	capture, _ := os.ReadFile(filepath.Join(tmpDir, ".agentlog", "capture.go"))
+	if !strings.Contains(string(capture), `url = "http://192.168.1.20:7654/__agentlog"`) {
+		t.Errorf("installed capture.go should post to the URL:\n%s", capture)
+	}
+
+	cfg, err := config.Load(tmpDir)
+	if err != nil || cfg.Init == nil || cfg.Init.Remote != "http://192.168.1.20:7654/__agentlog" {
+		t.Fatalf("config should record the remote URL, got %+v (%v)", cfg.Init, err)
+	}
+	report, err := upgradeFiles(tmpDir, false)
+	if err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+	if len(report.Files) != 1 || report.Files[0].Status != "current" {
+		t.Errorf("upgrade should regenerate the remote capture unchanged, got %+v", report.Files)
+	}
+}
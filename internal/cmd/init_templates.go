@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+)
+
+// templateFiles are the templates a team can put in
+// .agentlog/templates/<stack>/ to replace init's built-in ones: the
+// printed snippet, then whatever --install writes for that stack.
+var templateFiles = map[string][]string{
+	"typescript":   {"snippet.ts", "capture.ts"},
+	"node":         {"snippet.ts", "capture.ts"},
+	"react-native": {"snippet.ts", "capture.ts"},
+	"go":           {"snippet.go", "capture.go"},
+	"python":       {"snippet.py", "capture.py"},
+	"rust":         {"snippet.rs", "capture.rs"},
+	"ruby":         {"snippet.rb", "agentlog_controller.rb", "agentlog.rb", "application.js"},
+	rubyScript:     {"snippet.rb", "capture.rb"},
+}
+
+// templatePath is where a stack's template called name lives
+func templatePath(dir, stack, name string) string {
+	return filepath.Join(dir, ".agentlog", "templates", stack, name)
+}
+
+// snippetTemplate returns the project's template for stack's name if it
+// has one, otherwise builtin
+func snippetTemplate(files initFiles, dir, stack, name, builtin string) string {
+	data, err := files.ReadFile(templatePath(dir, stack, name))
+	if err != nil {
+		return builtin
+	}
+	return string(data)
+}
+
+// printedSnippet is the snippet init prints for stack
+func printedSnippet(files initFiles, dir, stack string) string {
+	names, ok := templateFiles[stack]
+	if !ok {
+		return getSnippet(stack)
+	}
+	return snippetTemplate(files, dir, stack, names[0], getSnippet(stack))
+}
+
+// usedTemplates lists the project templates init used for stacks,
+// relative to dir
+func usedTemplates(files initFiles, dir string, stacks []StackSetup) []string {
+	var used []string
+	for _, s := range stacks {
+		for _, name := range templateFiles[s.SnippetLang] {
+			if !files.NotExist(templatePath(dir, s.SnippetLang, name)) {
+				used = append(used, filepath.ToSlash(filepath.Join(".agentlog", "templates", s.SnippetLang, name)))
+			}
+		}
+	}
+	return used
+}
+
+// templateStamp marks the first line of files init creates, with a hash
+// of the rest, so upgrade can tell an outdated file from a locally edited
+// one
+const templateStamp = "agentlog:template "
+
+// templateSum is the stamp hash of a file's content after the stamp line
+func templateSum(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:6])
+}
+
+// stampTemplate prefixes content with its stamp line, commented for the
+// language path is in
+func stampTemplate(path, content string) string {
+	comment := "//"
+	if ext := filepath.Ext(path); ext == ".py" || ext == ".rb" {
+		comment = "#"
+	}
+	return comment + " " + templateStamp + templateSum(content) + "\n" + content
+}
+
+// parseStamp splits a file into its stamp hash and the content after the
+// stamp line. Files without a stamp return ok false and all of content.
+func parseStamp(content string) (sum, body string, ok bool) {
+	first, rest, found := strings.Cut(content, "\n")
+	for _, comment := range []string{"// ", "# "} {
+		if s, isStamp := strings.CutPrefix(first, comment+templateStamp); isStamp && found {
+			return s, rest, true
+		}
+	}
+	return "", content, false
+}
+
+// writeStamped creates a file init owns, stamped for upgrade
+func writeStamped(files initFiles, path, content string) error {
+	return files.WriteFile(path, []byte(stampTemplate(path, content)))
+}
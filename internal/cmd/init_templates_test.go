@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTemplate(t *testing.T, dir, stack, name, content string) {
+	t.Helper()
+	path := templatePath(dir, stack, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInit_ProjectTemplates(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTemplate(t, tmpDir, "typescript", "snippet.ts", "// acme snippet {{AGENTLOG_TOKEN}}\n")
+	writeTemplate(t, tmpDir, "typescript", "capture.ts", "// acme capture, eslint-approved\n")
+
+	result, err := initWithOptions(tmpDir, initOptions{Stacks: []string{"typescript"}, Install: true})
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if !strings.HasPrefix(result.Snippet, "// acme snippet ") || strings.Contains(result.Snippet, tokenPlaceholder) {
+		t.Errorf("printed snippet should come from the template with the token filled in, got %q", result.Snippet)
+	}
+	capture, _ := os.ReadFile(filepath.Join(tmpDir, ".agentlog", "capture.ts"))
+	if _, body, _ := parseStamp(string(capture)); body != "// acme capture, eslint-approved\n" {
+		t.Errorf("installed capture should come from the template, got %q", capture)
+	}
+	want := ".agentlog/templates/typescript/snippet.ts,.agentlog/templates/typescript/capture.ts"
+	if strings.Join(result.Templates, ",") != want {
+		t.Errorf("Templates = %v, want %s", result.Templates, want)
+	}
+}
+
+func TestInit_ProjectTemplatesPerStack(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTemplate(t, tmpDir, "python", "capture.py", "# acme python capture\n")
+
+	result, err := initWithOptions(tmpDir, initOptions{Stacks: []string{"go"}, Install: true})
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if len(result.Templates) != 0 {
+		t.Errorf("another stack's templates shouldn't be used, got %v", result.Templates)
+	}
+	capture, _ := os.ReadFile(filepath.Join(tmpDir, ".agentlog", "capture.go"))
+	if _, body, _ := parseStamp(string(capture)); body != snippetGo {
+		t.Error("stacks without templates should get the built-in capture")
+	}
+}
+
+func TestInit_RailsTemplates(t *testing.T) {
+	tmpDir := railsFixture(t)
+	writeTemplate(t, tmpDir, "ruby", "agentlog_controller.rb", "# acme controller\n")
+
+	if _, err := initWithOptions(tmpDir, initOptions{Stacks: []string{"ruby"}, Install: true}); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	controller, _ := os.ReadFile(filepath.Join(tmpDir, "app", "controllers", "agentlog_controller.rb"))
+	if _, body, _ := parseStamp(string(controller)); body != "# acme controller\n" {
+		t.Errorf("controller should come from the template, got %q", controller)
+	}
+	initializer, _ := os.ReadFile(filepath.Join(tmpDir, "config", "initializers", "agentlog.rb"))
+	if _, body, _ := parseStamp(string(initializer)); body != rubyInitializer {
+		t.Error("files without a template should be the built-in ones")
+	}
+}
+
+func TestStampTemplate(t *testing.T) {
+	stamped := stampTemplate("capture.py", "import sys\n")
+	if !strings.HasPrefix(stamped, "# agentlog:template ") {
+		t.Errorf("Python files should get a # stamp, got %q", stamped)
+	}
+	sum, body, ok := parseStamp(stamped)
+	if !ok || body != "import sys\n" || sum != templateSum("import sys\n") {
+		t.Errorf("parseStamp(%q) = %q, %q, %v", stamped, sum, body, ok)
+	}
+	if _, body, ok := parseStamp("// agentlog:installed\n"); ok || body != "// agentlog:installed\n" {
+		t.Error("files without a stamp should come back whole")
+	}
+}
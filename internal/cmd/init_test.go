@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/agentlog/agentlog/internal/config"
 )
 
 func TestInitCommand_CreatesAgentlogDir(t *testing.T) {
@@ -125,6 +127,62 @@ func TestInitCommand_SkipsGitignore_AlreadyPresent(t *testing.T) {
 	}
 }
 
+func TestInitCommand_GitignoreAll(t *testing.T) {
+	tmpDir := t.TempDir()
+	gitignore := filepath.Join(tmpDir, ".gitignore")
+	os.WriteFile(gitignore, []byte("node_modules/\n.agentlog/errors.jsonl\n"), 0644)
+
+	result, err := initWithOptions(tmpDir, initOptions{GitignoreAll: true})
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if !result.GitIgnored || !result.GitignoreAll {
+		t.Errorf("GitIgnored = %v, GitignoreAll = %v; want both", result.GitIgnored, result.GitignoreAll)
+	}
+	content, _ := os.ReadFile(gitignore)
+	if !strings.Contains(string(content), "\n.agentlog/*\n") || !strings.Contains(string(content), "!.agentlog/templates/") {
+		t.Errorf(".gitignore should ignore .agentlog/* with guidance for shared files:\n%s", content)
+	}
+
+	cfg, err := config.Load(tmpDir)
+	if err != nil || !cfg.GitignoreAll || cfg.Serve.Token == "" {
+		t.Errorf("config should record gitignore_all and keep the token, got %+v (%v)", cfg, err)
+	}
+
+	// Later runs follow the config without the flag, and don't repeat it
+	result, err = runInit(tmpDir, false, "", false)
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if result.GitIgnored || !result.GitignoreAll {
+		t.Errorf("GitIgnored = %v, GitignoreAll = %v; want false, true", result.GitIgnored, result.GitignoreAll)
+	}
+	content, _ = os.ReadFile(gitignore)
+	if strings.Count(string(content), ".agentlog/*") != 1 {
+		t.Errorf("expected one .agentlog/* entry:\n%s", content)
+	}
+}
+
+func TestGitignoreCovers(t *testing.T) {
+	tests := []struct {
+		content string
+		all     bool
+		want    bool
+	}{
+		{".agentlog/errors.jsonl\n", false, true},
+		{".agentlog/errors.jsonl\n", true, false},
+		{".agentlog/\n", false, true},
+		{".agentlog/\n", true, true},
+		{"/.agentlog/*\n", true, true},
+		{".agentlog/templates/\n", true, false},
+	}
+	for _, tt := range tests {
+		if got := gitignoreCovers(tt.content, tt.all); got != tt.want {
+			t.Errorf("gitignoreCovers(%q, %v) = %v, want %v", tt.content, tt.all, got, tt.want)
+		}
+	}
+}
+
 func TestInitCommand_DetectsStack(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -225,6 +283,44 @@ func TestInitCommand_Idempotent(t *testing.T) {
 	}
 }
 
+func TestInitCommand_GeneratesServeToken(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	result, err := runInit(tmpDir, false, "typescript", false)
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if !result.TokenCreated {
+		t.Error("first run should generate a serve token")
+	}
+
+	cfg, err := config.Load(tmpDir)
+	if err != nil {
+		t.Fatalf("config.Load() error = %v", err)
+	}
+	if len(cfg.Serve.Token) != 32 {
+		t.Fatalf("expected 32-char hex token, got %q", cfg.Serve.Token)
+	}
+	if !strings.Contains(result.Snippet, "Bearer "+cfg.Serve.Token) {
+		t.Error("snippet should carry the generated token")
+	}
+	if strings.Contains(result.Snippet, tokenPlaceholder) {
+		t.Error("snippet should not contain the token placeholder")
+	}
+
+	// Second run keeps the existing token
+	result2, err := runInit(tmpDir, false, "typescript", false)
+	if err != nil {
+		t.Fatalf("second init failed: %v", err)
+	}
+	if result2.TokenCreated {
+		t.Error("second run should reuse the existing token")
+	}
+	if !strings.Contains(result2.Snippet, "Bearer "+cfg.Serve.Token) {
+		t.Error("second run should inject the same token")
+	}
+}
+
 func TestInitCommand_JSONOutput(t *testing.T) {
 	tmpDir := t.TempDir()
 	os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte("{}"), 0644)
@@ -322,6 +418,91 @@ func TestTypeScriptSnippet_ErrorHandlers(t *testing.T) {
 	}
 }
 
+func TestTypeScriptSnippet_TopLevelLocation(t *testing.T) {
+	snippet := getSnippet("typescript")
+	if !strings.Contains(snippet, "{ file: src, line, column: col }") {
+		t.Error("TypeScript snippet should report file/line/column of uncaught errors")
+	}
+	if !strings.Contains(snippet, "...fields") {
+		t.Error("TypeScript snippet should send location as top-level fields")
+	}
+}
+
+func TestSnippets_Tags(t *testing.T) {
+	if !strings.Contains(getSnippet("typescript"), "tags?: string[]") {
+		t.Error("TypeScript logError should accept tags")
+	}
+	for _, stack := range []string{"node", "go", "python", "rust", "ruby"} {
+		if !strings.Contains(getSnippet(stack), "AGENTLOG_TAGS") {
+			t.Errorf("%s snippet should read default tags from AGENTLOG_TAGS", stack)
+		}
+	}
+}
+
+func TestSnippets_SlowOperations(t *testing.T) {
+	if snippet := getSnippet("typescript"); !strings.Contains(snippet, "_agentlogSlowMs = 0") || !strings.Contains(snippet, "longtask") {
+		t.Error("TypeScript snippet should offer opt-in long task reporting")
+	}
+	for _, stack := range []string{"node", "go", "python", "rust", "ruby"} {
+		snippet := getSnippet(stack)
+		if !strings.Contains(snippet, "AGENTLOG_SLOW_MS") {
+			t.Errorf("%s snippet should read the slow threshold from AGENTLOG_SLOW_MS", stack)
+		}
+		if !strings.Contains(snippet, "perf") || !strings.Contains(snippet, "duration_ms") {
+			t.Errorf("%s snippet should write kind perf entries with duration_ms", stack)
+		}
+	}
+	if !strings.Contains(nodeCapture, "export function logSlow") || !strings.Contains(rubyInitializer, "SLOW_REQUEST") {
+		t.Error("installed captures should match the printed snippets")
+	}
+}
+
+func TestSnippets_Project(t *testing.T) {
+	for _, stack := range []string{"node", "go", "python", "rust", "ruby"} {
+		if !strings.Contains(getSnippet(stack), "AGENTLOG_PROJECT") {
+			t.Errorf("%s snippet should stamp a project (AGENTLOG_PROJECT or the directory name)", stack)
+		}
+	}
+}
+
+func TestSnippets_Attribution(t *testing.T) {
+	snippets := map[string]string{"capture": nodeCapture, "rq": rqIntegration, "gqlgen": gqlgenIntegration, "bullmq": bullmqIntegration}
+	for _, stack := range []string{"node", "go", "python", "rust", "ruby", rubyScript} {
+		snippets[stack] = getSnippet(stack)
+	}
+	for name, snippet := range snippets {
+		if !strings.Contains(snippet, "AGENTLOG_HOST") || !strings.Contains(snippet, "AGENTLOG_USER") {
+			t.Errorf("%s should stamp a host and user (AGENTLOG_HOST, AGENTLOG_USER)", name)
+		}
+		if !strings.Contains(snippet, "AGENTLOG_AGENT") || !strings.Contains(snippet, "CLAUDE_SESSION_ID") {
+			t.Errorf("%s should stamp the agent (AGENTLOG_AGENT, CLAUDE_SESSION_ID)", name)
+		}
+	}
+}
+
+func TestSnippets_TestRun(t *testing.T) {
+	snippets := map[string]string{"capture": nodeCapture, "node": getSnippet("node"), "python": getSnippet("python")}
+	for _, stack := range []string{"node", "python"} {
+		snippets[stack+" remote"] = remoteSnippet(stack, getSnippet(stack), "http://localhost:4317/__agentlog")
+	}
+	for name, snippet := range snippets {
+		if !strings.Contains(snippet, "AGENTLOG_TEST_RUN_ID") || !strings.Contains(snippet, "test_run_id") {
+			t.Errorf("%s should put AGENTLOG_TEST_RUN_ID in context.test_run_id", name)
+		}
+	}
+}
+
+func TestSnippets_Environment(t *testing.T) {
+	if !strings.Contains(getSnippet("typescript"), "environment:") {
+		t.Error("TypeScript snippet should record an environment")
+	}
+	for _, stack := range []string{"node", "go", "python", "rust", "ruby"} {
+		if !strings.Contains(getSnippet(stack), "AGENTLOG_ENV") {
+			t.Errorf("%s snippet should read the environment from AGENTLOG_ENV", stack)
+		}
+	}
+}
+
 func TestTypeScriptSnippet_DevModeCheck(t *testing.T) {
 	snippet := getSnippet("typescript")
 
@@ -1247,3 +1428,250 @@ func TestNodeCapture_UpdatesGitignore(t *testing.T) {
 		t.Error("nodeCapture should add .agentlog/errors.jsonl to .gitignore")
 	}
 }
+
+// ========== Browser capture tests ==========
+
+func TestInit_CaptureConsole(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	result, err := initWithOptions(tmpDir, initOptions{Stacks: []string{"typescript"}, CaptureConsole: true})
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if len(result.BrowserCapture) != 1 || result.BrowserCapture[0] != "console" {
+		t.Errorf("BrowserCapture = %v, want [console]", result.BrowserCapture)
+	}
+	for _, want := range []string{"CONSOLE_ERROR", "CONSOLE_WARN", "_consoleSent >= 10"} {
+		if !strings.Contains(result.Snippet, want) {
+			t.Errorf("snippet should contain %q", want)
+		}
+	}
+	// The capture runs in the browser section, before the dev server plugin
+	if strings.Index(result.Snippet, "console[level] =") > strings.Index(result.Snippet, "// === DEV SERVER") {
+		t.Error("console capture should be inserted into the browser section")
+	}
+
+	plain, err := runInit(t.TempDir(), false, "typescript", false)
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if strings.Contains(plain.Snippet, "CONSOLE_ERROR") || plain.BrowserCapture != nil {
+		t.Error("console capture should be opt-in")
+	}
+}
+
+func TestInit_CaptureConsoleInstall(t *testing.T) {
+	tmpDir := t.TempDir()
+	if _, err := initWithOptions(tmpDir, initOptions{Stacks: []string{"typescript"}, Install: true, CaptureConsole: true}); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(tmpDir, ".agentlog", "capture.ts"))
+	if !strings.Contains(string(content), "_sendLog(level === 'error' ? 'CONSOLE_ERROR'") {
+		t.Errorf("capture.ts should include console capture:\n%s", content)
+	}
+
+	railsDir := t.TempDir()
+	os.MkdirAll(filepath.Join(railsDir, "app", "javascript"), 0755)
+	os.WriteFile(filepath.Join(railsDir, "app", "javascript", "application.js"), []byte("import './controllers';\n"), 0644)
+	if _, err := initWithOptions(railsDir, initOptions{Stacks: []string{"ruby"}, Install: true, CaptureConsole: true}); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	content, _ = os.ReadFile(filepath.Join(railsDir, "app", "javascript", "application.js"))
+	js := string(content)
+	if !strings.Contains(js, "log(level === 'error' ? 'CONSOLE_ERROR'") || strings.Index(js, "consoleSeen") > strings.LastIndex(js, "})();") {
+		t.Errorf("application.js should include console capture inside the closure:\n%s", js)
+	}
+}
+
+func TestInit_CaptureConsoleNeedsBrowserStack(t *testing.T) {
+	if _, err := initWithOptions(t.TempDir(), initOptions{Stacks: []string{"go"}, CaptureConsole: true}); err == nil {
+		t.Error("--capture-console should be rejected for stacks without a browser snippet")
+	}
+}
+
+func TestInit_CaptureNetwork(t *testing.T) {
+	result, err := initWithOptions(t.TempDir(), initOptions{Stacks: []string{"ruby"}, CaptureConsole: true, CaptureNetwork: true})
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if strings.Join(result.BrowserCapture, ",") != "console,network" {
+		t.Errorf("BrowserCapture = %v, want [console network]", result.BrowserCapture)
+	}
+	browser := result.Snippet[:strings.Index(result.Snippet, "# === RAILS CONTROLLER")]
+	for _, want := range []string{"window.fetch = async", "XMLHttpRequest.prototype.open", "log('NETWORK_ERROR'", "includes('/__agentlog')", "consoleSeen"} {
+		if !strings.Contains(browser, want) {
+			t.Errorf("browser snippet should contain %q", want)
+		}
+	}
+
+	ts := addBrowserCaptures(snippetTypeScript, []browserCapture{{TS: networkCaptureTS, JS: networkCaptureJS}})
+	if !strings.Contains(ts, "_sendLog('NETWORK_ERROR'") {
+		t.Error("TypeScript snippet should report failed requests through _sendLog")
+	}
+
+	if _, err := initWithOptions(t.TempDir(), initOptions{Stacks: []string{"python"}, CaptureNetwork: true}); err == nil || !strings.Contains(err.Error(), "--capture-network") {
+		t.Errorf("--capture-network should be rejected for python, got %v", err)
+	}
+}
+
+func TestNodeSnippet_WarningCapture(t *testing.T) {
+	for name, snippet := range map[string]string{"snippet": getSnippet("node"), "capture": nodeCapture} {
+		for _, want := range []string{"AGENTLOG_WARNINGS", "process.on('warning'", "DEPRECATION_WARNING", "level: 'warning'"} {
+			if !strings.Contains(snippet, want) {
+				t.Errorf("node %s should contain %q", name, want)
+			}
+		}
+		// Opt-in: the listener is only registered behind the flag
+		if strings.Index(snippet, "if (captureWarnings)") > strings.Index(snippet, "process.on('warning'") {
+			t.Errorf("node %s should only listen for warnings when AGENTLOG_WARNINGS is set", name)
+		}
+	}
+}
+
+func TestNodeSnippet_RepeatCollapsing(t *testing.T) {
+	for name, snippet := range map[string]string{"snippet": getSnippet("node"), "capture": nodeCapture} {
+		for _, want := range []string{"AGENTLOG_REPEAT_WINDOW_MS", "if (admitRepeat(entry)) appendEntry(entry);", "first_seen: first, last_seen: held.timestamp", "process.on('exit'"} {
+			if !strings.Contains(snippet, want) {
+				t.Errorf("node %s should contain %q", name, want)
+			}
+		}
+	}
+}
+
+func TestPythonSnippet_LoggingHandler(t *testing.T) {
+	snippet := getSnippet("python")
+	for _, want := range []string{
+		"class AgentlogHandler(logging.Handler):",
+		"def __init__(self, level=logging.ERROR):",
+		`"logger": record.name`,
+		"traceback.format_exception(*record.exc_info)",
+		"logging.getLogger().addHandler(AgentlogHandler())",
+	} {
+		if !strings.Contains(snippet, want) {
+			t.Errorf("python snippet should contain %q", want)
+		}
+	}
+}
+
+// ========== Ruby script snippet tests ==========
+
+func TestInit_RubyWithoutRailsGetsScriptCapture(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "Gemfile"), []byte("source 'https://rubygems.org'\ngem 'thor'\n"), 0644)
+
+	result, err := runInit(tmpDir, false, "", true)
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if result.Stack != "ruby" || result.SnippetLang != rubyScript {
+		t.Errorf("stack = %s, snippet language = %s; want ruby, ruby-script", result.Stack, result.SnippetLang)
+	}
+	for _, want := range []string{"at_exit do", "error = $!", "Thread.prepend(Agentlog::ThreadCapture)", "source: 'cli'"} {
+		if !strings.Contains(result.Snippet, want) {
+			t.Errorf("script snippet should contain %q", want)
+		}
+	}
+	if strings.Contains(result.Snippet, "Rails") && strings.Contains(result.Snippet, "middleware") {
+		t.Error("script snippet should not use the Rails middleware")
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, ".agentlog", "capture.rb")); err != nil {
+		t.Errorf("capture.rb should be installed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "app", "controllers", "agentlog_controller.rb")); !os.IsNotExist(err) {
+		t.Error("Rails files should not be installed without Rails")
+	}
+}
+
+func TestInit_RubyScriptStackOverride(t *testing.T) {
+	result, err := runInit(t.TempDir(), false, "ruby-script", false)
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if result.Stack != "ruby" || result.SnippetLang != rubyScript || !strings.Contains(result.Snippet, "at_exit") {
+		t.Errorf("--stack ruby-script should select the script capture, got %s/%s", result.Stack, result.SnippetLang)
+	}
+
+	railsDir := t.TempDir()
+	os.WriteFile(filepath.Join(railsDir, "Gemfile"), []byte("gem \"rails\", \"~> 7.1\"\n"), 0644)
+	result, err = runInit(railsDir, false, "", false)
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if result.SnippetLang != "ruby" {
+		t.Errorf("Rails projects should keep the Rails snippet, got %s", result.SnippetLang)
+	}
+}
+
+// ========== Multiple stack tests ==========
+
+func TestInit_MultipleStacks(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	result, err := initWithOptions(tmpDir, initOptions{Stacks: []string{"Go", "typescript", "go"}, Install: true, CaptureConsole: true})
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if result.Stack != "go" || len(result.Stacks) != 2 || result.Stacks[1].SnippetLang != "typescript" {
+		t.Fatalf("stacks = %+v, want go then typescript", result.Stacks)
+	}
+	for _, want := range []string{"// === GO ===", "// === TYPESCRIPT ===", "debug.Stack()", "window.onerror", "CONSOLE_ERROR"} {
+		if !strings.Contains(result.Snippet, want) {
+			t.Errorf("snippet should contain %q", want)
+		}
+	}
+	if strings.Contains(result.Stacks[0].Snippet, "CONSOLE_ERROR") {
+		t.Error("browser captures only go in the browser snippet")
+	}
+	for _, path := range []string{"capture.go", "capture.ts"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, ".agentlog", path)); err != nil {
+			t.Errorf("%s should be installed: %v", path, err)
+		}
+	}
+}
+
+func TestInit_MultipleStacksShareCaptureModule(t *testing.T) {
+	_, err := initWithOptions(t.TempDir(), initOptions{Stacks: []string{"typescript", "node"}})
+	if err == nil || !strings.Contains(err.Error(), "both use .agentlog/capture.ts") {
+		t.Errorf("expected an error for typescript with node, got %v", err)
+	}
+}
+
+// ========== React Native snippet tests ==========
+
+func TestInit_ReactNative(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(`{"dependencies": {"react": "18.2.0", "react-native": "0.74.0"}}`), 0644)
+
+	result, err := runInit(tmpDir, false, "", true)
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if result.Stack != "react-native" {
+		t.Errorf("stack = %s, want react-native", result.Stack)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, ".agentlog", "capture.ts"))
+	if err != nil {
+		t.Fatalf("capture.ts should be installed: %v", err)
+	}
+	capture := string(data)
+	for _, want := range []string{"errorUtils.setGlobalHandler(", "NativeModules.SourceCode?.scriptURL", "':' + AGENTLOG_PORT + '/__agentlog'", "previous(error, isFatal);"} {
+		if !strings.Contains(capture, want) {
+			t.Errorf("capture.ts should contain %q", want)
+		}
+	}
+	if strings.Contains(capture, tokenPlaceholder) {
+		t.Error("capture.ts should have the serve token filled in")
+	}
+	if strings.Contains(capture, "window.onerror") {
+		t.Error("React Native capture should not use the browser snippet")
+	}
+}
+
+func TestInit_ReactNativeRejectsBrowserCaptures(t *testing.T) {
+	_, err := initWithOptions(t.TempDir(), initOptions{Stacks: []string{"react-native"}, CaptureConsole: true})
+	if err == nil {
+		t.Error("--capture-console should need a browser snippet")
+	}
+}
@@ -0,0 +1,307 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/agentlog/agentlog/internal/config"
+	"github.com/agentlog/agentlog/internal/detect"
+)
+
+// knownStacks are the stacks init has snippets for, as --stack takes them
+var knownStacks = []string{"typescript", "node", "go", "python", "rust", "ruby", rubyScript, "react-native"}
+
+// initWizard asks init's questions, reading one answer per line
+type initWizard struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// readAnswer reads one trimmed line. Input that ends before a question is
+// answered is an error, so piped answers that run short don't install
+// half-chosen defaults.
+func (w *initWizard) readAnswer() (string, error) {
+	line, err := w.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("input ended before init --interactive finished")
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// ask prompts with question and returns the answer, or def if it is blank
+func (w *initWizard) ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(w.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(w.out, "%s: ", question)
+	}
+	answer, err := w.readAnswer()
+	if err != nil || answer == "" {
+		return def, err
+	}
+	return answer, nil
+}
+
+// choose asks until the answer is one of options
+func (w *initWizard) choose(question string, options []string, def string) (string, error) {
+	for {
+		answer, err := w.ask(fmt.Sprintf("%s (%s)", question, strings.Join(options, ", ")), def)
+		if err != nil {
+			return "", err
+		}
+		answer = strings.ToLower(answer)
+		for _, o := range options {
+			if answer == o {
+				return o, nil
+			}
+		}
+		fmt.Fprintf(w.out, "  Please answer one of: %s\n", strings.Join(options, ", "))
+	}
+}
+
+// confirm asks a yes/no question
+func (w *initWizard) confirm(question string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	for {
+		fmt.Fprintf(w.out, "%s [%s]: ", question, hint)
+		answer, err := w.readAnswer()
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		fmt.Fprintln(w.out, "  Please answer y or n")
+	}
+}
+
+// runInitWizard asks for init's options on out, reading answers from in.
+// Flags already given and choices saved by an earlier wizard run are the
+// defaults; so are the detected stack and integrations.
+func runInitWizard(in io.Reader, out io.Writer, dir string, opts initOptions) (initOptions, error) {
+	cfg, err := config.Load(dir)
+	if err != nil {
+		return opts, err
+	}
+	prev := cfg.Init
+	if prev == nil {
+		prev = &config.InitConfig{}
+	}
+	w := &initWizard{in: bufio.NewReader(in), out: out}
+
+	// Stacks
+	stacks := opts.Stacks
+	if len(stacks) == 0 {
+		stacks = prev.Stacks
+	}
+	if len(stacks) == 0 {
+		detection := detect.DetectStack(dir)
+		stack := detection.Stack.String()
+		if filepath.Base(detection.MarkerFile) == "Gemfile" && !detect.IsRails(filepath.Join(dir, filepath.Dir(detection.MarkerFile))) {
+			stack = rubyScript
+		}
+		if detection.Detected {
+			fmt.Fprintf(out, "Detected %s (from %s).\n", capitalize(stack), detection.MarkerFile)
+		}
+		stacks = []string{stack}
+	}
+	fmt.Fprintf(out, "Stacks: %s\n", strings.Join(knownStacks, ", "))
+	for {
+		answer, err := w.ask("Stacks to set up, comma-separated (e.g. ruby, typescript)", strings.ToLower(strings.Join(stacks, ", ")))
+		if err != nil {
+			return opts, err
+		}
+		names, unknown := parseNameList(answer, knownStacks)
+		if unknown == "" && len(names) > 0 {
+			if _, err := overrideStacks(names); err != nil {
+				fmt.Fprintf(out, "  %v\n", err)
+				continue
+			}
+			opts.Stacks = names
+			break
+		}
+		if unknown == "" {
+			fmt.Fprintln(out, "  Name at least one stack")
+			continue
+		}
+		fmt.Fprintf(out, "  Unknown stack '%s'\n", unknown)
+	}
+
+	// Install or print
+	mode := "print"
+	if opts.Install || prev.Install {
+		mode = "install"
+	}
+	if mode, err = w.choose("Install the capture files into the project, or print the snippet to copy in?", []string{"install", "print"}, mode); err != nil {
+		return opts, err
+	}
+	opts.Install = mode == "install"
+
+	// Browser capture and transport
+	browser := false
+	for _, stack := range opts.Stacks {
+		browser = browser || isBrowserSnippet(stack)
+	}
+	if browser {
+		if opts.CaptureConsole, err = w.confirm("Also report console.error and console.warn?", opts.CaptureConsole || containsString(prev.Capture, "console")); err != nil {
+			return opts, err
+		}
+		if opts.CaptureNetwork, err = w.confirm("Also report failed fetch/XHR requests?", opts.CaptureNetwork || containsString(prev.Capture, "network")); err != nil {
+			return opts, err
+		}
+		fmt.Fprintln(out, "Browser errors can go through your dev server, which writes them to .agentlog/errors.jsonl (file),")
+		fmt.Fprintln(out, "or to 'agentlog serve' running alongside it (serve).")
+		if opts.Transport, err = w.choose("Transport", []string{"file", "serve"}, firstNonEmpty(opts.Transport, prev.Transport, "file")); err != nil {
+			return opts, err
+		}
+	}
+
+	if opts.Remote == "" && prev.Remote != "" {
+		fmt.Fprintf(out, "Snippets post to %s (saved from --remote).\n", prev.Remote)
+		opts.Remote = prev.Remote
+	}
+
+	// Framework and worker hooks
+	hooks := opts.Integrations
+	if cfg.Init != nil {
+		hooks = append(hooks, prev.Integrations...)
+	} else {
+		for _, found := range detect.DetectIntegrations(dir) {
+			hooks = append(hooks, found.String())
+		}
+	}
+	def := strings.Join(uniqueStrings(hooks), ", ")
+	if def == "" {
+		def = "none"
+	}
+	fmt.Fprintf(out, "Framework and job worker hooks: %s\n", strings.Join(integrationNames(), ", "))
+	for {
+		answer, err := w.ask("Hooks to add, comma-separated, or none", def)
+		if err != nil {
+			return opts, err
+		}
+		if strings.EqualFold(answer, "none") {
+			opts.Integrations = nil
+			break
+		}
+		names, unknown := parseNameList(answer, integrationNames())
+		if unknown == "" {
+			opts.Integrations = names
+			break
+		}
+		fmt.Fprintf(out, "  Unknown hook '%s'\n", unknown)
+	}
+	opts.OnlyIntegrations = true
+	opts.Record = true
+	return opts, nil
+}
+
+// parseNameList splits a comma-separated answer into names from known,
+// or returns the first name that isn't known
+func parseNameList(answer string, known []string) ([]string, string) {
+	var names []string
+	for _, name := range strings.Split(answer, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !containsString(known, name) {
+			return nil, name
+		}
+		names = append(names, name)
+	}
+	return uniqueStrings(names), ""
+}
+
+// updateConfig changes the config through files, so --dry-run only plans
+// it
+func updateConfig(files initFiles, dir string, change func(*config.Config)) error {
+	cfg := &config.Config{}
+	data, err := files.ReadFile(config.Path(dir))
+	if err == nil {
+		if cfg, err = config.Decode(data); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", config.FileName, err)
+	}
+
+	change(cfg)
+	if data, err = config.Encode(cfg); err != nil {
+		return err
+	}
+	if err := files.MkdirAll(filepath.Dir(config.Path(dir))); err != nil {
+		return fmt.Errorf("failed to create .agentlog directory: %w", err)
+	}
+	if err := files.WriteFile(config.Path(dir), data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", config.FileName, err)
+	}
+	return nil
+}
+
+// recordInitChoices saves what init was told to the config's "init"
+// section. Installs record it too, so upgrade can regenerate what was
+// installed.
+func recordInitChoices(files initFiles, dir string, result *InitResult, opts initOptions) error {
+	var stacks []string
+	browser := false
+	for _, s := range result.Stacks {
+		stacks = append(stacks, s.SnippetLang)
+		browser = browser || isBrowserSnippet(s.SnippetLang)
+	}
+	choices := &config.InitConfig{
+		Stacks:       stacks,
+		Install:      opts.Install,
+		Capture:      result.BrowserCapture,
+		Integrations: result.Integrations,
+		Transport:    result.Transport,
+		Remote:       result.Remote,
+	}
+	if choices.Transport == "" && browser {
+		choices.Transport = "file"
+	}
+	return updateConfig(files, dir, func(cfg *config.Config) { cfg.Init = choices })
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueStrings drops repeats, keeping the first of each
+func uniqueStrings(list []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, v := range list {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agentlog/agentlog/internal/config"
+)
+
+func TestInitWizard_Defaults(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(`{"dependencies": {"vue": "^3.4.0"}}`), 0644)
+
+	var out bytes.Buffer
+	// Accept every default: stack, print, no captures, file, detected hooks
+	opts, err := runInitWizard(strings.NewReader("\n\n\n\n\n\n"), &out, tmpDir, initOptions{})
+	if err != nil {
+		t.Fatalf("wizard failed: %v\n%s", err, out.String())
+	}
+	if strings.Join(opts.Stacks, ",") != "typescript" || opts.Install || opts.CaptureConsole || opts.CaptureNetwork || opts.Transport != "file" {
+		t.Errorf("defaults = %+v", opts)
+	}
+	if len(opts.Integrations) != 1 || opts.Integrations[0] != "vue" || !opts.OnlyIntegrations || !opts.Record {
+		t.Errorf("detected hooks should be the default, got %+v", opts)
+	}
+	if !strings.Contains(out.String(), "Detected Typescript (from package.json)") || !strings.Contains(out.String(), "Hooks to add, comma-separated, or none [vue]") {
+		t.Errorf("unexpected prompts:\n%s", out.String())
+	}
+}
+
+func TestInitWizard_Answers(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(`{"dependencies": {"vue": "^3.4.0"}}`), 0644)
+
+	var out bytes.Buffer
+	answers := "elm\nruby, typescript\nInstall\ny\nno\nserve\nember\nsidekiq, bullmq\n"
+	opts, err := runInitWizard(strings.NewReader(answers), &out, tmpDir, initOptions{})
+	if err != nil {
+		t.Fatalf("wizard failed: %v\n%s", err, out.String())
+	}
+	if strings.Join(opts.Stacks, ",") != "ruby,typescript" || !opts.Install || !opts.CaptureConsole || opts.CaptureNetwork || opts.Transport != "serve" {
+		t.Errorf("answers = %+v", opts)
+	}
+	if strings.Join(opts.Integrations, ",") != "sidekiq,bullmq" {
+		t.Errorf("Integrations = %v, want the answered ones without the detected vue", opts.Integrations)
+	}
+	if !strings.Contains(out.String(), "Unknown stack 'elm'") || !strings.Contains(out.String(), "Unknown hook 'ember'") {
+		t.Errorf("invalid answers should be asked again:\n%s", out.String())
+	}
+}
+
+func TestInitWizard_SkipsBrowserQuestions(t *testing.T) {
+	var out bytes.Buffer
+	opts, err := runInitWizard(strings.NewReader("go\nprint\nnone\n"), &out, t.TempDir(), initOptions{})
+	if err != nil {
+		t.Fatalf("wizard failed: %v\n%s", err, out.String())
+	}
+	if strings.Join(opts.Stacks, ",") != "go" || opts.Transport != "" || opts.Integrations != nil {
+		t.Errorf("opts = %+v", opts)
+	}
+	if strings.Contains(out.String(), "Transport") {
+		t.Errorf("Go has no browser snippet to ask about:\n%s", out.String())
+	}
+}
+
+func TestInitWizard_InputEnds(t *testing.T) {
+	var out bytes.Buffer
+	if _, err := runInitWizard(strings.NewReader("go\n"), &out, t.TempDir(), initOptions{}); err == nil || !strings.Contains(err.Error(), "input ended") {
+		t.Errorf("expected an error when answers run out, got %v", err)
+	}
+}
+
+func TestInitWizard_SavesAndReusesChoices(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte("{}"), 0644)
+
+	var out bytes.Buffer
+	opts, err := runInitWizard(strings.NewReader("\ninstall\n\ny\nserve\nvue\n"), &out, tmpDir, initOptions{})
+	if err != nil {
+		t.Fatalf("wizard failed: %v", err)
+	}
+	result, err := initWithOptions(tmpDir, opts)
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if !result.ChoicesSaved || result.Transport != "serve" {
+		t.Errorf("result = %+v", result)
+	}
+
+	cfg, err := config.Load(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Serve.Token == "" {
+		t.Error("saving choices should keep the serve token")
+	}
+	want := config.InitConfig{Stacks: []string{"typescript"}, Install: true, Capture: []string{"network"}, Integrations: []string{"vue"}, Transport: "serve"}
+	if cfg.Init == nil || strings.Join(cfg.Init.Stacks, ",") != "typescript" || !cfg.Init.Install || strings.Join(cfg.Init.Capture, ",") != "network" || strings.Join(cfg.Init.Integrations, ",") != "vue" || cfg.Init.Transport != "serve" {
+		t.Errorf("Init = %+v, want %+v", cfg.Init, want)
+	}
+
+	capture, _ := os.ReadFile(filepath.Join(tmpDir, ".agentlog", "capture.ts"))
+	if !strings.Contains(string(capture), "fetch('"+defaultServeURL+"'") {
+		t.Error("the serve transport should post to agentlog serve")
+	}
+
+	// The next run offers the saved answers
+	out.Reset()
+	again, err := runInitWizard(strings.NewReader("\n\n\n\n\n\n"), &out, tmpDir, initOptions{})
+	if err != nil {
+		t.Fatalf("wizard failed: %v", err)
+	}
+	if !again.Install || again.CaptureConsole || !again.CaptureNetwork || again.Transport != "serve" || strings.Join(again.Integrations, ",") != "vue" {
+		t.Errorf("second run should default to the saved choices, got %+v", again)
+	}
+}
+
+func TestInit_ServeTransportNeedsBrowserSnippet(t *testing.T) {
+	if _, err := initWithOptions(t.TempDir(), initOptions{Stacks: []string{"go"}, Transport: "serve"}); err == nil || !strings.Contains(err.Error(), "needs a browser snippet") {
+		t.Errorf("expected an error for serve with Go, got %v", err)
+	}
+	if _, err := initWithOptions(t.TempDir(), initOptions{Transport: "carrier-pigeon"}); err == nil || !strings.Contains(err.Error(), "unknown transport") {
+		t.Errorf("expected an error for an unknown transport, got %v", err)
+	}
+}
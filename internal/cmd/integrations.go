@@ -0,0 +1,1335 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/agentlog/agentlog/internal/detect"
+)
+
+// integration is a framework error hook installed alongside a stack's
+// capture snippet, for frameworks that handle errors before window.onerror
+// or the process-level handlers see them
+type integration struct {
+	Name  string
+	Title string
+	Files []integrationFile
+	// Usage tells the user how to wire the installed files in
+	Usage string
+}
+
+// integrationFile is one file an integration installs, relative to the
+// project root
+type integrationFile struct {
+	Path    string
+	Content string
+}
+
+var integrations = []integration{
+	{
+		Name:  "vue",
+		Title: "Vue",
+		Files: []integrationFile{{Path: ".agentlog/vue.ts", Content: vueIntegration}},
+		Usage: "import { agentlogVue } from './.agentlog/vue'; app.use(agentlogVue);",
+	},
+	{
+		Name:  "sveltekit",
+		Title: "SvelteKit",
+		Files: []integrationFile{
+			{Path: "src/hooks.client.ts", Content: svelteKitClientHooks},
+			{Path: "src/hooks.server.ts", Content: svelteKitServerHooks},
+		},
+		Usage: "nothing to do; SvelteKit loads src/hooks.client.ts and src/hooks.server.ts itself",
+	},
+	{
+		Name:  "angular",
+		Title: "Angular",
+		Files: []integrationFile{{Path: "src/app/agentlog-error-handler.ts", Content: angularErrorHandler}},
+		Usage: "add { provide: ErrorHandler, useClass: AgentlogErrorHandler } to the providers in app.config.ts (or your AppModule)",
+	},
+	{
+		Name:  "celery",
+		Title: "Celery",
+		Files: []integrationFile{{Path: ".agentlog/agentlog_celery.py", Content: celeryIntegration}},
+		Usage: "import agentlog_celery in the module that creates your Celery app (with .agentlog on sys.path)",
+	},
+	{
+		Name:  "sidekiq",
+		Title: "Sidekiq",
+		Files: []integrationFile{{Path: "config/initializers/agentlog_sidekiq.rb", Content: sidekiqIntegration}},
+		Usage: "nothing to do in Rails; otherwise require config/initializers/agentlog_sidekiq.rb where you configure Sidekiq",
+	},
+	{
+		Name:  "rq",
+		Title: "RQ",
+		Files: []integrationFile{{Path: ".agentlog/agentlog_rq.py", Content: rqIntegration}},
+		Usage: "rq worker --exception-handler agentlog_rq.agentlog_rq_handler (with .agentlog on PYTHONPATH)",
+	},
+	{
+		Name:  "bullmq",
+		Title: "BullMQ",
+		Files: []integrationFile{{Path: ".agentlog/bullmq.ts", Content: bullmqIntegration}},
+		Usage: "import { captureWorkerFailures } from './.agentlog/bullmq'; captureWorkerFailures(worker);",
+	},
+	{
+		Name:  "apollo",
+		Title: "Apollo Server",
+		Files: []integrationFile{{Path: ".agentlog/apollo.ts", Content: apolloIntegration}},
+		Usage: "new ApolloServer({ typeDefs, resolvers, plugins: [agentlogApolloPlugin] })",
+	},
+	{
+		Name:  "graphql-yoga",
+		Title: "GraphQL Yoga",
+		Files: []integrationFile{{Path: ".agentlog/yoga.ts", Content: yogaIntegration}},
+		Usage: "createYoga({ schema, plugins: [agentlogYogaPlugin()] })",
+	},
+	{
+		Name:  "gqlgen",
+		Title: "gqlgen",
+		Files: []integrationFile{{Path: ".agentlog/agentlog_gqlgen.go", Content: gqlgenIntegration}},
+		Usage: "copy .agentlog/agentlog_gqlgen.go next to your server setup and call srv.SetErrorPresenter(agentlogErrorPresenter)",
+	},
+	{
+		Name:  "grpc-go",
+		Title: "gRPC (Go)",
+		Files: []integrationFile{{Path: ".agentlog/agentlog_grpc.go", Content: grpcGoIntegration}},
+		Usage: "copy .agentlog/agentlog_grpc.go next to your server setup and pass grpc.ChainUnaryInterceptor(agentlogUnaryInterceptor), grpc.ChainStreamInterceptor(agentlogStreamInterceptor) to grpc.NewServer",
+	},
+	{
+		Name:  "grpc-node",
+		Title: "gRPC (Node)",
+		Files: []integrationFile{{Path: ".agentlog/grpc.ts", Content: grpcNodeIntegration}},
+		Usage: "server.addService(Greeter.service, withAgentlog('helloworld.Greeter', impl))",
+	},
+	{
+		Name:  "electron",
+		Title: "Electron",
+		Files: []integrationFile{
+			{Path: ".agentlog/electron-main.ts", Content: electronMainIntegration},
+			{Path: ".agentlog/electron-preload.ts", Content: electronPreloadIntegration},
+		},
+		Usage: "call initAgentlogMain() first in the main process, and import .agentlog/electron-preload.ts from each window's preload script",
+	},
+	{
+		Name:  "cloudflare-workers",
+		Title: "Cloudflare Workers",
+		Files: []integrationFile{{Path: ".agentlog/worker.ts", Content: workersIntegration}},
+		Usage: "export default withAgentlog({ fetch(request, env, ctx) { ... } }), set AGENTLOG_URL under [vars] in wrangler.toml, and run 'agentlog serve'",
+	},
+	{
+		Name:  "tauri",
+		Title: "Tauri",
+		Files: []integrationFile{
+			{Path: "src-tauri/src/agentlog.rs", Content: tauriRustIntegration},
+			{Path: ".agentlog/tauri.ts", Content: tauriWebviewIntegration},
+		},
+		Usage: "add mod agentlog; to main.rs, call agentlog::init_agentlog() and register .invoke_handler(tauri::generate_handler![agentlog::agentlog_log]), then import './.agentlog/tauri' in the frontend entry point (needs the chrono and serde_json crates)",
+	},
+	{
+		Name:  "lambda",
+		Title: "AWS Lambda",
+		Files: []integrationFile{
+			{Path: ".agentlog/lambda.ts", Content: lambdaNodeIntegration},
+			{Path: ".agentlog/agentlog_lambda.py", Content: lambdaPythonIntegration},
+		},
+		Usage: "wrap handlers with withAgentlog(handler) (Node) or @agentlog_handler (Python); under sam local, set AGENTLOG_URL=http://host.docker.internal:7654/__agentlog and run 'agentlog serve'",
+	},
+}
+
+// findIntegration looks up an integration by name
+func findIntegration(name string) (integration, bool) {
+	for _, in := range integrations {
+		if in.Name == name {
+			return in, true
+		}
+	}
+	return integration{}, false
+}
+
+// integrationNames lists the known integrations, sorted
+func integrationNames() []string {
+	var names []string
+	for _, in := range integrations {
+		names = append(names, in.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolveIntegrations returns those requested by name plus, with
+// detected, the integrations detected in dir, each once
+func resolveIntegrations(dir string, requested []string, detected bool) ([]integration, error) {
+	var names []string
+	if detected {
+		for _, found := range detect.DetectIntegrations(dir) {
+			names = append(names, found.String())
+		}
+	}
+	for _, name := range requested {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := findIntegration(name); !ok {
+			return nil, invalidInput("unknown integration '%s' (available: %s)", name, strings.Join(integrationNames(), ", "))
+		}
+		names = append(names, name)
+	}
+
+	var list []integration
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		in, _ := findIntegration(name)
+		list = append(list, in)
+	}
+	return list, nil
+}
+
+// integrationSnippet renders integration files to print after the stack's
+// snippet, each under a header naming where it goes
+func integrationSnippet(list []integration) string {
+	var sb strings.Builder
+	for _, in := range list {
+		for _, f := range in.Files {
+			comment := "//"
+			if ext := filepath.Ext(f.Path); ext == ".py" || ext == ".rb" {
+				comment = "#"
+			}
+			sb.WriteString(fmt.Sprintf("\n\n%s === %s (%s) ===\n", comment, strings.ToUpper(in.Title), f.Path))
+			sb.WriteString(strings.TrimRight(f.Content, "\n"))
+		}
+	}
+	return sb.String()
+}
+
+// installIntegrations writes the integrations' files. Existing files are
+// left alone; those agentlog didn't write are reported as skipped, since
+// the hook has to be merged into them by hand.
+func installIntegrations(files initFiles, dir string, list []integration, token string) ([]InstallAction, error) {
+	var actions []InstallAction
+	for _, in := range list {
+		for _, f := range in.Files {
+			path := filepath.Join(dir, filepath.FromSlash(f.Path))
+			if existing, err := files.ReadFile(path); err == nil {
+				if !strings.Contains(string(existing), "agentlog:installed") {
+					actions = append(actions, InstallAction{Path: f.Path, Operation: "skip"})
+				}
+				continue
+			}
+			if err := files.MkdirAll(filepath.Dir(path)); err != nil {
+				return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(f.Path), err)
+			}
+			if err := writeStamped(files, path, injectToken(f.Content, token)); err != nil {
+				return nil, fmt.Errorf("failed to create %s: %w", f.Path, err)
+			}
+			actions = append(actions, InstallAction{Path: f.Path, Operation: "create"})
+		}
+	}
+	return actions, nil
+}
+
+// printIntegrationUsage prints how to wire in each installed integration
+func printIntegrationUsage(names []string) {
+	var lines []string
+	for _, name := range names {
+		if in, ok := findIntegration(name); ok && in.Usage != "" {
+			lines = append(lines, fmt.Sprintf("  %s: %s", in.Title, in.Usage))
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Println("Wire in the framework integrations:")
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+}
+
+const vueIntegration = `// agentlog:installed - Vue error capture
+// Usage: import { agentlogVue } from './.agentlog/vue'; app.use(agentlogVue);
+// Vue sends render, watcher, and lifecycle errors to app.config.errorHandler
+// rather than window.onerror, so they need their own hook.
+import { onErrorCaptured, type App, type ComponentPublicInstance } from 'vue';
+
+const reported = new WeakSet<object>();
+
+const report = (err: unknown, instance: ComponentPublicInstance | null, info: string) => {
+  if (import.meta.env?.DEV === false) return;
+  if (typeof err === 'object' && err !== null) {
+    if (reported.has(err)) return;
+    reported.add(err);
+  }
+  const e = err instanceof Error ? err : new Error(String(err));
+  const options = instance?.$options as { name?: string; __name?: string } | undefined;
+  fetch('/__agentlog', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json', Authorization: 'Bearer {{AGENTLOG_TOKEN}}' },
+    body: JSON.stringify({
+      timestamp: new Date().toISOString(),
+      source: 'frontend',
+      error_type: 'RENDER_ERROR',
+      message: e.message.slice(0, 500),
+      context: {
+        component: options?.name || options?.__name || 'Anonymous',
+        info,
+        stack_trace: e.stack?.slice(0, 2048),
+      },
+    }),
+  }).catch(() => {});
+};
+
+export const agentlogVue = {
+  install(app: App) {
+    const previous = app.config.errorHandler;
+    app.config.errorHandler = (err, instance, info) => {
+      report(err, instance, info);
+      if (previous) previous(err, instance, info);
+      else console.error(err);
+    };
+  },
+};
+
+// Call first in error boundary components whose onErrorCaptured returns
+// false, since errors they stop never reach app.config.errorHandler
+export function useAgentlogErrorCapture(): void {
+  onErrorCaptured((err, instance, info) => {
+    report(err, instance, info);
+  });
+}
+`
+
+const svelteKitClientHooks = `// agentlog:installed - SvelteKit client error capture
+// SvelteKit passes unexpected errors from loading and rendering pages to
+// handleError instead of window.onerror; it loads this file itself.
+import type { HandleClientError } from '@sveltejs/kit';
+import { dev } from '$app/environment';
+
+export const handleError: HandleClientError = ({ error, event, status, message }) => {
+  if (dev) {
+    const e = error instanceof Error ? error : new Error(String(error));
+    fetch('/__agentlog', {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json', Authorization: 'Bearer {{AGENTLOG_TOKEN}}' },
+      body: JSON.stringify({
+        timestamp: new Date().toISOString(),
+        source: 'frontend',
+        error_type: 'RENDER_ERROR',
+        message: e.message.slice(0, 500),
+        endpoint: event.url.pathname,
+        context: { route_id: event.route.id, status, stack_trace: e.stack?.slice(0, 2048) },
+      }),
+    }).catch(() => {});
+  }
+  return { message };
+};
+`
+
+const svelteKitServerHooks = `// agentlog:installed - SvelteKit server error capture
+// SvelteKit passes unexpected errors from load functions, actions, and
+// endpoints to handleError; it loads this file itself.
+import type { HandleServerError } from '@sveltejs/kit';
+import { dev } from '$app/environment';
+import { appendFileSync, mkdirSync } from 'node:fs';
+import { hostname } from 'node:os';
+
+export const handleError: HandleServerError = ({ error, event, status, message }) => {
+  if (dev) {
+    const e = error instanceof Error ? error : new Error(String(error));
+    try {
+      mkdirSync('.agentlog', { recursive: true });
+      appendFileSync('.agentlog/errors.jsonl', JSON.stringify({
+        timestamp: new Date().toISOString(),
+        source: 'backend',
+        error_type: 'REQUEST_ERROR',
+        message: e.message.slice(0, 500),
+        endpoint: event.url.pathname,
+        environment: process.env.AGENTLOG_ENV || 'dev',
+        host: process.env.AGENTLOG_HOST || hostname(),
+        user: process.env.AGENTLOG_USER || process.env.USER || process.env.USERNAME,
+        agent: process.env.AGENTLOG_AGENT || (process.env.CLAUDE_SESSION_ID ? 'claude:' + process.env.CLAUDE_SESSION_ID : undefined),
+        context: { route_id: event.route.id, status, method: event.request.method, stack_trace: e.stack?.slice(0, 2048) },
+      }) + '\n');
+    } catch {}
+  }
+  return { message };
+};
+`
+
+const angularErrorHandler = `// agentlog:installed - Angular error capture
+// Angular catches errors from components, templates, and zone tasks in its
+// ErrorHandler, so window.onerror never sees them. Register this one in
+// app.config.ts (or your AppModule's providers):
+//   import { ErrorHandler } from '@angular/core';
+//   import { AgentlogErrorHandler } from './agentlog-error-handler';
+//   providers: [{ provide: ErrorHandler, useClass: AgentlogErrorHandler }]
+import { ErrorHandler, Injectable, isDevMode } from '@angular/core';
+
+@Injectable()
+export class AgentlogErrorHandler extends ErrorHandler {
+  override handleError(error: unknown): void {
+    if (isDevMode()) {
+      // Rejected promises arrive wrapped, with the original as .rejection
+      const cause = (error as { rejection?: unknown } | null)?.rejection ?? error;
+      const e = cause instanceof Error ? cause : new Error(String(cause));
+      fetch('/__agentlog', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json', Authorization: 'Bearer {{AGENTLOG_TOKEN}}' },
+        body: JSON.stringify({
+          timestamp: new Date().toISOString(),
+          source: 'frontend',
+          error_type: 'UNCAUGHT_ERROR',
+          message: e.message.slice(0, 500),
+          endpoint: location.pathname,
+          context: { framework: 'angular', error_class: e.name, stack_trace: e.stack?.slice(0, 2048) },
+        }),
+      }).catch(() => {});
+    }
+    super.handleError(error);
+  }
+}
+`
+
+const celeryIntegration = `# agentlog:installed - Celery task failure capture
+# Usage: import agentlog_celery in the module that creates your Celery app
+# Celery catches task exceptions itself, so sys.excepthook never sees them.
+import json
+import os
+import socket
+import traceback as _traceback
+from datetime import datetime, timezone
+
+from celery.signals import task_failure
+
+
+def _summarize(value, limit=200):
+    text = repr(value)
+    return text if len(text) <= limit else text[:limit] + '...'
+
+
+@task_failure.connect
+def agentlog_task_failure(sender=None, task_id=None, exception=None, args=None, kwargs=None, traceback=None, **_):
+    if os.environ.get('ENV') == 'production':
+        return
+    try:
+        delivery = getattr(getattr(sender, 'request', None), 'delivery_info', None) or {}
+        entry = {
+            "timestamp": datetime.now(timezone.utc).isoformat(),
+            "source": "worker",
+            "error_type": "JOB_ERROR",
+            "message": str(exception)[:500],
+            "project": os.environ.get('AGENTLOG_PROJECT') or os.path.basename(os.getcwd()),
+            "environment": os.environ.get('AGENTLOG_ENV') or 'dev',
+            "host": os.environ.get('AGENTLOG_HOST') or socket.gethostname(),
+            "user": os.environ.get('AGENTLOG_USER') or os.environ.get('USER') or os.environ.get('USERNAME'),
+            "agent": os.environ.get('AGENTLOG_AGENT') or ('claude:' + os.environ['CLAUDE_SESSION_ID'] if os.environ.get('CLAUDE_SESSION_ID') else None),
+            "context": {
+                "queue": delivery.get('routing_key'),
+                "job_id": task_id,
+                "job_class": getattr(sender, 'name', None),
+                "args": _summarize({"args": args, "kwargs": kwargs}),
+                "error_class": type(exception).__name__,
+                "stack_trace": "".join(_traceback.format_exception(type(exception), exception, traceback))[:2048],
+            },
+        }
+        frames = _traceback.extract_tb(traceback) if traceback else []
+        if frames:
+            entry["file"] = frames[-1].filename
+            entry["line"] = frames[-1].lineno
+        os.makedirs('.agentlog', exist_ok=True)
+        with open('.agentlog/errors.jsonl', 'a') as f:
+            f.write(json.dumps(entry) + '\n')
+    except Exception:
+        pass  # never let logging break the worker
+`
+
+const rqIntegration = `# agentlog:installed - RQ job failure capture
+# Usage: rq worker --exception-handler agentlog_rq.agentlog_rq_handler
+#    or: Worker(queues, exception_handlers=[agentlog_rq_handler])
+# RQ catches job exceptions itself, so sys.excepthook never sees them.
+import json
+import os
+import socket
+import traceback as _traceback
+from datetime import datetime, timezone
+
+
+def _summarize(value, limit=200):
+    text = repr(value)
+    return text if len(text) <= limit else text[:limit] + '...'
+
+
+def agentlog_rq_handler(job, exc_type, exc_value, tb):
+    if os.environ.get('ENV') == 'production':
+        return True
+    try:
+        entry = {
+            "timestamp": datetime.now(timezone.utc).isoformat(),
+            "source": "worker",
+            "error_type": "JOB_ERROR",
+            "message": str(exc_value)[:500],
+            "project": os.environ.get('AGENTLOG_PROJECT') or os.path.basename(os.getcwd()),
+            "environment": os.environ.get('AGENTLOG_ENV') or 'dev',
+            "host": os.environ.get('AGENTLOG_HOST') or socket.gethostname(),
+            "user": os.environ.get('AGENTLOG_USER') or os.environ.get('USER') or os.environ.get('USERNAME'),
+            "agent": os.environ.get('AGENTLOG_AGENT') or ('claude:' + os.environ['CLAUDE_SESSION_ID'] if os.environ.get('CLAUDE_SESSION_ID') else None),
+            "context": {
+                "queue": job.origin,
+                "job_id": job.id,
+                "job_class": job.func_name,
+                "args": _summarize({"args": job.args, "kwargs": job.kwargs}),
+                "error_class": exc_type.__name__,
+                "stack_trace": "".join(_traceback.format_exception(exc_type, exc_value, tb))[:2048],
+            },
+        }
+        frames = _traceback.extract_tb(tb) if tb else []
+        if frames:
+            entry["file"] = frames[-1].filename
+            entry["line"] = frames[-1].lineno
+        os.makedirs('.agentlog', exist_ok=True)
+        with open('.agentlog/errors.jsonl', 'a') as f:
+            f.write(json.dumps(entry) + '\n')
+    except Exception:
+        pass  # never let logging break the worker
+    return True  # let RQ's other handlers run too
+`
+
+const sidekiqIntegration = `# agentlog:installed - Sidekiq job failure capture
+# Rails loads this initializer itself; elsewhere, require it where you
+# configure Sidekiq. Sidekiq rescues job exceptions to retry them, so they
+# never reach the Rails middleware.
+require 'json'
+require 'socket'
+require 'fileutils'
+require 'time'
+
+module AgentlogSidekiq
+  FILE = '.agentlog/errors.jsonl'
+
+  def self.record(error_type, error, job)
+    return if ENV['RAILS_ENV'] == 'production' || ENV['RACK_ENV'] == 'production'
+
+    job ||= {}
+    location = error.backtrace_locations&.first
+    entry = {
+      timestamp: Time.now.utc.iso8601(3),
+      source: 'worker',
+      error_type: error_type,
+      message: error.message.to_s[0, 500],
+      project: ENV['AGENTLOG_PROJECT'] || File.basename(Dir.pwd),
+      environment: ENV['AGENTLOG_ENV'] || 'dev',
+      host: ENV['AGENTLOG_HOST'] || Socket.gethostname,
+      user: ENV['AGENTLOG_USER'] || ENV['USER'] || ENV['USERNAME'],
+      agent: ENV['AGENTLOG_AGENT'] || (ENV['CLAUDE_SESSION_ID'] && "claude:#{ENV['CLAUDE_SESSION_ID']}"),
+      file: location&.path,
+      line: location&.lineno,
+      context: {
+        queue: job['queue'],
+        job_id: job['jid'],
+        job_class: job['wrapped'] || job['class'],
+        args: job['args'].inspect[0, 200],
+        retry_count: job['retry_count'],
+        error_class: error.class.name,
+        stack_trace: error.full_message(highlight: false)[0, 2048]
+      }.compact
+    }.compact
+
+    FileUtils.mkdir_p(File.dirname(FILE))
+    File.open(FILE, 'a') { |f| f.puts(entry.to_json) }
+  rescue StandardError
+    # Never let logging break the worker
+  end
+end
+
+Sidekiq.configure_server do |config|
+  # Every failed attempt, including ones that will be retried
+  config.error_handlers << proc { |error, ctx| AgentlogSidekiq.record('JOB_ERROR', error, ctx[:job]) }
+  # Jobs out of retries, moved to the dead set
+  config.death_handlers << ->(job, error) { AgentlogSidekiq.record('JOB_DEAD', error, job) }
+end
+`
+
+const bullmqIntegration = `// agentlog:installed - BullMQ job failure capture
+// Usage: import { captureWorkerFailures } from './.agentlog/bullmq';
+//        captureWorkerFailures(worker);
+// BullMQ catches processor errors to retry jobs, so the process-level
+// handlers in the Node snippet never see them.
+import { appendFileSync, mkdirSync } from 'fs';
+import { hostname } from 'os';
+import { basename } from 'path';
+import type { Job, Worker } from 'bullmq';
+
+const summarize = (value: unknown): string => {
+  let text: string;
+  try { text = JSON.stringify(value) ?? String(value); } catch { text = String(value); }
+  return text.length <= 200 ? text : text.slice(0, 200) + '...';
+};
+
+export function captureWorkerFailures(worker: Worker): Worker {
+  if (process.env.NODE_ENV === 'production') return worker;
+
+  worker.on('failed', (job: Job | undefined, err: Error) => {
+    const maxAttempts = job?.opts.attempts ?? 1;
+    const entry = {
+      timestamp: new Date().toISOString(),
+      source: 'worker',
+      error_type: job && job.attemptsMade >= maxAttempts ? 'JOB_DEAD' : 'JOB_ERROR',
+      message: String(err?.message ?? err).slice(0, 500),
+      project: process.env.AGENTLOG_PROJECT || basename(process.cwd()),
+      environment: process.env.AGENTLOG_ENV || (process.env.NODE_ENV === 'test' ? 'test' : 'dev'),
+      host: process.env.AGENTLOG_HOST || hostname(),
+      user: process.env.AGENTLOG_USER || process.env.USER || process.env.USERNAME,
+      agent: process.env.AGENTLOG_AGENT || (process.env.CLAUDE_SESSION_ID ? 'claude:' + process.env.CLAUDE_SESSION_ID : undefined),
+      context: {
+        queue: worker.name,
+        job_id: job?.id,
+        job_class: job?.name,
+        attempt: job?.attemptsMade,
+        max_attempts: maxAttempts,
+        args: summarize(job?.data),
+        stack_trace: err?.stack?.slice(0, 2048),
+      },
+    };
+    try {
+      mkdirSync('.agentlog', { recursive: true });
+      appendFileSync('.agentlog/errors.jsonl', JSON.stringify(entry) + '\n');
+    } catch {
+      // Silently fail - don't crash the worker for logging
+    }
+  });
+  return worker;
+}
+`
+
+// GraphQL servers turn resolver exceptions into "errors" in the response,
+// so nothing reaches the process-level handlers. Errors without a path come
+// from parsing or validating the client's query and aren't recorded.
+
+const apolloIntegration = `// agentlog:installed - Apollo Server resolver error capture
+// Usage: new ApolloServer({ typeDefs, resolvers, plugins: [agentlogApolloPlugin] })
+import { appendFileSync, mkdirSync } from 'fs';
+import { hostname } from 'os';
+import { basename } from 'path';
+import type { ApolloServerPlugin } from '@apollo/server';
+import type { GraphQLError } from 'graphql';
+
+const record = (err: GraphQLError, operation: string | undefined) => {
+  if (process.env.NODE_ENV === 'production' || !err.path) return;
+  const cause = err.originalError ?? err;
+  try {
+    mkdirSync('.agentlog', { recursive: true });
+    appendFileSync('.agentlog/errors.jsonl', JSON.stringify({
+      timestamp: new Date().toISOString(),
+      source: 'backend',
+      error_type: 'GRAPHQL_ERROR',
+      message: err.message.slice(0, 500),
+      project: process.env.AGENTLOG_PROJECT || basename(process.cwd()),
+      environment: process.env.AGENTLOG_ENV || (process.env.NODE_ENV === 'test' ? 'test' : 'dev'),
+      host: process.env.AGENTLOG_HOST || hostname(),
+      user: process.env.AGENTLOG_USER || process.env.USER || process.env.USERNAME,
+      agent: process.env.AGENTLOG_AGENT || (process.env.CLAUDE_SESSION_ID ? 'claude:' + process.env.CLAUDE_SESSION_ID : undefined),
+      context: {
+        operation: operation || 'anonymous',
+        path: err.path.join('.'),
+        code: err.extensions?.code,
+        stack_trace: cause.stack?.slice(0, 2048),
+      },
+    }) + '\n');
+  } catch {
+    // Silently fail - don't break requests for logging
+  }
+};
+
+export const agentlogApolloPlugin: ApolloServerPlugin = {
+  async requestDidStart() {
+    return {
+      async didEncounterErrors({ errors, operationName }) {
+        errors.forEach((err) => record(err, operationName ?? undefined));
+      },
+    };
+  },
+};
+`
+
+const yogaIntegration = `// agentlog:installed - GraphQL Yoga resolver error capture
+// Usage: createYoga({ schema, plugins: [agentlogYogaPlugin()] })
+import { appendFileSync, mkdirSync } from 'fs';
+import { hostname } from 'os';
+import { basename } from 'path';
+import type { Plugin } from 'graphql-yoga';
+import type { GraphQLError } from 'graphql';
+
+const record = (err: GraphQLError, operation: string | undefined) => {
+  if (process.env.NODE_ENV === 'production' || !err.path) return;
+  const cause = err.originalError ?? err;
+  try {
+    mkdirSync('.agentlog', { recursive: true });
+    appendFileSync('.agentlog/errors.jsonl', JSON.stringify({
+      timestamp: new Date().toISOString(),
+      source: 'backend',
+      error_type: 'GRAPHQL_ERROR',
+      message: err.message.slice(0, 500),
+      project: process.env.AGENTLOG_PROJECT || basename(process.cwd()),
+      environment: process.env.AGENTLOG_ENV || (process.env.NODE_ENV === 'test' ? 'test' : 'dev'),
+      host: process.env.AGENTLOG_HOST || hostname(),
+      user: process.env.AGENTLOG_USER || process.env.USER || process.env.USERNAME,
+      agent: process.env.AGENTLOG_AGENT || (process.env.CLAUDE_SESSION_ID ? 'claude:' + process.env.CLAUDE_SESSION_ID : undefined),
+      context: {
+        operation: operation || 'anonymous',
+        path: err.path.join('.'),
+        code: err.extensions?.code,
+        stack_trace: cause.stack?.slice(0, 2048),
+      },
+    }) + '\n');
+  } catch {
+    // Silently fail - don't break requests for logging
+  }
+};
+
+export const agentlogYogaPlugin = (): Plugin => ({
+  onExecute({ args }) {
+    return {
+      onExecuteDone({ result }) {
+        // Subscriptions stream results; only single results carry errors here
+        const errors = (result as { errors?: readonly GraphQLError[] }).errors;
+        errors?.forEach((err) => record(err, args.operationName ?? undefined));
+      },
+    };
+  },
+});
+`
+
+const gqlgenIntegration = `// agentlog:installed - gqlgen resolver error capture
+// Usage: copy next to your server setup, then
+//   srv.SetErrorPresenter(agentlogErrorPresenter)
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func agentlogErrorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	presented := graphql.DefaultErrorPresenter(ctx, err)
+	if os.Getenv("PRODUCTION") != "" || len(presented.Path) == 0 {
+		return presented
+	}
+
+	operation := "anonymous"
+	if graphql.HasOperationContext(ctx) {
+		if name := graphql.GetOperationContext(ctx).OperationName; name != "" {
+			operation = name
+		}
+	}
+	project := os.Getenv("AGENTLOG_PROJECT")
+	if project == "" {
+		wd, _ := os.Getwd()
+		project = filepath.Base(wd)
+	}
+	environment := os.Getenv("AGENTLOG_ENV")
+	if environment == "" {
+		environment = "dev"
+	}
+	host := os.Getenv("AGENTLOG_HOST")
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	user := os.Getenv("AGENTLOG_USER")
+	if user == "" {
+		user = os.Getenv("USER")
+	}
+	agent := os.Getenv("AGENTLOG_AGENT")
+	if session := os.Getenv("CLAUDE_SESSION_ID"); agent == "" && session != "" {
+		agent = "claude:" + session
+	}
+	message := presented.Message
+	if len(message) > 500 {
+		message = message[:500]
+	}
+
+	entry := map[string]interface{}{
+		"timestamp":   time.Now().UTC().Format(time.RFC3339Nano),
+		"source":      "backend",
+		"error_type":  "GRAPHQL_ERROR",
+		"message":     message,
+		"project":     project,
+		"environment": environment,
+		"host":        host,
+		"user":        user,
+		"agent":       agent,
+		"context": map[string]interface{}{
+			"operation": operation,
+			"path":      presented.Path.String(),
+			"code":      presented.Extensions["code"],
+		},
+	}
+	if data, err := json.Marshal(entry); err == nil {
+		os.MkdirAll(".agentlog", 0755)
+		if f, err := os.OpenFile(".agentlog/errors.jsonl", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err == nil {
+			f.Write(append(data, '\n'))
+			f.Close()
+		}
+	}
+	return presented
+}
+`
+
+const grpcGoIntegration = `// agentlog:installed - gRPC server error capture
+// Usage: copy next to your server setup and pass
+// grpc.ChainUnaryInterceptor(agentlogUnaryInterceptor) and
+// grpc.ChainStreamInterceptor(agentlogStreamInterceptor) to grpc.NewServer.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+func agentlogUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if err != nil {
+		logGRPCError(info.FullMethod, "unary", err)
+	}
+	return resp, err
+}
+
+func agentlogStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	err := handler(srv, ss)
+	if err != nil {
+		logGRPCError(info.FullMethod, "stream", err)
+	}
+	return err
+}
+
+func logGRPCError(method, kind string, err error) {
+	if os.Getenv("PRODUCTION") != "" {
+		return
+	}
+	st := status.Convert(err)
+	project := os.Getenv("AGENTLOG_PROJECT")
+	if project == "" {
+		wd, _ := os.Getwd()
+		project = filepath.Base(wd)
+	}
+	environment := os.Getenv("AGENTLOG_ENV")
+	if environment == "" {
+		environment = "dev"
+	}
+	host := os.Getenv("AGENTLOG_HOST")
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	user := os.Getenv("AGENTLOG_USER")
+	if user == "" {
+		user = os.Getenv("USER")
+	}
+	agent := os.Getenv("AGENTLOG_AGENT")
+	if session := os.Getenv("CLAUDE_SESSION_ID"); agent == "" && session != "" {
+		agent = "claude:" + session
+	}
+	message := st.Message()
+	if len(message) > 500 {
+		message = message[:500]
+	}
+
+	entry := map[string]interface{}{
+		"timestamp":   time.Now().UTC().Format(time.RFC3339Nano),
+		"source":      "backend",
+		"error_type":  "GRPC_ERROR",
+		"message":     message,
+		"endpoint":    method,
+		"project":     project,
+		"environment": environment,
+		"host":        host,
+		"user":        user,
+		"agent":       agent,
+		"context": map[string]interface{}{
+			"method": method,
+			"code":   st.Code().String(),
+			"rpc":    kind,
+		},
+	}
+	if data, err := json.Marshal(entry); err == nil {
+		os.MkdirAll(".agentlog", 0755)
+		if f, err := os.OpenFile(".agentlog/errors.jsonl", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err == nil {
+			f.Write(append(data, '\n'))
+			f.Close()
+		}
+	}
+}
+`
+
+const grpcNodeIntegration = `// agentlog:installed - gRPC server error capture for @grpc/grpc-js
+// Usage: server.addService(Greeter.service, withAgentlog('helloworld.Greeter', impl));
+// Handlers report errors through their callback (unary, client streaming)
+// or by emitting 'error' on the call (server and bidi streaming), so the
+// process-level handlers never see them.
+import { appendFileSync, mkdirSync } from 'fs';
+import { hostname } from 'os';
+import { basename } from 'path';
+import { status } from '@grpc/grpc-js';
+
+type GrpcError = { code?: number; details?: string; message?: string; stack?: string };
+
+const record = (method: string, rpc: string, err: GrpcError) => {
+  if (process.env.NODE_ENV === 'production') return;
+  try {
+    mkdirSync('.agentlog', { recursive: true });
+    appendFileSync('.agentlog/errors.jsonl', JSON.stringify({
+      timestamp: new Date().toISOString(),
+      source: 'backend',
+      error_type: 'GRPC_ERROR',
+      message: String(err.details || err.message || err).slice(0, 500),
+      endpoint: method,
+      project: process.env.AGENTLOG_PROJECT || basename(process.cwd()),
+      environment: process.env.AGENTLOG_ENV || (process.env.NODE_ENV === 'test' ? 'test' : 'dev'),
+      host: process.env.AGENTLOG_HOST || hostname(),
+      user: process.env.AGENTLOG_USER || process.env.USER || process.env.USERNAME,
+      agent: process.env.AGENTLOG_AGENT || (process.env.CLAUDE_SESSION_ID ? 'claude:' + process.env.CLAUDE_SESSION_ID : undefined),
+      context: {
+        method,
+        code: err.code !== undefined ? status[err.code] ?? err.code : 'UNKNOWN',
+        rpc,
+        stack_trace: err.stack?.slice(0, 2048),
+      },
+    }) + '\n');
+  } catch {
+    // Silently fail - don't break calls for logging
+  }
+};
+
+// withAgentlog wraps every handler of a service implementation
+export function withAgentlog<T extends object>(serviceName: string, impl: T): T {
+  const wrapped: Record<string, unknown> = {};
+  for (const [name, handler] of Object.entries(impl)) {
+    if (typeof handler !== 'function') {
+      wrapped[name] = handler;
+      continue;
+    }
+    const method = '/' + serviceName + '/' + name;
+    wrapped[name] = (call: any, callback?: (err: GrpcError | null, ...rest: unknown[]) => void) => {
+      try {
+        if (typeof callback === 'function') {
+          return handler.call(impl, call, (err: GrpcError | null, ...rest: unknown[]) => {
+            if (err) record(method, 'unary', err);
+            callback(err, ...rest);
+          });
+        }
+        call.on?.('error', (err: GrpcError) => record(method, 'stream', err));
+        return handler.call(impl, call);
+      } catch (err) {
+        record(method, typeof callback === 'function' ? 'unary' : 'stream', err as GrpcError);
+        throw err;
+      }
+    };
+  }
+  return wrapped as T;
+}
+`
+
+const electronMainIntegration = `// agentlog:installed - Electron main process error capture
+// Usage: import { initAgentlogMain } from './.agentlog/electron-main';
+//        initAgentlogMain(); // before creating windows
+// Renderers can't write to the project, so the preload script forwards
+// their errors here over IPC and this process writes them.
+import { app, crashReporter, ipcMain } from 'electron';
+import { appendFileSync, mkdirSync } from 'fs';
+import { hostname } from 'os';
+import { basename, join } from 'path';
+
+const logDir = join(process.env.AGENTLOG_DIR || process.cwd(), '.agentlog');
+
+const write = (entry: Record<string, unknown>) => {
+  try {
+    mkdirSync(logDir, { recursive: true });
+    appendFileSync(join(logDir, 'errors.jsonl'), JSON.stringify({
+      timestamp: new Date().toISOString(),
+      project: process.env.AGENTLOG_PROJECT || basename(process.cwd()),
+      environment: process.env.AGENTLOG_ENV || 'dev',
+      host: process.env.AGENTLOG_HOST || hostname(),
+      user: process.env.AGENTLOG_USER || process.env.USER || process.env.USERNAME,
+      agent: process.env.AGENTLOG_AGENT || (process.env.CLAUDE_SESSION_ID ? 'claude:' + process.env.CLAUDE_SESSION_ID : undefined),
+      ...entry,
+    }) + '\n');
+  } catch {
+    // Silently fail - don't crash the app for logging
+  }
+};
+
+const fromError = (errorType: string, err: unknown) => {
+  const e = err instanceof Error ? err : new Error(String(err));
+  write({
+    source: 'backend',
+    error_type: errorType,
+    message: e.message.slice(0, 500),
+    context: { process: 'main', stack_trace: e.stack?.slice(0, 2048) },
+  });
+};
+
+export function initAgentlogMain(): void {
+  if (app.isPackaged) return;
+
+  // Native crash dumps stay local; PROCESS_CRASH entries point at them
+  crashReporter.start({ uploadToServer: false });
+
+  process.on('uncaughtException', (err) => {
+    fromError('UNCAUGHT_ERROR', err);
+    console.error(err);
+  });
+  process.on('unhandledRejection', (reason) => fromError('UNHANDLED_REJECTION', reason));
+
+  app.on('render-process-gone', (_event, contents, details) => {
+    write({
+      source: 'frontend',
+      error_type: 'PROCESS_CRASH',
+      message: 'Renderer process gone: ' + details.reason,
+      endpoint: contents.getURL(),
+      context: { process: 'renderer', reason: details.reason, exit_code: details.exitCode, crash_dumps: app.getPath('crashDumps') },
+    });
+  });
+  app.on('child-process-gone', (_event, details) => {
+    write({
+      source: 'backend',
+      error_type: 'PROCESS_CRASH',
+      message: details.type + ' process gone: ' + details.reason,
+      context: { process: details.type, reason: details.reason, exit_code: details.exitCode, crash_dumps: app.getPath('crashDumps') },
+    });
+  });
+
+  // Errors forwarded by electron-preload.ts
+  ipcMain.on('agentlog:error', (_event, entry: Record<string, unknown>) => {
+    write({ ...entry, source: 'frontend' });
+  });
+}
+`
+
+const electronPreloadIntegration = `// agentlog:installed - Electron renderer error capture
+// Usage: import './.agentlog/electron-preload'; at the top of each preload
+// script. Entries are sent to initAgentlogMain() in the main process.
+import { ipcRenderer } from 'electron';
+
+const send = (errorType: string, err: unknown, context: Record<string, unknown> = {}) => {
+  const e = err instanceof Error ? err : new Error(String(err));
+  ipcRenderer.send('agentlog:error', {
+    error_type: errorType,
+    message: e.message.slice(0, 500),
+    endpoint: window.location.pathname,
+    context: { process: 'renderer', url: window.location.href, stack_trace: e.stack?.slice(0, 2048), ...context },
+  });
+};
+
+window.addEventListener('error', (event) => {
+  send('UNCAUGHT_ERROR', event.error ?? event.message, { file: event.filename, line: event.lineno, column: event.colno });
+});
+window.addEventListener('unhandledrejection', (event) => {
+  send('UNHANDLED_REJECTION', event.reason);
+});
+`
+
+const workersIntegration = `// agentlog:installed - Cloudflare Workers error capture
+// Usage: export default withAgentlog({ async fetch(request, env, ctx) { ... } });
+// Workers have no filesystem, so errors are posted to 'agentlog serve' at
+// AGENTLOG_URL, set in wrangler.toml for local development:
+//   [vars]
+//   AGENTLOG_URL = "http://localhost:7654/__agentlog"
+// Leave it unset in production and nothing is sent.
+
+type AgentlogEnv = { AGENTLOG_URL?: string };
+type FetchHandler<E> = (request: Request, env: E, ctx: ExecutionContext) => Response | Promise<Response>;
+
+// The URL comes from env, which only handlers see; the global listener
+// uses the last one a request brought in
+let agentlogURL: string | undefined;
+
+const send = (errorType: string, err: unknown, context: Record<string, unknown> = {}): Promise<unknown> => {
+  if (!agentlogURL) return Promise.resolve();
+  const e = err instanceof Error ? err : new Error(String(err));
+  return fetch(agentlogURL, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json', Authorization: 'Bearer {{AGENTLOG_TOKEN}}' },
+    body: JSON.stringify({
+      timestamp: new Date().toISOString(),
+      source: 'backend',
+      error_type: errorType,
+      message: e.message.slice(0, 500),
+      context: { runtime: 'cloudflare-workers', stack_trace: e.stack?.slice(0, 2048), ...context },
+    }),
+  }).catch(() => {});
+};
+
+addEventListener('unhandledrejection', (event) => {
+  send('UNHANDLED_REJECTION', (event as PromiseRejectionEvent).reason);
+});
+
+// withAgentlog wraps a module worker's fetch handler, reporting thrown
+// errors as REQUEST_ERROR before rethrowing them
+export function withAgentlog<E extends AgentlogEnv>(handler: { fetch: FetchHandler<E> } & Record<string, unknown>) {
+  return {
+    ...handler,
+    async fetch(request: Request, env: E, ctx: ExecutionContext): Promise<Response> {
+      agentlogURL = env.AGENTLOG_URL;
+      try {
+        return await handler.fetch(request, env, ctx);
+      } catch (err) {
+        const url = new URL(request.url);
+        ctx.waitUntil(send('REQUEST_ERROR', err, { method: request.method, url: url.pathname }).then(() => {}));
+        throw err;
+      }
+    },
+  };
+}
+`
+
+// tauriRustIntegration is the Rust snippet's panic hook, writing to the
+// project root rather than src-tauri (where 'tauri dev' runs the app), plus
+// the command the webview capture calls
+var tauriRustIntegration = `// agentlog:installed - Tauri error capture
+// Usage: mod agentlog; in main.rs, then
+//   agentlog::init_agentlog();
+//   tauri::Builder::default()
+//       .invoke_handler(tauri::generate_handler![agentlog::agentlog_log])
+` + strings.NewReplacer(
+	"// agentlog error handler - add to your main.rs\n", "",
+	`create_dir_all(".agentlog")`, `create_dir_all(agentlog_dir())`,
+	`.open(".agentlog/errors.jsonl")`, `.open(agentlog_dir().join("errors.jsonl"))`,
+	"\n// Call at application startup\n// fn main() { init_agentlog(); ... }", "",
+).Replace(snippetRust) + `
+
+use std::path::PathBuf;
+
+// The project root: src-tauri's parent
+fn agentlog_dir() -> PathBuf {
+    PathBuf::from(env!("CARGO_MANIFEST_DIR")).join("..").join(".agentlog")
+}
+
+// Appends an entry sent by the webview capture (.agentlog/tauri.ts), which
+// can't write to the filesystem itself
+#[tauri::command]
+pub fn agentlog_log(mut entry: serde_json::Value) {
+    if std::env::var("PRODUCTION").is_ok() {
+        return;
+    }
+    let Some(obj) = entry.as_object_mut() else {
+        return;
+    };
+    obj.insert("source".to_string(), json!("frontend"));
+    obj.entry("timestamp").or_insert_with(|| json!(Utc::now().to_rfc3339()));
+    obj.entry("environment").or_insert_with(|| {
+        json!(std::env::var("AGENTLOG_ENV").unwrap_or_else(|_| "dev".to_string()))
+    });
+
+    let _ = create_dir_all(agentlog_dir());
+    if let Ok(mut file) = OpenOptions::new().create(true).append(true).open(agentlog_dir().join("errors.jsonl")) {
+        let _ = file.write_all(format!("{}\n", entry).as_bytes());
+    }
+}
+`
+
+const tauriWebviewIntegration = `// agentlog:installed - Tauri webview error capture
+// Usage: import './.agentlog/tauri'; in the frontend entry point.
+// Entries go through the agentlog_log command in src-tauri/src/agentlog.rs.
+// Tauri 1.x exports invoke from '@tauri-apps/api/tauri' instead.
+import { invoke } from '@tauri-apps/api/core';
+
+const send = (errorType: string, err: unknown, context: Record<string, unknown> = {}) => {
+  const e = err instanceof Error ? err : new Error(String(err));
+  invoke('agentlog_log', {
+    entry: {
+      timestamp: new Date().toISOString(),
+      error_type: errorType,
+      message: e.message.slice(0, 500),
+      endpoint: window.location.pathname,
+      context: { stack_trace: e.stack?.slice(0, 2048), ...context },
+    },
+  }).catch(() => {});
+};
+
+if (import.meta.env?.DEV !== false) {
+  window.addEventListener('error', (event) => {
+    send('UNCAUGHT_ERROR', event.error ?? event.message, { file: event.filename, line: event.lineno, column: event.colno });
+  });
+  window.addEventListener('unhandledrejection', (event) => {
+    send('UNHANDLED_REJECTION', event.reason);
+  });
+}
+`
+
+const lambdaNodeIntegration = `// agentlog:installed - AWS Lambda invocation error capture
+// Usage: export const handler = withAgentlog(async (event, context) => { ... });
+// Records only under 'serverless offline' (IS_OFFLINE) or 'sam local'
+// (AWS_SAM_LOCAL). sam local runs handlers in a container with the code
+// mounted read-only, so set AGENTLOG_URL to post to 'agentlog serve'
+// instead: AGENTLOG_URL=http://host.docker.internal:7654/__agentlog
+import { appendFileSync, mkdirSync } from 'fs';
+import { hostname } from 'os';
+import { basename } from 'path';
+
+type LambdaContext = { functionName: string; awsRequestId: string };
+type Handler<E, R> = (event: E, context: LambdaContext) => Promise<R>;
+
+const isLocal = () => !!(process.env.IS_OFFLINE || process.env.AWS_SAM_LOCAL || process.env.AGENTLOG_URL);
+
+// summarizeEvent names the trigger without copying the payload
+const summarizeEvent = (event: any): string => {
+  if (event?.requestContext?.http) return event.requestContext.http.method + ' ' + event.rawPath;
+  if (event?.httpMethod) return event.httpMethod + ' ' + event.path;
+  if (Array.isArray(event?.Records) && event.Records.length > 0) {
+    const r = event.Records[0];
+    return (r.eventSource || r.EventSource || 'records') + ' x' + event.Records.length;
+  }
+  if (event?.source && event?.['detail-type']) return event.source + ': ' + event['detail-type'];
+  return event && typeof event === 'object' ? 'keys: ' + Object.keys(event).slice(0, 10).join(', ') : String(event);
+};
+
+const record = async (err: unknown, event: unknown, context: LambdaContext) => {
+  const e = err instanceof Error ? err : new Error(String(err));
+  const entry = {
+    timestamp: new Date().toISOString(),
+    source: 'backend',
+    error_type: 'INVOCATION_ERROR',
+    message: e.message.slice(0, 500),
+    project: process.env.AGENTLOG_PROJECT || basename(process.cwd()),
+    environment: process.env.AGENTLOG_ENV || 'dev',
+    host: process.env.AGENTLOG_HOST || hostname(),
+    user: process.env.AGENTLOG_USER || process.env.USER || process.env.USERNAME,
+    agent: process.env.AGENTLOG_AGENT || (process.env.CLAUDE_SESSION_ID ? 'claude:' + process.env.CLAUDE_SESSION_ID : undefined),
+    context: {
+      function_name: context.functionName,
+      request_id: context.awsRequestId,
+      event: summarizeEvent(event).slice(0, 200),
+      stack_trace: e.stack?.slice(0, 2048),
+    },
+  };
+  try {
+    if (process.env.AGENTLOG_URL) {
+      await fetch(process.env.AGENTLOG_URL, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json', Authorization: 'Bearer {{AGENTLOG_TOKEN}}' },
+        body: JSON.stringify(entry),
+      });
+      return;
+    }
+    mkdirSync('.agentlog', { recursive: true });
+    appendFileSync('.agentlog/errors.jsonl', JSON.stringify(entry) + '\n');
+  } catch {
+    // Silently fail - don't break invocations for logging
+  }
+};
+
+// withAgentlog records errors thrown by handler, then rethrows them so the
+// invocation still fails
+export function withAgentlog<E, R>(handler: Handler<E, R>): Handler<E, R> {
+  return async (event, context) => {
+    try {
+      return await handler(event, context);
+    } catch (err) {
+      if (isLocal()) await record(err, event, context);
+      throw err;
+    }
+  };
+}
+`
+
+const lambdaPythonIntegration = `# agentlog:installed - AWS Lambda invocation error capture
+# Usage: from agentlog_lambda import agentlog_handler, then decorate handlers
+# with @agentlog_handler. Records only under 'serverless offline'
+# (IS_OFFLINE) or 'sam local' (AWS_SAM_LOCAL). sam local mounts the code
+# read-only, so set AGENTLOG_URL to post to 'agentlog serve' instead:
+# AGENTLOG_URL=http://host.docker.internal:7654/__agentlog
+import functools
+import json
+import os
+import socket
+import traceback as _traceback
+import urllib.request
+from datetime import datetime, timezone
+
+
+def _is_local():
+    return any(os.environ.get(k) for k in ('IS_OFFLINE', 'AWS_SAM_LOCAL', 'AGENTLOG_URL'))
+
+
+def _summarize_event(event):
+    """Name the trigger without copying the payload."""
+    if not isinstance(event, dict):
+        return repr(event)[:200]
+    http = (event.get('requestContext') or {}).get('http')
+    if http:
+        return f"{http.get('method')} {event.get('rawPath')}"
+    if event.get('httpMethod'):
+        return f"{event['httpMethod']} {event.get('path')}"
+    records = event.get('Records')
+    if isinstance(records, list) and records:
+        first = records[0] if isinstance(records[0], dict) else {}
+        return f"{first.get('eventSource') or first.get('EventSource') or 'records'} x{len(records)}"
+    if event.get('source') and event.get('detail-type'):
+        return f"{event['source']}: {event['detail-type']}"
+    return "keys: " + ", ".join(list(event)[:10])
+
+
+def _record(exc, event, context):
+    entry = {
+        "timestamp": datetime.now(timezone.utc).isoformat(),
+        "source": "backend",
+        "error_type": "INVOCATION_ERROR",
+        "message": str(exc)[:500],
+        "project": os.environ.get('AGENTLOG_PROJECT') or os.path.basename(os.getcwd()),
+        "environment": os.environ.get('AGENTLOG_ENV') or 'dev',
+        "host": os.environ.get('AGENTLOG_HOST') or socket.gethostname(),
+        "user": os.environ.get('AGENTLOG_USER') or os.environ.get('USER') or os.environ.get('USERNAME'),
+        "agent": os.environ.get('AGENTLOG_AGENT') or ('claude:' + os.environ['CLAUDE_SESSION_ID'] if os.environ.get('CLAUDE_SESSION_ID') else None),
+        "context": {
+            "function_name": getattr(context, 'function_name', None),
+            "request_id": getattr(context, 'aws_request_id', None),
+            "event": _summarize_event(event)[:200],
+            "error_class": type(exc).__name__,
+            "stack_trace": _traceback.format_exc()[:2048],
+        },
+    }
+    frames = _traceback.extract_tb(exc.__traceback__)
+    if frames:
+        entry["file"] = frames[-1].filename
+        entry["line"] = frames[-1].lineno
+    url = os.environ.get('AGENTLOG_URL')
+    if url:
+        request = urllib.request.Request(url, data=json.dumps(entry).encode(), method='POST', headers={
+            'Content-Type': 'application/json',
+            'Authorization': 'Bearer {{AGENTLOG_TOKEN}}',
+        })
+        urllib.request.urlopen(request, timeout=2).close()
+        return
+    os.makedirs('.agentlog', exist_ok=True)
+    with open('.agentlog/errors.jsonl', 'a') as f:
+        f.write(json.dumps(entry) + '\n')
+
+
+def agentlog_handler(handler):
+    """Record exceptions raised by a Lambda handler, then re-raise them."""
+    @functools.wraps(handler)
+    def wrapper(event, context):
+        try:
+            return handler(event, context)
+        except Exception as exc:
+            if _is_local():
+                try:
+                    _record(exc, event, context)
+                except Exception:
+                    pass  # never let logging break the invocation
+            raise
+    return wrapper
+`
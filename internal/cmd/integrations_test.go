@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveIntegrations(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"dependencies": {"vue": "^3.4.0"}}`), 0644)
+
+	list, err := resolveIntegrations(dir, []string{" Vue "}, true)
+	if err != nil {
+		t.Fatalf("resolveIntegrations() error = %v", err)
+	}
+	if len(list) != 1 || list[0].Name != "vue" {
+		t.Errorf("detected and requested integrations should be merged, got %+v", list)
+	}
+
+	if _, err := resolveIntegrations(t.TempDir(), []string{"ember"}, true); err == nil || !strings.Contains(err.Error(), "available: angular, apollo, bullmq, celery, cloudflare-workers, electron, gqlgen, graphql-yoga, grpc-go, grpc-node, lambda, rq, sidekiq, sveltekit, tauri, vue") {
+		t.Errorf("unknown integrations should list the available ones, got %v", err)
+	}
+}
+
+func TestInit_VueIntegration(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(`{"dependencies": {"vue": "^3.4.0"}}`), 0644)
+
+	result, err := runInit(tmpDir, false, "", false)
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if len(result.Integrations) != 1 || result.Integrations[0] != "vue" {
+		t.Errorf("Integrations = %v, want [vue]", result.Integrations)
+	}
+	if !strings.Contains(result.Snippet, "// === VUE (.agentlog/vue.ts) ===") || !strings.Contains(result.Snippet, "app.config.errorHandler") {
+		t.Error("snippet should include the Vue integration")
+	}
+
+	result, err = runInit(tmpDir, false, "", true)
+	if err != nil {
+		t.Fatalf("init --install failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(tmpDir, ".agentlog", "vue.ts"))
+	if err != nil {
+		t.Fatalf("vue.ts not installed: %v", err)
+	}
+	for _, want := range []string{"onErrorCaptured", "component:", "RENDER_ERROR"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("vue.ts should contain %q", want)
+		}
+	}
+	if strings.Contains(string(content), tokenPlaceholder) {
+		t.Error("vue.ts should have the serve token filled in")
+	}
+
+	found := false
+	for _, a := range result.InstallActions {
+		found = found || a.Path == ".agentlog/vue.ts"
+	}
+	if !found {
+		t.Errorf("install actions should include vue.ts: %+v", result.InstallActions)
+	}
+}
+
+func TestInit_SvelteKitIntegration(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(`{"devDependencies": {"@sveltejs/kit": "^2.0.0", "svelte": "^4.0.0"}}`), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "svelte.config.js"), []byte("export default {}"), 0644)
+	os.MkdirAll(filepath.Join(tmpDir, "src", "routes"), 0755)
+	// An existing server hook is left for the user to merge
+	os.WriteFile(filepath.Join(tmpDir, "src", "hooks.server.ts"), []byte("export const handle = async ({ event, resolve }) => resolve(event);\n"), 0644)
+
+	result, err := runInit(tmpDir, false, "", true)
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if strings.Join(result.Integrations, ",") != "sveltekit" {
+		t.Errorf("Integrations = %v, want [sveltekit]", result.Integrations)
+	}
+
+	client, err := os.ReadFile(filepath.Join(tmpDir, "src", "hooks.client.ts"))
+	if err != nil {
+		t.Fatalf("hooks.client.ts not installed: %v", err)
+	}
+	if !strings.Contains(string(client), "HandleClientError") || !strings.Contains(string(client), "route_id: event.route.id") {
+		t.Errorf("unexpected hooks.client.ts:\n%s", client)
+	}
+	server, _ := os.ReadFile(filepath.Join(tmpDir, "src", "hooks.server.ts"))
+	if strings.Contains(string(server), "agentlog") {
+		t.Error("existing hooks.server.ts should not be modified")
+	}
+
+	ops := make(map[string]string)
+	for _, a := range result.InstallActions {
+		ops[a.Path] = a.Operation
+	}
+	if ops["src/hooks.client.ts"] != "create" || ops["src/hooks.server.ts"] != "skip" {
+		t.Errorf("unexpected install actions: %+v", result.InstallActions)
+	}
+
+	// Reinstalling leaves agentlog's own files alone without reporting them
+	result, _ = runInit(tmpDir, false, "", true)
+	for _, a := range result.InstallActions {
+		if a.Path == "src/hooks.client.ts" {
+			t.Errorf("installed hook should not be reported again: %+v", a)
+		}
+	}
+}
+
+func TestInit_AngularIntegration(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(`{"dependencies": {"@angular/core": "^17.0.0"}}`), 0644)
+
+	result, err := runInit(tmpDir, false, "", false)
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	for _, want := range []string{
+		"// === ANGULAR (src/app/agentlog-error-handler.ts) ===",
+		"export class AgentlogErrorHandler extends ErrorHandler",
+		"{ provide: ErrorHandler, useClass: AgentlogErrorHandler }",
+		"super.handleError(error);",
+	} {
+		if !strings.Contains(result.Snippet, want) {
+			t.Errorf("snippet should contain %q", want)
+		}
+	}
+}
+
+func TestInit_ElectronIntegration(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(`{"devDependencies": {"electron": "^30.0.0"}}`), 0644)
+
+	result, err := runInit(tmpDir, false, "", true)
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if strings.Join(result.Integrations, ",") != "electron" {
+		t.Errorf("Integrations = %v, want [electron]", result.Integrations)
+	}
+
+	main, err := os.ReadFile(filepath.Join(tmpDir, ".agentlog", "electron-main.ts"))
+	if err != nil {
+		t.Fatalf("electron-main.ts not installed: %v", err)
+	}
+	for _, want := range []string{"export function initAgentlogMain", "crashReporter.start({ uploadToServer: false })", "ipcMain.on('agentlog:error'", "'render-process-gone'"} {
+		if !strings.Contains(string(main), want) {
+			t.Errorf("electron-main.ts should contain %q", want)
+		}
+	}
+	preload, err := os.ReadFile(filepath.Join(tmpDir, ".agentlog", "electron-preload.ts"))
+	if err != nil {
+		t.Fatalf("electron-preload.ts not installed: %v", err)
+	}
+	if !strings.Contains(string(preload), "ipcRenderer.send('agentlog:error'") {
+		t.Errorf("preload should forward errors over IPC:\n%s", preload)
+	}
+}
+
+func TestInit_TauriIntegration(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(`{"devDependencies": {"@tauri-apps/cli": "^2.0.0"}}`), 0644)
+	os.MkdirAll(filepath.Join(tmpDir, "src-tauri", "src"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "src-tauri", "tauri.conf.json"), []byte("{}"), 0644)
+
+	result, err := runInit(tmpDir, false, "", true)
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if strings.Join(result.Integrations, ",") != "tauri" {
+		t.Errorf("Integrations = %v, want [tauri]", result.Integrations)
+	}
+
+	rust, err := os.ReadFile(filepath.Join(tmpDir, "src-tauri", "src", "agentlog.rs"))
+	if err != nil {
+		t.Fatalf("agentlog.rs not installed: %v", err)
+	}
+	for _, want := range []string{"pub fn init_agentlog()", "panic::set_hook(", "#[tauri::command]\npub fn agentlog_log(", `.open(agentlog_dir().join("errors.jsonl"))`} {
+		if !strings.Contains(string(rust), want) {
+			t.Errorf("agentlog.rs should contain %q", want)
+		}
+	}
+	if strings.Contains(string(rust), `".agentlog/errors.jsonl"`) {
+		t.Error("agentlog.rs should write to the project root, not relative to src-tauri")
+	}
+
+	webview, err := os.ReadFile(filepath.Join(tmpDir, ".agentlog", "tauri.ts"))
+	if err != nil {
+		t.Fatalf("tauri.ts not installed: %v", err)
+	}
+	if !strings.Contains(string(webview), "invoke('agentlog_log'") {
+		t.Errorf("webview capture should send through the Tauri command:\n%s", webview)
+	}
+}
+
+func TestInit_LambdaIntegration(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "template.yaml"), []byte("Transform: AWS::Serverless-2016-10-31\n"), 0644)
+
+	result, err := runInit(tmpDir, false, "", true)
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if strings.Join(result.Integrations, ",") != "lambda" {
+		t.Errorf("Integrations = %v, want [lambda]", result.Integrations)
+	}
+
+	node, err := os.ReadFile(filepath.Join(tmpDir, ".agentlog", "lambda.ts"))
+	if err != nil {
+		t.Fatalf("lambda.ts not installed: %v", err)
+	}
+	for _, want := range []string{"export function withAgentlog", "function_name: context.functionName", "event: summarizeEvent(event)", "process.env.AWS_SAM_LOCAL"} {
+		if !strings.Contains(string(node), want) {
+			t.Errorf("lambda.ts should contain %q", want)
+		}
+	}
+	python, err := os.ReadFile(filepath.Join(tmpDir, ".agentlog", "agentlog_lambda.py"))
+	if err != nil {
+		t.Fatalf("agentlog_lambda.py not installed: %v", err)
+	}
+	for _, want := range []string{"def agentlog_handler(handler):", `"function_name": getattr(context, 'function_name', None)`, "raise\n"} {
+		if !strings.Contains(string(python), want) {
+			t.Errorf("agentlog_lambda.py should contain %q", want)
+		}
+	}
+	if strings.Contains(string(node)+string(python), tokenPlaceholder) {
+		t.Error("installed wrappers should have the serve token filled in")
+	}
+}
+
+func TestInit_ServerIntegrations(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		content  string
+		path     string
+		want     []string
+	}{
+		{"celery", "requirements.txt", "celery[redis]>=5.3\n", ".agentlog/agentlog_celery.py", []string{"@task_failure.connect", `"job_id": task_id`, `"queue": delivery.get('routing_key')`}},
+		{"rq", "requirements.txt", "rq==1.16\n", ".agentlog/agentlog_rq.py", []string{"def agentlog_rq_handler(job, exc_type, exc_value, tb):", `"queue": job.origin`, "return True"}},
+		{"sidekiq", "Gemfile", "gem 'sidekiq'\n", "config/initializers/agentlog_sidekiq.rb", []string{"config.error_handlers <<", "config.death_handlers <<", "job_id: job['jid']"}},
+		{"apollo", "package.json", `{"dependencies": {"@apollo/server": "^4.10.0"}}`, ".agentlog/apollo.ts", []string{"didEncounterErrors", "operation: operation || 'anonymous'", "path: err.path.join('.')"}},
+		{"graphql-yoga", "package.json", `{"dependencies": {"graphql-yoga": "^5.0.0"}}`, ".agentlog/yoga.ts", []string{"onExecuteDone", "args.operationName", "GRAPHQL_ERROR"}},
+		{"gqlgen", "go.mod", "module x\n\nrequire github.com/99designs/gqlgen v0.17.45\n", ".agentlog/agentlog_gqlgen.go", []string{"func agentlogErrorPresenter(ctx context.Context, err error) *gqlerror.Error", "graphql.GetOperationContext(ctx).OperationName", `"path":      presented.Path.String()`}},
+		{"grpc-go", "go.mod", "module x\n\nrequire google.golang.org/grpc v1.62.0\n", ".agentlog/agentlog_grpc.go", []string{"func agentlogUnaryInterceptor(", "func agentlogStreamInterceptor(", `"code":   st.Code().String()`}},
+		{"grpc-node", "package.json", `{"dependencies": {"@grpc/grpc-js": "^1.10.0"}}`, ".agentlog/grpc.ts", []string{"export function withAgentlog", "call.on?.('error'", "status[err.code]"}},
+		{"cloudflare-workers", "wrangler.toml", "name = \"api\"\n", ".agentlog/worker.ts", []string{"export function withAgentlog", "agentlogURL = env.AGENTLOG_URL;", "addEventListener('unhandledrejection'", "ctx.waitUntil("}},
+		{"bullmq", "package.json", `{"dependencies": {"bullmq": "^5.0.0"}}`, ".agentlog/bullmq.ts", []string{"worker.on('failed'", "attempt: job?.attemptsMade", "source: 'worker'"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			os.WriteFile(filepath.Join(tmpDir, tt.manifest), []byte(tt.content), 0644)
+
+			result, err := runInit(tmpDir, false, "", true)
+			if err != nil {
+				t.Fatalf("init failed: %v", err)
+			}
+			if strings.Join(result.Integrations, ",") != tt.name {
+				t.Errorf("Integrations = %v, want [%s]", result.Integrations, tt.name)
+			}
+			content, err := os.ReadFile(filepath.Join(tmpDir, filepath.FromSlash(tt.path)))
+			if err != nil {
+				t.Fatalf("%s not installed: %v", tt.path, err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(content), want) {
+					t.Errorf("%s should contain %q", tt.path, want)
+				}
+			}
+		})
+	}
+}
+
+func TestInit_IntegrationFlag(t *testing.T) {
+	// Named integrations are added even when nothing is detected
+	result, err := initWithOptions(t.TempDir(), initOptions{Stacks: []string{"ruby"}, Integrations: []string{"sidekiq"}})
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if !strings.Contains(result.Snippet, "# === SIDEKIQ (config/initializers/agentlog_sidekiq.rb) ===") {
+		t.Error("snippet should include the Sidekiq integration under a Ruby comment header")
+	}
+}
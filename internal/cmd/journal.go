@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/agentlog/agentlog/internal/diag"
+	"github.com/agentlog/agentlog/internal/self"
+	"github.com/spf13/cobra"
+)
+
+var (
+	journalUnits  []string
+	journalUser   bool
+	journalSource string
+	journalSince  string
+)
+
+// journalCmd represents the journal command
+var journalCmd = &cobra.Command{
+	Use:   "journal",
+	Short: "Follow systemd journal records for units into .agentlog/errors.jsonl",
+	Long: `Follow 'journalctl -o json' for the chosen systemd units and append
+records with priority err or above (err, crit, alert, emerg) as LOG_ERROR
+entries with context.unit and context.priority.
+
+By default only new records are ingested; use --since to backfill.
+Use Ctrl+C to stop.
+
+Examples:
+  agentlog journal --unit postgresql
+  agentlog journal --unit api.service --unit worker.service
+  agentlog journal --user --unit myapp --since "10 min ago"`,
+	RunE: runJournal,
+}
+
+func init() {
+	rootCmd.AddCommand(journalCmd)
+
+	journalCmd.Flags().StringSliceVar(&journalUnits, "unit", nil, "Systemd unit to follow (repeatable, required)")
+	journalCmd.Flags().BoolVar(&journalUser, "user", false, "Follow user units (journalctl --user)")
+	journalCmd.Flags().StringVar(&journalSource, "source", "backend", "Source to record on ingested entries")
+	journalCmd.Flags().StringVar(&journalSince, "since", "", "Also ingest records since this time (passed to journalctl --since)")
+}
+
+func runJournal(cmd *cobra.Command, args []string) error {
+	// Determine base directory (use --path override or cwd)
+	baseDir := GetPathOverride()
+	if baseDir == "" {
+		var err error
+		baseDir, err = os.Getwd()
+		if err != nil {
+			self.LogError(".", "GETWD_ERROR", err.Error())
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+
+	if len(journalUnits) == 0 {
+		return invalidInput("--unit is required")
+	}
+
+	ingester, err := newStreamIngester(baseDir, "journal", journalSource)
+	if err != nil {
+		return err
+	}
+
+	if _, err := exec.LookPath("journalctl"); err != nil {
+		self.LogError(baseDir, "COMMAND_ERROR", "journalctl not found in PATH")
+		return fmt.Errorf("journalctl not found in PATH: journal ingestion requires systemd")
+	}
+
+	// Set up signal handling for graceful shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		cancel()
+	}()
+
+	if !IsJSONOutput() {
+		diag.Infof("Following journal for %v (Ctrl+C to stop)", journalUnits)
+	}
+
+	c := exec.CommandContext(ctx, "journalctl", journalctlArgs(journalUnits, journalUser, journalSince)...)
+	err = followCommand(c, cmd.OutOrStdout(), nil, ingester)
+	if err != nil && ctx.Err() == nil {
+		self.LogError(baseDir, "COMMAND_ERROR", fmt.Sprintf("journalctl failed: %v", err))
+		return fmt.Errorf("journalctl failed: %w", err)
+	}
+
+	return nil
+}
+
+// journalctlArgs builds the journalctl arguments for following units
+func journalctlArgs(units []string, user bool, since string) []string {
+	args := []string{"--follow", "--output", "json", "--priority", "err"}
+	if user {
+		args = append(args, "--user")
+	}
+	if since != "" {
+		args = append(args, "--since", since)
+	} else {
+		args = append(args, "--lines", "0")
+	}
+	for _, u := range units {
+		args = append(args, "--unit", u)
+	}
+	return args
+}
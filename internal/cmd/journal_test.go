@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJournalctlArgs(t *testing.T) {
+	args := strings.Join(journalctlArgs([]string{"api.service", "worker"}, false, ""), " ")
+	for _, want := range []string{"--follow", "--output json", "--priority err", "--lines 0", "--unit api.service", "--unit worker"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("args %q should contain %q", args, want)
+		}
+	}
+	if strings.Contains(args, "--user") {
+		t.Error("args should not contain --user by default")
+	}
+
+	args = strings.Join(journalctlArgs([]string{"myapp"}, true, "1 hour ago"), " ")
+	if !strings.Contains(args, "--user") || !strings.Contains(args, "--since 1 hour ago") {
+		t.Errorf("unexpected args: %q", args)
+	}
+	if strings.Contains(args, "--lines 0") {
+		t.Error("--since should replace --lines 0")
+	}
+}
+
+func TestParseJournal(t *testing.T) {
+	input := `{"__REALTIME_TIMESTAMP":"1765394372941000","PRIORITY":"3","_SYSTEMD_UNIT":"api.service","SYSLOG_IDENTIFIER":"api","_PID":"4242","MESSAGE":"database connection lost"}
+{"__REALTIME_TIMESTAMP":"1765394373000000","PRIORITY":"6","_SYSTEMD_UNIT":"api.service","MESSAGE":"request handled"}
+{"__REALTIME_TIMESTAMP":"1765394374000000","PRIORITY":"2","_SYSTEMD_UNIT":"user@1000.service","_SYSTEMD_USER_UNIT":"myapp.service","MESSAGE":[98,111,111,109]}
+-- No entries --
+`
+	entries, err := parseJournal(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseJournal() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("parseJournal() returned %d entries, want 2", len(entries))
+	}
+
+	e := entries[0]
+	if e.ErrorType != "LOG_ERROR" || e.Message != "database connection lost" {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if e.Timestamp != "2025-12-10T19:19:32.941Z" {
+		t.Errorf("Timestamp = %q, want 2025-12-10T19:19:32.941Z", e.Timestamp)
+	}
+	if e.Context["unit"] != "api.service" || e.Context["priority"] != "err" || e.Context["pid"] != "4242" {
+		t.Errorf("unexpected context: %v", e.Context)
+	}
+
+	if entries[1].Message != "boom" {
+		t.Errorf("byte-array MESSAGE should be decoded, got %q", entries[1].Message)
+	}
+	if entries[1].Context["unit"] != "myapp.service" {
+		t.Errorf("user unit should take precedence, got %v", entries[1].Context["unit"])
+	}
+	if entries[1].Context["priority"] != "crit" {
+		t.Errorf("priority = %v, want crit", entries[1].Context["priority"])
+	}
+}
+
+func TestJournalCommand_RequiresUnit(t *testing.T) {
+	originalPath := pathOverride
+	defer func() { pathOverride = originalPath }()
+	pathOverride = t.TempDir()
+
+	journalUnits = nil
+	err := runJournal(journalCmd, []string{})
+	if err == nil || !strings.Contains(err.Error(), "--unit") {
+		t.Errorf("expected --unit required error, got: %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/agentlog/agentlog/internal/diag"
+	"github.com/agentlog/agentlog/internal/self"
+	"github.com/spf13/cobra"
+)
+
+var (
+	k8sSelector       string
+	k8sNamespace      string
+	k8sParser         string
+	k8sSource         string
+	k8sSince          string
+	k8sMaxLogRequests int
+)
+
+// k8sCmd represents the k8s command
+var k8sCmd = &cobra.Command{
+	Use:   "k8s",
+	Short: "Stream Kubernetes pod logs into .agentlog/errors.jsonl",
+	Long: `Follow 'kubectl logs -f' for pods matching a label selector in the current
+kubecontext, apply a line parser, and append matching lines as entries
+tagged with context.pod, context.container, and context.namespace.
+
+Intended for dev clusters (kind, minikube, Tilt). By default only new log
+lines are ingested; use --since to backfill. Pods are resolved when the
+command starts, so restart it after pods are replaced. Use Ctrl+C to stop.
+
+Examples:
+  agentlog k8s --selector app=api
+  agentlog k8s --selector app=api --namespace dev
+  agentlog k8s --selector 'tier in (api,worker)' --since 10m`,
+	RunE: runK8s,
+}
+
+func init() {
+	rootCmd.AddCommand(k8sCmd)
+
+	k8sCmd.Flags().StringVarP(&k8sSelector, "selector", "l", "", "Label selector for pods to follow (required)")
+	k8sCmd.Flags().StringVarP(&k8sNamespace, "namespace", "n", "", "Namespace (default: the current kubecontext's namespace)")
+	k8sCmd.Flags().StringVar(&k8sParser, "parser", "errorlines", "Line parser to apply")
+	k8sCmd.Flags().StringVar(&k8sSource, "source", "backend", "Source to record on ingested entries")
+	k8sCmd.Flags().StringVar(&k8sSince, "since", "", "Also ingest logs newer than this duration (passed to kubectl logs --since)")
+	k8sCmd.Flags().IntVar(&k8sMaxLogRequests, "max-log-requests", 10, "Maximum number of containers to follow")
+}
+
+func runK8s(cmd *cobra.Command, args []string) error {
+	// Determine base directory (use --path override or cwd)
+	baseDir := GetPathOverride()
+	if baseDir == "" {
+		var err error
+		baseDir, err = os.Getwd()
+		if err != nil {
+			self.LogError(".", "GETWD_ERROR", err.Error())
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+
+	if k8sSelector == "" {
+		return invalidInput("--selector is required")
+	}
+
+	ingester, err := newStreamIngester(baseDir, k8sParser, k8sSource)
+	if err != nil {
+		return err
+	}
+
+	if _, err := exec.LookPath("kubectl"); err != nil {
+		self.LogError(baseDir, "COMMAND_ERROR", "kubectl not found in PATH")
+		return fmt.Errorf("kubectl not found in PATH: install kubectl or check your PATH")
+	}
+
+	namespace := k8sNamespace
+	if namespace == "" {
+		namespace = currentK8sNamespace()
+	}
+	ingester.context = map[string]interface{}{"namespace": namespace}
+
+	// Set up signal handling for graceful shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		cancel()
+	}()
+
+	if !IsJSONOutput() {
+		diag.Infof("Following logs for pods matching %s in namespace %s (Ctrl+C to stop)", k8sSelector, namespace)
+	}
+
+	c := exec.CommandContext(ctx, "kubectl", kubectlLogsArgs(k8sSelector, namespace, k8sSince, k8sMaxLogRequests)...)
+	err = followCommand(c, cmd.OutOrStdout(), splitK8sPrefix, ingester)
+	if err != nil && ctx.Err() == nil {
+		self.LogError(baseDir, "COMMAND_ERROR", fmt.Sprintf("kubectl logs failed: %v", err))
+		return fmt.Errorf("kubectl logs failed: %w", err)
+	}
+
+	return nil
+}
+
+// currentK8sNamespace returns the namespace of the current kubecontext,
+// falling back to "default"
+func currentK8sNamespace() string {
+	out, err := exec.Command("kubectl", "config", "view", "--minify", "--output", "jsonpath={..namespace}").Output()
+	if err != nil {
+		return "default"
+	}
+	if ns := strings.TrimSpace(string(out)); ns != "" {
+		return ns
+	}
+	return "default"
+}
+
+// kubectlLogsArgs builds the kubectl arguments for following pods by selector
+func kubectlLogsArgs(selector, namespace, since string, maxLogRequests int) []string {
+	args := []string{
+		"logs", "--follow", "--timestamps", "--prefix", "--all-containers",
+		"--selector", selector,
+		"--namespace", namespace,
+		"--max-log-requests", strconv.Itoa(maxLogRequests),
+	}
+	if since != "" {
+		args = append(args, "--since", since)
+	} else {
+		args = append(args, "--tail", "0")
+	}
+	return args
+}
+
+// splitK8sPrefix splits the "[pod/<pod>/<container>]" prefix added by
+// `kubectl logs --prefix` and the timestamp added by --timestamps
+func splitK8sPrefix(line string) (string, string, map[string]interface{}) {
+	var extra map[string]interface{}
+	if strings.HasPrefix(line, "[pod/") {
+		if end := strings.Index(line, "] "); end > 0 {
+			pod, container, _ := strings.Cut(line[len("[pod/"):end], "/")
+			extra = map[string]interface{}{"pod": pod}
+			if container != "" {
+				extra["container"] = container
+			}
+			line = line[end+2:]
+		}
+	}
+
+	line, timestamp, _ := splitDockerTimestamp(line)
+	return line, timestamp, extra
+}
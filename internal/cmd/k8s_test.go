@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestSplitK8sPrefix(t *testing.T) {
+	line, ts, extra := splitK8sPrefix("[pod/api-7d9f8b-x2kq/api] 2025-12-10T19:19:32.123456789Z ERROR: upstream timeout")
+	if line != "ERROR: upstream timeout" {
+		t.Errorf("line = %q", line)
+	}
+	if ts != "2025-12-10T19:19:32.123456789Z" {
+		t.Errorf("timestamp = %q", ts)
+	}
+	if extra["pod"] != "api-7d9f8b-x2kq" || extra["container"] != "api" {
+		t.Errorf("unexpected extra context: %v", extra)
+	}
+
+	line, ts, extra = splitK8sPrefix("plain line")
+	if line != "plain line" || ts != "" || extra != nil {
+		t.Errorf("line without prefix should be unchanged, got %q %q %v", line, ts, extra)
+	}
+}
+
+func TestKubectlLogsArgs(t *testing.T) {
+	args := strings.Join(kubectlLogsArgs("app=api", "dev", "", 10), " ")
+	for _, want := range []string{"logs", "--follow", "--prefix", "--all-containers", "--selector app=api", "--namespace dev", "--max-log-requests 10", "--tail 0"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("args %q should contain %q", args, want)
+		}
+	}
+
+	args = strings.Join(kubectlLogsArgs("app=api", "dev", "10m", 10), " ")
+	if !strings.Contains(args, "--since 10m") || strings.Contains(args, "--tail") {
+		t.Errorf("--since should replace --tail 0, got %q", args)
+	}
+}
+
+func TestFollowCommand_TagsPod(t *testing.T) {
+	tmpDir := t.TempDir()
+	ingester, err := newStreamIngester(tmpDir, "errorlines", "backend")
+	if err != nil {
+		t.Fatalf("newStreamIngester() error = %v", err)
+	}
+	ingester.context = map[string]interface{}{"namespace": "dev"}
+
+	c := exec.Command("sh", "-c", `printf '[pod/api-1/api] 2025-12-10T19:19:32.000Z listening on :8080\n[pod/api-2/api] 2025-12-10T19:19:33.000Z panic: nil map\n'`)
+	buf := new(bytes.Buffer)
+	if err := followCommand(c, buf, splitK8sPrefix, ingester); err != nil {
+		t.Fatalf("followCommand() error = %v", err)
+	}
+
+	entries, err := readErrors(tmpDir)
+	if err != nil {
+		t.Fatalf("readErrors() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d: %+v", len(entries), entries)
+	}
+	e := entries[0]
+	if e.Context["pod"] != "api-2" || e.Context["namespace"] != "dev" {
+		t.Errorf("unexpected context: %v", e.Context)
+	}
+	if e.Timestamp != "2025-12-10T19:19:33Z" {
+		t.Errorf("Timestamp = %q, want kubectl timestamp", e.Timestamp)
+	}
+}
+
+func TestK8sCommand_RequiresSelector(t *testing.T) {
+	originalPath := pathOverride
+	defer func() { pathOverride = originalPath }()
+	pathOverride = t.TempDir()
+
+	k8sSelector = ""
+	err := runK8s(k8sCmd, []string{})
+	if err == nil || !strings.Contains(err.Error(), "--selector") {
+		t.Errorf("expected --selector required error, got: %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/self"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logType     string
+	logSource   string
+	logTags     []string
+	logFile     string
+	logLine     int
+	logEndpoint string
+	logEnv      string
+	logAgent    string
+	logKind     string
+	logDuration time.Duration
+)
+
+// logCmd represents the log command
+var logCmd = &cobra.Command{
+	Use:   "log <message>",
+	Short: "Append an entry to .agentlog/errors.jsonl",
+	Long: `Append a single entry to .agentlog/errors.jsonl from the command line,
+for scripts, CI steps, and languages without a snippet.
+
+Tags mark entries for later filtering (agentlog errors --tag) and appear in
+the stats breakdown. Tags listed in AGENTLOG_TAGS (comma-separated) are
+added to every entry, the same variable the snippets read.
+
+Examples:
+  agentlog log "migration 0042 failed"
+  agentlog log --type DATABASE_ERROR --source backend "connection refused"
+  agentlog log --tag checkout-v2 --tag experiment "cart total mismatch"
+  agentlog log --file src/app.ts --line 12 "unexpected null"
+  AGENTLOG_TAGS=feature/search agentlog log "index build failed"
+  agentlog log --env test "fixture database missing"
+  agentlog log --agent reviewer-bot "lint step crashed"
+  agentlog log --kind perf --type SLOW_QUERY --duration 8.2s "orders report query"`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runLog,
+}
+
+func init() {
+	rootCmd.AddCommand(logCmd)
+
+	logCmd.Flags().StringVar(&logType, "type", "UNEXPECTED_ERROR", "Error type to record")
+	logCmd.Flags().StringVar(&logSource, "source", "cli", "Source to record (frontend, backend, cli, worker, test)")
+	logCmd.Flags().StringSliceVar(&logTags, "tag", nil, "Tag to attach (repeatable)")
+	logCmd.Flags().StringVar(&logFile, "file", "", "File the error relates to")
+	logCmd.Flags().IntVar(&logLine, "line", 0, "Line number within --file")
+	logCmd.Flags().StringVar(&logEnv, "env", "", "Environment to record (default: $AGENTLOG_ENV)")
+	logCmd.Flags().StringVar(&logAgent, "agent", "", "AI agent or session to record (default: $AGENTLOG_AGENT, or the running agent's session)")
+	logCmd.Flags().StringVar(&logEndpoint, "endpoint", "", "Endpoint the error relates to")
+	logCmd.Flags().StringVar(&logKind, "kind", kindError, "Entry kind: error or perf")
+	logCmd.Flags().DurationVar(&logDuration, "duration", 0, "How long the operation took (e.g. 850ms, 8.2s), for perf entries")
+}
+
+func runLog(cmd *cobra.Command, args []string) error {
+	// Determine base directory (use --path override or cwd)
+	baseDir := GetPathOverride()
+	if baseDir == "" {
+		var err error
+		baseDir, err = os.Getwd()
+		if err != nil {
+			self.LogError(".", "GETWD_ERROR", err.Error())
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+
+	message := strings.TrimSpace(strings.Join(args, " "))
+	if message == "" {
+		return fmt.Errorf("message is required")
+	}
+	if strings.TrimSpace(logType) == "" {
+		return invalidInput("--type must not be empty")
+	}
+
+	if logKind == "" || !validKind(logKind) {
+		return invalidInput("invalid --kind '%s' (want error or perf)", logKind)
+	}
+	if logDuration < 0 {
+		return invalidInput("--duration must not be negative")
+	}
+	kind := ""
+	if logKind == kindPerf {
+		kind = kindPerf
+	}
+
+	env := logEnv
+	if env == "" {
+		env = os.Getenv("AGENTLOG_ENV")
+	}
+
+	tags := append(strings.Split(os.Getenv("AGENTLOG_TAGS"), ","), logTags...)
+	entry := ErrorEntry{
+		Timestamp:   time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+		Source:      logSource,
+		ErrorType:   logType,
+		Message:     truncate(message, maxMessageLength),
+		File:        logFile,
+		Line:        logLine,
+		Endpoint:    logEndpoint,
+		Kind:        kind,
+		DurationMs:  float64(logDuration) / float64(time.Millisecond),
+		Tags:        normalizeTags(tags),
+		Environment: env,
+		Agent:       logAgent,
+	}
+
+	entries := []ErrorEntry{entry}
+	if err := appendErrors(baseDir, entries); err != nil {
+		self.LogError(baseDir, "FILE_WRITE_ERROR", err.Error())
+		return err
+	}
+	entry = entries[0]
+
+	if IsJSONOutput() {
+		output, _ := json.MarshalIndent(entry, "", "  ")
+		fmt.Fprintln(cmd.OutOrStdout(), string(output))
+		return nil
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Logged %s: %s\n", entry.ErrorType, entry.Message)
+	return nil
+}
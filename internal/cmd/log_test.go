@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func resetLogFlags() {
+	logType, logSource, logTags = "UNEXPECTED_ERROR", "cli", nil
+	logFile, logLine, logEndpoint, logEnv, logAgent = "", 0, "", "", ""
+	logKind, logDuration = kindError, 0
+}
+
+func TestLogCommand_AppendsEntry(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalPath := pathOverride
+	defer func() { pathOverride = originalPath }()
+	pathOverride = tmpDir
+	resetLogFlags()
+	defer resetLogFlags()
+
+	t.Setenv("AGENTLOG_TAGS", "feature/search, experiment")
+	t.Setenv("AGENTLOG_ENV", "test")
+	t.Setenv("AGENTLOG_AGENT", "")
+	t.Setenv("CLAUDE_SESSION_ID", "4f2a9c")
+	logType, logTags = "DATABASE_ERROR", []string{"experiment", "nightly"}
+	logFile, logLine = "db/migrate.go", 42
+
+	buf := new(bytes.Buffer)
+	logCmd.SetOut(buf)
+	defer logCmd.SetOut(nil)
+	if err := runLog(logCmd, []string{"migration", "failed"}); err != nil {
+		t.Fatalf("runLog() error = %v", err)
+	}
+
+	entries, err := readErrors(tmpDir)
+	if err != nil {
+		t.Fatalf("readErrors() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Message != "migration failed" || e.ErrorType != "DATABASE_ERROR" || e.Source != "cli" {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if e.File != "db/migrate.go" || e.Line != 42 {
+		t.Errorf("unexpected location: %s:%d", e.File, e.Line)
+	}
+	if strings.Join(e.Tags, ",") != "feature/search,experiment,nightly" {
+		t.Errorf("Tags = %v, want env tags then --tag values, deduplicated", e.Tags)
+	}
+	if e.Environment != "test" {
+		t.Errorf("Environment = %q, want AGENTLOG_ENV value", e.Environment)
+	}
+	if e.Agent != "claude:4f2a9c" {
+		t.Errorf("Agent = %q, want the CLAUDE_SESSION_ID session", e.Agent)
+	}
+	if _, err := parseEntryTime(e.Timestamp); err != nil {
+		t.Errorf("Timestamp %q should be RFC3339: %v", e.Timestamp, err)
+	}
+	if !strings.Contains(buf.String(), "Logged DATABASE_ERROR") {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+}
+
+func TestLogCommand_JSONOutput(t *testing.T) {
+	originalPath, originalJSON := pathOverride, jsonOutput
+	defer func() { pathOverride, jsonOutput = originalPath, originalJSON }()
+	pathOverride, jsonOutput = t.TempDir(), true
+	resetLogFlags()
+	t.Setenv("AGENTLOG_TAGS", "")
+
+	buf := new(bytes.Buffer)
+	logCmd.SetOut(buf)
+	defer logCmd.SetOut(nil)
+	if err := runLog(logCmd, []string{"boom"}); err != nil {
+		t.Fatalf("runLog() error = %v", err)
+	}
+
+	var e ErrorEntry
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if e.Message != "boom" || e.ErrorType != "UNEXPECTED_ERROR" {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if e.Project != filepath.Base(pathOverride) {
+		t.Errorf("Project = %q, want directory name", e.Project)
+	}
+	if e.Tags != nil {
+		t.Errorf("Tags should be omitted when none are set, got %v", e.Tags)
+	}
+}
+
+func TestLogCommand_RequiresMessage(t *testing.T) {
+	originalPath := pathOverride
+	defer func() { pathOverride = originalPath }()
+	pathOverride = t.TempDir()
+	resetLogFlags()
+
+	if err := runLog(logCmd, []string{"  "}); err == nil {
+		t.Error("expected error for blank message")
+	}
+}
+
+func TestLogCommand_PerfEntry(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalPath := pathOverride
+	defer func() { pathOverride = originalPath }()
+	pathOverride = tmpDir
+	resetLogFlags()
+	defer resetLogFlags()
+
+	logType, logKind, logDuration, logEndpoint = "SLOW_REQUEST", kindPerf, 8200*time.Millisecond, "/api/users"
+	logCmd.SetOut(new(bytes.Buffer))
+	defer logCmd.SetOut(nil)
+	if err := runLog(logCmd, []string{"GET /api/users"}); err != nil {
+		t.Fatalf("runLog() error = %v", err)
+	}
+
+	entries, _ := readErrors(tmpDir)
+	if len(entries) != 1 || entries[0].Kind != kindPerf || entries[0].DurationMs != 8200 {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+
+	resetLogFlags()
+	if err := runLog(logCmd, []string{"plain"}); err != nil {
+		t.Fatalf("runLog() error = %v", err)
+	}
+	entries, _ = readErrors(tmpDir)
+	if entries[1].Kind != "" {
+		t.Errorf("error entries should omit kind, got %q", entries[1].Kind)
+	}
+
+	logKind = "metric"
+	if err := runLog(logCmd, []string{"x"}); err == nil {
+		t.Error("unknown --kind should fail")
+	}
+}
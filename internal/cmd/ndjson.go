@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// streamNDJSON writes the entries keep accepts to w as NDJSON, one compact
+// object per line. With limit 0 each is written as soon as it's read; with
+// a limit, the last limit matches are kept in a ring and written at the
+// end. Either way memory doesn't grow with the log. A missing log writes
+// nothing. Entries are marked as regressions or expired snoozes the way
+// the errors list marks them.
+func streamNDJSON(w io.Writer, baseDir string, limit int, keep func(ErrorEntry) bool) error {
+	out := bufio.NewWriter(w)
+	defer out.Flush()
+	policy := entryPolicyFor(baseDir)
+	resolutions := loadResolutions(baseDir)
+	snoozes := loadSnoozes(baseDir, time.Now())
+	write := func(e ErrorEntry) error {
+		e = snoozes.mark(resolutions.mark([]ErrorEntry{e}))[0]
+		data, err := json.Marshal(policy.apply(e))
+		if err != nil {
+			return fmt.Errorf("failed to encode entry: %w", err)
+		}
+		out.Write(data)
+		return out.WriteByte('\n')
+	}
+
+	var ring *entryRing
+	if limit > 0 {
+		ring = newEntryRing(limit)
+	}
+	var writeErr error
+	err := scanErrors(baseDir, func(e ErrorEntry) bool {
+		if !keep(e) {
+			return true
+		}
+		if limit <= 0 {
+			writeErr = write(e)
+			return writeErr == nil
+		}
+		ring.add(e)
+		return true
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+
+	if ring != nil {
+		for _, e := range ring.ordered() {
+			if err := write(e); err != nil {
+				return err
+			}
+		}
+	}
+	return out.Flush()
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestStreamNDJSON(t *testing.T) {
+	tmpDir := writeScanLog(t, 100)
+	backend := func(e ErrorEntry) bool { return e.Source == "backend" }
+
+	messages := func(out string) []string {
+		var got []string
+		for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+			var e ErrorEntry
+			if err := json.Unmarshal([]byte(line), &e); err != nil {
+				t.Fatalf("line isn't an entry: %q", line)
+			}
+			got = append(got, e.Message)
+		}
+		return got
+	}
+
+	var buf bytes.Buffer
+	if err := streamNDJSON(&buf, tmpDir, 0, backend); err != nil {
+		t.Fatal(err)
+	}
+	if got := messages(buf.String()); len(got) != 34 || got[0] != "error 0" || got[33] != "error 99" {
+		t.Errorf("--limit 0 should stream every match in order, got %d: %v", len(got), got)
+	}
+
+	buf.Reset()
+	if err := streamNDJSON(&buf, tmpDir, 3, backend); err != nil {
+		t.Fatal(err)
+	}
+	if got := messages(buf.String()); strings.Join(got, ",") != "error 93,error 96,error 99" {
+		t.Errorf("--limit 3 should keep the last three matches, got %v", got)
+	}
+
+	buf.Reset()
+	if err := streamNDJSON(&buf, t.TempDir(), 0, backend); err != nil || buf.Len() != 0 {
+		t.Errorf("a missing log should write nothing, got %q (%v)", buf.String(), err)
+	}
+}
+
+func TestErrorsCommand_OutputNDJSON(t *testing.T) {
+	tmpDir := writeScanLog(t, 10)
+	originalPath := pathOverride
+	defer func() {
+		pathOverride, errorsOutput, errorsLimit, errorsGroup, jsonOutput = originalPath, "", 10, false, false
+	}()
+	pathOverride = tmpDir
+	errorsLimit, errorsSource, errorsType, errorsSince = 2, "", "", ""
+	errorsCount, errorsGroup, errorsPick, errorsFields, errorsTemplate, jsonOutput = false, false, false, "", "", false
+	errorsOutput = "ndjson"
+
+	buf := new(bytes.Buffer)
+	errorsCmd.SetOut(buf)
+	if err := runErrors(errorsCmd, []string{}); err != nil {
+		t.Fatalf("runErrors() error = %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[1], `{"id":`) {
+		t.Errorf("expected two compact entries, got:\n%s", buf.String())
+	}
+
+	errorsGroup = true
+	if err := runErrors(errorsCmd, []string{}); err == nil {
+		t.Error("--output ndjson with --group should fail")
+	}
+	errorsGroup, errorsOutput = false, "yaml"
+	if err := runErrors(errorsCmd, []string{}); err == nil {
+		t.Error("an unknown --output should fail")
+	}
+}
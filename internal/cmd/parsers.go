@@ -0,0 +1,573 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/agentlog/agentlog/internal/config"
+	"github.com/agentlog/agentlog/internal/logfile"
+)
+
+// Parser converts the output of an external tool into error entries.
+// Parsers fill in error_type, message, and context; the caller stamps
+// timestamp and source before writing.
+type Parser func(r io.Reader) ([]ErrorEntry, error)
+
+// parsers maps --parser names to their implementations
+var parsers = map[string]Parser{
+	"errorlines":    parseErrorLines,
+	"journal":       parseJournal,
+	"tsc":           parseTSC,
+	"eslint":        parseESLint,
+	"ruff":          parseRuff,
+	"golangci":      parseGolangCI,
+	"golangci-lint": parseGolangCI,
+}
+
+// lineParsers are the built-in parsers that work on individual lines and can
+// therefore be applied to live log streams. Config regex parsers are always
+// line-oriented. tsc isn't: a diagnostic's indented continuation lines
+// belong to the header before them.
+var lineParsers = map[string]bool{
+	"errorlines": true,
+	"journal":    true,
+}
+
+// isLineParser reports whether the named parser can be applied line by line
+func isLineParser(name string, cfg *config.Config) bool {
+	if _, ok := parsers[strings.ToLower(name)]; ok {
+		return lineParsers[strings.ToLower(name)]
+	}
+	if cfg != nil {
+		_, ok := cfg.Parsers[name]
+		return ok
+	}
+	return false
+}
+
+// getParser returns the built-in parser registered under name, falling back
+// to a regex parser defined in config. Built-in names take precedence.
+func getParser(name string, cfg *config.Config) (Parser, error) {
+	if p, ok := parsers[strings.ToLower(name)]; ok {
+		return p, nil
+	}
+	if cfg != nil {
+		if pc, ok := cfg.Parsers[name]; ok {
+			p, err := newRegexParser(pc)
+			if err != nil {
+				return nil, fmt.Errorf("invalid parser '%s' in %s: %w", name, config.FileName, err)
+			}
+			return p, nil
+		}
+	}
+	return nil, invalidInput("unknown parser '%s' (available: %s)", name, strings.Join(parserNames(cfg), ", "))
+}
+
+// parserNames returns built-in and configured parser names in sorted order
+func parserNames(cfg *config.Config) []string {
+	var names []string
+	for name := range parsers {
+		names = append(names, name)
+	}
+	if cfg != nil {
+		for name := range cfg.Parsers {
+			if _, ok := parsers[strings.ToLower(name)]; !ok {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// regexTimeFormats are tried for the "timestamp" group when no time_format is configured
+var regexTimeFormats = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.000",
+	"2006-01-02 15:04:05",
+	"2006/01/02 15:04:05",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// newRegexParser builds a line parser from a config definition. The pattern
+// must contain a "message" group; "timestamp" and "type" are optional and
+// all other named groups become context fields.
+func newRegexParser(pc config.ParserConfig) (Parser, error) {
+	if pc.Pattern == "" {
+		return nil, fmt.Errorf("pattern is required")
+	}
+	re, err := regexp.Compile(pc.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	if re.SubexpIndex("message") < 0 {
+		return nil, fmt.Errorf("pattern must have a named group 'message', e.g. (?P<message>.*)")
+	}
+
+	errorType := pc.ErrorType
+	if errorType == "" {
+		errorType = "UNEXPECTED_ERROR"
+	}
+
+	return func(r io.Reader) ([]ErrorEntry, error) {
+		var entries []ErrorEntry
+		scanner := logfile.NewLines(r, logfile.DefaultMaxLine)
+		for scanner.Scan() {
+			m := re.FindStringSubmatch(scanner.Text())
+			if m == nil {
+				continue
+			}
+
+			entry := ErrorEntry{
+				ErrorType: errorType,
+				Source:    pc.Source,
+			}
+			for i, group := range re.SubexpNames() {
+				value := strings.TrimSpace(m[i])
+				if group == "" || value == "" {
+					continue
+				}
+				switch group {
+				case "message":
+					entry.Message = truncate(value, 500)
+				case "type":
+					entry.ErrorType = value
+				case "timestamp":
+					if ts, ok := parseLogTimestamp(value, pc.TimeFormat); ok {
+						entry.Timestamp = ts.UTC().Format(time.RFC3339Nano)
+					}
+				case "file":
+					entry.File = value
+				case "endpoint":
+					entry.Endpoint = value
+				case "line", "column":
+					n, err := strconv.Atoi(value)
+					if err != nil {
+						break
+					}
+					if group == "line" {
+						entry.Line = n
+					} else {
+						entry.Column = n
+					}
+				default:
+					if entry.Context == nil {
+						entry.Context = make(map[string]interface{})
+					}
+					entry.Context[group] = value
+				}
+			}
+
+			if entry.Message == "" {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+
+		if err := scanner.Err(); err != nil {
+			return entries, fmt.Errorf("error reading input: %w", err)
+		}
+		return entries, nil
+	}, nil
+}
+
+// parseLogTimestamp parses a timestamp captured from a log line, using the
+// configured layout if set, otherwise trying common formats
+func parseLogTimestamp(value, layout string) (time.Time, bool) {
+	if layout != "" {
+		t, err := time.Parse(layout, value)
+		return t, err == nil
+	}
+	for _, f := range regexTimeFormats {
+		if t, err := time.Parse(f, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+var (
+	// errorLinePattern matches common error markers in unstructured logs:
+	// upper-case levels (postgres, redis, nginx), logfmt/JSON level keys,
+	// Go panics, Python tracebacks, and Java/JS exception names
+	errorLinePattern = regexp.MustCompile(`\b(?:ERROR|FATAL|PANIC|CRITICAL|CRIT)\b|(?i:"?level"?\s*[=:]\s*"?(?:error|fatal|panic|crit(?:ical)?)\b)|\bpanic: |Traceback \(most recent call last\)|\b(?:[A-Z]\w*)?(?:Exception|Error)\b:`)
+
+	// ansiPattern matches terminal color escape sequences (tsc --pretty)
+	ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+	// tscPlainPattern matches: src/app.ts(12,5): error TS2322: message
+	tscPlainPattern = regexp.MustCompile(`^(.+?)\((\d+),(\d+)\): (error|warning|message) (TS\d+): (.*)$`)
+
+	// tscPrettyPattern matches: src/app.ts:12:5 - error TS2322: message
+	tscPrettyPattern = regexp.MustCompile(`^(.+?):(\d+):(\d+) - (error|warning|message) (TS\d+): (.*)$`)
+
+	// tscGlobalPattern matches diagnostics without a location: error TS5023: message
+	tscGlobalPattern = regexp.MustCompile(`^(error|warning|message) (TS\d+): (.*)$`)
+)
+
+// parseErrorLines is a heuristic parser for unstructured service logs
+// (databases, sidecars, dev servers). Each line containing an error marker
+// becomes a LOG_ERROR entry with the line as its message.
+func parseErrorLines(r io.Reader) ([]ErrorEntry, error) {
+	var entries []ErrorEntry
+	scanner := logfile.NewLines(r, logfile.DefaultMaxLine)
+	for scanner.Scan() {
+		line := strings.TrimSpace(ansiPattern.ReplaceAllString(scanner.Text(), ""))
+		if line == "" || !errorLinePattern.MatchString(line) {
+			continue
+		}
+		entries = append(entries, ErrorEntry{
+			ErrorType: "LOG_ERROR",
+			Message:   truncate(line, 500),
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return entries, fmt.Errorf("error reading input: %w", err)
+	}
+	return entries, nil
+}
+
+// journalPriorities names syslog priorities at err and above
+var journalPriorities = map[int]string{
+	0: "emerg",
+	1: "alert",
+	2: "crit",
+	3: "err",
+}
+
+// journalContextFields maps journal fields to context keys, in precedence order
+var journalContextFields = []struct {
+	field string
+	key   string
+}{
+	{"_SYSTEMD_UNIT", "unit"},
+	{"_SYSTEMD_USER_UNIT", "unit"},
+	{"SYSLOG_IDENTIFIER", "syslog_identifier"},
+	{"_PID", "pid"},
+}
+
+// parseJournal parses `journalctl -o json` records (one JSON object per
+// line), keeping those with priority err or more severe as LOG_ERROR entries.
+// Lines that aren't journal JSON are skipped.
+func parseJournal(r io.Reader) ([]ErrorEntry, error) {
+	var entries []ErrorEntry
+	scanner := logfile.NewLines(r, logfile.DefaultMaxLine)
+	for scanner.Scan() {
+		var rec map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+
+		priority, err := strconv.Atoi(journalField(rec, "PRIORITY"))
+		if err != nil {
+			continue
+		}
+		name, ok := journalPriorities[priority]
+		if !ok {
+			continue
+		}
+
+		message := journalField(rec, "MESSAGE")
+		if message == "" {
+			continue
+		}
+
+		entry := ErrorEntry{
+			ErrorType: "LOG_ERROR",
+			Message:   truncate(strings.TrimSpace(message), 500),
+			Context:   map[string]interface{}{"priority": name},
+		}
+		if usec, err := strconv.ParseInt(journalField(rec, "__REALTIME_TIMESTAMP"), 10, 64); err == nil {
+			entry.Timestamp = time.UnixMicro(usec).UTC().Format(time.RFC3339Nano)
+		}
+		// _SYSTEMD_USER_UNIT comes last so user services report their own
+		// unit rather than user@<uid>.service
+		for _, f := range journalContextFields {
+			if v := journalField(rec, f.field); v != "" {
+				entry.Context[f.key] = v
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return entries, fmt.Errorf("error reading journal output: %w", err)
+	}
+	return entries, nil
+}
+
+// journalField returns a journal field as a string. journalctl encodes
+// fields that aren't valid UTF-8 as arrays of byte values.
+func journalField(rec map[string]interface{}, field string) string {
+	switch v := rec[field].(type) {
+	case string:
+		return v
+	case []interface{}:
+		b := make([]byte, 0, len(v))
+		for _, n := range v {
+			if f, ok := n.(float64); ok {
+				b = append(b, byte(f))
+			}
+		}
+		return string(b)
+	default:
+		return ""
+	}
+}
+
+// parseTSC parses `tsc --noEmit` output into one TYPE_ERROR entry per diagnostic.
+// Both the plain format and the --pretty format are supported. Indented lines
+// following a diagnostic are treated as message continuations until a blank
+// line (which precedes the code frame in --pretty output).
+func parseTSC(r io.Reader) ([]ErrorEntry, error) {
+	var entries []ErrorEntry
+	var current *ErrorEntry
+	inFrame := false
+
+	flush := func() {
+		if current != nil {
+			current.Message = truncate(current.Message, 500)
+			entries = append(entries, *current)
+			current = nil
+		}
+	}
+
+	scanner := logfile.NewLines(r, logfile.DefaultMaxLine)
+	for scanner.Scan() {
+		line := ansiPattern.ReplaceAllString(scanner.Text(), "")
+
+		if entry, ok := parseTSCDiagnostic(line); ok {
+			flush()
+			current = &entry
+			inFrame = false
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			inFrame = true
+			continue
+		}
+
+		// Message continuation (indented, before any code frame)
+		if current != nil && !inFrame && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			current.Message += " " + strings.TrimSpace(line)
+		}
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		return entries, fmt.Errorf("error reading tsc output: %w", err)
+	}
+
+	return entries, nil
+}
+
+// parseTSCDiagnostic parses a single tsc diagnostic header line
+func parseTSCDiagnostic(line string) (ErrorEntry, bool) {
+	line = strings.TrimRight(line, "\r")
+
+	var file, lineNo, col, severity, code, message string
+	if m := tscPlainPattern.FindStringSubmatch(line); m != nil {
+		file, lineNo, col, severity, code, message = m[1], m[2], m[3], m[4], m[5], m[6]
+	} else if m := tscPrettyPattern.FindStringSubmatch(line); m != nil {
+		file, lineNo, col, severity, code, message = m[1], m[2], m[3], m[4], m[5], m[6]
+	} else if m := tscGlobalPattern.FindStringSubmatch(line); m != nil {
+		severity, code, message = m[1], m[2], m[3]
+	} else {
+		return ErrorEntry{}, false
+	}
+
+	entry := ErrorEntry{
+		ErrorType: "TYPE_ERROR",
+		Message:   strings.TrimSpace(message),
+		Context: map[string]interface{}{
+			"code":     code,
+			"severity": severity,
+		},
+	}
+	if file != "" {
+		entry.File = strings.TrimSpace(file)
+		entry.Line, _ = strconv.Atoi(lineNo)
+		entry.Column, _ = strconv.Atoi(col)
+	}
+	return entry, true
+}
+
+// readJSONInput reads all of r, returning nil for blank input so linters
+// that print nothing on a clean run produce zero entries
+func readJSONInput(r io.Reader, tool string) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s output: %w", tool, err)
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return data, nil
+}
+
+// lintEntry builds a LINT_ERROR entry with its location and the standard
+// lint context keys
+func lintEntry(linter, rule, severity, message, file string, line, column int) ErrorEntry {
+	ctx := map[string]interface{}{
+		"linter":   linter,
+		"severity": severity,
+	}
+	if rule != "" {
+		ctx["rule"] = rule
+	}
+
+	return ErrorEntry{
+		ErrorType: "LINT_ERROR",
+		Message:   truncate(strings.TrimSpace(message), 500),
+		File:      file,
+		Line:      line,
+		Column:    column,
+		Context:   ctx,
+	}
+}
+
+// parseESLint parses `eslint -f json` output. ESLint severity 2 maps to
+// "error" and 1 to "warning".
+func parseESLint(r io.Reader) ([]ErrorEntry, error) {
+	data, err := readJSONInput(r, "eslint")
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	var results []struct {
+		FilePath string `json:"filePath"`
+		Messages []struct {
+			RuleID   *string `json:"ruleId"`
+			Severity int     `json:"severity"`
+			Message  string  `json:"message"`
+			Line     int     `json:"line"`
+			Column   int     `json:"column"`
+			Fatal    bool    `json:"fatal"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("invalid eslint JSON (use 'eslint -f json'): %w", err)
+	}
+
+	var entries []ErrorEntry
+	for _, res := range results {
+		for _, m := range res.Messages {
+			severity := "warning"
+			if m.Severity >= 2 || m.Fatal {
+				severity = "error"
+			}
+			rule := ""
+			if m.RuleID != nil {
+				rule = *m.RuleID
+			} else if m.Fatal {
+				rule = "parse"
+			}
+			entries = append(entries, lintEntry("eslint", rule, severity, m.Message, res.FilePath, m.Line, m.Column))
+		}
+	}
+
+	return entries, nil
+}
+
+// parseRuff parses `ruff check --output-format json` output. Ruff has no
+// severity levels; every violation fails the check, so all map to "error".
+func parseRuff(r io.Reader) ([]ErrorEntry, error) {
+	data, err := readJSONInput(r, "ruff")
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	var results []struct {
+		Code     *string `json:"code"`
+		Message  string  `json:"message"`
+		Filename string  `json:"filename"`
+		Location struct {
+			Row    int `json:"row"`
+			Column int `json:"column"`
+		} `json:"location"`
+	}
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("invalid ruff JSON (use 'ruff check --output-format json'): %w", err)
+	}
+
+	var entries []ErrorEntry
+	for _, res := range results {
+		rule := "syntax"
+		if res.Code != nil {
+			rule = *res.Code
+		}
+		entries = append(entries, lintEntry("ruff", rule, "error", res.Message, res.Filename, res.Location.Row, res.Location.Column))
+	}
+
+	return entries, nil
+}
+
+// parseGolangCI parses golangci-lint JSON output. Issues without an explicit
+// severity (the default configuration) map to "error".
+func parseGolangCI(r io.Reader) ([]ErrorEntry, error) {
+	data, err := readJSONInput(r, "golangci-lint")
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	var report struct {
+		Issues []struct {
+			FromLinter string `json:"FromLinter"`
+			Text       string `json:"Text"`
+			Severity   string `json:"Severity"`
+			Pos        struct {
+				Filename string `json:"Filename"`
+				Line     int    `json:"Line"`
+				Column   int    `json:"Column"`
+			} `json:"Pos"`
+		} `json:"Issues"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("invalid golangci-lint JSON (use '--out-format json'): %w", err)
+	}
+
+	var entries []ErrorEntry
+	for _, issue := range report.Issues {
+		entries = append(entries, lintEntry("golangci-lint", issue.FromLinter, normalizeSeverity(issue.Severity), issue.Text, issue.Pos.Filename, issue.Pos.Line, issue.Pos.Column))
+	}
+
+	return entries, nil
+}
+
+// normalizeSeverity maps tool-specific severity names onto error/warning/info
+func normalizeSeverity(severity string) string {
+	switch strings.ToLower(severity) {
+	case "warning", "warn":
+		return "warning"
+	case "info", "note", "hint", "suggestion":
+		return "info"
+	default:
+		return "error"
+	}
+}
+
+// truncate truncates a string to max length with "..." suffix
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	cut := max - 3
+	// Back up to a rune boundary so multi-byte characters aren't split
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "..."
+}
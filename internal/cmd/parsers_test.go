@@ -0,0 +1,443 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agentlog/agentlog/internal/config"
+)
+
+func TestParseTSC_PlainFormat(t *testing.T) {
+	input := `src/app.ts(12,5): error TS2322: Type 'string' is not assignable to type 'number'.
+src/api/users.ts(3,10): error TS2305: Module '"./db"' has no exported member 'query'.
+Found 2 errors in 2 files.
+`
+	entries, err := parseTSC(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseTSC() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("parseTSC() returned %d entries, want 2", len(entries))
+	}
+
+	e := entries[0]
+	if e.ErrorType != "TYPE_ERROR" {
+		t.Errorf("ErrorType = %q, want TYPE_ERROR", e.ErrorType)
+	}
+	if e.Message != "Type 'string' is not assignable to type 'number'." {
+		t.Errorf("Message = %q", e.Message)
+	}
+	if e.Context["code"] != "TS2322" {
+		t.Errorf("context.code = %v, want TS2322", e.Context["code"])
+	}
+	if e.File != "src/app.ts" {
+		t.Errorf("File = %q, want src/app.ts", e.File)
+	}
+	if e.Line != 12 {
+		t.Errorf("Line = %d, want 12", e.Line)
+	}
+	if e.Column != 5 {
+		t.Errorf("Column = %d, want 5", e.Column)
+	}
+}
+
+func TestParseTSC_PrettyFormat(t *testing.T) {
+	input := "\x1b[96msrc/app.ts\x1b[0m:\x1b[93m12\x1b[0m:\x1b[93m5\x1b[0m - \x1b[91merror\x1b[0m\x1b[90m TS2322: \x1b[0mType 'string' is not assignable to type 'number'.\n" +
+		"\n" +
+		"\x1b[7m12\x1b[0m     const x: number = 'a';\n" +
+		"\x1b[7m  \x1b[0m \x1b[91m    ~\x1b[0m\n" +
+		"\n" +
+		"Found 1 error in src/app.ts\x1b[90m:12\x1b[0m\n"
+
+	entries, err := parseTSC(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseTSC() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("parseTSC() returned %d entries, want 1", len(entries))
+	}
+	if entries[0].File != "src/app.ts" {
+		t.Errorf("File = %q, want src/app.ts", entries[0].File)
+	}
+	if strings.Contains(entries[0].Message, "const x") {
+		t.Errorf("code frame should not be part of message, got %q", entries[0].Message)
+	}
+}
+
+func TestParseTSC_MultilineMessage(t *testing.T) {
+	input := `src/app.ts(4,7): error TS2322: Type '{ a: string; }' is not assignable to type 'Foo'.
+  Property 'b' is missing in type '{ a: string; }' but required in type 'Foo'.
+`
+	entries, err := parseTSC(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseTSC() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("parseTSC() returned %d entries, want 1", len(entries))
+	}
+	if !strings.Contains(entries[0].Message, "Property 'b' is missing") {
+		t.Errorf("continuation line should be appended to message, got %q", entries[0].Message)
+	}
+}
+
+func TestParseTSC_GlobalDiagnostic(t *testing.T) {
+	input := "error TS5023: Unknown compiler option 'foo'.\n"
+
+	entries, err := parseTSC(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseTSC() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("parseTSC() returned %d entries, want 1", len(entries))
+	}
+	if entries[0].File != "" {
+		t.Error("global diagnostic should not have a file")
+	}
+	if entries[0].Context["code"] != "TS5023" {
+		t.Errorf("context.code = %v, want TS5023", entries[0].Context["code"])
+	}
+}
+
+func TestParseTSC_NoErrors(t *testing.T) {
+	entries, err := parseTSC(strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("parseTSC() error = %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("parseTSC() returned %d entries, want 0", len(entries))
+	}
+}
+
+func TestGetParser(t *testing.T) {
+	if _, err := getParser("tsc", nil); err != nil {
+		t.Errorf("getParser(tsc) error = %v", err)
+	}
+	if _, err := getParser("TSC", nil); err != nil {
+		t.Errorf("getParser should be case-insensitive, error = %v", err)
+	}
+	_, err := getParser("nope", nil)
+	if err == nil {
+		t.Fatal("getParser(nope) should return an error")
+	}
+	if !strings.Contains(err.Error(), "tsc") {
+		t.Errorf("error should list available parsers, got: %v", err)
+	}
+}
+
+func TestParseESLint(t *testing.T) {
+	input := `[
+  {"filePath":"/app/src/a.ts","messages":[
+    {"ruleId":"no-unused-vars","severity":2,"message":"'x' is defined but never used.","line":3,"column":7},
+    {"ruleId":"prefer-const","severity":1,"message":"'y' is never reassigned.","line":4,"column":5}
+  ],"errorCount":1,"warningCount":1},
+  {"filePath":"/app/src/b.ts","messages":[
+    {"ruleId":null,"fatal":true,"severity":2,"message":"Parsing error: Unexpected token","line":1,"column":1}
+  ]},
+  {"filePath":"/app/src/clean.ts","messages":[]}
+]`
+	entries, err := parseESLint(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseESLint() error = %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("parseESLint() returned %d entries, want 3", len(entries))
+	}
+
+	tests := []struct {
+		idx      int
+		rule     string
+		severity string
+	}{
+		{0, "no-unused-vars", "error"},
+		{1, "prefer-const", "warning"},
+		{2, "parse", "error"},
+	}
+	for _, tt := range tests {
+		e := entries[tt.idx]
+		if e.ErrorType != "LINT_ERROR" {
+			t.Errorf("entry %d ErrorType = %q, want LINT_ERROR", tt.idx, e.ErrorType)
+		}
+		if e.Context["rule"] != tt.rule {
+			t.Errorf("entry %d rule = %v, want %s", tt.idx, e.Context["rule"], tt.rule)
+		}
+		if e.Context["severity"] != tt.severity {
+			t.Errorf("entry %d severity = %v, want %s", tt.idx, e.Context["severity"], tt.severity)
+		}
+		if e.Context["linter"] != "eslint" {
+			t.Errorf("entry %d linter = %v, want eslint", tt.idx, e.Context["linter"])
+		}
+	}
+	if entries[0].File != "/app/src/a.ts" || entries[0].Line != 3 {
+		t.Errorf("unexpected location: %s:%d", entries[0].File, entries[0].Line)
+	}
+}
+
+func TestParseRuff(t *testing.T) {
+	input := `[
+  {"code":"F401","message":"'os' imported but unused","filename":"/app/main.py","location":{"row":1,"column":8},"end_location":{"row":1,"column":10},"fix":null,"url":"https://docs.astral.sh/ruff/rules/unused-import"},
+  {"code":null,"message":"SyntaxError: Expected an expression","filename":"/app/bad.py","location":{"row":2,"column":1}}
+]`
+	entries, err := parseRuff(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseRuff() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("parseRuff() returned %d entries, want 2", len(entries))
+	}
+	if entries[0].Context["rule"] != "F401" {
+		t.Errorf("rule = %v, want F401", entries[0].Context["rule"])
+	}
+	if entries[0].File != "/app/main.py" || entries[0].Line != 1 || entries[0].Column != 8 {
+		t.Errorf("unexpected location: %s:%d:%d", entries[0].File, entries[0].Line, entries[0].Column)
+	}
+	if entries[1].Context["rule"] != "syntax" {
+		t.Errorf("rule = %v, want syntax", entries[1].Context["rule"])
+	}
+	if entries[0].Context["severity"] != "error" {
+		t.Errorf("severity = %v, want error", entries[0].Context["severity"])
+	}
+}
+
+func TestParseGolangCI(t *testing.T) {
+	input := `{"Issues":[
+  {"FromLinter":"errcheck","Text":"Error return value of ` + "`f.Close`" + ` is not checked","Severity":"","Pos":{"Filename":"main.go","Offset":120,"Line":10,"Column":12}},
+  {"FromLinter":"revive","Text":"exported function Foo should have comment","Severity":"warning","Pos":{"Filename":"foo.go","Line":3,"Column":1}}
+],"Report":{"Linters":[{"Name":"errcheck","Enabled":true}]}}`
+	entries, err := parseGolangCI(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseGolangCI() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("parseGolangCI() returned %d entries, want 2", len(entries))
+	}
+	if entries[0].Context["rule"] != "errcheck" || entries[0].Context["severity"] != "error" {
+		t.Errorf("unexpected context: %v", entries[0].Context)
+	}
+	if entries[1].Context["severity"] != "warning" {
+		t.Errorf("severity = %v, want warning", entries[1].Context["severity"])
+	}
+	if entries[0].File != "main.go" || entries[0].Line != 10 {
+		t.Errorf("unexpected location: %s:%d", entries[0].File, entries[0].Line)
+	}
+}
+
+func TestLintParsers_EmptyInput(t *testing.T) {
+	for _, name := range []string{"eslint", "ruff", "golangci"} {
+		t.Run(name, func(t *testing.T) {
+			p, _ := getParser(name, nil)
+			entries, err := p(strings.NewReader("  \n"))
+			if err != nil {
+				t.Errorf("%s parser error on empty input = %v", name, err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("%s parser returned %d entries for empty input", name, len(entries))
+			}
+		})
+	}
+}
+
+func TestLintParsers_InvalidJSON(t *testing.T) {
+	for _, name := range []string{"eslint", "ruff", "golangci"} {
+		t.Run(name, func(t *testing.T) {
+			p, _ := getParser(name, nil)
+			if _, err := p(strings.NewReader("src/a.ts: error")); err == nil {
+				t.Errorf("%s parser should reject non-JSON input", name)
+			}
+		})
+	}
+}
+
+func TestNormalizeSeverity(t *testing.T) {
+	tests := map[string]string{
+		"":        "error",
+		"error":   "error",
+		"WARNING": "warning",
+		"warn":    "warning",
+		"info":    "info",
+		"hint":    "info",
+	}
+	for in, want := range tests {
+		if got := normalizeSeverity(in); got != want {
+			t.Errorf("normalizeSeverity(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRegexParser_NamedGroups(t *testing.T) {
+	p, err := newRegexParser(config.ParserConfig{
+		Pattern:    `^(?P<timestamp>\S+ \S+) \[(?P<level>error|crit)\] \d+#\d+: (?P<message>.*?)(?:, client: (?P<client>\S+))?$`,
+		ErrorType:  "REQUEST_ERROR",
+		TimeFormat: "2006/01/02 15:04:05",
+	})
+	if err != nil {
+		t.Fatalf("newRegexParser() error = %v", err)
+	}
+
+	input := `2025/12/10 19:19:32 [error] 12#12: upstream timed out, client: 127.0.0.1
+2025/12/10 19:19:33 [notice] 12#12: signal process started
+2025/12/10 19:20:00 [crit] 12#12: disk full
+`
+	entries, err := p(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parser error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("parser returned %d entries, want 2", len(entries))
+	}
+
+	e := entries[0]
+	if e.ErrorType != "REQUEST_ERROR" {
+		t.Errorf("ErrorType = %q, want REQUEST_ERROR", e.ErrorType)
+	}
+	if e.Message != "upstream timed out" {
+		t.Errorf("Message = %q", e.Message)
+	}
+	if e.Timestamp != "2025-12-10T19:19:32Z" {
+		t.Errorf("Timestamp = %q, want 2025-12-10T19:19:32Z", e.Timestamp)
+	}
+	if e.Context["level"] != "error" || e.Context["client"] != "127.0.0.1" {
+		t.Errorf("unexpected context: %v", e.Context)
+	}
+	if _, ok := entries[1].Context["client"]; ok {
+		t.Error("empty optional groups should not be stored in context")
+	}
+}
+
+func TestRegexParser_TypeGroup(t *testing.T) {
+	p, err := newRegexParser(config.ParserConfig{
+		Pattern: `^(?P<type>[A-Z_]+): (?P<message>.+)$`,
+		Source:  "worker",
+	})
+	if err != nil {
+		t.Fatalf("newRegexParser() error = %v", err)
+	}
+
+	entries, _ := p(strings.NewReader("QUEUE_ERROR: job 42 failed\nnot a match\n"))
+	if len(entries) != 1 {
+		t.Fatalf("parser returned %d entries, want 1", len(entries))
+	}
+	if entries[0].ErrorType != "QUEUE_ERROR" {
+		t.Errorf("ErrorType = %q, want QUEUE_ERROR", entries[0].ErrorType)
+	}
+	if entries[0].Source != "worker" {
+		t.Errorf("Source = %q, want worker", entries[0].Source)
+	}
+	if entries[0].Timestamp != "" {
+		t.Errorf("Timestamp should be left for ingest to stamp, got %q", entries[0].Timestamp)
+	}
+}
+
+func TestRegexParser_LocationGroups(t *testing.T) {
+	p, err := newRegexParser(config.ParserConfig{
+		Pattern: `^(?P<file>[^:]+):(?P<line>\d+):(?P<column>\d+) (?P<message>.+)$`,
+	})
+	if err != nil {
+		t.Fatalf("newRegexParser() error = %v", err)
+	}
+
+	entries, _ := p(strings.NewReader("lib/app.rb:42:7 undefined method 'name'\n"))
+	if len(entries) != 1 {
+		t.Fatalf("parser returned %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.File != "lib/app.rb" || e.Line != 42 || e.Column != 7 {
+		t.Errorf("unexpected location: %s:%d:%d", e.File, e.Line, e.Column)
+	}
+	if len(e.Context) != 0 {
+		t.Errorf("location groups should not be stored in context, got %v", e.Context)
+	}
+}
+
+func TestRegexParser_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+	}{
+		{"empty pattern", ""},
+		{"invalid regex", `(?P<message>`},
+		{"missing message group", `^(?P<text>.*)$`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newRegexParser(config.ParserConfig{Pattern: tt.pattern}); err == nil {
+				t.Errorf("newRegexParser(%q) should fail", tt.pattern)
+			}
+		})
+	}
+}
+
+func TestGetParser_FromConfig(t *testing.T) {
+	cfg := &config.Config{
+		Parsers: map[string]config.ParserConfig{
+			"myapp": {Pattern: `^ERROR (?P<message>.*)$`},
+		},
+	}
+
+	p, err := getParser("myapp", cfg)
+	if err != nil {
+		t.Fatalf("getParser(myapp) error = %v", err)
+	}
+	entries, _ := p(strings.NewReader("ERROR boom\n"))
+	if len(entries) != 1 || entries[0].ErrorType != "UNEXPECTED_ERROR" {
+		t.Errorf("expected 1 UNEXPECTED_ERROR entry, got %+v", entries)
+	}
+
+	_, err = getParser("missing", cfg)
+	if err == nil || !strings.Contains(err.Error(), "myapp") {
+		t.Errorf("error should list configured parsers, got: %v", err)
+	}
+}
+
+func TestParseErrorLines(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{`2025-12-10 19:19:32.941 UTC [1] ERROR:  relation "foo" does not exist`, true},
+		{`2025-12-10 19:19:32.941 UTC [1] FATAL:  password authentication failed`, true},
+		{`level=error msg="connection reset"`, true},
+		{`{"level":"error","msg":"boom"}`, true},
+		{`panic: runtime error: index out of range`, true},
+		{`Traceback (most recent call last):`, true},
+		{`TypeError: Cannot read properties of undefined`, true},
+		{`Error: connect ECONNREFUSED 127.0.0.1:5432`, true},
+		{`java.lang.NullPointerException: foo`, true},
+		{`1:M 10 Dec 2025 19:19:32.941 * Ready to accept connections`, false},
+		{`level=info msg="errors handled: 0"`, false},
+		{`GET /api/errors 200`, false},
+		{`writing to stderr`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			entries, err := parseErrorLines(strings.NewReader(tt.line))
+			if err != nil {
+				t.Fatalf("parseErrorLines() error = %v", err)
+			}
+			if got := len(entries) == 1; got != tt.want {
+				t.Errorf("parseErrorLines(%q) matched = %v, want %v", tt.line, got, tt.want)
+			}
+			if tt.want && entries[0].ErrorType != "LOG_ERROR" {
+				t.Errorf("ErrorType = %q, want LOG_ERROR", entries[0].ErrorType)
+			}
+		})
+	}
+}
+
+func TestIsLineParser(t *testing.T) {
+	cfg := &config.Config{Parsers: map[string]config.ParserConfig{"custom": {Pattern: `(?P<message>.*)`}}}
+
+	tests := map[string]bool{
+		"errorlines": true,
+		"tsc":        false,
+		"eslint":     false,
+		"ruff":       false,
+		"custom":     true,
+		"unknown":    false,
+	}
+	for name, want := range tests {
+		if got := isLineParser(name, cfg); got != want {
+			t.Errorf("isLineParser(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,445 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// pickAction is what the user chose to do with the picked entry
+type pickAction int
+
+const (
+	pickPrint pickAction = iota // print the entry as JSON
+	pickOpen                    // open the entry's file in $EDITOR
+)
+
+// pickKey is a decoded keypress
+type pickKey int
+
+const (
+	keyRune pickKey = iota
+	keyBackspace
+	keyClear
+	keyUp
+	keyDown
+	keyEnter
+	keyOpen
+	keyCancel
+	keyIgnore
+)
+
+// picker holds the state of the errors --pick view: entries newest first,
+// the query, and the matches for it, best first
+type picker struct {
+	entries []ErrorEntry
+	texts   []string
+	query   string
+	matches []int
+	cursor  int
+	offset  int
+	colors  theme // for the terminal drawn on, which needn't be stdout
+}
+
+func newPicker(entries []ErrorEntry) *picker {
+	p := &picker{}
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		p.entries = append(p.entries, e)
+		p.texts = append(p.texts, strings.Join([]string{
+			e.ID, e.ErrorType, e.Source, e.Message, e.File, e.Endpoint, strings.Join(e.Tags, " "),
+		}, " "))
+	}
+	p.setQuery("")
+	return p
+}
+
+// setQuery re-ranks entries for q, keeping newest first among equal scores
+func (p *picker) setQuery(q string) {
+	p.query = q
+	type scored struct{ index, score int }
+	var ranked []scored
+	for i, text := range p.texts {
+		if score, ok := fuzzyScore(q, text); ok {
+			ranked = append(ranked, scored{i, score})
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+
+	p.matches = p.matches[:0]
+	for _, r := range ranked {
+		p.matches = append(p.matches, r.index)
+	}
+	p.cursor, p.offset = 0, 0
+}
+
+func (p *picker) move(delta int) {
+	p.cursor += delta
+	if p.cursor >= len(p.matches) {
+		p.cursor = len(p.matches) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+}
+
+func (p *picker) selected() (ErrorEntry, bool) {
+	if len(p.matches) == 0 {
+		return ErrorEntry{}, false
+	}
+	return p.entries[p.matches[p.cursor]], true
+}
+
+// render draws the prompt, the match list with a preview of the selected
+// entry beside it, and a key help line, filling width x height
+func (p *picker) render(width, height int) string {
+	listWidth := width * 2 / 5
+	if listWidth < 20 {
+		listWidth = 20
+	}
+	previewWidth := width - listWidth - 3
+	rows := height - 2
+	if rows < 1 {
+		rows = 1
+	}
+
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+rows {
+		p.offset = p.cursor - rows + 1
+	}
+
+	var preview []string
+	if e, ok := p.selected(); ok {
+		preview = wrapRunes(fmt.Sprintf("%s  %s", e.ErrorType, formatShowTime(e.Timestamp)), previewWidth)
+		data, _ := json.MarshalIndent(e, "", "  ")
+		for _, line := range strings.Split(string(data), "\n") {
+			preview = append(preview, wrapRunes(line, previewWidth)...)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\033[H")
+	prompt := fmt.Sprintf("> %s", p.query)
+	count := fmt.Sprintf("%d/%d", len(p.matches), len(p.entries))
+	sb.WriteString(clipRunes(prompt, width-len(count)-2))
+	sb.WriteString("  " + p.colors.dim(count) + "\033[K\r\n")
+
+	for row := 0; row < rows; row++ {
+		item, when, errorType := "", "", ""
+		i := p.offset + row
+		if i < len(p.matches) {
+			e := p.entries[p.matches[i]]
+			if when = formatTimestamp(e.Timestamp); when != "" {
+				when += " "
+			}
+			item = clipRunes(fmt.Sprintf("%s%s %s", when, e.ErrorType, singleLine(e.Message)), listWidth-2)
+			errorType = e.ErrorType
+		}
+		item = padRunes(item, listWidth-2)
+		if i == p.cursor && i < len(p.matches) {
+			sb.WriteString("\033[7m> " + item + "\033[0m")
+		} else if rest, ok := strings.CutPrefix(item, when+errorType); ok && errorType != "" {
+			// Colored after padding, so the escapes don't count toward the width
+			sb.WriteString("  " + p.colors.dim(when) + p.colors.err(errorType) + rest)
+		} else {
+			sb.WriteString("  " + item)
+		}
+		sb.WriteString(" │ ")
+		if row < len(preview) {
+			sb.WriteString(preview[row])
+		}
+		sb.WriteString("\033[K\r\n")
+	}
+
+	sb.WriteString(p.colors.dim(clipRunes("enter: print JSON  ctrl-o: open file  ctrl-u: clear  esc: cancel", width)))
+	sb.WriteString("\033[K\033[J")
+	return sb.String()
+}
+
+// fuzzyScore reports whether every space-separated term of query appears in
+// text as a case-insensitive subsequence, scoring consecutive characters and
+// matches at word starts higher
+func fuzzyScore(query, text string) (int, bool) {
+	hay := []rune(strings.ToLower(text))
+	total := 0
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		score, pos, prev := 0, 0, -2
+		for _, c := range term {
+			for pos < len(hay) && hay[pos] != c {
+				pos++
+			}
+			if pos == len(hay) {
+				return 0, false
+			}
+			score++
+			if pos == prev+1 {
+				score += 5
+			}
+			if pos == 0 || !unicode.IsLetter(hay[pos-1]) && !unicode.IsDigit(hay[pos-1]) {
+				score += 3
+			}
+			prev = pos
+			pos++
+		}
+		total += score
+	}
+	return total, true
+}
+
+// readKey reads one keypress from a terminal in raw mode. A lone escape
+// cancels; arrow keys arrive as escape sequences in a single read.
+func readKey(r *bufio.Reader) (pickKey, rune, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return keyCancel, 0, err
+	}
+
+	switch b {
+	case 3, 7: // ctrl-c, ctrl-g
+		return keyCancel, 0, nil
+	case '\r', '\n':
+		return keyEnter, 0, nil
+	case 127, 8:
+		return keyBackspace, 0, nil
+	case 21: // ctrl-u
+		return keyClear, 0, nil
+	case 14: // ctrl-n
+		return keyDown, 0, nil
+	case 16: // ctrl-p
+		return keyUp, 0, nil
+	case 15: // ctrl-o
+		return keyOpen, 0, nil
+	case 27:
+		if r.Buffered() == 0 {
+			return keyCancel, 0, nil
+		}
+		next, _ := r.ReadByte()
+		if next != '[' && next != 'O' {
+			return keyIgnore, 0, nil
+		}
+		code, _ := r.ReadByte()
+		switch code {
+		case 'A':
+			return keyUp, 0, nil
+		case 'B':
+			return keyDown, 0, nil
+		}
+		return keyIgnore, 0, nil
+	}
+
+	if b < 32 {
+		return keyIgnore, 0, nil
+	}
+	if b >= utf8.RuneSelf {
+		r.UnreadByte()
+		c, _, err := r.ReadRune()
+		return keyRune, c, err
+	}
+	return keyRune, rune(b), nil
+}
+
+// runPicker drives the picker from keys read from in, drawing to out. It
+// returns false if the user cancelled.
+func runPicker(in io.Reader, out io.Writer, entries []ErrorEntry, width, height int) (ErrorEntry, pickAction, bool, error) {
+	p := newPicker(entries)
+	if f, ok := out.(*os.File); ok {
+		p.colors = theme{on: colorEnabled(f)}
+	}
+	r := bufio.NewReader(in)
+	for {
+		fmt.Fprint(out, p.render(width, height))
+
+		key, c, err := readKey(r)
+		if err != nil {
+			if err == io.EOF {
+				return ErrorEntry{}, pickPrint, false, nil
+			}
+			return ErrorEntry{}, pickPrint, false, err
+		}
+
+		switch key {
+		case keyRune:
+			p.setQuery(p.query + string(c))
+		case keyBackspace:
+			if q := []rune(p.query); len(q) > 0 {
+				p.setQuery(string(q[:len(q)-1]))
+			}
+		case keyClear:
+			p.setQuery("")
+		case keyUp:
+			p.move(-1)
+		case keyDown:
+			p.move(1)
+		case keyEnter, keyOpen:
+			if e, ok := p.selected(); ok {
+				action := pickPrint
+				if key == keyOpen {
+					action = pickOpen
+				}
+				return e, action, true, nil
+			}
+		case keyCancel:
+			return ErrorEntry{}, pickPrint, false, nil
+		}
+	}
+}
+
+// pickEntries runs the picker on the controlling terminal and writes the
+// chosen entry to w as JSON, or opens its file in $EDITOR. Drawing goes to
+// /dev/tty so the selection can be piped.
+func pickEntries(w io.Writer, baseDir string, entries []ErrorEntry) error {
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No errors match the filter criteria.")
+		return nil
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("--pick needs an interactive terminal: %w", err)
+	}
+	defer tty.Close()
+
+	saved, err := stty(tty, "-g")
+	if err != nil {
+		return fmt.Errorf("--pick needs an interactive terminal: %w", err)
+	}
+	if _, err := stty(tty, "raw", "-echo"); err != nil {
+		return fmt.Errorf("failed to set terminal mode: %w", err)
+	}
+	width, height := terminalSize(tty)
+
+	fmt.Fprint(tty, "\033[?1049h\033[?25l")
+	entry, action, ok, err := runPicker(tty, tty, entries, width, height)
+	fmt.Fprint(tty, "\033[?25h\033[?1049l")
+	stty(tty, saved)
+	if err != nil || !ok {
+		return err
+	}
+
+	if action == pickOpen {
+		path := entryLocalPath(baseDir, entry)
+		if path == "" {
+			return fmt.Errorf("entry %s has no file location", entry.ID)
+		}
+		args := editorCommand(editorName(), path, entry.Line)
+		editor := exec.Command(args[0], args[1:]...)
+		editor.Stdin, editor.Stdout, editor.Stderr = tty, tty, tty
+		return editor.Run()
+	}
+
+	output, _ := json.MarshalIndent(entry, "", "  ")
+	fmt.Fprintln(w, string(output))
+	return nil
+}
+
+// stty runs stty against the given terminal and returns its trimmed output
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// terminalSize returns the terminal's columns and rows, defaulting to 80x24
+func terminalSize(tty *os.File) (int, int) {
+	out, err := stty(tty, "size")
+	if err == nil {
+		if parts := strings.Fields(out); len(parts) == 2 {
+			rows, errR := strconv.Atoi(parts[0])
+			cols, errC := strconv.Atoi(parts[1])
+			if errR == nil && errC == nil && rows > 0 && cols > 0 {
+				return cols, rows
+			}
+		}
+	}
+	return 80, 24
+}
+
+// editorName returns the user's editor: $VISUAL, then $EDITOR, then vi
+func editorName() string {
+	for _, v := range []string{"VISUAL", "EDITOR"} {
+		if e := strings.TrimSpace(os.Getenv(v)); e != "" {
+			return e
+		}
+	}
+	return "vi"
+}
+
+// editorCommand builds the command line that opens file at line in editor,
+// using the line syntax of common editors
+func editorCommand(editor, file string, line int) []string {
+	args := strings.Fields(editor)
+	if line <= 0 {
+		return append(args, file)
+	}
+
+	switch filepath.Base(args[0]) {
+	case "code", "codium", "cursor":
+		return append(args, "-g", fmt.Sprintf("%s:%d", file, line))
+	case "subl", "zed", "hx", "helix":
+		return append(args, fmt.Sprintf("%s:%d", file, line))
+	}
+	return append(args, fmt.Sprintf("+%d", line), file)
+}
+
+// entryLocalPath resolves an entry's file against baseDir. Browser stack
+// frame URLs are mapped to their path within the project.
+func entryLocalPath(baseDir string, e ErrorEntry) string {
+	f := e.File
+	if u, err := url.Parse(f); err == nil && u.Scheme != "" && u.Host != "" {
+		f = strings.TrimPrefix(u.Path, "/")
+	}
+	if f == "" {
+		return ""
+	}
+	if filepath.IsAbs(f) {
+		return f
+	}
+	return filepath.Join(baseDir, f)
+}
+
+// clipRunes shortens s to at most n runes, marking the cut with "…"
+func clipRunes(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// padRunes right-pads s with spaces to n runes
+func padRunes(s string, n int) string {
+	if c := utf8.RuneCountInString(s); c < n {
+		return s + strings.Repeat(" ", n-c)
+	}
+	return s
+}
+
+// wrapRunes splits s into lines of at most n runes
+func wrapRunes(s string, n int) []string {
+	r := []rune(s)
+	if n <= 0 || len(r) <= n {
+		return []string{s}
+	}
+	var lines []string
+	for len(r) > n {
+		lines = append(lines, string(r[:n]))
+		r = r[n:]
+	}
+	return append(lines, string(r))
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var pickEntriesFixture = []ErrorEntry{
+	{ID: "aaaa000001", ErrorType: "DATABASE_ERROR", Source: "backend", Message: "connection refused", File: "db/pool.go", Line: 42},
+	{ID: "bbbb000002", ErrorType: "NETWORK_ERROR", Source: "frontend", Message: "timeout after 3000ms", Endpoint: "/api/users"},
+	{ID: "cccc000003", ErrorType: "UNCAUGHT_ERROR", Source: "frontend", Message: "Cannot read property 'foo' of undefined"},
+}
+
+func TestFuzzyScore(t *testing.T) {
+	if _, ok := fuzzyScore("", "anything"); !ok {
+		t.Error("empty query should match everything")
+	}
+	if _, ok := fuzzyScore("tmout", "NETWORK_ERROR timeout after 3000ms"); !ok {
+		t.Error("subsequence should match")
+	}
+	if _, ok := fuzzyScore("TIMEOUT users", "timeout on /api/users"); !ok {
+		t.Error("every term should match, case-insensitively")
+	}
+	if _, ok := fuzzyScore("timeout orders", "timeout on /api/users"); ok {
+		t.Error("a missing term should not match")
+	}
+
+	contiguous, _ := fuzzyScore("time", "timeout")
+	scattered, _ := fuzzyScore("time", "t_i_m_e")
+	if contiguous <= scattered {
+		t.Errorf("consecutive matches should score higher: %d <= %d", contiguous, scattered)
+	}
+}
+
+func TestPicker_Filtering(t *testing.T) {
+	p := newPicker(pickEntriesFixture)
+	if e, _ := p.selected(); e.ID != "cccc000003" {
+		t.Errorf("newest entry should be selected first, got %s", e.ID)
+	}
+
+	p.setQuery("front")
+	if len(p.matches) != 2 {
+		t.Fatalf("expected 2 frontend matches, got %d", len(p.matches))
+	}
+	p.move(5)
+	if e, _ := p.selected(); e.ID != "bbbb000002" {
+		t.Errorf("move should stop at the last match, got %s", e.ID)
+	}
+	p.move(-5)
+	if p.cursor != 0 {
+		t.Errorf("move should stop at the first match, got %d", p.cursor)
+	}
+
+	p.setQuery("zzz")
+	if _, ok := p.selected(); ok {
+		t.Error("no matches should select nothing")
+	}
+}
+
+func TestPicker_Render(t *testing.T) {
+	p := newPicker(pickEntriesFixture)
+	p.setQuery("pool")
+	out := p.render(100, 10)
+
+	if !strings.Contains(out, "> pool") || !strings.Contains(out, "1/3") {
+		t.Errorf("prompt should show the query and match count:\n%s", out)
+	}
+	if !strings.Contains(out, "DATABASE_ERROR connection refused") {
+		t.Errorf("list should show the match:\n%s", out)
+	}
+	if !strings.Contains(out, `"file": "db/pool.go"`) {
+		t.Errorf("preview should show the entry JSON:\n%s", out)
+	}
+	if lines := strings.Count(out, "\r\n"); lines != 9 {
+		t.Errorf("render should fill the height, got %d lines", lines+1)
+	}
+}
+
+func TestPicker_RenderShowsTime(t *testing.T) {
+	entries := append([]ErrorEntry(nil), pickEntriesFixture...)
+	ts := time.Now().Add(-3 * time.Minute).UTC().Format(time.RFC3339)
+	entries[0].Timestamp = ts
+	p := newPicker(entries)
+	p.setQuery("pool")
+	out := p.render(160, 10)
+
+	if !strings.Contains(out, "> 3m ago DATABASE_ERROR connection refused") {
+		t.Errorf("the row should lead with the relative time:\n%s", out)
+	}
+	if !strings.Contains(out, "│ DATABASE_ERROR  3m ago ("+ts+")") {
+		t.Errorf("the preview should start with the time, relative and exact:\n%s", out)
+	}
+}
+
+func TestReadKey(t *testing.T) {
+	tests := []struct {
+		input string
+		key   pickKey
+		r     rune
+	}{
+		{"a", keyRune, 'a'},
+		{"é", keyRune, 'é'},
+		{"\r", keyEnter, 0},
+		{"\x7f", keyBackspace, 0},
+		{"\x1b[A", keyUp, 0},
+		{"\x1b[B", keyDown, 0},
+		{"\x0e", keyDown, 0},
+		{"\x0f", keyOpen, 0},
+		{"\x1b", keyCancel, 0},
+		{"\x03", keyCancel, 0},
+		{"\x1b[C", keyIgnore, 0},
+	}
+	for _, tt := range tests {
+		key, r, err := readKey(bufio.NewReader(strings.NewReader(tt.input)))
+		if err != nil || key != tt.key || r != tt.r {
+			t.Errorf("readKey(%q) = %v, %q, %v; want %v, %q", tt.input, key, r, err, tt.key, tt.r)
+		}
+	}
+}
+
+func TestRunPicker(t *testing.T) {
+	out := new(bytes.Buffer)
+	e, action, ok, err := runPicker(strings.NewReader("fronx\x7f\x1b[B\r"), out, pickEntriesFixture, 80, 12)
+	if err != nil || !ok {
+		t.Fatalf("runPicker() = %v, %v", ok, err)
+	}
+	if e.ID != "bbbb000002" || action != pickPrint {
+		t.Errorf("runPicker() picked %s (action %v)", e.ID, action)
+	}
+
+	e, action, ok, _ = runPicker(strings.NewReader("pool\x0f"), out, pickEntriesFixture, 80, 12)
+	if !ok || e.ID != "aaaa000001" || action != pickOpen {
+		t.Errorf("ctrl-o should open the entry, got %s (action %v, ok %v)", e.ID, action, ok)
+	}
+
+	if _, _, ok, _ := runPicker(strings.NewReader("zzz\r\x1b"), out, pickEntriesFixture, 80, 12); ok {
+		t.Error("enter with no matches then esc should cancel")
+	}
+	if _, _, ok, err := runPicker(strings.NewReader("db"), out, pickEntriesFixture, 80, 12); ok || err != nil {
+		t.Errorf("end of input should cancel cleanly, got %v, %v", ok, err)
+	}
+}
+
+func TestEditorCommand(t *testing.T) {
+	tests := []struct {
+		editor string
+		line   int
+		want   string
+	}{
+		{"vim", 42, "vim +42 src/app.ts"},
+		{"nano", 0, "nano src/app.ts"},
+		{"code -w", 42, "code -w -g src/app.ts:42"},
+		{"/usr/local/bin/subl", 7, "/usr/local/bin/subl src/app.ts:7"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(editorCommand(tt.editor, "src/app.ts", tt.line), " "); got != tt.want {
+			t.Errorf("editorCommand(%q, %d) = %q, want %q", tt.editor, tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestEntryLocalPath(t *testing.T) {
+	base := filepath.Join(string(os.PathSeparator), "proj")
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"src/app.ts", filepath.Join(base, "src", "app.ts")},
+		{"http://localhost:5173/src/app.ts?t=123", filepath.Join(base, "src", "app.ts")},
+		{"/abs/main.go", "/abs/main.go"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := entryLocalPath(base, ErrorEntry{File: tt.file}); got != tt.want {
+			t.Errorf("entryLocalPath(%q) = %q, want %q", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestErrorsCommand_PickRejectsOtherModes(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, ".agentlog"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, ".agentlog", "errors.jsonl"), []byte(
+		`{"timestamp":"2025-12-10T19:19:32.941Z","source":"frontend","error_type":"NETWORK_ERROR","message":"a"}
+`), 0644)
+
+	originalPath := pathOverride
+	defer func() {
+		pathOverride = originalPath
+		errorsPick, errorsCount, errorsWatch = false, false, false
+	}()
+	pathOverride = tmpDir
+	errorsPick, errorsCount = true, true
+
+	if err := runErrors(errorsCmd, []string{}); err == nil || !strings.Contains(err.Error(), "--pick") {
+		t.Errorf("--pick with --count should fail, got %v", err)
+	}
+
+	errorsCount, errorsWatch = false, true
+	if err := runErrors(errorsCmd, []string{}); err == nil || !strings.Contains(err.Error(), "--pick") {
+		t.Errorf("--pick with --watch should fail, got %v", err)
+	}
+}
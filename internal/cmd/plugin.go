@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/agentlog/agentlog/internal/diag"
+)
+
+// pluginPrefix names plugin executables: agentlog-foo on PATH runs as
+// 'agentlog foo', as git and kubectl plugins do
+const pluginPrefix = "agentlog-"
+
+// The variables a plugin is given, replacing any it inherits
+const (
+	envPluginPath       = "AGENTLOG_PATH"        // the project directory, absolute
+	envPluginErrorsFile = "AGENTLOG_ERRORS_FILE" // its errors.jsonl
+	envPluginBin        = "AGENTLOG_BIN"         // this agentlog, for calling back into it
+	envPluginJSON       = "AGENTLOG_JSON"        // "1" with --json
+	envPluginQuiet      = "AGENTLOG_QUIET"       // "1" with --quiet
+	envPluginVerbose    = "AGENTLOG_VERBOSE"     // "1" with --verbose
+	envPluginNoColor    = "AGENTLOG_NO_COLOR"    // "1" with --no-color
+	envPluginAbsolute   = "AGENTLOG_ABSOLUTE"    // "1" with --absolute
+	envPluginMaxEntries = "AGENTLOG_MAX_ENTRIES" // --max-entries, when given
+)
+
+// PluginInfo describes a plugin found on PATH, for ai-help
+type PluginInfo struct {
+	Name  string `json:"name"`
+	Usage string `json:"usage"`
+	Path  string `json:"path"`
+}
+
+// findPluginCall reports whether args run a plugin: the first argument
+// that isn't a global flag names no built-in command, and agentlog-<name>
+// is on PATH. It returns the plugin's path, the global flags before its
+// name, and the arguments after it, which are the plugin's own.
+func findPluginCall(args []string) (path string, globals, rest []string, ok bool) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return "", nil, nil, false
+		}
+		if strings.HasPrefix(arg, "-") {
+			if takesValue(arg) && !strings.Contains(arg, "=") {
+				i++
+			}
+			continue
+		}
+		if isBuiltinCommand(arg) {
+			return "", nil, nil, false
+		}
+		path, err := exec.LookPath(pluginPrefix + arg)
+		if err != nil {
+			return "", nil, nil, false
+		}
+		return path, args[:i], args[i+1:], true
+	}
+	return "", nil, nil, false
+}
+
+// takesValue reports whether a global flag argument like --path needs
+// the next argument as its value
+func takesValue(arg string) bool {
+	name := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)[0]
+	flags := rootCmd.PersistentFlags()
+	f := flags.Lookup(name)
+	if f == nil && !strings.HasPrefix(arg, "--") && len(name) == 1 {
+		f = flags.ShorthandLookup(name)
+	}
+	return f != nil && f.NoOptDefVal == ""
+}
+
+// isBuiltinCommand reports whether name is one of agentlog's own commands,
+// which plugins can't shadow
+func isBuiltinCommand(name string) bool {
+	switch name {
+	case "help", "completion":
+		return true
+	}
+	for _, c := range rootCmd.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// runPlugin runs the plugin at path with args, once globals (the global
+// flags given before its name) are parsed into the environment it gets.
+// Its exit code becomes agentlog's.
+func runPlugin(path string, globals, args []string, stdout, stderr io.Writer) error {
+	if err := rootCmd.PersistentFlags().Parse(globals); err != nil {
+		return &CommandError{Code: "INVALID_FLAG", Err: err}
+	}
+	baseDir := GetPathOverride()
+	if baseDir == "" {
+		var err error
+		if baseDir, err = os.Getwd(); err != nil {
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+	if abs, err := filepath.Abs(baseDir); err == nil {
+		baseDir = abs
+	}
+
+	plugin := exec.Command(path, args...)
+	plugin.Stdin, plugin.Stdout, plugin.Stderr = os.Stdin, stdout, stderr
+	plugin.Env = pluginEnv(os.Environ(), baseDir)
+	diag.SetLevel(verbosity())
+	diag.Debugf("running plugin %s %s", path, strings.Join(args, " "))
+
+	err := plugin.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &ExitCodeError{Code: exitErr.ExitCode()}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to run plugin %s: %w", path, err)
+	}
+	return nil
+}
+
+// pluginEnv is environ with the plugin variables set for baseDir and the
+// global flags, and any inherited from an enclosing plugin call removed
+func pluginEnv(environ []string, baseDir string) []string {
+	set := map[string]string{
+		envPluginPath:       baseDir,
+		envPluginErrorsFile: GetErrorsPath(baseDir),
+	}
+	if bin, err := os.Executable(); err == nil {
+		set[envPluginBin] = bin
+	}
+	for name, on := range map[string]bool{
+		envPluginJSON:     IsJSONOutput(),
+		envPluginQuiet:    quiet,
+		envPluginVerbose:  verbose,
+		envPluginNoColor:  noColor,
+		envPluginAbsolute: IsAbsoluteTime(),
+	} {
+		if on {
+			set[name] = "1"
+		}
+	}
+	if n := GetMaxEntries(); n > 0 {
+		set[envPluginMaxEntries] = strconv.Itoa(n)
+	}
+
+	var env []string
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		switch name {
+		case envPluginPath, envPluginErrorsFile, envPluginBin, envPluginJSON, envPluginQuiet,
+			envPluginVerbose, envPluginNoColor, envPluginAbsolute, envPluginMaxEntries:
+			continue
+		}
+		env = append(env, kv)
+	}
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env = append(env, name+"="+set[name])
+	}
+	return env
+}
+
+// discoverPlugins lists the agentlog-* executables on PATH, the first of
+// each name winning as it does when run, leaving out those builtin
+// reports a built-in command shadows
+func discoverPlugins(builtin func(name string) bool) []PluginInfo {
+	seen := make(map[string]bool)
+	var plugins []PluginInfo
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name, ok := strings.CutPrefix(e.Name(), pluginPrefix)
+			if !ok || e.IsDir() {
+				continue
+			}
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			if name == "" || seen[name] || builtin(name) {
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			if _, err := exec.LookPath(path); err != nil {
+				continue // not executable
+			}
+			seen[name] = true
+			plugins = append(plugins, PluginInfo{Name: name, Usage: "agentlog " + name + " [args]", Path: path})
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writePlugin puts an agentlog-name shell script on a fresh PATH
+func writePlugin(t *testing.T, name, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts need a POSIX shell")
+	}
+	bin := t.TempDir()
+	path := filepath.Join(bin, pluginPrefix+name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return path
+}
+
+func TestFindPluginCall(t *testing.T) {
+	path := writePlugin(t, "hello", "exit 0\n")
+
+	tests := []struct {
+		args    []string
+		globals []string
+		rest    []string
+		ok      bool
+	}{
+		{args: []string{"hello", "--json", "x"}, rest: []string{"--json", "x"}, ok: true},
+		{args: []string{"--path", "/tmp/p", "-q", "hello"}, globals: []string{"--path", "/tmp/p", "-q"}, rest: []string{}, ok: true},
+		{args: []string{"--path=/tmp/p", "hello", "y"}, globals: []string{"--path=/tmp/p"}, rest: []string{"y"}, ok: true},
+		{args: []string{"--path", "hello"}}, // hello is --path's value
+		{args: []string{"errors", "hello"}}, // a built-in command
+		{args: []string{"nothere"}},         // no such plugin: cobra reports it
+		{args: []string{"--", "hello"}},
+	}
+	for _, tt := range tests {
+		got, globals, rest, ok := findPluginCall(tt.args)
+		if ok != tt.ok {
+			t.Errorf("findPluginCall(%q) ok = %v, want %v", tt.args, ok, tt.ok)
+			continue
+		}
+		if ok && (got != path || strings.Join(globals, " ") != strings.Join(tt.globals, " ") || strings.Join(rest, " ") != strings.Join(tt.rest, " ")) {
+			t.Errorf("findPluginCall(%q) = %s, %q, %q", tt.args, got, globals, rest)
+		}
+	}
+}
+
+func TestRunPlugin(t *testing.T) {
+	defer func() { jsonOutput, pathOverride, quiet, maxEntries = false, "", false, 0 }()
+	writePlugin(t, "env", `echo "$AGENTLOG_PATH|$AGENTLOG_ERRORS_FILE|$AGENTLOG_JSON|$AGENTLOG_QUIET|$AGENTLOG_VERBOSE|$AGENTLOG_MAX_ENTRIES|$*"
+exit 4
+`)
+	t.Setenv(envPluginVerbose, "1") // inherited from an enclosing call, not this one's flags
+	dir := t.TempDir()
+
+	stdout := new(bytes.Buffer)
+	path, globals, rest, ok := findPluginCall([]string{"--json", "--path", dir, "--quiet", "--max-entries", "50", "env", "a", "--b"})
+	if !ok {
+		t.Fatal("plugin not found")
+	}
+	err := runPlugin(path, globals, rest, stdout, new(bytes.Buffer))
+	if ExitCode(err) != 4 {
+		t.Errorf("the plugin's exit code should be agentlog's, got %v", err)
+	}
+	want := dir + "|" + GetErrorsPath(dir) + "|1|1||50|a --b\n"
+	if stdout.String() != want {
+		t.Errorf("plugin saw %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestAIHelp_ListsPlugins(t *testing.T) {
+	path := writePlugin(t, "report", "exit 0\n")
+	writePlugin(t, "errors", "exit 0\n") // shadowed by the built-in
+
+	buf := new(bytes.Buffer)
+	printAIHelpTo(buf)
+	var parsed CommandMetadata
+	if err := json.Unmarshal(buf.Bytes(), &parsed); err != nil {
+		t.Fatalf("failed to parse output: %v", err)
+	}
+	var found bool
+	for _, p := range parsed.Plugins {
+		if p.Name == "errors" {
+			t.Error("a plugin a built-in shadows shouldn't be listed")
+		}
+		found = found || (p.Name == "report" && p.Path == path)
+	}
+	if !found {
+		t.Errorf("plugins should include report at %s, got %+v", path, parsed.Plugins)
+	}
+	if parsed.PluginEnv[envPluginPath] == "" {
+		t.Error("plugin_env should document AGENTLOG_PATH")
+	}
+}
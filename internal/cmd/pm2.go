@@ -0,0 +1,304 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/diag"
+	"github.com/agentlog/agentlog/internal/self"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pm2LogsDir string
+	pm2Parser  string
+	pm2Source  string
+)
+
+// pm2ErrorLogPattern matches PM2 error log names: <app>-error.log, or
+// <app>-error-<instance>.log in cluster mode
+var pm2ErrorLogPattern = regexp.MustCompile(`^(.+)-error(?:-(\d+))?\.log$`)
+
+// pm2Cmd represents the pm2 command
+var pm2Cmd = &cobra.Command{
+	Use:   "pm2 [app...]",
+	Short: "Watch PM2 error logs and convert them into entries",
+	Long: `Watch PM2's error log files (~/.pm2/logs/<app>-error.log) and append new
+error lines as entries with source="worker" and context.pm2_app.
+
+Only lines written after the command starts are ingested. Indented lines
+following an error (stack frames) are attached as context.stack_trace.
+With no app names, all PM2 apps are watched, including ones started later.
+Use Ctrl+C to stop.
+
+Examples:
+  agentlog pm2                  # Watch every PM2 app
+  agentlog pm2 api worker       # Watch selected apps
+  agentlog pm2 --logs-dir /srv/pm2/logs`,
+	RunE: runPM2,
+}
+
+func init() {
+	rootCmd.AddCommand(pm2Cmd)
+
+	pm2Cmd.Flags().StringVar(&pm2LogsDir, "logs-dir", "", "PM2 logs directory (default: $PM2_HOME/logs or ~/.pm2/logs)")
+	pm2Cmd.Flags().StringVar(&pm2Parser, "parser", "errorlines", "Line parser to apply")
+	pm2Cmd.Flags().StringVar(&pm2Source, "source", "worker", "Source to record on ingested entries")
+}
+
+func runPM2(cmd *cobra.Command, args []string) error {
+	// Determine base directory (use --path override or cwd)
+	baseDir := GetPathOverride()
+	if baseDir == "" {
+		var err error
+		baseDir, err = os.Getwd()
+		if err != nil {
+			self.LogError(".", "GETWD_ERROR", err.Error())
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+
+	logsDir := pm2LogsDir
+	if logsDir == "" {
+		logsDir = defaultPM2LogsDir()
+	}
+	if info, err := os.Stat(logsDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("PM2 logs directory not found: %s (is pm2 installed? use --logs-dir to override)", logsDir)
+	}
+
+	ingester, err := newStreamIngester(baseDir, pm2Parser, pm2Source)
+	if err != nil {
+		return err
+	}
+
+	tailer := newPM2Tailer(logsDir, args, ingester)
+	tailer.skipExisting()
+
+	// Set up signal handling for graceful shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		cancel()
+	}()
+
+	if !IsJSONOutput() {
+		diag.Infof("Watching PM2 error logs in %s (Ctrl+C to stop)", logsDir)
+	}
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := tailer.poll(cmd.OutOrStdout()); err != nil {
+				self.LogError(baseDir, "INGEST_ERROR", err.Error())
+				return err
+			}
+		}
+	}
+}
+
+// defaultPM2LogsDir returns $PM2_HOME/logs, falling back to ~/.pm2/logs
+func defaultPM2LogsDir() string {
+	if home := os.Getenv("PM2_HOME"); home != "" {
+		return filepath.Join(home, "logs")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".pm2", "logs")
+}
+
+// pm2Tailer follows PM2 error log files and ingests new lines
+type pm2Tailer struct {
+	logsDir  string
+	apps     map[string]bool // empty means all apps
+	offsets  map[string]int64
+	ingester *streamIngester
+}
+
+// newPM2Tailer creates a tailer for the given apps (all apps if none)
+func newPM2Tailer(logsDir string, apps []string, ingester *streamIngester) *pm2Tailer {
+	t := &pm2Tailer{
+		logsDir:  logsDir,
+		apps:     make(map[string]bool),
+		offsets:  make(map[string]int64),
+		ingester: ingester,
+	}
+	for _, app := range apps {
+		t.apps[app] = true
+	}
+	return t
+}
+
+// pm2LogFile is an error log file and the app it belongs to
+type pm2LogFile struct {
+	path     string
+	app      string
+	instance string
+}
+
+// files lists the error logs for the selected apps
+func (t *pm2Tailer) files() []pm2LogFile {
+	dirEntries, err := os.ReadDir(t.logsDir)
+	if err != nil {
+		return nil
+	}
+
+	var files []pm2LogFile
+	for _, de := range dirEntries {
+		m := pm2ErrorLogPattern.FindStringSubmatch(de.Name())
+		if de.IsDir() || m == nil {
+			continue
+		}
+		if len(t.apps) > 0 && !t.apps[m[1]] {
+			continue
+		}
+		files = append(files, pm2LogFile{path: filepath.Join(t.logsDir, de.Name()), app: m[1], instance: m[2]})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+	return files
+}
+
+// skipExisting records current file sizes so only new lines are ingested
+func (t *pm2Tailer) skipExisting() {
+	for _, f := range t.files() {
+		if info, err := os.Stat(f.path); err == nil {
+			t.offsets[f.path] = info.Size()
+		}
+	}
+}
+
+// poll ingests lines appended to each log since the last poll. Files that
+// appear after startup are read from the beginning; truncated files
+// (pm2 flush) are re-read from the start.
+func (t *pm2Tailer) poll(w io.Writer) error {
+	for _, f := range t.files() {
+		info, err := os.Stat(f.path)
+		if err != nil {
+			continue
+		}
+		offset := t.offsets[f.path]
+		if info.Size() < offset {
+			offset = 0
+		}
+		if info.Size() == offset {
+			continue
+		}
+
+		data, newOffset, err := readFrom(f.path, offset)
+		if err != nil {
+			continue
+		}
+		t.offsets[f.path] = newOffset
+
+		if err := t.ingestBlock(data, f, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ingestBlock splits newly read data into error blocks (a header line plus
+// indented continuation lines) and ingests each header with its stack trace
+func (t *pm2Tailer) ingestBlock(data string, f pm2LogFile, w io.Writer) error {
+	jsonMode := IsJSONOutput()
+
+	for _, block := range splitPM2Blocks(data) {
+		line, timestamp := splitPM2Timestamp(block.header)
+
+		extra := map[string]interface{}{"pm2_app": f.app}
+		if f.instance != "" {
+			extra["pm2_instance"] = f.instance
+		}
+		if len(block.frames) > 0 {
+			extra["stack_trace"] = truncate(strings.Join(block.frames, "\n"), 2048)
+		}
+
+		entries, err := t.ingester.ingestLine(line, timestamp, extra)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			fmt.Fprintln(w, formatTailEntry(e, jsonMode))
+		}
+	}
+	return nil
+}
+
+// readFrom reads complete lines from path starting at offset, returning the
+// data and the offset just past the last complete line
+func readFrom(path string, offset int64) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", offset, err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return "", offset, err
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", offset, err
+	}
+
+	// Leave any partial trailing line for the next poll
+	end := strings.LastIndexByte(string(data), '\n')
+	if end < 0 {
+		return "", offset, nil
+	}
+	return string(data[:end+1]), offset + int64(end+1), nil
+}
+
+// pm2Block is a log line plus the indented lines that follow it
+type pm2Block struct {
+	header string
+	frames []string
+}
+
+// splitPM2Blocks groups lines into blocks; indented lines (stack frames)
+// attach to the preceding non-indented line
+func splitPM2Blocks(data string) []pm2Block {
+	var blocks []pm2Block
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(blocks) > 0 {
+			last := &blocks[len(blocks)-1]
+			last.frames = append(last.frames, strings.TrimSpace(line))
+			continue
+		}
+		blocks = append(blocks, pm2Block{header: line})
+	}
+	return blocks
+}
+
+// splitPM2Timestamp strips the prefix added by `pm2 start --time`
+// ("2025-12-10T19:19:32: message"), returning the line and an RFC3339 timestamp
+func splitPM2Timestamp(line string) (string, string) {
+	if len(line) < 21 || line[19] != ':' || line[20] != ' ' {
+		return line, ""
+	}
+	ts, err := time.ParseInLocation("2006-01-02T15:04:05", line[:19], time.Local)
+	if err != nil {
+		return line, ""
+	}
+	return line[21:], ts.UTC().Format(time.RFC3339Nano)
+}
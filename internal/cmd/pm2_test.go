@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitPM2Blocks(t *testing.T) {
+	data := `Error: connect ECONNREFUSED 127.0.0.1:6379
+    at TCPConnectWrap.afterConnect [as oncomplete] (node:net:1555:16)
+    at TCPConnectWrap.callbackTrampoline (node:internal/async_hooks:130:17)
+
+deprecation notice
+`
+	blocks := splitPM2Blocks(data)
+	if len(blocks) != 2 {
+		t.Fatalf("splitPM2Blocks() returned %d blocks, want 2", len(blocks))
+	}
+	if blocks[0].header != "Error: connect ECONNREFUSED 127.0.0.1:6379" {
+		t.Errorf("header = %q", blocks[0].header)
+	}
+	if len(blocks[0].frames) != 2 {
+		t.Errorf("expected 2 stack frames, got %d", len(blocks[0].frames))
+	}
+	if len(blocks[1].frames) != 0 {
+		t.Errorf("second block should have no frames, got %v", blocks[1].frames)
+	}
+}
+
+func TestSplitPM2Timestamp(t *testing.T) {
+	line, ts := splitPM2Timestamp("2025-12-10T19:19:32: Error: boom")
+	if line != "Error: boom" {
+		t.Errorf("line = %q, want 'Error: boom'", line)
+	}
+	if ts == "" {
+		t.Error("timestamp should be parsed from --time prefix")
+	}
+
+	line, ts = splitPM2Timestamp("Error: boom")
+	if line != "Error: boom" || ts != "" {
+		t.Errorf("line without prefix should be unchanged, got %q %q", line, ts)
+	}
+}
+
+func TestPM2Tailer_IngestsNewLines(t *testing.T) {
+	baseDir := t.TempDir()
+	logsDir := t.TempDir()
+
+	apiLog := filepath.Join(logsDir, "api-error.log")
+	workerLog := filepath.Join(logsDir, "worker-error-1.log")
+	os.WriteFile(apiLog, []byte("Error: old failure before start\n"), 0644)
+	os.WriteFile(workerLog, []byte(""), 0644)
+	os.WriteFile(filepath.Join(logsDir, "api-out.log"), []byte("Error: stdout is ignored\n"), 0644)
+
+	ingester, err := newStreamIngester(baseDir, "errorlines", "worker")
+	if err != nil {
+		t.Fatalf("newStreamIngester() error = %v", err)
+	}
+	tailer := newPM2Tailer(logsDir, nil, ingester)
+	tailer.skipExisting()
+
+	f, _ := os.OpenFile(apiLog, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("TypeError: Cannot read properties of undefined (reading 'id')\n    at handler (/app/api.js:10:5)\n")
+	f.Close()
+	os.WriteFile(workerLog, []byte("Error: job failed\npartial line without newl"), 0644)
+
+	buf := new(bytes.Buffer)
+	if err := tailer.poll(buf); err != nil {
+		t.Fatalf("poll() error = %v", err)
+	}
+
+	entries, err := readErrors(baseDir)
+	if err != nil {
+		t.Fatalf("readErrors() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d: %+v", len(entries), entries)
+	}
+
+	byApp := map[string]ErrorEntry{}
+	for _, e := range entries {
+		byApp[e.Context["pm2_app"].(string)] = e
+		if e.Source != "worker" {
+			t.Errorf("Source = %q, want worker", e.Source)
+		}
+		if strings.Contains(e.Message, "old failure") {
+			t.Error("lines written before start should be skipped")
+		}
+	}
+
+	api, ok := byApp["api"]
+	if !ok {
+		t.Fatal("expected entry for api app")
+	}
+	if st, _ := api.Context["stack_trace"].(string); !strings.Contains(st, "api.js:10:5") {
+		t.Errorf("stack frames should be attached, got context %v", api.Context)
+	}
+	if byApp["worker"].Context["pm2_instance"] != "1" {
+		t.Errorf("pm2_instance = %v, want 1", byApp["worker"].Context["pm2_instance"])
+	}
+
+	// Completing the partial line makes it available on the next poll
+	f, _ = os.OpenFile(workerLog, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("ine\n")
+	f.Close()
+	if err := tailer.poll(buf); err != nil {
+		t.Fatalf("poll() error = %v", err)
+	}
+	entries, _ = readErrors(baseDir)
+	if len(entries) != 2 {
+		t.Errorf("non-error partial line should not create an entry, got %d entries", len(entries))
+	}
+}
+
+func TestPM2Tailer_SelectedApps(t *testing.T) {
+	logsDir := t.TempDir()
+	os.WriteFile(filepath.Join(logsDir, "api-error.log"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(logsDir, "worker-error.log"), []byte(""), 0644)
+
+	tailer := newPM2Tailer(logsDir, []string{"worker"}, nil)
+	files := tailer.files()
+	if len(files) != 1 || files[0].app != "worker" {
+		t.Errorf("expected only worker log, got %+v", files)
+	}
+}
+
+func TestDefaultPM2LogsDir(t *testing.T) {
+	t.Setenv("PM2_HOME", "/custom/pm2")
+	if got := defaultPM2LogsDir(); got != "/custom/pm2/logs" {
+		t.Errorf("defaultPM2LogsDir() = %q, want /custom/pm2/logs", got)
+	}
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/agentlog/agentlog/internal/config"
+	"github.com/agentlog/agentlog/internal/diag"
+	"github.com/agentlog/agentlog/internal/redact"
+)
+
+// entryPolicy is what's done to entries before they're stored or shown:
+// context keys the config excludes are dropped, and secrets and personal
+// data are masked. A nil *entryPolicy leaves entries as they are.
+type entryPolicy struct {
+	redactor *redact.Redactor // nil when redaction is disabled
+	allow    []string         // top-level context keys kept; empty keeps all
+	deny     []string         // context keys, or dotted paths, dropped
+}
+
+// entryPolicyFor is baseDir's entry policy
+func entryPolicyFor(baseDir string) *entryPolicy {
+	cfg, err := config.Load(baseDir)
+	if err != nil {
+		cfg = &config.Config{}
+	}
+	return policyFor(cfg)
+}
+
+// policyFor builds the policy cfg asks for. Invalid extra redact patterns
+// are warned about and the built-in ones used alone, since failing to
+// redact at all would be worse.
+func policyFor(cfg *config.Config) *entryPolicy {
+	p := &entryPolicy{
+		allow: lowerAll(cfg.Context.Allow),
+		deny:  lowerAll(cfg.Context.Deny),
+	}
+	if !cfg.Redact.Disabled {
+		r, err := redact.New(cfg.Redact.Patterns)
+		if err != nil {
+			diag.Warnf("%v; masking with the built-in patterns only", err)
+			r, _ = redact.New(nil)
+		}
+		p.redactor = r
+	}
+	return p
+}
+
+func lowerAll(keys []string) []string {
+	lower := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			lower = append(lower, k)
+		}
+	}
+	return lower
+}
+
+// apply drops e's excluded context keys and masks its free-text fields:
+// its message, endpoint, and context. The ID, computed when e was read,
+// is kept, so it still finds the stored entry.
+func (p *entryPolicy) apply(e ErrorEntry) ErrorEntry {
+	if p == nil {
+		return e
+	}
+	e.Context = p.filterContext(e.Context, "")
+	if p.redactor != nil {
+		e.Message = p.redactor.String(e.Message)
+		e.Endpoint = p.redactor.String(e.Endpoint)
+		e.Context = p.redactor.Map(e.Context)
+	}
+	return e
+}
+
+// applyAll applies the policy to each of entries in place and returns them
+func (p *entryPolicy) applyAll(entries []ErrorEntry) []ErrorEntry {
+	if p == nil {
+		return entries
+	}
+	for i := range entries {
+		entries[i] = p.apply(entries[i])
+	}
+	return entries
+}
+
+// filterContext returns ctx without the keys the policy excludes. prefix
+// is ctx's dotted path within the entry's context, "" at the top, where
+// the allow list applies. A deny entry without a dot matches that key at
+// any depth; one with dots matches that path from the top.
+func (p *entryPolicy) filterContext(ctx map[string]interface{}, prefix string) map[string]interface{} {
+	if len(ctx) == 0 || (len(p.allow) == 0 && len(p.deny) == 0) {
+		return ctx
+	}
+	kept := make(map[string]interface{}, len(ctx))
+	for k, v := range ctx {
+		key := strings.ToLower(k)
+		if prefix == "" && len(p.allow) > 0 && !containsString(p.allow, key) {
+			continue
+		}
+		if containsString(p.deny, key) || containsString(p.deny, prefix+key) {
+			continue
+		}
+		if nested, ok := v.(map[string]interface{}); ok && len(nested) > 0 {
+			filtered := p.filterContext(nested, prefix+key+".")
+			if filtered == nil {
+				continue // every key in it was dropped
+			}
+			v = filtered
+		}
+		kept[k] = v
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return kept
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/agentlog/agentlog/internal/config"
+)
+
+func TestAppendErrors_Redacts(t *testing.T) {
+	tmpDir := t.TempDir()
+	entries := []ErrorEntry{{
+		Timestamp: "2025-12-10T12:00:00.000Z", Source: "backend", ErrorType: "AUTH_ERROR",
+		Message:  "login failed for ann@example.com",
+		Endpoint: "/api/session?token=abc123def",
+		Context:  map[string]interface{}{"authorization": "Bearer abcdefghijk", "attempt": 2.0},
+	}}
+	if err := appendErrors(tmpDir, entries); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(GetErrorsPath(tmpDir))
+	for _, secret := range []string{"ann@example.com", "abc123def", "abcdefghijk"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("errors.jsonl should not contain %q:\n%s", secret, data)
+		}
+	}
+	if entries[0].Message != "login failed for [EMAIL]" {
+		t.Errorf("appendErrors should redact entries in place, got %q", entries[0].Message)
+	}
+
+	os.WriteFile(filepath.Join(tmpDir, ".agentlog", "config.json"), []byte(`{"redact": {"disabled": true}}`), 0644)
+	appendErrors(tmpDir, []ErrorEntry{{Source: "backend", ErrorType: "E", Message: "bob@example.com"}})
+	if data, _ := os.ReadFile(GetErrorsPath(tmpDir)); !strings.Contains(string(data), "bob@example.com") {
+		t.Error("redact.disabled should leave entries as they are")
+	}
+}
+
+func TestRenderErrors_RedactsOnRead(t *testing.T) {
+	// Written by a snippet that doesn't redact
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, ".agentlog"), 0755)
+	os.WriteFile(GetErrorsPath(tmpDir), []byte(
+		`{"timestamp":"2025-12-10T12:00:00.000Z","source":"frontend","error_type":"NETWORK_ERROR","message":"POST failed for cust_123456 with api_key=s3cr3tvalue","context":{"cookie":"sid=1"}}
+`), 0644)
+	os.WriteFile(filepath.Join(tmpDir, ".agentlog", "config.json"), []byte(`{"redact": {"patterns": ["cust_[0-9]{6}"]}}`), 0644)
+
+	defer func() { errorsLimit, errorsQuery, jsonOutput = 10, "", false }()
+	errorsLimit, errorsSource, errorsType, errorsSince, errorsQuery = 10, "", "", "", "message~s3cr3t"
+	errorsCount, errorsGroup, errorsPick, errorsFields, errorsTemplate, jsonOutput = false, false, false, "", "", true
+
+	var buf bytes.Buffer
+	if err := renderErrors(&buf, tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	var got []ErrorEntry
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(got) != 1 {
+		t.Fatalf("--query should match the stored text, got %d entries", len(got))
+	}
+	if got[0].Message != "POST failed for [REDACTED] with api_key=[REDACTED]" || got[0].Context["cookie"] != "[REDACTED]" {
+		t.Errorf("entry = %+v", got[0])
+	}
+
+	errorsQuery = ""
+	buf.Reset()
+	if err := streamNDJSON(&buf, tmpDir, 0, func(ErrorEntry) bool { return true }); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "s3cr3tvalue") {
+		t.Errorf("--output ndjson should redact too:\n%s", buf.String())
+	}
+}
+
+func TestPrimeSummary_Redacts(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, ".agentlog"), 0755)
+	os.WriteFile(GetErrorsPath(tmpDir), []byte(
+		`{"timestamp":"2025-12-10T12:00:00.000Z","source":"backend","error_type":"AUTH_ERROR","message":"no account for ann@example.com","endpoint":"/login?password=hunter22"}
+`), 0644)
+	originalPath := pathOverride
+	defer func() { pathOverride, primeNoCache = originalPath, false }()
+	pathOverride, primeNoCache = tmpDir, true
+
+	summary, err := generatePrimeSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := formatPrimeSummaryJSON(summary)
+	if strings.Contains(out, "ann@example.com") || strings.Contains(out, "hunter22") {
+		t.Errorf("prime should redact what it summarizes:\n%s", out)
+	}
+}
+
+func TestNodeSnippet_Redacts(t *testing.T) {
+	for name, snippet := range map[string]string{"snippet": getSnippet("node"), "capture": nodeCapture} {
+		for _, want := range []string{"AGENTLOG_REDACT", "redact(entry.message)", "redact(entry.context)", "'[EMAIL]'", "'[CARD]'"} {
+			if !strings.Contains(snippet, want) {
+				t.Errorf("node %s should contain %q", name, want)
+			}
+		}
+	}
+}
+
+func TestFilterContext(t *testing.T) {
+	ctx := map[string]interface{}{
+		"route":   "/checkout",
+		"Headers": map[string]interface{}{"accept": "json"},
+		"request": map[string]interface{}{
+			"url":     "/api/cart",
+			"body":    map[string]interface{}{"card": "x"},
+			"headers": map[string]interface{}{"accept": "json"},
+		},
+		"only": map[string]interface{}{"body": 1.0},
+		"env":  map[string]interface{}{"HOME": "/home/ann"},
+	}
+	tests := []struct {
+		name        string
+		allow, deny []string
+		want        map[string]interface{}
+	}{
+		{"no lists", nil, nil, ctx},
+		{"deny", nil, []string{"headers", "Request.Body", "env"}, map[string]interface{}{
+			"route":   "/checkout",
+			"request": map[string]interface{}{"url": "/api/cart"},
+			"only":    map[string]interface{}{"body": 1.0},
+		}},
+		{"allow", []string{"route", "request"}, []string{"request.url"}, map[string]interface{}{
+			"route": "/checkout",
+			"request": map[string]interface{}{
+				"body":    map[string]interface{}{"card": "x"},
+				"headers": map[string]interface{}{"accept": "json"},
+			},
+		}},
+		{"nothing kept", []string{"user"}, nil, nil},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{Context: config.ContextConfig{Allow: tt.allow, Deny: tt.deny}}
+		if got := policyFor(cfg).filterContext(ctx, ""); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: filterContext() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if _, ok := ctx["env"]; !ok {
+		t.Error("filterContext() should leave its argument as it was")
+	}
+}
+
+func TestAppendErrors_ContextPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, ".agentlog"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, ".agentlog", "config.json"), []byte(`{"context": {"deny": ["headers", "request.body"]}}`), 0644)
+	entries := []ErrorEntry{{
+		Timestamp: "2025-12-10T12:00:00.000Z", Source: "backend", ErrorType: "HTTP_ERROR", Message: "POST /cart 500",
+		Context: map[string]interface{}{
+			"status":  500.0,
+			"request": map[string]interface{}{"body": "card=4111", "headers": map[string]interface{}{"x-user": "ann"}},
+		},
+	}}
+	if err := appendErrors(tmpDir, entries); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := readErrors(tmpDir)
+	if err != nil || len(stored) != 1 {
+		t.Fatalf("readErrors() = %d entries, %v", len(stored), err)
+	}
+	if want := map[string]interface{}{"status": 500.0}; !reflect.DeepEqual(stored[0].Context, want) {
+		t.Errorf("stored context = %v, want %v", stored[0].Context, want)
+	}
+}
+
+func TestNodeSnippet_ContextPolicy(t *testing.T) {
+	for name, snippet := range map[string]string{"snippet": getSnippet("node"), "capture": nodeCapture} {
+		for _, want := range []string{".agentlog/config.json", "filterContext(entry.context)"} {
+			if !strings.Contains(snippet, want) {
+				t.Errorf("node %s should contain %q", name, want)
+			}
+		}
+	}
+}
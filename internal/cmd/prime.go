@@ -8,26 +8,44 @@ import (
 	"strings"
 	"time"
 
+	"github.com/agentlog/agentlog/internal/diag"
 	"github.com/agentlog/agentlog/internal/self"
 	"github.com/spf13/cobra"
 )
 
 // PrimeSummary is the output structure for prime command
 type PrimeSummary struct {
-	TotalErrors    int              `json:"total_errors"`
-	Last24hErrors  int              `json:"last_24h_errors"`
-	LastHourErrors int              `json:"last_hour_errors"`
-	TopErrorTypes  []ErrorTypeCount `json:"top_error_types"`
-	TopSources     []SourceCount    `json:"top_sources"`
-	ActionableTip  string           `json:"actionable_tip"`
-	GeneratedAt    string           `json:"generated_at"`
-	NoLogFile      bool             `json:"no_log_file,omitempty"`
+	TotalErrors    int               `json:"total_errors"`
+	Last24hErrors  int               `json:"last_24h_errors"`
+	LastHourErrors int               `json:"last_hour_errors"`
+	TopErrorTypes  []ErrorTypeCount  `json:"top_error_types"`
+	TopSources     []SourceCount     `json:"top_sources"`
+	TopGroups      []ErrorGroup      `json:"top_groups"`
+	TopFiles       []LocationCount   `json:"top_files"`
+	TopEndpoints   []LocationCount   `json:"top_endpoints"`
+	SlowOperations []ErrorEntry      `json:"slow_operations,omitempty"`
+	Correlations   []Correlation     `json:"correlations,omitempty"`
+	RecentEdits    []EditCorrelation `json:"recent_edits,omitempty"`   // error types that started right after files were edited
+	Flaky          []FlakyError      `json:"flaky,omitempty"`          // errors that keep going away and coming back
+	Regressions    []RegressedError  `json:"regressions,omitempty"`    // resolved errors that occurred again
+	PastFixes      []RecurringFix    `json:"past_fixes,omitempty"`     // how errors that occurred again were fixed before
+	SnoozeExpired  []ExpiredSnooze   `json:"snooze_expired,omitempty"` // snoozed errors still occurring after the snooze
+	Storms         []ErrorStorm      `json:"storms,omitempty"`         // bursts of one error in the last 24h
+	Suggestions    []GroupSuggestion `json:"suggestions,omitempty"`    // next steps for top groups a suggestion rule matches
+	Environment    string            `json:"environment,omitempty"`
+	ActionableTip  string            `json:"actionable_tip"`
+	GeneratedAt    string            `json:"generated_at"`
+	NoLogFile      bool              `json:"no_log_file,omitempty"`
+	NotInitialized bool              `json:"not_initialized,omitempty"` // no .agentlog/ directory either
+	Delta          bool              `json:"delta,omitempty"`           // only entries appended since the last --delta run
+	LastRun        string            `json:"last_run,omitempty"`        // when that run was; empty on the first
 }
 
 // ErrorTypeCount aggregates error counts by type
 type ErrorTypeCount struct {
 	ErrorType string `json:"error_type"`
 	Count     int    `json:"count"`
+	Trend     []int  `json:"trend_24h,omitempty"` // hourly counts over the last 24h, oldest first
 }
 
 // SourceCount aggregates error counts by source
@@ -36,6 +54,14 @@ type SourceCount struct {
 	Count  int    `json:"count"`
 }
 
+var (
+	primeEnv     string
+	primeSince   string
+	primeAgent   string
+	primeNoCache bool
+	primeDelta   bool
+)
+
 // primeCmd represents the prime command
 var primeCmd = &cobra.Command{
 	Use:   "prime",
@@ -45,41 +71,107 @@ var primeCmd = &cobra.Command{
 This command is designed to be used by orchestration hooks to inject
 error context into agent prompts. Output includes:
   - Recent error count (last hour, last 24h)
+  - Regressions: errors marked fixed with 'agentlog resolve' that came back,
+    with how they were fixed when that was recorded (resolve --note/--commit).
+    Occurrences from before an error was resolved are left out of
+    everything else, so only open problems are counted
+  - Errors hidden with 'agentlog snooze' that are still happening after the
+    snooze ended; while it lasts, they're left out of everything else
+  - Error storms in the last 24h: one error 50 or more times within a minute
+    ("storms" in .agentlog/config.json), to be treated as one problem
   - Top error types by frequency
   - Top sources by frequency
+  - Top recurring errors (similar messages grouped)
+  - Files and endpoints producing the most errors
+  - Errors from different sources that coincided in the last 24h (shared
+    request, trace, or session IDs, or within 2 seconds on the same endpoint)
+  - The slowest operations recorded as perf entries (kind "perf")
+  - Error types that first appeared within 10 minutes of file edits, when
+    "track_edits" is set in .agentlog/config.json
+  - Errors that keep going away and coming back across sessions, which are
+    likely flaky rather than regressions
+  - Suggested next steps for top recurring errors that match a known
+    pattern (connection refused, CORS, undefined property access, ...)
   - Actionable tip for the agent
 
+Aggregates are cached in .agentlog/cache.json, so each run only parses
+entries appended since the last one; --no-cache reads the whole log. The
+cache isn't used with --since.
+
+--delta summarizes only the entries appended since the previous
+'prime --delta', which suits hooks that run on every turn: when nothing
+was appended it says so without parsing the log. The position is kept in
+.agentlog/prime-delta.json.
+
+With "track_edits", agentlog records the files git reports as changed in
+.agentlog/edits.jsonl when entries are written, while serve or tail runs,
+and when prime runs. An error type that was already occurring before
+the edits isn't blamed on them, so --since and --delta, which see only
+part of the log, leave the edits out.
+
+Sessions are runs of entries with no 30-minute gap. An error group missing
+from a session that came back afterwards, at least twice, is listed as
+likely flaky; --delta leaves these out.
+
+--agent shapes the output for a specific consumer instead: claude (tagged
+sections with instructions), cursor (a terse two-line note), or
+generic-json (flat JSON with a status and one instruction string).
+
 Examples:
   agentlog prime          # Human-readable summary
+  agentlog prime --env dev  # Only errors from the dev server, not test runs
+  agentlog prime --since yesterday  # Only errors since yesterday's start
+  agentlog prime --agent claude  # For a Claude Code hook
+  agentlog prime --delta  # Only what's new since the last --delta
   agentlog prime --json   # JSON for programmatic use`,
-	Run: runPrimeCommand,
+	RunE: runPrimeCommand,
 }
 
 func init() {
 	rootCmd.AddCommand(primeCmd)
+
+	primeCmd.Flags().StringVar(&primeSince, "since", "", "Only summarize errors since time (e.g., '2h', '2d', 'yesterday', '2024-01-01 14:00')")
+	primeCmd.Flags().StringVar(&primeEnv, "env", "", "Only summarize errors from this environment (dev, test, preview, staging)")
+	primeCmd.Flags().BoolVar(&primeDelta, "delta", false, "Only summarize entries appended since the last 'prime --delta'")
+	primeCmd.Flags().BoolVar(&primeNoCache, "no-cache", false, "Read the whole log instead of updating .agentlog/cache.json")
+	primeCmd.Flags().StringVar(&primeAgent, "agent", "", "Shape output for a consumer ("+strings.Join(primePresetNames(), ", ")+")")
 }
 
-func runPrimeCommand(cmd *cobra.Command, args []string) {
+func runPrimeCommand(cmd *cobra.Command, args []string) error {
+	var preset primePreset
+	if primeAgent != "" {
+		var err error
+		if preset, err = findPrimePreset(primeAgent); err != nil {
+			return reportFailure(cmd, "Error", err)
+		}
+	}
+
 	summary, err := generatePrimeSummary()
 	if err != nil {
-		fmt.Fprintf(cmd.ErrOrStderr(), "Error generating summary: %v\n", err)
-		return
+		return reportFailure(cmd, "Error generating summary", err)
 	}
 
 	var output string
-	if IsJSONOutput() {
+	if preset.Format != nil {
+		output = preset.Format(summary)
+	} else if IsJSONOutput() {
 		output = formatPrimeSummaryJSON(summary)
 	} else {
 		output = formatPrimeSummaryHuman(summary)
 	}
 
 	fmt.Fprint(cmd.OutOrStdout(), output)
+	if summary.NotInitialized {
+		return exitNotInitialized(cmd)
+	}
+	return nil
 }
 
 // generatePrimeSummary reads errors and generates aggregate summary
 func generatePrimeSummary() (PrimeSummary, error) {
 	summary := PrimeSummary{
 		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Environment: primeEnv,
 	}
 
 	// Determine base directory (use --path override or cwd)
@@ -93,64 +185,196 @@ func generatePrimeSummary() (PrimeSummary, error) {
 		}
 	}
 
-	// Read errors using existing function
-	entries, err := readErrors(baseDir)
+	now := time.Now().UTC()
+	var sinceTime time.Time
+	if primeSince != "" {
+		var err error
+		sinceTime, err = parseSince(primeSince)
+		if err != nil {
+			self.LogError(baseDir, "INVALID_INPUT", fmt.Sprintf("invalid --since value '%s': %v", primeSince, err))
+			return summary, invalidInput("invalid --since value: %w", err)
+		}
+	}
+
+	if primeDelta && !sinceTime.IsZero() {
+		self.LogError(baseDir, "INVALID_INPUT", "--delta and --since can't be combined")
+		return summary, invalidInput("--delta and --since can't be combined")
+	}
+
+	// The cache collapses entries, which --since can't filter, so it
+	// reads the whole log
+	var set primeEntries
+	var err error
+	switch {
+	case primeDelta:
+		var lastRun time.Time
+		set, lastRun, err = loadDeltaEntries(baseDir, now)
+		summary.Delta = true
+		if !lastRun.IsZero() {
+			summary.LastRun = lastRun.UTC().Format(time.RFC3339)
+		}
+	case sinceTime.IsZero() && !primeNoCache:
+		set, err = loadPrimeEntries(baseDir, now)
+	default:
+		var entries []ErrorEntry
+		entries, err = readErrors(baseDir)
+		set = primeEntriesFrom(filterErrors(entries, "", "", sinceTime), now)
+	}
 	if err != nil {
 		if os.IsNotExist(err) {
 			summary.NoLogFile = true
+			summary.NotInitialized = uninitialized(baseDir)
 			return summary, nil
 		}
 		return summary, err
 	}
 
+	// What's summarized is redacted, since it goes into agent context
+	policy := entryPolicyFor(baseDir)
+	redactor := policy.redactor
+	set = set.environment(primeEnv)
+	summary.SlowOperations = policy.applyAll(slowest(set.perf, 3))
+	snoozes := loadSnoozes(baseDir, now)
+	set.errors, set.recent = snoozes.hide(set.errors), snoozes.hide(set.recent)
+	// What resolved errors did before they were resolved isn't counted;
+	// their regressions are
+	resolutions := loadResolutions(baseDir)
+	set.errors, set.recent = resolutions.hide(set.errors), resolutions.hide(set.recent)
+	entries := set.errors
 	if len(entries) == 0 {
 		return summary, nil
 	}
 
-	// Calculate time boundaries
-	now := time.Now().UTC()
+	// Count by time window
 	oneHourAgo := now.Add(-1 * time.Hour)
-	twentyFourHoursAgo := now.Add(-24 * time.Hour)
-
-	// Aggregate counts
-	errorTypeCounts := make(map[string]int)
-	sourceCounts := make(map[string]int)
 	var lastHour, last24h int
-
-	for _, entry := range entries {
-		// Parse timestamp
-		ts, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
-		if err != nil {
-			// Try without nano
-			ts, err = time.Parse(time.RFC3339, entry.Timestamp)
-		}
+	for _, entry := range set.recent {
+		ts, err := parseEntryTime(entry.Timestamp)
 		if err != nil {
 			continue
 		}
-
-		// Count by time window
+		last24h += entry.occurrences()
 		if ts.After(oneHourAgo) {
-			lastHour++
-		}
-		if ts.After(twentyFourHoursAgo) {
-			last24h++
+			lastHour += entry.occurrences()
 		}
+	}
 
-		// Aggregate by type and source
-		errorTypeCounts[entry.ErrorType]++
-		sourceCounts[entry.Source]++
+	// Aggregate by type and source
+	errorTypeCounts := make(map[string]int)
+	sourceCounts := make(map[string]int)
+	for _, entry := range entries {
+		errorTypeCounts[entry.ErrorType] += entry.occurrences()
+		sourceCounts[entry.Source] += entry.occurrences()
 	}
 
-	summary.TotalErrors = len(entries)
+	summary.TotalErrors = totalOccurrences(entries)
 	summary.LastHourErrors = lastHour
 	summary.Last24hErrors = last24h
 	summary.TopErrorTypes = topN(errorTypeCounts, 3)
+	trends := newTrendCounter(now)
+	for _, entry := range set.recent {
+		trends.add(entry)
+	}
+	trends.fill(summary.TopErrorTypes)
 	summary.TopSources = topNSources(sourceCounts, 3)
+	summary.TopGroups = groupErrors(entries)
+	if len(summary.TopGroups) > 3 {
+		summary.TopGroups = summary.TopGroups[:3]
+	}
+	summary.Suggestions = suggesterFor(baseDir).suggestGroups(summary.TopGroups)
+	for i := range summary.TopGroups {
+		summary.TopGroups[i].Message = redactor.String(summary.TopGroups[i].Message)
+		summary.TopGroups[i].Pattern = redactor.String(summary.TopGroups[i].Pattern)
+	}
+	files, endpoints := locationCounts(entries)
+	summary.TopFiles = topLocations(files, 3)
+	summary.TopEndpoints = topLocations(endpoints, 3)
+	for i := range summary.TopEndpoints {
+		summary.TopEndpoints[i].Name = redactor.String(summary.TopEndpoints[i].Name)
+	}
+	summary.Correlations = correlate(set.recent, 3)
+	for i := range summary.Correlations {
+		summary.Correlations[i].Endpoint = redactor.String(summary.Correlations[i].Endpoint)
+	}
+	// An error type's onset needs the whole log, which --since and
+	// --delta leave out
+	if tracksEdits(baseDir) && sinceTime.IsZero() && !primeDelta {
+		if err := recordEdits(baseDir, now); err != nil {
+			diag.Debugf("recording edits: %v", err)
+		}
+		if edits, err := readEdits(baseDir); err == nil {
+			summary.RecentEdits = correlateEdits(entries, edits, now, 3)
+		}
+	}
+	summary.Regressions = resolutions.regressions(entries)
+	if len(summary.Regressions) > 3 {
+		summary.Regressions = summary.Regressions[:3]
+	}
+	for i := range summary.Regressions {
+		summary.Regressions[i].Pattern = redactor.String(summary.Regressions[i].Pattern)
+	}
+	summary.PastFixes = resolutions.fixes.recurring(entries)
+	if len(summary.PastFixes) > 3 {
+		summary.PastFixes = summary.PastFixes[:3]
+	}
+	for i := range summary.PastFixes {
+		summary.PastFixes[i].Pattern = redactor.String(summary.PastFixes[i].Pattern)
+		summary.PastFixes[i].Note = redactor.String(summary.PastFixes[i].Note)
+	}
+	summary.SnoozeExpired = snoozes.expired(entries)
+	if len(summary.SnoozeExpired) > 3 {
+		summary.SnoozeExpired = summary.SnoozeExpired[:3]
+	}
+	for i := range summary.SnoozeExpired {
+		summary.SnoozeExpired[i].Pattern = redactor.String(summary.SnoozeExpired[i].Pattern)
+	}
+	stormThreshold, stormWindow := stormSettingsFor(baseDir)
+	summary.Storms = detectStorms(set.recent, stormThreshold, stormWindow)
+	if len(summary.Storms) > maxStorms {
+		summary.Storms = summary.Storms[:maxStorms]
+	}
+	for i := range summary.Storms {
+		summary.Storms[i].Pattern = redactor.String(summary.Storms[i].Pattern)
+	}
+	// Sessions without an error need the log around it, which --delta
+	// leaves out
+	if !primeDelta {
+		summary.Flaky = primeFlaky(set.presence, entries, primeEnv != "")
+		for i := range summary.Flaky {
+			summary.Flaky[i].Pattern = redactor.String(summary.Flaky[i].Pattern)
+		}
+	}
 	summary.ActionableTip = generateTip(summary)
 
 	return summary, nil
 }
 
+// primeFlaky returns up to 3 likely-flaky errors. Sessions span every
+// environment, so with --env only errors that also occurred in it are kept.
+func primeFlaky(p *presence, entries []ErrorEntry, byEnv bool) []FlakyError {
+	if p == nil {
+		return nil
+	}
+	flaky := p.flaky(len(p.buckets))
+	if byEnv {
+		inEnv := make(map[string]bool)
+		for _, g := range groupErrors(entries) {
+			inEnv[g.Fingerprint] = true
+		}
+		var kept []FlakyError
+		for _, f := range flaky {
+			if inEnv[f.Fingerprint] {
+				kept = append(kept, f)
+			}
+		}
+		flaky = kept
+	}
+	if len(flaky) > 3 {
+		flaky = flaky[:3]
+	}
+	return flaky
+}
+
 // topN returns top N error types sorted by count
 func topN(counts map[string]int, n int) []ErrorTypeCount {
 	var result []ErrorTypeCount
@@ -191,11 +415,28 @@ func generateTip(summary PrimeSummary) string {
 		return ""
 	}
 
+	// A fix that didn't hold comes before everything else
+	if len(summary.Regressions) > 0 {
+		r := summary.Regressions[0]
+		return fmt.Sprintf("Start with the regression: %s was resolved %s and is back", r.ErrorType, formatTimestamp(r.ResolvedAt))
+	}
+	// A storm is hundreds of entries with one cause
+	if len(summary.Storms) > 0 {
+		s := summary.Storms[0]
+		return fmt.Sprintf("Treat the %s storm (%dx) as one problem: find why it repeats, not each occurrence", s.ErrorType, s.Count)
+	}
+
 	topType := summary.TopErrorTypes[0]
 	topSource := summary.TopSources[0]
 	percentage := (topType.Count * 100) / summary.TotalErrors
 
-	return fmt.Sprintf("Focus on %s in %s - %d%% of errors", topType.ErrorType, topSource.Source, percentage)
+	tip := fmt.Sprintf("Focus on %s in %s - %d%% of errors", topType.ErrorType, topSource.Source, percentage)
+	for _, f := range summary.Flaky {
+		if f.ErrorType == topType.ErrorType {
+			return tip + ", though it comes and goes and may be flaky"
+		}
+	}
+	return tip
 }
 
 // formatPrimeSummaryJSON returns JSON formatted output
@@ -214,8 +455,19 @@ func formatPrimeSummaryHuman(summary PrimeSummary) string {
 		return sb.String()
 	}
 
+	if summary.TotalErrors == 0 && summary.Delta {
+		sb.WriteString(fmt.Sprintf("agentlog: No new errors%s%s\n", primeEnvSuffix(summary), primeDeltaSuffix(summary)))
+		writeSlowLine(&sb, summary.SlowOperations)
+		return sb.String()
+	}
+	if summary.TotalErrors == 0 && summary.Environment != "" {
+		sb.WriteString(fmt.Sprintf("agentlog: No errors logged in %s\n", summary.Environment))
+		writeSlowLine(&sb, summary.SlowOperations)
+		return sb.String()
+	}
 	if summary.TotalErrors == 0 {
 		sb.WriteString("agentlog: No errors logged\n")
+		writeSlowLine(&sb, summary.SlowOperations)
 		return sb.String()
 	}
 
@@ -224,18 +476,33 @@ func formatPrimeSummaryHuman(summary PrimeSummary) string {
 	if summary.TotalErrors == 1 {
 		errWord = "error"
 	}
+	if summary.Delta {
+		errWord = "new " + errWord
+	}
 	sb.WriteString(fmt.Sprintf("agentlog: %d %s", summary.TotalErrors, errWord))
-	if summary.LastHourErrors > 0 {
+	if summary.Environment != "" {
+		sb.WriteString(fmt.Sprintf(" in %s", summary.Environment))
+	}
+	sb.WriteString(primeDeltaSuffix(summary))
+	if summary.LastHourErrors > 0 && !summary.Delta {
 		sb.WriteString(fmt.Sprintf(" (%d in last hour)", summary.LastHourErrors))
 	}
 	sb.WriteString("\n")
+	writeStormLines(&sb, summary.Storms)
+	writeRegressionLines(&sb, summary.Regressions)
+	writePastFixLines(&sb, summary.PastFixes)
+	writeSnoozeExpiredLines(&sb, summary.SnoozeExpired)
 
 	// Top error types
 	if len(summary.TopErrorTypes) > 0 {
 		sb.WriteString("  Top types: ")
 		var types []string
 		for _, t := range summary.TopErrorTypes {
-			types = append(types, fmt.Sprintf("%s (%d)", t.ErrorType, t.Count))
+			s := fmt.Sprintf("%s (%d)", t.ErrorType, t.Count)
+			if t.Trend != nil {
+				s += " " + sparkline(t.Trend)
+			}
+			types = append(types, s)
 		}
 		sb.WriteString(strings.Join(types, ", "))
 		sb.WriteString("\n")
@@ -252,6 +519,29 @@ func formatPrimeSummaryHuman(summary PrimeSummary) string {
 		sb.WriteString("\n")
 	}
 
+	// Recurring errors (only worth listing once something repeats)
+	if len(summary.TopGroups) > 0 && summary.TopGroups[0].Count > 1 {
+		sb.WriteString("  Recurring: ")
+		var groups []string
+		for _, g := range summary.TopGroups {
+			if g.Count < 2 {
+				break
+			}
+			groups = append(groups, fmt.Sprintf("%q (%d)", truncate(g.Pattern, 80), g.Count))
+		}
+		sb.WriteString(strings.Join(groups, ", "))
+		sb.WriteString("\n")
+	}
+
+	// Top offending files and endpoints
+	writeLocationLine(&sb, "Files", summary.TopFiles)
+	writeLocationLine(&sb, "Endpoints", summary.TopEndpoints)
+	writeCorrelationLines(&sb, summary.Correlations)
+	writeEditLines(&sb, summary.RecentEdits)
+	writeFlakyLines(&sb, summary.Flaky)
+	writeSlowLine(&sb, summary.SlowOperations)
+	writeSuggestionLines(&sb, summary.Suggestions)
+
 	// Actionable tip
 	if summary.ActionableTip != "" {
 		sb.WriteString("  Tip: ")
@@ -261,3 +551,102 @@ func formatPrimeSummaryHuman(summary PrimeSummary) string {
 
 	return sb.String()
 }
+
+// slowest returns the n perf entries with the longest durations
+func slowest(entries []ErrorEntry, n int) []ErrorEntry {
+	sorted := append([]ErrorEntry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].DurationMs > sorted[j].DurationMs })
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// writeSlowLine writes a one-line list of the slowest operations, named by
+// endpoint when they have one
+func writeSlowLine(sb *strings.Builder, slow []ErrorEntry) {
+	if len(slow) == 0 {
+		return
+	}
+	var parts []string
+	for _, e := range slow {
+		name := e.Endpoint
+		if name == "" {
+			name = truncate(singleLine(e.Message), 60)
+		}
+		parts = append(parts, fmt.Sprintf("%s %s (%s)", name, formatDuration(e.DurationMs), e.ErrorType))
+	}
+	sb.WriteString(fmt.Sprintf("  Slow: %s\n", strings.Join(parts, ", ")))
+}
+
+// writeCorrelationLines writes one line per cross-source correlation
+func writeCorrelationLines(sb *strings.Builder, correlations []Correlation) {
+	for _, c := range correlations {
+		sb.WriteString(fmt.Sprintf("  Correlated: %s\n", c))
+	}
+}
+
+// writeEditLines writes one line per error type that started right after
+// files were edited
+func writeEditLines(sb *strings.Builder, edits []EditCorrelation) {
+	for _, c := range edits {
+		sb.WriteString(fmt.Sprintf("  After edits: %s\n", c))
+	}
+}
+
+// writeRegressionLines writes one line per resolved error that came back
+func writeRegressionLines(sb *strings.Builder, regressions []RegressedError) {
+	for _, r := range regressions {
+		sb.WriteString(fmt.Sprintf("  REGRESSION: %s\n", r))
+	}
+}
+
+// writePastFixLines writes one line per fixed error that came back, with
+// how it was fixed
+func writePastFixLines(sb *strings.Builder, fixes []RecurringFix) {
+	for _, f := range fixes {
+		sb.WriteString(fmt.Sprintf("  Fixed before: %s\n", f))
+	}
+}
+
+// writeStormLines writes one line per error storm
+func writeStormLines(sb *strings.Builder, storms []ErrorStorm) {
+	for _, s := range storms {
+		sb.WriteString(fmt.Sprintf("  ERROR STORM: %s\n", s))
+	}
+}
+
+// writeSnoozeExpiredLines writes one line per snoozed error still occurring
+// after its snooze
+func writeSnoozeExpiredLines(sb *strings.Builder, expired []ExpiredSnooze) {
+	for _, x := range expired {
+		sb.WriteString(fmt.Sprintf("  Snooze expired: %s\n", x))
+	}
+}
+
+// writeFlakyLines writes one line per error that keeps going away and
+// coming back
+func writeFlakyLines(sb *strings.Builder, flaky []FlakyError) {
+	for _, f := range flaky {
+		sb.WriteString(fmt.Sprintf("  Likely flaky: %s\n", f))
+	}
+}
+
+// writeSuggestionLines writes one line per suggested next step
+func writeSuggestionLines(sb *strings.Builder, suggestions []GroupSuggestion) {
+	for _, s := range suggestions {
+		sb.WriteString(fmt.Sprintf("  Suggested: %s\n", s))
+	}
+}
+
+// writeLocationLine writes a one-line ranking of files or endpoints
+func writeLocationLine(sb *strings.Builder, label string, locations []LocationCount) {
+	if len(locations) == 0 {
+		return
+	}
+	var parts []string
+	for _, l := range locations {
+		parts = append(parts, fmt.Sprintf("%s (%d)", l.Name, l.Count))
+	}
+	sb.WriteString(fmt.Sprintf("  %s: %s\n", label, strings.Join(parts, ", ")))
+}
@@ -0,0 +1,350 @@
+package cmd
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/diag"
+	"github.com/agentlog/agentlog/internal/logfile"
+)
+
+// primeCacheVersion changes whenever the cached aggregates change meaning,
+// so older caches are rebuilt rather than misread
+const primeCacheVersion = 3
+
+// primeCacheCheckLen is how much of errors.jsonl is hashed at its start
+// and just before Offset to notice when it was rewritten (by dedupe, or
+// truncated and refilled)
+const primeCacheCheckLen = 4096
+
+// primeRecentWindow is how long raw entries are kept in the cache, for the
+// last-hour and last-24h counts and cross-source correlations
+const primeRecentWindow = 24 * time.Hour
+
+// primeCache holds prime's aggregates of errors.jsonl up to Offset, so
+// later runs only parse what was appended since. Error entries are kept
+// collapsed, one per bucket of entries that every prime aggregate treats
+// alike; the last 24 hours of entries are also kept as they are. Buckets
+// don't mix occurrences from before and after their error was resolved,
+// so the cache is rebuilt when resolved.jsonl changes.
+type primeCache struct {
+	Version    int                     `json:"version"`
+	Offset     int64                   `json:"offset"`
+	ModTime    time.Time               `json:"mod_time"`
+	Check      string                  `json:"check"`
+	Lines      int                     `json:"lines"`
+	Buckets    []ErrorEntry            `json:"buckets"`
+	Recent     []ErrorEntry            `json:"recent"`
+	Slow       map[string][]ErrorEntry `json:"slow"` // slowest perf entries per environment
+	Presence   *presence               `json:"presence"`
+	Resolved   time.Time               `json:"resolved"` // resolved.jsonl's mtime the buckets were split by
+	bucketByID map[string]int
+	resolved   *resolutionSet
+}
+
+// primeEntries are the entries a prime summary is computed from: errors
+// (possibly collapsed, see primeCache), the errors of the last 24 hours as
+// written, and perf entries, with the sessions the errors occurred in
+type primeEntries struct {
+	errors   []ErrorEntry
+	recent   []ErrorEntry
+	perf     []ErrorEntry
+	presence *presence
+}
+
+// primeEntriesFrom splits entries read from the log
+func primeEntriesFrom(entries []ErrorEntry, now time.Time) primeEntries {
+	set := primeEntries{errors: filterKind(entries, kindError), perf: filterKind(entries, kindPerf), presence: newPresence()}
+	for _, e := range entries {
+		set.presence.add(e)
+	}
+	for _, e := range set.errors {
+		if isRecent(e, now) {
+			set.recent = append(set.recent, e)
+		}
+	}
+	return set
+}
+
+// environment keeps only entries from env (all when env is empty). The
+// sessions aren't split by environment; see primeFlaky.
+func (p primeEntries) environment(env string) primeEntries {
+	return primeEntries{
+		errors:   filterEnvironment(p.errors, env),
+		recent:   filterEnvironment(p.recent, env),
+		perf:     filterEnvironment(p.perf, env),
+		presence: p.presence,
+	}
+}
+
+func isRecent(e ErrorEntry, now time.Time) bool {
+	ts, err := parseEntryTime(e.Timestamp)
+	return err == nil && ts.After(now.Add(-primeRecentWindow))
+}
+
+func primeCachePath(baseDir string) string {
+	return filepath.Join(baseDir, ".agentlog", "cache.json")
+}
+
+// loadPrimeEntries returns the log's entries for prime, updating
+// .agentlog/cache.json with whatever was appended since it was written.
+// When the log hasn't changed (same size and mtime) it isn't read at all.
+func loadPrimeEntries(baseDir string, now time.Time) (primeEntries, error) {
+	path := GetErrorsPath(baseDir)
+	info, err := os.Stat(path)
+	if err != nil {
+		return primeEntries{}, err
+	}
+
+	cache := readPrimeCache(baseDir)
+	unchanged := cache.Offset == info.Size() && cache.ModTime.Equal(info.ModTime())
+	if !unchanged {
+		f, err := os.Open(path)
+		if err != nil {
+			return primeEntries{}, err
+		}
+		defer f.Close()
+
+		if !cache.validFor(f, info.Size()) {
+			diag.Debugf("%s doesn't match the log; rebuilding it", primeCachePath(baseDir))
+			cache = newPrimeCache(cache.resolved)
+		}
+		if err := cache.update(f, maxLineBytes(baseDir), now); err != nil {
+			return primeEntries{}, err
+		}
+		cache.ModTime = info.ModTime()
+		// A cache that can't be saved only costs the next run a full read
+		_ = writePrimeCache(baseDir, cache)
+	} else {
+		diag.Debugf("%s is current (offset %d); not reading the log", primeCachePath(baseDir), cache.Offset)
+	}
+
+	var recent []ErrorEntry
+	for _, e := range cache.Recent {
+		if isRecent(e, now) {
+			recent = append(recent, e)
+		}
+	}
+	set := primeEntries{errors: cache.Buckets, recent: recent, presence: cache.Presence}
+	for _, slow := range cache.Slow {
+		set.perf = append(set.perf, slow...)
+	}
+	return set, nil
+}
+
+// newPrimeCache returns an empty cache, splitting buckets by resolved
+func newPrimeCache(resolved *resolutionSet) *primeCache {
+	return &primeCache{
+		Version: primeCacheVersion, Slow: make(map[string][]ErrorEntry), Presence: newPresence(),
+		Resolved: resolved.modTime, bucketByID: make(map[string]int), resolved: resolved,
+	}
+}
+
+// readPrimeCache loads the cache, or returns an empty one if it is missing,
+// unreadable, from another version, or split by other resolutions
+func readPrimeCache(baseDir string) *primeCache {
+	resolved := loadResolutions(baseDir)
+	data, err := os.ReadFile(primeCachePath(baseDir))
+	if err != nil {
+		return newPrimeCache(resolved)
+	}
+	cache := newPrimeCache(resolved)
+	if err := json.Unmarshal(data, cache); err != nil || cache.Version != primeCacheVersion || !cache.Resolved.Equal(resolved.modTime) {
+		return newPrimeCache(resolved)
+	}
+	if cache.Slow == nil {
+		cache.Slow = make(map[string][]ErrorEntry)
+	}
+	if cache.Presence == nil {
+		cache.Presence = newPresence()
+	}
+	cache.bucketByID = make(map[string]int, len(cache.Buckets))
+	for i, b := range cache.Buckets {
+		cache.bucketByID[cache.bucketKey(b)] = i
+	}
+	return cache
+}
+
+// writePrimeCache saves the cache atomically, so a concurrent prime never
+// reads half of it
+func writePrimeCache(baseDir string, cache *primeCache) error {
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	return logfile.WriteFile(primeCachePath(baseDir), data, 0644)
+}
+
+// validFor reports whether the cache describes the start of f: the file is
+// at least as long as what was summarized, with the same bytes at its start
+// and just before Offset
+func (c *primeCache) validFor(f *os.File, size int64) bool {
+	if c.Offset == 0 {
+		return true
+	}
+	if size < c.Offset {
+		return false
+	}
+	check, err := checkRegions(f, c.Offset)
+	return err == nil && check == c.Check
+}
+
+// checkRegions hashes the first and last primeCacheCheckLen bytes before
+// offset
+func checkRegions(f *os.File, offset int64) (string, error) {
+	n := offset
+	if n > primeCacheCheckLen {
+		n = primeCacheCheckLen
+	}
+	head := make([]byte, n)
+	tail := make([]byte, n)
+	if _, err := f.ReadAt(head, 0); err != nil && err != io.EOF {
+		return "", err
+	}
+	if _, err := f.ReadAt(tail, offset-n); err != nil && err != io.EOF {
+		return "", err
+	}
+	sum := sha1.Sum(append(head, tail...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// update folds the lines appended to f since Offset into the cache
+func (c *primeCache) update(f *os.File, maxLine int, now time.Time) error {
+	var err error
+	c.Offset, c.Lines, err = readAppended(f, c.Offset, c.Lines, maxLine, func(e ErrorEntry) { c.add(e, now) })
+	if err != nil {
+		return err
+	}
+	if c.Check, err = checkRegions(f, c.Offset); err != nil {
+		return err
+	}
+
+	var recent []ErrorEntry
+	for _, e := range c.Recent {
+		if isRecent(e, now) {
+			recent = append(recent, e)
+		}
+	}
+	c.Recent = recent
+	return nil
+}
+
+// readAppended parses the lines of f after offset, passing each entry to
+// add, and returns the offset and line count it stopped at. lines is the
+// number of lines before offset, for warnings about malformed ones. A
+// final line without a newline is taken if it parses; otherwise a writer
+// is midway through it and it waits for the next run.
+func readAppended(f *os.File, offset int64, lines, maxLine int, add func(ErrorEntry)) (int64, int, error) {
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return offset, lines, err
+	}
+	diag.Debugf("reading %s from offset %d (after line %d)", f.Name(), offset, lines)
+
+	// Lines are read one at a time, so only the longest is held however
+	// much was appended
+	var consumed int64
+	partial := false // the last line read has no newline yet
+	scanner := logfile.NewLines(f, maxLine)
+	for scanner.Scan() {
+		partial = !scanner.Complete()
+		if text := strings.TrimSpace(scanner.Text()); text != "" {
+			var entry ErrorEntry
+			if err := json.Unmarshal([]byte(text), &entry); err != nil {
+				if partial {
+					break
+				}
+				diag.Warnf("skipping malformed line %d: %v", lines+scanner.Line(), err)
+			} else if entry.Kind != kindHealthcheck {
+				add(entry)
+			}
+		}
+		consumed = scanner.Offset()
+	}
+	if err := scanner.Err(); err != nil {
+		return offset, lines, fmt.Errorf("error reading file: %w", err)
+	}
+	if !partial {
+		consumed = scanner.Offset() // through any lines over the limit at the end
+	}
+	for i := range scanner.Skipped {
+		scanner.Skipped[i].Line += lines
+	}
+	warnSkippedLines(scanner.Skipped, maxLine)
+
+	read := scanner.Line()
+	if partial {
+		read--
+	}
+	return offset + consumed, lines + read, nil
+}
+
+// add folds one entry into the cache
+func (c *primeCache) add(e ErrorEntry, now time.Time) {
+	c.Presence.add(e)
+	if e.kind() == kindPerf {
+		perf := e
+		perf.Context, perf.Tags = nil, nil
+		c.Slow[e.Environment] = slowest(append(c.Slow[e.Environment], perf), 3)
+		return
+	}
+	if e.kind() != kindError {
+		return
+	}
+
+	if isRecent(e, now) {
+		recent := e
+		recent.Tags = nil
+		recent.Context = correlationContext(e.Context)
+		c.Recent = append(c.Recent, recent)
+	}
+
+	bucket := e
+	bucket.Context, bucket.Tags = nil, nil
+	key := c.bucketKey(bucket)
+	if i, ok := c.bucketByID[key]; ok {
+		c.Buckets[i] = mergeRepeat(c.Buckets[i], bucket)
+		return
+	}
+	c.bucketByID[key] = len(c.Buckets)
+	c.Buckets = append(c.Buckets, bucket)
+}
+
+// bucketKey is primeBucketKey, apart for occurrences of resolved errors
+// from before they were resolved
+func (c *primeCache) bucketKey(e ErrorEntry) string {
+	key := primeBucketKey(e)
+	if c.resolved != nil && c.resolved.resolved(e) {
+		key += "\x00resolved"
+	}
+	return key
+}
+
+// primeBucketKey groups entries that prime counts, groups, filters, and
+// ranks the same way
+func primeBucketKey(e ErrorEntry) string {
+	return strings.Join([]string{
+		e.ErrorType, normalizeMessage(e.Message), e.Source, e.Environment,
+		entryFile(e), entryEndpoint(e),
+	}, "\x00")
+}
+
+// correlationContext keeps the context values correlate looks at
+func correlationContext(ctx map[string]interface{}) map[string]interface{} {
+	kept := make(map[string]interface{})
+	for _, key := range append([]string{"url"}, correlationKeys...) {
+		if v, ok := ctx[key]; ok {
+			kept[key] = v
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return kept
+}
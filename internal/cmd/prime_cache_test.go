@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// primeCacheFixture writes lines to a temp project's errors.jsonl and
+// returns the project dir
+func primeCacheFixture(t *testing.T, lines ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, ".agentlog"), 0755)
+	os.WriteFile(GetErrorsPath(dir), []byte(strings.Join(lines, "")), 0644)
+	return dir
+}
+
+func primeCacheLine(ts time.Time, source, errorType, message string, extra string) string {
+	return fmt.Sprintf(`{"timestamp":%q,"source":%q,"error_type":%q,"message":%q%s}`+"\n",
+		ts.Format(time.RFC3339Nano), source, errorType, message, extra)
+}
+
+func appendLines(t *testing.T, dir string, lines ...string) {
+	t.Helper()
+	f, err := os.OpenFile(GetErrorsPath(dir), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.WriteString(strings.Join(lines, ""))
+}
+
+func readCacheFile(t *testing.T, dir string) *primeCache {
+	t.Helper()
+	data, err := os.ReadFile(primeCachePath(dir))
+	if err != nil {
+		t.Fatalf("cache not written: %v", err)
+	}
+	var c primeCache
+	if err := json.Unmarshal(data, &c); err != nil {
+		t.Fatalf("invalid cache: %v", err)
+	}
+	return &c
+}
+
+// primeInDir runs generatePrimeSummary in dir with the cache on or off
+func primeInDir(t *testing.T, dir string, noCache bool) PrimeSummary {
+	t.Helper()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	primeNoCache = noCache
+	defer func() { primeNoCache = false }()
+
+	summary, err := generatePrimeSummary()
+	if err != nil {
+		t.Fatalf("generatePrimeSummary: %v", err)
+	}
+	summary.GeneratedAt = ""
+	return summary
+}
+
+func TestPrimeCache_MatchesFullRead(t *testing.T) {
+	now := time.Now().UTC()
+	dir := primeCacheFixture(t,
+		primeCacheLine(now.Add(-72*time.Hour), "backend", "DATABASE_ERROR", "connection refused on port 5432", `,"file":"db.go"`),
+		primeCacheLine(now.Add(-48*time.Hour), "backend", "DATABASE_ERROR", "connection refused on port 5433", `,"file":"db.go"`),
+		primeCacheLine(now.Add(-2*time.Hour), "frontend", "NETWORK_ERROR", "fetch failed", `,"context":{"url":"http://localhost/api/users","request_id":"r1"}`),
+		primeCacheLine(now.Add(-2*time.Hour), "backend", "DATABASE_ERROR", "timeout", `,"endpoint":"GET /api/users","context":{"request_id":"r1"}`),
+		primeCacheLine(now.Add(-10*time.Minute), "frontend", "UNCAUGHT_ERROR", "x is undefined", `,"file":"app.tsx","count":4`),
+		primeCacheLine(now.Add(-5*time.Minute), "backend", "SLOW_QUERY", "SELECT *", `,"kind":"perf","duration_ms":900`),
+	)
+
+	cached := primeInDir(t, dir, false)
+	full := primeInDir(t, dir, true)
+	if !reflect.DeepEqual(cached, full) {
+		t.Errorf("cached summary differs from full read:\ncached: %+v\nfull:   %+v", cached, full)
+	}
+	if cached.TotalErrors != 8 || cached.Last24hErrors != 6 || cached.LastHourErrors != 4 {
+		t.Errorf("unexpected counts: %+v", cached)
+	}
+	if len(cached.Correlations) != 1 || cached.Correlations[0].SharedKey != "request_id" {
+		t.Errorf("expected a request_id correlation, got %+v", cached.Correlations)
+	}
+	if len(cached.SlowOperations) != 1 {
+		t.Errorf("expected one slow operation, got %+v", cached.SlowOperations)
+	}
+
+	// A second run reads the cache rather than the log
+	again := primeInDir(t, dir, false)
+	if !reflect.DeepEqual(again, full) {
+		t.Errorf("summary from the cache differs from full read:\ncached: %+v\nfull:   %+v", again, full)
+	}
+}
+
+func TestPrimeCache_OnlyParsesAppendedLines(t *testing.T) {
+	now := time.Now().UTC()
+	dir := primeCacheFixture(t, primeCacheLine(now, "backend", "DATABASE_ERROR", "one", ""))
+	if s := primeInDir(t, dir, false); s.TotalErrors != 1 {
+		t.Fatalf("expected 1 error, got %d", s.TotalErrors)
+	}
+	first := readCacheFile(t, dir)
+
+	appendLines(t, dir, primeCacheLine(now, "frontend", "NETWORK_ERROR", "two", ""))
+	if s := primeInDir(t, dir, false); s.TotalErrors != 2 {
+		t.Fatalf("expected 2 errors after append, got %d", s.TotalErrors)
+	}
+	second := readCacheFile(t, dir)
+	info, _ := os.Stat(GetErrorsPath(dir))
+	if second.Offset != info.Size() || second.Offset <= first.Offset {
+		t.Errorf("expected offset to advance from %d to %d, got %d", first.Offset, info.Size(), second.Offset)
+	}
+	if second.Lines != 2 {
+		t.Errorf("expected 2 lines read, got %d", second.Lines)
+	}
+}
+
+func TestPrimeCache_UnchangedLogIsNotRead(t *testing.T) {
+	now := time.Now().UTC()
+	dir := primeCacheFixture(t, primeCacheLine(now, "backend", "DATABASE_ERROR", "one", ""))
+	primeInDir(t, dir, false)
+
+	// Doctor the cache: if the log were re-read, the count would be 1
+	c := readPrimeCache(dir)
+	c.Buckets[0].Count = 7
+	if err := writePrimeCache(dir, c); err != nil {
+		t.Fatal(err)
+	}
+	if s := primeInDir(t, dir, false); s.TotalErrors != 7 {
+		t.Errorf("expected the cached count 7, got %d", s.TotalErrors)
+	}
+}
+
+func TestPrimeCache_RebuildsWhenRewritten(t *testing.T) {
+	now := time.Now().UTC()
+	dir := primeCacheFixture(t,
+		primeCacheLine(now, "backend", "DATABASE_ERROR", "one", ""),
+		primeCacheLine(now, "backend", "DATABASE_ERROR", "two", ""),
+	)
+	primeInDir(t, dir, false)
+
+	// Truncated
+	os.WriteFile(GetErrorsPath(dir), []byte(primeCacheLine(now, "frontend", "NETWORK_ERROR", "x", "")), 0644)
+	if s := primeInDir(t, dir, false); s.TotalErrors != 1 || s.TopErrorTypes[0].ErrorType != "NETWORK_ERROR" {
+		t.Errorf("expected rebuild after truncation, got %+v", s)
+	}
+
+	// Rewritten to a longer file with different content
+	os.WriteFile(GetErrorsPath(dir), []byte(
+		primeCacheLine(now, "frontend", "UNCAUGHT_ERROR", "y", "")+
+			primeCacheLine(now, "frontend", "UNCAUGHT_ERROR", "z", "")), 0644)
+	s := primeInDir(t, dir, false)
+	if s.TotalErrors != 2 || len(s.TopErrorTypes) != 1 || s.TopErrorTypes[0].ErrorType != "UNCAUGHT_ERROR" {
+		t.Errorf("expected rebuild after rewrite, got %+v", s)
+	}
+}
+
+func TestPrimeCache_PartialTrailingLine(t *testing.T) {
+	now := time.Now().UTC()
+	line := primeCacheLine(now, "backend", "DATABASE_ERROR", "two", "")
+	dir := primeCacheFixture(t, primeCacheLine(now, "backend", "DATABASE_ERROR", "one", ""), line[:20])
+
+	if s := primeInDir(t, dir, false); s.TotalErrors != 1 {
+		t.Fatalf("expected the partial line to wait, got %d errors", s.TotalErrors)
+	}
+	appendLines(t, dir, line[20:])
+	if s := primeInDir(t, dir, false); s.TotalErrors != 2 {
+		t.Errorf("expected the completed line to count, got %d errors", s.TotalErrors)
+	}
+}
+
+func TestReadAppended(t *testing.T) {
+	now := time.Now().UTC()
+	first := primeCacheLine(now, "backend", "DATABASE_ERROR", "one", "")
+	taken := primeCacheLine(now, "backend", "DATABASE_ERROR", "three", "")
+	dir := primeCacheFixture(t,
+		first,
+		"{not json\n",
+		primeCacheLine(now, "backend", "HEALTHCHECK", "ok", `,"kind":"healthcheck"`),
+		primeCacheLine(now, "backend", "DATABASE_ERROR", strings.Repeat("x", 2048), ""),
+		primeCacheLine(now, "backend", "DATABASE_ERROR", "two", ""),
+		strings.TrimSuffix(taken, "\n"),
+	)
+	f, _ := os.Open(GetErrorsPath(dir))
+	defer f.Close()
+	info, _ := f.Stat()
+
+	// From after the first line, which is line 7 of the whole log
+	var got []string
+	offset, lines, err := readAppended(f, int64(len(first)), 6, 1024, func(e ErrorEntry) { got = append(got, e.Message) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "two,three" {
+		t.Errorf("entries = %v; want the malformed, healthcheck, and oversized lines skipped", got)
+	}
+	// A final line without a newline is taken, but isn't a whole line yet
+	if offset != info.Size() || lines != 10 {
+		t.Errorf("stopped at offset %d, line %d; want %d, 10", offset, lines, info.Size())
+	}
+
+	// One that doesn't parse waits for its writer
+	appendLines(t, dir, "\n", taken[:20])
+	offset, lines, _ = readAppended(f, offset, lines, 1024, func(ErrorEntry) {})
+	if offset != info.Size()+1 || lines != 11 {
+		t.Errorf("stopped at offset %d, line %d; want before the partial line", offset, lines)
+	}
+}
+
+func TestPrimeCache_EnvFilter(t *testing.T) {
+	now := time.Now().UTC()
+	dir := primeCacheFixture(t,
+		primeCacheLine(now, "backend", "DATABASE_ERROR", "one", `,"environment":"staging"`),
+		primeCacheLine(now, "backend", "DATABASE_ERROR", "one", `,"environment":"production"`),
+		primeCacheLine(now, "backend", "DATABASE_ERROR", "one", `,"environment":"production"`),
+	)
+	primeEnv = "production"
+	defer func() { primeEnv = "" }()
+
+	cached := primeInDir(t, dir, false)
+	full := primeInDir(t, dir, true)
+	if cached.TotalErrors != 2 || !reflect.DeepEqual(cached, full) {
+		t.Errorf("expected 2 production errors from both paths, got cached %+v, full %+v", cached, full)
+	}
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/diag"
+	"github.com/agentlog/agentlog/internal/logfile"
+)
+
+// primeDeltaState is where the last 'prime --delta' stopped reading
+// errors.jsonl, so the next one summarizes only what came after
+type primeDeltaState struct {
+	Offset int64     `json:"offset"`
+	Check  string    `json:"check"`
+	Lines  int       `json:"lines"`
+	RunAt  time.Time `json:"run_at"`
+}
+
+func primeDeltaPath(baseDir string) string {
+	return filepath.Join(baseDir, ".agentlog", "prime-delta.json")
+}
+
+// loadDeltaEntries returns the entries appended since the last --delta run
+// and when that run was (zero on the first). When nothing was appended the
+// log isn't parsed at all. A log that was rewritten or truncated since is
+// summarized from the start.
+func loadDeltaEntries(baseDir string, now time.Time) (primeEntries, time.Time, error) {
+	f, err := os.Open(GetErrorsPath(baseDir))
+	if err != nil {
+		return primeEntries{}, time.Time{}, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return primeEntries{}, time.Time{}, err
+	}
+
+	state := readDeltaState(baseDir)
+	lastRun := state.RunAt
+	if state.Offset > info.Size() {
+		diag.Debugf("log is shorter than the last --delta position (%d bytes); starting over", state.Offset)
+		state = primeDeltaState{}
+	} else if state.Offset > 0 {
+		if check, err := checkRegions(f, state.Offset); err != nil || check != state.Check {
+			diag.Debugf("log was rewritten since the last --delta; starting over")
+			state = primeDeltaState{}
+		}
+	}
+
+	var entries []ErrorEntry
+	if state.Offset < info.Size() {
+		state.Offset, state.Lines, err = readAppended(f, state.Offset, state.Lines, maxLineBytes(baseDir), func(e ErrorEntry) { entries = append(entries, e) })
+		if err != nil {
+			return primeEntries{}, lastRun, err
+		}
+		if state.Check, err = checkRegions(f, state.Offset); err != nil {
+			return primeEntries{}, lastRun, err
+		}
+	}
+	state.RunAt = now
+	// A position that can't be saved only makes the next run repeat this one
+	_ = writeDeltaState(baseDir, state)
+
+	return primeEntriesFrom(entries, now), lastRun, nil
+}
+
+func readDeltaState(baseDir string) primeDeltaState {
+	var state primeDeltaState
+	data, err := os.ReadFile(primeDeltaPath(baseDir))
+	if err != nil || json.Unmarshal(data, &state) != nil {
+		return primeDeltaState{}
+	}
+	return state
+}
+
+func writeDeltaState(baseDir string, state primeDeltaState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return logfile.WriteFile(primeDeltaPath(baseDir), data, 0644)
+}
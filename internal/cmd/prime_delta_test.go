@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// primeDeltaInDir runs generatePrimeSummary with --delta in dir
+func primeDeltaInDir(t *testing.T, dir string) PrimeSummary {
+	t.Helper()
+	primeDelta = true
+	defer func() { primeDelta = false }()
+	return primeInDir(t, dir, false)
+}
+
+func TestPrimeDelta_OnlyNewEntries(t *testing.T) {
+	now := time.Now().UTC()
+	dir := primeCacheFixture(t,
+		primeCacheLine(now, "backend", "DATABASE_ERROR", "one", ""),
+		primeCacheLine(now, "backend", "DATABASE_ERROR", "two", ""),
+	)
+
+	first := primeDeltaInDir(t, dir)
+	if !first.Delta || first.TotalErrors != 2 || first.LastRun != "" {
+		t.Errorf("expected the first run to cover the whole log, got %+v", first)
+	}
+
+	second := primeDeltaInDir(t, dir)
+	if second.TotalErrors != 0 || second.LastRun == "" {
+		t.Errorf("expected nothing new since the first run, got %+v", second)
+	}
+	if out := formatPrimeSummaryHuman(second); !strings.HasPrefix(out, "agentlog: No new errors since last check") {
+		t.Errorf("unexpected output: %q", out)
+	}
+
+	appendLines(t, dir, primeCacheLine(now, "frontend", "NETWORK_ERROR", "three", ""))
+	third := primeDeltaInDir(t, dir)
+	if third.TotalErrors != 1 || third.TopErrorTypes[0].ErrorType != "NETWORK_ERROR" {
+		t.Errorf("expected only the appended error, got %+v", third)
+	}
+	if out := formatPrimeSummaryHuman(third); !strings.HasPrefix(out, "agentlog: 1 new error since last check") {
+		t.Errorf("unexpected output: %q", out)
+	}
+
+	// Plain prime still covers everything
+	if full := primeInDir(t, dir, false); full.TotalErrors != 3 || full.Delta {
+		t.Errorf("expected prime without --delta to cover the whole log, got %+v", full)
+	}
+}
+
+func TestPrimeDelta_NothingNewSkipsParsing(t *testing.T) {
+	now := time.Now().UTC()
+	dir := primeCacheFixture(t, primeCacheLine(now, "backend", "DATABASE_ERROR", "one", ""))
+	primeDeltaInDir(t, dir)
+
+	before := readDeltaState(dir)
+	if s := primeDeltaInDir(t, dir); s.TotalErrors != 0 {
+		t.Fatalf("expected no new errors, got %d", s.TotalErrors)
+	}
+	after := readDeltaState(dir)
+	if after.Offset != before.Offset || !after.RunAt.After(before.RunAt) {
+		t.Errorf("expected the same offset and a later run time, got %+v then %+v", before, after)
+	}
+}
+
+func TestPrimeDelta_RewrittenLogStartsOver(t *testing.T) {
+	now := time.Now().UTC()
+	dir := primeCacheFixture(t,
+		primeCacheLine(now, "backend", "DATABASE_ERROR", "one", ""),
+		primeCacheLine(now, "backend", "DATABASE_ERROR", "two", ""),
+	)
+	primeDeltaInDir(t, dir)
+
+	os.WriteFile(GetErrorsPath(dir), []byte(primeCacheLine(now, "frontend", "NETWORK_ERROR", "x", "")), 0644)
+	if s := primeDeltaInDir(t, dir); s.TotalErrors != 1 {
+		t.Errorf("expected the truncated log to be read from the start, got %+v", s)
+	}
+}
+
+func TestPrimeDelta_RejectsSince(t *testing.T) {
+	dir := primeCacheFixture(t)
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	primeDelta, primeSince = true, "1h"
+	defer func() { primeDelta, primeSince = false, "" }()
+
+	if _, err := generatePrimeSummary(); err == nil {
+		t.Error("expected --delta with --since to fail")
+	}
+}
+
+func TestPrimePresets_Delta(t *testing.T) {
+	s := PrimeSummary{Delta: true, LastRun: time.Now().UTC().Format(time.RFC3339)}
+	if out := formatPrimeClaude(s); !strings.Contains(out, "No new errors since last check") {
+		t.Errorf("unexpected claude output: %q", out)
+	}
+	if out := formatPrimeCursor(s); out != "**agentlog:** no new errors\n" {
+		t.Errorf("unexpected cursor output: %q", out)
+	}
+	if out := formatPrimeGenericJSON(s); !strings.Contains(out, `"delta": true`) {
+		t.Errorf("unexpected generic-json output: %q", out)
+	}
+}
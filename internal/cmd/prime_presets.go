@@ -0,0 +1,355 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// primePreset shapes the prime summary for one consumer, so hook authors
+// don't have to rewrite it per tool
+type primePreset struct {
+	Name   string
+	Format func(PrimeSummary) string
+}
+
+var primePresets = []primePreset{
+	{Name: "claude", Format: formatPrimeClaude},
+	{Name: "cursor", Format: formatPrimeCursor},
+	{Name: "generic-json", Format: formatPrimeGenericJSON},
+}
+
+// findPrimePreset looks up a preset by name
+func findPrimePreset(name string) (primePreset, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, p := range primePresets {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return primePreset{}, invalidInput("unknown --agent preset '%s' (available: %s)", name, strings.Join(primePresetNames(), ", "))
+}
+
+// primePresetNames lists the preset names, sorted
+func primePresetNames() []string {
+	var names []string
+	for _, p := range primePresets {
+		names = append(names, p.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatPrimeClaude wraps the summary and an instruction in tags, which
+// Claude treats as distinct context blocks
+func formatPrimeClaude(s PrimeSummary) string {
+	var sb strings.Builder
+	sb.WriteString("<agentlog_errors>\n")
+	switch {
+	case s.NoLogFile:
+		sb.WriteString("No error log found (.agentlog/errors.jsonl). Error capture is not set up; run 'agentlog init' if the user wants it.\n")
+		sb.WriteString("</agentlog_errors>\n")
+		return sb.String()
+	case s.TotalErrors == 0 && s.Delta:
+		sb.WriteString("No new errors" + primeEnvSuffix(s) + primeDeltaSuffix(s) + ".\n")
+		writeSlowLine(&sb, s.SlowOperations)
+		sb.WriteString("</agentlog_errors>\n")
+		return unindent(sb.String())
+	case s.TotalErrors == 0:
+		sb.WriteString("No errors logged" + primeEnvSuffix(s) + ".\n")
+		writeSlowLine(&sb, s.SlowOperations)
+		sb.WriteString("</agentlog_errors>\n")
+		return unindent(sb.String())
+	}
+
+	if s.Delta {
+		sb.WriteString(fmt.Sprintf("%d new %s logged%s%s.\n", s.TotalErrors, errorsWord(s.TotalErrors), primeEnvSuffix(s), primeDeltaSuffix(s)))
+	} else {
+		sb.WriteString(fmt.Sprintf("%d %s logged%s", s.TotalErrors, errorsWord(s.TotalErrors), primeEnvSuffix(s)))
+		sb.WriteString(fmt.Sprintf(" (%d in the last hour, %d in the last 24h).\n", s.LastHourErrors, s.Last24hErrors))
+	}
+	writeStormLines(&sb, s.Storms)
+	writeRegressionLines(&sb, s.Regressions)
+	writePastFixLines(&sb, s.PastFixes)
+	writeSnoozeExpiredLines(&sb, s.SnoozeExpired)
+	if len(s.TopErrorTypes) > 0 {
+		var types []string
+		for _, t := range s.TopErrorTypes {
+			types = append(types, fmt.Sprintf("%s (%d)", t.ErrorType, t.Count))
+		}
+		sb.WriteString("Most common types: " + strings.Join(types, ", ") + "\n")
+	}
+	if len(s.TopSources) > 0 {
+		var sources []string
+		for _, src := range s.TopSources {
+			sources = append(sources, fmt.Sprintf("%s (%d)", src.Source, src.Count))
+		}
+		sb.WriteString("Sources: " + strings.Join(sources, ", ") + "\n")
+	}
+	for _, g := range s.TopGroups {
+		if g.Count < 2 {
+			break
+		}
+		sb.WriteString(fmt.Sprintf("Recurring %dx: %q (fingerprint %s)\n", g.Count, truncate(g.Pattern, 120), g.Fingerprint))
+	}
+	writeLocationLine(&sb, "Files", s.TopFiles)
+	writeLocationLine(&sb, "Endpoints", s.TopEndpoints)
+	writeCorrelationLines(&sb, s.Correlations)
+	writeEditLines(&sb, s.RecentEdits)
+	writeFlakyLines(&sb, s.Flaky)
+	writeSlowLine(&sb, s.SlowOperations)
+	writeSuggestionLines(&sb, s.Suggestions)
+	sb.WriteString("</agentlog_errors>\n")
+
+	sb.WriteString("<agentlog_instructions>\n")
+	if len(s.Regressions) > 0 {
+		sb.WriteString(fmt.Sprintf("A REGRESSION is an error that was marked resolved and came back, so the earlier fix didn't hold; it outranks everything else here. Run 'agentlog show %s' to see how it's recurring.\n", s.Regressions[0].Fingerprint))
+	}
+	if len(s.PastFixes) > 0 {
+		sb.WriteString("An error \"fixed before\" was fixed once already, as noted; before trying something new, check whether that fix was reverted or only partly worked.\n")
+	}
+	if len(s.Storms) > 0 {
+		sb.WriteString("An ERROR STORM is one error repeating hundreds of times, usually a retry loop or a failing dependency; treat it as a single problem, and don't read its count as hundreds of bugs.\n")
+	}
+	if len(s.SnoozeExpired) > 0 {
+		sb.WriteString("A snoozed error was set aside for a while and outlasted it; mention it to the user rather than fixing it unasked, since someone may already be on it.\n")
+	}
+	if s.ActionableTip != "" {
+		sb.WriteString(s.ActionableTip + ".\n")
+	}
+	if len(s.Correlations) > 0 {
+		sb.WriteString(fmt.Sprintf("The correlated errors likely share one cause; look at the %s %s first.\n", s.Correlations[0].WithSource, s.Correlations[0].WithType))
+	}
+	if len(s.RecentEdits) > 0 {
+		sb.WriteString(fmt.Sprintf("%s started right after edits; review the changes to %s first.\n", s.RecentEdits[0].ErrorType, s.RecentEdits[0].Files[0]))
+	}
+	if len(s.Flaky) > 0 {
+		sb.WriteString("The likely-flaky errors come and go across sessions, which points to an intermittent cause (network, timing, a race) rather than a regression; don't treat them as caused by recent changes, and deprioritize them unless the user asks.\n")
+	}
+	if len(s.Suggestions) > 0 {
+		sb.WriteString("The suggested steps come from matching common error patterns, not from this code; check that one fits before acting on it.\n")
+	}
+	sb.WriteString("Before changing code these errors touch, run 'agentlog errors --json' for details, or 'agentlog show <fingerprint>' for one recurring error's history. Check 'agentlog errors --since 5m' after a fix to confirm it stopped.\n")
+	sb.WriteString("</agentlog_instructions>\n")
+	return unindent(sb.String())
+}
+
+// unindent strips the leading spaces the shared line writers indent with
+func unindent(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimLeft(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatPrimeCursor is a two-line note: what's wrong, then what to do
+func formatPrimeCursor(s PrimeSummary) string {
+	switch {
+	case s.NoLogFile:
+		return "**agentlog:** not set up (`agentlog init`)\n"
+	case s.TotalErrors == 0 && s.Delta:
+		return "**agentlog:** no new errors" + primeEnvSuffix(s) + "\n"
+	case s.TotalErrors == 0:
+		return "**agentlog:** no errors" + primeEnvSuffix(s) + "\n"
+	}
+
+	word := errorsWord(s.TotalErrors)
+	if s.Delta {
+		word = "new " + word
+	}
+	line := fmt.Sprintf("**agentlog:** %d %s%s", s.TotalErrors, word, primeEnvSuffix(s))
+	if s.LastHourErrors > 0 && !s.Delta {
+		line += fmt.Sprintf(", %d in last hour", s.LastHourErrors)
+	}
+	if len(s.Regressions) > 0 {
+		line += fmt.Sprintf(" | **REGRESSION** `%s`", s.Regressions[0].ErrorType)
+	}
+	if len(s.Storms) > 0 {
+		line += fmt.Sprintf(" | **STORM** `%s` %dx", s.Storms[0].ErrorType, s.Storms[0].Count)
+	}
+	if len(s.TopErrorTypes) > 0 {
+		var types []string
+		for _, t := range s.TopErrorTypes {
+			types = append(types, fmt.Sprintf("`%s` %d", t.ErrorType, t.Count))
+		}
+		line += " | " + strings.Join(types, ", ")
+	}
+	if len(s.TopFiles) > 0 {
+		line += fmt.Sprintf(" | hot file `%s`", s.TopFiles[0].Name)
+	}
+
+	next := "→ `agentlog errors --json` for details"
+	if s.ActionableTip != "" {
+		next = "→ " + s.ActionableTip + "; `agentlog errors --json` for details"
+	}
+	return line + "\n" + next + "\n"
+}
+
+// genericPrimeItem is a named count in the generic-json preset
+type genericPrimeItem struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// genericPrimeSlow is a slow operation in the generic-json preset
+type genericPrimeSlow struct {
+	Name       string  `json:"name"`
+	Type       string  `json:"type"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// genericPrimeSummary is the generic-json preset's shape: flat, with a
+// status instead of optional flags and one ready-to-use instruction
+type genericPrimeSummary struct {
+	Tool           string             `json:"tool"`
+	Status         string             `json:"status"` // ok, no_errors, or no_log_file
+	ErrorCount     int                `json:"error_count"`
+	LastHour       int                `json:"errors_last_hour"`
+	Last24h        int                `json:"errors_last_24h"`
+	Environment    string             `json:"environment,omitempty"`
+	Delta          bool               `json:"delta"` // counts cover only what's new since last_run
+	LastRun        string             `json:"last_run,omitempty"`
+	Types          []genericPrimeItem `json:"types"`
+	Sources        []genericPrimeItem `json:"sources"`
+	Recurring      []genericPrimeItem `json:"recurring"`
+	Files          []genericPrimeItem `json:"files"`
+	Endpoints      []genericPrimeItem `json:"endpoints"`
+	SlowOperations []genericPrimeSlow `json:"slow_operations"`
+	Correlations   []string           `json:"correlations"`
+	Edits          []string           `json:"edits"`
+	Flaky          []string           `json:"flaky"`
+	Regressions    []string           `json:"regressions"`
+	PastFixes      []string           `json:"past_fixes"`
+	SnoozeExpired  []string           `json:"snooze_expired"`
+	Storms         []string           `json:"storms"`
+	Suggestions    []string           `json:"suggestions"`
+	Instruction    string             `json:"instruction"`
+	GeneratedAt    string             `json:"generated_at"`
+}
+
+// formatPrimeGenericJSON renders the summary for tools that want JSON
+// without agentlog's own field names
+func formatPrimeGenericJSON(s PrimeSummary) string {
+	out := genericPrimeSummary{
+		Tool:           "agentlog",
+		Status:         "ok",
+		ErrorCount:     s.TotalErrors,
+		LastHour:       s.LastHourErrors,
+		Last24h:        s.Last24hErrors,
+		Environment:    s.Environment,
+		Delta:          s.Delta,
+		LastRun:        s.LastRun,
+		Types:          []genericPrimeItem{},
+		Sources:        []genericPrimeItem{},
+		Recurring:      []genericPrimeItem{},
+		Files:          []genericPrimeItem{},
+		Endpoints:      []genericPrimeItem{},
+		SlowOperations: []genericPrimeSlow{},
+		Correlations:   []string{},
+		Edits:          []string{},
+		Flaky:          []string{},
+		Regressions:    []string{},
+		PastFixes:      []string{},
+		SnoozeExpired:  []string{},
+		Storms:         []string{},
+		Suggestions:    []string{},
+		Instruction:    "Run 'agentlog errors --json' for details.",
+		GeneratedAt:    s.GeneratedAt,
+	}
+	switch {
+	case s.NoLogFile:
+		out.Status = "no_log_file"
+		out.Instruction = "Error capture is not set up; run 'agentlog init'."
+	case s.TotalErrors == 0:
+		out.Status = "no_errors"
+		out.Instruction = ""
+	case s.ActionableTip != "":
+		out.Instruction = s.ActionableTip + ". " + out.Instruction
+	}
+
+	for _, t := range s.TopErrorTypes {
+		out.Types = append(out.Types, genericPrimeItem{Name: t.ErrorType, Count: t.Count})
+	}
+	for _, src := range s.TopSources {
+		out.Sources = append(out.Sources, genericPrimeItem{Name: src.Source, Count: src.Count})
+	}
+	for _, g := range s.TopGroups {
+		if g.Count > 1 {
+			out.Recurring = append(out.Recurring, genericPrimeItem{Name: g.Pattern, Count: g.Count})
+		}
+	}
+	for _, l := range s.TopFiles {
+		out.Files = append(out.Files, genericPrimeItem(l))
+	}
+	for _, l := range s.TopEndpoints {
+		out.Endpoints = append(out.Endpoints, genericPrimeItem(l))
+	}
+	for _, e := range s.SlowOperations {
+		name := e.Endpoint
+		if name == "" {
+			name = truncate(singleLine(e.Message), 60)
+		}
+		out.SlowOperations = append(out.SlowOperations, genericPrimeSlow{Name: name, Type: e.ErrorType, DurationMs: e.DurationMs})
+	}
+
+	for _, c := range s.Correlations {
+		out.Correlations = append(out.Correlations, c.String())
+	}
+	for _, c := range s.RecentEdits {
+		out.Edits = append(out.Edits, c.String())
+	}
+	for _, f := range s.Flaky {
+		out.Flaky = append(out.Flaky, f.String())
+	}
+	for _, r := range s.Regressions {
+		out.Regressions = append(out.Regressions, r.String())
+	}
+	for _, f := range s.PastFixes {
+		out.PastFixes = append(out.PastFixes, f.String())
+	}
+	for _, x := range s.SnoozeExpired {
+		out.SnoozeExpired = append(out.SnoozeExpired, x.String())
+	}
+	for _, x := range s.Storms {
+		out.Storms = append(out.Storms, x.String())
+	}
+	for _, sug := range s.Suggestions {
+		out.Suggestions = append(out.Suggestions, sug.String())
+	}
+
+	data, _ := json.MarshalIndent(out, "", "  ")
+	return string(data) + "\n"
+}
+
+// errorsWord is "error" or "errors" to follow n
+func errorsWord(n int) string {
+	if n == 1 {
+		return "error"
+	}
+	return "errors"
+}
+
+// primeDeltaSuffix says what a --delta summary's counts are relative to
+func primeDeltaSuffix(s PrimeSummary) string {
+	if !s.Delta {
+		return ""
+	}
+	if s.LastRun == "" {
+		return " (first --delta run)"
+	}
+	if t, err := time.Parse(time.RFC3339, s.LastRun); err == nil {
+		return " since last check (" + relativeTime(t, time.Now()) + ")"
+	}
+	return " since last check"
+}
+
+// primeEnvSuffix names the summary's environment, if it has one
+func primeEnvSuffix(s PrimeSummary) string {
+	if s.Environment == "" {
+		return ""
+	}
+	return " in " + s.Environment
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func presetSummary() PrimeSummary {
+	s := PrimeSummary{
+		TotalErrors:    5,
+		LastHourErrors: 2,
+		Last24hErrors:  5,
+		TopErrorTypes:  []ErrorTypeCount{{ErrorType: "DATABASE_ERROR", Count: 4}, {ErrorType: "UNCAUGHT_ERROR", Count: 1}},
+		TopSources:     []SourceCount{{Source: "backend", Count: 4}, {Source: "frontend", Count: 1}},
+		TopGroups:      []ErrorGroup{{Fingerprint: "8c1d2e3f4a5b", Pattern: "connection refused", Count: 4}},
+		TopFiles:       []LocationCount{{Name: "src/db.ts", Count: 4}},
+		SlowOperations: []ErrorEntry{{ErrorType: "SLOW_QUERY", Endpoint: "/api/users", DurationMs: 950, Kind: kindPerf}},
+		GeneratedAt:    "2024-01-01T00:00:00Z",
+	}
+	s.ActionableTip = generateTip(s)
+	return s
+}
+
+func TestFindPrimePreset(t *testing.T) {
+	if p, err := findPrimePreset(" Claude "); err != nil || p.Name != "claude" {
+		t.Errorf("findPrimePreset(Claude) = %v, %v", p.Name, err)
+	}
+	_, err := findPrimePreset("copilot")
+	if err == nil || !strings.Contains(err.Error(), "available: claude, cursor, generic-json") {
+		t.Errorf("unknown preset should list the available ones, got %v", err)
+	}
+}
+
+func TestFormatPrimeClaude(t *testing.T) {
+	out := formatPrimeClaude(presetSummary())
+	for _, want := range []string{
+		"<agentlog_errors>\n5 errors logged (2 in the last hour, 5 in the last 24h).",
+		"Most common types: DATABASE_ERROR (4), UNCAUGHT_ERROR (1)",
+		`Recurring 4x: "connection refused" (fingerprint 8c1d2e3f4a5b)`,
+		"\nFiles: src/db.ts (4)\n",
+		"\nSlow: /api/users 950ms (SLOW_QUERY)\n",
+		"<agentlog_instructions>\nFocus on DATABASE_ERROR in backend - 80% of errors.\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("claude output should contain %q, got:\n%s", want, out)
+		}
+	}
+
+	if out := formatPrimeClaude(PrimeSummary{}); strings.Contains(out, "<agentlog_instructions>") || !strings.Contains(out, "No errors logged.") {
+		t.Errorf("empty summary should be one short block, got:\n%s", out)
+	}
+}
+
+func TestFormatPrimeCursor(t *testing.T) {
+	out := formatPrimeCursor(presetSummary())
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("cursor output should be two lines, got:\n%s", out)
+	}
+	if !strings.Contains(lines[0], "**agentlog:** 5 errors, 2 in last hour | `DATABASE_ERROR` 4") || !strings.Contains(lines[0], "hot file `src/db.ts`") {
+		t.Errorf("unexpected summary line: %s", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "→ Focus on DATABASE_ERROR") {
+		t.Errorf("unexpected instruction line: %s", lines[1])
+	}
+	if out := formatPrimeCursor(PrimeSummary{NoLogFile: true}); !strings.Contains(out, "agentlog init") {
+		t.Errorf("missing log should point at init, got: %s", out)
+	}
+}
+
+func TestFormatPrimeGenericJSON(t *testing.T) {
+	var out genericPrimeSummary
+	if err := json.Unmarshal([]byte(formatPrimeGenericJSON(presetSummary())), &out); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if out.Status != "ok" || out.ErrorCount != 5 || out.Types[0].Name != "DATABASE_ERROR" || out.Recurring[0].Count != 4 {
+		t.Errorf("unexpected generic JSON: %+v", out)
+	}
+	if len(out.SlowOperations) != 1 || out.SlowOperations[0].DurationMs != 950 {
+		t.Errorf("unexpected slow operations: %+v", out.SlowOperations)
+	}
+	if !strings.HasPrefix(out.Instruction, "Focus on DATABASE_ERROR") {
+		t.Errorf("instruction should lead with the tip, got %q", out.Instruction)
+	}
+
+	json.Unmarshal([]byte(formatPrimeGenericJSON(PrimeSummary{NoLogFile: true})), &out)
+	if out.Status != "no_log_file" || out.Types == nil {
+		t.Errorf("missing log should set status and keep empty lists, got %+v", out)
+	}
+}
+
+func TestPrimeCommand_AgentFlag(t *testing.T) {
+	tmpDir := t.TempDir()
+	agentlogDir := filepath.Join(tmpDir, ".agentlog")
+	os.MkdirAll(agentlogDir, 0755)
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	os.WriteFile(filepath.Join(agentlogDir, "errors.jsonl"), []byte(`{"timestamp":"`+now+`","source":"frontend","error_type":"UNCAUGHT_ERROR","message":"Test error"}`+"\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(origDir)
+	defer func() { primeAgent = "" }()
+
+	buf := new(bytes.Buffer)
+	primeCmd.SetOut(buf)
+	primeCmd.SetErr(buf)
+
+	primeAgent = "claude"
+	if err := primeCmd.RunE(primeCmd, []string{}); err != nil {
+		t.Fatalf("prime failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "<agentlog_errors>\n1 error logged") {
+		t.Errorf("--agent claude output unexpected: %s", buf.String())
+	}
+
+	buf.Reset()
+	primeAgent = "nope"
+	if err := primeCmd.RunE(primeCmd, []string{}); ExitCode(err) != ExitError {
+		t.Errorf("unknown preset should exit %d, got %v", ExitError, err)
+	}
+	if !strings.Contains(buf.String(), "unknown --agent preset 'nope'") {
+		t.Errorf("unknown preset should be reported, got: %s", buf.String())
+	}
+}
@@ -137,6 +137,12 @@ func TestPrimeCommand_MultipleErrors(t *testing.T) {
 	if summary.TopSources[0].Source != "frontend" || summary.TopSources[0].Count != 3 {
 		t.Errorf("expected frontend with 3 as top source, got %v", summary.TopSources[0])
 	}
+	// Each type's trend has its entries in the hour they happened
+	for _, typ := range summary.TopErrorTypes {
+		if typ.ErrorType == "VALIDATION_ERROR" && (len(typ.Trend) != 24 || typ.Trend[20] != 1) {
+			t.Errorf("VALIDATION_ERROR trend = %v, want 1 three hours back", typ.Trend)
+		}
+	}
 }
 
 func TestPrimeCommand_JSONOutput(t *testing.T) {
@@ -280,13 +286,15 @@ func TestPrimeCommand_Integration(t *testing.T) {
 	os.Chdir(tmpDir)
 	defer os.Chdir(origDir)
 
-	// Execute command by calling the Run function directly
+	// Execute command by calling the RunE function directly
 	buf := new(bytes.Buffer)
 	primeCmd.SetOut(buf)
 	primeCmd.SetErr(buf)
 
-	// Call Run directly with empty args
-	primeCmd.Run(primeCmd, []string{})
+	// Call RunE directly with empty args
+	if err := primeCmd.RunE(primeCmd, []string{}); err != nil {
+		t.Fatalf("prime failed: %v", err)
+	}
 
 	output := buf.String()
 	if !strings.Contains(output, "1 error") {
@@ -294,6 +302,34 @@ func TestPrimeCommand_Integration(t *testing.T) {
 	}
 }
 
+func TestPrimeCommand_NotInitializedExitCode(t *testing.T) {
+	tmpDir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(origDir)
+
+	buf := new(bytes.Buffer)
+	primeCmd.SetOut(buf)
+	primeCmd.SetErr(buf)
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+
+	if err := primeCmd.RunE(primeCmd, []string{}); ExitCode(err) != ExitNotInitialized {
+		t.Fatalf("prime without .agentlog/ = %v, want exit %d", err, ExitNotInitialized)
+	}
+	var summary PrimeSummary
+	if err := json.Unmarshal(buf.Bytes(), &summary); err != nil || !summary.NotInitialized {
+		t.Errorf("summary should still be printed with not_initialized, got %q (%v)", buf.String(), err)
+	}
+
+	// Posting to serve, a project has .agentlog/ before any errors.jsonl
+	os.Mkdir(filepath.Join(tmpDir, ".agentlog"), 0755)
+	buf.Reset()
+	if err := primeCmd.RunE(primeCmd, []string{}); err != nil {
+		t.Errorf("prime with .agentlog/ but no log = %v, want success", err)
+	}
+}
+
 func TestTopN_SortsCorrectly(t *testing.T) {
 	counts := map[string]int{
 		"NETWORK_ERROR":    5,
@@ -341,3 +377,152 @@ func TestGenerateTip(t *testing.T) {
 		t.Errorf("tip should mention percentage, got: %s", tip)
 	}
 }
+
+func TestFormatPrimeSummaryHuman_Recurring(t *testing.T) {
+	summary := PrimeSummary{
+		TotalErrors: 4,
+		TopGroups: []ErrorGroup{
+			{ErrorType: "NETWORK_ERROR", Pattern: "timeout after <n>ms", Count: 3},
+			{ErrorType: "DATABASE_ERROR", Pattern: "deadlock detected", Count: 1},
+		},
+	}
+
+	output := formatPrimeSummaryHuman(summary)
+	if !strings.Contains(output, `Recurring: "timeout after <n>ms" (3)`) {
+		t.Errorf("expected recurring group in output, got: %s", output)
+	}
+	if strings.Contains(output, "deadlock detected") {
+		t.Errorf("one-off groups should not be listed as recurring, got: %s", output)
+	}
+}
+
+func TestFormatPrimeSummaryHuman_Sparklines(t *testing.T) {
+	trend := make([]int, 24)
+	trend[22], trend[23] = 1, 4
+	summary := PrimeSummary{
+		TotalErrors:   6,
+		TopErrorTypes: []ErrorTypeCount{{ErrorType: "DATABASE_ERROR", Count: 5, Trend: trend}, {ErrorType: "OLD_ERROR", Count: 1}},
+	}
+
+	output := formatPrimeSummaryHuman(summary)
+	if !strings.Contains(output, "Top types: DATABASE_ERROR (5) ▁▁▁▁▁▁▁▁▁▁▁▁▁▁▁▁▁▁▁▁▁▁▃█, OLD_ERROR (1)\n") {
+		t.Errorf("expected a sparkline for the type with a trend, got: %s", output)
+	}
+}
+
+func TestFormatPrimeSummaryHuman_TopLocations(t *testing.T) {
+	summary := PrimeSummary{
+		TotalErrors:  3,
+		TopFiles:     []LocationCount{{Name: "src/App.tsx", Count: 2}},
+		TopEndpoints: []LocationCount{{Name: "/api/users/:id", Count: 3}},
+	}
+
+	output := formatPrimeSummaryHuman(summary)
+	if !strings.Contains(output, "Files: src/App.tsx (2)") {
+		t.Errorf("expected top files in output, got: %s", output)
+	}
+	if !strings.Contains(output, "Endpoints: /api/users/:id (3)") {
+		t.Errorf("expected top endpoints in output, got: %s", output)
+	}
+}
+
+func TestPrimeCommand_EnvFilter(t *testing.T) {
+	tmpDir := t.TempDir()
+	agentlogDir := filepath.Join(tmpDir, ".agentlog")
+	os.MkdirAll(agentlogDir, 0755)
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	lines := `{"timestamp":"` + now + `","source":"frontend","error_type":"UNCAUGHT_ERROR","message":"dev error","environment":"dev"}
+{"timestamp":"` + now + `","source":"test","error_type":"ASSERTION_ERROR","message":"test failure","environment":"test"}
+{"timestamp":"` + now + `","source":"test","error_type":"ASSERTION_ERROR","message":"another failure","environment":"test"}
+`
+	os.WriteFile(filepath.Join(agentlogDir, "errors.jsonl"), []byte(lines), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(origDir)
+	defer func() { primeEnv = "" }()
+
+	primeEnv = "dev"
+	summary, err := generatePrimeSummary()
+	if err != nil {
+		t.Fatalf("generatePrimeSummary() error = %v", err)
+	}
+	if summary.TotalErrors != 1 || summary.TopErrorTypes[0].ErrorType != "UNCAUGHT_ERROR" {
+		t.Errorf("--env dev should exclude test-run errors, got %+v", summary)
+	}
+	if out := formatPrimeSummaryHuman(summary); !strings.Contains(out, "1 error in dev") {
+		t.Errorf("expected environment in header, got: %s", out)
+	}
+
+	primeEnv = "staging"
+	summary, _ = generatePrimeSummary()
+	if out := formatPrimeSummaryHuman(summary); !strings.Contains(out, "No errors logged in staging") {
+		t.Errorf("unexpected output for empty environment: %s", out)
+	}
+}
+
+func TestPrimeCommand_Since(t *testing.T) {
+	tmpDir := t.TempDir()
+	agentlogDir := filepath.Join(tmpDir, ".agentlog")
+	os.MkdirAll(agentlogDir, 0755)
+
+	recent := time.Now().UTC().Add(-time.Hour).Format(time.RFC3339Nano)
+	old := time.Now().UTC().Add(-72 * time.Hour).Format(time.RFC3339Nano)
+	lines := `{"timestamp":"` + old + `","source":"backend","error_type":"DATABASE_ERROR","message":"old"}
+{"timestamp":"` + recent + `","source":"frontend","error_type":"UNCAUGHT_ERROR","message":"recent"}
+`
+	os.WriteFile(filepath.Join(agentlogDir, "errors.jsonl"), []byte(lines), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(origDir)
+	defer func() { primeSince = "" }()
+
+	primeSince = "2d"
+	summary, err := generatePrimeSummary()
+	if err != nil {
+		t.Fatalf("generatePrimeSummary() error = %v", err)
+	}
+	if summary.TotalErrors != 1 || summary.TopErrorTypes[0].ErrorType != "UNCAUGHT_ERROR" {
+		t.Errorf("--since 2d should drop the 3-day-old entry, got %+v", summary)
+	}
+
+	primeSince = "whenever"
+	if _, err := generatePrimeSummary(); err == nil {
+		t.Error("invalid --since should fail")
+	}
+}
+
+func TestPrimeCommand_SlowOperations(t *testing.T) {
+	tmpDir := t.TempDir()
+	agentlogDir := filepath.Join(tmpDir, ".agentlog")
+	os.MkdirAll(agentlogDir, 0755)
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	lines := `{"timestamp":"` + now + `","source":"backend","error_type":"DATABASE_ERROR","message":"boom"}
+{"timestamp":"` + now + `","source":"backend","error_type":"SLOW_QUERY","message":"orders report","kind":"perf","duration_ms":950}
+{"timestamp":"` + now + `","source":"backend","error_type":"SLOW_REQUEST","message":"GET /api/users","endpoint":"/api/users","kind":"perf","duration_ms":8200}
+`
+	os.WriteFile(filepath.Join(agentlogDir, "errors.jsonl"), []byte(lines), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(origDir)
+
+	summary, err := generatePrimeSummary()
+	if err != nil {
+		t.Fatalf("generatePrimeSummary() error = %v", err)
+	}
+	if summary.TotalErrors != 1 || len(summary.TopErrorTypes) != 1 {
+		t.Errorf("perf entries should not count as errors: %+v", summary)
+	}
+	if len(summary.SlowOperations) != 2 || summary.SlowOperations[0].ErrorType != "SLOW_REQUEST" {
+		t.Fatalf("slowest operations should come first: %+v", summary.SlowOperations)
+	}
+
+	output := formatPrimeSummaryHuman(summary)
+	if !strings.Contains(output, "Slow: /api/users 8.2s (SLOW_REQUEST), orders report 950ms (SLOW_QUERY)") {
+		t.Errorf("unexpected output:\n%s", output)
+	}
+}
@@ -0,0 +1,350 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A query is a boolean expression over entry fields and context keys, as
+// given to errors --query:
+//
+//	query      = or
+//	or         = and { "or" and }
+//	and        = not { "and" not }
+//	not        = "not" not | "(" query ")" | comparison
+//	comparison = field op value
+//
+// Operators are = and != (exact), ~ and !~ (substring, or a regular
+// expression written /like this/), and <, <=, >, >= (numbers, or for
+// timestamp, anything --since accepts). Values may be quoted with ' or ".
+// Keywords are case-insensitive. As with --where, a field that isn't an
+// entry field is a context key, and entries without the field never
+// match a comparison; tags match if any tag does.
+type queryExpr interface {
+	match(e ErrorEntry) bool
+}
+
+type queryAnd struct{ left, right queryExpr }
+
+func (q queryAnd) match(e ErrorEntry) bool { return q.left.match(e) && q.right.match(e) }
+
+type queryOr struct{ left, right queryExpr }
+
+func (q queryOr) match(e ErrorEntry) bool { return q.left.match(e) || q.right.match(e) }
+
+type queryNot struct{ expr queryExpr }
+
+func (q queryNot) match(e ErrorEntry) bool { return !q.expr.match(e) }
+
+// queryCompare is one field op value comparison
+type queryCompare struct {
+	field string
+	op    string
+	value string
+	re    *regexp.Regexp // for ~ and !~ with a /regex/
+	num   float64        // for ordering ops on numbers
+	time  time.Time      // for ordering ops on timestamp
+}
+
+// queryOps are the comparison operators, longest first so "<=" isn't
+// read as "<"
+var queryOps = []string{"!=", "!~", "<=", ">=", "=", "~", "<", ">"}
+
+func (q queryCompare) match(e ErrorEntry) bool {
+	v := fieldValue(e, q.field)
+	if v == nil {
+		return false
+	}
+	if tags, ok := v.([]string); ok {
+		for _, t := range tags {
+			if q.matchValue(t) {
+				return true
+			}
+		}
+		return false
+	}
+	return q.matchValue(v)
+}
+
+func (q queryCompare) matchValue(v interface{}) bool {
+	s, ok := v.(string)
+	if !ok {
+		s = formatCell(v)
+	}
+	switch q.op {
+	case "=":
+		return s == q.value
+	case "!=":
+		return s != q.value
+	case "~", "!~":
+		found := strings.Contains(s, q.value)
+		if q.re != nil {
+			found = q.re.MatchString(s)
+		}
+		return found == (q.op == "~")
+	}
+
+	var cmp int
+	if !q.time.IsZero() {
+		t, err := parseEntryTime(s)
+		if err != nil {
+			return false
+		}
+		cmp = t.Compare(q.time)
+	} else {
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return false
+		}
+		switch {
+		case n < q.num:
+			cmp = -1
+		case n > q.num:
+			cmp = 1
+		}
+	}
+	switch q.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default: // ">="
+		return cmp >= 0
+	}
+}
+
+// parseQuery parses an errors --query expression
+func parseQuery(input string) (queryExpr, error) {
+	p := &queryParser{input: input}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, p.errorf("unexpected %q", p.rest())
+	}
+	return expr, nil
+}
+
+// queryParser is a recursive-descent parser over the query text
+type queryParser struct {
+	input string
+	pos   int
+}
+
+func (p *queryParser) errorf(format string, a ...interface{}) error {
+	return invalidInput("invalid --query at column %d: %s", p.pos+1, fmt.Sprintf(format, a...))
+}
+
+func (p *queryParser) rest() string {
+	rest := p.input[p.pos:]
+	if len(rest) > 20 {
+		rest = rest[:20] + "..."
+	}
+	return rest
+}
+
+func (p *queryParser) skipSpace() {
+	for p.pos < len(p.input) && isQuerySpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+// keyword consumes word if it comes next as a whole word
+func (p *queryParser) keyword(word string) bool {
+	p.skipSpace()
+	end := p.pos + len(word)
+	if end > len(p.input) || !strings.EqualFold(p.input[p.pos:end], word) {
+		return false
+	}
+	if end < len(p.input) && isFieldChar(p.input[end]) {
+		return false
+	}
+	p.pos = end
+	return true
+}
+
+func (p *queryParser) parseOr() (queryExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = queryOr{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = queryAnd{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseNot() (queryExpr, error) {
+	if p.keyword("not") {
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return queryNot{expr}, nil
+	}
+
+	p.skipSpace()
+	if p.pos < len(p.input) && p.input[p.pos] == '(' {
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.pos >= len(p.input) || p.input[p.pos] != ')' {
+			return nil, p.errorf("missing )")
+		}
+		p.pos++
+		return expr, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *queryParser) parseComparison() (queryExpr, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.input) && isFieldChar(p.input[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start {
+		if p.pos >= len(p.input) {
+			return nil, p.errorf("expected a comparison, got the end of the query")
+		}
+		return nil, p.errorf("expected a field name, got %q", p.rest())
+	}
+	name := p.input[start:p.pos]
+	q := queryCompare{field: name}
+	if !validField(q.field) {
+		q.field = "context." + q.field
+	}
+
+	p.skipSpace()
+	for _, op := range queryOps {
+		if strings.HasPrefix(p.input[p.pos:], op) {
+			q.op = op
+			p.pos += len(op)
+			break
+		}
+	}
+	if q.op == "" {
+		return nil, p.errorf("expected an operator (=, !=, ~, !~, <, <=, >, >=) after %q", name)
+	}
+
+	p.skipSpace()
+	if (q.op == "~" || q.op == "!~") && p.pos < len(p.input) && p.input[p.pos] == '/' {
+		pattern, err := p.delimited('/')
+		if err != nil {
+			return nil, err
+		}
+		if q.re, err = regexp.Compile(pattern); err != nil {
+			return nil, p.errorf("invalid regular expression: %v", err)
+		}
+		return q, nil
+	}
+
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	q.value = value
+	switch q.op {
+	case "<", "<=", ">", ">=":
+		if q.field == "timestamp" {
+			if q.time, err = parseSince(value); err != nil {
+				return nil, p.errorf("invalid time %q: %v", value, err)
+			}
+		} else if q.num, err = strconv.ParseFloat(value, 64); err != nil {
+			return nil, p.errorf("%s needs a number, got %q", q.op, value)
+		}
+	}
+	return q, nil
+}
+
+// value reads a quoted or bare value. Bare values end at whitespace or a
+// closing parenthesis.
+func (p *queryParser) value() (string, error) {
+	if p.pos < len(p.input) && (p.input[p.pos] == '"' || p.input[p.pos] == '\'') {
+		return p.delimited(p.input[p.pos])
+	}
+	start := p.pos
+	for p.pos < len(p.input) && !isQuerySpace(p.input[p.pos]) && p.input[p.pos] != ')' {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("expected a value")
+	}
+	return p.input[start:p.pos], nil
+}
+
+// delimited reads text between two delim characters, where a backslash
+// escapes the delimiter. Other backslashes are kept, so regular
+// expressions need no extra escaping.
+func (p *queryParser) delimited(delim byte) (string, error) {
+	open := p.pos
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.input) && p.input[p.pos+1] == delim:
+			sb.WriteByte(delim)
+			p.pos += 2
+		case c == delim:
+			p.pos++
+			return sb.String(), nil
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+	p.pos = open
+	return "", p.errorf("unterminated %c", delim)
+}
+
+func isQuerySpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isFieldChar(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// filterQuery keeps entries matching q; a nil query keeps them all
+func filterQuery(entries []ErrorEntry, q queryExpr) []ErrorEntry {
+	if q == nil {
+		return entries
+	}
+	var filtered []ErrorEntry
+	for _, e := range entries {
+		if q.match(e) {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseQuery(t *testing.T) {
+	recent := time.Now().Add(-10 * time.Minute).UTC().Format(time.RFC3339)
+	entries := []ErrorEntry{
+		{ID: "a", Timestamp: "2025-12-10T19:00:00.000Z", Source: "backend", ErrorType: "DATABASE_ERROR", Message: "query timeout after 30s", Context: map[string]interface{}{"queue": "emails", "attempt": float64(3)}},
+		{ID: "b", Timestamp: "2025-12-10T19:05:00.000Z", Source: "worker", ErrorType: "DATABASE_ERROR", Message: "deadlock detected", Tags: []string{"flaky", "ci"}},
+		{ID: "c", Timestamp: recent, Source: "frontend", ErrorType: "UNCAUGHT_ERROR", Message: "connection timeout", DurationMs: 1200},
+		{ID: "d", Timestamp: recent, Source: "backend", ErrorType: "SLOW_REQUEST", Message: "GET /api/users", Endpoint: "/api/users", DurationMs: 300},
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"type=DATABASE_ERROR and (source=backend or source=worker) and message~timeout", "a"},
+		{"type=DATABASE_ERROR", "a,b"},
+		{"type=DATABASE_ERROR OR source=frontend", "a,b,c"},
+		{"message~timeout and not source=backend", "c"},
+		{"not (source=backend or source=worker)", "c"},
+		{"source!=backend", "b,c"},
+		{"message!~timeout", "b,d"},
+		{`message~/^(query|connection) timeout/`, "a,c"},
+		{`message!~/dead ?lock/`, "a,c,d"},
+		{"duration_ms>=300", "c,d"},
+		{"duration_ms>300", "c"},
+		{"duration_ms < 1000", "d"},
+		{"attempt>2", "a"},
+		{"queue=emails", "a"},
+		{"tags=ci", "b"},
+		{"timestamp>1h", "c,d"},
+		{"timestamp<=2025-12-10", ""},
+		{`message="deadlock detected"`, "b"},
+		{`message~'GET /api' or message~"dead"`, "b,d"},
+		{"endpoint~/^\\/api/", "d"},
+		{"not not source=worker", "b"},
+	}
+	for _, tt := range tests {
+		q, err := parseQuery(tt.query)
+		if err != nil {
+			t.Errorf("parseQuery(%q) error = %v", tt.query, err)
+			continue
+		}
+		var got []string
+		for _, e := range filterQuery(entries, q) {
+			got = append(got, e.ID)
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("%q matched %v, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseQuery_Errors(t *testing.T) {
+	for query, want := range map[string]string{
+		"":                            "end of the query",
+		"type":                        "expected an operator",
+		"type=":                       "expected a value",
+		"(type=X":                     "missing )",
+		"type=X source=Y":             "unexpected",
+		"type=X and":                  "end of the query",
+		"line>abc":                    "needs a number",
+		"timestamp>whenever":          "invalid time",
+		"message~/(/":                 "invalid regular expression",
+		`message="open`:               "unterminated",
+		"type=X or ) and source=Y":    "expected a field name",
+		"not":                         "end of the query",
+		"message~timeout and or x=y":  "expected an operator",
+		"source = backend or type = ": "expected a value",
+	} {
+		_, err := parseQuery(query)
+		if err == nil || !strings.Contains(err.Error(), want) || errorCode(err) != "INVALID_INPUT" {
+			t.Errorf("parseQuery(%q) error = %v, want one mentioning %q", query, err, want)
+		}
+	}
+}
@@ -0,0 +1,544 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/diag"
+	"github.com/agentlog/agentlog/internal/logfile"
+	"github.com/agentlog/agentlog/internal/self"
+	"github.com/spf13/cobra"
+)
+
+var (
+	resolveUndo   bool
+	resolveList   bool
+	resolveNote   string
+	resolveCommit string
+)
+
+const resolvedFileName = "resolved.jsonl"
+
+// Resolution is one line of .agentlog/resolved.jsonl: an error group marked
+// resolved, or with Reopened, unmarked. The latest line for a fingerprint
+// wins.
+type Resolution struct {
+	Fingerprint string `json:"fingerprint"`
+	ErrorType   string `json:"error_type"`
+	Pattern     string `json:"pattern"`
+	ResolvedAt  string `json:"resolved_at"`
+	Reopened    bool   `json:"reopened,omitempty"`
+}
+
+// ResolveResult is a resolution resolve recorded, with the fix recorded
+// alongside it by --note or --commit
+type ResolveResult struct {
+	Resolution
+	Fix *Fix `json:"fix,omitempty"`
+}
+
+// Regression marks an entry or group of a resolved error that occurred
+// again after it was resolved
+type Regression struct {
+	Fingerprint string `json:"fingerprint"` // the resolved group's
+	ResolvedAt  string `json:"resolved_at"`
+}
+
+// resolveCmd represents the resolve command
+var resolveCmd = &cobra.Command{
+	Use:   "resolve <id|fingerprint>...",
+	Short: "Mark errors resolved, so their return is flagged as a regression",
+	Long: `Mark the error groups of entries (by ID) or groups (by fingerprint, from
+errors --group) resolved. An error that occurs again after being resolved is
+a regression: errors, tail, and prime flag it as REGRESSION with how long
+ago it was resolved, ahead of everything else, since a fix that didn't hold
+matters more than a new error.
+
+Later entries count as the same error when they'd group with the resolved
+one: the same type and a similar message, ignoring numbers, IDs, and paths.
+Resolutions are kept in .agentlog/resolved.jsonl.
+
+--note and --commit record how the error was fixed, in
+.agentlog/resolutions.jsonl. When an error like it occurs again, errors,
+tail, and prime show the fix ("Fixed before: ..."), as do show and similar,
+so the next attempt starts from what worked, or didn't, last time. Fixes
+are kept when a group is reopened with --undo. --commit without a value
+records HEAD.
+
+Examples:
+  agentlog resolve 8c1d2e3f4a5b        # A group, after fixing it
+  agentlog resolve 8c1d --note "Retry the pool on startup" --commit
+  agentlog resolve 3f9a 77b0           # The groups of two entries
+  agentlog resolve --list              # What's resolved, and what came back
+  agentlog resolve --undo 8c1d         # No longer resolved`,
+	RunE: runResolve,
+}
+
+func init() {
+	rootCmd.AddCommand(resolveCmd)
+
+	resolveCmd.Flags().BoolVar(&resolveUndo, "undo", false, "Remove the resolved mark from these groups")
+	resolveCmd.Flags().BoolVar(&resolveList, "list", false, "List resolved errors and whether they came back")
+	resolveCmd.Flags().StringVar(&resolveNote, "note", "", "Record how the error was fixed, shown when it comes back")
+	resolveCmd.Flags().StringVar(&resolveCommit, "commit", "", "Record the commit that fixed it (default with no value: HEAD)")
+	resolveCmd.Flags().Lookup("commit").NoOptDefVal = "HEAD"
+}
+
+func runResolve(cmd *cobra.Command, args []string) error {
+	// Determine base directory (use --path override or cwd)
+	baseDir := GetPathOverride()
+	if baseDir == "" {
+		var err error
+		baseDir, err = os.Getwd()
+		if err != nil {
+			self.LogError(".", "GETWD_ERROR", err.Error())
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+	if uninitialized(baseDir) {
+		return exitNotInitialized(cmd)
+	}
+
+	recordingFix := resolveNote != "" || resolveCommit != ""
+	if resolveList {
+		if len(args) > 0 || resolveUndo || recordingFix {
+			return invalidInput("--list takes no IDs and can't be combined with --undo, --note, or --commit")
+		}
+		return listResolutions(cmd, baseDir)
+	}
+	if len(args) == 0 {
+		return invalidInput("an ID or fingerprint is required (see 'agentlog errors --group')")
+	}
+	if resolveUndo && recordingFix {
+		return invalidInput("--undo can't be combined with --note or --commit")
+	}
+	commit := ""
+	if resolveCommit != "" {
+		var err error
+		if commit, err = commitHash(baseDir, resolveCommit); err != nil {
+			return invalidInput("%w", err)
+		}
+	}
+
+	var records []Resolution
+	var err error
+	if resolveUndo {
+		records, err = reopenRefs(baseDir, args)
+	} else {
+		records, err = resolveRefs(baseDir, args, time.Now())
+	}
+	if err != nil {
+		self.LogError(baseDir, "INVALID_INPUT", err.Error())
+		return invalidInput("%w", err)
+	}
+	if err := recordResolutions(baseDir, records); err != nil {
+		self.LogError(baseDir, "FILE_WRITE_ERROR", err.Error())
+		return fmt.Errorf("failed to record the resolution: %w", err)
+	}
+	var fixes []Fix
+	if recordingFix {
+		for _, r := range records {
+			fixes = append(fixes, Fix{Fingerprint: r.Fingerprint, ErrorType: r.ErrorType, Pattern: r.Pattern, FixedAt: r.ResolvedAt, Commit: commit, Note: resolveNote})
+		}
+		if err := recordFixes(baseDir, fixes); err != nil {
+			self.LogError(baseDir, "FILE_WRITE_ERROR", err.Error())
+			return fmt.Errorf("failed to record the fix: %w", err)
+		}
+	}
+
+	w := cmd.OutOrStdout()
+	if IsJSONOutput() {
+		results := make([]ResolveResult, len(records))
+		for i, r := range records {
+			results[i].Resolution = r
+			if fixes != nil {
+				results[i].Fix = &fixes[i]
+			}
+		}
+		output, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Fprintln(w, string(output))
+		return nil
+	}
+	redactor := entryPolicyFor(baseDir).redactor
+	verb := "Resolved"
+	if resolveUndo {
+		verb = "Reopened"
+	}
+	for _, r := range records {
+		fmt.Fprintf(w, "%s %s %s: %s\n", verb, r.Fingerprint, colors.err(r.ErrorType), redactor.String(r.Pattern))
+	}
+	if len(fixes) > 0 {
+		fmt.Fprintf(w, "  Fix: %s\n", fixes[0])
+	}
+	if !resolveUndo {
+		fmt.Fprintln(w, colors.dim("If these occur again, errors, tail, and prime flag them as regressions."))
+	}
+	return nil
+}
+
+// resolveRefs finds the groups refs name, as show does, and returns a
+// resolution for each
+func resolveRefs(baseDir string, refs []string, now time.Time) ([]Resolution, error) {
+	entries, err := readErrors(baseDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no errors file found; run 'agentlog init' to set up")
+		}
+		return nil, err
+	}
+	clusters := clusterEntries(filterKind(entries, kindError))
+
+	var records []Resolution
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		ref = strings.ToLower(strings.TrimSpace(ref))
+		_, cluster, err := resolveShowRef(entries, clusters, ref)
+		if err != nil {
+			return nil, err
+		}
+		if seen[cluster.fingerprint()] {
+			continue
+		}
+		seen[cluster.fingerprint()] = true
+		records = append(records, Resolution{
+			Fingerprint: cluster.fingerprint(),
+			ErrorType:   cluster.errorType,
+			Pattern:     cluster.pattern,
+			ResolvedAt:  now.UTC().Format(time.RFC3339Nano),
+		})
+	}
+	return records, nil
+}
+
+// reopenRefs matches refs against the fingerprints of resolved groups
+func reopenRefs(baseDir string, refs []string) ([]Resolution, error) {
+	active := loadResolutions(baseDir).active
+	var records []Resolution
+	for _, ref := range refs {
+		ref = strings.ToLower(strings.TrimSpace(ref))
+		var found []Resolution
+		for _, r := range active {
+			if strings.HasPrefix(r.Fingerprint, ref) {
+				found = append(found, r.Resolution)
+			}
+		}
+		switch len(found) {
+		case 0:
+			return nil, fmt.Errorf("no resolved group matches '%s' (see 'agentlog resolve --list')", ref)
+		case 1:
+			r := found[0]
+			r.Reopened = true
+			records = append(records, r)
+		default:
+			return nil, fmt.Errorf("'%s' matches %d resolved groups; use more characters", ref, len(found))
+		}
+	}
+	return records, nil
+}
+
+// ResolutionStatus is a resolved group and, if it came back, when it last
+// occurred
+type ResolutionStatus struct {
+	Resolution
+	Regressed bool   `json:"regressed"`
+	LastSeen  string `json:"last_seen,omitempty"` // latest occurrence since it was resolved
+	Fixes     []Fix  `json:"fixes,omitempty"`     // how it was fixed, the latest first
+}
+
+// listResolutions prints the resolved groups, latest first, and which of
+// them occurred again
+func listResolutions(cmd *cobra.Command, baseDir string) error {
+	set := loadResolutions(baseDir)
+	entries, err := readErrors(baseDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	statuses := make([]ResolutionStatus, 0, len(set.active))
+	for _, r := range set.active {
+		statuses = append(statuses, ResolutionStatus{Resolution: r.Resolution, Fixes: set.fixes.find(r.ErrorType, r.Pattern, time.Time{})})
+	}
+	for _, e := range entries {
+		r := set.match(e)
+		if r == nil {
+			continue
+		}
+		for i := range statuses {
+			if statuses[i].Fingerprint == r.Fingerprint && !timestampBefore(e.Timestamp, statuses[i].LastSeen) {
+				statuses[i].Regressed, statuses[i].LastSeen = true, e.Timestamp
+			}
+		}
+	}
+	sort.SliceStable(statuses, func(i, j int) bool { return timestampBefore(statuses[j].ResolvedAt, statuses[i].ResolvedAt) })
+
+	w := cmd.OutOrStdout()
+	if IsJSONOutput() {
+		output, _ := json.MarshalIndent(statuses, "", "  ")
+		fmt.Fprintln(w, string(output))
+		return nil
+	}
+	if len(statuses) == 0 {
+		fmt.Fprintln(w, "No errors are marked resolved. Mark one with 'agentlog resolve <fingerprint>'.")
+		return nil
+	}
+	redactor := entryPolicyFor(baseDir).redactor
+	for _, s := range statuses {
+		line := fmt.Sprintf("%s  %s  resolved %s  %s", s.Fingerprint, s.ErrorType, colors.dim(formatTimestamp(s.ResolvedAt)), truncate(redactor.String(s.Pattern), 80))
+		if s.Regressed {
+			line += "  " + colors.err("REGRESSION, last seen "+formatTimestamp(s.LastSeen))
+		}
+		fmt.Fprintln(w, line)
+		for _, f := range s.Fixes {
+			fmt.Fprintf(w, "  Fix: %s\n", f)
+		}
+	}
+	return nil
+}
+
+func resolvedPath(baseDir string) string {
+	return filepath.Join(baseDir, ".agentlog", resolvedFileName)
+}
+
+// recordResolutions appends records to .agentlog/resolved.jsonl
+func recordResolutions(baseDir string, records []Resolution) error {
+	if err := os.MkdirAll(filepath.Join(baseDir, ".agentlog"), 0755); err != nil {
+		return err
+	}
+	var sb strings.Builder
+	for _, r := range records {
+		data, _ := json.Marshal(r)
+		sb.Write(append(data, '\n'))
+	}
+	return logfile.Append(resolvedPath(baseDir), []byte(sb.String()))
+}
+
+// readResolutions reads .agentlog/resolved.jsonl, skipping lines that don't
+// parse
+func readResolutions(baseDir string) ([]Resolution, error) {
+	f, err := os.Open(resolvedPath(baseDir))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Resolution
+	err = scanRecords(baseDir, f, func(line []byte) error {
+		var r Resolution
+		if err := json.Unmarshal(line, &r); err != nil {
+			return err
+		}
+		if r.Fingerprint != "" {
+			records = append(records, r)
+		}
+		return nil
+	})
+	return records, err
+}
+
+// latestResolutions returns the latest record for each fingerprint, in the
+// order fingerprints first appear
+func latestResolutions(records []Resolution) []Resolution {
+	latest := make(map[string]Resolution)
+	var order []string
+	for _, r := range records {
+		if _, ok := latest[r.Fingerprint]; !ok {
+			order = append(order, r.Fingerprint)
+		}
+		latest[r.Fingerprint] = r
+	}
+	result := make([]Resolution, 0, len(order))
+	for _, fp := range order {
+		result = append(result, latest[fp])
+	}
+	return result
+}
+
+// activeResolution is a resolution in effect, parsed for matching
+type activeResolution struct {
+	Resolution
+	resolved time.Time
+	tokens   map[string]bool
+}
+
+// resolutionSet is the resolutions in effect for a project, and how its
+// errors were fixed
+type resolutionSet struct {
+	baseDir string
+	modTime time.Time
+	active  []activeResolution
+	fixes   *fixSet
+}
+
+// loadResolutions reads the resolutions in effect. A missing or unreadable
+// file means none.
+func loadResolutions(baseDir string) *resolutionSet {
+	set := &resolutionSet{baseDir: baseDir, fixes: loadFixes(baseDir)}
+	if info, err := os.Stat(resolvedPath(baseDir)); err == nil {
+		set.modTime = info.ModTime()
+	}
+	records, err := readResolutions(baseDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			diag.Debugf("reading %s: %v", resolvedPath(baseDir), err)
+		}
+		return set
+	}
+
+	for _, r := range latestResolutions(records) {
+		ts, err := parseEntryTime(r.ResolvedAt)
+		if r.Reopened || err != nil {
+			continue
+		}
+		set.active = append(set.active, activeResolution{Resolution: r, resolved: ts, tokens: messageTokens(r.Pattern)})
+	}
+	return set
+}
+
+// refresh reloads the set if resolved.jsonl changed since it was read, for
+// commands that run for a while
+func (s *resolutionSet) refresh() {
+	s.fixes.refresh()
+	info, err := os.Stat(resolvedPath(s.baseDir))
+	if err != nil && s.modTime.IsZero() {
+		return
+	}
+	if err == nil && info.ModTime().Equal(s.modTime) {
+		return
+	}
+	*s = *loadResolutions(s.baseDir)
+}
+
+// match returns the resolution e is a regression of: an error after the
+// latest resolution that it would group with (see clusterEntries), or nil
+func (s *resolutionSet) match(e ErrorEntry) *Resolution {
+	return s.find(e, true)
+}
+
+// resolved reports whether e is an occurrence of a resolved group from
+// before it was resolved, which the fix took care of
+func (s *resolutionSet) resolved(e ErrorEntry) bool {
+	return s.find(e, false) != nil
+}
+
+// hide returns entries without those resolved groups had before they were
+// resolved; regressions are kept
+func (s *resolutionSet) hide(entries []ErrorEntry) []ErrorEntry {
+	if len(s.active) == 0 {
+		return entries
+	}
+	kept := make([]ErrorEntry, 0, len(entries))
+	for _, e := range entries {
+		if !s.resolved(e) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// find returns the latest resolution of the group e would join that e
+// occurred after (regressed) or at or before (not), or nil
+func (s *resolutionSet) find(e ErrorEntry, regressed bool) *Resolution {
+	if len(s.active) == 0 || e.kind() != kindError {
+		return nil
+	}
+	ts, err := parseEntryTime(e.Timestamp)
+	if err != nil {
+		return nil
+	}
+	pattern := normalizeMessage(e.Message)
+	var tokens map[string]bool
+	var found *activeResolution
+	for i := range s.active {
+		r := &s.active[i]
+		if r.ErrorType != e.ErrorType || ts.After(r.resolved) != regressed {
+			continue
+		}
+		if r.Pattern != pattern {
+			if tokens == nil {
+				tokens = messageTokens(pattern)
+			}
+			if !wouldGroup(tokens, r.tokens) {
+				continue
+			}
+		}
+		if found == nil || r.resolved.After(found.resolved) {
+			found = r
+		}
+	}
+	if found == nil {
+		return nil
+	}
+	return &found.Resolution
+}
+
+// wouldGroup reports whether messages with these token sets are similar
+// enough for clusterEntries to group them
+func wouldGroup(a, b map[string]bool) bool {
+	return len(a) >= minClusterTokens && len(b) >= minClusterTokens && jaccard(a, b) >= clusterSimilarity
+}
+
+// mark sets Regression on the entries that are regressions, and PastFixes
+// on those of errors fixed before, in place, and returns them
+func (s *resolutionSet) mark(entries []ErrorEntry) []ErrorEntry {
+	if len(s.active) == 0 && len(s.fixes.fixes) == 0 {
+		return entries
+	}
+	for i := range entries {
+		if r := s.match(entries[i]); r != nil {
+			entries[i].Regression = &Regression{Fingerprint: r.Fingerprint, ResolvedAt: r.ResolvedAt}
+		}
+		entries[i].PastFixes = s.fixes.match(entries[i])
+	}
+	return entries
+}
+
+// RegressedError is a resolved error group that occurred again
+type RegressedError struct {
+	Fingerprint string `json:"fingerprint"` // the resolved group's
+	ErrorType   string `json:"error_type"`
+	Pattern     string `json:"pattern"`
+	ResolvedAt  string `json:"resolved_at"`
+	LastSeen    string `json:"last_seen"`
+}
+
+// String describes the regression, e.g. `DATABASE_ERROR "connection
+// refused" is back (resolved 2d ago, last seen 5m ago)`
+func (r RegressedError) String() string {
+	return fmt.Sprintf("%s %q is back (resolved %s, last seen %s)", r.ErrorType, truncate(r.Pattern, 80), formatTimestamp(r.ResolvedAt), formatTimestamp(r.LastSeen))
+}
+
+// regressions returns the resolved groups that entries include
+// occurrences of since they were resolved, most recently seen first
+func (s *resolutionSet) regressions(entries []ErrorEntry) []RegressedError {
+	byFingerprint := make(map[string]*RegressedError)
+	for _, e := range entries {
+		r := s.match(e)
+		if r == nil {
+			continue
+		}
+		reg := byFingerprint[r.Fingerprint]
+		if reg == nil {
+			reg = &RegressedError{Fingerprint: r.Fingerprint, ErrorType: r.ErrorType, Pattern: r.Pattern, ResolvedAt: r.ResolvedAt}
+			byFingerprint[r.Fingerprint] = reg
+		}
+		if reg.LastSeen == "" || timestampBefore(reg.LastSeen, e.Timestamp) {
+			reg.LastSeen = e.Timestamp
+		}
+	}
+
+	result := make([]RegressedError, 0, len(byFingerprint))
+	for _, reg := range byFingerprint {
+		result = append(result, *reg)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].LastSeen != result[j].LastSeen {
+			return timestampBefore(result[j].LastSeen, result[i].LastSeen)
+		}
+		return result[i].Fingerprint < result[j].Fingerprint
+	})
+	return result
+}
@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func resetResolveFlags() {
+	resolveUndo, resolveList, resolveNote, resolveCommit = false, false, "", ""
+}
+
+// writeResolveLog writes a log whose database error was fixed an hour ago
+// and came back, beside one that stayed fixed
+func writeResolveLog(t *testing.T, now time.Time) string {
+	t.Helper()
+	at := func(d time.Duration) string { return now.Add(d).UTC().Format(time.RFC3339Nano) }
+	return writeEntriesLog(t, []ErrorEntry{
+		{Timestamp: at(-3 * time.Hour), Source: "backend", ErrorType: "DATABASE_ERROR", Message: "connection refused on port 5432"},
+		{Timestamp: at(-2 * time.Hour), Source: "backend", ErrorType: "DATABASE_ERROR", Message: "connection refused on port 5432"},
+		{Timestamp: at(-2 * time.Hour), Source: "frontend", ErrorType: "UNCAUGHT_ERROR", Message: "x is undefined"},
+		{Timestamp: at(-10 * time.Minute), Source: "backend", ErrorType: "DATABASE_ERROR", Message: "connection refused on port 5433"},
+	})
+}
+
+// resolveAt marks the groups of refs resolved at the given time
+func resolveAt(t *testing.T, dir string, at time.Time, refs ...string) []Resolution {
+	t.Helper()
+	records, err := resolveRefs(dir, refs, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := recordResolutions(dir, records); err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+func TestResolutionSet_Match(t *testing.T) {
+	now := time.Now()
+	dir := writeResolveLog(t, now)
+	resolveAt(t, dir, now.Add(-time.Hour), fingerprint("DATABASE_ERROR", "connection refused on port <n>"), fingerprint("UNCAUGHT_ERROR", "x is undefined"))
+	set := loadResolutions(dir)
+	if len(set.active) != 2 {
+		t.Fatalf("active = %+v", set.active)
+	}
+
+	later := now.UTC().Format(time.RFC3339Nano)
+	earlier := now.Add(-2 * time.Hour).UTC().Format(time.RFC3339Nano)
+	tests := []struct {
+		entry ErrorEntry
+		want  bool
+	}{
+		{ErrorEntry{Timestamp: later, ErrorType: "DATABASE_ERROR", Message: "connection refused on port 6543"}, true},
+		{ErrorEntry{Timestamp: later, ErrorType: "DATABASE_ERROR", Message: "connection refused on db port 6543"}, true},
+		{ErrorEntry{Timestamp: earlier, ErrorType: "DATABASE_ERROR", Message: "connection refused on port 5432"}, false},
+		{ErrorEntry{Timestamp: later, ErrorType: "NETWORK_ERROR", Message: "connection refused on port 5432"}, false},
+		{ErrorEntry{Timestamp: later, ErrorType: "DATABASE_ERROR", Message: "deadlock detected"}, false},
+		{ErrorEntry{Timestamp: later, ErrorType: "DATABASE_ERROR", Message: "connection refused on port 1", Kind: kindPerf}, false},
+	}
+	for _, tt := range tests {
+		if got := set.match(tt.entry) != nil; got != tt.want {
+			t.Errorf("match(%s %q at %s) = %v, want %v", tt.entry.ErrorType, tt.entry.Message, tt.entry.Timestamp, got, tt.want)
+		}
+	}
+
+	// Reopening removes the mark; the latest line wins
+	recordResolutions(dir, []Resolution{{Fingerprint: set.active[1].Fingerprint, Reopened: true}})
+	set.refresh()
+	if len(set.active) != 1 || set.active[0].ErrorType != "DATABASE_ERROR" {
+		t.Errorf("after reopening, active = %+v", set.active)
+	}
+}
+
+func TestReadResolutions_LongAndMalformedLines(t *testing.T) {
+	now := time.Now()
+	dir := writeResolveLog(t, now)
+	resolveAt(t, dir, now.Add(-time.Hour), fingerprint("DATABASE_ERROR", "connection refused on port <n>"))
+
+	// A long pattern is past bufio.Scanner's 64 KiB default
+	long := Resolution{Fingerprint: "longpattern0", ErrorType: "RENDER_ERROR", Pattern: strings.Repeat("x", 100<<10), ResolvedAt: now.UTC().Format(time.RFC3339Nano)}
+	data, _ := json.Marshal(long)
+	f, _ := os.OpenFile(resolvedPath(dir), os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("{not json\n" + string(data) + "\n")
+	f.Close()
+	resolveAt(t, dir, now.Add(-time.Hour), fingerprint("UNCAUGHT_ERROR", "x is undefined"))
+
+	records, err := readResolutions(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[1].Fingerprint != "longpattern0" || records[2].ErrorType != "UNCAUGHT_ERROR" {
+		t.Errorf("the malformed line should be skipped and the rest read, got %d records", len(records))
+	}
+}
+
+func TestResolveCommand(t *testing.T) {
+	dir := writeResolveLog(t, time.Now())
+	originalPath, originalJSON := pathOverride, jsonOutput
+	defer func() { pathOverride, jsonOutput = originalPath, originalJSON; resetResolveFlags() }()
+	pathOverride, jsonOutput = dir, false
+	resetResolveFlags()
+
+	entries, _ := readErrors(dir)
+	buf := new(bytes.Buffer)
+	resolveCmd.SetOut(buf)
+	defer resolveCmd.SetOut(nil)
+	if err := runResolve(resolveCmd, []string{entries[2].ID[:6]}); err != nil {
+		t.Fatalf("runResolve() error = %v", err)
+	}
+	fp := fingerprint("UNCAUGHT_ERROR", "x is undefined")
+	if !strings.Contains(buf.String(), "Resolved "+fp+" UNCAUGHT_ERROR: x is undefined\n") {
+		t.Errorf("output = %q", buf.String())
+	}
+	records, err := readResolutions(dir)
+	if err != nil || len(records) != 1 || records[0].Fingerprint != fp || records[0].Pattern != "x is undefined" {
+		t.Errorf("resolved.jsonl = %+v (%v)", records, err)
+	}
+
+	resolveList, jsonOutput = true, true
+	buf.Reset()
+	if err := runResolve(resolveCmd, nil); err != nil {
+		t.Fatalf("runResolve(--list) error = %v", err)
+	}
+	var statuses []ResolutionStatus
+	if err := json.Unmarshal(buf.Bytes(), &statuses); err != nil || len(statuses) != 1 || statuses[0].Regressed {
+		t.Errorf("--list = %s (%v)", buf.String(), err)
+	}
+
+	resolveList, resolveUndo, jsonOutput = false, true, false
+	buf.Reset()
+	if err := runResolve(resolveCmd, []string{fp[:4]}); err != nil {
+		t.Fatalf("runResolve(--undo) error = %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "Reopened "+fp) || len(loadResolutions(dir).active) != 0 {
+		t.Errorf("--undo should reopen the group, got %q", buf.String())
+	}
+
+	resolveUndo = false
+	if err := runResolve(resolveCmd, []string{"zzzz"}); ExitCode(err) != ExitError {
+		t.Errorf("an unknown ref should exit 2, got %v", err)
+	}
+}
+
+func TestRenderErrors_Regression(t *testing.T) {
+	now := time.Now()
+	dir := writeResolveLog(t, now)
+	resolveAt(t, dir, now.Add(-time.Hour), fingerprint("DATABASE_ERROR", "connection refused on port <n>"))
+	defer func() { errorsLimit, errorsGroup, jsonOutput = 10, false, false }()
+	errorsLimit, errorsSource, errorsType, errorsSince = 10, "", "", ""
+	errorsCount, errorsGroup, errorsPick, errorsFields, errorsTemplate, jsonOutput = false, false, false, "", "", false
+
+	var buf bytes.Buffer
+	if err := renderErrors(&buf, dir); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(buf.String(), "REGRESSION: ") != 1 || !strings.Contains(buf.String(), "REGRESSION: connection refused on port 5433\n  Resolved: 1h ago (group ") {
+		t.Errorf("only the entry after the resolution is a regression:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	errorsGroup, jsonOutput = true, true
+	if err := renderErrors(&buf, dir); err != nil {
+		t.Fatal(err)
+	}
+	var groups []ErrorGroup
+	if err := json.Unmarshal(buf.Bytes(), &groups); err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range groups {
+		if (g.Regression != nil) != (g.ErrorType == "DATABASE_ERROR") {
+			t.Errorf("group %s regression = %+v", g.ErrorType, g.Regression)
+		}
+	}
+
+	// The JSON list and the NDJSON stream carry the same mark
+	regressed := func(format string, entries []ErrorEntry) {
+		t.Helper()
+		marked := 0
+		for _, e := range entries {
+			if e.Regression != nil {
+				marked++
+				if e.Message != "connection refused on port 5433" {
+					t.Errorf("%s: %q shouldn't be a regression", format, e.Message)
+				}
+			}
+		}
+		if marked != 1 {
+			t.Errorf("%s: %d entries marked as regressions, want 1", format, marked)
+		}
+	}
+	buf.Reset()
+	errorsGroup = false
+	if err := renderErrors(&buf, dir); err != nil {
+		t.Fatal(err)
+	}
+	var list []ErrorEntry
+	if err := json.Unmarshal(buf.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	regressed("--json", list)
+
+	buf.Reset()
+	jsonOutput, errorsOutput = false, "ndjson"
+	defer func() { errorsOutput = "" }()
+	if err := renderErrors(&buf, dir); err != nil {
+		t.Fatal(err)
+	}
+	var stream []ErrorEntry
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e ErrorEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("bad NDJSON line %q: %v", line, err)
+		}
+		stream = append(stream, e)
+	}
+	regressed("--output ndjson", stream)
+}
+
+func TestFormatTailEntry_Regression(t *testing.T) {
+	entry := ErrorEntry{
+		Timestamp:  "2025-12-10T19:19:32.941Z",
+		Source:     "backend",
+		ErrorType:  "DATABASE_ERROR",
+		Message:    "connection refused",
+		Regression: &Regression{Fingerprint: "8c1d2e3f4a5b", ResolvedAt: "2025-12-08T10:00:00Z"},
+	}
+	defer func() { absoluteTime = false }()
+	absoluteTime = true
+	output := formatTailEntry(entry, false)
+	if !strings.Contains(output, "] REGRESSION: connection refused\n") || !strings.Contains(output, "  Resolved: 2025-12-08T10:00:00Z (group 8c1d2e3f4a5b)\n") {
+		t.Errorf("output = %q", output)
+	}
+	withoutMark := entry
+	withoutMark.Regression = nil
+	if entryID(entry) != entryID(withoutMark) {
+		t.Error("the regression mark shouldn't change the entry's ID")
+	}
+}
+
+func TestPrimeSummary_LeavesOutResolved(t *testing.T) {
+	now := time.Now()
+	dir := writeResolveLog(t, now)
+	resolveAt(t, dir, now.Add(-time.Hour), fingerprint("DATABASE_ERROR", "connection refused on port <n>"), fingerprint("UNCAUGHT_ERROR", "x is undefined"))
+
+	// The undefined error was fixed for good; the database error came back
+	if full := primeInDir(t, dir, true); full.TotalErrors != 1 {
+		t.Errorf("a full read should count only the regression, got %d", full.TotalErrors)
+	}
+	summary := primeInDir(t, dir, false)
+	if summary.TotalErrors != 1 || len(summary.TopErrorTypes) != 1 || summary.TopErrorTypes[0].ErrorType != "DATABASE_ERROR" {
+		t.Errorf("only the regression should be counted, got %d errors, types %+v", summary.TotalErrors, summary.TopErrorTypes)
+	}
+	if len(summary.Regressions) != 1 || summary.Regressions[0].ErrorType != "DATABASE_ERROR" {
+		t.Errorf("Regressions = %+v", summary.Regressions)
+	}
+	out := formatPrimeSummaryHuman(summary)
+	if strings.Contains(out, "UNCAUGHT_ERROR") || strings.Contains(out, "frontend") {
+		t.Errorf("the resolved error shouldn't be in the output:\n%s", out)
+	}
+	if !strings.Contains(out, "REGRESSION: DATABASE_ERROR") {
+		t.Errorf("the regression should be:\n%s", out)
+	}
+
+	// Resolving the regression too leaves nothing open
+	resolveAt(t, dir, now, fingerprint("DATABASE_ERROR", "connection refused on port <n>"))
+	if summary := primeInDir(t, dir, false); summary.TotalErrors != 0 {
+		t.Errorf("expected no open errors, got %+v", summary)
+	}
+}
+
+func TestPrimeSummary_LeavesOutIgnored(t *testing.T) {
+	// Snoozing is how a group is ignored for now; the database error's
+	// snooze is over, the undefined error's lasts another hour
+	now := time.Now()
+	dir := writeSnoozeLog(t, now)
+
+	for _, noCache := range []bool{true, false} {
+		summary := primeInDir(t, dir, noCache)
+		if summary.TotalErrors != 3 || len(summary.TopErrorTypes) != 1 || summary.TopErrorTypes[0].ErrorType != "DATABASE_ERROR" {
+			t.Errorf("noCache %v: the ignored error shouldn't be counted, got %d errors, types %+v", noCache, summary.TotalErrors, summary.TopErrorTypes)
+		}
+		if strings.Contains(formatPrimeSummaryHuman(summary), "x is undefined") {
+			t.Errorf("noCache %v: the ignored error shouldn't be in the output", noCache)
+		}
+	}
+}
+
+func TestPrimeSummary_Regression(t *testing.T) {
+	now := time.Now()
+	dir := writeResolveLog(t, now)
+	resolveAt(t, dir, now.Add(-time.Hour), fingerprint("DATABASE_ERROR", "connection refused on port <n>"))
+
+	summary := primeInDir(t, dir, false)
+	if len(summary.Regressions) != 1 || summary.Regressions[0].ErrorType != "DATABASE_ERROR" {
+		t.Fatalf("Regressions = %+v", summary.Regressions)
+	}
+	out := formatPrimeSummaryHuman(summary)
+	lines := strings.Split(out, "\n")
+	if !strings.HasPrefix(lines[1], `  REGRESSION: DATABASE_ERROR "connection refused on port <n>" is back (resolved 1h ago, last seen 10m ago)`) {
+		t.Errorf("the regression should come right after the counts:\n%s", out)
+	}
+	if !strings.HasPrefix(summary.ActionableTip, "Start with the regression: DATABASE_ERROR") {
+		t.Errorf("tip = %q", summary.ActionableTip)
+	}
+	if claude := formatPrimeClaude(summary); !strings.Contains(claude, "REGRESSION: DATABASE_ERROR") || !strings.Contains(claude, "the earlier fix didn't hold") {
+		t.Errorf("the claude preset should flag the regression:\n%s", claude)
+	}
+}
@@ -2,11 +2,16 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/agentlog/agentlog/internal/diag"
+	"github.com/agentlog/agentlog/internal/self"
 	"github.com/spf13/cobra"
 )
 
@@ -15,6 +20,12 @@ var (
 	jsonOutput   bool
 	aiHelp       bool
 	pathOverride string
+	absoluteTime bool
+	maxEntries   int
+	quiet        bool
+	verbose      bool
+	noColor      bool
+	commandStart time.Time // when the command began, for --verbose timing
 )
 
 // CommandMetadata provides machine-readable command information for AI agents
@@ -24,6 +35,9 @@ type CommandMetadata struct {
 	Description string            `json:"description"`
 	Commands    []CommandInfo     `json:"commands"`
 	GlobalFlags map[string]string `json:"global_flags"`
+	ExitCodes   map[string]string `json:"exit_codes"`
+	Plugins     []PluginInfo      `json:"plugins,omitempty"` // agentlog-* executables on PATH
+	PluginEnv   map[string]string `json:"plugin_env"`
 }
 
 // CommandInfo describes a single command
@@ -32,6 +46,42 @@ type CommandInfo struct {
 	Description string            `json:"description"`
 	Usage       string            `json:"usage"`
 	Flags       map[string]string `json:"flags,omitempty"`
+	ExitCodes   map[string]string `json:"exit_codes,omitempty"`
+}
+
+// ExitCodeError ends a command with Code. The command has already reported
+// why, so nothing more is printed, except that with --json a set Err is
+// written to stderr as a JSONError.
+type ExitCodeError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitCodeError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+func (e *ExitCodeError) Unwrap() error { return e.Err }
+
+// exitWith silences cobra's error and usage output for cmd and returns an
+// ExitCodeError
+func exitWith(cmd *cobra.Command, code int) error {
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	return &ExitCodeError{Code: code}
+}
+
+// ExitCode is the process exit code for an error returned by Execute: the
+// code of an ExitCodeError, otherwise ExitError
+func ExitCode(err error) int {
+	var exitErr *ExitCodeError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return ExitError
 }
 
 // rootCmd represents the base command when called without any subcommands
@@ -55,12 +105,24 @@ Quick start:
   agentlog errors     View recent errors
   agentlog tail       Watch errors in real-time
   agentlog prime      Output context summary for AI agents`,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		// Handle --ai-help before running any command
 		if aiHelp {
 			printAIHelp()
 			os.Exit(0)
 		}
+		if quiet && verbose {
+			return invalidInput("--quiet and --verbose can't be combined")
+		}
+		diag.SetLevel(verbosity())
+		diag.SetColor(colorEnabled(os.Stderr))
+		colors = theme{on: !IsJSONOutput() && colorEnabled(os.Stdout)}
+		commandStart = time.Now()
+		diag.Debugf("agentlog %s (%s)", cmd.CommandPath(), strings.Join(args, " "))
+		return nil
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		diag.Debugf("%s finished in %s", cmd.CommandPath(), time.Since(commandStart).Round(time.Millisecond))
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		// Default behavior: show help
@@ -70,7 +132,27 @@ Quick start:
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() error {
-	return rootCmd.Execute()
+	return execute(os.Args[1:], os.Stderr)
+}
+
+// execute runs the command args name. With --json, a failure is written to
+// stderr as a JSONError instead of cobra's "Error: ..." and usage text;
+// an ExitCodeError's command has already reported its outcome, unless it
+// carries an Err.
+func execute(args []string, stderr io.Writer) error {
+	if path, globals, rest, ok := findPluginCall(args); ok {
+		return runPlugin(path, globals, rest, os.Stdout, stderr)
+	}
+	jsonErrors := jsonRequested(args)
+	rootCmd.SilenceErrors, rootCmd.SilenceUsage = jsonErrors, jsonErrors
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	self.Flush()
+	var exitErr *ExitCodeError
+	if err != nil && jsonErrors && (!errors.As(err, &exitErr) || exitErr.Err != nil) {
+		writeJSONError(stderr, err)
+	}
+	return err
 }
 
 func init() {
@@ -78,6 +160,14 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "Output in JSON format for programmatic use")
 	rootCmd.PersistentFlags().BoolVar(&aiHelp, "ai-help", false, "Output machine-readable command metadata")
 	rootCmd.PersistentFlags().StringVar(&pathOverride, "path", "", "Override project path (for monorepo/subdir support)")
+	rootCmd.PersistentFlags().BoolVar(&absoluteTime, "absolute", false, "Show full timestamps instead of relative times (\"3m ago\")")
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return &CommandError{Code: "INVALID_FLAG", Err: err}
+	})
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Suppress warnings and status messages on stderr (e.g. malformed-line warnings)")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Also print debug detail on stderr: files read, offsets, and timings")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Don't color human output (also off when NO_COLOR is set or output isn't a terminal)")
+	rootCmd.PersistentFlags().IntVar(&maxEntries, "max-entries", 0, "Most entries to hold in memory, keeping the latest (default: max_entries from config, or 500000)")
 }
 
 // IsJSONOutput returns whether JSON output is enabled
@@ -85,6 +175,27 @@ func IsJSONOutput() bool {
 	return jsonOutput
 }
 
+// IsAbsoluteTime returns whether human output should show full timestamps
+func IsAbsoluteTime() bool {
+	return absoluteTime
+}
+
+// verbosity is the diagnostics level --quiet or --verbose asks for
+func verbosity() diag.Level {
+	switch {
+	case quiet:
+		return diag.Quiet
+	case verbose:
+		return diag.Verbose
+	}
+	return diag.Normal
+}
+
+// GetMaxEntries returns the --max-entries cap, or 0 if it wasn't given
+func GetMaxEntries() int {
+	return maxEntries
+}
+
 // GetPathOverride returns the path override if set, empty string otherwise
 func GetPathOverride() string {
 	return pathOverride
@@ -113,44 +224,407 @@ func printAIHelpTo(w io.Writer) {
 		Version:     "0.1.0",
 		Description: "AI-native development observability CLI - error visibility for agents in any stack",
 		GlobalFlags: map[string]string{
-			"--json":    "Output in JSON format for programmatic use",
-			"--ai-help": "Output this machine-readable command metadata",
-			"--path":    "Override project path (for monorepo/subdir support)",
+			"--json":        "Output in JSON format for programmatic use; failures are written to stderr as {\"error\": {\"code\", \"message\", \"exit_code\"}} (codes: INVALID_FLAG, INVALID_INPUT, UNKNOWN_COMMAND, NOT_INITIALIZED, FILE_NOT_FOUND, PERMISSION_DENIED, PARSE_ERROR, COMMAND_ERROR)",
+			"--ai-help":     "Output this machine-readable command metadata",
+			"--path":        "Override project path (for monorepo/subdir support)",
+			"--absolute":    "Show full RFC3339 timestamps in human output instead of relative times like \"3m ago\" (JSON output is always absolute)",
+			"--quiet":       "Suppress warnings and status messages on stderr, such as malformed-line warnings; output and failures are unaffected",
+			"--verbose":     "Also print debug detail on stderr (lines starting \"debug: \"): files read, offsets resumed from, timings",
+			"--no-color":    "Don't color human output; color is also off when NO_COLOR is set, TERM is dumb, or output isn't a terminal, and JSON is never colored",
+			"--max-entries": "Most entries commands hold in memory; past it the oldest are dropped with a warning on stderr (default: max_entries from config, or 500000)",
+		},
+		ExitCodes: exitCodes(),
+		PluginEnv: map[string]string{
+			envPluginPath:       "Absolute project directory (--path, or the working directory)",
+			envPluginErrorsFile: "The project's .agentlog/errors.jsonl",
+			envPluginBin:        "Path of the agentlog that ran the plugin",
+			envPluginJSON:       "\"1\" when --json was given",
+			envPluginQuiet:      "\"1\" when --quiet was given",
+			envPluginVerbose:    "\"1\" when --verbose was given",
+			envPluginNoColor:    "\"1\" when --no-color was given",
+			envPluginAbsolute:   "\"1\" when --absolute was given",
+			envPluginMaxEntries: "The --max-entries value, when given",
 		},
 		Commands: []CommandInfo{
 			{
 				Name:        "init",
 				Description: "Initialize agentlog in your project, detect stack, create config",
 				Usage:       "agentlog init [flags]",
+				Flags: map[string]string{
+					"--stack":           "Override stack detection (typescript, go, python, rust, ruby, ruby-script, react-native); Ruby projects without Rails get ruby-script. Repeatable: each stack gets its snippet (JSON: stacks[])",
+					"--install":         "Install snippets directly to project files",
+					"--capture-console": "Browser snippets also report console.error/warn as CONSOLE_ERROR/CONSOLE_WARN (each message once a minute, at most 10 a minute)",
+					"--capture-network": "Browser snippets also report failed fetch/XHR requests (network errors, 5xx) as NETWORK_ERROR with method, url, and status in context",
+					"--integration":     "Add a framework error hook (" + strings.Join(integrationNames(), ", ") + "); detected frameworks are added automatically (repeatable)",
+					"--dry-run":         "Show the files init would create, append to, or edit, with diffs, without changing anything (--json for a plan)",
+					"--gitignore-all":   "Ignore all of .agentlog/ (.agentlog/*) in .gitignore instead of just errors.jsonl; saved as gitignore_all in config, which doctor --fix follows too",
+					"--docker":          "Detect compose.yaml/docker-compose.yml and devcontainer.json; generate the .agentlog/ bind mount and AGENTLOG_PROJECT (AGENTLOG_URL and host-gateway with --remote) per built service; --install writes <compose>.override.yml (JSON: docker)",
+					"--remote":          "Snippets post entries to this 'agentlog serve' URL (a bare host gets /__agentlog) instead of writing errors.jsonl; Node/Go/Python/Ruby read AGENTLOG_URL first; Rust needs http; saved as init.remote in config (JSON: remote)",
+					"--interactive":     "Prompt for stack, install vs print, browser captures, framework/worker hooks, and file vs serve transport; saves the answers to config (not with --json)",
+				},
 			},
 			{
 				Name:        "errors",
-				Description: "Query and display errors from .agentlog/errors.jsonl",
+				Description: "Query and display errors from .agentlog/errors.jsonl. Secrets and personal data (tokens, API keys, emails, card numbers) are masked in the output; filters match the stored text. config.json \"redact\": {\"patterns\": [...]} adds patterns, {\"disabled\": true} turns masking off. \"context\": {\"deny\": [\"headers\", \"request.body\"]} drops context keys (any depth, or dotted paths), {\"allow\": [...]} keeps only those top-level keys",
 				Usage:       "agentlog errors [flags]",
 				Flags: map[string]string{
-					"--limit":  "Maximum number of errors to show (default: 10)",
-					"--source": "Filter by source (frontend, backend, cli, worker, test)",
-					"--type":   "Filter by error type",
-					"--since":  "Show errors since time (e.g., '1h', '30m', '2024-01-01')",
+					"--limit":        "Maximum number of errors to show (default: 10)",
+					"--source":       "Filter by source (frontend, backend, cli, worker, test)",
+					"--type":         "Filter by error type",
+					"--since":        "Show errors since time (e.g., '1h', '2d', '1w', 'yesterday', '2024-01-01 14:00')",
+					"--group":        "Group similar errors (numbers, IDs, and paths ignored), most frequent first",
+					"--file":         "Filter by file (substring match)",
+					"--endpoint":     "Filter by endpoint (substring match)",
+					"--project":      "Filter by project (entries without one belong to this directory's project)",
+					"--env":          "Filter by environment (dev, test, preview, staging)",
+					"--host":         "Filter by the machine that recorded the error",
+					"--user":         "Filter by the user who recorded the error",
+					"--agent":        "Filter by the AI agent or session that produced the error (\"claude\" matches every claude:<session>)",
+					"--test-run":     "Filter by test run (context.test_run_id, as set by 'agentlog capture --test-run')",
+					"--kind":         "Filter by entry kind: error, or perf for slow operations (default: both)",
+					"--where":        "Filter on a field or context key, repeatable and all must match: key=value (exact) or key~value (substring), e.g. queue=emails, endpoint~/api/",
+					"--query":        "Filter with an expression: comparisons joined by and/or/not and parentheses; ops = != (exact), ~ !~ (substring, or ~/regex/), < <= > >= (numbers; timestamp takes --since values), e.g. 'type=DATABASE_ERROR and (source=backend or source=worker) and message~timeout'",
+					"--id":           "Show entries with this ID or ID prefix (repeatable); IDs are stable content hashes shown in all output",
+					"--count":        "Only print the number of matching errors, ignoring --limit (JSON: {\"count\": N})",
+					"--group-by":     "With --count, count per value of one field (e.g. type, source, tags, context.endpoint)",
+					"--full":         "Show each entry's project, attribution, agent, and context, plus a suggested next step when a rule matches its message (JSON: suggestion {rule, hint}); rules under config.json \"suggestions\" come before the built-in ones; not with --count, --group, --fields, or --pick",
+					"--pick":         "Interactive fuzzy search over matching errors with a JSON preview pane (humans only, needs a terminal); Enter prints the entry as JSON, ctrl-o opens its file in $EDITOR",
+					"--watch":        "Redraw the view (filters and grouping kept) when errors.jsonl changes; for humans, not with --json",
+					"--interval":     "With --watch, redraw at least this often (default: 2s)",
+					"--output":       "Output format: text, json (same as --json), or ndjson: one compact entry per line, streamed as matches are read with --limit 0 (constant memory); not with --group, --count, --fields, --template, --pick, or --watch",
+					"--template":     "Go template rendered per entry, e.g. '{{.ErrorType}}: {{.Message}}' (default: errors.template in config.json)",
+					"--no-formatter": "Use the built-in human output even if config.json sets a formatter command (which reads entries as NDJSON on stdin and prints in their place)",
+					"--fields":       "Comma-separated fields to show, as columns or JSON keys (id, timestamp, source, type, message, file, line, column, endpoint, project, environment, tags, context, context.<key>)",
+					"--tag":          "Only show errors with this tag (repeatable; all must match)",
+					"--exclude-tag":  "Hide errors with this tag (repeatable)",
+				},
+				ExitCodes: map[string]string{
+					"0": "Listed, counted, or streamed the matches, including none",
+					"2": "Invalid flags or an unreadable log",
+					"3": "No .agentlog/ directory; the 'No errors file found' message (or a zero count) is still printed",
+				},
+			},
+			{
+				Name:        "log",
+				Description: "Append one entry to .agentlog/errors.jsonl from the command line; AGENTLOG_TAGS adds default tags. Like serve and ingest, it applies config.json \"sampling\" rules ({error_type, keep_first, per, keep_one_in}): past keep_first entries of a type per window, one in keep_one_in is written, with count set to the entries it stands for",
+				Usage:       "agentlog log [flags] <message>",
+				Flags: map[string]string{
+					"--type":     "Error type to record (default: UNEXPECTED_ERROR)",
+					"--source":   "Source to record (default: cli)",
+					"--tag":      "Tag to attach (repeatable)",
+					"--file":     "File the error relates to",
+					"--line":     "Line number within --file",
+					"--endpoint": "Endpoint the error relates to",
+					"--env":      "Environment to record (default: $AGENTLOG_ENV)",
+					"--agent":    "AI agent or session to record (default: $AGENTLOG_AGENT, or claude:$CLAUDE_SESSION_ID)",
+					"--kind":     "Entry kind: error (default) or perf",
+					"--duration": "How long the operation took (e.g. 850ms, 8.2s), for perf entries",
 				},
 			},
 			{
 				Name:        "tail",
-				Description: "Watch .agentlog/errors.jsonl for new errors in real-time",
+				Description: "Watch .agentlog/errors.jsonl for new errors in real-time, firing config.json alert rules as entries arrive",
 				Usage:       "agentlog tail [flags]",
+				Flags: map[string]string{
+					"--no-formatter": "Use the built-in human output even if config.json sets a formatter command",
+				},
+				ExitCodes: map[string]string{
+					"0": "Stopped with Ctrl-C",
+					"3": "No .agentlog/ directory",
+				},
 			},
 			{
 				Name:        "doctor",
-				Description: "Check agentlog configuration and health",
+				Description: "Check agentlog configuration and health, warning about error storms (config.json \"storms\": {threshold, window}, default 50 within 1m) in the last 24h",
 				Usage:       "agentlog doctor",
+				Flags: map[string]string{
+					"--fix":      "Untrack .agentlog data committed to git and add the .gitignore entry",
+					"--endpoint": "Capture endpoint to post a healthcheck entry to (default: found in the installed captures)",
+					"--offline":  "Skip posting a healthcheck entry to the capture endpoint",
+					"--strict":   "Exit 1 on warnings, 2 on errors, and 3 without .agentlog/, so scripts can branch on health without parsing output",
+				},
+				ExitCodes: map[string]string{
+					"0": "Healthy; without --strict, always 0",
+					"1": "--strict: warnings (status \"warning\")",
+					"2": "--strict: errors (status \"unhealthy\")",
+					"3": "--strict: no .agentlog/ directory",
+				},
+			},
+			{
+				Name:        "upgrade",
+				Description: "Rewrite files init --install created from the current templates; files edited since install are left alone with a diff",
+				Usage:       "agentlog upgrade",
+				Flags: map[string]string{
+					"--force": "Also overwrite files edited since they were installed",
+				},
+				ExitCodes: map[string]string{
+					"0": "Every installed file is up to date",
+					"1": "Outdated files were left alone (edited locally, or installed before template stamps); see their diffs",
+				},
 			},
 			{
 				Name:        "prime",
-				Description: "Output context summary for AI agent injection",
+				Description: "Output context summary for AI agent injection. With config.json \"track_edits\": true, names the files edited (per git) within 10 minutes before an error type first appeared (JSON: recent_edits). Flags errors that keep going away and coming back across sessions as likely flaky (JSON: flaky). Suggests next steps for top groups matching common patterns (JSON: suggestions). Calls out error storms, one error at least storms.threshold times within storms.window (default 50 in 1m), so they're treated as one problem (JSON: storms)",
 				Usage:       "agentlog prime",
+				Flags: map[string]string{
+					"--env":      "Only summarize errors from this environment (dev, test, preview, staging)",
+					"--since":    "Only summarize errors since time (e.g., '2h', '2d', 'yesterday', '2024-01-01 14:00')",
+					"--agent":    "Shape output for a consumer (" + strings.Join(primePresetNames(), ", ") + "); overrides --json",
+					"--delta":    "Only summarize entries appended since the last 'prime --delta' (position in .agentlog/prime-delta.json)",
+					"--no-cache": "Read the whole log instead of updating .agentlog/cache.json (aggregates of already-read entries)",
+				},
+				ExitCodes: map[string]string{
+					"0": "Printed the summary, including when no errors are recorded",
+					"2": "Invalid flags or an unreadable log",
+					"3": "No .agentlog/ directory; the 'not set up' summary (JSON: not_initialized) is still printed",
+				},
+			},
+			{
+				Name:        "stats",
+				Description: "Show error counts by type, source, tag, and agent, the files and endpoints producing the most errors, and errors that look flaky: gone for a session and back again, at least twice (JSON: flaky)",
+				Usage:       "agentlog stats [flags]",
+				Flags: map[string]string{
+					"--since":   "Only count errors since time (e.g., '1h', '2d', '1w', 'yesterday', '2024-01-01 14:00')",
+					"--limit":   "Number of files and endpoints to show (default: 5)",
+					"--kind":    "Entries to count: error (default) or perf",
+					"--bucket":  "Count per time bucket of this size (15m, 1h, 1d); JSON adds series.buckets: [{start (RFC3339 UTC), count, counts}] from the first entry (or --since) to the last (or now), empty buckets included",
+					"--by":      "With --bucket, break each bucket's counts down by a field: type, source, tags, endpoint, context.<key>, ...",
+					"--heatmap": "Count by weekday and hour of day in local time, drawn as a shaded grid; JSON adds heatmap.counts[weekday][hour] (Monday first), timezone, and peak",
+				},
+				ExitCodes: map[string]string{
+					"0": "Printed the counts",
+					"2": "Invalid flags or an unreadable log",
+					"3": "No .agentlog/ directory",
+				},
+			},
+			{
+				Name:        "show",
+				Description: "Show one error in full: all fields and context, its group's occurrence history, and entries sharing its request_id/trace_id/session_id",
+				Usage:       "agentlog show <id|fingerprint>",
+				Flags: map[string]string{
+					"--limit": "Maximum occurrences and related entries to list (default: 10)",
+				},
+				ExitCodes: map[string]string{
+					"0": "Showed the entry",
+					"2": "No entry or group matches, more than one does, or the log is unreadable",
+					"3": "No .agentlog/ directory",
+				},
+			},
+			{
+				Name:        "resolve",
+				Description: "Mark the groups of entries (by ID) or groups (by fingerprint) resolved. A later error that groups with a resolved one is a regression: errors and tail label it REGRESSION (JSON: regression on entries and groups), and prime lists it first (JSON: regressions)",
+				Usage:       "agentlog resolve <id|fingerprint>...",
+				Flags: map[string]string{
+					"--undo":   "Remove the resolved mark from these groups",
+					"--list":   "List resolved groups and whether they came back",
+					"--note":   "Record how the error was fixed (kept in .agentlog/resolutions.jsonl); when it recurs, errors, tail, show, and similar print 'Fixed before:' with it (JSON: past_fixes), and prime lists it (JSON: past_fixes)",
+					"--commit": "Record the commit that fixed it; with no value, HEAD",
+				},
+				ExitCodes: map[string]string{
+					"0": "Marked the groups resolved (or, with --undo, not)",
+					"2": "No group, or more than one, matches an ID or fingerprint, or --commit isn't a commit",
+					"3": "No .agentlog/ directory",
+				},
+			},
+			{
+				Name:        "snooze",
+				Description: "Hide the groups of entries (by ID) or groups (by fingerprint) from errors and prime until a deadline. Entries since the snooze that are still there after it are flagged SNOOZE EXPIRED (JSON: snooze_expired on entries and groups; prime lists them, JSON: snooze_expired)",
+				Usage:       "agentlog snooze <id|fingerprint>... --for <duration>",
+				Flags: map[string]string{
+					"--for":  "How long to hide the errors (e.g. 30m, 4h, 2d, 1w); required unless --list or --undo",
+					"--undo": "End the snooze of these groups now",
+					"--list": "List snoozed groups and how often they occurred since",
+				},
+				ExitCodes: map[string]string{
+					"0": "Snoozed the groups (or, with --undo, unsnoozed them)",
+					"2": "No --for, an invalid one, or no group, or more than one, matches an ID or fingerprint",
+					"3": "No .agentlog/ directory; nothing is recorded",
+				},
+			},
+			{
+				Name:        "digest",
+				Description: "Summarize the last N hours for standup notes: new types, biggest movers, gone quiet, noisiest files/endpoints (Markdown)",
+				Usage:       "agentlog digest [flags]",
+				Flags: map[string]string{
+					"--hours": "Size of the window to summarize, in hours (default: 24)",
+				},
+				ExitCodes: map[string]string{
+					"0": "Printed the digest, including an empty one",
+					"2": "Invalid flags or an unreadable log",
+					"3": "No .agentlog/ directory; the 'No errors file found' message is still printed",
+				},
+			},
+			{
+				Name:        "share",
+				Description: "Package filtered errors, the prime summary, and the doctor report into a sanitized Markdown file or .tar.gz for bug reports; paths are made project-relative, home and hostname masked, context left out. Lists what's included and asks to confirm",
+				Usage:       "agentlog share [flags]",
+				Flags: map[string]string{
+					"--since":           "Only include errors since time",
+					"--type":            "Only include errors of this type",
+					"--source":          "Only include errors from this source",
+					"--query":           "Only include errors matching a filter expression (errors --query syntax)",
+					"--limit":           "Most entries to include, the latest (default: 50)",
+					"--include-context": "Include each entry's context",
+					"--format":          "markdown or archive (default: from --output's extension, else markdown)",
+					"--output":          "File to write, or - for stdout (default: agentlog-share-<time>.md or .tar.gz)",
+					"--yes":             "Write without asking; required when stdin isn't a terminal",
+				},
+				ExitCodes: map[string]string{
+					"0": "Wrote the bundle, or it was declined at the prompt",
+					"2": "Invalid flags, or stdin isn't a terminal and --yes wasn't given",
+					"3": "No .agentlog/ directory",
+				},
+			},
+			{
+				Name:        "similar",
+				Description: "List the error groups most like an entry's or group's, scored 0-1 by shared words (Jaccard, other error types weighted down) or, with config.json \"embeddings\" {model, endpoint, api_key_env}, by embedding cosine similarity; each with its resolution from 'agentlog resolve', including resolved groups no longer in the log (count 0). Vectors are cached in .agentlog/embeddings.json",
+				Usage:       "agentlog similar <id|fingerprint>",
+				Flags: map[string]string{
+					"--limit":     "Most similar groups to list (default: 5)",
+					"--min-score": "Lowest score listed, 0-1 (default: 0.3 for tokens, 0.75 for embeddings)",
+					"--method":    "tokens or embeddings (default: embeddings when configured, else tokens; falls back to tokens if the endpoint fails)",
+				},
+				ExitCodes: map[string]string{
+					"0": "Listed the similar groups, including none",
+					"2": "Invalid flags, no entry or group matches, or the log is unreadable",
+					"3": "No .agentlog/ directory",
+				},
+			},
+			{
+				Name:        "analyze",
+				Description: "Send a sanitized sample of the most frequent recent error groups to the model configured under config.json \"analyze\" {provider: openai|anthropic|local, model, endpoint, api_key_env} and print its prioritized root-cause analysis. Off until a provider is set; the key comes from OPENAI_API_KEY or ANTHROPIC_API_KEY. Secrets, paths, home, and hostname are masked like share",
+				Usage:       "agentlog analyze [flags]",
+				Flags: map[string]string{
+					"--since":           "Only analyze errors since time (default: 24h; empty for the whole log)",
+					"--limit":           "Most error groups to send, the most frequent first (default: 10)",
+					"--include-context": "Send each group's latest context too",
+					"--model":           "Model to use instead of config.json's analyze.model",
+					"--dry-run":         "Print the sample that would be sent, without sending it (JSON: dry_run true, groups)",
+				},
+				ExitCodes: map[string]string{
+					"0": "Printed the analysis, the dry run, or that there were no errors to analyze",
+					"2": "Invalid flags, analyze not configured or missing an API key, or the model request failed",
+					"3": "No .agentlog/ directory",
+				},
+			},
+			{
+				Name:        "dedupe",
+				Description: "Rewrite .agentlog/errors.jsonl collapsing runs of repeated errors (same fingerprint, source, project, environment, host, user, agent, file, endpoint, and kind) into one entry with count, first_seen, and last_seen; perf entries keep the slowest duration_ms",
+				Usage:       "agentlog dedupe [flags]",
+				Flags: map[string]string{
+					"--dry-run": "Report what would be collapsed without rewriting the file",
+				},
+				ExitCodes: map[string]string{
+					"0": "Collapsed the repeats (or, with --dry-run, reported them), including none",
+					"2": "The log couldn't be read or rewritten",
+					"3": "No .agentlog/ directory; the 'No errors file found' message is still printed",
+				},
+			},
+			{
+				Name:        "ingest",
+				Description: "Convert tool output (tsc, eslint, ruff, golangci-lint) into entries in .agentlog/errors.jsonl",
+				Usage:       "agentlog ingest --parser <name> [file]",
+				Flags: map[string]string{
+					"--parser": "Parser to apply (errorlines, journal, tsc, eslint, ruff, golangci, or a regex parser from .agentlog/config.json)",
+					"--source": "Source to record on ingested entries (default: build)",
+				},
+			},
+			{
+				Name:        "capture",
+				Description: "Run a command, pass its output through, and append the lines a line parser matches as entries. With --test-run, entries (including those the command's Node/Python snippets and 'agentlog log' write, via AGENTLOG_TEST_RUN_ID) get context.test_run_id and the run is recorded in .agentlog/test-runs.jsonl",
+				Usage:       "agentlog capture [flags] -- <command> [args...]",
+				Flags: map[string]string{
+					"--parser":   "Line parser to apply to the command's output (default: errorlines)",
+					"--source":   "Source to record on captured entries (default: test)",
+					"--test-run": "Tag entries with this test run ID; a new one (e.g. 20251210-150405-3f2a) when given without a value",
+				},
+				ExitCodes: map[string]string{
+					"0": "The command succeeded; otherwise capture exits with the command's exit code (128+N when killed by signal N)",
+					"2": "The command couldn't be started or the parser name is invalid (or the command itself exited 2)",
+				},
+			},
+			{
+				Name:        "runs",
+				Description: "List test runs (context.test_run_id), latest first, with exit code and error count; with an ID or unique prefix, that run's errors grouped as in errors --group",
+				Usage:       "agentlog runs [id]",
+				Flags: map[string]string{
+					"--limit": "Maximum runs (with an ID, groups) to show (default: 10)",
+				},
+				ExitCodes: map[string]string{
+					"0": "Listed the runs, including when none are recorded",
+					"2": "No run, or more than one, matches the ID",
+					"3": "No .agentlog/ directory",
+				},
+			},
+			{
+				Name:        "docker",
+				Description: "Stream a container's logs into .agentlog/errors.jsonl, tagged with context.container",
+				Usage:       "agentlog docker --container <name> [flags]",
+				Flags: map[string]string{
+					"--container": "Container name or ID to follow (required)",
+					"--parser":    "Line parser to apply (default: errorlines)",
+					"--source":    "Source to record on ingested entries (default: backend)",
+					"--since":     "Also ingest logs since this time (passed to docker logs --since)",
+				},
+			},
+			{
+				Name:        "pm2",
+				Description: "Watch PM2 error logs (~/.pm2/logs/<app>-error.log) and convert new lines into worker entries",
+				Usage:       "agentlog pm2 [app...] [flags]",
+				Flags: map[string]string{
+					"--logs-dir": "PM2 logs directory (default: $PM2_HOME/logs or ~/.pm2/logs)",
+					"--parser":   "Line parser to apply (default: errorlines)",
+					"--source":   "Source to record on ingested entries (default: worker)",
+				},
+			},
+			{
+				Name:        "journal",
+				Description: "Follow systemd journal records (priority err and above) for units into .agentlog/errors.jsonl",
+				Usage:       "agentlog journal --unit <svc> [flags]",
+				Flags: map[string]string{
+					"--unit":   "Systemd unit to follow (repeatable, required)",
+					"--user":   "Follow user units (journalctl --user)",
+					"--source": "Source to record on ingested entries (default: backend)",
+					"--since":  "Also ingest records since this time (passed to journalctl --since)",
+				},
+			},
+			{
+				Name:        "k8s",
+				Description: "Stream Kubernetes pod logs by label selector into .agentlog/errors.jsonl, tagged with pod and namespace",
+				Usage:       "agentlog k8s --selector <labels> [flags]",
+				Flags: map[string]string{
+					"--selector":         "Label selector for pods to follow (required)",
+					"--namespace":        "Namespace (default: the current kubecontext's namespace)",
+					"--parser":           "Line parser to apply (default: errorlines)",
+					"--source":           "Source to record on ingested entries (default: backend)",
+					"--since":            "Also ingest logs newer than this duration (passed to kubectl logs --since)",
+					"--max-log-requests": "Maximum number of containers to follow (default: 10)",
+				},
+			},
+			{
+				Name:        "serve",
+				Description: "Run an HTTP endpoint (POST /__agentlog) that appends posted entries to .agentlog/errors.jsonl, with CORS for dev origins; GET /stream pushes new entries as server-sent events; posted entries are checked against config.json alert rules ({name, query, threshold, window, webhook, command}), which fire when more than threshold matching entries arrive within window; writes an ERROR_STORM entry when one error reaches the config.json storm threshold",
+				Usage:       "agentlog serve [flags]",
+				Flags: map[string]string{
+					"--addr":          "Address to listen on (default: 127.0.0.1:7654)",
+					"--allow-origin":  "Allowed CORS origin (repeatable; default: localhost on any port)",
+					"--token":         "Bearer token required on requests (default: serve.token from config)",
+					"--rate-limit":    "Entries per second accepted from each client, 0 disables (default: 20)",
+					"--burst":         "Entries a client may send at once before --rate-limit applies (default: 50)",
+					"--fsync":         "Fsync errors.jsonl before answering each request so acknowledged entries survive a crash (default: fsync from config; AGENTLOG_FSYNC=1 also enables it)",
+					"--repeat-window": "Identical entries arriving within this long of one written are held and written as one entry with count, first_seen, last_seen, e.g. 10s; 0 writes every one (default: serve.repeat_window from config, else 5s)",
+				},
 			},
 		},
 	}
+	metadata.Plugins = discoverPlugins(func(name string) bool {
+		for _, c := range metadata.Commands {
+			if c.Name == name {
+				return true
+			}
+		}
+		return name == "help" || name == "completion"
+	})
 
 	output, _ := json.MarshalIndent(metadata, "", "  ")
 	fmt.Fprintln(w, string(output))
@@ -3,8 +3,15 @@ package cmd
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
+
+	"github.com/agentlog/agentlog/internal/diag"
 )
 
 func TestRootCommand_Help(t *testing.T) {
@@ -218,3 +225,94 @@ func TestPathFlagInGlobalFlags(t *testing.T) {
 		t.Error("global_flags should include --path")
 	}
 }
+
+func TestExitCode(t *testing.T) {
+	if got := ExitCode(&ExitCodeError{Code: 2}); got != 2 {
+		t.Errorf("ExitCode(ExitCodeError{2}) = %d, want 2", got)
+	}
+	if got := ExitCode(fmt.Errorf("wrapped: %w", &ExitCodeError{Code: 3})); got != 3 {
+		t.Errorf("ExitCode(wrapped) = %d, want 3", got)
+	}
+	if got := ExitCode(errors.New("other")); got != ExitError {
+		t.Errorf("ExitCode(other) = %d, want %d", got, ExitError)
+	}
+}
+
+func TestAIHelp_DoctorExitCodes(t *testing.T) {
+	buf := new(bytes.Buffer)
+	printAIHelpTo(buf)
+
+	var parsed CommandMetadata
+	if err := json.Unmarshal(buf.Bytes(), &parsed); err != nil {
+		t.Fatalf("failed to parse output: %v", err)
+	}
+	for _, c := range parsed.Commands {
+		if c.Name == "doctor" {
+			for _, code := range []string{"0", "1", "2", "3"} {
+				if c.ExitCodes[code] == "" {
+					t.Errorf("doctor should document exit code %s", code)
+				}
+			}
+			return
+		}
+	}
+	t.Fatal("doctor command not found")
+}
+
+func TestAIHelp_ExitCodes(t *testing.T) {
+	buf := new(bytes.Buffer)
+	printAIHelpTo(buf)
+
+	var parsed CommandMetadata
+	if err := json.Unmarshal(buf.Bytes(), &parsed); err != nil {
+		t.Fatalf("failed to parse output: %v", err)
+	}
+	for _, code := range []int{ExitOK, ExitWarnings, ExitError, ExitNotInitialized} {
+		if parsed.ExitCodes[strconv.Itoa(code)] == "" {
+			t.Errorf("exit_codes should document %d, got %v", code, parsed.ExitCodes)
+		}
+	}
+	for _, c := range parsed.Commands {
+		switch c.Name {
+		case "errors", "prime", "stats", "tail", "show", "digest", "dedupe", "resolve", "similar", "snooze":
+			if c.ExitCodes["3"] == "" {
+				t.Errorf("%s should document exit code 3", c.Name)
+			}
+		}
+	}
+}
+
+func TestVerbosityFlags(t *testing.T) {
+	defer func() { quiet, verbose, pathOverride, jsonOutput = false, false, "", false }()
+	defer diag.SetLevel(diag.Normal)
+	buf := new(bytes.Buffer)
+	defer diag.SetOutput(diag.SetOutput(buf))
+
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, ".agentlog"), 0755)
+	os.WriteFile(GetErrorsPath(dir), []byte("not json\n"+`{"timestamp":"2024-01-01T00:00:00Z","source":"cli","error_type":"E","message":"m"}`+"\n"), 0644)
+	errorsCmd.SetOut(new(bytes.Buffer))
+
+	run := func(args ...string) string {
+		quiet, verbose = false, false
+		buf.Reset()
+		if err := execute(append([]string{"errors", "--path", dir}, args...), new(bytes.Buffer)); err != nil {
+			t.Fatalf("errors %v failed: %v", args, err)
+		}
+		return buf.String()
+	}
+	if out := run(); !strings.Contains(out, "Warning: skipping malformed line 1") || strings.Contains(out, "debug:") {
+		t.Errorf("default should warn without debug detail, got %q", out)
+	}
+	if out := run("--quiet"); out != "" {
+		t.Errorf("--quiet should suppress warnings, got %q", out)
+	}
+	if out := run("--verbose"); !strings.Contains(out, "Warning: skipping malformed") || !strings.Contains(out, "debug: reading "+GetErrorsPath(dir)) || !strings.Contains(out, "finished in") {
+		t.Errorf("--verbose should add debug detail, got %q", out)
+	}
+
+	err := execute([]string{"errors", "--path", dir, "-q", "-v"}, new(bytes.Buffer))
+	if errorCode(err) != "INVALID_INPUT" {
+		t.Errorf("--quiet with --verbose = %v, want INVALID_INPUT", err)
+	}
+}
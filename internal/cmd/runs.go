@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/self"
+	"github.com/spf13/cobra"
+)
+
+var runsLimit int
+
+// TestRunSummary is a test run with the errors recorded during it. Runs
+// only known from entries' test_run_id have their ID, Errors, and a
+// Started taken from the earliest entry.
+type TestRunSummary struct {
+	TestRun
+	Recorded bool         `json:"recorded"` // run by 'agentlog capture --test-run'
+	Errors   int          `json:"errors"`
+	Groups   []ErrorGroup `json:"groups,omitempty"` // with a run ID: its errors, grouped
+}
+
+// runsCmd represents the runs command
+var runsCmd = &cobra.Command{
+	Use:   "runs [id]",
+	Short: "List test runs, or group the errors from one",
+	Long: `List test runs, latest first, with how each ended and how many errors it
+produced, or with an ID (or a unique prefix), every error recorded during
+that run grouped as in errors --group.
+
+A run is the set of entries whose context.test_run_id matches. 'agentlog
+capture --test-run -- <command>' sets one, recorded with its command and
+exit code in .agentlog/test-runs.jsonl; writers can also set the key
+themselves, e.g. from a CI job ID.
+
+Examples:
+  agentlog runs
+  agentlog runs 20251210-150405-3f2a
+  agentlog runs 2025121 --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRuns,
+}
+
+func init() {
+	rootCmd.AddCommand(runsCmd)
+
+	runsCmd.Flags().IntVar(&runsLimit, "limit", 10, "Maximum number of runs (with an ID, groups) to show")
+}
+
+func runRuns(cmd *cobra.Command, args []string) error {
+	// Determine base directory (use --path override or cwd)
+	baseDir := GetPathOverride()
+	if baseDir == "" {
+		var err error
+		baseDir, err = os.Getwd()
+		if err != nil {
+			self.LogError(".", "GETWD_ERROR", err.Error())
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+
+	recorded, err := readTestRuns(baseDir)
+	if err != nil && !os.IsNotExist(err) {
+		self.LogError(baseDir, "FILE_READ_ERROR", err.Error())
+		return err
+	}
+	entries, err := readErrors(baseDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	entries = entryPolicyFor(baseDir).applyAll(entries)
+	runs := collectTestRuns(recorded, entries)
+	w := cmd.OutOrStdout()
+
+	if len(args) == 0 {
+		total := len(runs)
+		if runsLimit > 0 && len(runs) > runsLimit {
+			runs = runs[:runsLimit]
+		}
+		if IsJSONOutput() {
+			if runs == nil {
+				runs = []TestRunSummary{}
+			}
+			output, _ := json.MarshalIndent(runs, "", "  ")
+			fmt.Fprintln(w, string(output))
+			return nil
+		}
+		fmt.Fprint(w, formatTestRunsHuman(runs, total, time.Now()))
+		if total == 0 && uninitialized(baseDir) {
+			return exitNotInitialized(cmd)
+		}
+		return nil
+	}
+
+	run, err := findTestRun(runs, args[0])
+	if err != nil {
+		self.LogError(baseDir, "INVALID_INPUT", err.Error())
+		return invalidInput("%w", err)
+	}
+	run.Groups = groupErrors(testRunEntries(entries, run.ID))
+	total := len(run.Groups)
+	if runsLimit > 0 && len(run.Groups) > runsLimit {
+		run.Groups = run.Groups[:runsLimit]
+	}
+	if IsJSONOutput() {
+		output, _ := json.MarshalIndent(run, "", "  ")
+		fmt.Fprintln(w, string(output))
+		return nil
+	}
+	fmt.Fprint(w, formatTestRunHuman(run, total, time.Now()))
+	return nil
+}
+
+// collectTestRuns merges recorded runs with the test_run_ids of entries,
+// latest first. A run ID recorded twice keeps its latest record.
+func collectTestRuns(recorded []TestRun, entries []ErrorEntry) []TestRunSummary {
+	byID := make(map[string]*TestRunSummary)
+	var order []string
+	get := func(id string) *TestRunSummary {
+		if run := byID[id]; run != nil {
+			return run
+		}
+		byID[id] = &TestRunSummary{TestRun: TestRun{ID: id}}
+		order = append(order, id)
+		return byID[id]
+	}
+
+	for _, r := range recorded {
+		run := get(r.ID)
+		run.TestRun, run.Recorded = r, true
+	}
+	for _, e := range entries {
+		id := entryTestRun(e)
+		if id == "" || e.kind() != kindError {
+			continue
+		}
+		run := get(id)
+		run.Errors += e.occurrences()
+		if !run.Recorded && (run.Started == "" || timestampBefore(e.firstSeen(), run.Started)) {
+			run.Started = e.firstSeen()
+		}
+	}
+
+	runs := make([]TestRunSummary, 0, len(order))
+	for _, id := range order {
+		runs = append(runs, *byID[id])
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return timestampBefore(runs[j].Started, runs[i].Started) })
+	return runs
+}
+
+// findTestRun returns the run whose ID is ref, or the only one it prefixes
+func findTestRun(runs []TestRunSummary, ref string) (TestRunSummary, error) {
+	ref = strings.TrimSpace(ref)
+	var matches []TestRunSummary
+	for _, run := range runs {
+		if run.ID == ref {
+			return run, nil
+		}
+		if strings.HasPrefix(run.ID, ref) {
+			matches = append(matches, run)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return TestRunSummary{}, fmt.Errorf("no test run matches %q (see 'agentlog runs')", ref)
+	case 1:
+		return matches[0], nil
+	}
+	return TestRunSummary{}, fmt.Errorf("%q matches %d test runs; give more of the ID", ref, len(matches))
+}
+
+// testRunEntries keeps the error entries tagged with the test run id
+func testRunEntries(entries []ErrorEntry, id string) []ErrorEntry {
+	var matched []ErrorEntry
+	for _, e := range entries {
+		if entryTestRun(e) == id && e.kind() == kindError {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
+
+// filterTestRun keeps entries recorded during the test run id
+func filterTestRun(entries []ErrorEntry, id string) []ErrorEntry {
+	if id == "" {
+		return entries
+	}
+
+	var filtered []ErrorEntry
+	for _, e := range entries {
+		if entryTestRun(e) == id {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// testRunOutcome describes how a run ended and how long it took, e.g.
+// "exit 1, took 41s", or "not captured" for runs only known from entries
+func testRunOutcome(run TestRunSummary) string {
+	if !run.Recorded {
+		return "not captured"
+	}
+	outcome := fmt.Sprintf("exit %d", run.ExitCode)
+	start, err1 := parseEntryTime(run.Started)
+	end, err2 := parseEntryTime(run.Finished)
+	if err1 == nil && err2 == nil {
+		outcome += ", took " + formatDuration(float64(end.Sub(start).Milliseconds()))
+	}
+	return outcome
+}
+
+// formatTestRunsHuman lists runs one per line
+func formatTestRunsHuman(runs []TestRunSummary, total int, now time.Time) string {
+	if len(runs) == 0 {
+		return "No test runs recorded. Run tests with 'agentlog capture --test-run -- <command>'.\n"
+	}
+
+	var sb strings.Builder
+	for _, run := range runs {
+		when := run.Started
+		if ts, err := parseEntryTime(run.Started); err == nil && !IsAbsoluteTime() {
+			when = relativeTime(ts, now)
+		}
+		line := fmt.Sprintf("%s  %s  %d %s  %s", run.ID, colors.dim(when), run.Errors, errorsWord(run.Errors), testRunOutcome(run))
+		if run.Command != "" {
+			line += "  " + colors.dim(truncate(run.Command, 60))
+		}
+		sb.WriteString(line + "\n")
+	}
+	if len(runs) < total {
+		sb.WriteString("\n" + colors.dim(fmt.Sprintf("Showing %d of %d runs (use --limit to see more)", len(runs), total)) + "\n")
+	}
+	return sb.String()
+}
+
+// formatTestRunHuman describes one run, then its error groups
+func formatTestRunHuman(run TestRunSummary, totalGroups int, now time.Time) string {
+	var sb strings.Builder
+	sb.WriteString("Test run " + run.ID)
+	if run.Command != "" {
+		sb.WriteString(": " + run.Command)
+	}
+	sb.WriteString("\n")
+	when := run.Started
+	if ts, err := parseEntryTime(run.Started); err == nil && !IsAbsoluteTime() {
+		when = relativeTime(ts, now)
+	}
+	sb.WriteString(fmt.Sprintf("  Started: %s | %s | %d %s\n", when, testRunOutcome(run), run.Errors, errorsWord(run.Errors)))
+	if run.Errors == 0 {
+		return sb.String()
+	}
+	sb.WriteString("\n")
+	sb.WriteString(formatGroupsHuman(run.Groups, totalGroups))
+	return sb.String()
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollectTestRuns(t *testing.T) {
+	recorded := []TestRun{
+		{ID: "run-a", Command: "npm test", Started: "2025-12-10T10:00:00Z", Finished: "2025-12-10T10:00:41Z", ExitCode: 1},
+		{ID: "run-b", Command: "npm test -- old", Started: "2025-12-10T11:00:00Z", Finished: "2025-12-10T11:00:05Z"},
+		{ID: "run-b", Command: "npm test", Started: "2025-12-10T12:00:00Z", Finished: "2025-12-10T12:00:05Z"},
+	}
+	run := func(id string) map[string]interface{} { return map[string]interface{}{testRunKey: id} }
+	entries := []ErrorEntry{
+		{Timestamp: "2025-12-10T10:00:10Z", ErrorType: "TYPE_ERROR", Message: "x is undefined", Context: run("run-a")},
+		{Timestamp: "2025-12-10T10:00:20Z", ErrorType: "TYPE_ERROR", Message: "x is undefined", Count: 3, Context: run("run-a")},
+		{Timestamp: "2025-12-10T10:00:30Z", ErrorType: "SLOW_QUERY", Kind: kindPerf, Context: run("run-a")},
+		{Timestamp: "2025-12-10T09:30:00Z", ErrorType: "NETWORK_ERROR", Context: run("ci-4812")},
+		{Timestamp: "2025-12-10T09:00:00Z", ErrorType: "NETWORK_ERROR", Context: run("ci-4812")},
+		{Timestamp: "2025-12-10T09:00:00Z", ErrorType: "NETWORK_ERROR"},
+	}
+
+	runs := collectTestRuns(recorded, entries)
+	var ids []string
+	for _, r := range runs {
+		ids = append(ids, r.ID)
+	}
+	if strings.Join(ids, ",") != "run-b,run-a,ci-4812" {
+		t.Fatalf("runs = %v, want latest first", ids)
+	}
+	if runs[0].Started != "2025-12-10T12:00:00Z" || runs[0].Errors != 0 || !runs[0].Recorded {
+		t.Errorf("a run recorded twice should keep its latest record: %+v", runs[0])
+	}
+	if runs[1].Errors != 4 {
+		t.Errorf("run-a errors = %d, want 4 (perf entries aren't errors)", runs[1].Errors)
+	}
+	if r := runs[2]; r.Recorded || r.Errors != 2 || r.Started != "2025-12-10T09:00:00Z" {
+		t.Errorf("a run known only from entries should start at its first: %+v", r)
+	}
+	if got := testRunOutcome(runs[1]); got != "exit 1, took 41s" {
+		t.Errorf("testRunOutcome() = %q", got)
+	}
+	if got := testRunOutcome(runs[2]); got != "not captured" {
+		t.Errorf("testRunOutcome() = %q", got)
+	}
+
+	if got := filterTestRun(entries, "ci-4812"); len(got) != 2 {
+		t.Errorf("filterTestRun() kept %d entries, want 2", len(got))
+	}
+	if got := testRunEntries(entries, "run-a"); len(got) != 2 {
+		t.Errorf("testRunEntries() kept %d entries, want 2", len(got))
+	}
+}
+
+func TestFindTestRun(t *testing.T) {
+	runs := []TestRunSummary{{TestRun: TestRun{ID: "20251210-150405-3f2a"}}, {TestRun: TestRun{ID: "20251210-160000-aa01"}}, {TestRun: TestRun{ID: "ci"}}, {TestRun: TestRun{ID: "ci-2"}}}
+	for ref, want := range map[string]string{"20251210-15": "20251210-150405-3f2a", "ci": "ci", "ci-": "ci-2"} {
+		if run, err := findTestRun(runs, ref); err != nil || run.ID != want {
+			t.Errorf("findTestRun(%q) = %q, %v, want %q", ref, run.ID, err, want)
+		}
+	}
+	for _, ref := range []string{"2025", "nope"} {
+		if _, err := findTestRun(runs, ref); err == nil {
+			t.Errorf("findTestRun(%q) should fail", ref)
+		}
+	}
+}
+
+func TestRunsCommand(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, ".agentlog"), 0755)
+	started := time.Now().Add(-2 * time.Minute).UTC()
+	recordTestRun(dir, TestRun{ID: "20251210-150405-3f2a", Command: "npm test", Started: started.Format(time.RFC3339), Finished: started.Add(41 * time.Second).Format(time.RFC3339), ExitCode: 1, Captured: 1})
+	var sb strings.Builder
+	for _, e := range []ErrorEntry{
+		{Timestamp: started.Add(time.Second).Format(time.RFC3339), Source: "test", ErrorType: "TYPE_ERROR", Message: "x is undefined", Context: map[string]interface{}{testRunKey: "20251210-150405-3f2a"}},
+		{Timestamp: started.Add(2 * time.Second).Format(time.RFC3339), Source: "backend", ErrorType: "TYPE_ERROR", Message: "x is undefined", Context: map[string]interface{}{testRunKey: "20251210-150405-3f2a"}},
+		{Timestamp: started.Format(time.RFC3339), Source: "backend", ErrorType: "NETWORK_ERROR", Message: "fetch failed"},
+	} {
+		data, _ := json.Marshal(e)
+		sb.Write(append(data, '\n'))
+	}
+	os.WriteFile(GetErrorsPath(dir), []byte(sb.String()), 0644)
+
+	originalPath, originalJSON := pathOverride, jsonOutput
+	defer func() { pathOverride, jsonOutput = originalPath, originalJSON; runsCmd.SetOut(nil) }()
+	pathOverride, jsonOutput = dir, false
+	run := func(args ...string) string {
+		t.Helper()
+		buf := new(bytes.Buffer)
+		runsCmd.SetOut(buf)
+		if err := runRuns(runsCmd, args); err != nil {
+			t.Fatalf("runRuns(%v) error = %v", args, err)
+		}
+		return buf.String()
+	}
+
+	if out := run(); out != "20251210-150405-3f2a  2m ago  2 errors  exit 1, took 41s  npm test\n" {
+		t.Errorf("list = %q", out)
+	}
+	out := run("20251210")
+	for _, want := range []string{"Test run 20251210-150405-3f2a: npm test", "Started: 2m ago | exit 1, took 41s | 2 errors", "[2x] TYPE_ERROR: x is undefined", "Sources: backend, test"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("run view should contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "NETWORK_ERROR") {
+		t.Errorf("run view should only hold the run's errors:\n%s", out)
+	}
+
+	jsonOutput = true
+	var summary TestRunSummary
+	if err := json.Unmarshal([]byte(run("20251210-150405-3f2a")), &summary); err != nil || summary.Errors != 2 || len(summary.Groups) != 1 || summary.ExitCode != 1 {
+		t.Errorf("JSON = %+v (%v)", summary, err)
+	}
+	if err := runRuns(runsCmd, []string{"nope"}); ExitCode(err) != ExitError {
+		t.Errorf("an unknown run should fail with exit 2, got %v", err)
+	}
+}
+
+func TestRunsCommand_None(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, ".agentlog"), 0755)
+	originalPath := pathOverride
+	defer func() { pathOverride = originalPath; runsCmd.SetOut(nil) }()
+	pathOverride = dir
+	buf := new(bytes.Buffer)
+	runsCmd.SetOut(buf)
+	if err := runRuns(runsCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "No test runs recorded") {
+		t.Errorf("output = %q", buf.String())
+	}
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/config"
+	"github.com/agentlog/agentlog/internal/diag"
+	"github.com/agentlog/agentlog/internal/logfile"
+)
+
+// samplingFileName keeps each sampled error type's window between writes,
+// which come from many processes (log, ingest, serve)
+const samplingFileName = "sampling.json"
+
+// defaultSamplingPer is the window a rule's keep_first is counted in when
+// it doesn't name one
+const defaultSamplingPer = time.Hour
+
+// samplingRule is a configured rule ready to apply
+type samplingRule struct {
+	config.SamplingRule
+	per time.Duration
+}
+
+// sampledType is the window of one sampled error type: how many entries
+// it's seen, and the ones skipped since an entry was last kept, merged into
+// the one that will stand for them
+type sampledType struct {
+	Start   string      `json:"window_start"`
+	Seen    int         `json:"seen"`
+	Skipped *ErrorEntry `json:"skipped,omitempty"`
+}
+
+// samplingRules reads cfg's sampling rules by error type. Invalid rules
+// are skipped with a warning; of two for one type, the first applies.
+func samplingRules(cfg *config.Config) map[string]*samplingRule {
+	rules := make(map[string]*samplingRule)
+	for _, r := range cfg.Sampling {
+		per, ok := defaultSamplingPer, true
+		if r.Per != "" {
+			per, ok = parseSpan(r.Per)
+		}
+		if r.ErrorType == "" || r.KeepOneIn < 1 || r.KeepFirst < 0 || !ok || per <= 0 {
+			diag.Warnf("skipping sampling rule for %q: needs an \"error_type\", \"keep_one_in\" of 1 or more, and a valid \"per\" (e.g. 1h)", r.ErrorType)
+			continue
+		}
+		if rules[r.ErrorType] == nil {
+			rules[r.ErrorType] = &samplingRule{SamplingRule: r, per: per}
+		}
+	}
+	return rules
+}
+
+func samplingPath(baseDir string) string {
+	return filepath.Join(baseDir, ".agentlog", samplingFileName)
+}
+
+// sampleEntries returns the entries to write of those given, per cfg's
+// sampling rules. Each error type a rule names has its first keep_first
+// entries a window written as they come; after that, entries are skipped
+// and merged until keep_one_in have been, then written as one entry with
+// their count, so occurrence counts stay exact. Skipped entries still
+// pending when a window ends are written with the next entry written after;
+// stats counts them from sampling.json until then.
+func sampleEntries(baseDir string, cfg *config.Config, entries []ErrorEntry, now time.Time) []ErrorEntry {
+	path := samplingPath(baseDir)
+	if len(cfg.Sampling) == 0 {
+		if _, err := os.Stat(path); err != nil {
+			return entries
+		}
+	}
+
+	// Writers in other processes sample under the same lock, so none of
+	// their counts are lost between the read and the save
+	release, err := logfile.Lock(path)
+	if err != nil {
+		diag.Debugf("locking %s: %v", path, err)
+		return entries
+	}
+	defer release()
+
+	state := readSampling(path)
+	rules := samplingRules(cfg)
+
+	// Close ended windows first, and those of types no longer sampled, so
+	// what they skipped is written before what follows
+	var kept []ErrorEntry
+	types := make([]string, 0, len(state))
+	for errorType := range state {
+		types = append(types, errorType)
+	}
+	sort.Strings(types)
+	for _, errorType := range types {
+		w := state[errorType]
+		if r := rules[errorType]; r != nil && w != nil && !windowEnded(w, r, now) {
+			continue
+		}
+		if w != nil && w.Skipped != nil {
+			kept = append(kept, *w.Skipped)
+		}
+		delete(state, errorType)
+	}
+
+	for _, e := range entries {
+		r := rules[e.ErrorType]
+		if r == nil {
+			kept = append(kept, e)
+			continue
+		}
+		w := state[e.ErrorType]
+		if w == nil || windowEnded(w, r, now) {
+			if w != nil && w.Skipped != nil {
+				kept = append(kept, *w.Skipped)
+			}
+			w = &sampledType{Start: now.UTC().Format(time.RFC3339Nano)}
+			state[e.ErrorType] = w
+		}
+
+		if w.Seen < r.KeepFirst {
+			w.Seen += e.occurrences()
+			kept = append(kept, e)
+			continue
+		}
+		w.Seen += e.occurrences()
+		if w.Skipped == nil {
+			skipped := e
+			w.Skipped = &skipped
+		} else {
+			merged := mergeRepeat(*w.Skipped, e)
+			w.Skipped = &merged
+		}
+		if w.Skipped.occurrences() >= r.KeepOneIn {
+			kept = append(kept, *w.Skipped)
+			w.Skipped = nil
+		}
+	}
+
+	if len(state) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			diag.Debugf("removing %s: %v", path, err)
+		}
+		return kept
+	}
+	data, _ := json.Marshal(state)
+	if err := logfile.WriteFile(path, data, 0644); err != nil {
+		diag.Debugf("writing %s: %v", path, err)
+	}
+	return kept
+}
+
+// readSampling reads the sampled types' windows in path. A missing or
+// empty file has none.
+func readSampling(path string) map[string]*sampledType {
+	state := make(map[string]*sampledType)
+	if data, err := os.ReadFile(path); err == nil && len(data) > 0 {
+		if err := json.Unmarshal(data, &state); err != nil {
+			diag.Debugf("reading %s: %v", path, err)
+		}
+	}
+	return state
+}
+
+// pendingSamples returns the entries sampling has skipped but not yet
+// written, one per sampled type, standing for all of them with their count
+func pendingSamples(baseDir string) []ErrorEntry {
+	state := readSampling(samplingPath(baseDir))
+	types := make([]string, 0, len(state))
+	for errorType := range state {
+		types = append(types, errorType)
+	}
+	sort.Strings(types)
+	var pending []ErrorEntry
+	for _, errorType := range types {
+		if w := state[errorType]; w != nil && w.Skipped != nil {
+			pending = append(pending, *w.Skipped)
+		}
+	}
+	return pending
+}
+
+// windowEnded reports whether w's window of r's length is over at now
+func windowEnded(w *sampledType, r *samplingRule, now time.Time) bool {
+	start, err := parseEntryTime(w.Start)
+	return err != nil || !now.Before(start.Add(r.per))
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/config"
+)
+
+func TestSampleEntries(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(dir+"/.agentlog", 0755)
+	cfg := &config.Config{Sampling: []config.SamplingRule{{ErrorType: "NETWORK_ERROR", KeepFirst: 3, Per: "1h", KeepOneIn: 5}}}
+	start := time.Date(2025, 12, 10, 19, 0, 0, 0, time.UTC)
+	entry := func(errorType string, i int) ErrorEntry {
+		return ErrorEntry{Timestamp: start.Add(time.Duration(i) * time.Second).Format(time.RFC3339), ErrorType: errorType, Message: fmt.Sprintf("timeout %d", i)}
+	}
+
+	// Each write is its own call, as from separate 'agentlog log' runs
+	var written []ErrorEntry
+	for i := 0; i < 20; i++ {
+		now := start.Add(time.Duration(i) * time.Second)
+		written = append(written, sampleEntries(dir, cfg, []ErrorEntry{entry("NETWORK_ERROR", i), entry("DATABASE_ERROR", i)}, now)...)
+	}
+	var network []ErrorEntry
+	for _, e := range written {
+		if e.ErrorType == "NETWORK_ERROR" {
+			network = append(network, e)
+		}
+	}
+	if len(written)-len(network) != 20 {
+		t.Errorf("types without a rule should all be written, got %d", len(written)-len(network))
+	}
+	// 3 kept, then 5 + 5 + 5 skipped and written as one each; 2 pending
+	if len(network) != 6 || totalOccurrences(network) != 18 {
+		t.Fatalf("expected the first 3 and 3 samples standing for 15, got %d for %d", len(network), totalOccurrences(network))
+	}
+	if s := network[3]; s.Count != 5 || s.FirstSeen != entry("", 3).Timestamp || s.Message != "timeout 7" {
+		t.Errorf("sample = %+v", s)
+	}
+
+	// After the window, the pending ones are written first and a new window
+	// starts with keep_first again
+	later := sampleEntries(dir, cfg, []ErrorEntry{entry("NETWORK_ERROR", 100)}, start.Add(2*time.Hour))
+	if len(later) != 2 || later[0].Count != 2 || later[1].Message != "timeout 100" {
+		t.Fatalf("after the window = %+v", later)
+	}
+
+	// A removed rule writes what its type had pending, then stops tracking
+	sampleEntries(dir, cfg, []ErrorEntry{entry("NETWORK_ERROR", 1), entry("NETWORK_ERROR", 2), entry("NETWORK_ERROR", 3)}, start.Add(2*time.Hour))
+	if got := sampleEntries(dir, &config.Config{}, nil, start.Add(2*time.Hour)); len(got) != 1 || got[0].Message != "timeout 3" {
+		t.Errorf("removing the rule should write the pending entry, got %+v", got)
+	}
+	if _, err := os.Stat(samplingPath(dir)); !os.IsNotExist(err) {
+		t.Errorf("%s should be removed once nothing is sampled (%v)", samplingFileName, err)
+	}
+}
+
+func TestSamplingRules_Invalid(t *testing.T) {
+	rules := samplingRules(&config.Config{Sampling: []config.SamplingRule{
+		{ErrorType: "A", KeepOneIn: 0},
+		{ErrorType: "B", KeepOneIn: 10, Per: "soon"},
+		{KeepOneIn: 10},
+		{ErrorType: "C", KeepOneIn: 10},
+		{ErrorType: "C", KeepOneIn: 2},
+	}})
+	if len(rules) != 1 || rules["C"] == nil || rules["C"].KeepOneIn != 10 || rules["C"].per != defaultSamplingPer {
+		t.Errorf("rules = %+v", rules)
+	}
+}
+
+func TestAppendErrors_Sampled(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(dir+"/.agentlog", 0755)
+	os.WriteFile(config.Path(dir), []byte(`{"sampling": [{"error_type": "NETWORK_ERROR", "keep_first": 1, "keep_one_in": 10}]}`), 0644)
+
+	for i := 0; i < 21; i++ {
+		if err := appendErrors(dir, []ErrorEntry{{Timestamp: time.Now().UTC().Format(time.RFC3339Nano), ErrorType: "NETWORK_ERROR", Message: "timeout"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, _ := readErrors(dir)
+	if len(entries) != 3 || totalOccurrences(entries) != 21 {
+		t.Errorf("expected 3 entries standing for 21, got %d for %d", len(entries), totalOccurrences(entries))
+	}
+}
+
+func TestAppendErrors_SampledConcurrently(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(dir+"/.agentlog", 0755)
+	os.WriteFile(config.Path(dir), []byte(`{"sampling": [{"error_type": "NETWORK_ERROR", "keep_first": 2, "keep_one_in": 7}]}`), 0644)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 6; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				appendErrors(dir, []ErrorEntry{{Timestamp: time.Now().UTC().Format(time.RFC3339Nano), ErrorType: "NETWORK_ERROR", Message: "timeout"}})
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 2 kept, 56 written as 8 of 7, and 2 pending in sampling.json
+	entries, _ := readErrors(dir)
+	pending := pendingSamples(dir)
+	if totalOccurrences(entries) != 58 || len(pending) != 1 || pending[0].Count != 2 {
+		t.Errorf("expected 58 written and 2 pending, got %d and %+v", totalOccurrences(entries), pending)
+	}
+}
+
+func TestStatsCommand_CountsPendingSamples(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(dir+"/.agentlog", 0755)
+	os.WriteFile(config.Path(dir), []byte(`{"sampling": [{"error_type": "NETWORK_ERROR", "keep_first": 1, "keep_one_in": 10}]}`), 0644)
+	for i := 0; i < 5; i++ {
+		appendErrors(dir, []ErrorEntry{{Timestamp: time.Now().UTC().Format(time.RFC3339Nano), Source: "backend", ErrorType: "NETWORK_ERROR", Message: "timeout"}})
+	}
+
+	originalPath, originalJSON := pathOverride, jsonOutput
+	defer func() { pathOverride, jsonOutput = originalPath, originalJSON }()
+	pathOverride, jsonOutput = dir, true
+	statsSince, statsLimit, statsKind = "", 5, kindError
+
+	buf := new(bytes.Buffer)
+	statsCmd.SetOut(buf)
+	defer statsCmd.SetOut(nil)
+	if err := runStats(statsCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	var r StatsReport
+	if err := json.Unmarshal(buf.Bytes(), &r); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if r.TotalErrors != 5 || r.SampledPending != 4 || len(r.ByType) != 1 || r.ByType[0].Count != 5 {
+		t.Errorf("stats should count what sampling has pending: %+v", r)
+	}
+}
@@ -0,0 +1,315 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/config"
+	"github.com/agentlog/agentlog/internal/diag"
+	"github.com/agentlog/agentlog/internal/logfile"
+)
+
+// largeLogBytes is the size past which a log counts as large: errors
+// finds the latest entries by reading it from the end, and scans over it
+// report their progress
+var largeLogBytes int64 = 64 << 20
+
+// defaultMaxEntries is how many entries commands hold in memory when
+// neither --max-entries nor max_entries sets a cap: a few hundred MB
+const defaultMaxEntries = 500000
+
+// entryCap is the most entries a command holds in memory: --max-entries,
+// the "max_entries" config setting, or defaultMaxEntries
+func entryCap(baseDir string) int {
+	if n := GetMaxEntries(); n > 0 {
+		return n
+	}
+	if cfg, err := config.Load(baseDir); err == nil && cfg.MaxEntries > 0 {
+		return cfg.MaxEntries
+	}
+	return defaultMaxEntries
+}
+
+// entryRing keeps the latest max entries added to it
+type entryRing struct {
+	max     int
+	entries []ErrorEntry
+	next    int // oldest entry, once full
+	total   int // entries added, kept or not
+}
+
+func newEntryRing(max int) *entryRing {
+	return &entryRing{max: max}
+}
+
+func (r *entryRing) add(e ErrorEntry) {
+	r.total++
+	if len(r.entries) < r.max {
+		r.entries = append(r.entries, e)
+		return
+	}
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % r.max
+}
+
+// ordered returns the kept entries, oldest first
+func (r *entryRing) ordered() []ErrorEntry {
+	if r.next == 0 {
+		return r.entries
+	}
+	return append(append([]ErrorEntry(nil), r.entries[r.next:]...), r.entries[:r.next]...)
+}
+
+// rangedRing keeps the latest max entries added across the ranges of a
+// log parsed in parallel. Ranges are in file order, so any entry of a range
+// is newer than all of an earlier range's: once max are held, each added
+// entry evicts the oldest of the earliest range still holding any, or is
+// dropped if that range is later than its own. The ranges share the one
+// budget, so no more than max entries are held at once. It's safe for
+// concurrent use.
+type rangedRing struct {
+	mu       sync.Mutex
+	max      int
+	parts    [][]ErrorEntry
+	earliest int // the first range still holding entries
+	held     int
+	total    int // entries added, kept or not
+}
+
+func newRangedRing(max, ranges int) *rangedRing {
+	return &rangedRing{max: max, parts: make([][]ErrorEntry, ranges)}
+}
+
+func (r *rangedRing) add(rang int, e ErrorEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.total++
+	if r.held >= r.max {
+		if r.max == 0 {
+			return
+		}
+		for len(r.parts[r.earliest]) == 0 {
+			r.earliest++
+		}
+		if r.earliest > rang {
+			return
+		}
+		oldest := r.parts[r.earliest]
+		oldest[0] = ErrorEntry{}
+		if oldest = oldest[1:]; len(oldest) < cap(oldest)/2 {
+			// Let go of the space evicted entries held
+			oldest = append([]ErrorEntry(nil), oldest...)
+		}
+		r.parts[r.earliest] = oldest
+		r.held--
+	}
+	r.parts[rang] = append(r.parts[rang], e)
+	r.held++
+}
+
+// ordered returns the kept entries, oldest first
+func (r *rangedRing) ordered() []ErrorEntry {
+	entries := make([]ErrorEntry, 0, r.held)
+	for _, part := range r.parts {
+		entries = append(entries, part...)
+	}
+	return entries
+}
+
+// warnDropped warns that the oldest entries of a log were left out to stay
+// under the in-memory cap
+func warnDropped(kept, total int) {
+	if total > kept {
+		diag.Warnf("holding the latest %d of %d entries; the oldest %d were skipped (raise --max-entries or max_entries in .agentlog/config.json)", kept, total, total-kept)
+	}
+}
+
+// scanErrors passes each entry in .agentlog/errors.jsonl to fn, oldest
+// first, until fn returns false. Only one line is held in memory at a
+// time, so commands that aggregate as they go (stats) work on logs of any
+// size.
+func scanErrors(baseDir string, fn func(ErrorEntry) bool) error {
+	file, err := os.Open(GetErrorsPath(baseDir))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	progress := newScanProgress(file)
+	defer progress.done()
+
+	diag.Debugf("reading %s", file.Name())
+	maxLine := maxLineBytes(baseDir)
+	scanner := logfile.NewLines(file, maxLine)
+	for scanner.Scan() {
+		progress.update(scanner.Offset())
+		entry, ok := parseLogLine(scanner.Text(), fmt.Sprintf("line %d", scanner.Line()))
+		if ok && !fn(entry) {
+			break
+		}
+	}
+	progress.done()
+	warnSkippedLines(scanner.Skipped, maxLine)
+	diag.Debugf("read %d lines (%d bytes) of %s in %s", scanner.Line(), scanner.Offset(), file.Name(), progress.elapsed())
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading file: %w", err)
+	}
+	return nil
+}
+
+// latestErrors returns the last limit entries keep accepts, oldest first,
+// reading errors.jsonl from its end so only as much of the log as holds
+// them is read
+func latestErrors(baseDir string, limit int, keep func(ErrorEntry) bool) ([]ErrorEntry, error) {
+	file, err := os.Open(GetErrorsPath(baseDir))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	progress := newScanProgress(file)
+	defer progress.done()
+
+	diag.Debugf("reading %s backward from its end (%d bytes) for the latest %d matches", file.Name(), info.Size(), limit)
+	maxLine := maxLineBytes(baseDir)
+	scanner := logfile.NewBackward(file, info.Size(), maxLine)
+	var latest []ErrorEntry
+	for len(latest) < limit && scanner.Scan() {
+		progress.update(info.Size() - scanner.Remaining())
+		if entry, ok := parseLogLine(scanner.Text(), "line"); ok && keep(entry) {
+			latest = append(latest, entry)
+		}
+	}
+	progress.done()
+	warnSkippedLines(scanner.Skipped, maxLine)
+	diag.Debugf("read the last %d bytes of %s in %s", info.Size()-scanner.Remaining(), file.Name(), progress.elapsed())
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+	for i, j := 0, len(latest)-1; i < j; i, j = i+1, j-1 {
+		latest[i], latest[j] = latest[j], latest[i]
+	}
+	return latest, nil
+}
+
+// scanRecords passes each non-blank line of f, one of the .agentlog files
+// beside errors.jsonl, to decode. Lines decode fails on are skipped with a
+// warning, as are lines over the max_line_bytes limit, so one bad line
+// doesn't cost the rest of the file.
+func scanRecords(baseDir string, f *os.File, decode func(line []byte) error) error {
+	maxLine := maxLineBytes(baseDir)
+	scanner := logfile.NewLines(f, maxLine)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := decode(line); err != nil {
+			diag.Warnf("skipping malformed line %d of %s: %v", scanner.Line(), filepath.Base(f.Name()), err)
+		}
+	}
+	warnSkippedLines(scanner.Skipped, maxLine)
+	return scanner.Err()
+}
+
+// parseLogLine decodes one line of errors.jsonl, warning about a
+// malformed one (which names it). Blank lines and healthchecks aren't
+// entries.
+func parseLogLine(line, which string) (ErrorEntry, bool) {
+	entry, err := decodeLogLine(line)
+	if err != nil {
+		// Skip malformed lines with a warning
+		diag.Warnf("skipping malformed %s: %v", which, err)
+		return ErrorEntry{}, false
+	}
+	if entry == nil {
+		return ErrorEntry{}, false
+	}
+	return *entry, true
+}
+
+// decodeLogLine decodes one line of errors.jsonl, returning nil for blank
+// lines and healthchecks
+func decodeLogLine(line string) (*ErrorEntry, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil, nil
+	}
+	var entry ErrorEntry
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		return nil, err
+	}
+	if entry.Kind == kindHealthcheck {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// scanProgress reports how far a scan of a large log has got, on stderr
+// when it's a terminal, output isn't JSON, and --quiet isn't set. It redraws one line at most
+// a few times a second and clears it when the scan ends.
+type scanProgress struct {
+	w     io.Writer
+	total int64
+	start time.Time
+	drawn time.Time
+	shown bool
+}
+
+// progressDelay is how long a scan runs before its progress is shown
+const progressDelay = 500 * time.Millisecond
+
+func newScanProgress(file *os.File) *scanProgress {
+	p := &scanProgress{start: time.Now()}
+	info, err := file.Stat()
+	if err != nil || info.Size() < largeLogBytes || IsJSONOutput() || !diag.Enabled(diag.Normal) || !isTerminal(os.Stderr) {
+		return p
+	}
+	p.w, p.total = os.Stderr, info.Size()
+	return p
+}
+
+// update notes that read bytes of the log have been scanned
+func (p *scanProgress) update(read int64) {
+	if p.w == nil {
+		return
+	}
+	now := time.Now()
+	if now.Sub(p.start) < progressDelay || now.Sub(p.drawn) < 200*time.Millisecond {
+		return
+	}
+	p.drawn, p.shown = now, true
+	fmt.Fprintf(p.w, "\rScanning errors.jsonl: %d%% (%s of %s)", read*100/p.total, formatBytes(read), formatBytes(p.total))
+}
+
+// done clears the progress line, if one was drawn
+func (p *scanProgress) done() {
+	if p.shown {
+		fmt.Fprint(p.w, "\r\033[K")
+		p.shown = false
+	}
+}
+
+// elapsed is how long the scan has taken so far, for --verbose
+func (p *scanProgress) elapsed() time.Duration {
+	return time.Since(p.start).Round(time.Millisecond)
+}
+
+// isTerminal reports whether f is a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/diag"
+	"github.com/agentlog/agentlog/internal/logfile"
+)
+
+// parallelLogBytes is the size past which readErrors and stats split the
+// log into ranges parsed on separate goroutines. Below it, starting them
+// costs more than it saves.
+var parallelLogBytes int64 = 4 << 20
+
+// parseWorkers is how many ranges a large log is split into
+var parseWorkers = runtime.GOMAXPROCS(0)
+
+// rangeResult is what parsing one range of the log found. Line numbers in
+// malformed are counted from the start of the range.
+type rangeResult struct {
+	lines     int
+	malformed []malformedLine
+	skipped   []logfile.SkippedLine
+	err       error
+}
+
+type malformedLine struct {
+	line int
+	err  error
+}
+
+// useParallel reports whether the log at path is large enough to parse in
+// ranges
+func useParallel(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Size() >= parallelLogBytes && parseWorkers > 1
+}
+
+// scanErrorsParallel splits errors.jsonl into newline-aligned ranges and
+// parses them concurrently. setup is told how many ranges there are before
+// parsing starts; then each entry is passed to each, with the index of its
+// range, on that range's goroutine. Ranges are in file order and each is
+// read in order, so results collected per range and joined by index keep
+// the log's order. Warnings are printed once all ranges are parsed, with
+// line numbers counted from the start of the file, as scanErrors prints
+// them.
+func scanErrorsParallel(baseDir string, setup func(ranges int), each func(rang int, e ErrorEntry)) error {
+	file, err := os.Open(GetErrorsPath(baseDir))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	maxLine := maxLineBytes(baseDir)
+	bounds, err := splitRanges(file, info.Size(), parseWorkers)
+	if err != nil {
+		return fmt.Errorf("error reading file: %w", err)
+	}
+	ranges := len(bounds) - 1
+	setup(ranges)
+	diag.Debugf("reading %s (%d bytes) in %d ranges", file.Name(), info.Size(), ranges)
+
+	var read atomic.Int64
+	results := make([]rangeResult, ranges)
+	var wg sync.WaitGroup
+	for i := 0; i < ranges; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			section := io.NewSectionReader(file, bounds[i], bounds[i+1]-bounds[i])
+			results[i] = parseRange(section, maxLine, &read, func(e ErrorEntry) { each(i, e) })
+		}(i)
+	}
+
+	progress := newScanProgress(file)
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	for waiting := true; waiting; {
+		select {
+		case <-done:
+			waiting = false
+		case <-ticker.C:
+			progress.update(read.Load())
+		}
+	}
+	ticker.Stop()
+	progress.done()
+	diag.Debugf("parsed %d ranges of %s in %s", ranges, file.Name(), progress.elapsed())
+
+	before := 0
+	for _, r := range results {
+		if r.err != nil {
+			return fmt.Errorf("error reading file: %w", r.err)
+		}
+		for _, m := range r.malformed {
+			diag.Warnf("skipping malformed line %d: %v", before+m.line, m.err)
+		}
+		for i := range r.skipped {
+			r.skipped[i].Line += before
+		}
+		warnSkippedLines(r.skipped, maxLine)
+		before += r.lines
+	}
+	return nil
+}
+
+// parseRange parses the entries in r, adding the bytes it reads to read
+func parseRange(r io.Reader, maxLine int, read *atomic.Int64, fn func(ErrorEntry)) rangeResult {
+	var result rangeResult
+	scanner := logfile.NewLines(r, maxLine)
+	var reported int64
+	for scanner.Scan() {
+		if offset := scanner.Offset(); offset-reported >= 1<<20 {
+			read.Add(offset - reported)
+			reported = offset
+		}
+		entry, err := decodeLogLine(scanner.Text())
+		if err != nil {
+			result.malformed = append(result.malformed, malformedLine{line: scanner.Line(), err: err})
+			continue
+		}
+		if entry != nil {
+			fn(*entry)
+		}
+	}
+	read.Add(scanner.Offset() - reported)
+	result.lines = scanner.Line()
+	result.skipped = scanner.Skipped
+	result.err = scanner.Err()
+	return result
+}
+
+// splitRanges divides the first size bytes of f into about n ranges,
+// moving each boundary past the next newline so no line is split. It
+// returns the boundaries, from 0 to size.
+func splitRanges(f io.ReaderAt, size int64, n int) ([]int64, error) {
+	bounds := []int64{0}
+	for i := 1; i < n; i++ {
+		off := size * int64(i) / int64(n)
+		if off <= bounds[len(bounds)-1] {
+			continue
+		}
+		r := bufio.NewReader(io.NewSectionReader(f, off, size-off))
+		for {
+			chunk, err := r.ReadSlice('\n')
+			off += int64(len(chunk))
+			if err == nil || err == io.EOF {
+				break
+			}
+			if err != bufio.ErrBufferFull {
+				return nil, err
+			}
+		}
+		if off >= size {
+			break
+		}
+		if off > bounds[len(bounds)-1] {
+			bounds = append(bounds, off)
+		}
+	}
+	return append(bounds, size), nil
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSplitRanges(t *testing.T) {
+	input := "aaaa\nbb\ncccccccccc\nd\n"
+	bounds, err := splitRanges(strings.NewReader(input), int64(len(input)), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bounds[0] != 0 || bounds[len(bounds)-1] != int64(len(input)) {
+		t.Fatalf("bounds %v should run from 0 to %d", bounds, len(input))
+	}
+	for _, b := range bounds[1 : len(bounds)-1] {
+		if input[b-1] != '\n' {
+			t.Errorf("boundary %d splits a line (bounds %v)", b, bounds)
+		}
+	}
+}
+
+func TestReadErrors_ParallelMatchesSequential(t *testing.T) {
+	tmpDir := writeScanLog(t, 5000)
+	sequential, err := readErrors(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	originalBytes, originalWorkers := parallelLogBytes, parseWorkers
+	defer func() { parallelLogBytes, parseWorkers = originalBytes, originalWorkers }()
+	parallelLogBytes, parseWorkers = 1, 7
+
+	parallel, err := readErrors(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parallel, sequential) {
+		t.Fatalf("parallel read differs: %d entries vs %d", len(parallel), len(sequential))
+	}
+}
+
+func TestScanErrorsParallel_LineNumbers(t *testing.T) {
+	tmpDir := writeScanLog(t, 0)
+	var sb strings.Builder
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&sb, `{"timestamp":"2025-12-10T19:19:32.941Z","source":"backend","error_type":"E","message":"m%d"}`+"\n", i)
+	}
+	os.WriteFile(GetErrorsPath(tmpDir), []byte(sb.String()), 0644)
+
+	// Line numbers of each range, offset by the lines before it, cover the
+	// file exactly once
+	file, _ := os.Open(GetErrorsPath(tmpDir))
+	defer file.Close()
+	bounds, _ := splitRanges(file, int64(sb.Len()), 5)
+	total := 0
+	for i := 0; i+1 < len(bounds); i++ {
+		section := strings.NewReader(sb.String()[bounds[i]:bounds[i+1]])
+		total += parseRange(section, 0, new(atomic.Int64), func(ErrorEntry) {}).lines
+	}
+	if total != 1000 {
+		t.Errorf("ranges hold %d lines, want 1000", total)
+	}
+}
+
+func TestStats_ParallelMatchesSequential(t *testing.T) {
+	tmpDir := writeScanLog(t, 3000)
+	entries, _ := readErrors(tmpDir)
+	want := generateStats(entries, 5)
+
+	originalBytes, originalWorkers := parallelLogBytes, parseWorkers
+	defer func() { parallelLogBytes, parseWorkers = originalBytes, originalWorkers }()
+	parallelLogBytes, parseWorkers = 1, 4
+
+	var counters []*statsCounter
+	err := scanErrorsParallel(tmpDir, func(ranges int) {
+		for i := 0; i < ranges; i++ {
+			counters = append(counters, newStatsCounter())
+		}
+	}, func(rang int, e ErrorEntry) { counters[rang].add(e) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged := newStatsCounter()
+	for _, c := range counters {
+		merged.merge(c)
+	}
+	got := merged.report(5)
+	if got.TotalErrors != want.TotalErrors || fmt.Sprint(got.BySource) != fmt.Sprint(want.BySource) || len(counters) < 2 {
+		t.Errorf("merged stats = %+v over %d ranges, want %+v", got, len(counters), want)
+	}
+}
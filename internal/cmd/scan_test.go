@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestLog writes content as dir's errors.jsonl and returns dir
+func writeTestLog(t *testing.T, dir, content string) string {
+	t.Helper()
+	os.MkdirAll(filepath.Join(dir, ".agentlog"), 0755)
+	if err := os.WriteFile(GetErrorsPath(dir), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// writeEntriesLog writes entries as the errors.jsonl of a new directory
+// and returns it
+func writeEntriesLog(t *testing.T, entries []ErrorEntry) string {
+	t.Helper()
+	return writeTestLog(t, t.TempDir(), jsonLines(entries))
+}
+
+// jsonLines encodes entries one per line, as they're stored
+func jsonLines(entries []ErrorEntry) string {
+	var sb strings.Builder
+	for _, e := range entries {
+		data, _ := json.Marshal(e)
+		sb.Write(append(data, '\n'))
+	}
+	return sb.String()
+}
+
+// writeScanLog writes n generated entries and a malformed line to a new
+// directory
+func writeScanLog(t *testing.T, n int) string {
+	t.Helper()
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		source := "frontend"
+		if i%3 == 0 {
+			source = "backend"
+		}
+		fmt.Fprintf(&sb, `{"timestamp":"2025-12-10T19:%02d:%02d.000Z","source":"%s","error_type":"E%d","message":"error %d"}`+"\n", i/60%60, i%60, source, i%4, i)
+	}
+	sb.WriteString("not json\n")
+	return writeTestLog(t, t.TempDir(), sb.String())
+}
+
+func TestLatestErrors(t *testing.T) {
+	tmpDir := writeScanLog(t, 3000)
+	latest, err := latestErrors(tmpDir, 3, func(e ErrorEntry) bool { return e.Source == "backend" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range latest {
+		got = append(got, e.Message)
+	}
+	if strings.Join(got, ",") != "error 2991,error 2994,error 2997" {
+		t.Errorf("latestErrors() = %v, want the last three backend entries, oldest first", got)
+	}
+}
+
+func TestErrorsCommand_LargeLogReadFromEnd(t *testing.T) {
+	tmpDir := writeScanLog(t, 3000)
+
+	originalPath, originalLarge := pathOverride, largeLogBytes
+	defer func() {
+		pathOverride, largeLogBytes = originalPath, originalLarge
+		errorsLimit, errorsSource, jsonOutput = 10, "", false
+	}()
+	pathOverride = tmpDir
+	errorsLimit, errorsSource, errorsType, errorsSince = 2, "backend", "", ""
+	errorsCount, errorsGroup, errorsPick, jsonOutput = false, false, false, true
+
+	run := func() string {
+		buf := new(bytes.Buffer)
+		errorsCmd.SetOut(buf)
+		if err := runErrors(errorsCmd, []string{}); err != nil {
+			t.Fatalf("runErrors() error = %v", err)
+		}
+		return buf.String()
+	}
+	whole := run()
+	largeLogBytes = 1
+	if fromEnd := run(); fromEnd != whole {
+		t.Errorf("reading from the end should match reading it all:\n%s\nvs\n%s", fromEnd, whole)
+	}
+}
+
+func TestStatsCounterMatchesGenerateStats(t *testing.T) {
+	tmpDir := writeScanLog(t, 500)
+	entries, _ := readErrors(tmpDir)
+	counter := newStatsCounter()
+	if err := scanErrors(tmpDir, func(e ErrorEntry) bool { counter.add(e); return true }); err != nil {
+		t.Fatal(err)
+	}
+	// Ties in ByType come out in any order, so compare the sources
+	got, want := counter.report(5), generateStats(entries, 5)
+	if got.TotalErrors != 500 || got.TotalErrors != want.TotalErrors || fmt.Sprint(got.BySource) != fmt.Sprint(want.BySource) || len(got.ByType) != len(want.ByType) {
+		t.Errorf("streamed stats = %+v, want %+v", got, want)
+	}
+}
+
+func TestReadErrors_EntryCapKeepsLatest(t *testing.T) {
+	tmpDir := writeScanLog(t, 1000)
+	originalMax, originalBytes, originalWorkers := maxEntries, parallelLogBytes, parseWorkers
+	defer func() { maxEntries, parallelLogBytes, parseWorkers = originalMax, originalBytes, originalWorkers }()
+
+	check := func(name string) {
+		entries, err := readErrors(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 150 || entries[0].Message != "error 850" || entries[149].Message != "error 999" {
+			t.Errorf("%s: got %d entries, %q to %q; want the latest 150", name, len(entries), entries[0].Message, entries[len(entries)-1].Message)
+		}
+	}
+
+	os.WriteFile(filepath.Join(tmpDir, ".agentlog", "config.json"), []byte(`{"max_entries": 150}`), 0644)
+	check("max_entries")
+
+	parallelLogBytes, parseWorkers = 1, 6
+	check("parallel")
+
+	maxEntries = 150
+	os.WriteFile(filepath.Join(tmpDir, ".agentlog", "config.json"), []byte(`{"max_entries": 10}`), 0644)
+	check("--max-entries over config")
+}
+
+func TestRangedRing_SharesOneBudget(t *testing.T) {
+	ring := newRangedRing(10, 4)
+	// Ranges parse concurrently, so their entries arrive interleaved
+	for i := 0; i < 25; i++ {
+		for rang := 0; rang < 4; rang++ {
+			ring.add(rang, ErrorEntry{Message: fmt.Sprintf("range %d entry %02d", rang, i)})
+			if ring.held > 10 {
+				t.Fatalf("holding %d entries, over the cap of 10", ring.held)
+			}
+		}
+	}
+	entries := ring.ordered()
+	if ring.total != 100 || len(entries) != 10 || entries[0].Message != "range 3 entry 15" || entries[9].Message != "range 3 entry 24" {
+		t.Errorf("got %d of %d, %q to %q; want the last range's latest 10", len(entries), ring.total, entries[0].Message, entries[len(entries)-1].Message)
+	}
+
+	// Entries of later ranges never evict those of the last
+	ring = newRangedRing(3, 3)
+	ring.add(2, ErrorEntry{Message: "c"})
+	ring.add(1, ErrorEntry{Message: "b1"})
+	ring.add(1, ErrorEntry{Message: "b2"})
+	ring.add(0, ErrorEntry{Message: "a"})
+	ring.add(1, ErrorEntry{Message: "b3"})
+	var got []string
+	for _, e := range ring.ordered() {
+		got = append(got, e.Message)
+	}
+	if fmt.Sprint(got) != "[b2 b3 c]" || ring.held > 3 {
+		t.Errorf("kept %v, want [b2 b3 c]", got)
+	}
+}
@@ -0,0 +1,711 @@
+package cmd
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/config"
+	"github.com/agentlog/agentlog/internal/diag"
+	"github.com/agentlog/agentlog/internal/self"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr           string
+	serveAllowedOrigins []string
+	serveToken          string
+	serveRateLimit      float64
+	serveBurst          int
+	serveFsync          bool
+	serveRepeatWindow   string
+)
+
+// Limits from docs/jsonl-schema.md, enforced on posted entries
+const (
+	maxEntrySize       = 10240 // bytes; larger request bodies are rejected
+	maxMessageLength   = 500
+	maxStackTraceSize  = 2048
+	maxErrorTypeLength = 100
+	maxSourceLength    = 100
+	maxProjectLength   = 100
+	maxEnvLength       = 50
+	maxHostLength      = 100
+	maxUserLength      = 100
+	maxAgentLength     = 100
+	maxFileLength      = 200
+	maxEndpointLength  = 500
+	maxTags            = 20
+	maxTagLength       = 50
+	maxContextKeys     = 50
+	maxContextDepth    = 2
+
+	maxRateLimitClients = 1024 // tracked clients before idle ones are pruned
+)
+
+// defaultAllowedOrigins accepts browser requests from any port on the
+// loopback interface, which covers local dev servers (Vite on :5173, etc.)
+var defaultAllowedOrigins = []string{
+	"http://localhost:*",
+	"https://localhost:*",
+	"http://127.0.0.1:*",
+	"https://127.0.0.1:*",
+	"http://[::1]:*",
+}
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run an HTTP endpoint that appends posted errors to .agentlog/errors.jsonl",
+	Long: `Run a local HTTP server that accepts entries on POST /__agentlog and
+appends them to .agentlog/errors.jsonl.
+
+GET /stream pushes new entries as server-sent events ("entry" events whose
+data is the entry JSON), so dev overlays and editor extensions can
+subscribe instead of polling the file. Entries from every writer are
+streamed, not just ones posted to this server.
+
+This lets browser apps report errors without a dev-server plugin, including
+SPAs served from a different port than agentlog. Set the snippet's endpoint
+to http://localhost:<port>/__agentlog.
+
+Cross-origin requests (including OPTIONS preflight) are accepted only from
+allowed origins. By default any port on localhost/127.0.0.1 is allowed.
+Override with --allow-origin or "serve.allowed_origins" in
+.agentlog/config.json. An entry may end in ":*" to match any port, and "*"
+allows every origin.
+
+When "serve.token" is set in .agentlog/config.json (agentlog init generates
+one and injects it into browser snippets), every request must carry it as
+"Authorization: Bearer <token>". EventSource clients, which cannot set
+headers, may pass ?token=<token> instead. Keep the token set before binding
+to 0.0.0.0 for device testing, or anyone on the network can write entries.
+
+Posted entries are checked against the JSONL schema before writing: bodies
+over 10KB are rejected, entries without message or error_type are rejected,
+a missing or invalid timestamp is replaced with the receive time, and long
+fields are truncated (message 500 chars, stack_trace 2KB). Each client IP
+is rate limited (--rate-limit, --burst); excess requests get 429.
+
+With --fsync (or "fsync": true in .agentlog/config.json, or AGENTLOG_FSYNC=1),
+each write is fsynced before the request is answered, so an acknowledged entry
+survives a crash or power loss. It costs a disk flush per request.
+
+Identical entries (same error type and normalized message, source, project,
+environment, host, user, and agent) arriving within 5 seconds of one that
+was written are held back and counted instead, then written as one
+collapsed entry with "count", "first_seen", and "last_seen" when the window
+closes, so a client in a tight retry loop adds a line every few seconds
+rather than thousands. --repeat-window (or "serve.repeat_window") changes
+the window; 0 writes every entry.
+
+When one error reaches 50 occurrences within a minute ("storms" in
+.agentlog/config.json: {"threshold": 50, "window": "1m"}), an ERROR_STORM
+entry naming it, its count, and its fingerprint is written, once per storm,
+so whoever is watching the log sees one problem rather than hundreds.
+
+Entries are masked for secrets and personal data, and stripped of the context
+keys "context.deny" excludes (or "context.allow" doesn't keep) in
+.agentlog/config.json, before they're written or streamed.
+
+Posted entries are checked against the "alerts" rules in .agentlog/config.json.
+A rule fires when more than "threshold" entries matching "query" (the
+'agentlog errors --query' language) arrive within "window", at most once per
+window. Fired alerts are printed on stderr, recorded in .agentlog/alerts.jsonl
+for 'agentlog doctor', posted as JSON to the rule's "webhook", and piped to its
+"command" on stdin with AGENTLOG_ALERT set to the rule name:
+
+  {"alerts": [{"name": "network-storm", "query": "type=NETWORK_ERROR",
+               "threshold": 20, "window": "10m", "webhook": "https://..."}]}
+
+Examples:
+  agentlog serve
+  agentlog serve --addr 127.0.0.1:9000
+  agentlog serve --allow-origin http://localhost:5173 --allow-origin https://app.test`,
+	RunE: runServe,
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:7654", "Address to listen on")
+	serveCmd.Flags().StringSliceVar(&serveAllowedOrigins, "allow-origin", nil, "Allowed CORS origin (repeatable; default: localhost on any port)")
+	serveCmd.Flags().StringVar(&serveToken, "token", "", "Bearer token required on requests (default: serve.token from config)")
+	serveCmd.Flags().Float64Var(&serveRateLimit, "rate-limit", 20, "Entries per second accepted from each client (0 disables)")
+	serveCmd.Flags().IntVar(&serveBurst, "burst", 50, "Entries a client may send at once before --rate-limit applies")
+	serveCmd.Flags().BoolVar(&serveFsync, "fsync", false, "Fsync errors.jsonl before acknowledging each request (default: fsync from config)")
+	serveCmd.Flags().StringVar(&serveRepeatWindow, "repeat-window", "", "Collapse identical entries arriving this soon after one is written (default: serve.repeat_window from config, else 5s; 0 disables)")
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	// Determine base directory (use --path override or cwd)
+	baseDir := GetPathOverride()
+	if baseDir == "" {
+		var err error
+		baseDir, err = os.Getwd()
+		if err != nil {
+			self.LogError(".", "GETWD_ERROR", err.Error())
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+
+	cfg, err := config.Load(baseDir)
+	if err != nil {
+		return err
+	}
+
+	origins := serveAllowedOrigins
+	if len(origins) == 0 {
+		origins = cfg.Serve.AllowedOrigins
+	}
+	if len(origins) == 0 {
+		origins = defaultAllowedOrigins
+	}
+
+	token := serveToken
+	if token == "" {
+		token = cfg.Serve.Token
+	}
+	if token == "" && !isLoopbackAddr(serveAddr) {
+		diag.Warnf("listening on %s without an auth token; anyone who can reach it can write entries (run 'agentlog init' or pass --token)", serveAddr)
+	}
+
+	stormThreshold, stormWindow := stormSettings(cfg)
+	repeatWindow := defaultRepeatWindow
+	if value := firstNonEmpty(serveRepeatWindow, cfg.Serve.RepeatWindow); value != "" {
+		d, ok := parseSpan(value)
+		if !ok || d < 0 {
+			return invalidInput("invalid repeat window '%s' (use e.g. 5s, 1m, or 0 to write every entry)", value)
+		}
+		repeatWindow = d
+	}
+
+	s := newIngestServer(baseDir, origins)
+	s.token, s.fsync = token, serveFsync
+	if serveRateLimit > 0 {
+		s.limiter = newRateLimiter(serveRateLimit, serveBurst)
+	}
+	if repeatWindow > 0 {
+		s.repeats = newRepeatSuppressor(repeatWindow, s.writePosted)
+		defer s.repeats.close()
+	}
+	s.alerts = newAlertEngine(baseDir)
+	s.storms = newStormDetector(stormThreshold, stormWindow, s.writeEntries)
+	defer s.alerts.wait()
+	defer watchEdits(baseDir)()
+
+	srv := &http.Server{
+		Addr:              serveAddr,
+		Handler:           s.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Set up signal handling for graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		shutdownCtx, done := context.WithTimeout(context.Background(), 5*time.Second)
+		defer done()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	if !IsJSONOutput() {
+		diag.Infof("Listening on http://%s/__agentlog (Ctrl+C to stop)", serveAddr)
+	}
+
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		self.LogError(baseDir, "SERVE_ERROR", err.Error())
+		return fmt.Errorf("server failed: %w", err)
+	}
+	return nil
+}
+
+// ingestServer handles HTTP requests for agentlog serve
+type ingestServer struct {
+	baseDir        string
+	allowedOrigins []string
+	token          string            // required bearer token; empty disables auth
+	limiter        *rateLimiter      // per-client limit on posted entries; nil disables
+	alerts         *alertEngine      // checks posted entries against alert rules; nil disables
+	repeats        *repeatSuppressor // collapses identical entries posted in quick succession; nil disables
+	storms         *stormDetector    // writes an ERROR_STORM entry when one error bursts; nil disables
+	fsync          bool              // fsync each append, whatever config says
+	pollInterval   time.Duration     // how often /stream checks errors.jsonl
+	keepAlive      time.Duration     // interval between /stream keep-alive comments
+}
+
+// newIngestServer creates a server writing to baseDir's errors.jsonl
+func newIngestServer(baseDir string, allowedOrigins []string) *ingestServer {
+	return &ingestServer{
+		baseDir:        baseDir,
+		allowedOrigins: allowedOrigins,
+		pollInterval:   500 * time.Millisecond,
+		keepAlive:      15 * time.Second,
+	}
+}
+
+// handler returns the server's routes wrapped in CORS handling
+func (s *ingestServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/__agentlog", s.handleIngest)
+	mux.HandleFunc("/stream", s.handleStream)
+	return s.cors(s.auth(mux))
+}
+
+// auth rejects requests without the configured bearer token. Preflight
+// requests pass through since browsers never attach credentials to them.
+func (s *ingestServer) auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token == "" || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			got = r.URL.Query().Get("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "missing or invalid token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// cors rejects requests from disallowed origins and answers preflight
+// requests. Requests without an Origin header (curl, same-origin server
+// code) pass through unchanged.
+func (s *ingestServer) cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		if !originAllowed(origin, s.allowedOrigins) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleIngest validates a posted entry and appends it to errors.jsonl
+func (s *ingestServer) handleIngest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST, OPTIONS")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.limiter != nil && !s.limiter.allow(clientIP(r), time.Now()) {
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+		return
+	}
+
+	entry, err := decodeEntry(http.MaxBytesReader(w, r.Body, maxEntrySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("entry exceeds %d bytes", maxEntrySize), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// A repeat held back still counts toward alerts and storms, masked as
+	// it will be when it's written
+	now := time.Now()
+	entry = sanitizeEntry(entry, now)
+	if entry.Host == "" {
+		entry.Host = postedHost(r)
+	}
+	if !s.repeats.admit(entry, now) {
+		w.WriteHeader(http.StatusNoContent)
+		entry = entryPolicyFor(s.baseDir).apply(entry)
+		s.alerts.observe(entry)
+		s.storms.observe(entry)
+		return
+	}
+
+	// appendErrors redacts the entry in place, so alerts get it as stored
+	batch := []ErrorEntry{entry}
+	if err := appendErrorsSync(s.baseDir, batch, s.fsync, true); err != nil {
+		self.LogError(s.baseDir, "SERVE_ERROR", err.Error())
+		http.Error(w, "failed to write entry", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	s.alerts.observe(batch[0])
+	s.storms.observe(batch[0])
+}
+
+// writeEntries appends entries serve writes itself, ERROR_STORM entries
+func (s *ingestServer) writeEntries(entries []ErrorEntry) {
+	if err := appendErrorsSync(s.baseDir, entries, s.fsync, false); err != nil {
+		self.LogError(s.baseDir, "SERVE_ERROR", err.Error())
+	}
+}
+
+// writePosted appends the collapsed entries of held-back repeats, which
+// were posted like any other
+func (s *ingestServer) writePosted(entries []ErrorEntry) {
+	if err := appendErrorsSync(s.baseDir, entries, s.fsync, true); err != nil {
+		self.LogError(s.baseDir, "SERVE_ERROR", err.Error())
+	}
+}
+
+// postedHost is the host a posted entry is attributed to when it doesn't
+// name one: this machine for a loopback client, otherwise the client's IP.
+// The server's login is never assumed.
+func postedHost(r *http.Request) string {
+	if isLoopbackAddr(r.RemoteAddr) {
+		return entryHost()
+	}
+	return clientIP(r)
+}
+
+// decodeEntry decodes exactly one entry object and checks required fields
+func decodeEntry(r io.Reader) (ErrorEntry, error) {
+	var entry ErrorEntry
+	dec := json.NewDecoder(r)
+	if err := dec.Decode(&entry); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return entry, err
+		}
+		return entry, fmt.Errorf("invalid JSON entry")
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return entry, err
+		}
+		return entry, fmt.Errorf("body must contain a single JSON entry")
+	}
+
+	if strings.TrimSpace(entry.Message) == "" {
+		return entry, fmt.Errorf("message is required")
+	}
+	if strings.TrimSpace(entry.ErrorType) == "" {
+		return entry, fmt.Errorf("error_type is required")
+	}
+	return entry, nil
+}
+
+// sanitizeEntry coerces a posted entry to the schema: timestamps are
+// normalized to UTC (or replaced with now), source defaults to frontend,
+// oversized fields are truncated, tags are deduplicated, and context is bounded in size and depth
+func sanitizeEntry(e ErrorEntry, now time.Time) ErrorEntry {
+	ts, err := time.Parse(time.RFC3339Nano, e.Timestamp)
+	if err != nil {
+		ts = now
+	}
+	e.Timestamp = ts.UTC().Format("2006-01-02T15:04:05.000Z")
+
+	e.Source = strings.TrimSpace(e.Source)
+	if e.Source == "" {
+		e.Source = "frontend"
+	}
+	e.Source = truncate(singleLine(e.Source), maxSourceLength)
+	e.ErrorType = truncate(singleLine(strings.TrimSpace(e.ErrorType)), maxErrorTypeLength)
+	e.Message = truncate(e.Message, maxMessageLength)
+	e.Environment = truncate(singleLine(strings.TrimSpace(e.Environment)), maxEnvLength)
+	e.Project = truncate(singleLine(strings.TrimSpace(e.Project)), maxProjectLength)
+	e.Host = truncate(singleLine(strings.TrimSpace(e.Host)), maxHostLength)
+	e.User = truncate(singleLine(strings.TrimSpace(e.User)), maxUserLength)
+	e.Agent = truncate(singleLine(strings.TrimSpace(e.Agent)), maxAgentLength)
+	e.Kind = strings.ToLower(strings.TrimSpace(e.Kind))
+	if e.Kind != kindPerf && e.Kind != kindHealthcheck {
+		e.Kind = ""
+	}
+	if e.DurationMs < 0 {
+		e.DurationMs = 0
+	}
+	e.File = truncate(singleLine(e.File), maxFileLength)
+	e.Endpoint = truncate(singleLine(e.Endpoint), maxEndpointLength)
+	if e.Line < 0 {
+		e.Line = 0
+	}
+	if e.Column < 0 {
+		e.Column = 0
+	}
+	for i, t := range e.Tags {
+		e.Tags[i] = truncate(singleLine(t), maxTagLength)
+	}
+	e.Tags = normalizeTags(e.Tags)
+	if len(e.Tags) > maxTags {
+		e.Tags = e.Tags[:maxTags]
+	}
+
+	if len(e.Context) > 0 {
+		ctx := make(map[string]interface{}, len(e.Context))
+		keys := make([]string, 0, len(e.Context))
+		for k := range e.Context {
+			keys = append(keys, k)
+		}
+		// Keep the schema's common fields when trimming to maxContextKeys
+		sort.Slice(keys, func(i, j int) bool {
+			ci, cj := commonContextFields[keys[i]], commonContextFields[keys[j]]
+			if ci != cj {
+				return ci
+			}
+			return keys[i] < keys[j]
+		})
+		for _, k := range keys {
+			if len(ctx) == maxContextKeys {
+				break
+			}
+			v := e.Context[k]
+			if k == "stack_trace" {
+				if st, ok := v.(string); ok {
+					v = truncate(st, maxStackTraceSize)
+				}
+			}
+			ctx[k] = limitDepth(v, maxContextDepth)
+		}
+		e.Context = ctx
+	}
+	return e
+}
+
+// commonContextFields are the context fields defined in docs/jsonl-schema.md
+var commonContextFields = map[string]bool{
+	"session_id": true, "stack_trace": true, "url": true, "command": true,
+	"component": true, "user_id": true, "request_id": true,
+}
+
+// limitDepth replaces values nested deeper than depth levels with null
+func limitDepth(v interface{}, depth int) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if depth <= 0 {
+			return nil
+		}
+		for k, child := range val {
+			val[k] = limitDepth(child, depth-1)
+		}
+	case []interface{}:
+		if depth <= 0 {
+			return nil
+		}
+		for i, child := range val {
+			val[i] = limitDepth(child, depth-1)
+		}
+	}
+	return v
+}
+
+// singleLine collapses line breaks so a field can't fake extra output lines
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// clientIP returns the request's remote IP for rate limiting
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rateLimiter is a per-client token bucket
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // tokens added per second
+	burst   float64
+	buckets map[string]*tokenBucket
+}
+
+// tokenBucket tracks one client's remaining tokens
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter allows rate requests per second per client, with bursts of
+// up to burst requests
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{rate: rate, burst: float64(burst), buckets: make(map[string]*tokenBucket)}
+}
+
+// allow reports whether client may make a request at now, consuming a token
+func (l *rateLimiter) allow(client string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[client]
+	if !ok {
+		if len(l.buckets) >= maxRateLimitClients {
+			l.pruneIdle(now)
+		}
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// pruneIdle drops clients whose buckets have refilled completely, since
+// they're indistinguishable from new clients
+func (l *rateLimiter) pruneIdle(now time.Time) {
+	for client, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// handleStream pushes entries appended to errors.jsonl as server-sent
+// events. Each event's id is the file offset after the entry, so clients
+// reconnecting with Last-Event-ID resume without gaps; new clients start at
+// the end of the file.
+func (s *ingestServer) handleStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET, OPTIONS")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	path := GetErrorsPath(s.baseDir)
+	var offset int64
+	if id, err := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64); err == nil && id >= 0 {
+		offset = id
+	} else if info, err := os.Stat(path); err == nil {
+		offset = info.Size()
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	policy := entryPolicyFor(s.baseDir)
+	poll := time.NewTicker(s.pollInterval)
+	defer poll.Stop()
+	keepAlive := time.NewTicker(s.keepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case <-poll.C:
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			if info.Size() < offset {
+				offset = 0 // truncated or rotated
+			}
+			if info.Size() == offset {
+				continue
+			}
+
+			data, newOffset, err := readFrom(path, offset)
+			if err != nil {
+				continue
+			}
+			pos := offset
+			for _, line := range strings.SplitAfter(data, "\n") {
+				pos += int64(len(line))
+				var entry ErrorEntry
+				if err := json.Unmarshal([]byte(strings.TrimSpace(line)), &entry); err != nil {
+					continue // Skip blank and malformed lines
+				}
+				payload, _ := json.Marshal(policy.apply(entry))
+				fmt.Fprintf(w, "id: %d\nevent: entry\ndata: %s\n\n", pos, payload)
+			}
+			offset = newOffset
+			flusher.Flush()
+		}
+	}
+}
+
+// isLoopbackAddr reports whether a listen address only accepts local
+// connections
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// originAllowed reports whether origin matches an allowed entry. Entries are
+// exact origins, "*" for any origin, or "scheme://host:*" for any port.
+func originAllowed(origin string, allowed []string) bool {
+	for _, a := range allowed {
+		if a == "*" || a == origin {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(a, ":*"); ok {
+			if origin == prefix {
+				return true
+			}
+			if port, ok := strings.CutPrefix(origin, prefix+":"); ok {
+				if _, err := strconv.Atoi(port); err == nil {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
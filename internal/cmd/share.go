@@ -0,0 +1,572 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/redact"
+	"github.com/agentlog/agentlog/internal/self"
+	"github.com/spf13/cobra"
+)
+
+// Bundle formats
+const (
+	shareMarkdown = "markdown"
+	shareArchive  = "archive"
+)
+
+var (
+	shareSince   string
+	shareType    string
+	shareSource  string
+	shareQuery   string
+	shareLimit   int
+	shareContext bool
+	shareFormat  string
+	shareOutput  string
+	shareYes     bool
+)
+
+// ShareManifest is what a bundle holds. It's shown before the bundle is
+// written, so nothing is shared without being seen.
+type ShareManifest struct {
+	Path       string           `json:"path"`
+	Format     string           `json:"format"`
+	Entries    int              `json:"entries"`
+	Matched    int              `json:"matched"` // entries matching the filters, before --limit
+	First      string           `json:"first,omitempty"`
+	Last       string           `json:"last,omitempty"`
+	ErrorTypes []ErrorTypeCount `json:"error_types"`
+	Files      []string         `json:"files,omitempty"` // archive members
+	Masked     []string         `json:"masked"`
+	Omitted    []string         `json:"omitted,omitempty"`
+	Written    bool             `json:"written"`
+}
+
+// shareCmd represents the share command
+var shareCmd = &cobra.Command{
+	Use:   "share",
+	Short: "Package recent errors into a sanitized bundle for a bug report",
+	Long: `Package a filtered subset of errors, the prime summary, and the doctor
+report into one file to attach to a bug report: a Markdown file that can be
+pasted into an issue or gist (the default), or a .tar.gz archive holding
+bundle.md, errors.jsonl, prime.json, and doctor.json.
+
+The bundle is sanitized: paths under the project become relative ("./src/..."),
+the home directory and hostname are masked, entries' host and user are left
+out, and so are secrets and personal data (see "redact" in 'agentlog errors --help'). Entry context is left out
+unless --include-context is given. Doctor doesn't post its healthcheck entry
+while building the bundle.
+
+Before writing, share lists what the bundle will hold (entry count, time
+span, error types, sections, what's masked and left out) and asks to
+confirm. --yes skips the question; it's required when stdin isn't a
+terminal.
+
+The filters match 'agentlog errors': --since, --type, --source, and --query.
+The latest --limit matches are included.
+
+Examples:
+  agentlog share                          # Latest 50 errors, as Markdown
+  agentlog share --since 2h --type NETWORK_ERROR
+  agentlog share --format archive -o bug-123.tar.gz
+  agentlog share --yes -o - | gh gist create -f agentlog.md -`,
+	RunE: runShare,
+}
+
+func init() {
+	rootCmd.AddCommand(shareCmd)
+
+	shareCmd.Flags().StringVar(&shareSince, "since", "", "Only include errors since time (e.g., '2h', '2d', 'yesterday')")
+	shareCmd.Flags().StringVar(&shareType, "type", "", "Only include errors of this type")
+	shareCmd.Flags().StringVar(&shareSource, "source", "", "Only include errors from this source")
+	shareCmd.Flags().StringVar(&shareQuery, "query", "", "Only include errors matching a filter expression (see 'agentlog errors --help')")
+	shareCmd.Flags().IntVar(&shareLimit, "limit", 50, "Most entries to include, the latest first")
+	shareCmd.Flags().BoolVar(&shareContext, "include-context", false, "Include each entry's context (left out by default)")
+	shareCmd.Flags().StringVar(&shareFormat, "format", "", "Bundle format: markdown or archive (default: from --output's extension, else markdown)")
+	shareCmd.Flags().StringVarP(&shareOutput, "output", "o", "", "File to write, or - for stdout (default: agentlog-share-<time>.md or .tar.gz)")
+	shareCmd.Flags().BoolVarP(&shareYes, "yes", "y", false, "Write the bundle without asking")
+}
+
+func runShare(cmd *cobra.Command, args []string) error {
+	// Determine base directory (use --path override or cwd)
+	baseDir := GetPathOverride()
+	if baseDir == "" {
+		var err error
+		baseDir, err = os.Getwd()
+		if err != nil {
+			self.LogError(".", "GETWD_ERROR", err.Error())
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+	if uninitialized(baseDir) {
+		return exitNotInitialized(cmd)
+	}
+
+	now := time.Now()
+	format, path, err := shareTarget(shareFormat, shareOutput, now)
+	if err != nil {
+		self.LogError(baseDir, "INVALID_INPUT", err.Error())
+		return err
+	}
+	if shareLimit <= 0 {
+		self.LogError(baseDir, "INVALID_INPUT", "--limit must be positive")
+		return invalidInput("--limit must be positive")
+	}
+	var sinceTime time.Time
+	if shareSince != "" {
+		if sinceTime, err = parseSince(shareSince); err != nil {
+			self.LogError(baseDir, "INVALID_INPUT", fmt.Sprintf("invalid --since value '%s': %v", shareSince, err))
+			return invalidInput("invalid --since value: %w", err)
+		}
+	}
+	var query queryExpr
+	if shareQuery != "" {
+		if query, err = parseQuery(shareQuery); err != nil {
+			self.LogError(baseDir, "INVALID_INPUT", err.Error())
+			return err
+		}
+	}
+
+	entries, err := readErrors(baseDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	entries = filterQuery(filterErrors(entries, shareSource, shareType, sinceTime), query)
+	matched := len(entries)
+	if len(entries) > shareLimit {
+		entries = entries[len(entries)-shareLimit:]
+	}
+
+	scrub := newShareScrubber(baseDir)
+	for i := range entries {
+		entries[i] = scrub.entry(entries[i], shareContext)
+	}
+	summary, err := generatePrimeSummary()
+	if err != nil {
+		return err
+	}
+	health := shareHealth(baseDir)
+
+	manifest := newShareManifest(entries, matched, format, path)
+	if !shareContext {
+		manifest.Omitted = append(manifest.Omitted, "context (add --include-context)")
+	}
+	manifest.Masked = scrub.masked
+
+	if !shareYes {
+		ok, err := confirmShare(cmd, manifest)
+		if err != nil {
+			self.LogError(baseDir, "INVALID_INPUT", err.Error())
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(cmd.ErrOrStderr(), "Nothing written.")
+			return nil
+		}
+	}
+
+	b := shareBundle{
+		Project:  projectName(baseDir),
+		Now:      now,
+		Entries:  entries,
+		Matched:  matched,
+		Filters:  shareFilters(),
+		Manifest: manifest,
+		Prime:    scrub.text(formatPrimeSummaryHuman(summary)),
+		Health:   health,
+	}
+	var data []byte
+	if format == shareArchive {
+		data, err = b.archive(scrub, summary)
+	} else {
+		data = []byte(b.markdown())
+	}
+	if err != nil {
+		return err
+	}
+
+	if path == "-" {
+		_, err = cmd.OutOrStdout().Write(data)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		self.LogError(baseDir, "WRITE_ERROR", err.Error())
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	manifest.Written = true
+
+	if IsJSONOutput() {
+		output, _ := json.MarshalIndent(manifest, "", "  ")
+		fmt.Fprintln(cmd.OutOrStdout(), string(output))
+		return nil
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s (%d %s, the prime summary, and the doctor report)\n", path, len(entries), entriesWord(len(entries)))
+	return nil
+}
+
+// shareTarget settles the bundle's format and path. The format defaults to
+// the output's extension, and the output to a timestamped file.
+func shareTarget(format, output string, now time.Time) (string, string, error) {
+	switch strings.ToLower(format) {
+	case "":
+		format = shareMarkdown
+		if strings.HasSuffix(output, ".tar.gz") || strings.HasSuffix(output, ".tgz") {
+			format = shareArchive
+		}
+	case "markdown", "md":
+		format = shareMarkdown
+	case "archive", "tar.gz", "tgz":
+		format = shareArchive
+	default:
+		return "", "", invalidInput("invalid --format '%s' (use markdown or archive)", format)
+	}
+	if output == "" {
+		output = "agentlog-share-" + now.Format("20060102-150405") + ".md"
+		if format == shareArchive {
+			output = strings.TrimSuffix(output, ".md") + ".tar.gz"
+		}
+	}
+	return format, output, nil
+}
+
+// shareFilters describes the filters given, for the bundle's header
+func shareFilters() []string {
+	var filters []string
+	for _, f := range []struct{ name, value string }{
+		{"since", shareSince}, {"type", shareType}, {"source", shareSource}, {"query", shareQuery},
+	} {
+		if f.value != "" {
+			filters = append(filters, fmt.Sprintf("--%s %s", f.name, f.value))
+		}
+	}
+	return filters
+}
+
+// shareHealth runs doctor's checks without posting a healthcheck entry or
+// fixing anything, since sharing shouldn't change the project
+func shareHealth(baseDir string) HealthResult {
+	offline, fix := doctorOffline, doctorFix
+	defer func() { doctorOffline, doctorFix = offline, fix }()
+	doctorOffline, doctorFix = true, false
+	return checkHealth(baseDir)
+}
+
+func newShareManifest(entries []ErrorEntry, matched int, format, path string) ShareManifest {
+	m := ShareManifest{Path: path, Format: format, Entries: len(entries), Matched: matched, ErrorTypes: []ErrorTypeCount{}}
+	if len(entries) > 0 {
+		m.First, m.Last = entries[0].Timestamp, entries[len(entries)-1].Timestamp
+	}
+	counts := make(map[string]int)
+	for _, e := range entries {
+		counts[e.ErrorType] += e.occurrences()
+	}
+	for _, l := range topLocations(counts, len(counts)) {
+		m.ErrorTypes = append(m.ErrorTypes, ErrorTypeCount{ErrorType: l.Name, Count: l.Count})
+	}
+	if format == shareArchive {
+		m.Files = []string{"bundle.md", "errors.jsonl", "prime.json", "doctor.json"}
+	}
+	return m
+}
+
+// formatShareManifest lists what a bundle holds, for confirming
+func formatShareManifest(m ShareManifest) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Bundle %s (%s) will include:\n", m.Path, m.Format))
+	span := ""
+	if m.First != "" {
+		span = fmt.Sprintf(", %s to %s", m.First, m.Last)
+	}
+	sb.WriteString(fmt.Sprintf("  %d %s (of %d matching)%s\n", m.Entries, entriesWord(m.Entries), m.Matched, span))
+	if len(m.ErrorTypes) > 0 {
+		var parts []string
+		for _, t := range m.ErrorTypes {
+			parts = append(parts, fmt.Sprintf("%s %d", t.ErrorType, t.Count))
+		}
+		sb.WriteString("    " + strings.Join(parts, ", ") + "\n")
+	}
+	if m.Format == shareArchive {
+		sb.WriteString("  Files: " + strings.Join(m.Files, ", ") + "\n")
+	} else {
+		sb.WriteString("  The prime summary and doctor report\n")
+	}
+	sb.WriteString("  Masked: " + strings.Join(m.Masked, ", ") + "\n")
+	if len(m.Omitted) > 0 {
+		sb.WriteString("  Left out: " + strings.Join(m.Omitted, ", ") + "\n")
+	}
+	return sb.String()
+}
+
+// confirmShare shows the manifest on stderr and asks whether to write the
+// bundle. Stdin that's a file but not a terminal can't answer, so --yes is
+// needed there.
+func confirmShare(cmd *cobra.Command, m ShareManifest) (bool, error) {
+	in := cmd.InOrStdin()
+	if f, ok := in.(*os.File); ok && !isTerminal(f) {
+		return false, invalidInput("stdin isn't a terminal; check the bundle with 'agentlog share' first, then pass --yes to write it")
+	}
+	out := cmd.ErrOrStderr()
+	fmt.Fprint(out, formatShareManifest(m))
+	fmt.Fprint(out, "Write it? [y/N]: ")
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// entriesWord is "entry" or "entries" for n
+func entriesWord(n int) string {
+	if n == 1 {
+		return "entry"
+	}
+	return "entries"
+}
+
+// shareScrubber masks what identifies the machine a bundle came from, and
+// secrets and personal data unless redaction is disabled
+type shareScrubber struct {
+	replacer *strings.Replacer
+	policy   *entryPolicy
+	redactor *redact.Redactor
+	masked   []string
+}
+
+func newShareScrubber(baseDir string) *shareScrubber {
+	policy := entryPolicyFor(baseDir)
+	s := &shareScrubber{policy: policy, redactor: policy.redactor}
+	var pairs []string
+	if abs, err := filepath.Abs(baseDir); err == nil {
+		// The directory itself becomes ".", and paths under it relative
+		pairs = append(pairs, abs+string(filepath.Separator), "."+string(filepath.Separator), abs, ".")
+		s.masked = append(s.masked, "project directory")
+	}
+	if home, err := os.UserHomeDir(); err == nil && len(home) > 1 {
+		pairs = append(pairs, home, "~")
+		s.masked = append(s.masked, "home directory")
+	}
+	if host, err := os.Hostname(); err == nil && host != "" && host != "localhost" {
+		pairs = append(pairs, host, "<hostname>")
+		s.masked = append(s.masked, "hostname")
+	}
+	s.masked = append(s.masked, "entry host and user")
+	if s.redactor != nil {
+		s.masked = append(s.masked, "secrets and personal data")
+	}
+	s.replacer = strings.NewReplacer(pairs...)
+	return s
+}
+
+// text masks str
+func (s *shareScrubber) text(str string) string {
+	return s.replacer.Replace(s.redactor.String(str))
+}
+
+// value masks every string in v, a decoded JSON value
+func (s *shareScrubber) value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return s.text(v)
+	case []interface{}:
+		for i := range v {
+			v[i] = s.value(v[i])
+		}
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = s.value(v[k])
+		}
+	}
+	return v
+}
+
+// entry masks e's fields, dropping its context unless withContext
+func (s *shareScrubber) entry(e ErrorEntry, withContext bool) ErrorEntry {
+	if e.ID == "" {
+		e.ID = entryID(e)
+	}
+	e = s.policy.apply(e)
+	e.Host, e.User = "", ""
+	e.Message = s.text(e.Message)
+	e.Source = s.text(e.Source)
+	e.File = s.text(e.File)
+	e.Endpoint = s.text(e.Endpoint)
+	for i, t := range e.Tags {
+		e.Tags[i] = s.text(t)
+	}
+	if withContext && e.Context != nil {
+		ctx := make(map[string]interface{}, len(e.Context))
+		for k, v := range e.Context {
+			ctx[k] = s.value(v)
+		}
+		e.Context = ctx
+	} else {
+		e.Context = nil
+	}
+	return e
+}
+
+// json marshals v with every string in it masked
+func (s *shareScrubber) json(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(s.value(decoded), "", "  ")
+}
+
+// shareBundle is what goes into a bundle, already masked
+type shareBundle struct {
+	Project  string
+	Now      time.Time
+	Entries  []ErrorEntry
+	Matched  int
+	Filters  []string
+	Manifest ShareManifest
+	Prime    string
+	Health   HealthResult
+}
+
+// markdown renders the bundle as one Markdown document
+func (b shareBundle) markdown() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# agentlog bundle: %s\n\n", b.Project))
+	filters := "no filters"
+	if len(b.Filters) > 0 {
+		filters = strings.Join(b.Filters, " ")
+	}
+	sb.WriteString(fmt.Sprintf("Generated %s by `agentlog share` (%s). The latest %d of %d matching %s.\n",
+		b.Now.UTC().Format(time.RFC3339), filters, len(b.Entries), b.Matched, entriesWord(b.Matched)))
+	sb.WriteString("Masked: " + strings.Join(b.Manifest.Masked, ", ") + ".")
+	if len(b.Manifest.Omitted) > 0 {
+		sb.WriteString(" Left out: " + strings.Join(b.Manifest.Omitted, ", ") + ".")
+	}
+	sb.WriteString("\n\n## Summary\n\n")
+	sb.WriteString(fenced("text", b.Prime))
+
+	sb.WriteString("\n## Health\n\n")
+	for _, c := range b.Health.Checks {
+		sb.WriteString(fmt.Sprintf("- %s %s: %s\n", getStatusIcon(c.Status), c.Name, c.Message))
+	}
+	sb.WriteString(fmt.Sprintf("\n%s. %s\n", strings.ToUpper(b.Health.Status), b.Health.Summary))
+
+	sb.WriteString("\n## Errors\n")
+	if len(b.Entries) == 0 {
+		sb.WriteString("\nNo matching entries.\n")
+	}
+	for i := len(b.Entries) - 1; i >= 0; i-- {
+		e := b.Entries[i]
+		sb.WriteString(fmt.Sprintf("\n### %s (%s) %s\n\n", e.ErrorType, e.Source, e.Timestamp))
+		sb.WriteString(fenced("text", e.Message))
+		var details []string
+		if e.File != "" {
+			loc := e.File
+			if e.Line > 0 {
+				loc = fmt.Sprintf("%s:%d", loc, e.Line)
+			}
+			details = append(details, "File: `"+loc+"`")
+		}
+		if e.Endpoint != "" {
+			details = append(details, "Endpoint: `"+e.Endpoint+"`")
+		}
+		if e.Environment != "" {
+			details = append(details, "Environment: "+e.Environment)
+		}
+		if len(e.Tags) > 0 {
+			details = append(details, "Tags: "+strings.Join(e.Tags, ", "))
+		}
+		if e.Count > 1 {
+			details = append(details, fmt.Sprintf("Repeated %d times from %s", e.Count, e.FirstSeen))
+		}
+		details = append(details, "ID: `"+e.ID+"`")
+		for _, d := range details {
+			sb.WriteString("- " + d + "\n")
+		}
+		if len(e.Context) > 0 {
+			data, _ := json.MarshalIndent(e.Context, "", "  ")
+			sb.WriteString("\n" + fenced("json", string(data)))
+		}
+	}
+
+	if len(b.Entries) > 0 {
+		sb.WriteString("\n<details>\n<summary>Entries as JSONL</summary>\n\n")
+		sb.WriteString(fenced("jsonl", string(b.jsonl())))
+		sb.WriteString("\n</details>\n")
+	}
+	return sb.String()
+}
+
+// jsonl is the entries as errors.jsonl lines, oldest first
+func (b shareBundle) jsonl() []byte {
+	var buf bytes.Buffer
+	for _, e := range b.Entries {
+		e.ID = ""
+		data, _ := json.Marshal(e)
+		buf.Write(append(data, '\n'))
+	}
+	return buf.Bytes()
+}
+
+// archive packs the bundle as a .tar.gz of bundle.md, errors.jsonl,
+// prime.json, and doctor.json
+func (b shareBundle) archive(scrub *shareScrubber, summary PrimeSummary) ([]byte, error) {
+	prime, err := scrub.json(summary)
+	if err != nil {
+		return nil, err
+	}
+	doctor, err := scrub.json(b.Health)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, f := range []struct {
+		name string
+		data []byte
+	}{
+		{"bundle.md", []byte(b.markdown())},
+		{"errors.jsonl", b.jsonl()},
+		{"prime.json", append(prime, '\n')},
+		{"doctor.json", append(doctor, '\n')},
+	} {
+		hdr := &tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.data)), ModTime: b.Now}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// fenced wraps s in a code fence longer than any run of backticks in it,
+// so messages holding fences of their own can't break out
+func fenced(lang, s string) string {
+	fence := "```"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + strings.TrimRight(s, "\n") + "\n" + fence + "\n"
+}
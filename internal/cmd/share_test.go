@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeShareLog writes a log whose entries name paths in the project
+func writeShareLog(t *testing.T) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	return writeTestLog(t, tmpDir, jsonLines([]ErrorEntry{
+		{Timestamp: "2025-12-10T10:00:00.000Z", Source: "backend", ErrorType: "SEED_ERROR", Message: "seed failed"},
+		{Timestamp: "2025-12-10T11:00:00.000Z", Source: "frontend", ErrorType: "UNCAUGHT_ERROR",
+			Message: "cannot read " + filepath.Join(tmpDir, "src", "app.ts"), File: filepath.Join(tmpDir, "src", "app.ts"), Line: 12, Host: "devbox-9", User: "ann",
+			Context: map[string]interface{}{"route": "/checkout"}},
+		{Timestamp: "2025-12-10T12:00:00.000Z", Source: "frontend", ErrorType: "UNCAUGHT_ERROR", Message: "```\nfenced\n```"},
+	}))
+}
+
+func resetShareFlags() {
+	shareSince, shareType, shareSource, shareQuery = "", "", "", ""
+	shareLimit, shareContext, shareFormat, shareOutput, shareYes = 50, false, "", "", false
+}
+
+func TestShareTarget(t *testing.T) {
+	now := time.Date(2025, 12, 10, 15, 4, 5, 0, time.Local)
+	tests := []struct {
+		format, output     string
+		wantFormat, wantTo string
+	}{
+		{"", "", shareMarkdown, "agentlog-share-20251210-150405.md"},
+		{"archive", "", shareArchive, "agentlog-share-20251210-150405.tar.gz"},
+		{"", "bug.tgz", shareArchive, "bug.tgz"},
+		{"md", "-", shareMarkdown, "-"},
+	}
+	for _, tt := range tests {
+		format, path, err := shareTarget(tt.format, tt.output, now)
+		if err != nil || format != tt.wantFormat || path != tt.wantTo {
+			t.Errorf("shareTarget(%q, %q) = %q, %q, %v", tt.format, tt.output, format, path, err)
+		}
+	}
+	if _, _, err := shareTarget("zip", "", now); err == nil {
+		t.Error("shareTarget(zip) should fail")
+	}
+}
+
+func TestShareCommand_Markdown(t *testing.T) {
+	tmpDir := writeShareLog(t)
+	originalPath, originalJSON := pathOverride, jsonOutput
+	defer func() { pathOverride, jsonOutput = originalPath, originalJSON; resetShareFlags() }()
+	pathOverride, jsonOutput = tmpDir, false
+	resetShareFlags()
+	out := filepath.Join(t.TempDir(), "bundle.md")
+	shareType, shareOutput, shareYes = "UNCAUGHT_ERROR", out, true
+
+	buf := new(bytes.Buffer)
+	shareCmd.SetOut(buf)
+	defer shareCmd.SetOut(nil)
+	if err := runShare(shareCmd, nil); err != nil {
+		t.Fatalf("runShare() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "Wrote "+out+" (2 entries") {
+		t.Errorf("output = %q", buf.String())
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := string(data)
+	rel := "." + string(filepath.Separator) + filepath.Join("src", "app.ts")
+	if strings.Contains(md, tmpDir) {
+		t.Errorf("the bundle should mask the project directory:\n%s", md)
+	}
+	for _, want := range []string{
+		"(--type UNCAUGHT_ERROR). The latest 2 of 2 matching entries.",
+		"## Summary", "## Health", "## Errors",
+		"cannot read " + rel,
+		"- File: `" + rel + ":12`",
+		"````text\n```\nfenced\n```\n````",
+		"Left out: context",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("bundle should contain %q:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "SEED_ERROR (backend)") || strings.Contains(md, "/checkout") {
+		t.Errorf("the bundle should hold only matching entries, without context:\n%s", md)
+	}
+	if entries, _ := readErrors(tmpDir); len(entries) != 3 {
+		t.Errorf("share shouldn't write to the log (doctor's healthcheck), got %d entries", len(entries))
+	}
+}
+
+func TestShareCommand_Archive(t *testing.T) {
+	tmpDir := writeShareLog(t)
+	originalPath, originalJSON := pathOverride, jsonOutput
+	defer func() { pathOverride, jsonOutput = originalPath, originalJSON; resetShareFlags() }()
+	pathOverride, jsonOutput = tmpDir, true
+	resetShareFlags()
+	out := filepath.Join(t.TempDir(), "bug.tar.gz")
+	shareOutput, shareYes, shareContext, shareLimit = out, true, true, 2
+
+	buf := new(bytes.Buffer)
+	shareCmd.SetOut(buf)
+	defer shareCmd.SetOut(nil)
+	if err := runShare(shareCmd, nil); err != nil {
+		t.Fatalf("runShare() error = %v", err)
+	}
+	var m ShareManifest
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if !m.Written || m.Format != shareArchive || m.Entries != 2 || m.Matched != 3 || len(m.ErrorTypes) != 1 || len(m.Omitted) != 0 {
+		t.Errorf("manifest = %+v", m)
+	}
+
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	members := make(map[string]string)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(tr)
+		members[hdr.Name] = string(data)
+	}
+	for _, name := range m.Files {
+		if members[name] == "" {
+			t.Errorf("archive is missing %s", name)
+		}
+	}
+	if lines := strings.Count(members["errors.jsonl"], "\n"); lines != 2 {
+		t.Errorf("errors.jsonl has %d lines, want 2", lines)
+	}
+	if !strings.Contains(members["errors.jsonl"], "/checkout") {
+		t.Errorf("--include-context should keep context:\n%s", members["errors.jsonl"])
+	}
+	if strings.Contains(members["errors.jsonl"], "devbox-9") {
+		t.Errorf("entries' host and user should be left out:\n%s", members["errors.jsonl"])
+	}
+	for name, data := range members {
+		if strings.Contains(data, tmpDir) {
+			t.Errorf("%s should mask the project directory:\n%s", name, data)
+		}
+	}
+}
+
+func TestShareCommand_Confirm(t *testing.T) {
+	tmpDir := writeShareLog(t)
+	originalPath, originalJSON := pathOverride, jsonOutput
+	defer func() { pathOverride, jsonOutput = originalPath, originalJSON; resetShareFlags() }()
+	pathOverride, jsonOutput = tmpDir, false
+	resetShareFlags()
+	out := filepath.Join(t.TempDir(), "bundle.md")
+	shareOutput = out
+
+	stderr := new(bytes.Buffer)
+	shareCmd.SetOut(new(bytes.Buffer))
+	shareCmd.SetErr(stderr)
+	defer func() { shareCmd.SetOut(nil); shareCmd.SetErr(nil); shareCmd.SetIn(nil) }()
+
+	shareCmd.SetIn(strings.NewReader("n\n"))
+	if err := runShare(shareCmd, nil); err != nil {
+		t.Fatalf("runShare() error = %v", err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Error("declining should write nothing")
+	}
+	for _, want := range []string{"will include:", "3 entries (of 3 matching)", "UNCAUGHT_ERROR 2, SEED_ERROR 1", "Masked: project directory", "Write it? [y/N]"} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("prompt should contain %q:\n%s", want, stderr.String())
+		}
+	}
+
+	shareCmd.SetIn(strings.NewReader("y\n"))
+	if err := runShare(shareCmd, nil); err != nil {
+		t.Fatalf("runShare() error = %v", err)
+	}
+	if _, err := os.Stat(out); err != nil {
+		t.Errorf("confirming should write the bundle: %v", err)
+	}
+}
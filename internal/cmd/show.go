@@ -0,0 +1,383 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/self"
+	"github.com/spf13/cobra"
+)
+
+var showLimit int
+
+// correlationKeys are the context keys that tie entries from one session,
+// request, or trace together
+var correlationKeys = []string{"request_id", "trace_id", "session_id"}
+
+// ShowResult is everything known about one error
+type ShowResult struct {
+	Entry ErrorEntry `json:"entry"`
+	Group ErrorGroup `json:"group"`
+	// Occurrences are the group's entries, newest first
+	Occurrences      []ErrorEntry     `json:"occurrences"`
+	TotalOccurrences int              `json:"total_occurrences"`
+	Related          []RelatedEntries `json:"related,omitempty"`
+	// Status is the group's: open, resolved, regressed (occurred since it
+	// was resolved), or snoozed
+	Status       string `json:"status"`
+	ResolvedAt   string `json:"resolved_at,omitempty"`
+	SnoozedUntil string `json:"snoozed_until,omitempty"`
+}
+
+// RelatedEntries are other entries sharing a correlation key's value with
+// the shown entry, newest first
+type RelatedEntries struct {
+	Key     string       `json:"key"`
+	Value   string       `json:"value"`
+	Entries []ErrorEntry `json:"entries"`
+}
+
+// showCmd represents the show command
+var showCmd = &cobra.Command{
+	Use:   "show <id|fingerprint>",
+	Short: "Show everything known about one error",
+	Long: `Show a single error in full: every field and context value, the history of
+similar errors (its group, as in errors --group), and other entries
+sharing its request_id, trace_id, or session_id.
+
+The argument is an entry ID (or a unique prefix) as shown by errors and
+tail, or a group fingerprint from errors --group, which shows the group's
+most recent entry.
+
+Examples:
+  agentlog show 3f9a2c1b7e       # One entry by ID
+  agentlog show 3f9a             # A unique prefix works
+  agentlog show 8c1d2e3f4a5b     # Latest entry in a group
+  agentlog show 3f9a --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runShow,
+}
+
+func init() {
+	rootCmd.AddCommand(showCmd)
+
+	showCmd.Flags().IntVar(&showLimit, "limit", 10, "Maximum occurrences and related entries to list")
+}
+
+func runShow(cmd *cobra.Command, args []string) error {
+	// Determine base directory (use --path override or cwd)
+	baseDir := GetPathOverride()
+	if baseDir == "" {
+		var err error
+		baseDir, err = os.Getwd()
+		if err != nil {
+			self.LogError(".", "GETWD_ERROR", err.Error())
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+	if uninitialized(baseDir) {
+		return exitNotInitialized(cmd)
+	}
+
+	entries, err := readErrors(baseDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no errors file found; run 'agentlog init' to set up")
+		}
+		return err
+	}
+
+	// Marked and judged as errors does, so the two agree on the group
+	resolutions, snoozes := loadResolutions(baseDir), loadSnoozes(baseDir, time.Now())
+	entries = entryPolicyFor(baseDir).applyAll(snoozes.mark(resolutions.mark(entries)))
+	result, err := showEntry(entries, args[0], showLimit)
+	if err != nil {
+		return err
+	}
+	result.setStatus(resolutions, snoozes)
+	result.Group.PastFixes = loadFixes(baseDir).find(result.Group.ErrorType, result.Group.Pattern, time.Time{})
+
+	if IsJSONOutput() {
+		output, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Fprintln(cmd.OutOrStdout(), string(output))
+		return nil
+	}
+
+	fmt.Fprint(cmd.OutOrStdout(), formatShowHuman(result))
+	return nil
+}
+
+// showEntry finds the entry ref names (an ID prefix, or a group fingerprint
+// prefix) and collects its group and related entries, keeping at most limit
+// of each (0 for all)
+func showEntry(entries []ErrorEntry, ref string, limit int) (ShowResult, error) {
+	ref = strings.ToLower(strings.TrimSpace(ref))
+	if ref == "" {
+		return ShowResult{}, fmt.Errorf("an ID or fingerprint is required")
+	}
+
+	clusters := clusterEntries(entries)
+	entry, cluster, err := resolveShowRef(entries, clusters, ref)
+	if err != nil {
+		return ShowResult{}, err
+	}
+
+	result := ShowResult{
+		Entry:            entry,
+		Group:            summarizeCluster(cluster),
+		Occurrences:      newestFirst(cluster.entries, limit),
+		TotalOccurrences: len(cluster.entries),
+	}
+
+	for _, key := range correlationKeys {
+		value, ok := entry.Context[key]
+		if !ok || value == nil {
+			continue
+		}
+		want := formatCell(value)
+		var related []ErrorEntry
+		for _, e := range entries {
+			if e.ID != entry.ID && e.Context[key] != nil && formatCell(e.Context[key]) == want {
+				related = append(related, e)
+			}
+		}
+		if len(related) > 0 {
+			result.Related = append(result.Related, RelatedEntries{Key: key, Value: want, Entries: newestFirst(related, limit)})
+		}
+	}
+	return result, nil
+}
+
+// resolveShowRef matches ref against entry IDs, then group fingerprints.
+// Identical entries share an ID, so several matches with one ID aren't
+// ambiguous; the latest is used.
+func resolveShowRef(entries []ErrorEntry, clusters []*errorCluster, ref string) (ErrorEntry, *errorCluster, error) {
+	var ids []string
+	matched := make(map[string]ErrorEntry)
+	for _, e := range entries {
+		if strings.HasPrefix(e.ID, ref) {
+			if _, seen := matched[e.ID]; !seen {
+				ids = append(ids, e.ID)
+			}
+			matched[e.ID] = e
+		}
+	}
+	if len(ids) > 1 {
+		sort.Strings(ids)
+		if len(ids) > 5 {
+			ids = append(ids[:5], "...")
+		}
+		return ErrorEntry{}, nil, fmt.Errorf("'%s' matches %d entries (%s); use more characters", ref, len(matched), strings.Join(ids, ", "))
+	}
+	if len(ids) == 1 {
+		entry := matched[ids[0]]
+		for _, c := range clusters {
+			for _, e := range c.entries {
+				if e.ID == entry.ID {
+					return entry, c, nil
+				}
+			}
+		}
+	}
+
+	var found []*errorCluster
+	for _, c := range clusters {
+		if strings.HasPrefix(c.fingerprint(), ref) {
+			found = append(found, c)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return ErrorEntry{}, nil, fmt.Errorf("no entry or group matches '%s'", ref)
+	case 1:
+		c := found[0]
+		latest := c.entries[0]
+		for _, e := range c.entries[1:] {
+			if !timestampBefore(e.Timestamp, latest.Timestamp) {
+				latest = e
+			}
+		}
+		return latest, c, nil
+	}
+	return ErrorEntry{}, nil, fmt.Errorf("'%s' matches %d groups; use more characters", ref, len(found))
+}
+
+// newestFirst returns up to limit entries (0 for all), most recent first
+func newestFirst(entries []ErrorEntry, limit int) []ErrorEntry {
+	sorted := append([]ErrorEntry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool { return timestampBefore(sorted[j].Timestamp, sorted[i].Timestamp) })
+	if limit > 0 && len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+	return sorted
+}
+
+// attribution names who recorded e and where: "ann@devbox", or whichever
+// of the two it has
+func attribution(e ErrorEntry) string {
+	switch {
+	case e.Host != "" && e.User != "":
+		return e.User + "@" + e.Host
+	case e.Host != "":
+		return e.Host
+	}
+	return e.User
+}
+
+// writeContextLines lists context values one per line, sorted by key, with
+// multi-line values (stack traces) indented below their key
+func writeContextLines(sb *strings.Builder, ctx map[string]interface{}) {
+	if len(ctx) == 0 {
+		return
+	}
+	sb.WriteString("  Context:\n")
+	keys := make([]string, 0, len(ctx))
+	for k := range ctx {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		value, ok := ctx[k].(string)
+		if !ok {
+			data, _ := json.Marshal(ctx[k])
+			value = string(data)
+		}
+		if !strings.Contains(value, "\n") {
+			sb.WriteString(fmt.Sprintf("    %s: %s\n", k, value))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("    %s:\n", k))
+		for _, line := range strings.Split(strings.TrimRight(value, "\n"), "\n") {
+			sb.WriteString("      " + line + "\n")
+		}
+	}
+}
+
+// formatShowHuman formats a show result for human-readable output
+func formatShowHuman(r ShowResult) string {
+	var sb strings.Builder
+	e := r.Entry
+
+	sb.WriteString(fmt.Sprintf("Error: %s\n", e.Message))
+	meta := fmt.Sprintf("  ID: %s | Source: %s | Type: %s", e.ID, e.Source, e.ErrorType)
+	if e.Environment != "" {
+		meta += fmt.Sprintf(" | Env: %s", e.Environment)
+	}
+	if e.Kind == kindPerf {
+		meta += " | Kind: perf"
+	}
+	sb.WriteString(meta + "\n")
+	if e.DurationMs > 0 {
+		sb.WriteString(fmt.Sprintf("  Duration: %s\n", formatDuration(e.DurationMs)))
+	}
+	if e.Project != "" {
+		sb.WriteString(fmt.Sprintf("  Project: %s\n", e.Project))
+	}
+	if e.Host != "" || e.User != "" {
+		sb.WriteString(fmt.Sprintf("  Recorded by: %s\n", attribution(e)))
+	}
+	if e.Agent != "" {
+		sb.WriteString(fmt.Sprintf("  Agent: %s\n", e.Agent))
+	}
+	if loc := formatLocation(e); loc != "" {
+		sb.WriteString(fmt.Sprintf("  Location: %s\n", loc))
+	}
+	if e.Endpoint != "" {
+		sb.WriteString(fmt.Sprintf("  Endpoint: %s\n", e.Endpoint))
+	}
+	if len(e.Tags) > 0 {
+		sb.WriteString(fmt.Sprintf("  Tags: %s\n", strings.Join(e.Tags, ", ")))
+	}
+	if e.Count > 1 {
+		sb.WriteString(fmt.Sprintf("  Seen: %dx since %s\n", e.Count, formatTimestamp(e.FirstSeen)))
+	}
+	sb.WriteString(fmt.Sprintf("  Time: %s\n", formatShowTime(e.Timestamp)))
+	if r.Status != "" && r.Status != "open" {
+		sb.WriteString(fmt.Sprintf("  Status: %s\n", formatShowStatus(r)))
+	}
+
+	writeContextLines(&sb, e.Context)
+
+	g := r.Group
+	sb.WriteString(fmt.Sprintf("\nHistory: %d occurrences since %s | Fingerprint: %s\n", g.Count, formatTimestamp(g.FirstSeen), g.Fingerprint))
+	sb.WriteString(fmt.Sprintf("  Pattern: %s\n", g.Pattern))
+	for _, o := range r.Occurrences {
+		sb.WriteString(formatShowLine(o, o.ID == e.ID))
+	}
+	if len(r.Occurrences) < r.TotalOccurrences {
+		sb.WriteString(fmt.Sprintf("  (showing %d of %d entries; use --limit to see more)\n", len(r.Occurrences), r.TotalOccurrences))
+	}
+	if len(g.PastFixes) > 0 {
+		sb.WriteString("\nPast fixes:\n")
+		for _, f := range g.PastFixes {
+			sb.WriteString(fmt.Sprintf("  %s\n", f))
+		}
+	}
+
+	for _, rel := range r.Related {
+		sb.WriteString(fmt.Sprintf("\nRelated by %s=%s:\n", rel.Key, rel.Value))
+		for _, o := range rel.Entries {
+			sb.WriteString(formatShowLine(o, false))
+		}
+	}
+	return sb.String()
+}
+
+// setStatus sets r's status from its group's resolution and snooze, going
+// by the latest occurrence
+func (r *ShowResult) setStatus(resolutions *resolutionSet, snoozes *snoozeSet) {
+	latest := r.Entry
+	if len(r.Occurrences) > 0 {
+		latest = r.Occurrences[0]
+	}
+	r.Status = "open"
+	if res := resolutions.match(latest); res != nil {
+		r.Status, r.ResolvedAt = "regressed", res.ResolvedAt
+	} else if res := resolutions.find(latest, false); res != nil {
+		r.Status, r.ResolvedAt = "resolved", res.ResolvedAt
+	}
+	if i := snoozes.match(latest); i >= 0 && snoozes.snoozes[i].active {
+		r.Status, r.SnoozedUntil = "snoozed", snoozes.snoozes[i].Until
+	}
+}
+
+// formatShowStatus describes a status other than open, e.g. "regressed
+// (resolved 2d ago)"
+func formatShowStatus(r ShowResult) string {
+	switch r.Status {
+	case "resolved":
+		return "resolved " + formatTimestamp(r.ResolvedAt)
+	case "regressed":
+		return fmt.Sprintf("regressed (resolved %s)", formatTimestamp(r.ResolvedAt))
+	case "snoozed":
+		return "snoozed until " + formatTimestamp(r.SnoozedUntil)
+	}
+	return r.Status
+}
+
+// formatShowTime shows both the relative and absolute time, since show is
+// where the exact timestamp matters
+func formatShowTime(ts string) string {
+	if rel := formatTimestamp(ts); rel != ts {
+		return fmt.Sprintf("%s (%s)", rel, ts)
+	}
+	return ts
+}
+
+// formatShowLine formats one entry of a history or related list, marking
+// the shown entry
+func formatShowLine(e ErrorEntry, current bool) string {
+	marker := " "
+	if current {
+		marker = "*"
+	}
+	line := fmt.Sprintf(" %s %s  %s  %s: %s", marker, formatTimestamp(e.Timestamp), e.ID, e.ErrorType, singleLine(e.Message))
+	if e.Count > 1 {
+		line += fmt.Sprintf(" (%dx)", e.Count)
+	}
+	return line + "\n"
+}
@@ -0,0 +1,449 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/config"
+	"github.com/agentlog/agentlog/internal/diag"
+	"github.com/agentlog/agentlog/internal/logfile"
+	"github.com/agentlog/agentlog/internal/self"
+	"github.com/spf13/cobra"
+)
+
+// Ways similar compares groups
+const (
+	similarTokens     = "tokens"
+	similarEmbeddings = "embeddings"
+)
+
+const (
+	embeddingsFileName        = "embeddings.json"
+	defaultEmbeddingsEndpoint = "http://localhost:11434/v1/embeddings"
+	embeddingsTimeout         = 30 * time.Second
+)
+
+// similarMinScores are the default --min-score per method. Embeddings of
+// unrelated error messages still score around 0.5, where shared words
+// rarely pass 0.2.
+var similarMinScores = map[string]float64{similarTokens: 0.3, similarEmbeddings: 0.75}
+
+// otherTypeWeight scales the word overlap of groups of another error type,
+// so the same message under the same type ranks first
+const otherTypeWeight = 0.75
+
+var (
+	similarLimit    int
+	similarMinScore float64
+	similarMethod   string
+)
+
+// SimilarResult is an error and the groups most like it
+type SimilarResult struct {
+	Entry      ErrorEntry     `json:"entry"`
+	Group      ErrorGroup     `json:"group"`
+	Resolution *Resolution    `json:"resolution,omitempty"` // the group's own, if it was resolved
+	Method     string         `json:"method"`
+	Similar    []SimilarGroup `json:"similar"`
+}
+
+// SimilarGroup is another error group like the one looked up, with its
+// resolution if it was resolved. A resolved group whose entries are no
+// longer in the log has Count 0.
+type SimilarGroup struct {
+	ErrorGroup
+	Score      float64     `json:"score"`
+	Resolution *Resolution `json:"resolution,omitempty"`
+}
+
+// similarCmd represents the similar command
+var similarCmd = &cobra.Command{
+	Use:   "similar <id|fingerprint>",
+	Short: "Find past errors like this one, and how they were resolved",
+	Long: `Find the error groups most like an entry's (by ID) or a group's (by
+fingerprint), across the whole log, with the resolution of any that were
+marked resolved with 'agentlog resolve'. Resolved groups whose entries have
+since been rotated out of the log are still found. This answers "have we
+hit this before, and what fixed it?"
+
+Groups are compared by the words their patterns share, ignoring numbers,
+IDs, and paths (the Jaccard index), with groups of another error type
+weighted down. Errors scoring 0.7 or more against a group are grouped into
+it by 'errors --group', so other groups of the same type usually score
+below that.
+
+With "embeddings" in .agentlog/config.json, groups are compared by the
+cosine similarity of embedding vectors instead, which also finds errors
+worded differently. Any OpenAI-compatible embeddings endpoint works; the
+default is a local Ollama server:
+  {"embeddings": {"model": "nomic-embed-text"}}
+  {"embeddings": {"model": "text-embedding-3-small", "endpoint": "https://api.openai.com/v1/embeddings", "api_key_env": "OPENAI_API_KEY"}}
+Vectors are kept in .agentlog/embeddings.json, so each pattern is embedded
+once. Patterns are masked like errors output before they're sent. If the
+endpoint can't be reached, similar falls back to shared words.
+
+Examples:
+  agentlog similar 3f9a2c1b7e          # Groups like this entry's
+  agentlog similar 8c1d2e3f4a5b --limit 3
+  agentlog similar 3f9a --method tokens  # Shared words even with embeddings set
+  agentlog similar 3f9a --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSimilar,
+}
+
+func init() {
+	rootCmd.AddCommand(similarCmd)
+
+	similarCmd.Flags().IntVar(&similarLimit, "limit", 5, "Most similar groups to list")
+	similarCmd.Flags().Float64Var(&similarMinScore, "min-score", 0, "Lowest score listed, from 0 to 1 (default: 0.3 for tokens, 0.75 for embeddings)")
+	similarCmd.Flags().StringVar(&similarMethod, "method", "", "Compare by tokens (shared words) or embeddings (default: embeddings when configured, else tokens)")
+}
+
+func runSimilar(cmd *cobra.Command, args []string) error {
+	// Determine base directory (use --path override or cwd)
+	baseDir := GetPathOverride()
+	if baseDir == "" {
+		var err error
+		baseDir, err = os.Getwd()
+		if err != nil {
+			self.LogError(".", "GETWD_ERROR", err.Error())
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+	if uninitialized(baseDir) {
+		return exitNotInitialized(cmd)
+	}
+
+	if similarLimit <= 0 {
+		return invalidInput("--limit must be positive")
+	}
+	if similarMinScore < 0 || similarMinScore > 1 {
+		return invalidInput("--min-score must be between 0 and 1")
+	}
+	cfg, err := config.Load(baseDir)
+	if err != nil {
+		return err
+	}
+	method := similarMethod
+	switch method {
+	case "":
+		method = similarTokens
+		if cfg.Embeddings.Model != "" {
+			method = similarEmbeddings
+		}
+	case similarTokens:
+	case similarEmbeddings:
+		if cfg.Embeddings.Model == "" {
+			return invalidInput(`--method embeddings needs a model: set "embeddings": {"model": "..."} in .agentlog/config.json`)
+		}
+	default:
+		return invalidInput("invalid --method %q: use %s or %s", method, similarTokens, similarEmbeddings)
+	}
+
+	entries, err := readErrors(baseDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no errors file found; run 'agentlog init' to set up")
+		}
+		return err
+	}
+
+	result, err := findSimilar(baseDir, entryPolicyFor(baseDir).applyAll(entries), args[0], method, cfg.Embeddings)
+	if err != nil {
+		return err
+	}
+
+	if IsJSONOutput() {
+		output, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Fprintln(cmd.OutOrStdout(), string(output))
+		return nil
+	}
+	fmt.Fprint(cmd.OutOrStdout(), formatSimilarHuman(result))
+	return nil
+}
+
+// findSimilar looks up the group ref names and scores every other group in
+// entries, and every resolved group, against it
+func findSimilar(baseDir string, entries []ErrorEntry, ref, method string, embeddings config.EmbeddingsConfig) (SimilarResult, error) {
+	ref = strings.ToLower(strings.TrimSpace(ref))
+	if ref == "" {
+		return SimilarResult{}, fmt.Errorf("an ID or fingerprint is required")
+	}
+	clusters := clusterEntries(entries)
+	entry, cluster, err := resolveShowRef(entries, clusters, ref)
+	if err != nil {
+		return SimilarResult{}, err
+	}
+	result := SimilarResult{Entry: entry, Group: summarizeCluster(cluster), Method: method, Similar: []SimilarGroup{}}
+
+	resolved := make(map[string]*Resolution)
+	records, err := readResolutions(baseDir)
+	if err != nil && !os.IsNotExist(err) {
+		diag.Debugf("reading %s: %v", resolvedPath(baseDir), err)
+	}
+	latest := latestResolutions(records)
+	for i := range latest {
+		if !latest[i].Reopened {
+			resolved[latest[i].Fingerprint] = &latest[i]
+		}
+	}
+	result.Resolution = resolved[result.Group.Fingerprint]
+	fixes := loadFixes(baseDir)
+	result.Group.PastFixes = fixes.find(result.Group.ErrorType, result.Group.Pattern, time.Time{})
+
+	var candidates []SimilarGroup
+	inLog := map[string]bool{result.Group.Fingerprint: true}
+	for _, c := range clusters {
+		if c == cluster {
+			continue
+		}
+		g := summarizeCluster(c)
+		g.PastFixes = fixes.find(g.ErrorType, g.Pattern, time.Time{})
+		inLog[g.Fingerprint] = true
+		candidates = append(candidates, SimilarGroup{ErrorGroup: g, Resolution: resolved[g.Fingerprint]})
+	}
+	redactor := entryPolicyFor(baseDir).redactor
+	for _, r := range latest {
+		if !inLog[r.Fingerprint] && resolved[r.Fingerprint] != nil {
+			pattern := redactor.String(r.Pattern)
+			g := ErrorGroup{Fingerprint: r.Fingerprint, ErrorType: r.ErrorType, Pattern: pattern, Message: pattern, Sources: []string{}}
+			g.PastFixes = fixes.find(r.ErrorType, r.Pattern, time.Time{})
+			candidates = append(candidates, SimilarGroup{ErrorGroup: g, Resolution: resolved[r.Fingerprint]})
+		}
+	}
+
+	if method == similarEmbeddings {
+		if err := scoreByEmbeddings(baseDir, embeddings, result.Group, candidates); err != nil {
+			diag.Warnf("embeddings unavailable (%v); comparing shared words instead", err)
+			method, result.Method = similarTokens, similarTokens
+		}
+	}
+	if method == similarTokens {
+		target := messageTokens(result.Group.Pattern)
+		for i := range candidates {
+			candidates[i].Score = jaccard(target, messageTokens(candidates[i].Pattern))
+			if candidates[i].ErrorType != result.Group.ErrorType {
+				candidates[i].Score *= otherTypeWeight
+			}
+		}
+	}
+
+	minScore := similarMinScore
+	if minScore == 0 {
+		minScore = similarMinScores[method]
+	}
+	for _, c := range candidates {
+		if c.Score >= minScore {
+			c.Score = math.Round(c.Score*100) / 100
+			result.Similar = append(result.Similar, c)
+		}
+	}
+	sort.SliceStable(result.Similar, func(i, j int) bool {
+		a, b := result.Similar[i], result.Similar[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return timestampBefore(b.LastSeen, a.LastSeen)
+	})
+	if len(result.Similar) > similarLimit {
+		result.Similar = result.Similar[:similarLimit]
+	}
+	return result, nil
+}
+
+// embeddingText is what's embedded for a group
+func embeddingText(errorType, pattern string) string {
+	return errorType + ": " + pattern
+}
+
+// scoreByEmbeddings sets each candidate's Score to the cosine similarity of
+// its embedding and target's, embedding the patterns not yet in the index
+func scoreByEmbeddings(baseDir string, cfg config.EmbeddingsConfig, target ErrorGroup, candidates []SimilarGroup) error {
+	index := loadEmbeddingIndex(baseDir, cfg.Model)
+	redactor := entryPolicyFor(baseDir).redactor
+	text := func(g ErrorGroup) string { return redactor.String(embeddingText(g.ErrorType, g.Pattern)) }
+
+	var missing []string
+	queued := make(map[string]bool)
+	for _, g := range append([]ErrorGroup{target}, groupsOf(candidates)...) {
+		t := text(g)
+		if _, ok := index.Vectors[t]; !ok && !queued[t] {
+			queued[t] = true
+			missing = append(missing, t)
+		}
+	}
+	if len(missing) > 0 {
+		vectors, err := embed(cfg, missing)
+		if err != nil {
+			return err
+		}
+		for i, t := range missing {
+			index.Vectors[t] = vectors[i]
+		}
+		if err := index.save(baseDir); err != nil {
+			diag.Debugf("saving %s: %v", embeddingsFileName, err)
+		}
+	}
+
+	want := index.Vectors[text(target)]
+	for i := range candidates {
+		candidates[i].Score = cosine(want, index.Vectors[text(candidates[i].ErrorGroup)])
+	}
+	return nil
+}
+
+func groupsOf(candidates []SimilarGroup) []ErrorGroup {
+	groups := make([]ErrorGroup, len(candidates))
+	for i, c := range candidates {
+		groups[i] = c.ErrorGroup
+	}
+	return groups
+}
+
+// embeddingIndex is .agentlog/embeddings.json: the vectors of embedded
+// texts, for one model
+type embeddingIndex struct {
+	Model   string               `json:"model"`
+	Vectors map[string][]float64 `json:"vectors"`
+}
+
+func embeddingsPath(baseDir string) string {
+	return filepath.Join(baseDir, ".agentlog", embeddingsFileName)
+}
+
+// loadEmbeddingIndex reads the index, starting over if it's missing,
+// unreadable, or for another model
+func loadEmbeddingIndex(baseDir, model string) *embeddingIndex {
+	index := &embeddingIndex{Model: model, Vectors: make(map[string][]float64)}
+	data, err := os.ReadFile(embeddingsPath(baseDir))
+	if err != nil {
+		return index
+	}
+	var stored embeddingIndex
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Model != model || stored.Vectors == nil {
+		return index
+	}
+	return &stored
+}
+
+func (x *embeddingIndex) save(baseDir string) error {
+	data, err := json.Marshal(x)
+	if err != nil {
+		return err
+	}
+	return logfile.WriteFile(embeddingsPath(baseDir), data, 0644)
+}
+
+// embed asks the endpoint for the vectors of texts, in order
+func embed(cfg config.EmbeddingsConfig, texts []string) ([][]float64, error) {
+	endpoint := firstNonEmpty(cfg.Endpoint, defaultEmbeddingsEndpoint)
+	data, err := json.Marshal(map[string]interface{}{"model": cfg.Model, "input": texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cfg.APIKeyEnv != "" {
+		if key := os.Getenv(cfg.APIKeyEnv); key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+	}
+
+	resp, err := (&http.Client{Timeout: embeddingsTimeout}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s answered %s: %s", endpoint, resp.Status, truncate(singleLine(string(body)), 300))
+	}
+	var r struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("unreadable response: %w", err)
+	}
+	vectors := make([][]float64, len(texts))
+	for _, d := range r.Data {
+		if d.Index >= 0 && d.Index < len(vectors) {
+			vectors[d.Index] = d.Embedding
+		}
+	}
+	for _, v := range vectors {
+		if len(v) == 0 {
+			return nil, errors.New("the response is missing embeddings")
+		}
+	}
+	return vectors, nil
+}
+
+// cosine returns the cosine similarity of a and b, or 0 if they can't be
+// compared
+func cosine(a, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// formatSimilarHuman formats the looked-up group and the ones like it
+func formatSimilarHuman(r SimilarResult) string {
+	var sb strings.Builder
+	g := r.Group
+	sb.WriteString(fmt.Sprintf("%s: %s\n", g.ErrorType, r.Entry.Message))
+	sb.WriteString(fmt.Sprintf("  Group: %s | %d occurrences since %s\n", g.Fingerprint, g.Count, formatTimestamp(g.FirstSeen)))
+	if r.Resolution != nil {
+		sb.WriteString(fmt.Sprintf("  Resolved: %s\n", formatTimestamp(r.Resolution.ResolvedAt)))
+	}
+	writeFixLines(&sb, "  ", g.PastFixes)
+
+	if len(r.Similar) == 0 {
+		sb.WriteString("\nNo similar errors found.\n")
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("\nSimilar errors (by %s):\n", r.Method))
+	for _, s := range r.Similar {
+		sb.WriteString(fmt.Sprintf("  %.2f  %s  %s: %s\n", s.Score, s.Fingerprint, s.ErrorType, truncate(singleLine(s.Message), 100)))
+		if s.Count > 0 {
+			sb.WriteString(fmt.Sprintf("        Seen %dx, last %s\n", s.Count, formatTimestamp(s.LastSeen)))
+		} else {
+			sb.WriteString("        No longer in the log\n")
+		}
+		if s.Resolution != nil {
+			sb.WriteString(fmt.Sprintf("        %s %s\n", colors.warn("Resolved"), formatTimestamp(s.Resolution.ResolvedAt)))
+		}
+		for _, f := range s.PastFixes {
+			sb.WriteString(fmt.Sprintf("        Fix: %s\n", f))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/config"
+)
+
+func resetSimilarFlags() {
+	similarLimit, similarMinScore, similarMethod = 5, 0, ""
+}
+
+// writeSimilarLog writes a database error and others more or less like
+// it, with one resolved and another resolved group no longer in the log
+func writeSimilarLog(t *testing.T) string {
+	t.Helper()
+	now := time.Now().UTC()
+	at := func(d time.Duration) string { return now.Add(d).Format(time.RFC3339Nano) }
+	dir := writeEntriesLog(t, []ErrorEntry{
+		{Timestamp: at(-3 * time.Hour), Source: "backend", ErrorType: "DATABASE_ERROR", Message: "connection refused while connecting to replica"},
+		{Timestamp: at(-2 * time.Hour), Source: "frontend", ErrorType: "NETWORK_ERROR", Message: "connection refused on port 6379"},
+		{Timestamp: at(-time.Hour), Source: "frontend", ErrorType: "UNCAUGHT_ERROR", Message: "x is undefined"},
+		{Timestamp: at(-time.Minute), Source: "backend", ErrorType: "DATABASE_ERROR", Message: "connection refused on port 5432 while connecting"},
+	})
+	resolveAt(t, dir, now.Add(-150*time.Minute), fingerprint("DATABASE_ERROR", "connection refused while connecting to replica"))
+	recordResolutions(dir, []Resolution{{
+		Fingerprint: fingerprint("DATABASE_ERROR", "connection refused on port <n> at startup"),
+		ErrorType:   "DATABASE_ERROR",
+		Pattern:     "connection refused on port <n> at startup",
+		ResolvedAt:  now.Add(-7 * 24 * time.Hour).Format(time.RFC3339),
+	}})
+	return dir
+}
+
+func TestFindSimilar_Tokens(t *testing.T) {
+	dir := writeSimilarLog(t)
+	defer resetSimilarFlags()
+	resetSimilarFlags()
+	entries, _ := readErrors(dir)
+
+	result, err := findSimilar(dir, entries, entries[3].ID, similarTokens, config.EmbeddingsConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range result.Similar {
+		got = append(got, s.ErrorType+" "+s.Pattern)
+	}
+	want := []string{
+		"DATABASE_ERROR connection refused on port <n> at startup",
+		"NETWORK_ERROR connection refused on port <n>",
+		"DATABASE_ERROR connection refused while connecting to replica",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("similar =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if s := result.Similar[0]; s.Count != 0 || s.Resolution == nil || s.Score != 0.56 {
+		t.Errorf("the resolved group no longer in the log = %+v", s)
+	}
+	if s := result.Similar[1]; s.Score != 0.54 || s.Resolution != nil {
+		t.Errorf("another type should score 3/4 of its word overlap, got %+v", s)
+	}
+	if s := result.Similar[2]; s.Resolution == nil || s.Count != 1 {
+		t.Errorf("the resolved group in the log = %+v", s)
+	}
+	if result.Resolution != nil {
+		t.Errorf("the group itself isn't resolved, got %+v", result.Resolution)
+	}
+
+	similarLimit, similarMinScore = 1, 0.6
+	if result, _ := findSimilar(dir, entries, entries[3].ID, similarTokens, config.EmbeddingsConfig{}); len(result.Similar) != 0 {
+		t.Errorf("nothing scores 0.6, got %+v", result.Similar)
+	}
+	if _, err := findSimilar(dir, entries, "zzzz", similarTokens, config.EmbeddingsConfig{}); err == nil {
+		t.Error("expected an error for an unknown ref")
+	}
+}
+
+func TestFindSimilar_Embeddings(t *testing.T) {
+	dir := writeSimilarLog(t)
+	defer resetSimilarFlags()
+	resetSimilarFlags()
+	entries, _ := readErrors(dir)
+
+	// The fake model puts the undefined error right next to the database
+	// error, which shares no words with it
+	var requests, texts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var body struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		texts += len(body.Input)
+		type datum struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		}
+		var data []datum
+		for i, in := range body.Input {
+			v := []float64{0, 1}
+			if strings.Contains(in, "undefined") || strings.Contains(in, "while connecting") {
+				v = []float64{1, 0.1}
+			}
+			data = append(data, datum{Index: i, Embedding: v})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	defer srv.Close()
+	cfg := config.EmbeddingsConfig{Model: "test-embed", Endpoint: srv.URL}
+
+	result, err := findSimilar(dir, entries, entries[3].ID, similarEmbeddings, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Method != similarEmbeddings || len(result.Similar) != 2 || result.Similar[0].ErrorType != "UNCAUGHT_ERROR" || result.Similar[0].Score != 1 {
+		t.Fatalf("similar = %+v", result.Similar)
+	}
+	if requests != 1 || texts != 5 {
+		t.Errorf("expected one request for the 5 patterns, got %d for %d", requests, texts)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".agentlog", embeddingsFileName)); err != nil {
+		t.Errorf("expected the index to be saved: %v", err)
+	}
+
+	if _, err := findSimilar(dir, entries, entries[3].ID, similarEmbeddings, cfg); err != nil || requests != 1 {
+		t.Errorf("indexed patterns shouldn't be embedded again, got %d requests (%v)", requests, err)
+	}
+
+	// Another model starts over; an unreachable one falls back to words
+	srv.Close()
+	cfg.Model = "other-embed"
+	result, err = findSimilar(dir, entries, entries[3].ID, similarEmbeddings, cfg)
+	if err != nil || result.Method != similarTokens || len(result.Similar) != 3 {
+		t.Errorf("expected the token fallback, got %s %+v (%v)", result.Method, result.Similar, err)
+	}
+}
+
+func TestCosine(t *testing.T) {
+	tests := []struct {
+		a, b []float64
+		want float64
+	}{
+		{[]float64{1, 0}, []float64{1, 0}, 1},
+		{[]float64{1, 0}, []float64{0, 1}, 0},
+		{[]float64{1, 1}, []float64{2, 2}, 1},
+		{[]float64{1, 0}, []float64{1, 0, 0}, 0},
+		{nil, nil, 0},
+		{[]float64{0, 0}, []float64{1, 0}, 0},
+	}
+	for _, tt := range tests {
+		if got := cosine(tt.a, tt.b); got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("cosine(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSimilarCommand(t *testing.T) {
+	dir := writeSimilarLog(t)
+	originalPath, originalJSON := pathOverride, jsonOutput
+	defer func() { pathOverride, jsonOutput = originalPath, originalJSON; resetSimilarFlags() }()
+	pathOverride, jsonOutput = dir, false
+	resetSimilarFlags()
+
+	entries, _ := readErrors(dir)
+	buf := new(bytes.Buffer)
+	similarCmd.SetOut(buf)
+	defer similarCmd.SetOut(nil)
+	if err := runSimilar(similarCmd, []string{entries[3].ID[:6]}); err != nil {
+		t.Fatalf("runSimilar() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"DATABASE_ERROR: connection refused on port 5432 while connecting\n",
+		"\nSimilar errors (by tokens):\n  0.56  ",
+		"DATABASE_ERROR: connection refused on port <n> at startup\n        No longer in the log\n        Resolved 7d ago\n",
+		"Seen 1x, last 2h ago\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q:\n%s", want, out)
+		}
+	}
+
+	jsonOutput = true
+	buf.Reset()
+	if err := runSimilar(similarCmd, []string{entries[0].ID}); err != nil {
+		t.Fatal(err)
+	}
+	var result SimilarResult
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil || result.Resolution == nil || result.Method != similarTokens {
+		t.Errorf("JSON = %s (%v)", buf.String(), err)
+	}
+
+	similarMethod = "embeddings"
+	if err := runSimilar(similarCmd, []string{entries[0].ID}); ExitCode(err) != ExitError || !strings.Contains(err.Error(), "needs a model") {
+		t.Errorf("--method embeddings without a model should exit 2, got %v", err)
+	}
+}
@@ -0,0 +1,476 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/diag"
+	"github.com/agentlog/agentlog/internal/logfile"
+	"github.com/agentlog/agentlog/internal/self"
+	"github.com/spf13/cobra"
+)
+
+var (
+	snoozeFor  string
+	snoozeUndo bool
+	snoozeList bool
+)
+
+const snoozedFileName = "snoozed.jsonl"
+
+// Snooze is one line of .agentlog/snoozed.jsonl: an error group hidden
+// until a deadline, or with Cancelled, shown again early. The latest line
+// for a fingerprint wins.
+type Snooze struct {
+	Fingerprint string `json:"fingerprint"`
+	ErrorType   string `json:"error_type"`
+	Pattern     string `json:"pattern"`
+	SnoozedAt   string `json:"snoozed_at"`
+	Until       string `json:"until"`
+	Cancelled   bool   `json:"cancelled,omitempty"`
+}
+
+// SnoozeExpiry marks an entry or group of a snoozed error that occurred
+// since it was snoozed, once the snooze is over
+type SnoozeExpiry struct {
+	Fingerprint string `json:"fingerprint"` // the snoozed group's
+	SnoozedAt   string `json:"snoozed_at"`
+	Until       string `json:"until"`
+}
+
+// snoozeCmd represents the snooze command
+var snoozeCmd = &cobra.Command{
+	Use:   "snooze <id|fingerprint>... --for <duration>",
+	Short: "Hide errors for a while, then flag them if they're still happening",
+	Long: `Hide the error groups of entries (by ID) or groups (by fingerprint, from
+errors --group) from errors and prime until a deadline: a known error someone
+is already on, or noise from a dependency that's down for the afternoon.
+
+When the snooze is over the group shows up again, and its entries from
+while it was hidden and after are flagged SNOOZE EXPIRED (JSON:
+snooze_expired on entries and groups), so an error that outlasted the
+snooze isn't mistaken for a new one. Snooze it again to push the deadline
+back, or resolve it once it's fixed.
+
+Snoozes are kept in .agentlog/snoozed.jsonl. Later entries count as the
+same error when they'd group with the snoozed one, as with resolve.
+
+Examples:
+  agentlog snooze 8c1d2e3f4a5b --for 2d   # Hide a group for two days
+  agentlog snooze 3f9a 77b0 --for 4h      # The groups of two entries
+  agentlog snooze --list                  # What's snoozed, and until when
+  agentlog snooze --undo 8c1d             # Show it again now`,
+	RunE: runSnooze,
+}
+
+func init() {
+	rootCmd.AddCommand(snoozeCmd)
+
+	snoozeCmd.Flags().StringVar(&snoozeFor, "for", "", "How long to hide the errors (e.g. 30m, 4h, 2d, 1w)")
+	snoozeCmd.Flags().BoolVar(&snoozeUndo, "undo", false, "End the snooze of these groups now")
+	snoozeCmd.Flags().BoolVar(&snoozeList, "list", false, "List snoozed errors and how much they've occurred since")
+}
+
+func runSnooze(cmd *cobra.Command, args []string) error {
+	// Determine base directory (use --path override or cwd)
+	baseDir := GetPathOverride()
+	if baseDir == "" {
+		var err error
+		baseDir, err = os.Getwd()
+		if err != nil {
+			self.LogError(".", "GETWD_ERROR", err.Error())
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+	if uninitialized(baseDir) {
+		return exitNotInitialized(cmd)
+	}
+
+	now := time.Now()
+	if snoozeList {
+		if len(args) > 0 || snoozeUndo || snoozeFor != "" {
+			return invalidInput("--list takes no IDs and can't be combined with --undo or --for")
+		}
+		return listSnoozes(cmd, baseDir, now)
+	}
+	if len(args) == 0 {
+		return invalidInput("an ID or fingerprint is required (see 'agentlog errors --group')")
+	}
+
+	var records []Snooze
+	var err error
+	if snoozeUndo {
+		if snoozeFor != "" {
+			return invalidInput("--undo can't be combined with --for")
+		}
+		records, err = unsnoozeRefs(baseDir, args, now)
+	} else {
+		if snoozeFor == "" {
+			return invalidInput("--for is required, e.g. --for 2d")
+		}
+		d, ok := parseSpan(snoozeFor)
+		if !ok || d <= 0 {
+			return invalidInput("invalid --for '%s' (use e.g. 30m, 4h, 2d, or 1w)", snoozeFor)
+		}
+		records, err = snoozeRefs(baseDir, args, now, d)
+	}
+	if err != nil {
+		self.LogError(baseDir, "INVALID_INPUT", err.Error())
+		return invalidInput("%w", err)
+	}
+	if err := recordSnoozes(baseDir, records); err != nil {
+		self.LogError(baseDir, "FILE_WRITE_ERROR", err.Error())
+		return fmt.Errorf("failed to record the snooze: %w", err)
+	}
+
+	w := cmd.OutOrStdout()
+	if IsJSONOutput() {
+		output, _ := json.MarshalIndent(records, "", "  ")
+		fmt.Fprintln(w, string(output))
+		return nil
+	}
+	redactor := entryPolicyFor(baseDir).redactor
+	for _, s := range records {
+		if snoozeUndo {
+			fmt.Fprintf(w, "Unsnoozed %s %s: %s\n", s.Fingerprint, colors.err(s.ErrorType), redactor.String(s.Pattern))
+			continue
+		}
+		fmt.Fprintf(w, "Snoozed %s %s: %s\n", s.Fingerprint, colors.err(s.ErrorType), redactor.String(s.Pattern))
+	}
+	if !snoozeUndo {
+		fmt.Fprintln(w, colors.dim(fmt.Sprintf("Hidden from errors and prime until %s; if they're still happening then, they're flagged SNOOZE EXPIRED.", formatUntil(records[0].Until, now))))
+	}
+	return nil
+}
+
+// snoozeRefs finds the groups refs name, as resolve does, and returns a
+// snooze of each for d
+func snoozeRefs(baseDir string, refs []string, now time.Time, d time.Duration) ([]Snooze, error) {
+	groups, err := resolveRefs(baseDir, refs, now)
+	if err != nil {
+		return nil, err
+	}
+	until := now.Add(d).UTC().Format(time.RFC3339)
+	records := make([]Snooze, len(groups))
+	for i, g := range groups {
+		records[i] = Snooze{Fingerprint: g.Fingerprint, ErrorType: g.ErrorType, Pattern: g.Pattern, SnoozedAt: g.ResolvedAt, Until: until}
+	}
+	return records, nil
+}
+
+// unsnoozeRefs matches refs against the fingerprints of snoozed groups
+func unsnoozeRefs(baseDir string, refs []string, now time.Time) ([]Snooze, error) {
+	set := loadSnoozes(baseDir, now)
+	var records []Snooze
+	for _, ref := range refs {
+		ref = strings.ToLower(strings.TrimSpace(ref))
+		var found []Snooze
+		for _, s := range set.snoozes {
+			if s.active && strings.HasPrefix(s.Fingerprint, ref) {
+				found = append(found, s.Snooze)
+			}
+		}
+		switch len(found) {
+		case 0:
+			return nil, fmt.Errorf("no snoozed group matches '%s' (see 'agentlog snooze --list')", ref)
+		case 1:
+			s := found[0]
+			s.Until, s.Cancelled = now.UTC().Format(time.RFC3339), true
+			records = append(records, s)
+		default:
+			return nil, fmt.Errorf("'%s' matches %d snoozed groups; use more characters", ref, len(found))
+		}
+	}
+	return records, nil
+}
+
+// SnoozeStatus is a snooze and how much its group occurred since it began
+type SnoozeStatus struct {
+	Snooze
+	Expired     bool   `json:"expired"`
+	Occurrences int    `json:"occurrences"`         // since it was snoozed
+	LastSeen    string `json:"last_seen,omitempty"` // latest of those
+}
+
+// listSnoozes prints the snoozes, the ones in effect first, with how often
+// their groups occurred since
+func listSnoozes(cmd *cobra.Command, baseDir string, now time.Time) error {
+	set := loadSnoozes(baseDir, now)
+	entries, err := readErrors(baseDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	statuses := make([]SnoozeStatus, len(set.snoozes))
+	for i, s := range set.snoozes {
+		statuses[i] = SnoozeStatus{Snooze: s.Snooze, Expired: !s.active}
+	}
+	for _, e := range entries {
+		i := set.match(e)
+		if i < 0 || !set.snoozes[i].since(e) {
+			continue
+		}
+		statuses[i].Occurrences += e.occurrences()
+		if statuses[i].LastSeen == "" || timestampBefore(statuses[i].LastSeen, e.Timestamp) {
+			statuses[i].LastSeen = e.Timestamp
+		}
+	}
+	sort.SliceStable(statuses, func(i, j int) bool {
+		if statuses[i].Expired != statuses[j].Expired {
+			return !statuses[i].Expired
+		}
+		return timestampBefore(statuses[j].SnoozedAt, statuses[i].SnoozedAt)
+	})
+
+	w := cmd.OutOrStdout()
+	if IsJSONOutput() {
+		output, _ := json.MarshalIndent(statuses, "", "  ")
+		fmt.Fprintln(w, string(output))
+		return nil
+	}
+	if len(statuses) == 0 {
+		fmt.Fprintln(w, "No errors are snoozed. Snooze one with 'agentlog snooze <fingerprint> --for 2d'.")
+		return nil
+	}
+	redactor := entryPolicyFor(baseDir).redactor
+	for _, s := range statuses {
+		state := "until " + formatUntil(s.Until, now)
+		if s.Expired {
+			state = colors.warn("expired " + formatTimestamp(s.Until))
+		}
+		line := fmt.Sprintf("%s  %s  %s  %s", s.Fingerprint, s.ErrorType, state, truncate(redactor.String(s.Pattern), 80))
+		if s.Occurrences > 0 {
+			line += colors.dim(fmt.Sprintf("  %dx since snoozed, last %s", s.Occurrences, formatTimestamp(s.LastSeen)))
+		}
+		fmt.Fprintln(w, line)
+	}
+	return nil
+}
+
+// formatUntil describes a deadline, e.g. "2025-12-12 09:30 (in 2d)"
+func formatUntil(until string, now time.Time) string {
+	t, err := parseEntryTime(until)
+	if err != nil || IsAbsoluteTime() {
+		return until
+	}
+	d := t.Sub(now).Round(time.Minute)
+	var left string
+	switch {
+	case d < time.Minute:
+		left = "now"
+	case d < time.Hour:
+		left = fmt.Sprintf("in %dm", int(d/time.Minute))
+	case d < 24*time.Hour:
+		left = fmt.Sprintf("in %dh", int(d/time.Hour))
+	default:
+		left = fmt.Sprintf("in %dd", int(d/(24*time.Hour)))
+	}
+	return fmt.Sprintf("%s (%s)", t.Local().Format("2006-01-02 15:04"), left)
+}
+
+func snoozedPath(baseDir string) string {
+	return filepath.Join(baseDir, ".agentlog", snoozedFileName)
+}
+
+// recordSnoozes appends records to .agentlog/snoozed.jsonl
+func recordSnoozes(baseDir string, records []Snooze) error {
+	if err := os.MkdirAll(filepath.Join(baseDir, ".agentlog"), 0755); err != nil {
+		return err
+	}
+	var sb strings.Builder
+	for _, s := range records {
+		data, _ := json.Marshal(s)
+		sb.Write(append(data, '\n'))
+	}
+	return logfile.Append(snoozedPath(baseDir), []byte(sb.String()))
+}
+
+// readSnoozes reads .agentlog/snoozed.jsonl, skipping lines that don't
+// parse
+func readSnoozes(baseDir string) ([]Snooze, error) {
+	f, err := os.Open(snoozedPath(baseDir))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Snooze
+	err = scanRecords(baseDir, f, func(line []byte) error {
+		var s Snooze
+		if err := json.Unmarshal(line, &s); err != nil {
+			return err
+		}
+		if s.Fingerprint != "" {
+			records = append(records, s)
+		}
+		return nil
+	})
+	return records, err
+}
+
+// parsedSnooze is the latest snooze of a group, parsed for matching
+type parsedSnooze struct {
+	Snooze
+	snoozed time.Time
+	active  bool // the deadline hasn't passed
+	tokens  map[string]bool
+}
+
+// since reports whether e occurred after the group was snoozed
+func (s parsedSnooze) since(e ErrorEntry) bool {
+	ts, err := parseEntryTime(e.Timestamp)
+	return err == nil && ts.After(s.snoozed)
+}
+
+// snoozeSet is the snoozes of a project that weren't cancelled, as of a
+// given time
+type snoozeSet struct {
+	snoozes []parsedSnooze
+}
+
+// loadSnoozes reads the snoozes as of now. A missing or unreadable file
+// means none.
+func loadSnoozes(baseDir string, now time.Time) *snoozeSet {
+	set := &snoozeSet{}
+	records, err := readSnoozes(baseDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			diag.Debugf("reading %s: %v", snoozedPath(baseDir), err)
+		}
+		return set
+	}
+
+	latest := make(map[string]Snooze)
+	var order []string
+	for _, s := range records {
+		if _, ok := latest[s.Fingerprint]; !ok {
+			order = append(order, s.Fingerprint)
+		}
+		latest[s.Fingerprint] = s
+	}
+	for _, fp := range order {
+		s := latest[fp]
+		snoozed, err := parseEntryTime(s.SnoozedAt)
+		if err != nil || s.Cancelled {
+			continue
+		}
+		until, err := parseEntryTime(s.Until)
+		if err != nil {
+			continue
+		}
+		set.snoozes = append(set.snoozes, parsedSnooze{Snooze: s, snoozed: snoozed, active: now.Before(until), tokens: messageTokens(s.Pattern)})
+	}
+	return set
+}
+
+// match returns the index of the snooze of the group e would join (see
+// clusterEntries), or -1
+func (s *snoozeSet) match(e ErrorEntry) int {
+	if len(s.snoozes) == 0 || e.kind() != kindError {
+		return -1
+	}
+	pattern := normalizeMessage(e.Message)
+	var tokens map[string]bool
+	for i, sn := range s.snoozes {
+		if sn.ErrorType != e.ErrorType {
+			continue
+		}
+		if sn.Pattern != pattern {
+			if tokens == nil {
+				tokens = messageTokens(pattern)
+			}
+			if !wouldGroup(tokens, sn.tokens) {
+				continue
+			}
+		}
+		return i
+	}
+	return -1
+}
+
+// snoozed reports whether e belongs to a group snoozed right now
+func (s *snoozeSet) snoozed(e ErrorEntry) bool {
+	i := s.match(e)
+	return i >= 0 && s.snoozes[i].active
+}
+
+// hide returns entries without those of groups snoozed right now
+func (s *snoozeSet) hide(entries []ErrorEntry) []ErrorEntry {
+	if len(s.snoozes) == 0 {
+		return entries
+	}
+	kept := make([]ErrorEntry, 0, len(entries))
+	for _, e := range entries {
+		if !s.snoozed(e) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// mark sets SnoozeExpired, in place, on the entries of groups whose snooze
+// is over that occurred since they were snoozed, and returns them
+func (s *snoozeSet) mark(entries []ErrorEntry) []ErrorEntry {
+	if len(s.snoozes) == 0 {
+		return entries
+	}
+	for i := range entries {
+		if j := s.match(entries[i]); j >= 0 && !s.snoozes[j].active && s.snoozes[j].since(entries[i]) {
+			sn := s.snoozes[j]
+			entries[i].SnoozeExpired = &SnoozeExpiry{Fingerprint: sn.Fingerprint, SnoozedAt: sn.SnoozedAt, Until: sn.Until}
+		}
+	}
+	return entries
+}
+
+// ExpiredSnooze is a snoozed group that kept occurring past its snooze
+type ExpiredSnooze struct {
+	Fingerprint string `json:"fingerprint"` // the snoozed group's
+	ErrorType   string `json:"error_type"`
+	Pattern     string `json:"pattern"`
+	Until       string `json:"until"`
+	LastSeen    string `json:"last_seen"`
+}
+
+// String describes the expired snooze, e.g. `DATABASE_ERROR "connection
+// refused" is still happening (snooze ended 3h ago, last seen 5m ago)`
+func (x ExpiredSnooze) String() string {
+	return fmt.Sprintf("%s %q is still happening (snooze ended %s, last seen %s)", x.ErrorType, truncate(x.Pattern, 80), formatTimestamp(x.Until), formatTimestamp(x.LastSeen))
+}
+
+// expired returns the groups whose snooze is over that entries include
+// occurrences of since they were snoozed, most recently seen first
+func (s *snoozeSet) expired(entries []ErrorEntry) []ExpiredSnooze {
+	byFingerprint := make(map[string]*ExpiredSnooze)
+	for _, e := range entries {
+		j := s.match(e)
+		if j < 0 || s.snoozes[j].active || !s.snoozes[j].since(e) {
+			continue
+		}
+		sn := s.snoozes[j]
+		x := byFingerprint[sn.Fingerprint]
+		if x == nil {
+			x = &ExpiredSnooze{Fingerprint: sn.Fingerprint, ErrorType: sn.ErrorType, Pattern: sn.Pattern, Until: sn.Until}
+			byFingerprint[sn.Fingerprint] = x
+		}
+		if x.LastSeen == "" || timestampBefore(x.LastSeen, e.Timestamp) {
+			x.LastSeen = e.Timestamp
+		}
+	}
+
+	result := make([]ExpiredSnooze, 0, len(byFingerprint))
+	for _, x := range byFingerprint {
+		result = append(result, *x)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].LastSeen != result[j].LastSeen {
+			return timestampBefore(result[j].LastSeen, result[i].LastSeen)
+		}
+		return result[i].Fingerprint < result[j].Fingerprint
+	})
+	return result
+}
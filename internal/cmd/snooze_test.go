@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func resetSnoozeFlags() {
+	snoozeFor, snoozeUndo, snoozeList = "", false, false
+}
+
+// snoozeAt snoozes the group of ref from at until until
+func snoozeAt(t *testing.T, dir, ref string, at, until time.Time) {
+	t.Helper()
+	records, err := snoozeRefs(dir, []string{ref}, at, until.Sub(at))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := recordSnoozes(dir, records); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeSnoozeLog writes writeResolveLog's log with its database error
+// snoozed until half an hour ago and the undefined error for another hour
+func writeSnoozeLog(t *testing.T, now time.Time) string {
+	t.Helper()
+	dir := writeResolveLog(t, now)
+	snoozeAt(t, dir, fingerprint("DATABASE_ERROR", "connection refused on port <n>"), now.Add(-150*time.Minute), now.Add(-30*time.Minute))
+	snoozeAt(t, dir, fingerprint("UNCAUGHT_ERROR", "x is undefined"), now.Add(-time.Minute), now.Add(time.Hour))
+	return dir
+}
+
+func TestSnoozeSet(t *testing.T) {
+	now := time.Now()
+	dir := writeSnoozeLog(t, now)
+	set := loadSnoozes(dir, now)
+	entries, _ := readErrors(dir)
+
+	shown := set.mark(set.hide(entries))
+	if len(shown) != 3 {
+		t.Fatalf("the undefined error should be hidden, got %+v", shown)
+	}
+	var flagged []bool
+	for _, e := range shown {
+		flagged = append(flagged, e.SnoozeExpired != nil)
+	}
+	if flagged[0] || !flagged[1] || !flagged[2] {
+		t.Errorf("entries since the database error was snoozed should be flagged, got %v", flagged)
+	}
+
+	expired := set.expired(entries)
+	if len(expired) != 1 || expired[0].String() != `DATABASE_ERROR "connection refused on port <n>" is still happening (snooze ended 30m ago, last seen 10m ago)` {
+		t.Errorf("expired = %+v", expired)
+	}
+
+	// An hour and a half on, both are over
+	if later := loadSnoozes(dir, now.Add(90*time.Minute)); len(later.hide(entries)) != 4 {
+		t.Error("nothing should be hidden once every snooze is over")
+	}
+}
+
+func TestSnoozeCommand(t *testing.T) {
+	dir := writeResolveLog(t, time.Now())
+	originalPath, originalJSON := pathOverride, jsonOutput
+	defer func() { pathOverride, jsonOutput = originalPath, originalJSON; resetSnoozeFlags() }()
+	pathOverride, jsonOutput = dir, false
+	resetSnoozeFlags()
+
+	entries, _ := readErrors(dir)
+	buf := new(bytes.Buffer)
+	snoozeCmd.SetOut(buf)
+	defer snoozeCmd.SetOut(nil)
+	if err := runSnooze(snoozeCmd, []string{entries[2].ID[:6]}); ExitCode(err) != ExitError {
+		t.Errorf("snooze without --for should exit 2, got %v", err)
+	}
+	snoozeFor = "soon"
+	if err := runSnooze(snoozeCmd, []string{entries[2].ID[:6]}); ExitCode(err) != ExitError {
+		t.Errorf("an invalid --for should exit 2, got %v", err)
+	}
+
+	snoozeFor = "2d"
+	if err := runSnooze(snoozeCmd, []string{entries[2].ID[:6]}); err != nil {
+		t.Fatalf("runSnooze() error = %v", err)
+	}
+	fp := fingerprint("UNCAUGHT_ERROR", "x is undefined")
+	if !strings.HasPrefix(buf.String(), "Snoozed "+fp+" UNCAUGHT_ERROR: x is undefined\nHidden from errors and prime until 20") {
+		t.Errorf("output = %q", buf.String())
+	}
+	if !strings.Contains(buf.String(), " (in 2d); ") {
+		t.Errorf("expected the time left in %q", buf.String())
+	}
+
+	snoozeFor, snoozeList, jsonOutput = "", true, true
+	buf.Reset()
+	if err := runSnooze(snoozeCmd, nil); err != nil {
+		t.Fatalf("runSnooze(--list) error = %v", err)
+	}
+	var statuses []SnoozeStatus
+	if err := json.Unmarshal(buf.Bytes(), &statuses); err != nil || len(statuses) != 1 || statuses[0].Expired || statuses[0].Occurrences != 0 {
+		t.Errorf("--list = %s (%v)", buf.String(), err)
+	}
+
+	snoozeList, snoozeUndo, jsonOutput = false, true, false
+	buf.Reset()
+	if err := runSnooze(snoozeCmd, []string{fp[:4]}); err != nil {
+		t.Fatalf("runSnooze(--undo) error = %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "Unsnoozed "+fp) || len(loadSnoozes(dir, time.Now()).snoozes) != 0 {
+		t.Errorf("--undo should end the snooze, got %q", buf.String())
+	}
+	if err := runSnooze(snoozeCmd, []string{fp[:4]}); ExitCode(err) != ExitError {
+		t.Errorf("--undo of a group that isn't snoozed should exit 2, got %v", err)
+	}
+}
+
+func TestRenderErrors_Snoozed(t *testing.T) {
+	dir := writeSnoozeLog(t, time.Now())
+	defer func() { errorsLimit, errorsGroup, jsonOutput = 10, false, false }()
+	errorsLimit, errorsSource, errorsType, errorsSince = 10, "", "", ""
+	errorsCount, errorsGroup, errorsPick, errorsFields, errorsTemplate, jsonOutput = false, false, false, "", "", false
+
+	var buf bytes.Buffer
+	if err := renderErrors(&buf, dir); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "x is undefined") || strings.Count(out, "SNOOZE EXPIRED: ") != 2 || !strings.Contains(out, "SNOOZE EXPIRED: connection refused on port 5433\n  Snoozed: 2h ago, until 30m ago (group ") {
+		t.Errorf("the snoozed error should be hidden and the expired one flagged:\n%s", out)
+	}
+
+	buf.Reset()
+	errorsGroup, jsonOutput = true, true
+	if err := renderErrors(&buf, dir); err != nil {
+		t.Fatal(err)
+	}
+	var groups []ErrorGroup
+	if err := json.Unmarshal(buf.Bytes(), &groups); err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || groups[0].SnoozeExpired == nil {
+		t.Errorf("groups = %+v", groups)
+	}
+}
+
+func TestPrimeSummary_Snoozed(t *testing.T) {
+	dir := writeSnoozeLog(t, time.Now())
+
+	summary := primeInDir(t, dir, false)
+	if summary.TotalErrors != 3 || len(summary.TopErrorTypes) != 1 {
+		t.Errorf("the snoozed error should be left out, got %d errors, types %+v", summary.TotalErrors, summary.TopErrorTypes)
+	}
+	if len(summary.SnoozeExpired) != 1 || summary.SnoozeExpired[0].ErrorType != "DATABASE_ERROR" {
+		t.Fatalf("SnoozeExpired = %+v", summary.SnoozeExpired)
+	}
+	if out := formatPrimeSummaryHuman(summary); !strings.Contains(out, `  Snooze expired: DATABASE_ERROR "connection refused on port <n>" is still happening`) {
+		t.Errorf("expected the expired snooze in:\n%s", out)
+	}
+	if claude := formatPrimeClaude(summary); !strings.Contains(claude, "outlasted") {
+		t.Errorf("the claude preset should explain the expired snooze:\n%s", claude)
+	}
+}
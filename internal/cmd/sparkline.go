@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"strings"
+	"time"
+)
+
+// sparkBlocks draw a sparkline, from no entries to the busiest hour
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// trendHours is how far back a trend goes, one count per hour
+const trendHours = 24
+
+// sparkline draws counts as one block each, scaled to the largest. Empty
+// hours are the lowest block, so any count at all stands out from them.
+func sparkline(counts []int) string {
+	peak := 0
+	for _, n := range counts {
+		peak = max(peak, n)
+	}
+	var sb strings.Builder
+	levels := len(sparkBlocks) - 1
+	for _, n := range counts {
+		i := 0
+		if n > 0 {
+			i = (n*levels + peak - 1) / peak // ceiling, so 1..levels
+		}
+		sb.WriteRune(sparkBlocks[i])
+	}
+	return sb.String()
+}
+
+// trendCounter counts entries per type for each of the trendHours hours
+// up to end
+type trendCounter struct {
+	end    time.Time
+	counts map[string][]int
+}
+
+func newTrendCounter(end time.Time) *trendCounter {
+	return &trendCounter{end: end, counts: make(map[string][]int)}
+}
+
+func (c *trendCounter) add(e ErrorEntry) {
+	ts, err := parseEntryTime(e.Timestamp)
+	if err != nil || ts.After(c.end) {
+		return
+	}
+	ago := int(c.end.Sub(ts) / time.Hour)
+	if ago >= trendHours {
+		return
+	}
+	counts := c.counts[e.ErrorType]
+	if counts == nil {
+		counts = make([]int, trendHours)
+		c.counts[e.ErrorType] = counts
+	}
+	counts[trendHours-1-ago] += e.occurrences()
+}
+
+func (c *trendCounter) merge(other *trendCounter) {
+	for errType, from := range other.counts {
+		into := c.counts[errType]
+		if into == nil {
+			into = make([]int, trendHours)
+			c.counts[errType] = into
+		}
+		for i, n := range from {
+			into[i] += n
+		}
+	}
+}
+
+// fill sets each type's Trend, leaving it nil for types with nothing in
+// the last trendHours
+func (c *trendCounter) fill(types []ErrorTypeCount) {
+	for i := range types {
+		types[i].Trend = c.counts[types[i].ErrorType]
+	}
+}
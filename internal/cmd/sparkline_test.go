@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		counts []int
+		want   string
+	}{
+		{[]int{0, 1, 2, 3, 4, 5, 6, 7}, "▁▂▃▄▅▆▇█"},
+		{[]int{0, 0, 0}, "▁▁▁"},
+		{[]int{1, 100, 0}, "▂█▁"}, // a single entry still rises off the baseline
+		{[]int{5, 5}, "██"},
+	}
+	for _, tt := range tests {
+		if got := sparkline(tt.counts); got != tt.want {
+			t.Errorf("sparkline(%v) = %q, want %q", tt.counts, got, tt.want)
+		}
+	}
+}
+
+func TestTrendCounter(t *testing.T) {
+	end := time.Date(2025, 12, 10, 20, 0, 0, 0, time.UTC)
+	at := func(ago time.Duration) string { return end.Add(-ago).Format(time.RFC3339) }
+
+	c := newTrendCounter(end)
+	c.add(ErrorEntry{ErrorType: "DB", Timestamp: at(10 * time.Minute)})
+	c.add(ErrorEntry{ErrorType: "DB", Timestamp: at(30 * time.Minute), Count: 2})
+	c.add(ErrorEntry{ErrorType: "DB", Timestamp: at(23*time.Hour + 59*time.Minute)})
+	c.add(ErrorEntry{ErrorType: "DB", Timestamp: at(24 * time.Hour)}) // too old
+	c.add(ErrorEntry{ErrorType: "DB", Timestamp: at(-time.Minute)})   // after end
+	other := newTrendCounter(end)
+	other.add(ErrorEntry{ErrorType: "NET", Timestamp: at(5 * time.Hour)})
+	other.add(ErrorEntry{ErrorType: "DB", Timestamp: at(90 * time.Minute)})
+	c.merge(other)
+
+	types := []ErrorTypeCount{{ErrorType: "DB"}, {ErrorType: "NET"}, {ErrorType: "OLD"}}
+	c.fill(types)
+	db := types[0].Trend
+	if len(db) != trendHours || db[23] != 3 || db[22] != 1 || db[0] != 1 {
+		t.Errorf("DB trend = %v, want 3 in the last hour, 1 the hour before, and 1 in the first", db)
+	}
+	if types[1].Trend[18] != 1 {
+		t.Errorf("NET trend = %v", types[1].Trend)
+	}
+	if types[2].Trend != nil {
+		t.Errorf("a type without recent entries should have no trend, got %v", types[2].Trend)
+	}
+}
+
+func TestFormatStatsHuman_Sparklines(t *testing.T) {
+	recent := time.Now().UTC().Add(-30 * time.Minute).Format(time.RFC3339)
+	r := generateStats([]ErrorEntry{
+		{Source: "backend", ErrorType: "DATABASE_ERROR", Timestamp: recent},
+		{Source: "backend", ErrorType: "OLD_ERROR", Timestamp: "2020-01-01T00:00:00Z"},
+	}, 5)
+	out := formatStatsHuman(r)
+	if !strings.Contains(out, "DATABASE_ERROR           1       ▁▁▁▁▁▁▁▁▁▁▁▁▁▁▁▁▁▁▁▁▁▁▁█\n") {
+		t.Errorf("a recent type should get a sparkline:\n%s", out)
+	}
+	if !strings.Contains(out, "OLD_ERROR                1\n") {
+		t.Errorf("a type with nothing in 24h should have none:\n%s", out)
+	}
+}
@@ -0,0 +1,556 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/self"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsSince   string
+	statsLimit   int
+	statsKind    string
+	statsHeatmap bool
+	statsBucket  string
+	statsBy      string
+)
+
+// idSegmentPattern matches endpoint path segments that are IDs rather than
+// route names (numbers, UUIDs, long hex)
+var idSegmentPattern = regexp.MustCompile(`(?i)^(?:\d+|[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}|[0-9a-f]{16,})$`)
+
+// StatsReport aggregates errors by type, source, tag, agent, file, and
+// endpoint
+type StatsReport struct {
+	TotalErrors  int              `json:"total_errors"`
+	Since        string           `json:"since,omitempty"`
+	Kind         string           `json:"kind"`
+	ByType       []ErrorTypeCount `json:"by_type"`
+	BySource     []SourceCount    `json:"by_source"`
+	ByTag        []TagCount       `json:"by_tag,omitempty"`
+	ByAgent      []AgentCount     `json:"by_agent,omitempty"`
+	TopFiles     []LocationCount  `json:"top_files"`
+	TopEndpoints []LocationCount  `json:"top_endpoints"`
+	Heatmap      *Heatmap         `json:"heatmap,omitempty"`
+	Series       *Series          `json:"series,omitempty"`
+	Flaky        []FlakyError     `json:"flaky,omitempty"` // errors that keep going away and coming back
+	// SampledPending is occurrences sampling has skipped and not yet
+	// written, counted in the totals above
+	SampledPending int  `json:"sampled_pending,omitempty"`
+	NoLogFile      bool `json:"no_log_file,omitempty"`
+}
+
+// TagCount aggregates error counts by tag
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// AgentCount aggregates error counts by the AI agent that produced them
+type AgentCount struct {
+	Agent string `json:"agent"`
+	Count int    `json:"count"`
+}
+
+// LocationCount is a file or endpoint with its error count
+type LocationCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show error counts by type, source, tag, agent, file, and endpoint",
+	Long: `Aggregate errors from .agentlog/errors.jsonl by error type, source, and
+tag, and rank the source files and endpoints that produce the most errors.
+Entries that record the AI agent session that produced them ("agent") are
+also counted by agent, so several agents working one repo can be compared.
+
+File URLs are reduced to their path, and ID segments in endpoints are
+collapsed (/api/users/42 becomes /api/users/:id) so one route counts once.
+
+--heatmap counts entries by weekday and hour of day, in local time, and
+draws the grid shaded by count instead of the breakdowns, showing when
+errors cluster: every night a seed script runs, or each Monday's deploy.
+With --json the report gains a "heatmap" object whose counts matrix is
+indexed [weekday][hour], Monday first.
+
+--bucket 15m counts entries per 15 minutes instead, for trends and plots;
+--by type (or source, tags, endpoint, context.<key>, ...) breaks each
+bucket down by that field. Buckets run from the first entry to the last,
+or from --since to now, and empty ones are included. With --json the
+report gains "series": {"bucket", "by", "buckets": [{"start", "count",
+"counts"}]}, starts in UTC.
+
+Types with errors in the last 24 hours get a sparkline of them, an hour
+per block, ending now. With --json they're each type's trend_24h.
+
+Errors that keep going away and coming back are listed as likely flaky:
+the log is split into sessions wherever 30 minutes pass with no entries,
+and an error group that was missing from a session and then came back,
+at least twice, is more likely an intermittent failure (a network blip, a
+race, a timing-dependent test) than a regression. With --json they're
+"flaky", each with the sessions it occurred in, of those since it first
+did, and how many times it came back.
+
+Entries are counted as the log is read, one line at a time, so logs of
+hundreds of MB aren't loaded into memory. Logs over 4MB are split into
+ranges counted in parallel, one per CPU.
+
+Examples:
+  agentlog stats              # All errors
+  agentlog stats --since 1h   # Errors from the last hour
+  agentlog stats --limit 10   # Show the top 10 files and endpoints
+  agentlog stats --kind perf  # Slow operations instead of errors
+  agentlog stats --heatmap    # When errors happen, by weekday and hour
+  agentlog stats --since 6h --bucket 15m --by type --json  # Time series per type
+  agentlog stats --json`,
+	RunE: runStats,
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+
+	statsCmd.Flags().StringVar(&statsSince, "since", "", "Only count errors since time (e.g., '1h', '2d', '1w', 'yesterday', '2024-01-01 14:00')")
+	statsCmd.Flags().IntVar(&statsLimit, "limit", 5, "Number of files and endpoints to show")
+	statsCmd.Flags().StringVar(&statsKind, "kind", kindError, "Entry kind to count: error or perf")
+	statsCmd.Flags().StringVar(&statsBucket, "bucket", "", "Count per time bucket of this size (e.g. 15m, 1h, 1d) as a time series")
+	statsCmd.Flags().StringVar(&statsBy, "by", "", "With --bucket, break each bucket down by this field (e.g. type, source, tags, context.endpoint)")
+	statsCmd.Flags().BoolVar(&statsHeatmap, "heatmap", false, "Show counts by weekday and hour of day (local time) as a shaded grid")
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	// Determine base directory (use --path override or cwd)
+	baseDir := GetPathOverride()
+	if baseDir == "" {
+		var err error
+		baseDir, err = os.Getwd()
+		if err != nil {
+			self.LogError(".", "GETWD_ERROR", err.Error())
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+
+	if statsKind == "" || !validKind(statsKind) {
+		return invalidInput("invalid --kind '%s' (want error or perf)", statsKind)
+	}
+
+	var sinceTime time.Time
+	if statsSince != "" {
+		var err error
+		sinceTime, err = parseSince(statsSince)
+		if err != nil {
+			self.LogError(baseDir, "INVALID_INPUT", fmt.Sprintf("invalid --since value '%s': %v", statsSince, err))
+			return invalidInput("invalid --since value: %w", err)
+		}
+	}
+
+	var bucket time.Duration
+	if statsBucket != "" {
+		var err error
+		if bucket, err = parseBucket(statsBucket); err != nil {
+			return err
+		}
+	}
+	if statsBy != "" {
+		if bucket == 0 {
+			return invalidInput("--by requires --bucket")
+		}
+		if _, err := parseFields(statsBy); err != nil || strings.Contains(statsBy, ",") {
+			return invalidInput("invalid --by '%s' (want one field, e.g. type)", statsBy)
+		}
+	}
+	// Every range's trends end at the same hour, so they can be merged
+	now := time.Now().UTC()
+	newCounter := func() *statsCounter {
+		c := newStatsCounter()
+		c.trends = newTrendCounter(now)
+		if bucket > 0 {
+			c.series = newSeriesCounter(bucket, strings.TrimSpace(statsBy))
+		}
+		return c
+	}
+
+	// Counted as the log is read, so its size doesn't matter; a large log
+	// is counted in ranges on separate goroutines, whose counts are merged
+	var report StatsReport
+	inRange := func(e ErrorEntry) bool {
+		return len(filterErrors([]ErrorEntry{e}, "", "", sinceTime)) == 1
+	}
+	matches := func(e ErrorEntry) bool {
+		return len(filterKind([]ErrorEntry{e}, statsKind)) == 1
+	}
+	counter := newCounter()
+	var err error
+	if useParallel(GetErrorsPath(baseDir)) {
+		var counters []*statsCounter
+		err = scanErrorsParallel(baseDir, func(ranges int) {
+			for i := 0; i < ranges; i++ {
+				counters = append(counters, newCounter())
+			}
+		}, func(rang int, e ErrorEntry) {
+			if !inRange(e) {
+				return
+			}
+			// Every kind of entry marks a session as active
+			counters[rang].presence.add(e)
+			if matches(e) {
+				counters[rang].add(e)
+			}
+		})
+		for _, c := range counters {
+			counter.merge(c)
+		}
+	} else {
+		err = scanErrors(baseDir, func(e ErrorEntry) bool {
+			if !inRange(e) {
+				return true
+			}
+			counter.presence.add(e)
+			if matches(e) {
+				counter.add(e)
+			}
+			return true
+		})
+	}
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		report.NoLogFile = true
+	} else {
+		pending := 0
+		for _, e := range pendingSamples(baseDir) {
+			if inRange(e) && matches(e) {
+				counter.presence.add(e)
+				counter.add(e)
+				pending += e.occurrences()
+			}
+		}
+		report = counter.report(statsLimit)
+		report.SampledPending = pending
+		if statsKind == kindError {
+			report.Flaky = counter.presence.flaky(statsLimit)
+		}
+		if statsHeatmap {
+			report.Heatmap = newHeatmap(counter.heat, time.Local)
+		}
+		if counter.series != nil {
+			if report.Series, err = counter.series.series(statsBucket, sinceTime, now); err != nil {
+				return err
+			}
+		}
+	}
+	report.Since = statsSince
+	report.Kind = statsKind
+
+	if IsJSONOutput() {
+		output, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Fprintln(cmd.OutOrStdout(), string(output))
+	} else {
+		fmt.Fprint(cmd.OutOrStdout(), formatStatsHuman(report))
+	}
+	if report.NoLogFile && uninitialized(baseDir) {
+		return exitNotInitialized(cmd)
+	}
+	return nil
+}
+
+// generateStats aggregates entries, keeping the top limit files and endpoints
+func generateStats(entries []ErrorEntry, limit int) StatsReport {
+	counter := newStatsCounter()
+	for _, e := range entries {
+		counter.add(e)
+	}
+	return counter.report(limit)
+}
+
+// statsCounter aggregates entries one at a time, keeping only the counts
+type statsCounter struct {
+	total     int
+	types     map[string]int
+	sources   map[string]int
+	tags      map[string]int
+	agents    map[string]int
+	files     map[string]int
+	endpoints map[string]int
+	heat      [7][24]int     // by weekday and hour, local time
+	trends    *trendCounter  // by type, for the last 24h
+	series    *seriesCounter // with --bucket
+	presence  *presence      // which sessions each error occurred in, filled by the caller
+}
+
+func newStatsCounter() *statsCounter {
+	return &statsCounter{
+		types:     make(map[string]int),
+		sources:   make(map[string]int),
+		tags:      make(map[string]int),
+		agents:    make(map[string]int),
+		files:     make(map[string]int),
+		endpoints: make(map[string]int),
+		trends:    newTrendCounter(time.Now().UTC()),
+		presence:  newPresence(),
+	}
+}
+
+// add counts one entry, once per occurrence it records
+func (c *statsCounter) add(e ErrorEntry) {
+	n := e.occurrences()
+	c.total += n
+	c.types[e.ErrorType] += n
+	c.sources[e.Source] += n
+	for _, t := range e.Tags {
+		c.tags[t] += n
+	}
+	if e.Agent != "" {
+		c.agents[e.Agent] += n
+	}
+	if f := entryFile(e); f != "" {
+		c.files[f] += n
+	}
+	if ep := entryEndpoint(e); ep != "" {
+		c.endpoints[ep] += n
+	}
+	if t, err := parseEntryTime(e.Timestamp); err == nil {
+		row, hour := heatmapCell(t, time.Local)
+		c.heat[row][hour] += n
+	}
+	c.trends.add(e)
+	if c.series != nil {
+		c.series.add(e)
+	}
+}
+
+// merge adds other's counts to c
+func (c *statsCounter) merge(other *statsCounter) {
+	c.total += other.total
+	for row := range other.heat {
+		for hour, n := range other.heat[row] {
+			c.heat[row][hour] += n
+		}
+	}
+	c.trends.merge(other.trends)
+	c.presence.merge(other.presence)
+	if c.series != nil && other.series != nil {
+		c.series.merge(other.series)
+	}
+	for _, m := range []struct{ into, from map[string]int }{
+		{c.types, other.types},
+		{c.sources, other.sources},
+		{c.tags, other.tags},
+		{c.agents, other.agents},
+		{c.files, other.files},
+		{c.endpoints, other.endpoints},
+	} {
+		for k, n := range m.from {
+			m.into[k] += n
+		}
+	}
+}
+
+// report is the aggregate so far, keeping the top limit files and
+// endpoints
+func (c *statsCounter) report(limit int) StatsReport {
+	byType := topN(c.types, len(c.types))
+	c.trends.fill(byType)
+	return StatsReport{
+		TotalErrors:  c.total,
+		ByType:       byType,
+		BySource:     topNSources(c.sources, len(c.sources)),
+		ByTag:        tagCounts(c.tags),
+		ByAgent:      agentCounts(c.agents),
+		TopFiles:     topLocations(c.files, limit),
+		TopEndpoints: topLocations(c.endpoints, limit),
+	}
+}
+
+// tagCounts returns tags sorted by count, ties broken by name
+func tagCounts(counts map[string]int) []TagCount {
+	var result []TagCount
+	for _, l := range topLocations(counts, len(counts)) {
+		result = append(result, TagCount{Tag: l.Name, Count: l.Count})
+	}
+	return result
+}
+
+// agentCounts returns agents sorted by count, ties broken by name
+func agentCounts(counts map[string]int) []AgentCount {
+	var result []AgentCount
+	for _, l := range topLocations(counts, len(counts)) {
+		result = append(result, AgentCount{Agent: l.Name, Count: l.Count})
+	}
+	return result
+}
+
+// locationCounts counts entries by normalized file and endpoint
+func locationCounts(entries []ErrorEntry) (files, endpoints map[string]int) {
+	files = make(map[string]int)
+	endpoints = make(map[string]int)
+	for _, e := range entries {
+		if f := entryFile(e); f != "" {
+			files[f] += e.occurrences()
+		}
+		if ep := entryEndpoint(e); ep != "" {
+			endpoints[ep] += e.occurrences()
+		}
+	}
+	return files, endpoints
+}
+
+// entryFile returns the entry's file reduced to a path: URLs (from browser
+// stack frames) lose their scheme, host, and query string
+func entryFile(e ErrorEntry) string {
+	f := e.File
+	if f == "" {
+		return ""
+	}
+	if u, err := url.Parse(f); err == nil && u.Scheme != "" && u.Host != "" {
+		return u.Path
+	}
+	if i := strings.IndexAny(f, "?#"); i >= 0 {
+		f = f[:i]
+	}
+	return f
+}
+
+// entryEndpoint returns the entry's endpoint with the query string removed
+// and ID segments replaced by ":id"
+func entryEndpoint(e ErrorEntry) string {
+	ep := e.Endpoint
+	if ep == "" {
+		return ""
+	}
+	if i := strings.IndexAny(ep, "?#"); i >= 0 {
+		ep = ep[:i]
+	}
+
+	// Keep a leading method ("GET /api/users") intact
+	method, path, found := strings.Cut(ep, " ")
+	if !found {
+		method, path = "", ep
+	}
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if idSegmentPattern.MatchString(seg) {
+			segments[i] = ":id"
+		}
+	}
+	path = strings.Join(segments, "/")
+	if method != "" {
+		return method + " " + path
+	}
+	return path
+}
+
+// topLocations returns the n most frequent names, ties broken by name
+func topLocations(counts map[string]int, n int) []LocationCount {
+	var result []LocationCount
+	for name, count := range counts {
+		result = append(result, LocationCount{Name: name, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
+
+// formatStatsHuman formats the report for human-readable output
+func formatStatsHuman(r StatsReport) string {
+	var sb strings.Builder
+
+	if r.NoLogFile {
+		sb.WriteString("No errors file found. Run 'agentlog init' to set up.\n")
+		return sb.String()
+	}
+
+	label := "Errors"
+	if r.Kind == kindPerf {
+		label = "Slow operations"
+	}
+	if r.Since != "" {
+		sb.WriteString(fmt.Sprintf("%s since %s: %d\n", label, r.Since, r.TotalErrors))
+	} else {
+		sb.WriteString(fmt.Sprintf("%s: %d\n", label, r.TotalErrors))
+	}
+	if r.TotalErrors == 0 {
+		return sb.String()
+	}
+	if r.SampledPending > 0 {
+		sb.WriteString(fmt.Sprintf("  (including %d sampled out and not yet written)\n", r.SampledPending))
+	}
+	if r.Heatmap != nil || r.Series != nil {
+		if r.Heatmap != nil {
+			sb.WriteString(formatHeatmap(r.Heatmap))
+		}
+		if r.Series != nil {
+			sb.WriteString(formatSeries(r.Series))
+		}
+		return sb.String()
+	}
+
+	sb.WriteString("\nBy type:\n")
+	for _, t := range r.ByType {
+		if t.Trend != nil {
+			sb.WriteString(fmt.Sprintf("  %-24s %-7d %s\n", t.ErrorType, t.Count, sparkline(t.Trend)))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("  %-24s %d\n", t.ErrorType, t.Count))
+	}
+
+	sb.WriteString("\nBy source:\n")
+	for _, s := range r.BySource {
+		sb.WriteString(fmt.Sprintf("  %-24s %d\n", s.Source, s.Count))
+	}
+
+	if len(r.ByTag) > 0 {
+		sb.WriteString("\nBy tag:\n")
+		for _, t := range r.ByTag {
+			sb.WriteString(fmt.Sprintf("  %-24s %d\n", t.Tag, t.Count))
+		}
+	}
+
+	if len(r.ByAgent) > 0 {
+		sb.WriteString("\nBy agent:\n")
+		for _, a := range r.ByAgent {
+			sb.WriteString(fmt.Sprintf("  %-24s %d\n", a.Agent, a.Count))
+		}
+	}
+
+	writeLocations(&sb, "Top files", r.TopFiles)
+	writeLocations(&sb, "Top endpoints", r.TopEndpoints)
+
+	if len(r.Flaky) > 0 {
+		sb.WriteString("\nLikely flaky:\n")
+		for _, f := range r.Flaky {
+			sb.WriteString(fmt.Sprintf("  %s\n", f))
+		}
+	}
+
+	return sb.String()
+}
+
+// writeLocations writes a ranked location section, skipping empty ones
+func writeLocations(sb *strings.Builder, title string, locations []LocationCount) {
+	if len(locations) == 0 {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("\n%s:\n", title))
+	for _, l := range locations {
+		sb.WriteString(fmt.Sprintf("  %-40s %d\n", l.Name, l.Count))
+	}
+}
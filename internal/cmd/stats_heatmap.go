@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// heatmapWeekdays are the heatmap's rows, Monday first
+var heatmapWeekdays = []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+// heatmapShades are the characters cells are drawn with, from none to the
+// busiest hour
+const heatmapShades = " .:-=+*#%@"
+
+// Heatmap counts entries by weekday and hour of day in local time, for
+// spotting patterns like errors every night a seed script runs
+type Heatmap struct {
+	Timezone string       `json:"timezone"`
+	Weekdays []string     `json:"weekdays"` // row labels of Counts
+	Counts   [7][24]int   `json:"counts"`   // Counts[weekday][hour]
+	Peak     *HeatmapCell `json:"peak,omitempty"`
+}
+
+// HeatmapCell is one weekday and hour with its count
+type HeatmapCell struct {
+	Weekday string `json:"weekday"`
+	Hour    int    `json:"hour"`
+	Count   int    `json:"count"`
+}
+
+// heatmapCell is where t falls in the heatmap once in loc: the row, with
+// Monday first, and the hour
+func heatmapCell(t time.Time, loc *time.Location) (row, hour int) {
+	t = t.In(loc)
+	return (int(t.Weekday()) + 6) % 7, t.Hour()
+}
+
+// newHeatmap fills in the labels and peak for counts
+func newHeatmap(counts [7][24]int, loc *time.Location) *Heatmap {
+	h := &Heatmap{Timezone: time.Now().In(loc).Format("MST -07:00"), Weekdays: heatmapWeekdays, Counts: counts}
+	for row := range counts {
+		for hour, n := range counts[row] {
+			if n > 0 && (h.Peak == nil || n > h.Peak.Count) {
+				h.Peak = &HeatmapCell{Weekday: heatmapWeekdays[row], Hour: hour, Count: n}
+			}
+		}
+	}
+	return h
+}
+
+// formatHeatmap draws the heatmap as a grid of shaded cells, each
+// weekday a row and each hour a column
+func formatHeatmap(h *Heatmap) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("\nBy weekday and hour (%s):\n", h.Timezone))
+	sb.WriteString("      ")
+	for hour := 0; hour < 24; hour += 3 {
+		sb.WriteString(fmt.Sprintf("%-6d", hour))
+	}
+	sb.WriteString("\n")
+
+	peak := 0
+	if h.Peak != nil {
+		peak = h.Peak.Count
+	}
+	for row, label := range h.Weekdays {
+		cells := make([]byte, 0, 48)
+		for _, n := range h.Counts[row] {
+			cells = append(cells, heatmapShade(n, peak), ' ')
+		}
+		sb.WriteString(strings.TrimRight("  "+label+" "+string(cells), " ") + "\n")
+	}
+
+	if h.Peak != nil {
+		sb.WriteString(fmt.Sprintf("  Scale: '%c' none to '%c' %d. Busiest: %s %02d:00 (%d)\n",
+			heatmapShades[0], heatmapShades[len(heatmapShades)-1], peak, h.Peak.Weekday, h.Peak.Hour, h.Peak.Count))
+	}
+	return sb.String()
+}
+
+// heatmapShade picks n's character relative to peak. Any count gets at
+// least the lightest mark, so a single entry still shows.
+func heatmapShade(n, peak int) byte {
+	if n <= 0 || peak <= 0 {
+		return heatmapShades[0]
+	}
+	levels := len(heatmapShades) - 1
+	i := (n*levels + peak - 1) / peak // ceiling, so 1..levels
+	return heatmapShades[i]
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHeatmapCell(t *testing.T) {
+	// Wednesday 2025-12-10 23:30 UTC
+	ts := time.Date(2025, 12, 10, 23, 30, 0, 0, time.UTC)
+	if row, hour := heatmapCell(ts, time.UTC); heatmapWeekdays[row] != "Wed" || hour != 23 {
+		t.Errorf("UTC cell = %s %d, want Wed 23", heatmapWeekdays[row], hour)
+	}
+	// Two hours east, it's already Thursday
+	east := time.FixedZone("EET", 2*60*60)
+	if row, hour := heatmapCell(ts, east); heatmapWeekdays[row] != "Thu" || hour != 1 {
+		t.Errorf("EET cell = %s %d, want Thu 1", heatmapWeekdays[row], hour)
+	}
+	// Sunday is the last row
+	if row, _ := heatmapCell(time.Date(2025, 12, 14, 12, 0, 0, 0, time.UTC), time.UTC); row != 6 {
+		t.Errorf("Sunday row = %d, want 6", row)
+	}
+}
+
+func TestFormatHeatmap(t *testing.T) {
+	var counts [7][24]int
+	counts[1][2] = 40 // Tue 02:00
+	counts[1][3] = 1
+	counts[4][17] = 20
+	h := newHeatmap(counts, time.UTC)
+	if h.Peak == nil || *h.Peak != (HeatmapCell{Weekday: "Tue", Hour: 2, Count: 40}) {
+		t.Fatalf("Peak = %+v", h.Peak)
+	}
+
+	out := formatHeatmap(h)
+	lines := strings.Split(strings.TrimPrefix(out, "\n"), "\n")
+	if !strings.HasPrefix(lines[1], "      0     3     6") {
+		t.Errorf("hour header = %q", lines[1])
+	}
+	if lines[3] != "  Tue     @ ." {
+		t.Errorf("Tue row = %q, want the peak at 02:00 and a light mark at 03:00", lines[3])
+	}
+	if fri := lines[6]; len(fri) != 6+17*2+1 || fri[len(fri)-1] != '+' {
+		t.Errorf("Fri row = %q, want a mid shade at 17:00", fri)
+	}
+	if lines[2] != "  Mon" {
+		t.Errorf("an empty row should have no cells drawn, got %q", lines[2])
+	}
+	if !strings.Contains(out, "Busiest: Tue 02:00 (40)") {
+		t.Errorf("output should name the busiest hour:\n%s", out)
+	}
+}
+
+func TestStatsCommand_Heatmap(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, ".agentlog"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, ".agentlog", "errors.jsonl"), []byte(
+		`{"timestamp":"2025-12-08T03:10:00.000Z","source":"backend","error_type":"SEED_ERROR","message":"seed failed"}
+{"timestamp":"2025-12-09T03:20:00.000Z","source":"backend","error_type":"SEED_ERROR","message":"seed failed","count":3}
+{"timestamp":"2025-12-10T15:00:00.000Z","source":"frontend","error_type":"UNCAUGHT_ERROR","message":"boom"}
+`), 0644)
+
+	originalPath, originalJSON, originalLocal := pathOverride, jsonOutput, time.Local
+	defer func() {
+		pathOverride, jsonOutput, statsHeatmap, time.Local = originalPath, originalJSON, false, originalLocal
+	}()
+	pathOverride, jsonOutput, time.Local = tmpDir, true, time.UTC
+	statsSince, statsLimit, statsKind, statsHeatmap = "", 5, kindError, true
+
+	buf := new(bytes.Buffer)
+	statsCmd.SetOut(buf)
+	defer statsCmd.SetOut(nil)
+	if err := runStats(statsCmd, nil); err != nil {
+		t.Fatalf("runStats() error = %v", err)
+	}
+	var r StatsReport
+	if err := json.Unmarshal(buf.Bytes(), &r); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	h := r.Heatmap
+	if h == nil || h.Counts[0][3] != 1 || h.Counts[1][3] != 3 || h.Counts[2][15] != 1 || h.Timezone != "UTC +00:00" {
+		t.Fatalf("heatmap = %+v", h)
+	}
+	if *h.Peak != (HeatmapCell{Weekday: "Tue", Hour: 3, Count: 3}) {
+		t.Errorf("Peak = %+v", h.Peak)
+	}
+
+	jsonOutput = false
+	buf.Reset()
+	if err := runStats(statsCmd, nil); err != nil {
+		t.Fatalf("runStats() error = %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "By weekday and hour") || strings.Contains(out, "By type:") {
+		t.Errorf("--heatmap should show the grid in place of the breakdowns:\n%s", out)
+	}
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxSeriesBuckets bounds how many buckets a series holds, empty ones
+// included, so a small --bucket over a long log can't exhaust memory
+const maxSeriesBuckets = 100000
+
+// Series is entry counts over time, in fixed-size buckets
+type Series struct {
+	Bucket  string         `json:"bucket"`       // bucket size, as given to --bucket
+	By      string         `json:"by,omitempty"` // the field Counts breaks down
+	Buckets []SeriesBucket `json:"buckets"`
+}
+
+// SeriesBucket is one bucket of a series. Buckets without entries are
+// included, so the series can be plotted as is.
+type SeriesBucket struct {
+	Start  string         `json:"start"` // RFC3339, UTC
+	Count  int            `json:"count"`
+	Counts map[string]int `json:"counts,omitempty"` // per value of By
+}
+
+// parseBucket reads a --bucket size: a Go duration ("15m", "1h30m") or a
+// count of days or weeks ("1d", "2w")
+func parseBucket(s string) (time.Duration, error) {
+	d, ok := parseSpan(s)
+	if !ok {
+		return 0, invalidInput("invalid --bucket '%s' (e.g. 15m, 1h, 1d)", s)
+	}
+	if d < time.Second {
+		return 0, invalidInput("--bucket must be at least 1s")
+	}
+	return d, nil
+}
+
+// seriesCounter counts entries into buckets as they're read
+type seriesCounter struct {
+	size    time.Duration
+	by      string
+	buckets map[int64]map[string]int // by bucket index; "" holds the total
+}
+
+func newSeriesCounter(size time.Duration, by string) *seriesCounter {
+	return &seriesCounter{size: size, by: by, buckets: make(map[int64]map[string]int)}
+}
+
+func (s *seriesCounter) add(e ErrorEntry) {
+	t, err := parseEntryTime(e.Timestamp)
+	if err != nil {
+		return
+	}
+	i := s.index(t)
+	counts := s.buckets[i]
+	if counts == nil {
+		counts = make(map[string]int)
+		s.buckets[i] = counts
+	}
+	n := e.occurrences()
+	counts[""] += n
+	if s.by != "" {
+		for _, v := range fieldGroups(e, s.by) {
+			if v == "" {
+				v = "-" // "" is the total
+			}
+			counts[v] += n
+		}
+	}
+}
+
+// index is the bucket t falls in, counting from 1970
+func (s *seriesCounter) index(t time.Time) int64 {
+	i := t.UnixNano() / int64(s.size)
+	if t.UnixNano()%int64(s.size) < 0 {
+		i-- // before 1970, round down rather than toward zero
+	}
+	return i
+}
+
+func (s *seriesCounter) merge(other *seriesCounter) {
+	for i, from := range other.buckets {
+		into := s.buckets[i]
+		if into == nil {
+			into = make(map[string]int)
+			s.buckets[i] = into
+		}
+		for k, n := range from {
+			into[k] += n
+		}
+	}
+}
+
+// series lays the counts out from the first bucket to the last, or from
+// since to now when since is set, filling in empty buckets
+func (s *seriesCounter) series(bucket string, since, now time.Time) (*Series, error) {
+	result := &Series{Bucket: bucket, By: s.by, Buckets: []SeriesBucket{}}
+	var first, last int64
+	if !since.IsZero() {
+		first, last = s.index(since), s.index(now)
+	} else if len(s.buckets) > 0 {
+		indexes := make([]int64, 0, len(s.buckets))
+		for i := range s.buckets {
+			indexes = append(indexes, i)
+		}
+		sort.Slice(indexes, func(a, b int) bool { return indexes[a] < indexes[b] })
+		first, last = indexes[0], indexes[len(indexes)-1]
+	} else {
+		return result, nil
+	}
+	if n := last - first + 1; n > maxSeriesBuckets {
+		return nil, invalidInput("--bucket %s makes %d buckets (at most %d); use a larger bucket or --since", bucket, n, maxSeriesBuckets)
+	}
+
+	for i := first; i <= last; i++ {
+		b := SeriesBucket{Start: time.Unix(0, i*int64(s.size)).UTC().Format(time.RFC3339)}
+		if counts := s.buckets[i]; counts != nil {
+			b.Count = counts[""]
+			if s.by != "" {
+				b.Counts = make(map[string]int, len(counts)-1)
+				for k, n := range counts {
+					if k != "" {
+						b.Counts[k] = n
+					}
+				}
+			}
+		}
+		result.Buckets = append(result.Buckets, b)
+	}
+	return result, nil
+}
+
+// formatSeries prints one line per bucket: its local start time, its
+// count, and the breakdown, largest first
+func formatSeries(s *Series) string {
+	var sb strings.Builder
+	if s.By != "" {
+		sb.WriteString(fmt.Sprintf("\nPer %s, by %s:\n", s.Bucket, s.By))
+	} else {
+		sb.WriteString(fmt.Sprintf("\nPer %s:\n", s.Bucket))
+	}
+	for _, b := range s.Buckets {
+		start, _ := time.Parse(time.RFC3339, b.Start)
+		line := fmt.Sprintf("  %s  %6d", start.Local().Format("2006-01-02 15:04"), b.Count)
+		if len(b.Counts) > 0 {
+			var parts []string
+			for _, l := range topLocations(b.Counts, len(b.Counts)) {
+				parts = append(parts, fmt.Sprintf("%s %d", l.Name, l.Count))
+			}
+			line += "  " + strings.Join(parts, ", ")
+		}
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
+}
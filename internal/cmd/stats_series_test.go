@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseBucket(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"15m":   15 * time.Minute,
+		"1h30m": 90 * time.Minute,
+		"1d":    24 * time.Hour,
+		"2w":    14 * 24 * time.Hour,
+		"1H":    time.Hour,
+	} {
+		if got, err := parseBucket(in); err != nil || got != want {
+			t.Errorf("parseBucket(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "soon", "500ms", "-1h"} {
+		if _, err := parseBucket(in); err == nil || errorCode(err) != "INVALID_INPUT" {
+			t.Errorf("parseBucket(%q) should be invalid input, got %v", in, err)
+		}
+	}
+}
+
+func TestSeriesCounter(t *testing.T) {
+	s := newSeriesCounter(15*time.Minute, "type")
+	s.add(ErrorEntry{Timestamp: "2025-12-10T19:01:00Z", ErrorType: "DATABASE_ERROR"})
+	s.add(ErrorEntry{Timestamp: "2025-12-10T19:14:59Z", ErrorType: "NETWORK_ERROR", Count: 2})
+	other := newSeriesCounter(15*time.Minute, "type")
+	other.add(ErrorEntry{Timestamp: "2025-12-10T19:50:00.123Z", ErrorType: "DATABASE_ERROR"})
+	other.add(ErrorEntry{Timestamp: "not a time", ErrorType: "DATABASE_ERROR"})
+	s.merge(other)
+
+	series, err := s.series("15m", time.Time{}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, b := range series.Buckets {
+		got = append(got, b.Start+"="+formatCell(b.Count))
+	}
+	want := "2025-12-10T19:00:00Z=3,2025-12-10T19:15:00Z=0,2025-12-10T19:30:00Z=0,2025-12-10T19:45:00Z=1"
+	if strings.Join(got, ",") != want {
+		t.Errorf("buckets = %v, want %s", got, want)
+	}
+	if c := series.Buckets[0].Counts; c["DATABASE_ERROR"] != 1 || c["NETWORK_ERROR"] != 2 || len(c) != 2 {
+		t.Errorf("first bucket counts = %v", c)
+	}
+	if series.Buckets[1].Counts != nil {
+		t.Errorf("an empty bucket has no breakdown, got %v", series.Buckets[1].Counts)
+	}
+
+	// --since runs the series to now
+	now := time.Date(2025, 12, 10, 20, 10, 0, 0, time.UTC)
+	series, _ = s.series("15m", now.Add(-time.Hour), now)
+	if len(series.Buckets) != 5 || series.Buckets[0].Start != "2025-12-10T19:00:00Z" || series.Buckets[4].Start != "2025-12-10T20:00:00Z" {
+		t.Errorf("--since buckets = %+v", series.Buckets)
+	}
+
+	if _, err := newSeriesCounter(time.Second, "").series("1s", now.Add(-30*24*time.Hour), now); err == nil {
+		t.Error("a month of 1s buckets should be refused")
+	}
+}
+
+func TestStatsCommand_Bucket(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, ".agentlog"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, ".agentlog", "errors.jsonl"), []byte(
+		`{"timestamp":"2025-12-10T19:00:00.000Z","source":"backend","error_type":"DATABASE_ERROR","message":"a","tags":["ci","flaky"]}
+{"timestamp":"2025-12-10T19:30:00.000Z","source":"frontend","error_type":"UNCAUGHT_ERROR","message":"b","tags":["ci"]}
+`), 0644)
+
+	originalPath, originalJSON := pathOverride, jsonOutput
+	defer func() { pathOverride, jsonOutput, statsBucket, statsBy = originalPath, originalJSON, "", "" }()
+	pathOverride, jsonOutput = tmpDir, true
+	statsSince, statsLimit, statsKind, statsHeatmap = "", 5, kindError, false
+	statsBucket, statsBy = "1h", "tags"
+
+	buf := new(bytes.Buffer)
+	statsCmd.SetOut(buf)
+	defer statsCmd.SetOut(nil)
+	if err := runStats(statsCmd, nil); err != nil {
+		t.Fatalf("runStats() error = %v", err)
+	}
+	var r StatsReport
+	if err := json.Unmarshal(buf.Bytes(), &r); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if r.Series == nil || r.Series.Bucket != "1h" || r.Series.By != "tags" || len(r.Series.Buckets) != 1 {
+		t.Fatalf("series = %+v", r.Series)
+	}
+	if b := r.Series.Buckets[0]; b.Count != 2 || b.Counts["ci"] != 2 || b.Counts["flaky"] != 1 {
+		t.Errorf("bucket = %+v, want each tag counted", b)
+	}
+
+	jsonOutput = false
+	buf.Reset()
+	if err := runStats(statsCmd, nil); err != nil {
+		t.Fatalf("runStats() error = %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "Per 1h, by tags:") || !strings.Contains(out, "ci 2, flaky 1") {
+		t.Errorf("human output should list buckets with their breakdown:\n%s", out)
+	}
+
+	statsBucket = ""
+	if err := runStats(statsCmd, nil); err == nil {
+		t.Error("--by without --bucket should fail")
+	}
+	statsBucket, statsBy = "1h", "type,source"
+	if err := runStats(statsCmd, nil); err == nil {
+		t.Error("--by takes a single field")
+	}
+}
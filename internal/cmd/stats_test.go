@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEntryFile(t *testing.T) {
+	tests := map[string]string{
+		"http://localhost:5173/src/App.tsx?t=1712345": "/src/App.tsx",
+		"src/lib/db.ts":              "src/lib/db.ts",
+		"/app/worker.js?v=2":         "/app/worker.js",
+		"webpack-internal:///./a.js": "webpack-internal:///./a.js",
+	}
+	for in, want := range tests {
+		e := ErrorEntry{File: in}
+		if got := entryFile(e); got != want {
+			t.Errorf("entryFile(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestEntryEndpoint(t *testing.T) {
+	tests := map[string]string{
+		"/api/users/42":          "/api/users/:id",
+		"/api/users/42?expand=1": "/api/users/:id",
+		"GET /api/orders/550e8400-e29b-41d4-a716-446655440000/items": "GET /api/orders/:id/items",
+		"/api/v2/health": "/api/v2/health",
+	}
+	for in, want := range tests {
+		e := ErrorEntry{Endpoint: in}
+		if got := entryEndpoint(e); got != want {
+			t.Errorf("entryEndpoint(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestGenerateStats(t *testing.T) {
+	entries := []ErrorEntry{
+		{Source: "frontend", ErrorType: "NETWORK_ERROR", Endpoint: "/api/users/1", Tags: []string{"checkout-v2"}, Agent: "claude:a1"},
+		{Source: "frontend", ErrorType: "NETWORK_ERROR", Endpoint: "/api/users/2", Tags: []string{"checkout-v2", "flaky"}, Agent: "claude:a1"},
+		{Source: "backend", ErrorType: "DATABASE_ERROR", File: "db/pool.go", Endpoint: "/api/orders", Agent: "reviewer-bot"},
+		{Source: "frontend", ErrorType: "UNCAUGHT_ERROR", File: "http://localhost:5173/src/App.tsx"},
+		{Source: "frontend", ErrorType: "UNCAUGHT_ERROR", File: "http://localhost:5173/src/App.tsx?t=2"},
+	}
+
+	r := generateStats(entries, 1)
+	if r.TotalErrors != 5 {
+		t.Errorf("TotalErrors = %d, want 5", r.TotalErrors)
+	}
+	if len(r.ByType) != 3 || len(r.BySource) != 2 {
+		t.Errorf("ByType/BySource should list every value, got %v / %v", r.ByType, r.BySource)
+	}
+	if len(r.ByTag) != 2 || r.ByTag[0] != (TagCount{Tag: "checkout-v2", Count: 2}) {
+		t.Errorf("ByTag = %+v", r.ByTag)
+	}
+	if len(r.ByAgent) != 2 || r.ByAgent[0] != (AgentCount{Agent: "claude:a1", Count: 2}) {
+		t.Errorf("ByAgent = %+v", r.ByAgent)
+	}
+	if len(r.TopFiles) != 1 || r.TopFiles[0] != (LocationCount{Name: "/src/App.tsx", Count: 2}) {
+		t.Errorf("TopFiles = %+v", r.TopFiles)
+	}
+	if len(r.TopEndpoints) != 1 || r.TopEndpoints[0] != (LocationCount{Name: "/api/users/:id", Count: 2}) {
+		t.Errorf("TopEndpoints = %+v", r.TopEndpoints)
+	}
+
+	out := formatStatsHuman(r)
+	for _, want := range []string{"Errors: 5", "By type:", "By tag:", "checkout-v2", "By agent:", "reviewer-bot", "Top files:", "/src/App.tsx", "Top endpoints:", "/api/users/:id"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestStatsCommand_JSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, ".agentlog"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, ".agentlog", "errors.jsonl"), []byte(
+		`{"timestamp":"2025-12-10T19:19:32.941Z","source":"backend","error_type":"DATABASE_ERROR","message":"boom","context":{"file":"db.go"}}
+`), 0644)
+
+	originalPath, originalJSON := pathOverride, jsonOutput
+	defer func() { pathOverride, jsonOutput = originalPath, originalJSON }()
+	pathOverride, jsonOutput = tmpDir, true
+	statsSince, statsLimit = "", 5
+
+	buf := new(bytes.Buffer)
+	statsCmd.SetOut(buf)
+	defer statsCmd.SetOut(nil)
+	if err := runStats(statsCmd, nil); err != nil {
+		t.Fatalf("runStats() error = %v", err)
+	}
+
+	var r StatsReport
+	if err := json.Unmarshal(buf.Bytes(), &r); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if r.TotalErrors != 1 || len(r.TopFiles) != 1 || r.TopFiles[0].Name != "db.go" {
+		t.Errorf("unexpected report: %+v", r)
+	}
+}
+
+func TestStatsCommand_Kind(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, ".agentlog"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, ".agentlog", "errors.jsonl"), []byte(
+		`{"timestamp":"2025-12-10T19:19:32.941Z","source":"backend","error_type":"DATABASE_ERROR","message":"boom"}
+{"timestamp":"2025-12-10T19:19:33.941Z","source":"backend","error_type":"SLOW_QUERY","message":"orders report","kind":"perf","duration_ms":8200}
+`), 0644)
+
+	originalPath, originalJSON := pathOverride, jsonOutput
+	defer func() { pathOverride, jsonOutput, statsKind = originalPath, originalJSON, kindError }()
+	pathOverride, jsonOutput = tmpDir, false
+	statsSince, statsLimit, statsKind = "", 5, kindError
+
+	buf := new(bytes.Buffer)
+	statsCmd.SetOut(buf)
+	defer statsCmd.SetOut(nil)
+	if err := runStats(statsCmd, nil); err != nil {
+		t.Fatalf("runStats() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "Errors: 1\n") || strings.Contains(buf.String(), "SLOW_QUERY") {
+		t.Errorf("stats should count errors only by default, got:\n%s", buf.String())
+	}
+
+	statsKind = kindPerf
+	buf.Reset()
+	if err := runStats(statsCmd, nil); err != nil {
+		t.Fatalf("runStats() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "Slow operations: 1\n") || !strings.Contains(buf.String(), "SLOW_QUERY") {
+		t.Errorf("--kind perf should count perf entries, got:\n%s", buf.String())
+	}
+
+	statsKind = "both"
+	if err := runStats(statsCmd, nil); err == nil {
+		t.Error("unknown --kind should fail")
+	}
+}
@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/config"
+	"github.com/agentlog/agentlog/internal/diag"
+)
+
+// stormErrorType is the type of the entry serve writes when a storm starts
+const stormErrorType = "ERROR_STORM"
+
+// Defaults for what counts as a storm, overridden by "storms" in config
+const (
+	defaultStormThreshold = 50
+	defaultStormWindow    = time.Minute
+)
+
+// maxStorms is the most storms prime and doctor list, the latest first
+const maxStorms = 3
+
+// stormSettings reads the storm threshold and window from cfg. Invalid
+// values are warned about and replaced with the defaults.
+func stormSettings(cfg *config.Config) (threshold int, window time.Duration) {
+	threshold, window = defaultStormThreshold, defaultStormWindow
+	if cfg.Storms.Threshold > 0 {
+		threshold = cfg.Storms.Threshold
+	} else if cfg.Storms.Threshold < 0 {
+		diag.Warnf("ignoring storms.threshold %d: must be positive", cfg.Storms.Threshold)
+	}
+	if cfg.Storms.Window != "" {
+		if d, ok := parseSpan(cfg.Storms.Window); ok && d > 0 {
+			window = d
+		} else {
+			diag.Warnf("ignoring storms.window %q (e.g. 30s, 1m)", cfg.Storms.Window)
+		}
+	}
+	return threshold, window
+}
+
+// stormSettingsFor is stormSettings for baseDir's config
+func stormSettingsFor(baseDir string) (int, time.Duration) {
+	cfg, err := config.Load(baseDir)
+	if err != nil {
+		cfg = &config.Config{}
+	}
+	return stormSettings(cfg)
+}
+
+// ErrorStorm is a burst of one error: at least the threshold of its
+// occurrences within the window, and every occurrence in windows that
+// overlap it
+type ErrorStorm struct {
+	Fingerprint string `json:"fingerprint"`
+	ErrorType   string `json:"error_type"`
+	Pattern     string `json:"pattern"`
+	Count       int    `json:"count"` // occurrences during the storm
+	Peak        int    `json:"peak"`  // most occurrences within one window
+	Window      string `json:"window"`
+	Started     string `json:"started"`
+	Ended       string `json:"ended"` // the last occurrence
+}
+
+// String describes the storm, e.g. `NETWORK_ERROR "timeout after <n>ms"
+// 412x in 3m10s (peak 120 in 1m0s), started 5m ago, last 10s ago`
+func (s ErrorStorm) String() string {
+	span := "at once"
+	if start, err := parseEntryTime(s.Started); err == nil {
+		if end, err := parseEntryTime(s.Ended); err == nil && end.Sub(start) >= time.Second {
+			span = "in " + end.Sub(start).Round(time.Second).String()
+		}
+	}
+	return fmt.Sprintf("%s %q %dx %s (peak %d in %s), started %s, last %s",
+		s.ErrorType, truncate(s.Pattern, 80), s.Count, span, s.Peak, s.Window, formatTimestamp(s.Started), formatTimestamp(s.Ended))
+}
+
+// stormHit is occurrences of an error at one time
+type stormHit struct {
+	at time.Time
+	n  int
+}
+
+// detectStorms finds errors with threshold or more occurrences within
+// window among entries, returning each one's latest storm, the most recent
+// first. ERROR_STORM entries themselves aren't counted.
+func detectStorms(entries []ErrorEntry, threshold int, window time.Duration) []ErrorStorm {
+	type series struct {
+		errorType, pattern string
+		hits               []stormHit
+	}
+	byFingerprint := make(map[string]*series)
+	for _, e := range entries {
+		if e.kind() != kindError || e.ErrorType == stormErrorType {
+			continue
+		}
+		ts, err := parseEntryTime(e.Timestamp)
+		if err != nil {
+			continue
+		}
+		pattern := normalizeMessage(e.Message)
+		fp := fingerprint(e.ErrorType, pattern)
+		s := byFingerprint[fp]
+		if s == nil {
+			s = &series{errorType: e.ErrorType, pattern: pattern}
+			byFingerprint[fp] = s
+		}
+		s.hits = append(s.hits, stormHit{at: ts, n: e.occurrences()})
+	}
+
+	var storms []ErrorStorm
+	for fp, s := range byFingerprint {
+		if storm, ok := latestStorm(s.hits, threshold, window); ok {
+			storm.Fingerprint, storm.ErrorType, storm.Pattern = fp, s.errorType, s.pattern
+			storms = append(storms, storm)
+		}
+	}
+	sort.Slice(storms, func(i, j int) bool {
+		if storms[i].Ended != storms[j].Ended {
+			return timestampBefore(storms[j].Ended, storms[i].Ended)
+		}
+		return storms[i].Fingerprint < storms[j].Fingerprint
+	})
+	return storms
+}
+
+// latestStorm slides a window over hits and returns the last run of
+// overlapping windows holding threshold or more occurrences
+func latestStorm(hits []stormHit, threshold int, window time.Duration) (ErrorStorm, bool) {
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].at.Before(hits[j].at) })
+
+	var storm ErrorStorm
+	var found bool
+	var stormFrom, stormTo int // hits in the current storm
+	inWindow, left := 0, 0
+	for right, h := range hits {
+		inWindow += h.n
+		for h.at.Sub(hits[left].at) > window {
+			inWindow -= hits[left].n
+			left++
+		}
+		if inWindow < threshold {
+			continue
+		}
+		if !found || left > stormTo {
+			storm = ErrorStorm{Window: window.String()}
+			stormFrom = left
+			found = true
+		}
+		stormTo = right
+		if inWindow > storm.Peak {
+			storm.Peak = inWindow
+		}
+	}
+	if !found {
+		return storm, false
+	}
+	for _, h := range hits[stormFrom : stormTo+1] {
+		storm.Count += h.n
+	}
+	storm.Started = hits[stormFrom].at.UTC().Format(time.RFC3339Nano)
+	storm.Ended = hits[stormTo].at.UTC().Format(time.RFC3339Nano)
+	return storm, true
+}
+
+// stormDetector watches entries as serve writes them and writes an
+// ERROR_STORM entry when an error reaches the threshold within the window.
+// One entry is written per storm: another needs the error to drop below
+// the threshold first. It's safe for concurrent use.
+type stormDetector struct {
+	threshold int
+	window    time.Duration
+	write     func([]ErrorEntry)
+	now       func() time.Time
+
+	mu     sync.Mutex
+	errors map[string]*stormTrack
+}
+
+// stormTrack is an error's occurrences within the window, and whether
+// they're a storm already reported
+type stormTrack struct {
+	hits   []stormHit
+	active bool
+}
+
+// newStormDetector detects storms as threshold occurrences within window,
+// writing ERROR_STORM entries with write
+func newStormDetector(threshold int, window time.Duration, write func([]ErrorEntry)) *stormDetector {
+	return &stormDetector{threshold: threshold, window: window, write: write, now: time.Now, errors: make(map[string]*stormTrack)}
+}
+
+// observe counts e toward its error's storm
+func (d *stormDetector) observe(e ErrorEntry) {
+	if d == nil || e.kind() != kindError || e.ErrorType == stormErrorType {
+		return
+	}
+	now := d.now()
+	at, err := parseEntryTime(e.Timestamp)
+	if err != nil || at.After(now) {
+		at = now
+	}
+	pattern := normalizeMessage(e.Message)
+	fp := fingerprint(e.ErrorType, pattern)
+
+	d.mu.Lock()
+	t := d.errors[fp]
+	if t == nil {
+		if len(d.errors) >= maxRepeatKeys {
+			d.prune(now)
+		}
+		t = &stormTrack{}
+		d.errors[fp] = t
+	}
+	t.hits = append(t.hits, stormHit{at: at, n: e.occurrences()})
+
+	// Keep only what's still within the window
+	count, kept := 0, t.hits[:0]
+	for _, h := range t.hits {
+		if !h.at.Before(now.Add(-d.window)) {
+			kept = append(kept, h)
+			count += h.n
+		}
+	}
+	t.hits = kept
+	if count < d.threshold {
+		t.active = false
+		d.mu.Unlock()
+		return
+	}
+	if t.active {
+		d.mu.Unlock()
+		return
+	}
+	t.active = true
+	started := t.hits[0].at
+	d.mu.Unlock()
+
+	d.write([]ErrorEntry{{
+		Timestamp:   now.UTC().Format(time.RFC3339Nano),
+		Source:      e.Source,
+		ErrorType:   stormErrorType,
+		Message:     fmt.Sprintf("%s storm: %d in %s: %s", e.ErrorType, count, d.window, truncate(e.Message, 200)),
+		Environment: e.Environment,
+		Project:     e.Project,
+		Context: map[string]interface{}{
+			"fingerprint": fp,
+			"error_type":  e.ErrorType,
+			"pattern":     pattern,
+			"count":       count,
+			"window":      d.window.String(),
+			"started":     started.UTC().Format(time.RFC3339Nano),
+		},
+	}})
+}
+
+// prune forgets errors with nothing left in the window
+func (d *stormDetector) prune(now time.Time) {
+	for fp, t := range d.errors {
+		if n := len(t.hits); n == 0 || t.hits[n-1].at.Before(now.Add(-d.window)) {
+			delete(d.errors, fp)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agentlog/agentlog/internal/config"
+)
+
+// writeStormLog writes a storm of timeouts a few minutes ago, a steadier
+// database error that never bursts, and an earlier storm of the timeouts
+func writeStormLog(t *testing.T, now time.Time) string {
+	t.Helper()
+	var entries []ErrorEntry
+	for i := 0; i < 60; i++ {
+		entries = append(entries,
+			ErrorEntry{Timestamp: now.Add(-5*time.Hour + time.Duration(i)*time.Second).Format(time.RFC3339Nano), Source: "backend", ErrorType: "NETWORK_ERROR", Message: fmt.Sprintf("timeout after %dms", 3000+i)},
+			ErrorEntry{Timestamp: now.Add(-10*time.Minute + time.Duration(i)*500*time.Millisecond).Format(time.RFC3339Nano), Source: "backend", ErrorType: "NETWORK_ERROR", Message: fmt.Sprintf("timeout after %dms", 3000+i)},
+		)
+	}
+	for i := 0; i < 30; i++ {
+		entries = append(entries, ErrorEntry{Timestamp: now.Add(-time.Duration(i) * time.Minute).Format(time.RFC3339Nano), Source: "backend", ErrorType: "DATABASE_ERROR", Message: "connection refused"})
+	}
+	// Collapsed entries count every occurrence they stand for
+	entries = append(entries, ErrorEntry{Timestamp: now.Add(-time.Minute).Format(time.RFC3339Nano), Source: "frontend", ErrorType: "UNCAUGHT_ERROR", Message: "x is undefined", Count: 80})
+	return writeEntriesLog(t, entries)
+}
+
+func TestDetectStorms(t *testing.T) {
+	now := time.Now().UTC()
+	dir := writeStormLog(t, now)
+	entries, _ := readErrors(dir)
+
+	storms := detectStorms(entries, defaultStormThreshold, defaultStormWindow)
+	if len(storms) != 2 {
+		t.Fatalf("storms = %+v", storms)
+	}
+	if s := storms[0]; s.ErrorType != "UNCAUGHT_ERROR" || s.Count != 80 || s.Peak != 80 {
+		t.Errorf("a collapsed entry of 80 is a storm on its own, got %+v", s)
+	}
+	s := storms[1]
+	if s.ErrorType != "NETWORK_ERROR" || s.Pattern != "timeout after <n>ms" || s.Count != 60 || s.Peak != 60 || s.Fingerprint != fingerprint("NETWORK_ERROR", "timeout after <n>ms") {
+		t.Fatalf("the latest timeout storm = %+v", s)
+	}
+	if started, _ := parseEntryTime(s.Started); !started.Equal(now.Add(-10 * time.Minute)) {
+		t.Errorf("Started = %s, want the latest storm's first occurrence", s.Started)
+	}
+	if got := s.String(); !strings.HasPrefix(got, `NETWORK_ERROR "timeout after <n>ms" 60x in 30s (peak 60 in 1m0s), started 10m ago`) {
+		t.Errorf("String() = %q", got)
+	}
+
+	if storms := detectStorms(entries, 61, defaultStormWindow); len(storms) != 1 {
+		t.Errorf("a higher threshold leaves only the 80, got %+v", storms)
+	}
+	if storms := detectStorms(entries, 25, time.Hour); len(storms) != 3 {
+		t.Errorf("30 database errors in 30m is a storm of 25 in 1h, got %+v", storms)
+	}
+}
+
+func TestLatestStorm_Spans(t *testing.T) {
+	start := time.Date(2025, 12, 10, 19, 0, 0, 0, time.UTC)
+	var hits []stormHit
+	// Two bursts of 3 a minute apart, then one stray hit
+	for _, sec := range []int{0, 1, 2, 60, 61, 62, 100} {
+		hits = append(hits, stormHit{at: start.Add(time.Duration(sec) * time.Second), n: 1})
+	}
+	storm, ok := latestStorm(hits, 3, 10*time.Second)
+	if !ok || storm.Count != 3 || storm.Started != start.Add(60*time.Second).Format(time.RFC3339Nano) {
+		t.Errorf("expected the second burst alone, got %+v", storm)
+	}
+	// A window wide enough to join them makes one storm, the stray hit
+	// included
+	if storm, _ := latestStorm(hits, 3, time.Minute); storm.Count != 7 || storm.Peak != 4 || storm.Started != start.Format(time.RFC3339Nano) {
+		t.Errorf("overlapping windows should merge, got %+v", storm)
+	}
+	if _, ok := latestStorm(hits, 4, 10*time.Second); ok {
+		t.Error("no 4 within 10s")
+	}
+}
+
+func TestStormSettings(t *testing.T) {
+	if n, w := stormSettings(&config.Config{}); n != defaultStormThreshold || w != defaultStormWindow {
+		t.Errorf("defaults = %d, %s", n, w)
+	}
+	if n, w := stormSettings(&config.Config{Storms: config.StormConfig{Threshold: 10, Window: "30s"}}); n != 10 || w != 30*time.Second {
+		t.Errorf("configured = %d, %s", n, w)
+	}
+	if n, w := stormSettings(&config.Config{Storms: config.StormConfig{Threshold: -1, Window: "often"}}); n != defaultStormThreshold || w != defaultStormWindow {
+		t.Errorf("invalid values should fall back to the defaults, got %d, %s", n, w)
+	}
+}
+
+func TestStormDetector(t *testing.T) {
+	var mu sync.Mutex
+	var written []ErrorEntry
+	d := newStormDetector(5, time.Minute, func(entries []ErrorEntry) {
+		mu.Lock()
+		written = append(written, entries...)
+		mu.Unlock()
+	})
+	now := time.Date(2025, 12, 10, 19, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+	at := func(msg string) ErrorEntry {
+		return ErrorEntry{Timestamp: now.Format(time.RFC3339Nano), Source: "frontend", ErrorType: "NETWORK_ERROR", Message: msg}
+	}
+
+	for i := 0; i < 12; i++ {
+		d.observe(at(fmt.Sprintf("retry %d failed", i)))
+	}
+	d.observe(at("something else"))
+	if len(written) != 1 {
+		t.Fatalf("expected one ERROR_STORM entry per storm, got %+v", written)
+	}
+	e := written[0]
+	if e.ErrorType != stormErrorType || e.Source != "frontend" || e.Message != "NETWORK_ERROR storm: 5 in 1m0s: retry 4 failed" ||
+		e.Context["fingerprint"] != fingerprint("NETWORK_ERROR", "retry <n> failed") || e.Context["count"] != 5 {
+		t.Errorf("storm entry = %+v", e)
+	}
+
+	// Storm entries aren't counted toward storms
+	for i := 0; i < 5; i++ {
+		d.observe(e)
+	}
+	if len(written) != 1 {
+		t.Fatalf("ERROR_STORM entries shouldn't storm, got %d entries", len(written))
+	}
+
+	// Once it drops below the threshold, a new burst is another storm
+	now = now.Add(2 * time.Minute)
+	d.observe(at("retry 1 failed"))
+	for i := 0; i < 5; i++ {
+		d.observe(at("retry 1 failed"))
+	}
+	if len(written) != 2 {
+		t.Errorf("expected a new storm after the last one ended, got %d entries", len(written))
+	}
+
+	var nilDetector *stormDetector
+	nilDetector.observe(at("x"))
+}
+
+func TestPrimeSummary_Storms(t *testing.T) {
+	dir := writeStormLog(t, time.Now().UTC())
+
+	// The storm 5h ago is in the last 24h, but only each error's latest counts
+	summary := primeInDir(t, dir, false)
+	if len(summary.Storms) != 2 || summary.Storms[1].ErrorType != "NETWORK_ERROR" || summary.Storms[1].Count != 60 {
+		t.Fatalf("Storms = %+v", summary.Storms)
+	}
+	if !strings.HasPrefix(summary.ActionableTip, "Treat the UNCAUGHT_ERROR storm (80x) as one problem") {
+		t.Errorf("ActionableTip = %q", summary.ActionableTip)
+	}
+	out := formatPrimeSummaryHuman(summary)
+	if !strings.Contains(out, ")\n  ERROR STORM: UNCAUGHT_ERROR \"x is undefined\" 80x at once") {
+		t.Errorf("expected the storms right under the counts:\n%s", out)
+	}
+	if claude := formatPrimeClaude(summary); !strings.Contains(claude, "treat it as a single problem") {
+		t.Errorf("the claude preset should explain storms:\n%s", claude)
+	}
+	if cursor := formatPrimeCursor(summary); !strings.Contains(cursor, "**STORM** `UNCAUGHT_ERROR` 80x") {
+		t.Errorf("cursor = %s", cursor)
+	}
+	if generic := formatPrimeGenericJSON(summary); !strings.Contains(generic, `"storms": [`) {
+		t.Errorf("generic-json should list storms:\n%s", generic)
+	}
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/agentlog/agentlog/internal/config"
+	"github.com/agentlog/agentlog/internal/diag"
+)
+
+// Suggestion is a short next step for an error, from the first rule that
+// matches it
+type Suggestion struct {
+	Rule string `json:"rule"`
+	Hint string `json:"hint"`
+}
+
+// suggestionRule maps errors whose message matches pattern to a hint.
+// types limits it to those error types; empty means any.
+type suggestionRule struct {
+	name    string
+	types   []string
+	pattern *regexp.Regexp
+	hint    string
+}
+
+const dbTimeoutHint = "Queries are waiting on the database: look for a query per row in a loop (N+1), missing indexes, or connections never released; batch or eager-load related rows."
+
+// builtinSuggestions cover errors common enough that the next step rarely
+// depends on the project. They're checked in order, after the rules in
+// config.json.
+var builtinSuggestions = []suggestionRule{
+	{
+		name:    "connection-refused",
+		pattern: regexp.MustCompile(`(?i)ECONNREFUSED|connection refused`),
+		hint:    "Nothing is listening on that host and port: start the service (database, API, dev server) or fix the configured port.",
+	},
+	{
+		name:    "address-in-use",
+		pattern: regexp.MustCompile(`(?i)EADDRINUSE|address already in use`),
+		hint:    "Another process holds the port: stop it (lsof -i :<port>) or configure a different port.",
+	},
+	{
+		name:    "host-not-found",
+		pattern: regexp.MustCompile(`(?i)ENOTFOUND|getaddrinfo|no such host|name or service not known`),
+		hint:    "The hostname doesn't resolve: check the URL or host in config and environment variables.",
+	},
+	{
+		name:    "cors",
+		pattern: regexp.MustCompile(`(?i)\bCORS\b|Access-Control-Allow-Origin|cross-origin request blocked`),
+		hint:    "The API must allow this origin: add it to the server's CORS settings (including the OPTIONS preflight), or proxy the request through the dev server.",
+	},
+	{
+		name:    "undefined-property",
+		pattern: regexp.MustCompile(`(?i)cannot read propert(?:y|ies) .*of (?:undefined|null)|undefined is not an object|is not a function|cannot destructure property`),
+		hint:    "A value is missing where it's used: find where it should be set (an API response, props, an await) and guard or default it at the access.",
+	},
+	{
+		name:    "none-attribute",
+		pattern: regexp.MustCompile(`'NoneType' object (?:has no attribute|is not subscriptable)`),
+		hint:    "A value is None where it's used: find the call that returned None (a lookup, a missing return) and handle that case.",
+	},
+	{
+		name:    "nil-pointer",
+		pattern: regexp.MustCompile(`(?i)nil pointer dereference|nil map`),
+		hint:    "A nil pointer or map is used: check the error returned alongside it, or initialize it before use.",
+	},
+	{
+		name:    "db-timeout",
+		types:   []string{"DATABASE_ERROR", "DB_ERROR", "QUERY_ERROR", "SLOW_QUERY"},
+		pattern: regexp.MustCompile(`(?i)time(?:d)? ?out|too many connections|pool (?:is )?(?:exhausted|full)`),
+		hint:    dbTimeoutHint,
+	},
+	{
+		name:    "query-timeout",
+		pattern: regexp.MustCompile(`(?i)(?:query|statement) (?:timeout|timed out)|canceling statement due to statement timeout|KnexTimeoutError|ConnectionAcquireTimeoutError`),
+		hint:    dbTimeoutHint,
+	},
+	{
+		name:    "module-not-found",
+		pattern: regexp.MustCompile(`(?i)cannot find module|module not found|no module named|cannot find package`),
+		hint:    "An import doesn't resolve: install the dependency, or fix the import path (case, extension, alias).",
+	},
+	{
+		name:    "unauthorized",
+		pattern: regexp.MustCompile(`(?i)status(?: code)? (?:401|403)\b|\b(?:401|403) (?:unauthorized|forbidden)|unauthori[sz]ed|forbidden|invalid token|jwt expired`),
+		hint:    "The request's credentials are missing or expired: check the token or API key it sends, and the session's expiry.",
+	},
+	{
+		name:    "hydration",
+		pattern: regexp.MustCompile(`(?i)hydration|did not match\. server`),
+		hint:    "Server and client rendered different markup: move browser-only values (Date.now, window, random IDs) into an effect.",
+	},
+	{
+		name:    "infinite-loop",
+		pattern: regexp.MustCompile(`(?i)maximum (?:update depth|call stack size) exceeded|too many re-renders|recursion(?:error)?`),
+		hint:    "Something recurses without end: a state update during render or in an effect without dependencies, or a function calling itself.",
+	},
+}
+
+// suggester matches errors against the project's suggestion rules, then
+// the built-in ones
+type suggester struct {
+	rules []suggestionRule
+}
+
+// suggesterFor loads the "suggestions" rules in baseDir's config. Rules
+// with an invalid pattern are skipped with a warning.
+func suggesterFor(baseDir string) *suggester {
+	s := &suggester{}
+	if cfg, err := config.Load(baseDir); err == nil {
+		for _, r := range cfg.Suggestions {
+			pattern, err := regexp.Compile(r.Match)
+			if err != nil || r.Hint == "" {
+				diag.Warnf("skipping suggestion rule %q: needs a valid \"match\" pattern and a \"hint\"", r.Name)
+				continue
+			}
+			s.rules = append(s.rules, suggestionRule{name: r.Name, types: r.Types, pattern: pattern, hint: r.Hint})
+		}
+	}
+	s.rules = append(s.rules, builtinSuggestions...)
+	return s
+}
+
+// suggest returns the suggestion for an error's type and message, or nil
+func (s *suggester) suggest(errorType, message string) *Suggestion {
+	for _, r := range s.rules {
+		if len(r.types) > 0 && !containsFold(r.types, errorType) {
+			continue
+		}
+		if r.pattern.MatchString(message) {
+			name := r.name
+			if name == "" {
+				name = "custom"
+			}
+			return &Suggestion{Rule: name, Hint: r.hint}
+		}
+	}
+	return nil
+}
+
+// mark sets Suggestion on the error entries a rule matches, in place, and
+// returns them
+func (s *suggester) mark(entries []ErrorEntry) []ErrorEntry {
+	for i := range entries {
+		if entries[i].kind() == kindError {
+			entries[i].Suggestion = s.suggest(entries[i].ErrorType, entries[i].Message)
+		}
+	}
+	return entries
+}
+
+func containsFold(values []string, v string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// GroupSuggestion is a suggested next step for one of prime's top groups
+type GroupSuggestion struct {
+	Fingerprint string `json:"fingerprint"`
+	ErrorType   string `json:"error_type"`
+	Suggestion
+}
+
+// String pairs the hint with its error type, e.g. `NETWORK_ERROR: Nothing
+// is listening ...`
+func (s GroupSuggestion) String() string {
+	return fmt.Sprintf("%s: %s", s.ErrorType, s.Hint)
+}
+
+// suggestGroups returns a suggestion for each group a rule matches, using
+// its latest message, one per rule
+func (s *suggester) suggestGroups(groups []ErrorGroup) []GroupSuggestion {
+	var result []GroupSuggestion
+	seen := make(map[string]bool)
+	for _, g := range groups {
+		sug := s.suggest(g.ErrorType, g.Message)
+		if sug == nil || seen[sug.Rule] {
+			continue
+		}
+		seen[sug.Rule] = true
+		result = append(result, GroupSuggestion{Fingerprint: g.Fingerprint, ErrorType: g.ErrorType, Suggestion: *sug})
+	}
+	return result
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSuggest_Builtin(t *testing.T) {
+	s := suggesterFor(t.TempDir())
+	tests := []struct {
+		errorType, message, want string
+	}{
+		{"NETWORK_ERROR", "connect ECONNREFUSED 127.0.0.1:5432", "connection-refused"},
+		{"NETWORK_ERROR", "Access to fetch at 'http://api' has been blocked by CORS policy", "cors"},
+		{"UNCAUGHT_ERROR", "Cannot read properties of undefined (reading 'map')", "undefined-property"},
+		{"PYTHON_ERROR", "'NoneType' object has no attribute 'id'", "none-attribute"},
+		{"DATABASE_ERROR", "Connection pool exhausted after 30000ms", "db-timeout"},
+		{"BACKEND_ERROR", "canceling statement due to statement timeout", "query-timeout"},
+		{"BUILD_ERROR", "Cannot find module './utils'", "module-not-found"},
+		{"HTTP_ERROR", "Request failed with status code 401", "unauthorized"},
+		{"REACT_ERROR", "Maximum update depth exceeded", "infinite-loop"},
+		{"BUILD_ERROR", "tsc exited with 2", ""},
+		// db-timeout only applies to database error types
+		{"NETWORK_ERROR", "request timed out", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if sug := s.suggest(tt.errorType, tt.message); sug != nil {
+			got = sug.Rule
+		}
+		if got != tt.want {
+			t.Errorf("suggest(%s, %q) = %q, want %q", tt.errorType, tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestSuggesterFor_ConfigRules(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, ".agentlog"), 0755)
+	os.WriteFile(filepath.Join(dir, ".agentlog", "config.json"), []byte(`{"suggestions": [
+		{"name": "broken", "match": "(", "hint": "never used"},
+		{"name": "no-hint", "match": "refused"},
+		{"name": "local-db", "types": ["database_error"], "match": "(?i)econnrefused", "hint": "Run docker compose up db"},
+		{"match": "quota", "hint": "Raise the quota"}
+	]}`), 0644)
+
+	s := suggesterFor(dir)
+	if sug := s.suggest("DATABASE_ERROR", "connect ECONNREFUSED 127.0.0.1:5432"); sug == nil || sug.Rule != "local-db" || sug.Hint != "Run docker compose up db" {
+		t.Errorf("a config rule should come before the built-in one, got %+v", sug)
+	}
+	if sug := s.suggest("NETWORK_ERROR", "connect ECONNREFUSED 127.0.0.1:80"); sug == nil || sug.Rule != "connection-refused" {
+		t.Errorf("other types should fall through to the built-in rule, got %+v", sug)
+	}
+	if sug := s.suggest("X", "quota exceeded"); sug == nil || sug.Rule != "custom" {
+		t.Errorf("an unnamed rule should be reported as custom, got %+v", sug)
+	}
+	if len(s.rules) != len(builtinSuggestions)+2 {
+		t.Errorf("invalid rules should be skipped, got %d rules", len(s.rules))
+	}
+}
+
+func TestSuggestGroups(t *testing.T) {
+	groups := []ErrorGroup{
+		{Fingerprint: "a", ErrorType: "NETWORK_ERROR", Message: "connect ECONNREFUSED 127.0.0.1:5432"},
+		{Fingerprint: "b", ErrorType: "NETWORK_ERROR", Message: "connect ECONNREFUSED 127.0.0.1:6379"},
+		{Fingerprint: "c", ErrorType: "BUILD_ERROR", Message: "tsc exited with 2"},
+	}
+	got := suggesterFor(t.TempDir()).suggestGroups(groups)
+	if len(got) != 1 || got[0].Fingerprint != "a" || got[0].Rule != "connection-refused" {
+		t.Errorf("expected one suggestion per rule, got %+v", got)
+	}
+	if !strings.HasPrefix(got[0].String(), "NETWORK_ERROR: Nothing is listening") {
+		t.Errorf("String() = %q", got[0].String())
+	}
+}
+
+func TestRenderErrors_Full(t *testing.T) {
+	now := time.Now().UTC()
+	dir := writeEntriesLog(t, []ErrorEntry{
+		{Timestamp: now.Add(-time.Minute).Format(time.RFC3339Nano), Source: "backend", ErrorType: "NETWORK_ERROR", Message: "connect ECONNREFUSED 127.0.0.1:5432", Context: map[string]interface{}{"query": "SELECT 1"}},
+		{Timestamp: now.Format(time.RFC3339Nano), Source: "backend", ErrorType: "BUILD_ERROR", Message: "tsc exited with 2"},
+	})
+	defer func() { errorsLimit, errorsFull, jsonOutput = 10, false, false }()
+	errorsLimit, errorsSource, errorsType, errorsSince = 10, "", "", ""
+	errorsCount, errorsGroup, errorsPick, errorsFields, errorsTemplate, jsonOutput = false, false, false, "", "", false
+
+	var buf bytes.Buffer
+	if err := renderErrors(&buf, dir); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "Suggestion:") || strings.Contains(buf.String(), "Context:") {
+		t.Errorf("without --full, entries are shown briefly:\n%s", buf.String())
+	}
+
+	errorsFull = true
+	buf.Reset()
+	if err := renderErrors(&buf, dir); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "  Context:\n    query: SELECT 1\n  Suggestion: Nothing is listening") {
+		t.Errorf("--full should show context and the suggestion:\n%s", out)
+	}
+	if strings.Count(out, "Suggestion:") != 1 {
+		t.Errorf("only the matching entry gets a suggestion:\n%s", out)
+	}
+
+	jsonOutput = true
+	buf.Reset()
+	if err := renderErrors(&buf, dir); err != nil {
+		t.Fatal(err)
+	}
+	var entries []ErrorEntry
+	if err := json.Unmarshal(buf.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Suggestion == nil || entries[0].Suggestion.Rule != "connection-refused" || entries[1].Suggestion != nil {
+		t.Errorf("entries = %+v", entries)
+	}
+	if entryID(entries[0]) != entries[0].ID {
+		t.Error("the suggestion shouldn't change the entry's ID")
+	}
+
+	jsonOutput, errorsGroup = false, true
+	defer func() { errorsGroup = false }()
+	if err := renderErrors(&buf, dir); ExitCode(err) != ExitError {
+		t.Errorf("--full with --group should exit 2, got %v", err)
+	}
+}
+
+func TestPrimeSummary_Suggestions(t *testing.T) {
+	now := time.Now().UTC()
+	at := func(d time.Duration) string { return now.Add(d).Format(time.RFC3339Nano) }
+	dir := writeEntriesLog(t, []ErrorEntry{
+		{Timestamp: at(-2 * time.Minute), Source: "frontend", ErrorType: "UNCAUGHT_ERROR", Message: "Cannot read properties of undefined (reading 'map')"},
+		{Timestamp: at(-time.Minute), Source: "frontend", ErrorType: "UNCAUGHT_ERROR", Message: "Cannot read properties of undefined (reading 'map')"},
+		{Timestamp: at(0), Source: "backend", ErrorType: "BUILD_ERROR", Message: "tsc exited with 2"},
+	})
+
+	summary := primeInDir(t, dir, false)
+	if len(summary.Suggestions) != 1 || summary.Suggestions[0].ErrorType != "UNCAUGHT_ERROR" || summary.Suggestions[0].Rule != "undefined-property" {
+		t.Fatalf("Suggestions = %+v", summary.Suggestions)
+	}
+	if out := formatPrimeSummaryHuman(summary); !strings.Contains(out, "  Suggested: UNCAUGHT_ERROR: A value is missing") {
+		t.Errorf("human output should list the suggestion:\n%s", out)
+	}
+	if out := formatPrimeClaude(summary); !strings.Contains(out, "Suggested: UNCAUGHT_ERROR") || !strings.Contains(out, "check that one fits") {
+		t.Errorf("the claude preset should list the suggestion:\n%s", out)
+	}
+	var generic genericPrimeSummary
+	if err := json.Unmarshal([]byte(formatPrimeGenericJSON(summary)), &generic); err != nil || len(generic.Suggestions) != 1 {
+		t.Errorf("generic-json suggestions = %v (%v)", generic.Suggestions, err)
+	}
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultRepeatWindow is how long agentlog serve holds back repeats of an
+// entry it just wrote
+const defaultRepeatWindow = 5 * time.Second
+
+// maxRepeatKeys is how many errors a repeatSuppressor tracks before it
+// forgets the ones it's not holding repeats of
+const maxRepeatKeys = 1024
+
+// repeatSuppressor collapses storms of one error at write time. The first
+// entry is written as it arrives; identical ones (see dedupeKey) arriving
+// within window of it are held back and merged into one collapsed entry
+// (count, first_seen, last_seen, as agentlog dedupe writes) that's written
+// when the window closes, which starts another. A client stuck in a retry
+// loop then adds a line per window instead of one per attempt.
+type repeatSuppressor struct {
+	window time.Duration
+	write  func([]ErrorEntry)
+
+	mu      sync.Mutex
+	repeats map[string]*heldRepeats
+	closed  bool
+}
+
+// heldRepeats is the open window of one error, and its repeats so far
+type heldRepeats struct {
+	until time.Time
+	held  *ErrorEntry
+	timer *time.Timer
+}
+
+// newRepeatSuppressor collapses repeats within window, writing collapsed
+// entries with write
+func newRepeatSuppressor(window time.Duration, write func([]ErrorEntry)) *repeatSuppressor {
+	return &repeatSuppressor{window: window, write: write, repeats: make(map[string]*heldRepeats)}
+}
+
+// admit reports whether e should be written now. If not, it's held as a
+// repeat of an entry written less than the window ago.
+func (s *repeatSuppressor) admit(e ErrorEntry, now time.Time) bool {
+	if s == nil {
+		return true
+	}
+	key := dedupeKey(e)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return true
+	}
+
+	// A window with repeats held stays open until its timer writes them
+	r := s.repeats[key]
+	if r == nil || (r.held == nil && !now.Before(r.until)) {
+		if len(s.repeats) >= maxRepeatKeys {
+			s.prune(now)
+		}
+		s.repeats[key] = &heldRepeats{until: now.Add(s.window)}
+		return true
+	}
+	if r.held == nil {
+		r.held = &e
+		r.timer = time.AfterFunc(r.until.Sub(now), func() { s.flush(key) })
+	} else {
+		merged := mergeRepeat(*r.held, e)
+		r.held = &merged
+	}
+	return false
+}
+
+// flush writes the repeats held for key and opens the next window, so a
+// storm that's still going keeps collapsing
+func (s *repeatSuppressor) flush(key string) {
+	s.mu.Lock()
+	r := s.repeats[key]
+	if r == nil || r.held == nil {
+		s.mu.Unlock()
+		return
+	}
+	held := *r.held
+	r.held, r.timer = nil, nil
+	r.until = time.Now().Add(s.window)
+	s.mu.Unlock()
+
+	s.write([]ErrorEntry{held})
+}
+
+// prune forgets errors whose window closed with nothing held
+func (s *repeatSuppressor) prune(now time.Time) {
+	for key, r := range s.repeats {
+		if r.held == nil && !now.Before(r.until) {
+			delete(s.repeats, key)
+		}
+	}
+}
+
+// close writes every held repeat now; entries admitted after it are
+// written as they come
+func (s *repeatSuppressor) close() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	var held []ErrorEntry
+	for _, r := range s.repeats {
+		if r.held != nil {
+			r.timer.Stop()
+			held = append(held, *r.held)
+		}
+	}
+	s.repeats, s.closed = nil, true
+	s.mu.Unlock()
+
+	if len(held) > 0 {
+		s.write(held)
+	}
+}
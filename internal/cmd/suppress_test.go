@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRepeatSuppressor(t *testing.T) {
+	var mu sync.Mutex
+	var written []ErrorEntry
+	s := newRepeatSuppressor(time.Hour, func(entries []ErrorEntry) {
+		mu.Lock()
+		written = append(written, entries...)
+		mu.Unlock()
+	})
+
+	start := time.Date(2025, 12, 10, 19, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) ErrorEntry {
+		return ErrorEntry{Timestamp: start.Add(d).Format(time.RFC3339), Source: "backend", ErrorType: "NETWORK_ERROR", Message: "timeout after 3004ms"}
+	}
+	if !s.admit(at(0), start) {
+		t.Fatal("the first entry should be written")
+	}
+	for i := 1; i <= 3; i++ {
+		e := at(time.Duration(i) * time.Second)
+		e.Message = fmt.Sprintf("timeout after 300%dms", i)
+		if s.admit(e, start.Add(time.Duration(i)*time.Second)) {
+			t.Fatalf("repeat %d should be held", i)
+		}
+	}
+	other := at(time.Second)
+	other.ErrorType = "DATABASE_ERROR"
+	if !s.admit(other, start.Add(time.Second)) {
+		t.Error("another error should be written")
+	}
+
+	s.close()
+	if len(written) != 1 {
+		t.Fatalf("close should write the held repeats as one entry, got %+v", written)
+	}
+	if e := written[0]; e.Count != 3 || e.FirstSeen != at(time.Second).Timestamp || e.LastSeen != at(3*time.Second).Timestamp || e.Message != "timeout after 3003ms" {
+		t.Errorf("collapsed entry = %+v", e)
+	}
+	if !s.admit(at(4*time.Second), start.Add(4*time.Second)) {
+		t.Error("entries after close should be written")
+	}
+
+	var nilSuppressor *repeatSuppressor
+	if !nilSuppressor.admit(at(0), start) {
+		t.Error("a nil suppressor should write everything")
+	}
+	nilSuppressor.close()
+}
+
+func TestRepeatSuppressor_WindowCloses(t *testing.T) {
+	done := make(chan []ErrorEntry, 1)
+	s := newRepeatSuppressor(20*time.Millisecond, func(entries []ErrorEntry) { done <- entries })
+	defer s.close()
+
+	e := ErrorEntry{Timestamp: time.Now().UTC().Format(time.RFC3339Nano), ErrorType: "X", Message: "a"}
+	s.admit(e, time.Now())
+	if s.admit(e, time.Now()) {
+		t.Fatal("a repeat within the window should be held")
+	}
+	select {
+	case entries := <-done:
+		if len(entries) != 1 || entries[0].occurrences() != 1 {
+			t.Errorf("a single held repeat should be written as is, got %+v", entries)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("held repeats weren't written when the window closed")
+	}
+
+	// The flush opened another window: a repeat in it is held again
+	if s.admit(e, time.Now()) {
+		t.Error("a storm that keeps going should keep collapsing")
+	}
+}
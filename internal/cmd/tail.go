@@ -1,9 +1,9 @@
 package cmd
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -13,6 +13,8 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/agentlog/agentlog/internal/diag"
+	"github.com/agentlog/agentlog/internal/logfile"
 	"github.com/agentlog/agentlog/internal/self"
 	"github.com/spf13/cobra"
 )
@@ -27,12 +29,27 @@ Outputs errors in real-time as they are logged. Use Ctrl+C to stop watching.
 
 Examples:
   agentlog tail          # Watch errors in human-readable format
-  agentlog tail --json   # Watch errors in JSON format (one object per line)`,
+  agentlog tail --json   # Watch errors in JSON format (one object per line)
+  agentlog tail --absolute  # Full timestamps instead of "3m ago"
+
+A "formatter" command set in .agentlog/config.json renders the human-readable
+output, as it does for 'agentlog errors': it's started once and reads new
+entries as NDJSON on stdin as they arrive. --no-formatter bypasses it.
+
+Entries are checked against the "alerts" rules in .agentlog/config.json as
+they're read; see 'agentlog serve --help'. Errors marked fixed with
+'agentlog resolve' that occur again are labeled REGRESSION.`,
 	RunE: runTail,
 }
 
+var tailNoFormat bool
+
+// errFormatterExited stops tail when its formatter exits before Ctrl-C
+var errFormatterExited = errors.New("formatter exited")
+
 func init() {
 	rootCmd.AddCommand(tailCmd)
+	tailCmd.Flags().BoolVar(&tailNoFormat, "no-formatter", false, "Use the built-in human-readable output even if config.json sets a formatter")
 }
 
 func runTail(cmd *cobra.Command, args []string) error {
@@ -48,21 +65,50 @@ func runTail(cmd *cobra.Command, args []string) error {
 	}
 
 	// Set up signal handling for graceful shutdown
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sigChan
-		cancel()
+		cancel(nil)
 	}()
 
-	// Run tail
-	err := tailFile(ctx, baseDir, cmd.OutOrStdout(), IsJSONOutput())
+	// A formatter is fed the entries as JSON lines and prints them in our
+	// place; tail stops if it exits
+	w, jsonMode := cmd.OutOrStdout(), IsJSONOutput()
+	var formatter *formatterStream
+	if command := configuredFormatter(baseDir); command != "" && !jsonMode && !tailNoFormat {
+		var err error
+		formatter, err = startFormatter(w, baseDir, command)
+		if err != nil {
+			self.LogError(baseDir, "FORMATTER_ERROR", err.Error())
+			return err
+		}
+		w, jsonMode = formatter.stdin, true
+		go func() {
+			<-formatter.exited
+			cancel(errFormatterExited)
+		}()
+	}
+
+	// Run tail, noting edits as they happen when the project tracks them
+	stopEdits := watchEdits(baseDir)
+	err := tailFile(ctx, baseDir, w, jsonMode)
+	stopEdits()
+	if formatter != nil {
+		if ferr := formatter.close(); ferr != nil && context.Cause(ctx) == errFormatterExited {
+			self.LogError(baseDir, "FORMATTER_ERROR", ferr.Error())
+			return ferr
+		}
+	}
 	if err != nil && err != context.Canceled {
 		if os.IsNotExist(err) {
 			fmt.Fprintln(cmd.OutOrStdout(), "No errors file found. Run 'agentlog init' to set up.")
+			if uninitialized(baseDir) {
+				return exitNotInitialized(cmd)
+			}
 			return nil
 		}
 		return err
@@ -73,6 +119,11 @@ func runTail(cmd *cobra.Command, args []string) error {
 
 // formatTailEntry formats a single error entry for tail output
 func formatTailEntry(entry ErrorEntry, jsonMode bool) string {
+	// Entries echoed by ingesters haven't been read back from the file
+	if entry.ID == "" {
+		entry.ID = entryID(entry)
+	}
+
 	if jsonMode {
 		output, err := json.Marshal(entry)
 		if err != nil {
@@ -83,12 +134,21 @@ func formatTailEntry(entry ErrorEntry, jsonMode bool) string {
 
 	// Human-readable format
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("[%s] %s\n", entry.Timestamp, entry.Message))
-	sb.WriteString(fmt.Sprintf("  Source: %s | Type: %s\n", entry.Source, entry.ErrorType))
+	message := entry.Message
+	if entry.Regression != nil {
+		message = colors.err("REGRESSION:") + " " + message
+	}
+	sb.WriteString(fmt.Sprintf("%s %s\n", colors.dim("["+formatTimestamp(entry.Timestamp)+"]"), message))
+	sb.WriteString(fmt.Sprintf("  ID: %s | Source: %s | Type: %s\n", entry.ID, entry.Source, colors.err(entry.ErrorType)))
+	if entry.Regression != nil {
+		sb.WriteString(fmt.Sprintf("  Resolved: %s (group %s)\n", formatTimestamp(entry.Regression.ResolvedAt), entry.Regression.Fingerprint))
+	}
+	writeFixLines(&sb, "  ", entry.PastFixes)
 	return sb.String()
 }
 
-// tailFile watches the errors file and outputs new entries
+// tailFile watches the errors file and outputs new entries, redacted,
+// checking each against the alert rules
 func tailFile(ctx context.Context, baseDir string, w io.Writer, jsonMode bool) error {
 	filePath := filepath.Join(baseDir, ".agentlog", "errors.jsonl")
 
@@ -104,8 +164,24 @@ func tailFile(ctx context.Context, baseDir string, w io.Writer, jsonMode bool) e
 	}
 	defer file.Close()
 
+	alerts := newAlertEngine(baseDir)
+	defer alerts.wait()
+	policy := entryPolicyFor(baseDir)
+	resolutions := loadResolutions(baseDir)
+	emit := func(entry ErrorEntry) {
+		resolutions.refresh()
+		if r := resolutions.match(entry); r != nil {
+			entry.Regression = &Regression{Fingerprint: r.Fingerprint, ResolvedAt: r.ResolvedAt}
+		}
+		entry.PastFixes = resolutions.fixes.match(entry)
+		entry = policy.apply(entry)
+		fmt.Fprintln(w, formatTailEntry(entry, jsonMode))
+		alerts.observe(entry)
+	}
+
 	// Read and output all existing entries first
-	scanner := bufio.NewScanner(file)
+	maxLine := maxLineBytes(baseDir)
+	scanner := logfile.NewLines(file, maxLine)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" {
@@ -117,18 +193,17 @@ func tailFile(ctx context.Context, baseDir string, w io.Writer, jsonMode bool) e
 			continue // Skip malformed lines
 		}
 
-		fmt.Fprintln(w, formatTailEntry(entry, jsonMode))
+		emit(entry)
 	}
+	warnSkippedLines(scanner.Skipped, maxLine)
 
 	if err := scanner.Err(); err != nil {
 		return fmt.Errorf("error reading file: %w", err)
 	}
 
-	// Get current position (after reading existing entries)
-	offset, err := file.Seek(0, io.SeekCurrent)
-	if err != nil {
-		return fmt.Errorf("error getting file position: %w", err)
-	}
+	// Position after the existing entries
+	offset := scanner.Offset()
+	diag.Debugf("following %s from offset %d", filePath, offset)
 
 	// Poll for new entries
 	pollInterval := 500 * time.Millisecond
@@ -141,7 +216,7 @@ func tailFile(ctx context.Context, baseDir string, w io.Writer, jsonMode bool) e
 			return ctx.Err()
 		case <-ticker.C:
 			// Check for new content
-			newOffset, err := readNewEntries(filePath, offset, w, jsonMode)
+			newOffset, err := readNewEntries(filePath, offset, maxLine, emit)
 			if err != nil {
 				// File might have been truncated or rotated
 				if os.IsNotExist(err) {
@@ -155,8 +230,9 @@ func tailFile(ctx context.Context, baseDir string, w io.Writer, jsonMode bool) e
 	}
 }
 
-// readNewEntries reads any new entries after the given offset
-func readNewEntries(filePath string, offset int64, w io.Writer, jsonMode bool) (int64, error) {
+// readNewEntries passes any new entries after the given offset to emit,
+// skipping lines longer than maxLine
+func readNewEntries(filePath string, offset int64, maxLine int, emit func(ErrorEntry)) (int64, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return offset, err
@@ -170,7 +246,7 @@ func readNewEntries(filePath string, offset int64, w io.Writer, jsonMode bool) (
 	}
 
 	// Read any new lines
-	scanner := bufio.NewScanner(file)
+	scanner := logfile.NewLines(file, maxLine)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" {
@@ -182,14 +258,13 @@ func readNewEntries(filePath string, offset int64, w io.Writer, jsonMode bool) (
 			continue // Skip malformed lines
 		}
 
-		fmt.Fprintln(w, formatTailEntry(entry, jsonMode))
+		emit(entry)
 	}
-
-	// Get new offset
-	newOffset, err := file.Seek(0, io.SeekCurrent)
-	if err != nil {
-		return offset, err
+	skipped := scanner.Skipped
+	for i := range skipped {
+		skipped[i].Line = 0 // counted from offset, not the start of the file
 	}
+	warnSkippedLines(skipped, maxLine)
 
-	return newOffset, scanner.Err()
+	return offset + scanner.Offset(), scanner.Err()
 }
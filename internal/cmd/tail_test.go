@@ -30,6 +30,27 @@ func TestFormatTailEntry_Human(t *testing.T) {
 	if !strings.Contains(output, "UNCAUGHT_ERROR") {
 		t.Error("output should contain error type")
 	}
+	if !strings.Contains(output, "ID: "+entryID(entry)) {
+		t.Error("output should contain the entry ID")
+	}
+}
+
+func TestFormatTailEntry_AbsoluteTime(t *testing.T) {
+	defer func() { absoluteTime = false }()
+	entry := ErrorEntry{
+		Timestamp: time.Now().UTC().Add(-2 * time.Hour).Format(time.RFC3339Nano),
+		Source:    "backend",
+		ErrorType: "DATABASE_ERROR",
+		Message:   "connection refused",
+	}
+
+	if output := formatTailEntry(entry, false); !strings.HasPrefix(output, "[2h ago] ") {
+		t.Errorf("tail should show relative time by default, got %q", output)
+	}
+	absoluteTime = true
+	if output := formatTailEntry(entry, false); !strings.HasPrefix(output, "["+entry.Timestamp+"] ") {
+		t.Errorf("--absolute should show the raw timestamp, got %q", output)
+	}
 }
 
 func TestFormatTailEntry_JSON(t *testing.T) {
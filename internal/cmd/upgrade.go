@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/agentlog/agentlog/internal/config"
+	"github.com/agentlog/agentlog/internal/logfile"
+	"github.com/agentlog/agentlog/internal/self"
+	"github.com/spf13/cobra"
+)
+
+var upgradeForce bool
+
+// UpgradeFile is one installed file upgrade looked at
+type UpgradeFile struct {
+	Path string `json:"path"`
+	// Status is "current", "upgraded", "stamped" (unchanged, but installed
+	// before stamps), "modified" (edited locally; left alone), or
+	// "unversioned" (no stamp and different; left alone)
+	Status string `json:"status"`
+	Diff   string `json:"diff,omitempty"` // for files left alone, the change --force would make
+}
+
+// UpgradeReport is the result of agentlog upgrade
+type UpgradeReport struct {
+	Files   []UpgradeFile `json:"files"`
+	Pending int           `json:"pending"` // outdated files that need --force
+}
+
+// upgradeCmd represents the upgrade command
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Rewrite installed capture files from the latest templates",
+	Long: `Rewrite the files 'agentlog init --install' created (capture files,
+framework hooks, the Rails controller and initializer) from the current
+templates.
+
+Each file init creates starts with an agentlog:template stamp, a hash of
+what was written. A file whose stamp differs from what init would write
+now is outdated. Outdated files that still match their stamp are
+rewritten; files edited since they were installed are left alone, with a
+diff of what --force would change. Files installed before stamps existed
+are treated as edited unless they match the current template exactly.
+
+The files are regenerated with what init recorded under "init" in
+.agentlog/config.json (stacks, browser captures, transport, remote URL,
+hooks), and from .agentlog/templates/ if the project has its own. Lines
+init appended to existing files (config/routes.rb, application.js)
+aren't upgraded.
+
+Exits 1 when outdated files were left alone.
+
+Examples:
+  agentlog upgrade          # Upgrade unmodified files, show diffs for edited ones
+  agentlog upgrade --force  # Overwrite edited files too
+  agentlog upgrade --json`,
+	RunE: runUpgrade,
+}
+
+func init() {
+	rootCmd.AddCommand(upgradeCmd)
+
+	upgradeCmd.Flags().BoolVar(&upgradeForce, "force", false, "Also overwrite files edited since they were installed")
+}
+
+func runUpgrade(cmd *cobra.Command, args []string) error {
+	// Determine base directory (use --path override or cwd)
+	baseDir := GetPathOverride()
+	if baseDir == "" {
+		var err error
+		baseDir, err = os.Getwd()
+		if err != nil {
+			self.LogError(".", "GETWD_ERROR", err.Error())
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+
+	report, err := upgradeFiles(baseDir, upgradeForce)
+	if err != nil {
+		self.LogError(baseDir, "UPGRADE_ERROR", err.Error())
+		return err
+	}
+
+	if IsJSONOutput() {
+		output, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Fprintln(cmd.OutOrStdout(), string(output))
+	} else {
+		printUpgradeReport(cmd.OutOrStdout(), report)
+	}
+	if report.Pending > 0 {
+		return exitWith(cmd, ExitWarnings)
+	}
+	return nil
+}
+
+// upgradeFiles compares installed files with what a fresh install would
+// write now, and rewrites those that are outdated and unmodified (all
+// outdated ones with force)
+func upgradeFiles(baseDir string, force bool) (*UpgradeReport, error) {
+	cfg, err := config.Load(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	fresh := freshFiles{newPlanFiles(baseDir)}
+	if _, err := initWithFiles(baseDir, upgradeOptions(cfg.Init), fresh); err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for path := range fresh.written {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	report := &UpgradeReport{Files: []UpgradeFile{}}
+	for _, path := range paths {
+		latest := string(fresh.written[path])
+		latestSum, latestBody, ok := parseStamp(latest)
+		if !ok {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue // not installed, or removed on purpose
+		}
+		installed := string(data)
+		rel, _ := filepath.Rel(baseDir, path)
+		file := UpgradeFile{Path: filepath.ToSlash(rel)}
+
+		sum, body, stamped := parseStamp(installed)
+		switch {
+		case stamped && sum == latestSum:
+			file.Status = "current"
+		case !stamped && body == latestBody:
+			file.Status = "stamped"
+		case stamped && templateSum(body) == sum, force:
+			file.Status = "upgraded"
+		case stamped:
+			file.Status = "modified"
+		default:
+			file.Status = "unversioned"
+		}
+
+		switch file.Status {
+		case "stamped", "upgraded":
+			if err := logfile.WriteFile(path, []byte(latest), 0644); err != nil {
+				return nil, fmt.Errorf("failed to write %s: %w", file.Path, err)
+			}
+		case "modified", "unversioned":
+			file.Diff = lineDiff(file.Path, installed, latest)
+			report.Pending++
+		}
+		report.Files = append(report.Files, file)
+	}
+	return report, nil
+}
+
+// upgradeOptions are the init options that reproduce a recorded install
+func upgradeOptions(recorded *config.InitConfig) initOptions {
+	opts := initOptions{Install: true}
+	if recorded == nil {
+		return opts
+	}
+	opts.Stacks = recorded.Stacks
+	opts.CaptureConsole = containsString(recorded.Capture, "console")
+	opts.CaptureNetwork = containsString(recorded.Capture, "network")
+	opts.Transport = recorded.Transport
+	opts.Remote = recorded.Remote
+	opts.Integrations = recorded.Integrations
+	opts.OnlyIntegrations = true
+	return opts
+}
+
+// freshFiles plans init as if the project had none of the files init
+// writes, so what it plans is a first install. The config (for the serve
+// token) and project templates are still read.
+type freshFiles struct {
+	*planFiles
+}
+
+// kept reports whether path is read from the project
+func (f freshFiles) kept(path string) bool {
+	return path == config.Path(f.dir) || strings.HasPrefix(path, filepath.Join(f.dir, ".agentlog", "templates")+string(filepath.Separator))
+}
+
+func (f freshFiles) ReadFile(path string) ([]byte, error) {
+	if data, ok := f.written[path]; ok {
+		return data, nil
+	}
+	if f.kept(path) {
+		return os.ReadFile(path)
+	}
+	return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+}
+
+func (f freshFiles) NotExist(path string) bool {
+	if _, ok := f.written[path]; ok || f.dirs[path] {
+		return false
+	}
+	if f.kept(path) {
+		return diskFiles{}.NotExist(path)
+	}
+	return true
+}
+
+// printUpgradeReport prints what upgrade did, then the diffs of the files
+// it left alone
+func printUpgradeReport(w io.Writer, report *UpgradeReport) {
+	if len(report.Files) == 0 {
+		fmt.Fprintln(w, "No installed capture files found. Run 'agentlog init --install' to install them.")
+		return
+	}
+
+	for _, f := range report.Files {
+		switch f.Status {
+		case "current":
+			fmt.Fprintf(w, "  up to date   %s\n", f.Path)
+		case "stamped":
+			fmt.Fprintf(w, "  stamped      %s (matches the current template)\n", f.Path)
+		case "upgraded":
+			fmt.Fprintf(w, "  upgraded     %s\n", f.Path)
+		case "modified":
+			fmt.Fprintf(w, "  modified     %s (edited since install; --force to overwrite)\n", f.Path)
+		case "unversioned":
+			fmt.Fprintf(w, "  unversioned  %s (installed before template stamps and differs; --force to overwrite)\n", f.Path)
+		}
+	}
+	for _, f := range report.Files {
+		if f.Diff != "" {
+			fmt.Fprintln(w)
+			fmt.Fprint(w, f.Diff)
+		}
+	}
+}
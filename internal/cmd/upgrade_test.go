@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func upgradeStatuses(report *UpgradeReport) map[string]string {
+	statuses := make(map[string]string)
+	for _, f := range report.Files {
+		statuses[f.Path] = f.Status
+	}
+	return statuses
+}
+
+func installForUpgrade(t *testing.T, opts initOptions) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	opts.Install = true
+	if _, err := initWithOptions(tmpDir, opts); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	return tmpDir
+}
+
+func TestUpgrade_Current(t *testing.T) {
+	tmpDir := installForUpgrade(t, initOptions{Stacks: []string{"go", "typescript"}, CaptureConsole: true, Integrations: []string{"vue"}})
+
+	report, err := upgradeFiles(tmpDir, false)
+	if err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+	want := map[string]string{".agentlog/capture.go": "current", ".agentlog/capture.ts": "current", ".agentlog/vue.ts": "current"}
+	got := upgradeStatuses(report)
+	for path, status := range want {
+		if got[path] != status {
+			t.Errorf("%s: status %q, want %q (all: %v)", path, got[path], status, got)
+		}
+	}
+	if report.Pending != 0 {
+		t.Errorf("Pending = %d, want 0", report.Pending)
+	}
+}
+
+func TestUpgrade_RewritesOutdated(t *testing.T) {
+	tmpDir := installForUpgrade(t, initOptions{Stacks: []string{"go"}})
+	path := filepath.Join(tmpDir, ".agentlog", "capture.go")
+	latest, _ := os.ReadFile(path)
+	os.WriteFile(path, []byte(stampTemplate(path, "// an older template\n")), 0644)
+
+	report, err := upgradeFiles(tmpDir, false)
+	if err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+	if got := upgradeStatuses(report)[".agentlog/capture.go"]; got != "upgraded" {
+		t.Errorf("status = %q, want upgraded", got)
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(latest) {
+		t.Error("outdated file should be rewritten with the latest template")
+	}
+}
+
+func TestUpgrade_KeepsLocalEdits(t *testing.T) {
+	tmpDir := installForUpgrade(t, initOptions{Stacks: []string{"python"}})
+	path := filepath.Join(tmpDir, ".agentlog", "capture.py")
+	edited := stampTemplate(path, "# an older template\n")
+	edited = strings.Replace(edited, "older template", "older template, edited", 1)
+	os.WriteFile(path, []byte(edited), 0644)
+
+	report, err := upgradeFiles(tmpDir, false)
+	if err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+	if len(report.Files) != 1 || report.Files[0].Status != "modified" || report.Pending != 1 {
+		t.Fatalf("report = %+v, want capture.py modified", report)
+	}
+	if !strings.Contains(report.Files[0].Diff, "-# an older template, edited") {
+		t.Errorf("diff should show the local edit being replaced:\n%s", report.Files[0].Diff)
+	}
+	if data, _ := os.ReadFile(path); string(data) != edited {
+		t.Error("edited file should be left alone without --force")
+	}
+
+	report, err = upgradeFiles(tmpDir, true)
+	if err != nil {
+		t.Fatalf("upgrade --force failed: %v", err)
+	}
+	if report.Files[0].Status != "upgraded" || report.Pending != 0 {
+		t.Errorf("--force should overwrite, got %+v", report)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "sys.excepthook") {
+		t.Error("--force should write the latest template")
+	}
+}
+
+func TestUpgrade_UnversionedFiles(t *testing.T) {
+	tmpDir := installForUpgrade(t, initOptions{Stacks: []string{"go", "rust"}})
+	goPath := filepath.Join(tmpDir, ".agentlog", "capture.go")
+	os.WriteFile(goPath, []byte(snippetGo), 0644)
+	rustPath := filepath.Join(tmpDir, ".agentlog", "capture.rs")
+	os.WriteFile(rustPath, []byte("// installed long ago\n"), 0644)
+
+	report, err := upgradeFiles(tmpDir, false)
+	if err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+	got := upgradeStatuses(report)
+	if got[".agentlog/capture.go"] != "stamped" || got[".agentlog/capture.rs"] != "unversioned" || report.Pending != 1 {
+		t.Errorf("statuses = %v, pending %d", got, report.Pending)
+	}
+	if data, _ := os.ReadFile(goPath); !strings.HasPrefix(string(data), "// agentlog:template ") {
+		t.Error("a file matching the template should get its stamp")
+	}
+}
+
+func TestUpgrade_NothingInstalled(t *testing.T) {
+	report, err := upgradeFiles(t.TempDir(), false)
+	if err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+	if len(report.Files) != 0 {
+		t.Errorf("files = %+v, want none", report.Files)
+	}
+	var out bytes.Buffer
+	printUpgradeReport(&out, report)
+	if !strings.Contains(out.String(), "No installed capture files found") {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+}
+
+func TestPrintUpgradeReport(t *testing.T) {
+	var out bytes.Buffer
+	printUpgradeReport(&out, &UpgradeReport{Files: []UpgradeFile{
+		{Path: ".agentlog/capture.go", Status: "upgraded"},
+		{Path: ".agentlog/capture.py", Status: "modified", Diff: "--- a/.agentlog/capture.py\n"},
+	}, Pending: 1})
+	for _, want := range []string{"upgraded     .agentlog/capture.go", "modified     .agentlog/capture.py (edited since install; --force to overwrite)", "--- a/.agentlog/capture.py"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
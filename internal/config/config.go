@@ -0,0 +1,308 @@
+// Package config loads project configuration from .agentlog/config.json.
+// A missing config file is not an error: every setting has a zero-value
+// default so agentlog stays zero-config.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/agentlog/agentlog/internal/logfile"
+)
+
+// FileName is the config file name inside .agentlog/
+const FileName = "config.json"
+
+// Config is the project configuration stored in .agentlog/config.json
+type Config struct {
+	// Project is stamped on entries that don't name one, so logs merged
+	// from several packages stay distinguishable. Empty means the
+	// project directory's name.
+	Project string `json:"project,omitempty"`
+
+	// Parsers defines custom regex-based line parsers, keyed by the name
+	// used with `agentlog ingest --parser <name>`
+	Parsers map[string]ParserConfig `json:"parsers,omitempty"`
+
+	// GitignoreAll has init and `doctor --fix` ignore all of .agentlog/
+	// in .gitignore, not just the log
+	GitignoreAll bool `json:"gitignore_all,omitempty"`
+
+	// Fsync has agentlog's own writers (serve, ingest, log) fsync
+	// errors.jsonl after every append, so a crash or power loss can't drop
+	// entries that were acknowledged. AGENTLOG_FSYNC=1 does the same, and
+	// the server snippets init generates honor it too.
+	Fsync bool `json:"fsync,omitempty"`
+
+	// MaxLineBytes is the longest errors.jsonl line readers accept.
+	// Longer lines (huge stack traces, embedded payloads) are skipped
+	// with a warning. Zero means 1MB.
+	MaxLineBytes int `json:"max_line_bytes,omitempty"`
+
+	// MaxEntries caps how many entries a command holds in memory. Past
+	// it the oldest are dropped, with a warning, so a huge log can't
+	// exhaust memory. --max-entries overrides it; zero means 500000.
+	MaxEntries int `json:"max_entries,omitempty"`
+
+	// Serve configures `agentlog serve`
+	Serve ServeConfig `json:"serve,omitempty"`
+
+	// Errors configures `agentlog errors`
+	Errors ErrorsConfig `json:"errors,omitempty"`
+
+	// Formatter is a shell command that renders human-readable output in
+	// place of the built-in one, e.g. "./scripts/format.sh". errors and
+	// tail write the entries to its stdin as NDJSON and print what it
+	// writes; their --no-formatter bypasses it.
+	Formatter string `json:"formatter,omitempty"`
+
+	// Alerts are threshold rules `agentlog tail` and `agentlog serve`
+	// check as entries arrive, e.g. more than 20 NETWORK_ERRORs in 10
+	// minutes. `agentlog doctor` reports invalid rules and recent alerts.
+	Alerts []AlertRule `json:"alerts,omitempty"`
+
+	// Sampling rules keep only some entries of high-volume error types as
+	// they're written, e.g. 1 in 50 NETWORK_ERRORs after the first 10 an
+	// hour. Kept entries carry the count of those they stand for.
+	Sampling []SamplingRule `json:"sampling,omitempty"`
+
+	// Storms sets what counts as an error storm: Threshold occurrences of
+	// one error within Window. serve writes an ERROR_STORM entry when one
+	// starts; prime and doctor call them out.
+	Storms StormConfig `json:"storms,omitempty"`
+
+	// Redact configures the masking of secrets and personal data in
+	// entries, which is on unless disabled here
+	Redact RedactConfig `json:"redact,omitempty"`
+
+	// Context limits which context keys entries keep, e.g. dropping
+	// request headers and bodies
+	Context ContextConfig `json:"context,omitempty"`
+
+	// TrackEdits records which files git reports as changed when entries
+	// are written (and every few seconds under `agentlog serve` and
+	// `agentlog tail`), so prime can name the edits new errors followed
+	TrackEdits bool `json:"track_edits,omitempty"`
+
+	// Suggestions are rules for the next steps errors --full and prime
+	// suggest, checked before the built-in ones
+	Suggestions []SuggestionRule `json:"suggestions,omitempty"`
+
+	// Analyze configures the model `agentlog analyze` sends error groups
+	// to. The command is off until Provider is set.
+	Analyze AnalyzeConfig `json:"analyze,omitempty"`
+
+	// Embeddings has `agentlog similar` compare errors by embedding
+	// vectors from an OpenAI-compatible endpoint instead of shared words.
+	// It's off until Model is set.
+	Embeddings EmbeddingsConfig `json:"embeddings,omitempty"`
+
+	// Init records the choices made in `agentlog init --interactive` or
+	// installed by `agentlog init --install`
+	Init *InitConfig `json:"init,omitempty"`
+}
+
+// InitConfig is what init was told. The next interactive run offers these
+// as its defaults, and `agentlog upgrade` regenerates installed files
+// from them.
+type InitConfig struct {
+	Stacks       []string `json:"stacks"`
+	Install      bool     `json:"install"`
+	Capture      []string `json:"capture,omitempty"` // browser captures: "console", "network"
+	Integrations []string `json:"integrations,omitempty"`
+	Transport    string   `json:"transport,omitempty"` // "file" or "serve"
+	Remote       string   `json:"remote,omitempty"`    // serve URL every snippet posts to (init --remote)
+}
+
+// ErrorsConfig configures the errors command's default output
+type ErrorsConfig struct {
+	// Template is a Go text/template rendered once per entry in place of
+	// the default human-readable output, e.g. "{{.ErrorType}}: {{.Message}}".
+	// The --template flag overrides it; --json and --fields ignore it.
+	Template string `json:"template,omitempty"`
+}
+
+// AlertRule fires when more than Threshold entries matching Query arrive
+// within Window. It fires at most once per Window.
+type AlertRule struct {
+	Name      string `json:"name"`
+	Query     string `json:"query,omitempty"`   // an errors --query expression; empty matches every entry
+	Threshold int    `json:"threshold"`         // fires above this many entries
+	Window    string `json:"window"`            // e.g. "10m", "1h"
+	Webhook   string `json:"webhook,omitempty"` // URL the alert is POSTed to as JSON
+	Command   string `json:"command,omitempty"` // shell command given the alert as JSON on stdin
+}
+
+// SamplingRule writes the first KeepFirst entries of ErrorType each Per,
+// then one in KeepOneIn, as an entry counting the ones it stands for
+type SamplingRule struct {
+	ErrorType string `json:"error_type"`
+	KeepFirst int    `json:"keep_first,omitempty"`
+	Per       string `json:"per,omitempty"` // e.g. "1h" (the default), "10m"
+	KeepOneIn int    `json:"keep_one_in"`
+}
+
+// StormConfig is the burst that makes an error storm. Zero values mean
+// the defaults, 50 within 1m.
+type StormConfig struct {
+	Threshold int    `json:"threshold,omitempty"`
+	Window    string `json:"window,omitempty"` // e.g. "30s", "1m"
+}
+
+// SuggestionRule suggests Hint for errors whose message matches the
+// regular expression Match, of one of Types when given
+type SuggestionRule struct {
+	Name  string   `json:"name,omitempty"`
+	Types []string `json:"types,omitempty"`
+	Match string   `json:"match"`
+	Hint  string   `json:"hint"`
+}
+
+// AnalyzeConfig names the model endpoint `agentlog analyze` uses
+type AnalyzeConfig struct {
+	// Provider is "openai", "anthropic", or "local" (an OpenAI-compatible
+	// server such as Ollama or llama.cpp)
+	Provider string `json:"provider,omitempty"`
+
+	// Model is the model name; openai and anthropic have defaults
+	Model string `json:"model,omitempty"`
+
+	// Endpoint overrides the provider's URL, e.g. a proxy or another
+	// port for a local server
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// APIKeyEnv names the environment variable holding the API key.
+	// Empty means OPENAI_API_KEY or ANTHROPIC_API_KEY for those providers.
+	APIKeyEnv string `json:"api_key_env,omitempty"`
+
+	// APIKey is the key itself, used when the variable isn't set. Keep
+	// config.json out of git if you set it.
+	APIKey string `json:"api_key,omitempty"`
+}
+
+// EmbeddingsConfig names the embeddings endpoint `agentlog similar` uses
+type EmbeddingsConfig struct {
+	// Model is the embedding model, e.g. "nomic-embed-text"
+	Model string `json:"model,omitempty"`
+
+	// Endpoint is an OpenAI-compatible embeddings URL. Empty means a
+	// local Ollama server, http://localhost:11434/v1/embeddings.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// APIKeyEnv names the environment variable holding an API key, for
+	// endpoints that need one
+	APIKeyEnv string `json:"api_key_env,omitempty"`
+}
+
+// RedactConfig configures redaction. Bearer tokens, API keys, emails, and
+// card numbers are masked when entries are written (serve, ingest, the
+// Node capture) and when they're read (errors, prime, tail, share).
+type RedactConfig struct {
+	// Disabled turns redaction off
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Patterns are extra regular expressions to mask, e.g. internal
+	// customer IDs
+	Patterns []string `json:"patterns,omitempty"`
+}
+
+// ContextConfig limits the context keys entries keep. It's enforced when
+// entries are written (serve, ingest, the Node capture), so excluded data
+// never reaches errors.jsonl, and when they're read, for entries written
+// by snippets that don't enforce it. Keys match case-insensitively.
+type ContextConfig struct {
+	// Allow lists the top-level keys kept; empty keeps every key
+	Allow []string `json:"allow,omitempty"`
+
+	// Deny lists the keys dropped, at any depth ("headers"), or dotted
+	// paths from the top ("request.body")
+	Deny []string `json:"deny,omitempty"`
+}
+
+// ServeConfig configures the HTTP ingestion server
+type ServeConfig struct {
+	// AllowedOrigins lists origins allowed to POST entries cross-origin.
+	// Entries may end in ":*" to match any port; "*" allows every origin.
+	// Empty means localhost on any port.
+	AllowedOrigins []string `json:"allowed_origins,omitempty"`
+
+	// Token, when set, is required as a bearer token on every request.
+	// `agentlog init` generates one and injects it into browser snippets.
+	Token string `json:"token,omitempty"`
+
+	// RepeatWindow is how long after an entry is written identical ones
+	// are held back and collapsed into one entry with a count, e.g. "10s".
+	// Empty means 5s; "0" writes every entry.
+	RepeatWindow string `json:"repeat_window,omitempty"`
+}
+
+// ParserConfig defines a regex-based line parser.
+//
+// Pattern is matched against each input line. Named groups map to entry
+// fields: "timestamp", "type" (error_type), "message", "file", "line",
+// "column", and "endpoint". Any other named group is stored in the entry's
+// context under the group name. Lines that
+// don't match are skipped.
+type ParserConfig struct {
+	Pattern    string `json:"pattern"`
+	ErrorType  string `json:"error_type,omitempty"`  // used when the pattern has no "type" group
+	Source     string `json:"source,omitempty"`      // overrides the ingest --source value
+	TimeFormat string `json:"time_format,omitempty"` // Go time layout for the "timestamp" group
+}
+
+// Path returns the config file path for a given base directory
+func Path(baseDir string) string {
+	return filepath.Join(baseDir, ".agentlog", FileName)
+}
+
+// Load reads .agentlog/config.json from baseDir. If the file does not
+// exist, an empty config is returned.
+func Load(baseDir string) (*Config, error) {
+	data, err := os.ReadFile(Path(baseDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Config{}, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", FileName, err)
+	}
+
+	return Decode(data)
+}
+
+// Decode parses config file contents
+func Decode(data []byte) (*Config, error) {
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", FileName, err)
+	}
+
+	return &cfg, nil
+}
+
+// Save writes cfg to .agentlog/config.json under baseDir, creating the
+// directory if needed
+func Save(baseDir string, cfg *Config) error {
+	if err := os.MkdirAll(filepath.Dir(Path(baseDir)), 0755); err != nil {
+		return fmt.Errorf("failed to create .agentlog directory: %w", err)
+	}
+
+	data, err := Encode(cfg)
+	if err != nil {
+		return err
+	}
+	if err := logfile.WriteFile(Path(baseDir), data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", FileName, err)
+	}
+	return nil
+}
+
+// Encode returns cfg as Save writes it
+func Encode(cfg *Config) ([]byte, error) {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", FileName, err)
+	}
+	return append(data, '\n'), nil
+}
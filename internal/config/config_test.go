@@ -0,0 +1,137 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad_MissingFile(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	cfg, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v, want nil for missing file", err)
+	}
+	if cfg == nil {
+		t.Fatal("Load() should return an empty config, got nil")
+	}
+	if len(cfg.Parsers) != 0 {
+		t.Errorf("expected no parsers, got %d", len(cfg.Parsers))
+	}
+}
+
+func TestLoad_Parsers(t *testing.T) {
+	tmpDir := t.TempDir()
+	agentlogDir := filepath.Join(tmpDir, ".agentlog")
+	os.MkdirAll(agentlogDir, 0755)
+	os.WriteFile(filepath.Join(agentlogDir, "config.json"), []byte(`{
+  "parsers": {
+    "nginx": {
+      "pattern": "^(?P<timestamp>\\S+ \\S+) \\[error\\] (?P<message>.*)$",
+      "error_type": "REQUEST_ERROR",
+      "time_format": "2006/01/02 15:04:05"
+    }
+  }
+}`), 0644)
+
+	cfg, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	p, ok := cfg.Parsers["nginx"]
+	if !ok {
+		t.Fatal("expected nginx parser to be loaded")
+	}
+	if p.ErrorType != "REQUEST_ERROR" {
+		t.Errorf("ErrorType = %q, want REQUEST_ERROR", p.ErrorType)
+	}
+	if !strings.Contains(p.Pattern, "(?P<message>") {
+		t.Errorf("Pattern not loaded correctly: %q", p.Pattern)
+	}
+	if p.TimeFormat != "2006/01/02 15:04:05" {
+		t.Errorf("TimeFormat = %q", p.TimeFormat)
+	}
+}
+
+func TestLoad_Serve(t *testing.T) {
+	tmpDir := t.TempDir()
+	agentlogDir := filepath.Join(tmpDir, ".agentlog")
+	os.MkdirAll(agentlogDir, 0755)
+	os.WriteFile(filepath.Join(agentlogDir, "config.json"), []byte(`{
+  "serve": {"allowed_origins": ["http://localhost:5173", "https://app.test"]}
+}`), 0644)
+
+	cfg, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.Serve.AllowedOrigins) != 2 || cfg.Serve.AllowedOrigins[1] != "https://app.test" {
+		t.Errorf("AllowedOrigins = %v", cfg.Serve.AllowedOrigins)
+	}
+}
+
+func TestLoad_ProjectAndErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	agentlogDir := filepath.Join(tmpDir, ".agentlog")
+	os.MkdirAll(agentlogDir, 0755)
+	os.WriteFile(filepath.Join(agentlogDir, "config.json"), []byte(`{"project": "billing-api", "errors": {"template": "{{.Message}}"}}`), 0644)
+
+	cfg, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Project != "billing-api" {
+		t.Errorf("Project = %q, want billing-api", cfg.Project)
+	}
+	if cfg.Errors.Template != "{{.Message}}" {
+		t.Errorf("Errors.Template = %q", cfg.Errors.Template)
+	}
+}
+
+func TestLoad_InvalidJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	agentlogDir := filepath.Join(tmpDir, ".agentlog")
+	os.MkdirAll(agentlogDir, 0755)
+	os.WriteFile(filepath.Join(agentlogDir, "config.json"), []byte(`{not json`), 0644)
+
+	if _, err := Load(tmpDir); err == nil {
+		t.Error("Load() should fail on invalid JSON")
+	}
+}
+
+func TestSave_RoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	cfg := &Config{
+		Parsers: map[string]ParserConfig{"nginx": {Pattern: "(?P<message>.*)"}},
+		Serve:   ServeConfig{Token: "secret"},
+		Init:    &InitConfig{Stacks: []string{"ruby", "typescript"}, Install: true, Integrations: []string{"sidekiq"}, Transport: "serve"},
+	}
+	if err := Save(tmpDir, cfg); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Serve.Token != "secret" {
+		t.Errorf("Token = %q, want secret", loaded.Serve.Token)
+	}
+	if loaded.Parsers["nginx"].Pattern != "(?P<message>.*)" {
+		t.Errorf("parsers not preserved: %+v", loaded.Parsers)
+	}
+	if loaded.Init == nil || strings.Join(loaded.Init.Stacks, ",") != "ruby,typescript" || !loaded.Init.Install || loaded.Init.Transport != "serve" {
+		t.Errorf("init choices not preserved: %+v", loaded.Init)
+	}
+}
+
+func TestPath(t *testing.T) {
+	got := Path("/project")
+	if got != "/project/.agentlog/config.json" {
+		t.Errorf("Path() = %q", got)
+	}
+}
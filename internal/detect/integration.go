@@ -0,0 +1,229 @@
+package detect
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Integration is a framework that routes errors to its own handler, so it
+// needs a hook of its own alongside the stack's capture snippet
+type Integration string
+
+const (
+	Vue       Integration = "vue"
+	SvelteKit Integration = "sveltekit"
+	Angular   Integration = "angular"
+	Celery    Integration = "celery"
+	Sidekiq   Integration = "sidekiq"
+	RQ        Integration = "rq"
+	BullMQ    Integration = "bullmq"
+	Apollo    Integration = "apollo"
+	Yoga      Integration = "graphql-yoga"
+	Gqlgen    Integration = "gqlgen"
+	GRPCGo    Integration = "grpc-go"
+	GRPCNode  Integration = "grpc-node"
+	Electron  Integration = "electron"
+	Workers   Integration = "cloudflare-workers"
+	Tauri     Integration = "tauri"
+	Lambda    Integration = "lambda"
+)
+
+// String returns the string representation of the integration
+func (i Integration) String() string {
+	return string(i)
+}
+
+// integrationMarkers lists what identifies each integration: any of the
+// package.json dependencies, gems, Python packages, or Go modules, and all
+// of the files. An integration may have several rows; any one matching is
+// enough.
+var integrationMarkers = []struct {
+	integration Integration
+	deps        []string
+	gems        []string
+	pyPackages  []string
+	goModules   []string
+	files       []string
+}{
+	{integration: Vue, deps: []string{"vue"}},
+	{integration: SvelteKit, files: []string{"svelte.config.js", "src/routes"}},
+	{integration: Angular, deps: []string{"@angular/core"}},
+	{integration: Celery, pyPackages: []string{"celery"}},
+	{integration: Sidekiq, gems: []string{"sidekiq"}},
+	{integration: RQ, pyPackages: []string{"rq"}},
+	{integration: BullMQ, deps: []string{"bullmq"}},
+	{integration: Apollo, deps: []string{"@apollo/server"}},
+	{integration: Yoga, deps: []string{"graphql-yoga"}},
+	{integration: Gqlgen, goModules: []string{"github.com/99designs/gqlgen"}},
+	{integration: GRPCGo, goModules: []string{"google.golang.org/grpc"}},
+	{integration: GRPCNode, deps: []string{"@grpc/grpc-js"}},
+	{integration: Electron, deps: []string{"electron"}},
+	{integration: Workers, files: []string{"wrangler.toml"}},
+	{integration: Tauri, files: []string{"src-tauri/tauri.conf.json"}},
+	{integration: Lambda, files: []string{"serverless.yml"}},
+	{integration: Lambda, files: []string{"template.yaml"}}, // AWS SAM
+}
+
+// DetectIntegrations returns the integrations used by the project in dir,
+// in a stable order
+func DetectIntegrations(dir string) []Integration {
+	deps := packageDeps(dir)
+	gems := gemfileGems(dir)
+	pyPackages := pythonPackages(dir)
+	goModules := goModuleRequires(dir)
+
+	var found []Integration
+	seen := make(map[Integration]bool)
+	for _, m := range integrationMarkers {
+		if seen[m.integration] {
+			continue
+		}
+		if len(m.deps) > 0 && !anyDep(deps, m.deps) {
+			continue
+		}
+		if len(m.gems) > 0 && !anyDep(gems, m.gems) {
+			continue
+		}
+		if len(m.pyPackages) > 0 && !anyDep(pyPackages, m.pyPackages) {
+			continue
+		}
+		if len(m.goModules) > 0 && !anyDep(goModules, m.goModules) {
+			continue
+		}
+		if !allFiles(dir, m.files) {
+			continue
+		}
+		seen[m.integration] = true
+		found = append(found, m.integration)
+	}
+	return found
+}
+
+// packageDeps returns the dependencies and devDependencies in dir's
+// package.json (none if it's missing or invalid)
+func packageDeps(dir string) map[string]bool {
+	deps := make(map[string]bool)
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return deps
+	}
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return deps
+	}
+	for dep := range pkg.Dependencies {
+		deps[dep] = true
+	}
+	for dep := range pkg.DevDependencies {
+		deps[dep] = true
+	}
+	return deps
+}
+
+// gemfileGems returns the gems named in dir's Gemfile
+func gemfileGems(dir string) map[string]bool {
+	gems := make(map[string]bool)
+	data, err := os.ReadFile(filepath.Join(dir, "Gemfile"))
+	if err != nil {
+		return gems
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "gem" {
+			gems[strings.Trim(fields[1], `"',`)] = true
+		}
+	}
+	return gems
+}
+
+// pythonPackages returns the packages listed in dir's requirements.txt and
+// pyproject.toml. pyproject.toml isn't parsed as TOML; quoted requirement
+// strings (PEP 621) and "name = version" lines (Poetry) are both read.
+func pythonPackages(dir string) map[string]bool {
+	packages := make(map[string]bool)
+	if data, err := os.ReadFile(filepath.Join(dir, "requirements.txt")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if name := requirementName(line); name != "" {
+				packages[name] = true
+			}
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "pyproject.toml")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, `"`) || strings.HasPrefix(line, "'") {
+				line = strings.Trim(line, `"',`)
+			} else if i := strings.Index(line, "="); i > 0 {
+				line = line[:i]
+			} else {
+				continue
+			}
+			if name := requirementName(line); name != "" {
+				packages[name] = true
+			}
+		}
+	}
+	return packages
+}
+
+// goModuleRequires returns the module paths required by dir's go.mod, in
+// either the single-line or the block form
+func goModuleRequires(dir string) map[string]bool {
+	modules := make(map[string]bool)
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return modules
+	}
+	inBlock := false
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case inBlock && fields[0] == ")":
+			inBlock = false
+		case inBlock:
+			modules[fields[0]] = true
+		case fields[0] == "require" && len(fields) >= 2 && fields[1] == "(":
+			inBlock = true
+		case fields[0] == "require" && len(fields) >= 2:
+			modules[fields[1]] = true
+		}
+	}
+	return modules
+}
+
+// requirementName returns the lowercased package name a requirement line
+// names, e.g. "celery" for "celery[redis]>=5.3"
+func requirementName(line string) string {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+		return ""
+	}
+	if i := strings.IndexAny(line, "[<>=~!; "); i >= 0 {
+		line = line[:i]
+	}
+	return strings.ToLower(line)
+}
+
+func anyDep(deps map[string]bool, names []string) bool {
+	for _, name := range names {
+		if deps[name] {
+			return true
+		}
+	}
+	return false
+}
+
+func allFiles(dir string, files []string) bool {
+	for _, file := range files {
+		if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+			return false
+		}
+	}
+	return true
+}
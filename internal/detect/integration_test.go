@@ -0,0 +1,161 @@
+package detect
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDetectIntegrations(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected []Integration
+	}{
+		{
+			name:     "no package.json",
+			files:    map[string]string{"go.mod": "module x"},
+			expected: nil,
+		},
+		{
+			name:     "vue dependency",
+			files:    map[string]string{"package.json": `{"dependencies": {"vue": "^3.4.0"}}`},
+			expected: []Integration{Vue},
+		},
+		{
+			name:     "vue dev dependency",
+			files:    map[string]string{"package.json": `{"devDependencies": {"vue": "^3.4.0"}}`},
+			expected: []Integration{Vue},
+		},
+		{
+			name: "sveltekit config and routes",
+			files: map[string]string{
+				"package.json":            `{"devDependencies": {"@sveltejs/kit": "^2.0.0"}}`,
+				"svelte.config.js":        "export default {}",
+				"src/routes/+page.svelte": "<h1>hi</h1>",
+			},
+			expected: []Integration{SvelteKit},
+		},
+		{
+			name:     "svelte config without routes",
+			files:    map[string]string{"svelte.config.js": "export default {}"},
+			expected: nil,
+		},
+		{
+			name:     "angular core",
+			files:    map[string]string{"package.json": `{"dependencies": {"@angular/core": "^17.0.0"}}`},
+			expected: []Integration{Angular},
+		},
+		{
+			name:     "celery in requirements.txt",
+			files:    map[string]string{"requirements.txt": "# workers\ncelery[redis]>=5.3\nflask==3.0\n"},
+			expected: []Integration{Celery},
+		},
+		{
+			name:     "rq in pyproject dependencies",
+			files:    map[string]string{"pyproject.toml": "[project]\ndependencies = [\n  \"rq>=1.15\",\n  \"requests\",\n]\n"},
+			expected: []Integration{RQ},
+		},
+		{
+			name:     "celery in poetry dependencies",
+			files:    map[string]string{"pyproject.toml": "[tool.poetry.dependencies]\npython = \"^3.11\"\ncelery = \"^5.3\"\n"},
+			expected: []Integration{Celery},
+		},
+		{
+			name:     "sidekiq gem",
+			files:    map[string]string{"Gemfile": "source 'https://rubygems.org'\ngem 'rails', '~> 7.1'\ngem \"sidekiq\", \"~> 7.0\"\n"},
+			expected: []Integration{Sidekiq},
+		},
+		{
+			name:     "similar names don't match",
+			files:    map[string]string{"requirements.txt": "rq-scheduler\ncelery-progress\n", "Gemfile": "gem 'sidekiq-cron'\n"},
+			expected: nil,
+		},
+		{
+			name:     "bullmq dependency",
+			files:    map[string]string{"package.json": `{"dependencies": {"bullmq": "^5.0.0", "express": "^4.0.0"}}`},
+			expected: []Integration{BullMQ},
+		},
+		{
+			name:     "apollo server",
+			files:    map[string]string{"package.json": `{"dependencies": {"@apollo/server": "^4.10.0", "graphql": "^16.8.0"}}`},
+			expected: []Integration{Apollo},
+		},
+		{
+			name:     "graphql yoga",
+			files:    map[string]string{"package.json": `{"dependencies": {"graphql-yoga": "^5.0.0"}}`},
+			expected: []Integration{Yoga},
+		},
+		{
+			name:     "gqlgen in require block",
+			files:    map[string]string{"go.mod": "module example.com/api\n\ngo 1.22\n\nrequire (\n\tgithub.com/99designs/gqlgen v0.17.45\n\tgithub.com/vektah/gqlparser/v2 v2.5.11\n)\n"},
+			expected: []Integration{Gqlgen},
+		},
+		{
+			name:     "gqlgen single require",
+			files:    map[string]string{"go.mod": "module example.com/api\n\nrequire github.com/99designs/gqlgen v0.17.45\n"},
+			expected: []Integration{Gqlgen},
+		},
+		{
+			name:     "grpc go",
+			files:    map[string]string{"go.mod": "module x\n\nrequire (\n\tgoogle.golang.org/grpc v1.62.0\n\tgoogle.golang.org/protobuf v1.33.0\n)\n"},
+			expected: []Integration{GRPCGo},
+		},
+		{
+			name:     "grpc node",
+			files:    map[string]string{"package.json": `{"dependencies": {"@grpc/grpc-js": "^1.10.0"}}`},
+			expected: []Integration{GRPCNode},
+		},
+		{
+			name:     "electron dev dependency",
+			files:    map[string]string{"package.json": `{"devDependencies": {"electron": "^30.0.0"}}`},
+			expected: []Integration{Electron},
+		},
+		{
+			name:     "cloudflare workers",
+			files:    map[string]string{"wrangler.toml": "name = \"api\"\nmain = \"src/index.ts\"\n"},
+			expected: []Integration{Workers},
+		},
+		{
+			name:     "tauri",
+			files:    map[string]string{"package.json": `{"devDependencies": {"@tauri-apps/cli": "^2.0.0"}}`, "src-tauri/tauri.conf.json": "{}"},
+			expected: []Integration{Tauri},
+		},
+		{
+			name:     "serverless framework",
+			files:    map[string]string{"serverless.yml": "service: api\n"},
+			expected: []Integration{Lambda},
+		},
+		{
+			name:     "sam template and serverless.yml counted once",
+			files:    map[string]string{"serverless.yml": "service: api\n", "template.yaml": "Transform: AWS::Serverless-2016-10-31\n"},
+			expected: []Integration{Lambda},
+		},
+		{
+			name:     "react only",
+			files:    map[string]string{"package.json": `{"dependencies": {"react": "^18.0.0"}}`},
+			expected: nil,
+		},
+		{
+			name:     "invalid package.json",
+			files:    map[string]string{"package.json": `{`},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(dir, name)
+				os.MkdirAll(filepath.Dir(path), 0755)
+				os.WriteFile(path, []byte(content), 0644)
+			}
+
+			if got := DetectIntegrations(dir); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("DetectIntegrations() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -17,6 +17,9 @@ const (
 	Python     Stack = "python"
 	Rust       Stack = "rust"
 	Ruby       Stack = "ruby"
+	// ReactNative is a package.json project depending on react-native;
+	// it runs on a device, not in a browser or Node
+	ReactNative Stack = "react-native"
 )
 
 // String returns the string representation of the stack
@@ -143,8 +146,14 @@ var nodeModuleSettings = []string{
 }
 
 // detectTypeScriptVariant determines if a TypeScript project is Node.js or browser
-// Returns TypeScript for browser projects, Node for server-side Node.js projects
+// Returns TypeScript for browser projects, Node for server-side Node.js projects,
+// and ReactNative for React Native apps
 func detectTypeScriptVariant(dir string) Stack {
+	// Priority 0: React Native apps also depend on react and have an App.tsx
+	if packageDeps(dir)["react-native"] {
+		return ReactNative
+	}
+
 	// Priority 1: Check for explicit browser indicators (files)
 	browserFiles := []string{
 		"vite.config.ts",
@@ -236,3 +245,30 @@ func detectTypeScriptVariant(dir string) Stack {
 	// Default: TypeScript (browser) - safer default for typical web projects
 	return TypeScript
 }
+
+// railsMarkers are files only found in Rails apps
+var railsMarkers = []string{
+	"config/routes.rb",
+	"config/application.rb",
+}
+
+// IsRails reports whether the Ruby project in dir is a Rails app rather
+// than a plain script, CLI, or gem
+func IsRails(dir string) bool {
+	for _, file := range railsMarkers {
+		if _, err := os.Stat(filepath.Join(dir, file)); err == nil {
+			return true
+		}
+	}
+	gemfile, err := os.ReadFile(filepath.Join(dir, "Gemfile"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(gemfile), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, `gem "rails"`) || strings.HasPrefix(line, `gem 'rails'`) {
+			return true
+		}
+	}
+	return false
+}
@@ -129,6 +129,7 @@ func TestStackString(t *testing.T) {
 		{Python, "python"},
 		{Rust, "rust"},
 		{Ruby, "ruby"},
+		{ReactNative, "react-native"},
 	}
 
 	for _, tc := range tests {
@@ -268,6 +269,16 @@ func TestDetectNodeVsBrowserTypeScript(t *testing.T) {
 		expectedStack  Stack
 		expectedDetect bool
 	}{
+		// React Native
+		{
+			name: "react-native dependency indicates React Native, not browser",
+			files: map[string]string{
+				"package.json": `{"dependencies": {"react": "18.2.0", "react-native": "0.74.0"}}`,
+				"src/App.tsx":  `export default function App() {}`,
+			},
+			expectedStack:  ReactNative,
+			expectedDetect: true,
+		},
 		// Browser indicators
 		{
 			name: "vite.config.ts indicates browser TypeScript",
@@ -488,3 +499,31 @@ func TestDetectNodeVsBrowserTypeScript(t *testing.T) {
 		})
 	}
 }
+
+func TestIsRails(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected bool
+	}{
+		{"routes.rb", map[string]string{"Gemfile": "", "config/routes.rb": ""}, true},
+		{"application.rb", map[string]string{"config/application.rb": ""}, true},
+		{"rails in Gemfile", map[string]string{"Gemfile": "source 'https://rubygems.org'\n  gem \"rails\", \"~> 7.1\"\n"}, true},
+		{"plain Gemfile", map[string]string{"Gemfile": "source 'https://rubygems.org'\ngem 'thor'\ngem 'rails-html-sanitizer'\n"}, false},
+		{"no Gemfile", map[string]string{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(dir, name)
+				os.MkdirAll(filepath.Dir(path), 0755)
+				os.WriteFile(path, []byte(content), 0644)
+			}
+			if got := IsRails(dir); got != tt.expected {
+				t.Errorf("IsRails() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Package diag writes agentlog's diagnostics about its own work to stderr:
+// warnings, status messages, and with --verbose, debug detail such as the
+// files read, offsets resumed from, and how long reads took. Command
+// output never goes through it, so --quiet and --verbose don't change what
+// a command prints on stdout.
+package diag
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Level is how much diag writes
+type Level int
+
+const (
+	// Quiet writes nothing; failures are still reported by the commands
+	Quiet Level = iota
+	// Normal writes warnings and status messages
+	Normal
+	// Verbose also writes debug detail
+	Verbose
+)
+
+var (
+	mu    sync.Mutex
+	level           = Normal
+	out   io.Writer = os.Stderr
+	color bool
+)
+
+// ANSI escapes for colored warnings and debug lines
+const (
+	ansiReset  = "\033[0m"
+	ansiDim    = "\033[2m"
+	ansiYellow = "\033[33m"
+)
+
+// SetLevel sets how much is written from now on
+func SetLevel(l Level) {
+	mu.Lock()
+	defer mu.Unlock()
+	level = l
+}
+
+// Enabled reports whether messages at l are written, for callers that
+// would otherwise compute one for nothing
+func Enabled(l Level) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return level >= l
+}
+
+// SetColor sets whether warnings are colored yellow and debug lines dim.
+// Commands turn it on when stderr is a terminal and color isn't disabled.
+func SetColor(on bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	color = on
+}
+
+// SetOutput redirects diagnostics to w and returns the previous writer
+func SetOutput(w io.Writer) io.Writer {
+	mu.Lock()
+	defer mu.Unlock()
+	prev := out
+	out = w
+	return prev
+}
+
+// Warnf writes a warning: something was skipped or may be wrong, but the
+// command carried on
+func Warnf(format string, a ...any) {
+	write(Normal, "Warning: ", ansiYellow, format, a)
+}
+
+// Infof writes a status message, such as what a long-running command is
+// watching
+func Infof(format string, a ...any) {
+	write(Normal, "", "", format, a)
+}
+
+// Debugf writes debug detail, shown only with --verbose
+func Debugf(format string, a ...any) {
+	write(Verbose, "debug: ", ansiDim, format, a)
+}
+
+func write(at Level, prefix, ansi, format string, a []any) {
+	mu.Lock()
+	defer mu.Unlock()
+	if level < at {
+		return
+	}
+	msg := prefix + strings.TrimSuffix(fmt.Sprintf(format, a...), "\n")
+	if color && ansi != "" {
+		msg = ansi + msg + ansiReset
+	}
+	io.WriteString(out, msg+"\n")
+}
@@ -0,0 +1,57 @@
+package diag
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLevels(t *testing.T) {
+	buf := new(bytes.Buffer)
+	prev := SetOutput(buf)
+	defer SetOutput(prev)
+	defer SetLevel(Normal)
+
+	tests := []struct {
+		level Level
+		want  string
+	}{
+		{Quiet, ""},
+		{Normal, "Warning: skipped 2 lines\nwatching app.log\n"},
+		{Verbose, "Warning: skipped 2 lines\nwatching app.log\ndebug: read 10 bytes\n"},
+	}
+	for _, tt := range tests {
+		buf.Reset()
+		SetLevel(tt.level)
+		Warnf("skipped %d lines", 2)
+		Infof("watching %s\n", "app.log")
+		Debugf("read %d bytes", 10)
+		if buf.String() != tt.want {
+			t.Errorf("level %d wrote %q, want %q", tt.level, buf.String(), tt.want)
+		}
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	defer SetLevel(Normal)
+	SetLevel(Normal)
+	if !Enabled(Normal) || Enabled(Verbose) {
+		t.Error("Normal should enable warnings but not debug detail")
+	}
+	SetLevel(Quiet)
+	if Enabled(Normal) {
+		t.Error("Quiet should disable warnings")
+	}
+}
+
+func TestColor(t *testing.T) {
+	buf := new(bytes.Buffer)
+	defer SetOutput(SetOutput(buf))
+	defer SetColor(false)
+
+	SetColor(true)
+	Warnf("careful")
+	Infof("plain")
+	if want := ansiYellow + "Warning: careful" + ansiReset + "\nplain\n"; buf.String() != want {
+		t.Errorf("wrote %q, want %q", buf.String(), want)
+	}
+}
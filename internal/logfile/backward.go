@@ -0,0 +1,118 @@
+package logfile
+
+import (
+	"bytes"
+	"io"
+)
+
+// backwardChunk is how much Backward reads at a time
+const backwardChunk = 64 * 1024
+
+// Backward reads lines from the end of a file to its start, newest first,
+// a chunk at a time. Finding the latest entries of a log hundreds of MB
+// long then costs only the chunks they're in. Lines longer than the limit
+// are skipped and recorded in Skipped, as with Lines, though without line
+// numbers: those aren't known counting from the end.
+type Backward struct {
+	r     io.ReaderAt
+	size  int64
+	pos   int64  // file offset where tail starts
+	tail  []byte // unread bytes before the line last returned, without its newline
+	max   int
+	line  []byte
+	drop  int64 // bytes of an oversized line already discarded
+	begun bool
+	done  bool
+	err   error
+
+	// Skipped lists the oversized lines passed over so far
+	Skipped []SkippedLine
+}
+
+// NewBackward reads the lines of the first size bytes of r from the last
+// one back, skipping any longer than max bytes. A max of zero or less
+// means DefaultMaxLine.
+func NewBackward(r io.ReaderAt, size int64, max int) *Backward {
+	if max <= 0 {
+		max = DefaultMaxLine
+	}
+	return &Backward{r: r, size: size, pos: size, max: max}
+}
+
+// Scan moves to the line before the current one, reporting false once the
+// start of the file is passed or on a read error
+func (b *Backward) Scan() bool {
+	if !b.begun {
+		b.begun = true
+		if b.size == 0 {
+			return false
+		}
+		// The file's final newline ends the last line; it doesn't start an
+		// empty one
+		if last, err := b.read(b.size-1, 1); err != nil {
+			b.err = err
+			return false
+		} else if last[0] == '\n' {
+			b.pos--
+		}
+	}
+
+	for !b.done && b.err == nil {
+		if i := bytes.LastIndexByte(b.tail, '\n'); i >= 0 || b.pos == 0 {
+			line := b.tail[i+1:]
+			if i >= 0 {
+				b.tail = b.tail[:i]
+			} else {
+				b.tail, b.done = nil, true
+			}
+			if b.drop > 0 || len(line) > b.max {
+				b.Skipped = append(b.Skipped, SkippedLine{Size: b.drop + int64(len(line)) + 1})
+				b.drop = 0
+				continue
+			}
+			b.line = dropNewline(line)
+			return true
+		}
+
+		// No line start in what's been read: read the chunk before it,
+		// discarding a line that's already over the limit
+		if len(b.tail) > b.max {
+			b.drop += int64(len(b.tail))
+			b.tail = b.tail[:0]
+		}
+		n := int64(backwardChunk)
+		if n > b.pos {
+			n = b.pos
+		}
+		chunk, err := b.read(b.pos-n, int(n))
+		if err != nil {
+			b.err = err
+			return false
+		}
+		b.pos -= n
+		b.tail = append(chunk, b.tail...)
+	}
+	return false
+}
+
+func (b *Backward) read(off int64, n int) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := b.r.ReadAt(buf, off); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// Bytes is the current line without its line ending. It's only valid
+// until the next Scan.
+func (b *Backward) Bytes() []byte { return b.line }
+
+// Text is the current line without its line ending
+func (b *Backward) Text() string { return string(b.line) }
+
+// Remaining is how many bytes before the current line are still unread,
+// for reporting progress
+func (b *Backward) Remaining() int64 { return b.pos + int64(len(b.tail)) }
+
+// Err is the first read error, if any. Oversized lines aren't errors.
+func (b *Backward) Err() error { return b.err }
@@ -0,0 +1,61 @@
+package logfile
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func backwardLines(input string, max int) ([]string, *Backward) {
+	b := NewBackward(strings.NewReader(input), int64(len(input)), max)
+	var got []string
+	for b.Scan() {
+		got = append(got, b.Text())
+	}
+	return got, b
+}
+
+func TestBackward_NewestFirst(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", ""},
+		{"a\n", "a"},
+		{"a\nb\nc\n", "c|b|a"},
+		{"a\nb\nc", "c|b|a"},   // unterminated last line
+		{"a\n\nb\r\n", "b||a"}, // blank line kept, CR dropped
+		{"\n", ""},
+	}
+	for _, tt := range tests {
+		got, b := backwardLines(tt.input, 0)
+		if strings.Join(got, "|") != tt.want || b.Err() != nil {
+			t.Errorf("lines of %q = %q (%v), want %q", tt.input, got, b.Err(), tt.want)
+		}
+	}
+}
+
+func TestBackward_AcrossChunks(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&sb, `{"i":%d}`+"\n", i)
+	}
+	got, b := backwardLines(sb.String(), 0)
+	if len(got) != 20000 || got[0] != `{"i":19999}` || got[19999] != `{"i":0}` {
+		t.Fatalf("got %d lines, first %q, last %q", len(got), got[0], got[len(got)-1])
+	}
+	if b.Remaining() != 0 {
+		t.Errorf("Remaining = %d after the whole file", b.Remaining())
+	}
+}
+
+func TestBackward_SkipsOversizedLines(t *testing.T) {
+	long := strings.Repeat("x", 3*backwardChunk)
+	got, b := backwardLines("first\n"+long+"\nlast\n", 1000)
+	if strings.Join(got, "|") != "last|first" {
+		t.Errorf("lines = %q", got)
+	}
+	if len(b.Skipped) != 1 || b.Skipped[0].Size != int64(len(long)+1) {
+		t.Errorf("Skipped = %+v", b.Skipped)
+	}
+}
@@ -0,0 +1,121 @@
+package logfile
+
+import (
+	"bufio"
+	"io"
+)
+
+// DefaultMaxLine is the longest line, in bytes, readers accept unless the
+// project configures another limit. bufio.Scanner's 64KB default is easily
+// passed by a stack trace or an embedded payload.
+const DefaultMaxLine = 1 << 20
+
+// SkippedLine is a line a Lines reader passed over for being too long
+type SkippedLine struct {
+	Line int   // 1-based line number
+	Size int64 // bytes, newline included
+}
+
+// Lines reads newline-terminated lines like bufio.Scanner, except that a
+// line longer than the limit is skipped and recorded in Skipped rather
+// than ending the scan with an error. Memory use stays bounded by the
+// limit however long a line is.
+type Lines struct {
+	r      *bufio.Reader
+	max    int
+	line   []byte
+	num    int
+	offset int64
+	ended  bool
+	err    error
+
+	// Skipped lists the oversized lines passed over so far
+	Skipped []SkippedLine
+}
+
+// NewLines reads lines from r, skipping any longer than max bytes. A max
+// of zero or less means DefaultMaxLine.
+func NewLines(r io.Reader, max int) *Lines {
+	if max <= 0 {
+		max = DefaultMaxLine
+	}
+	return &Lines{r: bufio.NewReader(r), max: max}
+}
+
+// Scan advances to the next line that fits the limit, reporting false at
+// the end of the input or on a read error
+func (l *Lines) Scan() bool {
+	for l.err == nil {
+		l.line = l.line[:0]
+		var size int64
+		var err error
+		var last byte
+		for {
+			var chunk []byte
+			chunk, err = l.r.ReadSlice('\n')
+			size += int64(len(chunk))
+			if len(chunk) > 0 {
+				last = chunk[len(chunk)-1]
+			}
+			if len(l.line) <= l.max { // past the limit, only count what's left of the line
+				l.line = append(l.line, chunk...)
+			}
+			if err != bufio.ErrBufferFull {
+				break
+			}
+		}
+		if err != nil && err != io.EOF {
+			l.err = err
+			return false
+		}
+		if size == 0 {
+			return false // EOF
+		}
+		l.offset += size
+		l.num++
+		l.ended = last == '\n'
+
+		length := size
+		if last == '\n' {
+			length-- // the newline isn't part of the line
+		}
+		if length > int64(l.max) {
+			l.Skipped = append(l.Skipped, SkippedLine{Line: l.num, Size: size})
+			continue
+		}
+		l.line = dropNewline(l.line)
+		return true
+	}
+	return false
+}
+
+func dropNewline(line []byte) []byte {
+	if n := len(line); n > 0 && line[n-1] == '\n' {
+		line = line[:n-1]
+	}
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		line = line[:n-1]
+	}
+	return line
+}
+
+// Bytes is the current line without its line ending. It's only valid
+// until the next Scan.
+func (l *Lines) Bytes() []byte { return l.line }
+
+// Text is the current line without its line ending
+func (l *Lines) Text() string { return string(l.line) }
+
+// Line is the current line's 1-based number, counting skipped lines
+func (l *Lines) Line() int { return l.num }
+
+// Offset is how many bytes of the input have been consumed: through the
+// end of the current line
+func (l *Lines) Offset() int64 { return l.offset }
+
+// Complete reports whether the current line ended in a newline, rather
+// than at the end of the input, where a writer may still be adding to it
+func (l *Lines) Complete() bool { return l.ended }
+
+// Err is the first read error, if any. Oversized lines aren't errors.
+func (l *Lines) Err() error { return l.err }
@@ -0,0 +1,61 @@
+package logfile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLines_SkipsOversizedLines(t *testing.T) {
+	long := strings.Repeat("x", 200*1024) // past bufio.Scanner's 64KB default
+	input := "{\"a\":1}\n" + long + "\n{\"a\":2}\r\n\n" + strings.Repeat("y", 600) + "\n{\"a\":3}"
+
+	lines := NewLines(strings.NewReader(input), 512)
+	var got []string
+	var nums []int
+	var complete []bool
+	for lines.Scan() {
+		got = append(got, lines.Text())
+		nums = append(nums, lines.Line())
+		complete = append(complete, lines.Complete())
+	}
+	if err := lines.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, "|") != `{"a":1}|{"a":2}||{"a":3}` {
+		t.Errorf("lines = %q", got)
+	}
+	if nums[len(nums)-1] != 6 {
+		t.Errorf("line numbers should count skipped lines, got %v", nums)
+	}
+	if !complete[2] || complete[3] {
+		t.Errorf("only the last line should be incomplete, got %v", complete)
+	}
+	want := []SkippedLine{{Line: 2, Size: int64(len(long) + 1)}, {Line: 5, Size: 601}}
+	if len(lines.Skipped) != 2 || lines.Skipped[0] != want[0] || lines.Skipped[1] != want[1] {
+		t.Errorf("Skipped = %+v, want %+v", lines.Skipped, want)
+	}
+	if lines.Offset() != int64(len(input)) {
+		t.Errorf("Offset = %d, want %d", lines.Offset(), len(input))
+	}
+}
+
+func TestLines_LimitIsInclusive(t *testing.T) {
+	lines := NewLines(strings.NewReader("abcd\nabcde"), 4)
+	if !lines.Scan() || lines.Text() != "abcd" {
+		t.Fatalf("a line of exactly the limit should be read, got %q", lines.Text())
+	}
+	if lines.Scan() {
+		t.Errorf("an unterminated last line over the limit should be skipped, got %q", lines.Text())
+	}
+	if len(lines.Skipped) != 1 || lines.Skipped[0].Line != 2 {
+		t.Errorf("Skipped = %+v", lines.Skipped)
+	}
+}
+
+func TestLines_DefaultLimit(t *testing.T) {
+	long := strings.Repeat("z", 100*1024)
+	lines := NewLines(strings.NewReader(long+"\n"), 0)
+	if !lines.Scan() || len(lines.Bytes()) != len(long) {
+		t.Errorf("a 100KB line is within the default limit")
+	}
+}
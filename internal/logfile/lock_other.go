@@ -0,0 +1,12 @@
+//go:build !unix
+
+package logfile
+
+import "os"
+
+// Without flock, appends rely on O_APPEND and writing each batch in one
+// call, which keeps small lines whole on local filesystems
+
+func lock(f *os.File) error { return nil }
+
+func unlock(f *os.File) {}
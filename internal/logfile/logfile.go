@@ -0,0 +1,201 @@
+// Package logfile appends to errors.jsonl so that entries from several
+// processes (app snippets, workers, agentlog serve, the CLI logging its
+// own errors) never interleave into torn lines, rewrites files atomically
+// so a crash never leaves one truncated, and reads lines back without
+// giving up on ones too long for bufio.Scanner.
+package logfile
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Append writes data, one or more newline-terminated lines, to the end of
+// path, creating the file if needed. The write happens in one call under
+// an exclusive advisory lock, so other agentlog writers never see half of
+// it. If the file doesn't end in a newline (a writer that doesn't lock
+// died mid-line), a newline is written first so data starts a line of its
+// own.
+func Append(path string, data []byte) error {
+	return appendLines(path, data, false)
+}
+
+// AppendSync is Append, then an fsync, so the lines survive a crash or
+// power loss once it returns
+func AppendSync(path string, data []byte) error {
+	return appendLines(path, data, true)
+}
+
+func appendLines(path string, data []byte, sync bool) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if data[len(data)-1] != '\n' {
+		data = append(data, '\n')
+	}
+
+	f, err := openLocked(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	defer unlock(f)
+
+	if torn, err := endsMidLine(f); err != nil {
+		return err
+	} else if torn {
+		data = append([]byte{'\n'}, data...)
+	}
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	if sync {
+		return f.Sync()
+	}
+	return nil
+}
+
+// openLocked opens path for appending and locks it. A rewrite (Replace)
+// may rename a new file over path while this waits for the lock; then the
+// file it locked is no longer the log, so it opens path again.
+func openLocked(path string) (*os.File, error) {
+	for {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
+		if err != nil {
+			return nil, err
+		}
+		if err := lock(f); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		opened, err := f.Stat()
+		if err != nil {
+			unlock(f)
+			f.Close()
+			return nil, err
+		}
+		if current, err := os.Stat(path); err == nil && os.SameFile(opened, current) {
+			return f, nil
+		}
+		unlock(f)
+		f.Close()
+	}
+}
+
+// Lock takes the exclusive lock appends take on path, creating the file if
+// needed, and returns the function that releases it. It serializes a
+// read, change, and WriteFile of a small state file across processes: a
+// writer waiting for the lock while another renames a new file over path
+// locks the new one.
+func Lock(path string) (release func(), err error) {
+	f, err := openLocked(path)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		unlock(f)
+		f.Close()
+	}, nil
+}
+
+// Replace atomically replaces the log at path with data, followed by
+// whatever was appended to path beyond offset since the caller read it.
+// The new file is written and fsynced beside path and renamed over it
+// while holding the log's lock, so a crash leaves either the old file or
+// the new one, never a truncated mix, and no locked append is lost.
+func Replace(path string, data []byte, offset int64) error {
+	current, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer current.Close()
+	if err := lock(current); err != nil {
+		return fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	defer unlock(current)
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".errors-*.jsonl")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if _, err := current.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, current); err != nil {
+		return fmt.Errorf("failed to copy new entries: %w", err)
+	}
+	return commit(tmp, path, 0644)
+}
+
+// WriteFile atomically replaces path with data: it's written and fsynced
+// to a temp file beside path, then renamed over it. An existing file keeps
+// its permissions; a symlink keeps pointing at the file that's replaced.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := tmp.Write(data); err != nil {
+		return err
+	}
+	return commit(tmp, path, perm)
+}
+
+// commit fsyncs and closes tmp, then renames it over path and fsyncs the
+// directory so the rename itself is durable
+func commit(tmp *os.File, path string, perm os.FileMode) error {
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// syncDir fsyncs a directory, where the platform allows it
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// endsMidLine reports whether f is non-empty and its last byte isn't a
+// newline
+func endsMidLine(f *os.File) (bool, error) {
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return false, err
+	}
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, info.Size()-1); err != nil && err != io.EOF {
+		return false, err
+	}
+	return last[0] != '\n', nil
+}
@@ -0,0 +1,193 @@
+package logfile
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAppend_ConcurrentWritersKeepLinesWhole(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "errors.jsonl")
+	padding := strings.Repeat("x", 3000) // long enough that unlocked writes could interleave
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				line := fmt.Sprintf(`{"writer":%d,"i":%d,"message":%q}`, w, i, padding)
+				if err := Append(path, []byte(line)); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024)
+	lines := 0
+	for scanner.Scan() {
+		lines++
+		var v map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &v); err != nil {
+			t.Fatalf("line %d is torn: %v", lines, err)
+		}
+	}
+	if lines != 400 {
+		t.Errorf("expected 400 lines, got %d", lines)
+	}
+}
+
+func TestAppend_StartsAfterTornLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "errors.jsonl")
+	os.WriteFile(path, []byte(`{"ok":1}`+"\n"+`{"half":`), 0644)
+
+	if err := Append(path, []byte(`{"ok":2}`)); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	want := `{"ok":1}` + "\n" + `{"half":` + "\n" + `{"ok":2}` + "\n"
+	if string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+}
+
+func TestAppend_CreatesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "errors.jsonl")
+	if err := Append(path, []byte("{}\n{}\n")); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "{}\n{}\n" {
+		t.Errorf("got %q", data)
+	}
+}
+
+func TestReplace_KeepsAppendsDuringRewrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "errors.jsonl")
+	os.WriteFile(path, []byte("{\"old\":1}\n{\"old\":2}\n"), 0644)
+	read := int64(len("{\"old\":1}\n{\"old\":2}\n"))
+
+	// Appended after the rewriter read the file
+	if err := Append(path, []byte(`{"new":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Replace(path, []byte("{\"compacted\":1}\n"), read); err != nil {
+		t.Fatal(err)
+	}
+	// Lands in the new file, not the one renamed away
+	if err := AppendSync(path, []byte(`{"new":2}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(path)
+	want := "{\"compacted\":1}\n{\"new\":1}\n{\"new\":2}\n"
+	if string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".errors-*")); len(leftovers) != 0 {
+		t.Errorf("temp files left behind: %v", leftovers)
+	}
+}
+
+func TestReplace_ConcurrentAppendsNotLost(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "errors.jsonl")
+	os.WriteFile(path, nil, 0644)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if err := Append(path, []byte(fmt.Sprintf(`{"writer":%d,"i":%d}`, w, i))); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(w)
+	}
+	// Rewrite the file unchanged while the writers run
+	for i := 0; i < 20; i++ {
+		data, _ := os.ReadFile(path)
+		data = data[:strings.LastIndexByte(string(data), '\n')+1]
+		if err := Replace(path, data, int64(len(data))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+
+	data, _ := os.ReadFile(path)
+	if lines := strings.Count(string(data), "\n"); lines != 200 {
+		t.Errorf("expected 200 lines after rewrites, got %d", lines)
+	}
+}
+
+func TestLock_SerializesReadModifyWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				release, err := Lock(path)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				var n int
+				if data, _ := os.ReadFile(path); len(data) > 0 {
+					json.Unmarshal(data, &n)
+				}
+				data, _ := json.Marshal(n + 1)
+				if err := WriteFile(path, data, 0644); err != nil {
+					t.Error(err)
+				}
+				release()
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "200" {
+		t.Errorf("expected 200 increments, got %s", data)
+	}
+}
+
+func TestWriteFile_KeepsModeAndSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "run.sh")
+	os.WriteFile(target, []byte("old"), 0755)
+	link := filepath.Join(dir, "link.sh")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	if err := WriteFile(link, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("link should stay a symlink, got %v (%v)", info, err)
+	}
+	info, _ := os.Stat(target)
+	data, _ := os.ReadFile(target)
+	if string(data) != "new" || info.Mode().Perm() != 0755 {
+		t.Errorf("target = %q, mode %v; want \"new\", 0755", data, info.Mode().Perm())
+	}
+}
@@ -7,9 +7,35 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
+
+	"github.com/agentlog/agentlog/internal/logfile"
 )
 
+// repeatWindow is how long after an error is written its identical repeats
+// (same file, type, and message) are counted instead of written. The count
+// goes out as one entry with "count", "first_seen", and "last_seen" when
+// the window closes, so a failure in a loop (serve's disk filling up, a
+// watcher that keeps erroring) can't flood the log.
+const repeatWindow = 5 * time.Second
+
+// repeats holds the open window of each error written recently
+var (
+	repeatsMu sync.Mutex
+	repeats   = make(map[string]*repeat)
+)
+
+// repeat is an error's open window and the repeats held in it
+type repeat struct {
+	path  string
+	until time.Time
+	entry map[string]interface{} // the latest repeat; nil when none is held
+	count int
+	first string
+	timer *time.Timer
+}
+
 // LogError logs an error to .agentlog/errors.jsonl with source="cli".
 // It silently no-ops if:
 // - .agentlog directory doesn't exist (no auto-creation)
@@ -19,7 +45,8 @@ func LogError(baseDir, errType, message string) {
 	LogErrorWithStack(baseDir, errType, message, "")
 }
 
-// LogErrorWithStack logs an error with a stack trace.
+// LogErrorWithStack logs an error with a stack trace. Repeats of it within
+// a few seconds are collapsed into one entry with a count.
 func LogErrorWithStack(baseDir, errType, message, stackTrace string) {
 	// No-op in production
 	if os.Getenv("PRODUCTION") != "" {
@@ -48,20 +75,89 @@ func LogErrorWithStack(baseDir, errType, message, stackTrace string) {
 		}
 	}
 
-	// Marshal to JSON
-	data, err := json.Marshal(entry)
-	if err != nil {
-		return // silently fail
+	if hold(errorsFile, errType, message, entry, time.Now()) {
+		return
+	}
+	write(errorsFile, entry)
+}
+
+// hold reports whether entry repeats an error written to path less than
+// repeatWindow ago, and if so holds it to be counted
+func hold(path, errType, message string, entry map[string]interface{}, now time.Time) bool {
+	key := path + "\x00" + errType + "\x00" + message
+	repeatsMu.Lock()
+	defer repeatsMu.Unlock()
+
+	r := repeats[key]
+	if r == nil || (r.entry == nil && !now.Before(r.until)) {
+		repeats[key] = &repeat{path: path, until: now.Add(repeatWindow)}
+		return false
+	}
+	if r.entry == nil {
+		r.first = entry["timestamp"].(string)
+		r.timer = time.AfterFunc(r.until.Sub(now), func() { flush(key) })
 	}
+	r.entry = entry
+	r.count++
+	return true
+}
 
-	// Append to file
-	f, err := os.OpenFile(errorsFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+// flush writes the repeats held for key and opens the next window
+func flush(key string) {
+	repeatsMu.Lock()
+	r := repeats[key]
+	if r == nil || r.entry == nil {
+		repeatsMu.Unlock()
+		return
+	}
+	path, entry := r.path, collapsed(r)
+	r.entry, r.count, r.timer = nil, 0, nil
+	r.until = time.Now().Add(repeatWindow)
+	repeatsMu.Unlock()
+
+	write(path, entry)
+}
+
+// Flush writes every repeat still held, for a process about to exit
+func Flush() {
+	repeatsMu.Lock()
+	var held []repeat
+	for key, r := range repeats {
+		if r.entry != nil {
+			r.timer.Stop()
+			held = append(held, repeat{path: r.path, entry: collapsed(r)})
+		}
+		delete(repeats, key)
+	}
+	repeatsMu.Unlock()
+
+	for _, r := range held {
+		write(r.path, r.entry)
+	}
+}
+
+// collapsed is the entry standing for the repeats r holds
+func collapsed(r *repeat) map[string]interface{} {
+	if r.count == 1 {
+		return r.entry
+	}
+	entry := make(map[string]interface{}, len(r.entry)+3)
+	for k, v := range r.entry {
+		entry[k] = v
+	}
+	entry["count"] = r.count
+	entry["first_seen"] = r.first
+	entry["last_seen"] = r.entry["timestamp"]
+	return entry
+}
+
+// write appends entry to path, locked against the app's writers
+func write(path string, entry map[string]interface{}) {
+	data, err := json.Marshal(entry)
 	if err != nil {
 		return // silently fail
 	}
-	defer f.Close()
-
-	f.WriteString(string(data) + "\n")
+	logfile.Append(path, append(data, '\n'))
 }
 
 // truncate truncates a string to max length with "..." suffix
@@ -209,3 +209,44 @@ func TestLogError_SilentlyFailsOnWriteError(t *testing.T) {
 	LogError(tmpDir, "TEST_ERROR", "should not fail")
 	// If we get here without panic, test passes
 }
+
+func TestLogError_CollapsesRepeats(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, ".agentlog"), 0755)
+	errorsFile := filepath.Join(tmpDir, ".agentlog", "errors.jsonl")
+	defer Flush()
+
+	for i := 0; i < 100; i++ {
+		LogError(tmpDir, "SERVE_ERROR", "disk full")
+	}
+	LogError(tmpDir, "SERVE_ERROR", "another error")
+	content, _ := os.ReadFile(errorsFile)
+	if lines := strings.Split(strings.TrimSpace(string(content)), "\n"); len(lines) != 2 {
+		t.Fatalf("expected the first of each error written and repeats held, got %d lines:\n%s", len(lines), content)
+	}
+
+	Flush()
+	content, _ = os.ReadFile(errorsFile)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Flush should write the held repeats as one entry, got %d lines:\n%s", len(lines), content)
+	}
+	var collapsed struct {
+		Message   string `json:"message"`
+		Count     int    `json:"count"`
+		FirstSeen string `json:"first_seen"`
+		LastSeen  string `json:"last_seen"`
+	}
+	if err := json.Unmarshal([]byte(lines[2]), &collapsed); err != nil {
+		t.Fatal(err)
+	}
+	if collapsed.Message != "disk full" || collapsed.Count != 99 || collapsed.FirstSeen == "" || collapsed.LastSeen == "" {
+		t.Errorf("collapsed entry = %s", lines[2])
+	}
+
+	// After Flush, the next one is written as it happens
+	LogError(tmpDir, "SERVE_ERROR", "disk full")
+	if content, _ = os.ReadFile(errorsFile); strings.Count(string(content), "\n") != 4 {
+		t.Errorf("expected the error written after Flush, got:\n%s", content)
+	}
+}